@@ -14,30 +14,43 @@
 package compiler
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/Workiva/frugal/compiler/generator"
+	"github.com/Workiva/frugal/compiler/generator/asyncapi"
 	"github.com/Workiva/frugal/compiler/generator/dartlang"
 	"github.com/Workiva/frugal/compiler/generator/golang"
 	"github.com/Workiva/frugal/compiler/generator/html"
 	"github.com/Workiva/frugal/compiler/generator/java"
+	"github.com/Workiva/frugal/compiler/generator/model"
+	"github.com/Workiva/frugal/compiler/generator/openapi"
+	"github.com/Workiva/frugal/compiler/generator/protobuf"
 	"github.com/Workiva/frugal/compiler/generator/python"
 	"github.com/Workiva/frugal/compiler/globals"
 	"github.com/Workiva/frugal/compiler/parser"
 )
 
+// Supported diagnostics formats.
+const (
+	DiagnosticsText = "text"
+	DiagnosticsJSON = "json"
+)
+
 // Options contains compiler options for code generation.
 type Options struct {
-	File    string // Frugal file to generate
-	Gen     string // Language to generate
-	Out     string // Output location for generated code
-	Delim   string // Token delimiter for scope topics
-	DryRun  bool   // Do not generate code
-	Recurse bool   // Generate includes
-	Verbose bool   // Verbose mode
+	File        string // Frugal file to generate
+	Gen         string // Language to generate
+	Out         string // Output location for generated code
+	Delim       string // Token delimiter for scope topics
+	DryRun      bool   // Do not generate code
+	Recurse     bool   // Generate includes
+	Verbose     bool   // Verbose mode
+	Diagnostics string // Format warnings are printed in (text or json)
 }
 
 // Compile parses the Frugal IDL and generates code for it, returning an error
@@ -63,9 +76,45 @@ func Compile(options Options) error {
 		return err
 	}
 
+	if warnings := collectWarnings(frugal, make(map[string]bool)); len(warnings) > 0 {
+		if err := PrintDiagnostics(os.Stdout, warnings, options.Diagnostics); err != nil {
+			return err
+		}
+	}
+
 	return generateFrugal(frugal)
 }
 
+// PrintDiagnostics writes the given Diagnostics to w in the given format. Text
+// diagnostics include the source line each one refers to while JSON
+// diagnostics are written as a single array.
+func PrintDiagnostics(w io.Writer, diags parser.Diagnostics, format string) error {
+	switch format {
+	case "", DiagnosticsText:
+		return diags.Format(w)
+	case DiagnosticsJSON:
+		return json.NewEncoder(w).Encode(diags)
+	default:
+		return fmt.Errorf("Invalid diagnostics format: %s", format)
+	}
+}
+
+// collectWarnings returns the warnings for the given Frugal and its includes,
+// skipping files which have already been visited.
+func collectWarnings(f *parser.Frugal, visited map[string]bool) parser.Diagnostics {
+	if visited[f.File] {
+		return nil
+	}
+	visited[f.File] = true
+	warnings := append(parser.Diagnostics{}, f.Warnings...)
+	for _, include := range f.OrderedIncludes() {
+		if parsed, ok := f.ParsedIncludes[include.Name]; ok {
+			warnings = append(warnings, collectWarnings(parsed, visited)...)
+		}
+	}
+	return warnings
+}
+
 // parseFrugal parses a frugal file.
 func parseFrugal(file string) (*parser.Frugal, error) {
 	if !exists(file) {
@@ -121,6 +170,10 @@ func generateFrugalRec(f *parser.Frugal, g generator.ProgramGenerator, generate
 		return nil
 	}
 
+	if err := checkStreaming(f, lang); err != nil {
+		return err
+	}
+
 	if err := g.Generate(f, fullOut); err != nil {
 		return err
 	}
@@ -163,12 +216,36 @@ func getProgramGenerator(lang string, options map[string]string) (generator.Prog
 		g = generator.NewProgramGenerator(python.NewGenerator(options), true)
 	case "html":
 		g = html.NewGenerator(options)
+	case "json":
+		g = model.NewGenerator(options)
+	case "proto":
+		g = protobuf.NewGenerator(options)
+	case "openapi":
+		g = openapi.NewGenerator(options)
+	case "asyncapi":
+		g = asyncapi.NewGenerator(options)
 	default:
 		return nil, fmt.Errorf("Invalid gen value %s", lang)
 	}
 	return g, nil
 }
 
+// checkStreaming returns an error if the frugal defines streaming service
+// methods and the language's generator doesn't support them.
+func checkStreaming(f *parser.Frugal, lang string) error {
+	switch lang {
+	case "go", "html", "json", "proto", "openapi", "asyncapi":
+		return nil
+	}
+	for _, service := range f.Services {
+		if methods := service.StreamingMethods(); len(methods) > 0 {
+			return fmt.Errorf("Streaming method %s.%s is not supported by the %s generator",
+				service.Name, methods[0].Name, lang)
+		}
+	}
+	return nil
+}
+
 // exists determines if the file at the given path exists.
 func exists(path string) bool {
 	_, err := os.Stat(path)
@@ -0,0 +1,265 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package descriptor builds descriptors of the services and scopes of parsed
+// Frugal files, which describe them well enough to call methods and publish
+// or subscribe to operations without generated code. Their JSON encoding is
+// that of frugal.FServiceDescriptor and frugal.FScopeDescriptor in the Go
+// library.
+package descriptor
+
+import (
+	"strings"
+
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+// Kinds of types.
+const (
+	KindBase      = "base"
+	KindList      = "list"
+	KindSet       = "set"
+	KindMap       = "map"
+	KindEnum      = "enum"
+	KindStruct    = "struct"
+	KindUnion     = "union"
+	KindException = "exception"
+	KindUnknown   = "unknown"
+)
+
+// Service describes a service. Types holds the definitions of the structs,
+// unions, exceptions, and enums the methods use, directly or through other
+// types, keyed by the name of the file declaring them and their name, e.g.
+// "music.Album". Typedefs are resolved.
+type Service struct {
+	Name        string             `json:"name"`
+	Doc         string             `json:"doc,omitempty"`
+	Extends     string             `json:"extends,omitempty"`
+	Methods     []*Method          `json:"methods"`
+	Types       map[string]*Define `json:"types,omitempty"`
+	Annotations map[string]string  `json:"annotations,omitempty"`
+}
+
+// Method describes a method of a service. Name is the name clients send in
+// requests. ReturnType is nil for void methods.
+type Method struct {
+	Name              string            `json:"name"`
+	Doc               string            `json:"doc,omitempty"`
+	Oneway            bool              `json:"oneway,omitempty"`
+	StreamingResponse bool              `json:"streamingResponse,omitempty"`
+	ReturnType        *Type             `json:"returnType,omitempty"`
+	Arguments         []*Field          `json:"arguments"`
+	RequestStream     *Field            `json:"requestStream,omitempty"`
+	Exceptions        []*Field          `json:"exceptions,omitempty"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
+}
+
+// Scope describes a scope. Prefix is the topic prefix template, whose
+// variables have the form {foo}. Types is keyed like it is for Service.
+type Scope struct {
+	Name        string             `json:"name"`
+	Doc         string             `json:"doc,omitempty"`
+	Prefix      string             `json:"prefix,omitempty"`
+	Variables   []string           `json:"variables,omitempty"`
+	Operations  []*Operation       `json:"operations"`
+	Types       map[string]*Define `json:"types,omitempty"`
+	Annotations map[string]string  `json:"annotations,omitempty"`
+}
+
+// Operation describes an operation of a scope.
+type Operation struct {
+	Name        string            `json:"name"`
+	Doc         string            `json:"doc,omitempty"`
+	Type        *Type             `json:"type"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Field describes a field of a struct, or an argument or exception of a
+// method.
+type Field struct {
+	ID          int               `json:"id"`
+	Name        string            `json:"name"`
+	Doc         string            `json:"doc,omitempty"`
+	Required    bool              `json:"required,omitempty"`
+	Type        *Type             `json:"type"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Type describes a type. Name is the IDL name of base types and the key of
+// the Define of other types which aren't containers.
+type Type struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name,omitempty"`
+	KeyType   *Type  `json:"keyType,omitempty"`
+	ValueType *Type  `json:"valueType,omitempty"`
+}
+
+// Define describes a struct, union, exception, or enum.
+type Define struct {
+	Kind        string            `json:"kind"`
+	Doc         string            `json:"doc,omitempty"`
+	Fields      []*Field          `json:"fields,omitempty"`
+	Values      map[string]int    `json:"values,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// NewService returns the descriptor of the service.
+func NewService(service *parser.Service) *Service {
+	types := make(map[string]*Define)
+	descriptor := &Service{
+		Name:        service.Name,
+		Doc:         doc(service.Comment),
+		Extends:     service.ExtendsService(),
+		Methods:     []*Method{},
+		Annotations: annotations(service.Annotations),
+	}
+	for _, method := range service.Methods {
+		m := &Method{
+			Name:              parser.LowercaseFirstLetter(method.Name),
+			Doc:               doc(method.Comment),
+			Oneway:            method.Oneway,
+			StreamingResponse: method.StreamingResponse,
+			Arguments:         newFields(service.Frugal, method.Arguments, types),
+			Exceptions:        newFields(service.Frugal, method.Exceptions, types),
+			Annotations:       annotations(method.Annotations),
+		}
+		if method.ReturnType != nil {
+			m.ReturnType = newType(service.Frugal, method.ReturnType, types)
+		}
+		if method.RequestStream != nil {
+			m.RequestStream = newField(service.Frugal, method.RequestStream, types)
+		}
+		descriptor.Methods = append(descriptor.Methods, m)
+	}
+	if len(types) > 0 {
+		descriptor.Types = types
+	}
+	return descriptor
+}
+
+// NewScope returns the descriptor of the scope.
+func NewScope(scope *parser.Scope) *Scope {
+	types := make(map[string]*Define)
+	descriptor := &Scope{
+		Name:        scope.Name,
+		Doc:         doc(scope.Comment),
+		Operations:  []*Operation{},
+		Annotations: annotations(scope.Annotations),
+	}
+	if scope.Prefix != nil {
+		descriptor.Prefix = scope.Prefix.String
+		descriptor.Variables = scope.Prefix.Variables
+	}
+	for _, op := range scope.Operations {
+		descriptor.Operations = append(descriptor.Operations, &Operation{
+			Name:        op.Name,
+			Doc:         doc(op.Comment),
+			Type:        newType(scope.Frugal, op.Type, types),
+			Annotations: annotations(op.Annotations),
+		})
+	}
+	if len(types) > 0 {
+		descriptor.Types = types
+	}
+	return descriptor
+}
+
+func newFields(f *parser.Frugal, fields []*parser.Field, types map[string]*Define) []*Field {
+	descriptors := []*Field{}
+	for _, field := range fields {
+		descriptors = append(descriptors, newField(f, field, types))
+	}
+	return descriptors
+}
+
+func newField(f *parser.Frugal, field *parser.Field, types map[string]*Define) *Field {
+	return &Field{
+		ID:          field.ID,
+		Name:        field.Name,
+		Doc:         doc(field.Comment),
+		Required:    field.Modifier == parser.Required,
+		Type:        newType(f, field.Type, types),
+		Annotations: annotations(field.Annotations),
+	}
+}
+
+// newType returns the descriptor of the type used in the Frugal, adding the
+// definitions of the types it refers to, keyed by the name of the file
+// declaring them and their name, to types.
+func newType(f *parser.Frugal, t *parser.Type, types map[string]*Define) *Type {
+	declaring, resolved := f.ResolveType(t)
+	if declaring == nil {
+		return &Type{Kind: KindUnknown, Name: t.Name}
+	}
+	switch {
+	case resolved.IsPrimitive():
+		return &Type{Kind: KindBase, Name: resolved.Name}
+	case resolved.Name == KindList || resolved.Name == KindSet:
+		return &Type{Kind: resolved.Name, ValueType: newType(declaring, resolved.ValueType, types)}
+	case resolved.Name == KindMap:
+		return &Type{
+			Kind:      KindMap,
+			KeyType:   newType(declaring, resolved.KeyType, types),
+			ValueType: newType(declaring, resolved.ValueType, types),
+		}
+	}
+
+	name := declaring.Name + "." + resolved.ParamName()
+	if definition, ok := types[name]; ok {
+		return &Type{Kind: definition.Kind, Name: name}
+	}
+	for _, enum := range declaring.Enums {
+		if enum.Name == resolved.ParamName() {
+			definition := &Define{
+				Kind:        KindEnum,
+				Doc:         doc(enum.Comment),
+				Values:      make(map[string]int),
+				Annotations: annotations(enum.Annotations),
+			}
+			for _, value := range enum.Values {
+				definition.Values[value.Name] = value.Value
+			}
+			types[name] = definition
+			return &Type{Kind: KindEnum, Name: name}
+		}
+	}
+	for _, s := range declaring.DataStructures() {
+		if s.Name == resolved.ParamName() {
+			definition := &Define{
+				Kind:        s.Type.String(),
+				Doc:         doc(s.Comment),
+				Annotations: annotations(s.Annotations),
+			}
+			// Register the definition before its fields for recursive types.
+			types[name] = definition
+			definition.Fields = newFields(declaring, s.Fields, types)
+			return &Type{Kind: definition.Kind, Name: name}
+		}
+	}
+	return &Type{Kind: KindUnknown, Name: name}
+}
+
+func doc(comment []string) string {
+	return strings.Join(comment, "\n")
+}
+
+func annotations(annotations parser.Annotations) map[string]string {
+	if len(annotations) == 0 {
+		return nil
+	}
+	descriptor := make(map[string]string, len(annotations))
+	for _, annotation := range annotations {
+		descriptor[annotation.Name] = annotation.Value
+	}
+	return descriptor
+}
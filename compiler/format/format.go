@@ -0,0 +1,581 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package format formats Frugal files in a canonical style.
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+const indentation = "    "
+
+// File parses the given Frugal file and returns it in canonical form.
+func File(file string) ([]byte, error) {
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	frugal, err := parser.ParseFrugalFrom(file, func(path string) ([]byte, error) {
+		if path == file {
+			return src, nil
+		}
+		return ioutil.ReadFile(path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return Source(frugal, src), nil
+}
+
+// Source returns the given Frugal, which was parsed from src, in canonical
+// form. Definitions are indented by four spaces, struct field ids are
+// aligned, annotations and constant values are written consistently, and
+// includes are sorted. Comments are kept where they appear relative to the
+// definitions around them, and single blank lines between definitions are
+// kept. Formatting is idempotent.
+func Source(frugal *parser.Frugal, src []byte) []byte {
+	p := &printer{src: src, comments: frugal.Comments, commentEnds: make(map[int]*parser.Comment)}
+	for _, c := range frugal.Comments {
+		p.commentEnds[c.Pos.Offset+c.Pos.Span] = c
+	}
+
+	decls := declarations(frugal)
+	for i := 0; i < len(decls); i++ {
+		if include, ok := decls[i].node.(*parser.Include); ok {
+			run := []*parser.Include{include}
+			for i+1 < len(decls) {
+				next, ok := decls[i+1].node.(*parser.Include)
+				if !ok {
+					break
+				}
+				run = append(run, next)
+				i++
+			}
+			p.includes(run)
+			continue
+		}
+		p.declaration(decls[i].node)
+	}
+
+	p.leading(len(src) + 1)
+	return p.buf.Bytes()
+}
+
+// declaration is a top-level definition and its offset in the source.
+type declaration struct {
+	offset int
+	node   interface{}
+}
+
+type byOffset []declaration
+
+func (b byOffset) Len() int {
+	return len(b)
+}
+
+func (b byOffset) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
+func (b byOffset) Less(i, j int) bool {
+	return b[i].offset < b[j].offset
+}
+
+// declarations returns the top-level definitions of the given Frugal in the
+// order they appear in the source.
+func declarations(f *parser.Frugal) []declaration {
+	decls := []declaration{}
+	for _, include := range f.Includes {
+		decls = append(decls, declaration{include.Pos.Offset, include})
+	}
+	for _, namespace := range f.Namespaces {
+		decls = append(decls, declaration{namespace.Pos.Offset, namespace})
+	}
+	for _, constant := range f.Constants {
+		decls = append(decls, declaration{constant.Pos.Offset, constant})
+	}
+	for _, typedef := range f.Typedefs {
+		decls = append(decls, declaration{typedef.Pos.Offset, typedef})
+	}
+	for _, enum := range f.Enums {
+		decls = append(decls, declaration{enum.Pos.Offset, enum})
+	}
+	for _, s := range f.DataStructures() {
+		decls = append(decls, declaration{s.Pos.Offset, s})
+	}
+	for _, service := range f.Services {
+		decls = append(decls, declaration{service.Pos.Offset, service})
+	}
+	for _, scope := range f.Scopes {
+		decls = append(decls, declaration{scope.Pos.Offset, scope})
+	}
+	sort.Sort(byOffset(decls))
+	return decls
+}
+
+// printer writes definitions in canonical form, interleaving the comments
+// from the source between them.
+type printer struct {
+	buf         bytes.Buffer
+	src         []byte
+	comments    []*parser.Comment
+	commentEnds map[int]*parser.Comment
+	next        int  // Index of the next comment to print
+	indent      int  // Current indentation level
+	lastEnd     int  // Source offset of the end of the last thing printed
+	blockStart  bool // Whether a block was just opened
+	forceBlank  bool // Whether the next line must be preceded by a blank line
+}
+
+func (p *printer) declaration(node interface{}) {
+	switch n := node.(type) {
+	case *parser.Namespace:
+		p.item(n.Pos, fmt.Sprintf("namespace %s %s%s", n.Scope, n.Value, annotations(n.Annotations)))
+	case *parser.Constant:
+		p.item(n.Pos, fmt.Sprintf("const %s %s = %s%s", typeName(n.Type), n.Name, value(n.Value), annotations(n.Annotations)))
+	case *parser.TypeDef:
+		p.item(n.Pos, fmt.Sprintf("typedef %s %s%s", typeName(n.Type), n.Name, annotations(n.Annotations)))
+	case *parser.Enum:
+		p.block(n.Pos, "enum "+n.Name, n.Annotations, len(n.Values), func() {
+			for _, v := range n.Values {
+				p.item(v.Pos, fmt.Sprintf("%s = %d%s,", v.Name, v.Value, annotations(v.Annotations)))
+			}
+		})
+	case *parser.Struct:
+		p.block(n.Pos, fmt.Sprintf("%s %s", n.Type, n.Name), n.Annotations, len(n.Fields), func() {
+			p.fields(n.Fields, nil, n.Type != parser.StructTypeUnion)
+		})
+	case *parser.Service:
+		header := "service " + n.Name
+		if n.Extends != "" {
+			header += " extends " + n.Extends
+		}
+		p.block(n.Pos, header, n.Annotations, len(n.Methods), func() {
+			for _, method := range n.Methods {
+				p.method(method)
+			}
+		})
+	case *parser.Scope:
+		header := "scope " + n.Name
+		if n.Prefix != nil && n.Prefix.String != "" {
+			header += " prefix " + n.Prefix.String
+		}
+		p.block(n.Pos, header, n.Annotations, len(n.Operations), func() {
+			for _, op := range n.Operations {
+				p.item(op.Pos, fmt.Sprintf("%s: %s%s", op.Name, typeName(op.Type), annotations(op.Annotations)))
+			}
+		})
+	}
+}
+
+// includes prints a run of consecutive includes sorted by path. Comments
+// between includes move with the include following them.
+func (p *printer) includes(run []*parser.Include) {
+	p.leading(run[0].Pos.Offset)
+	p.startLine(run[0].Pos.Offset)
+	sorted := make([]sortedInclude, len(run))
+	for i, include := range run {
+		sorted[i].include = include
+		sorted[i].leading = p.take(include.Pos.Offset)
+		sorted[i].trailing = p.trailing(p.end(include.Pos))
+	}
+	sort.Stable(byPath(sorted))
+	for _, s := range sorted {
+		for _, c := range s.leading {
+			p.comment(c)
+		}
+		p.line(fmt.Sprintf("include %s%s", strconv.Quote(s.include.Value), annotations(s.include.Annotations)), s.trailing)
+	}
+}
+
+// sortedInclude is an include and the comments which move with it when
+// includes are sorted.
+type sortedInclude struct {
+	include  *parser.Include
+	leading  []*parser.Comment
+	trailing []*parser.Comment
+}
+
+type byPath []sortedInclude
+
+func (b byPath) Len() int {
+	return len(b)
+}
+
+func (b byPath) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
+func (b byPath) Less(i, j int) bool {
+	return b[i].include.Value < b[j].include.Value
+}
+
+// fields prints struct fields or method arguments with their ids aligned.
+func (p *printer) fields(fields []*parser.Field, stream *parser.Field, modifiers bool) {
+	width := 0
+	if stream != nil {
+		width = len(strconv.Itoa(stream.ID))
+	}
+	for _, f := range fields {
+		if len(strconv.Itoa(f.ID)) > width {
+			width = len(strconv.Itoa(f.ID))
+		}
+	}
+	for _, f := range fields {
+		p.item(f.Pos, field(f, width, modifiers)+",")
+	}
+	if stream != nil {
+		p.item(stream.Pos, streamField(stream, width)+",")
+	}
+}
+
+// method prints a service method on a single line unless there are comments
+// within it, in which case its arguments and exceptions are printed one per
+// line so the comments can be kept beside them.
+func (p *printer) method(m *parser.Method) {
+	head := ""
+	if m.Oneway {
+		head = "oneway "
+	}
+	returnType := "void"
+	if m.ReturnType != nil {
+		returnType = typeName(m.ReturnType)
+		if m.StreamingResponse {
+			returnType = "stream<" + returnType + ">"
+		}
+	}
+	head += returnType + " " + m.Name
+	tail := annotations(m.Annotations) + ","
+
+	p.leading(m.Pos.Offset)
+	end := p.end(m.Pos)
+	if !p.commentBefore(end) {
+		args := []string{}
+		for _, arg := range m.Arguments {
+			args = append(args, field(arg, 0, true))
+		}
+		if m.RequestStream != nil {
+			args = append(args, streamField(m.RequestStream, 0))
+		}
+		text := head + "(" + strings.Join(args, ", ") + ")"
+		if len(m.Exceptions) > 0 {
+			exceptions := []string{}
+			for _, e := range m.Exceptions {
+				exceptions = append(exceptions, field(e, 0, false))
+			}
+			text += " throws (" + strings.Join(exceptions, ", ") + ")"
+		}
+		p.item(m.Pos, text+tail)
+		return
+	}
+
+	p.startLine(m.Pos.Offset)
+	argsEnd := end
+	if len(m.Exceptions) > 0 {
+		argsEnd = m.Exceptions[0].Pos.Offset
+	}
+	p.writeIndent()
+	p.buf.WriteString(head + "(")
+	p.list(argsEnd, len(m.Arguments) > 0 || m.RequestStream != nil, func() {
+		p.fields(m.Arguments, m.RequestStream, true)
+	})
+	p.buf.WriteString(")")
+	if len(m.Exceptions) > 0 {
+		p.buf.WriteString(" throws (")
+		p.list(end, true, func() {
+			p.fields(m.Exceptions, nil, false)
+		})
+		p.buf.WriteString(")")
+	}
+	p.buf.WriteString(tail)
+	p.finishLine(p.trailing(end))
+}
+
+// list prints the contents of a parenthesized list one item per line,
+// leaving the indentation ready for the closing parenthesis.
+func (p *printer) list(close int, nonEmpty bool, items func()) {
+	if !nonEmpty && !p.commentBefore(close) {
+		return
+	}
+	p.buf.WriteString("\n")
+	p.indent++
+	p.blockStart = true
+	items()
+	p.leading(close)
+	p.indent--
+	p.blockStart = false
+	p.writeIndent()
+}
+
+// block prints a definition with a body, such as a struct or service.
+func (p *printer) block(pos parser.Pos, header string, anns parser.Annotations, children int, body func()) {
+	end := p.end(pos)
+	close := end - 1
+	if len(anns) > 0 {
+		close = pos.Offset + bytes.LastIndexByte(p.src[pos.Offset:anns[0].Pos.Offset], '}')
+	}
+
+	p.forceBlank = true
+	p.leading(pos.Offset)
+	p.startLine(pos.Offset)
+	p.writeIndent()
+	p.buf.WriteString(header + " {")
+	for p.next < len(p.comments) {
+		c := p.comments[p.next]
+		if c.Pos.Line != pos.Line || c.Pos.Offset >= close {
+			break
+		}
+		p.buf.WriteString(" " + c.Text)
+		p.lastEnd = c.Pos.Offset + c.Pos.Span
+		p.next++
+	}
+	if children > 0 || p.commentBefore(close) {
+		p.buf.WriteString("\n")
+		p.indent++
+		p.blockStart = true
+		body()
+		p.leading(close)
+		p.indent--
+		p.blockStart = false
+		p.writeIndent()
+	}
+	p.buf.WriteString("}" + annotations(anns))
+	p.finishLine(p.trailing(end))
+	p.forceBlank = true
+}
+
+// item prints a definition which fits on a single line along with the
+// comments before and after it.
+func (p *printer) item(pos parser.Pos, text string) {
+	p.leading(pos.Offset)
+	p.startLine(pos.Offset)
+	p.line(text, p.trailing(p.end(pos)))
+}
+
+// line prints text at the current indentation followed by the given comments.
+func (p *printer) line(text string, trailing []*parser.Comment) {
+	p.writeIndent()
+	p.buf.WriteString(text)
+	p.finishLine(trailing)
+}
+
+func (p *printer) finishLine(trailing []*parser.Comment) {
+	for _, c := range trailing {
+		p.buf.WriteString(" " + c.Text)
+	}
+	p.buf.WriteString("\n")
+}
+
+// leading prints the comments which start before the given offset on their
+// own lines.
+func (p *printer) leading(offset int) {
+	for _, c := range p.take(offset) {
+		p.startLine(c.Pos.Offset)
+		p.comment(c)
+	}
+}
+
+// take returns the unprinted comments which start before the given offset.
+func (p *printer) take(offset int) []*parser.Comment {
+	start := p.next
+	for p.next < len(p.comments) && p.comments[p.next].Pos.Offset < offset {
+		p.next++
+	}
+	return p.comments[start:p.next]
+}
+
+// trailing returns the unprinted comments which follow the given offset on
+// the same line and records the end of the line.
+func (p *printer) trailing(end int) []*parser.Comment {
+	comments := []*parser.Comment{}
+	for p.next < len(p.comments) {
+		// Comments within a definition, such as in a constant's value, are
+		// printed after it instead.
+		c := p.comments[p.next]
+		if c.Pos.Offset < end || bytes.ContainsRune([]byte(c.Text), '\n') {
+			break
+		}
+		if len(bytes.Trim(p.src[end:c.Pos.Offset], " \t,;")) > 0 {
+			break
+		}
+		comments = append(comments, c)
+		end = c.Pos.Offset + c.Pos.Span
+		p.next++
+	}
+	p.lastEnd = end
+	return comments
+}
+
+// comment prints a comment on its own line. Lines after the first are
+// aligned on their leading asterisks if they all have one, otherwise they keep
+// their indentation relative to the first.
+func (p *printer) comment(c *parser.Comment) {
+	lines := strings.Split(c.Text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	starred := len(lines) > 1
+	for _, line := range lines[1:] {
+		if !strings.HasPrefix(strings.TrimLeft(line, " \t"), "*") {
+			starred = false
+		}
+	}
+
+	p.writeIndent()
+	p.buf.WriteString(lines[0])
+	for _, line := range lines[1:] {
+		if starred {
+			line = " " + strings.TrimLeft(line, " \t")
+		} else {
+			for i := 1; i < c.Pos.Column && len(line) > 0 && (line[0] == ' ' || line[0] == '\t'); i++ {
+				line = line[1:]
+			}
+		}
+		p.buf.WriteString("\n")
+		if line != "" {
+			p.writeIndent()
+			p.buf.WriteString(line)
+		}
+	}
+	p.buf.WriteString("\n")
+	p.lastEnd = c.Pos.Offset + c.Pos.Span
+}
+
+// startLine separates the next line from the previous with a blank line if
+// they were separated by one in the source, or if one is required.
+func (p *printer) startLine(offset int) {
+	blank := p.forceBlank
+	if p.lastEnd <= offset && bytes.Count(p.src[p.lastEnd:offset], []byte("\n")) > 1 {
+		blank = true
+	}
+	if blank && p.buf.Len() > 0 && !p.blockStart {
+		p.buf.WriteString("\n")
+	}
+	p.blockStart = false
+	p.forceBlank = false
+}
+
+func (p *printer) writeIndent() {
+	p.buf.WriteString(strings.Repeat(indentation, p.indent))
+}
+
+// commentBefore indicates if there's an unprinted comment which starts before
+// the given offset.
+func (p *printer) commentBefore(offset int) bool {
+	return p.next < len(p.comments) && p.comments[p.next].Pos.Offset < offset
+}
+
+// end returns the offset just past the last token of the definition at the
+// given position. Definitions can end with comments which the grammar skips
+// as whitespace, so those are left out.
+func (p *printer) end(pos parser.Pos) int {
+	end := pos.Offset + pos.Span
+	for {
+		end = pos.Offset + len(bytes.TrimRight(p.src[pos.Offset:end], " \t\r\n"))
+		c, ok := p.commentEnds[end]
+		if !ok || c.Pos.Offset < pos.Offset {
+			return end
+		}
+		end = c.Pos.Offset
+	}
+}
+
+func field(f *parser.Field, width int, modifiers bool) string {
+	text := fmt.Sprintf("%*d: ", width, f.ID)
+	if modifiers {
+		switch f.Modifier {
+		case parser.Required:
+			text += "required "
+		case parser.Optional:
+			text += "optional "
+		}
+	}
+	text += typeName(f.Type) + " " + f.Name
+	if f.Default != nil {
+		text += " = " + value(f.Default)
+	}
+	return text + annotations(f.Annotations)
+}
+
+func streamField(f *parser.Field, width int) string {
+	return fmt.Sprintf("%*d: stream<%s> %s%s", width, f.ID, typeName(f.Type), f.Name, annotations(f.Annotations))
+}
+
+func typeName(t *parser.Type) string {
+	name := t.Name
+	switch t.Name {
+	case "map":
+		name = fmt.Sprintf("map<%s, %s>", typeName(t.KeyType), typeName(t.ValueType))
+	case "list", "set":
+		name = fmt.Sprintf("%s<%s>", t.Name, typeName(t.ValueType))
+	}
+	return name + annotations(t.Annotations)
+}
+
+func annotations(anns parser.Annotations) string {
+	if len(anns) == 0 {
+		return ""
+	}
+	parts := make([]string, len(anns))
+	for i, ann := range anns {
+		parts[i] = ann.Name
+		if ann.Value != "" {
+			parts[i] += "=" + strconv.Quote(ann.Value)
+		}
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+func value(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		// Doubles must contain a decimal point to be parsed as one.
+		text := strconv.FormatFloat(v, 'f', -1, 64)
+		if !strings.Contains(text, ".") {
+			text += ".0"
+		}
+		return text
+	case parser.Identifier:
+		return string(v)
+	case []interface{}:
+		values := make([]string, len(v))
+		for i, elem := range v {
+			values[i] = value(elem)
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	case []parser.KeyValue:
+		pairs := make([]string, len(v))
+		for i, pair := range v {
+			pairs[i] = value(pair.Key) + ": " + value(pair.Value)
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	case nil:
+		// Empty maps are parsed as nil.
+		return "{}"
+	}
+	panic(fmt.Sprintf("format: unexpected constant value %#v", v))
+}
@@ -0,0 +1,299 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package asyncapi
+
+import (
+	"strings"
+
+	"github.com/Workiva/frugal/compiler/globals"
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+const (
+	asyncAPIVersion = "2.6.0"
+
+	// thriftContentType is the content type of messages, which are encoded
+	// with the Thrift protocol the scope's provider is configured with.
+	thriftContentType = "application/x-thrift"
+
+	// topicHeaderPrefix prefixes the names of the headers publishers set to
+	// the values of the prefix variables.
+	topicHeaderPrefix = "_topic_"
+)
+
+// Document is an AsyncAPI document describing a scope. Each operation is a
+// channel whose subscribe operation receives the messages publishers send.
+type Document struct {
+	AsyncAPI           string              `json:"asyncapi" yaml:"asyncapi"`
+	Info               *Info               `json:"info" yaml:"info"`
+	DefaultContentType string              `json:"defaultContentType" yaml:"defaultContentType"`
+	Channels           map[string]*Channel `json:"channels" yaml:"channels"`
+	Components         *Components         `json:"components,omitempty" yaml:"components,omitempty"`
+}
+
+// Info describes the scope.
+type Info struct {
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string `json:"version" yaml:"version"`
+}
+
+// Channel is the topic a scope operation is published to. Its parameters are
+// the variables of the scope's prefix.
+type Channel struct {
+	Description string                `json:"description,omitempty" yaml:"description,omitempty"`
+	Parameters  map[string]*Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Subscribe   *Operation            `json:"subscribe" yaml:"subscribe"`
+	Deprecated  bool                  `json:"x-deprecated,omitempty" yaml:"x-deprecated,omitempty"`
+}
+
+// Parameter is a variable of a scope's prefix.
+type Parameter struct {
+	Description string  `json:"description,omitempty" yaml:"description,omitempty"`
+	Schema      *Schema `json:"schema" yaml:"schema"`
+}
+
+// Operation is receiving the messages published to a channel.
+type Operation struct {
+	OperationID string   `json:"operationId" yaml:"operationId"`
+	Message     *Message `json:"message" yaml:"message"`
+}
+
+// Message is a message published to a channel. Headers has the FContext
+// headers publishers set.
+type Message struct {
+	Name       string  `json:"name" yaml:"name"`
+	Headers    *Schema `json:"headers,omitempty" yaml:"headers,omitempty"`
+	Payload    *Schema `json:"payload" yaml:"payload"`
+	Deprecated bool    `json:"x-deprecated,omitempty" yaml:"x-deprecated,omitempty"`
+}
+
+// Components has the schemas of the enums, structs, unions, and exceptions
+// the scope publishes, keyed by name. Those declared in other files are
+// qualified by their file's name.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas" yaml:"schemas"`
+}
+
+// Schema describes the structure of a type. Enums are their integer values,
+// sets are arrays of unique items, and maps with keys other than strings
+// have the schema of their keys in KeyType.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string             `json:"format,omitempty" yaml:"format,omitempty"`
+	Description          string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Enum                 []int              `json:"enum,omitempty" yaml:"enum,omitempty"`
+	EnumNames            []string           `json:"x-enum-varnames,omitempty" yaml:"x-enum-varnames,omitempty"`
+	Items                *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
+	UniqueItems          bool               `json:"uniqueItems,omitempty" yaml:"uniqueItems,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	KeyType              *Schema            `json:"x-key-type,omitempty" yaml:"x-key-type,omitempty"`
+	Required             []string           `json:"required,omitempty" yaml:"required,omitempty"`
+	MaxProperties        int                `json:"maxProperties,omitempty" yaml:"maxProperties,omitempty"`
+	Deprecated           bool               `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+// newDocument returns the AsyncAPI document for the given scope.
+func (g *Generator) newDocument(frugal *parser.Frugal, scope *parser.Scope) *Document {
+	version := defaultVersion
+	if v, ok := scope.Annotations.Get(versionAnnotation); ok {
+		version = v
+	}
+	b := &builder{frugal: frugal, schemas: make(map[string]*Schema)}
+	doc := &Document{
+		AsyncAPI: asyncAPIVersion,
+		Info: &Info{
+			Title:       scope.Name,
+			Description: description(scope.Comment, scope.Annotations),
+			Version:     version,
+		},
+		DefaultContentType: thriftContentType,
+		Channels:           make(map[string]*Channel),
+	}
+
+	_, scopeDeprecated := scope.Annotations.Deprecated()
+	for _, op := range scope.Operations {
+		annotations := operationAnnotations(op)
+		_, deprecated := annotations.Deprecated()
+		channel := &Channel{
+			Description: description(op.Comment, annotations),
+			Subscribe: &Operation{
+				OperationID: "publish" + op.Name,
+				Message: &Message{
+					Name:       op.Name,
+					Payload:    b.schema(frugal, op.Type),
+					Deprecated: scopeDeprecated || deprecated,
+				},
+			},
+			Deprecated: scopeDeprecated || deprecated,
+		}
+		if variables := scope.Prefix.Variables; len(variables) > 0 {
+			channel.Parameters = make(map[string]*Parameter, len(variables))
+			headers := &Schema{Type: "object", Properties: make(map[string]*Schema, len(variables))}
+			for _, variable := range variables {
+				channel.Parameters[variable] = &Parameter{Schema: &Schema{Type: "string"}}
+				headers.Properties[topicHeaderPrefix+variable] = &Schema{Type: "string"}
+				headers.Required = append(headers.Required, topicHeaderPrefix+variable)
+			}
+			channel.Subscribe.Message.Headers = headers
+		}
+		doc.Channels[g.channel(scope, op)] = channel
+	}
+
+	if len(b.schemas) > 0 {
+		doc.Components = &Components{Schemas: b.schemas}
+	}
+	return doc
+}
+
+// operationAnnotations returns the annotations of the given operation. The
+// annotations of an operation with a base or container type payload, such as
+// `Cancelled: i64 (deprecated)`, are parsed as annotations of the type, so
+// those are included after the operation's own.
+func operationAnnotations(op *parser.Operation) parser.Annotations {
+	if len(op.Type.Annotations) == 0 {
+		return op.Annotations
+	}
+	annotations := make(parser.Annotations, 0, len(op.Annotations)+len(op.Type.Annotations))
+	annotations = append(annotations, op.Annotations...)
+	return append(annotations, op.Type.Annotations...)
+}
+
+// channel returns the channel name of the given operation, which is the topic
+// it's published to with the prefix variables written as {name}.
+func (g *Generator) channel(scope *parser.Scope, op *parser.Operation) string {
+	channel := g.channelPrefix
+	if scope.Prefix.String != "" {
+		channel += scope.Prefix.String + globals.TopicDelimiter
+	}
+	return channel + scope.Name + globals.TopicDelimiter + op.Name
+}
+
+// builder builds the schemas of the types a scope publishes.
+type builder struct {
+	frugal  *parser.Frugal // The Frugal the document is for
+	schemas map[string]*Schema
+}
+
+// schema returns the schema of the given type used in the Frugal. Enums,
+// structs, unions, and exceptions are added to the components and
+// referenced.
+func (b *builder) schema(f *parser.Frugal, t *parser.Type) *Schema {
+	declaring, resolved := f.ResolveType(t)
+	if declaring == nil {
+		return &Schema{}
+	}
+	switch resolved.Name {
+	case "bool":
+		return &Schema{Type: "boolean"}
+	case "byte", "i8", "i16", "i32":
+		return &Schema{Type: "integer", Format: "int32"}
+	case "i64":
+		return &Schema{Type: "integer", Format: "int64"}
+	case "double":
+		return &Schema{Type: "number", Format: "double"}
+	case "string":
+		return &Schema{Type: "string"}
+	case "binary":
+		return &Schema{Type: "string", Format: "binary"}
+	case "list":
+		return &Schema{Type: "array", Items: b.schema(declaring, resolved.ValueType)}
+	case "set":
+		return &Schema{Type: "array", Items: b.schema(declaring, resolved.ValueType), UniqueItems: true}
+	case "map":
+		schema := &Schema{Type: "object", AdditionalProperties: b.schema(declaring, resolved.ValueType)}
+		if key := b.schema(declaring, resolved.KeyType); key.Type != "string" || key.Format != "" {
+			schema.KeyType = key
+		}
+		return schema
+	}
+
+	name := resolved.ParamName()
+	if declaring != b.frugal {
+		name = declaring.Name + "." + name
+	}
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+	if _, ok := b.schemas[name]; ok {
+		return ref
+	}
+	for _, enum := range declaring.Enums {
+		if enum.Name == resolved.ParamName() {
+			b.schemas[name] = enumSchema(enum)
+			return ref
+		}
+	}
+	for _, s := range declaring.DataStructures() {
+		if s.Name == resolved.ParamName() {
+			// Add the schema before its fields so recursive types terminate.
+			schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+			b.schemas[name] = schema
+			b.structSchema(declaring, s, schema)
+			return ref
+		}
+	}
+	return &Schema{}
+}
+
+func (b *builder) structSchema(declaring *parser.Frugal, s *parser.Struct, schema *Schema) {
+	schema.Description = description(s.Comment, s.Annotations)
+	_, schema.Deprecated = s.Annotations.Deprecated()
+	for _, field := range s.Fields {
+		fieldSchema := b.schema(declaring, field.Type)
+		// Siblings of references are ignored, so they aren't described.
+		if fieldSchema.Ref == "" {
+			fieldSchema.Description = description(field.Comment, field.Annotations)
+			_, fieldSchema.Deprecated = field.Annotations.Deprecated()
+		}
+		schema.Properties[field.Name] = fieldSchema
+		if field.Modifier == parser.Required {
+			schema.Required = append(schema.Required, field.Name)
+		}
+	}
+	if s.Type == parser.StructTypeUnion {
+		schema.MaxProperties = 1
+	}
+}
+
+func enumSchema(enum *parser.Enum) *Schema {
+	schema := &Schema{
+		Type:        "integer",
+		Format:      "int32",
+		Description: description(enum.Comment, enum.Annotations),
+	}
+	for _, value := range enum.Values {
+		schema.Enum = append(schema.Enum, value.Value)
+		schema.EnumNames = append(schema.EnumNames, value.Name)
+	}
+	return schema
+}
+
+// description returns the given doc comment followed by the deprecation
+// notice from the annotations, if any.
+func description(comment []string, annotations parser.Annotations) string {
+	desc := strings.Join(comment, "\n")
+	reason, ok := annotations.Deprecated()
+	if !ok {
+		return desc
+	}
+	if desc != "" {
+		desc += "\n\n"
+	}
+	desc += "Deprecated"
+	if reason != "" {
+		desc += ": " + reason
+	}
+	return desc
+}
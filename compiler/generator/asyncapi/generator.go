@@ -0,0 +1,103 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package asyncapi generates AsyncAPI documents describing Frugal scopes.
+package asyncapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/Workiva/frugal/compiler/generator"
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+const (
+	defaultOutputDir    = "gen-asyncapi"
+	channelPrefixOption = "channel_prefix"
+	yamlOption          = "yaml"
+
+	// defaultChannelPrefix is prepended to topics by the scope transports.
+	defaultChannelPrefix = "frugal."
+
+	versionAnnotation = "asyncapi.version"
+	defaultVersion    = "1.0.0"
+)
+
+// Generator implements the ProgramGenerator interface for AsyncAPI.
+type Generator struct {
+	channelPrefix string
+	yaml          bool
+}
+
+// NewGenerator creates a new AsyncAPI ProgramGenerator.
+func NewGenerator(options map[string]string) generator.ProgramGenerator {
+	channelPrefix, ok := options[channelPrefixOption]
+	if !ok {
+		channelPrefix = defaultChannelPrefix
+	}
+	_, yaml := options[yamlOption]
+	return &Generator{channelPrefix: channelPrefix, yaml: yaml}
+}
+
+// Generate writes <name>.<scope>.asyncapi.json, or .yaml with the yaml option,
+// to the output directory for each scope in the Frugal.
+func (g *Generator) Generate(frugal *parser.Frugal, outputDir string) error {
+	for _, scope := range frugal.Scopes {
+		if err := g.writeDocument(frugal, scope, outputDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *Generator) GetOutputDir(dir string, frugal *parser.Frugal) string {
+	return dir
+}
+
+func (g *Generator) DefaultOutputDir() string {
+	return defaultOutputDir
+}
+
+func (g *Generator) UseVendor() bool {
+	return false
+}
+
+func (g *Generator) writeDocument(frugal *parser.Frugal, scope *parser.Scope, outputDir string) error {
+	ext := "json"
+	if g.yaml {
+		ext = "yaml"
+	}
+	file, err := os.Create(fmt.Sprintf("%s/%s.%s.asyncapi.%s", outputDir, frugal.Name, scope.Name, ext))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	doc := g.newDocument(frugal, scope)
+	if g.yaml {
+		out, err := yaml.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		_, err = file.Write(out)
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
@@ -96,6 +96,15 @@ func (b *BaseGenerator) GetServiceMethodTypes(service *parser.Service) []*parser
 		}
 		structs = append(structs, arg)
 
+		if method.RequestStream != nil {
+			request := &parser.Struct{
+				Name:   fmt.Sprintf("%s_request", method.Name),
+				Fields: []*parser.Field{method.RequestStream},
+				Type:   parser.StructTypeStruct,
+			}
+			structs = append(structs, request)
+		}
+
 		if !method.Oneway {
 			numReturns := 0
 			if method.ReturnType != nil {
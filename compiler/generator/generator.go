@@ -54,6 +54,17 @@ var Languages = LanguageOptions{
 		"package_prefix": "Package prefix for generated files",
 		"async":          "Generate async client code using channels",
 		"use_vendor":     "Use specified import references for vendored includes and do not generate code for them",
+		"struct_helpers": "Generate Equals, DeepCopy, and field mask helpers for structs",
+		"fast_codec":     "Generate direct encoders and decoders for the binary and compact protocols",
+		"validate":       "Generate Validate methods from validate annotations and validate arguments in processors",
+		"mocks":          "Generate mocks of service, publisher, and subscriber interfaces and fake publishers",
+		"std_context":    "Take context.Context instead of FContext in clients, handlers, publishers, and subscribers",
+		"module":         "Resolve import paths from the nearest go.mod of the output directory",
+		"package_map":    "YAML file mapping namespaces to the import paths of their packages",
+		"go_mod":         "Generate a go.mod for each package which requires the packages of its includes",
+		"doc_go":         "Generate a doc.go with the package documentation for each package",
+		"multiplex":      "Generate multiplexed client constructors and processor registration for sharing a server between services",
+		"reflection":     "Embed service descriptors in processors and serve them with a reflection method",
 	},
 	"java": Options{
 		"generated_annotations": "[undated|suppress] " +
@@ -77,6 +88,24 @@ var Languages = LanguageOptions{
 	"html": Options{
 		"standalone": "Self-contained mode, includes all CSS in the HTML files. Generates no style.css file, but HTML files will be larger",
 	},
+	"json": Options{
+		"yaml": "Write the model as YAML rather than JSON",
+	},
+	"proto": Options{
+		"go_bridge":             "Generate a Go package bridging Frugal-generated Go and protobuf-generated Go",
+		"package_prefix":        "Package prefix of the Go packages generated from the .proto files and the bridge",
+		"frugal_package_prefix": "Package prefix of the Frugal-generated Go packages, as given to the go generator",
+		"strict":                "Fail on constructs protobuf can't represent instead of skipping them",
+	},
+	"openapi": Options{
+		"go_gateway":     "Generate a Go package serving the services as JSON over HTTP",
+		"package_prefix": "Package prefix of the Frugal-generated Go packages, as given to the go generator",
+		"yaml":           "Write the documents as YAML rather than JSON",
+	},
+	"asyncapi": Options{
+		"channel_prefix": "Prefix of channel names, which defaults to the frugal. prefix the scope transports add",
+		"yaml":           "Write the documents as YAML rather than JSON",
+	},
 }
 
 // ValidateOption indicates if the language option is supported for the given
@@ -0,0 +1,285 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package golang
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+func (g *Generator) generateFastCodec() bool {
+	_, ok := g.Options[fastCodecOption]
+	return ok
+}
+
+// generateFastWrite generates a method appending a struct to an FFastWriter,
+// which Write uses for the binary and compact protocols.
+func (g *Generator) generateFastWrite(s *parser.Struct, sName string) string {
+	contents := ""
+
+	contents += fmt.Sprintf("func (p *%s) FastWrite(w *frugal.FFastWriter) error {\n", sName)
+	if s.Type == parser.StructTypeUnion {
+		contents += fmt.Sprintf("\tif c := p.CountSetFields%s(); c != 1 {\n", sName)
+		contents += "\t\treturn thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf(\"%T write union: exactly one field must be set (%d set).\", p, c))\n"
+		contents += "\t}\n"
+	}
+	contents += "\tw.WriteStructBegin()\n"
+	for _, field := range s.Fields {
+		fName := title(field.Name)
+		indent := "\t"
+		if field.Modifier == parser.Optional {
+			contents += fmt.Sprintf("\tif p.IsSet%s() {\n", fName)
+			indent += "\t"
+		}
+		contents += fmt.Sprintf("%sw.WriteFieldBegin(%s, %d)\n", indent, g.getEnumFromThriftType(field.Type), field.ID)
+		value := "p." + fName
+		if g.isPointerField(field) && !g.Frugal.IsStruct(g.Frugal.UnderlyingType(field.Type)) {
+			value = "*" + value
+		}
+		contents += g.generateFastWriteRec(field.Type, value, indent)
+		if field.Modifier == parser.Optional {
+			contents += "\t}\n"
+		}
+	}
+	contents += "\tw.WriteFieldStop()\n"
+	contents += "\tw.WriteStructEnd()\n"
+	contents += "\treturn nil\n"
+	contents += "}\n\n"
+	return contents
+}
+
+// generateFastWriteRec generates statements writing the given value.
+func (g *Generator) generateFastWriteRec(t *parser.Type, value, indent string) string {
+	underlyingType := g.Frugal.UnderlyingType(t)
+	contents := ""
+
+	if g.Frugal.IsStruct(underlyingType) {
+		contents += fmt.Sprintf("%sif err := %s.FastWrite(w); err != nil {\n", indent, value)
+		contents += fmt.Sprintf("%s\treturn thrift.PrependError(fmt.Sprintf(\"%%T error writing struct: \", %s), err)\n", indent, value)
+		contents += indent + "}\n"
+		return contents
+	}
+	if g.Frugal.IsEnum(underlyingType) {
+		return fmt.Sprintf("%sw.WriteI32(int32(%s))\n", indent, value)
+	}
+
+	switch underlyingType.Name {
+	case "bool":
+		contents += fmt.Sprintf("%sw.WriteBool(bool(%s))\n", indent, value)
+	case "byte", "i8":
+		contents += fmt.Sprintf("%sw.WriteI8(int8(%s))\n", indent, value)
+	case "i16":
+		contents += fmt.Sprintf("%sw.WriteI16(int16(%s))\n", indent, value)
+	case "i32":
+		contents += fmt.Sprintf("%sw.WriteI32(int32(%s))\n", indent, value)
+	case "i64":
+		contents += fmt.Sprintf("%sw.WriteI64(int64(%s))\n", indent, value)
+	case "double":
+		contents += fmt.Sprintf("%sw.WriteDouble(float64(%s))\n", indent, value)
+	case "string":
+		contents += fmt.Sprintf("%sw.WriteString(string(%s))\n", indent, value)
+	case "binary":
+		contents += fmt.Sprintf("%sw.WriteBinary([]byte(%s))\n", indent, value)
+	case "list", "set":
+		elem := g.GetElem()
+		begin, loop := "WriteListBegin", "_, "+elem
+		if underlyingType.Name == "set" {
+			begin, loop = "WriteSetBegin", elem
+		}
+		contents += fmt.Sprintf("%sw.%s(%s, len(%s))\n", indent, begin, g.getEnumFromThriftType(underlyingType.ValueType), value)
+		contents += fmt.Sprintf("%sfor %s := range %s {\n", indent, loop, value)
+		contents += g.generateFastWriteRec(underlyingType.ValueType, elem, indent+"\t")
+		contents += indent + "}\n"
+	case "map":
+		key, val := g.GetElem(), g.GetElem()
+		contents += fmt.Sprintf("%sw.WriteMapBegin(%s, %s, len(%s))\n", indent,
+			g.getEnumFromThriftType(underlyingType.KeyType), g.getEnumFromThriftType(underlyingType.ValueType), value)
+		contents += fmt.Sprintf("%sfor %s, %s := range %s {\n", indent, key, val, value)
+		contents += g.generateFastWriteRec(underlyingType.KeyType, key, indent+"\t")
+		contents += g.generateFastWriteRec(underlyingType.ValueType, val, indent+"\t")
+		contents += indent + "}\n"
+	default:
+		panic("unknown thrift type: " + underlyingType.Name)
+	}
+	return contents
+}
+
+// generateFastRead generates a method decoding a struct from an
+// FFastReader, which Read uses for the binary and compact protocols. Fields
+// with unexpected types are skipped.
+func (g *Generator) generateFastRead(s *parser.Struct, sName string) string {
+	contents := ""
+
+	contents += fmt.Sprintf("func (p *%s) FastRead(r *frugal.FFastReader) error {\n", sName)
+	contents += "\tr.ReadStructBegin()\n"
+	for _, field := range s.Fields {
+		if field.Modifier == parser.Required {
+			contents += fmt.Sprintf("\tisset%s := false\n", title(field.Name))
+		}
+	}
+	contents += "\tfor {\n"
+	contents += "\t\tfieldTypeId, fieldId := r.ReadFieldBegin()\n"
+	contents += "\t\tif fieldTypeId == thrift.STOP {\n"
+	contents += "\t\t\tbreak\n"
+	contents += "\t\t}\n"
+	contents += "\t\tswitch {\n"
+	for _, field := range s.Fields {
+		contents += fmt.Sprintf("\t\tcase fieldId == %d && fieldTypeId == %s:\n", field.ID, g.getEnumFromThriftType(field.Type))
+		contents += g.generateFastReadRec(field.Type, "p."+title(field.Name), false, g.isPointerField(field), "\t\t\t")
+		if field.Modifier == parser.Required {
+			contents += fmt.Sprintf("\t\t\tisset%s = true\n", title(field.Name))
+		}
+	}
+	contents += "\t\tdefault:\n"
+	contents += "\t\t\tr.Skip(fieldTypeId)\n"
+	contents += "\t\t}\n"
+	contents += "\t\tif err := r.Err(); err != nil {\n"
+	contents += "\t\t\treturn thrift.PrependError(fmt.Sprintf(\"%T field %d read error: \", p, fieldId), err)\n"
+	contents += "\t\t}\n"
+	contents += "\t}\n"
+	contents += "\tr.ReadStructEnd()\n"
+	contents += "\tif err := r.Err(); err != nil {\n"
+	contents += "\t\treturn thrift.PrependError(fmt.Sprintf(\"%T read error: \", p), err)\n"
+	contents += "\t}\n"
+	for _, field := range s.Fields {
+		if field.Modifier == parser.Required {
+			fName := title(field.Name)
+			contents += fmt.Sprintf("\tif !isset%s {\n", fName)
+			errorMessage := fmt.Sprintf("Required field '%s' is not present in struct '%s'", fName, s.Name)
+			contents += fmt.Sprintf("\t\treturn thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf(\"%s\"))\n", errorMessage)
+			contents += "\t}\n"
+		}
+	}
+	if s.Type == parser.StructTypeUnion {
+		contents += fmt.Sprintf("\tif c := p.CountSetFields%s(); c != 1 {\n", sName)
+		contents += "\t\treturn thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf(\"%T read union: exactly one field must be set (%d set).\", p, c))\n"
+		contents += "\t}\n"
+	}
+	contents += "\treturn nil\n"
+	contents += "}\n\n"
+	return contents
+}
+
+// generateFastReadRec generates statements reading a value of the given type
+// into target, which is declared if declare is true and assigned the
+// address of the value if pointer is true.
+func (g *Generator) generateFastReadRec(t *parser.Type, target string, declare, pointer bool, indent string) string {
+	underlyingType := g.Frugal.UnderlyingType(t)
+	goOrigType := g.getGoTypeFromThriftTypePtr(t, false)
+	goUnderlyingType := g.getGoTypeFromThriftTypePtr(underlyingType, false)
+	eq := "="
+	if declare {
+		eq = ":="
+	}
+	contents := ""
+
+	if g.Frugal.IsStruct(underlyingType) {
+		// ie *base.APIException -> base.NewAPIException()
+		lastInd := strings.LastIndex(goUnderlyingType, ".")
+		if lastInd == -1 {
+			lastInd = 0
+		}
+		initializer := fmt.Sprintf("%sNew%s()", goUnderlyingType[1:lastInd+1], goUnderlyingType[lastInd+1:])
+		contents += fmt.Sprintf("%s%s %s %s\n", indent, target, eq, initializer)
+		contents += fmt.Sprintf("%sif err := %s.FastRead(r); err != nil {\n", indent, target)
+		contents += fmt.Sprintf("%s\treturn thrift.PrependError(fmt.Sprintf(\"%%T error reading struct: \", %s), err)\n", indent, target)
+		contents += indent + "}\n"
+		return contents
+	}
+
+	if underlyingType.IsContainer() {
+		// Build the container in a local, which is the target if declared
+		local := target
+		if !declare {
+			local = g.GetElem()
+		}
+		switch underlyingType.Name {
+		case "list":
+			contents += indent + "_, size := r.ReadListBegin()\n"
+			contents += fmt.Sprintf("%s%s := make(%s, 0, size)\n", indent, local, goOrigType)
+			contents += indent + "for i := 0; i < size; i++ {\n"
+			elem := g.GetElem()
+			contents += g.generateFastReadRec(underlyingType.ValueType, elem, true, false, indent+"\t")
+			contents += fmt.Sprintf("%s\t%s = append(%s, %s)\n", indent, local, local, elem)
+			contents += indent + "}\n"
+		case "set":
+			contents += indent + "_, size := r.ReadSetBegin()\n"
+			contents += fmt.Sprintf("%s%s := make(%s, size)\n", indent, local, goOrigType)
+			contents += indent + "for i := 0; i < size; i++ {\n"
+			elem := g.GetElem()
+			contents += g.generateFastReadRec(underlyingType.ValueType, elem, true, false, indent+"\t")
+			contents += fmt.Sprintf("%s\t%s[%s] = true\n", indent, local, elem)
+			contents += indent + "}\n"
+		case "map":
+			contents += indent + "_, _, size := r.ReadMapBegin()\n"
+			contents += fmt.Sprintf("%s%s := make(%s, size)\n", indent, local, goOrigType)
+			contents += indent + "for i := 0; i < size; i++ {\n"
+			key, val := g.GetElem(), g.GetElem()
+			contents += g.generateFastReadRec(underlyingType.KeyType, key, true, false, indent+"\t")
+			contents += g.generateFastReadRec(underlyingType.ValueType, val, true, false, indent+"\t")
+			contents += fmt.Sprintf("%s\t%s[%s] = %s\n", indent, local, key, val)
+			contents += indent + "}\n"
+		default:
+			panic("unrecognized thrift type: " + underlyingType.Name)
+		}
+		if !declare {
+			maybeAddress := ""
+			if pointer {
+				maybeAddress = "&"
+			}
+			contents += fmt.Sprintf("%s%s = %s%s\n", indent, target, maybeAddress, local)
+		}
+		return contents
+	}
+
+	isEnum := g.Frugal.IsEnum(underlyingType)
+	read := ""
+	switch underlyingType.Name {
+	case "bool":
+		read = "r.ReadBool()"
+	case "byte", "i8":
+		read = "r.ReadI8()"
+	case "i16":
+		read = "r.ReadI16()"
+	case "i32":
+		read = "r.ReadI32()"
+	case "i64":
+		read = "r.ReadI64()"
+	case "double":
+		read = "r.ReadDouble()"
+	case "string":
+		read = "r.ReadString()"
+	case "binary":
+		read = "r.ReadBinary()"
+	default:
+		if !isEnum {
+			panic("unknown thrift type: " + underlyingType.Name)
+		}
+		read = "r.ReadI32()"
+	}
+	// enums and typedefs need to be cast
+	if isEnum || goOrigType != goUnderlyingType {
+		read = fmt.Sprintf("%s(%s)", goOrigType, read)
+	}
+	if pointer {
+		temp := g.GetElem()
+		contents += fmt.Sprintf("%s%s := %s\n", indent, temp, read)
+		contents += fmt.Sprintf("%s%s %s &%s\n", indent, target, eq, temp)
+		return contents
+	}
+	contents += fmt.Sprintf("%s%s %s %s\n", indent, target, eq, read)
+	return contents
+}
@@ -34,11 +34,23 @@ const (
 	defaultOutputDir    = "gen-go"
 	serviceSuffix       = "_service"
 	scopeSuffix         = "_scope"
+	mockSuffix          = "_mock"
 	packagePrefixOption = "package_prefix"
 	thriftImportOption  = "thrift_import"
 	frugalImportOption  = "frugal_import"
 	asyncOption         = "async"
 	useVendorOption     = "use_vendor"
+	structHelpersOption = "struct_helpers"
+	fastCodecOption     = "fast_codec"
+	validateOption      = "validate"
+	mocksOption         = "mocks"
+	stdContextOption    = "std_context"
+	moduleOption        = "module"
+	packageMapOption    = "package_map"
+	goModOption         = "go_mod"
+	docGoOption         = "doc_go"
+	multiplexOption     = "multiplex"
+	reflectionOption    = "reflection"
 )
 
 // Generator implements the LanguageGenerator interface for Go.
@@ -46,15 +58,30 @@ type Generator struct {
 	*generator.BaseGenerator
 	generateConstants bool
 	typesFile         *os.File
+	localPackage      string           // Qualifies types declared in the Frugal, if set
+	packages          *packageResolver // Resolves generated packages, see resolver
 }
 
 // NewGenerator creates a new Go LanguageGenerator.
 func NewGenerator(options map[string]string) generator.LanguageGenerator {
-	return &Generator{&generator.BaseGenerator{Options: options}, true, nil}
+	return &Generator{&generator.BaseGenerator{Options: options}, true, nil, "", nil}
 }
 
 // SetupGenerator initializes globals the generator needs, like the types file.
 func (g *Generator) SetupGenerator(outputDir string) error {
+	resolver, err := g.resolver()
+	if err != nil {
+		return err
+	}
+	if err := resolver.checkImportCycles(g.Frugal, g.UseVendor()); err != nil {
+		return err
+	}
+	if g.generateReflection() {
+		if err := g.checkReflectionMethods(); err != nil {
+			return err
+		}
+	}
+
 	g.generateConstants = true
 	t, err := g.GenerateFile("", outputDir, generator.TypeFile)
 	if err != nil {
@@ -91,6 +118,10 @@ func (g *Generator) TeardownGenerator() error {
 
 // GetOutputDir returns the output directory for generated files.
 func (g *Generator) GetOutputDir(dir string) string {
+	if resolver, err := g.resolver(); err == nil {
+		// Otherwise the error is returned by SetupGenerator
+		return resolver.packageDir(dir, g.Frugal)
+	}
 	if namespace := g.Frugal.Namespace(lang); namespace != nil {
 		path := generator.GetPackageComponents(namespace.Value)
 		dir = filepath.Join(append([]string{dir}, path...)...)
@@ -118,8 +149,23 @@ func (g *Generator) PostProcess(f *os.File) error {
 	return ioutil.WriteFile(f.Name(), contents, 0)
 }
 
-// GenerateDependencies is a no-op.
+// GenerateDependencies generates a go.mod and doc.go for the package if
+// requested.
 func (g *Generator) GenerateDependencies(dir string) error {
+	resolver, err := g.resolver()
+	if err != nil {
+		return err
+	}
+	if _, ok := g.Options[goModOption]; ok {
+		if err := g.generateGoMod(resolver, dir); err != nil {
+			return err
+		}
+	}
+	if _, ok := g.Options[docGoOption]; ok {
+		if err := g.generateDocGo(dir); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -161,16 +207,18 @@ func (g *Generator) GenerateScopePackage(file *os.File, s *parser.Scope) error {
 }
 
 func (g *Generator) generatePackage(file *os.File) error {
-	pkg := ""
+	_, err := file.WriteString(fmt.Sprintf("package %s", g.packageName()))
+	return err
+}
+
+// packageName returns the name of the package generated for the Frugal.
+func (g *Generator) packageName() string {
 	namespace := g.Frugal.Namespace(lang)
 	if namespace != nil {
 		components := generator.GetPackageComponents(namespace.Value)
-		pkg = components[len(components)-1]
-	} else {
-		pkg = g.Frugal.Name
+		return components[len(components)-1]
 	}
-	_, err := file.WriteString(fmt.Sprintf("package %s", pkg))
-	return err
+	return g.Frugal.Name
 }
 
 // GenerateConstantsContents generates constants.
@@ -437,6 +485,23 @@ func (g *Generator) generateStruct(s *parser.Struct, serviceName string) string
 	contents += g.generateWrite(s, sName)
 	contents += g.generateToString(s, sName)
 
+	if g.generateFastCodec() {
+		contents += g.generateFastWrite(s, sName)
+		contents += g.generateFastRead(s, sName)
+	}
+
+	// Only args are validated by processors
+	if g.generateValidate() && (serviceName == "" || strings.HasSuffix(s.Name, "_args")) {
+		contents += g.generateValidateMethod(s, sName)
+	}
+
+	// Args and results are internal, so they don't need helpers
+	if serviceName == "" && g.generateStructHelpers() {
+		contents += g.generateEquals(s, sName)
+		contents += g.generateDeepCopy(s, sName)
+		contents += g.generateFieldMask(s, sName)
+	}
+
 	return contents
 }
 
@@ -590,6 +655,11 @@ func (g *Generator) generateRead(s *parser.Struct, sName string) string {
 	contents := ""
 
 	contents += fmt.Sprintf("func (p *%s) Read(iprot thrift.TProtocol) error {\n", sName)
+	if g.generateFastCodec() {
+		contents += "\tif ok, err := frugal.FastRead(iprot, p); ok {\n"
+		contents += "\t\treturn err\n"
+		contents += "\t}\n"
+	}
 	contents += "\tif _, err := iprot.ReadStructBegin(); err != nil {\n"
 	contents += "\t\treturn thrift.PrependError(fmt.Sprintf(\"%T read error: \", p), err)\n"
 	contents += "\t}\n\n"
@@ -664,6 +734,11 @@ func (g *Generator) generateWrite(s *parser.Struct, sName string) string {
 	contents := ""
 
 	contents += fmt.Sprintf("func (p *%s) Write(oprot thrift.TProtocol) error {\n", sName)
+	if g.generateFastCodec() {
+		contents += "\tif ok, err := frugal.FastWrite(oprot, p); ok {\n"
+		contents += "\t\treturn err\n"
+		contents += "\t}\n"
+	}
 
 	// Only one field can be set for a union, make sure that's the case
 	if s.Type == parser.StructTypeUnion {
@@ -1025,16 +1100,27 @@ func (g *Generator) GenerateTypesImports(file *os.File) error {
 		contents += "\t\"database/sql/driver\"\n"
 		contents += "\t\"errors\"\n"
 	}
+	if g.generateValidate() {
+		contents += "\t\"regexp\"\n"
+		contents += "\t\"unicode/utf8\"\n"
+	}
 	if g.Options[thriftImportOption] != "" {
 		contents += "\t\"" + g.Options[thriftImportOption] + "\"\n"
 	} else {
 		contents += "\t\"git.apache.org/thrift.git/lib/go/thrift\"\n"
 	}
+	// The fast codec and validation errors are in the Frugal library
+	if g.generateFastCodec() || g.generateValidate() {
+		if g.Options[frugalImportOption] != "" {
+			contents += "\t\"" + g.Options[frugalImportOption] + "\"\n"
+		} else {
+			contents += "\t\"github.com/Workiva/frugal/lib/go\"\n"
+		}
+	}
 
 	protections := ""
-	pkgPrefix := g.Options[packagePrefixOption]
 	for _, include := range g.Frugal.Includes {
-		if imp, err := g.generateIncludeImport(include, pkgPrefix); err != nil {
+		if imp, err := g.generateIncludeImport(include); err != nil {
 			return err
 		} else {
 			contents += imp
@@ -1046,7 +1132,11 @@ func (g *Generator) GenerateTypesImports(file *os.File) error {
 	contents += "// (needed to ensure safety because of naive import list construction.)\n"
 	contents += "var _ = thrift.ZERO\n"
 	contents += "var _ = fmt.Printf\n"
-	contents += "var _ = bytes.Equal\n\n"
+	contents += "var _ = bytes.Equal\n"
+	if g.generateFastCodec() {
+		contents += "var _ = frugal.FastWrite\n"
+	}
+	contents += "\n"
 	contents += protections
 	contents += "var GoUnusedProtection__ int\n"
 	_, err := file.WriteString(contents)
@@ -1067,9 +1157,8 @@ func (g *Generator) GenerateServiceResultArgsImports(file *os.File) error {
 	}
 
 	protections := ""
-	pkgPrefix := g.Options[packagePrefixOption]
 	for _, include := range g.Frugal.Includes {
-		if imp, err := g.generateIncludeImport(include, pkgPrefix); err != nil {
+		if imp, err := g.generateIncludeImport(include); err != nil {
 			return err
 		} else {
 			contents += imp
@@ -1092,8 +1181,21 @@ func (g *Generator) GenerateServiceResultArgsImports(file *os.File) error {
 func (g *Generator) GenerateServiceImports(file *os.File, s *parser.Service) error {
 	imports := "import (\n"
 	imports += "\t\"bytes\"\n"
+	if g.generateStdContext() {
+		imports += "\t\"context\"\n"
+	}
 	imports += "\t\"fmt\"\n"
+	if len(s.StreamingMethods()) > 0 {
+		// Only streaming methods require the io package.
+		imports += "\t\"io\"\n"
+	}
+	if g.generateValidate() {
+		imports += "\t\"regexp\"\n"
+	}
 	imports += "\t\"sync\"\n"
+	if g.generateValidate() {
+		imports += "\t\"unicode/utf8\"\n"
+	}
 	if len(s.TwowayMethods()) > 0 {
 		// Only non-oneway methods require the time package.
 		imports += "\t\"time\"\n\n"
@@ -1110,13 +1212,12 @@ func (g *Generator) GenerateServiceImports(file *os.File, s *parser.Service) err
 	}
 	imports += "\t\"github.com/Sirupsen/logrus\"\n"
 
-	pkgPrefix := g.Options[packagePrefixOption]
 	includes, err := s.ReferencedIncludes()
 	if err != nil {
 		return err
 	}
 	for _, include := range includes {
-		if imp, err := g.generateIncludeImport(include, pkgPrefix); err != nil {
+		if imp, err := g.generateIncludeImport(include); err != nil {
 			return err
 		} else {
 			imports += imp
@@ -1138,8 +1239,15 @@ func (g *Generator) GenerateServiceImports(file *os.File, s *parser.Service) err
 // GenerateScopeImports generates necessary imports for the given scope.
 func (g *Generator) GenerateScopeImports(file *os.File, s *parser.Scope) error {
 	imports := "import (\n"
+	if g.generateStdContext() {
+		imports += "\t\"context\"\n"
+	}
 	imports += "\t\"fmt\"\n"
-	imports += "\t\"log\"\n\n"
+	imports += "\t\"log\"\n"
+	if g.generateMocks() {
+		imports += "\t\"sync\"\n"
+	}
+	imports += "\n"
 	if g.Options[thriftImportOption] != "" {
 		imports += "\t\"" + g.Options[thriftImportOption] + "\"\n"
 	} else {
@@ -1151,13 +1259,12 @@ func (g *Generator) GenerateScopeImports(file *os.File, s *parser.Scope) error {
 		imports += "\t\"github.com/Workiva/frugal/lib/go\"\n"
 	}
 
-	pkgPrefix := g.Options[packagePrefixOption]
 	scopeIncludes, err := g.Frugal.ReferencedScopeIncludes()
 	if err != nil {
 		return err
 	}
 	for _, include := range scopeIncludes {
-		if imp, err := g.generateIncludeImport(include, pkgPrefix); err != nil {
+		if imp, err := g.generateIncludeImport(include); err != nil {
 			return err
 		} else {
 			imports += imp
@@ -1170,9 +1277,14 @@ func (g *Generator) GenerateScopeImports(file *os.File, s *parser.Scope) error {
 	return err
 }
 
-func (g *Generator) generateIncludeImport(include *parser.Include, pkgPrefix string) (string, error) {
+func (g *Generator) generateIncludeImport(include *parser.Include) (string, error) {
+	resolver, err := g.resolver()
+	if err != nil {
+		return "", err
+	}
 	includeName := filepath.Base(include.Name)
-	importPath := fmt.Sprintf("%s%s", pkgPrefix, includeNameToImport(includeName))
+	included := g.Frugal.ParsedIncludes[includeName]
+	importPath := resolver.importPath(included)
 	namespace := g.Frugal.NamespaceForInclude(includeName, lang)
 
 	_, vendored := include.Annotations.Vendor()
@@ -1180,7 +1292,6 @@ func (g *Generator) generateIncludeImport(include *parser.Include, pkgPrefix str
 	vendorPath := ""
 
 	if namespace != nil {
-		importPath = fmt.Sprintf("%s%s", pkgPrefix, includeNameToImport(namespace.Value))
 		if nsVendorPath, ok := namespace.Annotations.Vendor(); ok {
 			vendorPath = nsVendorPath
 		}
@@ -1194,6 +1305,10 @@ func (g *Generator) generateIncludeImport(include *parser.Include, pkgPrefix str
 				include.Name)
 		}
 		importPath = vendorPath
+	} else if resolver.isMapped(included) {
+		// The package name may differ from the last element of the mapped
+		// import path, so name the import the way the include is referenced.
+		return fmt.Sprintf("\t%s \"%s\"\n", includeNameToReference(packageKey(included)), importPath), nil
 	}
 
 	return fmt.Sprintf("\t\"%s\"\n", importPath), nil
@@ -1248,7 +1363,7 @@ func (g *Generator) GeneratePublisher(file *os.File, scope *parser.Scope) error
 	publisher += "\tOpen() error\n"
 	publisher += "\tClose() error\n"
 	for _, op := range scope.Operations {
-		publisher += fmt.Sprintf("\tPublish%s(ctx frugal.FContext, %sreq %s) error\n", op.Name, args, g.getGoTypeFromThriftType(op.Type))
+		publisher += fmt.Sprintf("\tPublish%s(ctx %s, %sreq %s) error\n", op.Name, g.contextType(), args, g.getGoTypeFromThriftType(op.Type))
 	}
 	publisher += "}\n\n"
 
@@ -1305,8 +1420,8 @@ func (g *Generator) generatePublishMethod(scope *parser.Scope, op *parser.Operat
 		publisher += g.GenerateInlineComment(op.Comment, "")
 	}
 
-	publisher += fmt.Sprintf("func (p *%sPublisher) Publish%s(ctx frugal.FContext, %sreq %s) error {\n",
-		scopeLower, op.Name, args, g.getGoTypeFromThriftType(op.Type))
+	publisher += fmt.Sprintf("func (p *%sPublisher) Publish%s(ctx %s, %sreq %s) error {\n",
+		scopeLower, op.Name, g.contextType(), args, g.getGoTypeFromThriftType(op.Type))
 	publisher += fmt.Sprintf("\tret := p.methods[\"publish%s\"].Invoke(%s)\n", op.Name, g.generateScopeArgs(scope))
 	publisher += "\tif ret[0] != nil {\n"
 	publisher += "\t\treturn ret[0].(error)\n"
@@ -1402,8 +1517,8 @@ func (g *Generator) GenerateSubscriber(file *os.File, scope *parser.Scope) error
 
 	subscriber += fmt.Sprintf("type %sSubscriber interface {\n", scopeCamel)
 	for _, op := range scope.Operations {
-		subscriber += fmt.Sprintf("\tSubscribe%s(%shandler func(frugal.FContext, %s)) (*frugal.FSubscription, error)\n",
-			op.Name, args, g.getGoTypeFromThriftType(op.Type))
+		subscriber += fmt.Sprintf("\tSubscribe%s(%shandler func(%s, %s)) (*frugal.FSubscription, error)\n",
+			op.Name, args, g.contextType(), g.getGoTypeFromThriftType(op.Type))
 	}
 	subscriber += "}\n\n"
 
@@ -1412,8 +1527,8 @@ func (g *Generator) GenerateSubscriber(file *os.File, scope *parser.Scope) error
 	}
 	subscriber += fmt.Sprintf("type %sErrorableSubscriber interface {\n", scopeCamel)
 	for _, op := range scope.Operations {
-		subscriber += fmt.Sprintf("\tSubscribe%sErrorable(%shandler func(frugal.FContext, %s) error) (*frugal.FSubscription, error)\n",
-			op.Name, args, g.getGoTypeFromThriftType(op.Type))
+		subscriber += fmt.Sprintf("\tSubscribe%sErrorable(%shandler func(%s, %s) error) (*frugal.FSubscription, error)\n",
+			op.Name, args, g.contextType(), g.getGoTypeFromThriftType(op.Type))
 	}
 	subscriber += "}\n\n"
 
@@ -1441,8 +1556,14 @@ func (g *Generator) GenerateSubscriber(file *os.File, scope *parser.Scope) error
 		subscriber += g.generateSubscribeMethod(scope, op, args, argsWithoutTypes)
 	}
 
-	_, err := file.WriteString(subscriber)
-	return err
+	if _, err := file.WriteString(subscriber); err != nil {
+		return err
+	}
+
+	if g.generateMocks() {
+		return g.generateScopeMockFile(file, scope)
+	}
+	return nil
 }
 
 func (g *Generator) generateSubscribeMethod(scope *parser.Scope, op *parser.Operation, args, argsWithoutTypes string) string {
@@ -1455,10 +1576,10 @@ func (g *Generator) generateSubscribeMethod(scope *parser.Scope, op *parser.Oper
 		subscriber += g.GenerateInlineComment(op.Comment, "")
 	}
 
-	subscriber += fmt.Sprintf("func (l *%sSubscriber) Subscribe%s(%shandler func(frugal.FContext, %s)) (*frugal.FSubscription, error) {\n",
-		scopeLower, op.Name, args, g.getGoTypeFromThriftType(op.Type))
-	subscriber += fmt.Sprintf("\treturn l.Subscribe%sErrorable(%sfunc(fctx frugal.FContext, arg %s) error {\n",
-		op.Name, argsWithoutTypes, g.getGoTypeFromThriftType(op.Type))
+	subscriber += fmt.Sprintf("func (l *%sSubscriber) Subscribe%s(%shandler func(%s, %s)) (*frugal.FSubscription, error) {\n",
+		scopeLower, op.Name, args, g.contextType(), g.getGoTypeFromThriftType(op.Type))
+	subscriber += fmt.Sprintf("\treturn l.Subscribe%sErrorable(%sfunc(fctx %s, arg %s) error {\n",
+		op.Name, argsWithoutTypes, g.contextType(), g.getGoTypeFromThriftType(op.Type))
 	subscriber += "\t\thandler(fctx, arg)\n"
 	subscriber += "\t\treturn nil\n"
 	subscriber += "\t})\n"
@@ -1467,8 +1588,8 @@ func (g *Generator) generateSubscribeMethod(scope *parser.Scope, op *parser.Oper
 	if op.Comment != nil {
 		subscriber += g.GenerateInlineComment(op.Comment, "")
 	}
-	subscriber += fmt.Sprintf("func (l *%sSubscriber) Subscribe%sErrorable(%shandler func(frugal.FContext, %s) error) (*frugal.FSubscription, error) {\n",
-		scopeLower, op.Name, args, g.getGoTypeFromThriftType(op.Type))
+	subscriber += fmt.Sprintf("func (l *%sSubscriber) Subscribe%sErrorable(%shandler func(%s, %s) error) (*frugal.FSubscription, error) {\n",
+		scopeLower, op.Name, args, g.contextType(), g.getGoTypeFromThriftType(op.Type))
 	subscriber += fmt.Sprintf("\top := \"%s\"\n", op.Name)
 	subscriber += fmt.Sprintf("\tprefix := %s\n", generatePrefixStringTemplate(scope))
 	subscriber += "\ttopic := fmt.Sprintf(\"%s" + scopeTitle + "%s%s\", prefix, delimiter, op)\n"
@@ -1482,8 +1603,8 @@ func (g *Generator) generateSubscribeMethod(scope *parser.Scope, op *parser.Oper
 	subscriber += "\treturn sub, nil\n"
 	subscriber += "}\n\n"
 
-	subscriber += fmt.Sprintf("func (l *%sSubscriber) recv%s(op string, pf *frugal.FProtocolFactory, handler func(frugal.FContext, %s) error) frugal.FAsyncCallback {\n",
-		scopeLower, op.Name, g.getGoTypeFromThriftType(op.Type))
+	subscriber += fmt.Sprintf("func (l *%sSubscriber) recv%s(op string, pf *frugal.FProtocolFactory, handler func(%s, %s) error) frugal.FAsyncCallback {\n",
+		scopeLower, op.Name, g.contextType(), g.getGoTypeFromThriftType(op.Type))
 	subscriber += fmt.Sprintf("\tmethod := frugal.NewMethod(l, handler, \"Subscribe%s\", l.middleware)\n", op.Name)
 	subscriber += "\treturn func(transport thrift.TTransport) error {\n"
 	subscriber += "\t\tiprot := pf.GetProtocol(transport)\n"
@@ -1502,7 +1623,7 @@ func (g *Generator) generateSubscribeMethod(scope *parser.Scope, op *parser.Oper
 	subscriber += "\t\t}\n"
 	subscriber += g.generateReadFieldRec(parser.FieldFromType(op.Type, "req"), false)
 	subscriber += "\t\tiprot.ReadMessageEnd()\n\n"
-	subscriber += "\t\treturn method.Invoke([]interface{}{ctx, req}).Error()\n"
+	subscriber += fmt.Sprintf("\t\treturn method.Invoke([]interface{}{%s, req}).Error()\n", g.incomingContext())
 	subscriber += "\t}\n"
 	subscriber += "}"
 
@@ -1513,12 +1634,20 @@ func (g *Generator) generateSubscribeMethod(scope *parser.Scope, op *parser.Oper
 func (g *Generator) GenerateService(file *os.File, s *parser.Service) error {
 	contents := ""
 	contents += g.generateServiceInterface(s)
+	for _, method := range s.StreamingMethods() {
+		contents += g.generateStreamInterfaces(s, method)
+	}
 	contents += g.generateClient(s)
 	contents += g.generateServer(s)
 	contents += g.generateServiceArgsResults(s)
+	if _, err := file.WriteString(contents); err != nil {
+		return err
+	}
 
-	_, err := file.WriteString(contents)
-	return err
+	if g.generateMocks() {
+		return g.generateServiceMockFile(file, s)
+	}
+	return nil
 }
 
 func (g *Generator) generateServiceInterface(service *parser.Service) string {
@@ -1533,9 +1662,15 @@ func (g *Generator) generateServiceInterface(service *parser.Service) string {
 	}
 	for _, method := range service.Methods {
 		contents += g.generateCommentWithDeprecated(method.Comment, "\t", method.Annotations)
-		contents += fmt.Sprintf("\t%s(ctx frugal.FContext%s) %s\n",
-			snakeToCamel(method.Name), g.generateInterfaceArgs(method.Arguments),
-			g.generateReturnArgs(method))
+		if method.IsStreaming() {
+			contents += fmt.Sprintf("\t%s(ctx %s%s%s) %s\n",
+				snakeToCamel(method.Name), g.contextType(), g.generateInterfaceArgs(method.Arguments),
+				g.generateStreamHandlerArgs(service, method), g.generateStreamHandlerReturnArgs(method))
+			continue
+		}
+		contents += fmt.Sprintf("\t%s(ctx %s%s) %s\n",
+			snakeToCamel(method.Name), g.contextType(), g.generateInterfaceArgs(method.Arguments),
+			g.generateReturnArgs(service, method))
 	}
 	contents += "}\n\n"
 	return contents
@@ -1568,13 +1703,132 @@ func (g *Generator) getServiceExtendsNamespace(service *parser.Service) string {
 	return namespace
 }
 
-func (g *Generator) generateReturnArgs(method *parser.Method) string {
-	if method.ReturnType == nil {
+// generateStreamHandlerArgs generates the stream arguments passed to the
+// handler of a streaming method after its declared arguments.
+func (g *Generator) generateStreamHandlerArgs(service *parser.Service, method *parser.Method) string {
+	args := ""
+	if method.RequestStream != nil {
+		args += fmt.Sprintf(", %s %s", method.RequestStream.Name, g.streamInterfaceName(service, method, "Receiver"))
+	}
+	if method.StreamingResponse {
+		args += fmt.Sprintf(", sender %s", g.streamInterfaceName(service, method, "Sender"))
+	}
+	return args
+}
+
+// generateStreamHandlerReturnArgs generates the values returned by the
+// handler of a streaming method. Handlers of methods which only stream
+// requests return a single value like any other method.
+func (g *Generator) generateStreamHandlerReturnArgs(method *parser.Method) string {
+	if method.StreamingResponse || method.ReturnType == nil {
 		return "(err error)"
 	}
 	return fmt.Sprintf("(r %s, err error)", g.getGoTypeFromThriftType(method.ReturnType))
 }
 
+func (g *Generator) generateReturnArgs(service *parser.Service, method *parser.Method) string {
+	if !g.returnsValue(method) {
+		return "(err error)"
+	}
+	return fmt.Sprintf("(r %s, err error)", g.getClientReturnType(service, method))
+}
+
+// returnsValue returns true if the client of the given method returns a value,
+// which is a stream interface for streaming methods.
+func (g *Generator) returnsValue(method *parser.Method) bool {
+	return method.ReturnType != nil || method.IsStreaming()
+}
+
+// getClientReturnType returns the type returned by the client for the given
+// method, which is a stream interface for streaming methods.
+func (g *Generator) getClientReturnType(service *parser.Service, method *parser.Method) string {
+	if method.IsStreaming() {
+		return g.streamInterfaceName(service, method, "Stream")
+	}
+	return g.getGoTypeFromThriftType(method.ReturnType)
+}
+
+// streamInterfaceName returns the name of the given kind of stream interface,
+// "Sender", "Receiver" or "Stream", generated for a streaming method.
+func (g *Generator) streamInterfaceName(service *parser.Service, method *parser.Method, kind string) string {
+	return fmt.Sprintf("F%s%s%s", snakeToCamel(service.Name), snakeToCamel(method.Name), kind)
+}
+
+// streamImplName returns the name of the unexported implementation of the
+// given kind of stream interface generated for a streaming method.
+func (g *Generator) streamImplName(service *parser.Service, method *parser.Method, kind string) string {
+	return fmt.Sprintf("%s%s%s", strings.ToLower(service.Name), snakeToCamel(method.Name), kind)
+}
+
+// generateStreamInterfaces generates the interfaces used by handlers to send
+// and receive the values of a streaming method and by clients to send and
+// receive them.
+func (g *Generator) generateStreamInterfaces(service *parser.Service, method *parser.Method) string {
+	var (
+		nameLower  = parser.LowercaseFirstLetter(method.Name)
+		streamName = g.streamInterfaceName(service, method, "Stream")
+		contents   = ""
+	)
+
+	if method.StreamingResponse {
+		contents += fmt.Sprintf("// %s is used by handlers to send the values streamed by %s.\n",
+			g.streamInterfaceName(service, method, "Sender"), nameLower)
+		if !g.isPrimitive(method.ReturnType) && !g.Frugal.IsEnum(method.ReturnType) {
+			contents += "// Send returns frugal.ErrStreamNilValue if value is nil.\n"
+		}
+		contents += fmt.Sprintf("type %s interface {\n", g.streamInterfaceName(service, method, "Sender"))
+		contents += fmt.Sprintf("\tSend(value %s) error\n", g.getGoTypeFromThriftType(method.ReturnType))
+		contents += "}\n\n"
+	}
+
+	if method.RequestStream != nil {
+		requestType := g.getGoTypeFromThriftType(method.RequestStream.Type)
+		contents += fmt.Sprintf("// %s is used by handlers to receive the values streamed to\n",
+			g.streamInterfaceName(service, method, "Receiver"))
+		contents += fmt.Sprintf("// %s. Recv returns io.EOF once the client has sent every value.\n", nameLower)
+		contents += fmt.Sprintf("type %s interface {\n", g.streamInterfaceName(service, method, "Receiver"))
+		contents += fmt.Sprintf("\tRecv() (%s, error)\n", requestType)
+		contents += "}\n\n"
+
+		if method.StreamingResponse {
+			contents += fmt.Sprintf("// %s sends the values streamed to %s and receives the\n", streamName, nameLower)
+			contents += "// values it streams back. CloseSend is called once every value has been sent.\n"
+			contents += "// Next returns io.EOF once the stream has ended. Close should be called if the\n"
+			contents += "// stream is abandoned before Next returns an error.\n"
+			contents += fmt.Sprintf("type %s interface {\n", streamName)
+			contents += fmt.Sprintf("\tSend(value %s) error\n", requestType)
+			contents += "\tCloseSend() error\n"
+			contents += fmt.Sprintf("\tNext() (%s, error)\n", g.getGoTypeFromThriftType(method.ReturnType))
+			contents += "\tClose() error\n"
+			contents += "}\n\n"
+			return contents
+		}
+
+		contents += fmt.Sprintf("// %s sends the values streamed to %s. CloseAndRecv is\n", streamName, nameLower)
+		contents += "// called once every value has been sent and returns the result. Close should\n"
+		contents += "// be called if the stream is abandoned before CloseAndRecv is called.\n"
+		contents += fmt.Sprintf("type %s interface {\n", streamName)
+		contents += fmt.Sprintf("\tSend(value %s) error\n", requestType)
+		if method.ReturnType != nil {
+			contents += fmt.Sprintf("\tCloseAndRecv() (%s, error)\n", g.getGoTypeFromThriftType(method.ReturnType))
+		} else {
+			contents += "\tCloseAndRecv() error\n"
+		}
+		contents += "\tClose() error\n"
+		contents += "}\n\n"
+		return contents
+	}
+
+	contents += fmt.Sprintf("// %s receives the values streamed by %s. Next returns\n", streamName, nameLower)
+	contents += "// io.EOF once the stream has ended. Close should be called if the stream is\n"
+	contents += "// abandoned before Next returns an error.\n"
+	contents += fmt.Sprintf("type %s interface {\n", streamName)
+	contents += fmt.Sprintf("\tNext() (%s, error)\n", g.getGoTypeFromThriftType(method.ReturnType))
+	contents += "\tClose() error\n"
+	contents += "}\n\n"
+	return contents
+}
+
 func (g *Generator) generateAsyncReturnArgs(method *parser.Method) string {
 	if method.ReturnType == nil {
 		return "(err <-chan error)"
@@ -1619,9 +1873,13 @@ func (g *Generator) generateClient(service *parser.Service) string {
 	contents += "\treturn client\n"
 	contents += "}\n\n"
 
+	if g.generateMultiplex() {
+		contents += g.generateMultiplexedClient(service)
+	}
+
 	for _, method := range service.Methods {
 		contents += g.generateClientMethod(service, method)
-		if g.generateAsync() {
+		if g.generateAsync() && !method.IsStreaming() {
 			contents += g.generateAsyncClientMethod(service, method)
 		}
 	}
@@ -1638,8 +1896,8 @@ func (g *Generator) generateAsyncClientMethod(service *parser.Service, method *p
 	if method.Comment != nil {
 		contents += g.GenerateInlineComment(method.Comment, "")
 	}
-	contents += fmt.Sprintf("func (f *F%sClient) %sAsync(ctx frugal.FContext%s) %s {\n",
-		servTitle, nameTitle, g.generateInputArgs(method.Arguments), g.generateAsyncReturnArgs(method))
+	contents += fmt.Sprintf("func (f *F%sClient) %sAsync(ctx %s%s) %s {\n",
+		servTitle, nameTitle, g.contextType(), g.generateInputArgs(method.Arguments), g.generateAsyncReturnArgs(method))
 	contents += "\terrC := make(chan error, 1)\n"
 	if method.ReturnType != nil {
 		contents += fmt.Sprintf("\tresultC := make(chan %s, 1)\n", g.getGoTypeFromThriftType(method.ReturnType))
@@ -1685,8 +1943,8 @@ func (g *Generator) generateClientMethod(service *parser.Service, method *parser
 		contents += fmt.Sprintf("// Deprecated%s\n", deprecationValue)
 	}
 
-	contents += fmt.Sprintf("func (f *F%sClient) %s(ctx frugal.FContext%s) %s {\n",
-		servTitle, nameTitle, g.generateInputArgs(method.Arguments), g.generateReturnArgs(method))
+	contents += fmt.Sprintf("func (f *F%sClient) %s(ctx %s%s) %s {\n",
+		servTitle, nameTitle, g.contextType(), g.generateInputArgs(method.Arguments), g.generateReturnArgs(service, method))
 
 	if deprecated {
 		contents += fmt.Sprintf("\tlogrus.Warn(\"Call to deprecated function '%s.%s'\")\n", service.Name, nameTitle)
@@ -1694,15 +1952,15 @@ func (g *Generator) generateClientMethod(service *parser.Service, method *parser
 
 	contents += fmt.Sprintf("\tret := f.methods[\"%s\"].Invoke(%s)\n", nameLower, g.generateClientArgs(method))
 	numReturn := "2"
-	if method.ReturnType == nil {
+	if !g.returnsValue(method) {
 		numReturn = "1"
 	}
 	contents += fmt.Sprintf("\tif len(ret) != %s {\n", numReturn)
 	contents += fmt.Sprintf("\t\tpanic(fmt.Sprintf(\"Middleware returned %%d arguments, expected %s\", len(ret)))\n", numReturn)
 	contents += "\t}\n"
-	if method.ReturnType != nil {
+	if g.returnsValue(method) {
 		contents += "\tif ret[0] != nil {\n"
-		contents += fmt.Sprintf("\t\tr = ret[0].(%s)\n", g.getGoTypeFromThriftType(method.ReturnType))
+		contents += fmt.Sprintf("\t\tr = ret[0].(%s)\n", g.getClientReturnType(service, method))
 		contents += "\t}\n"
 		contents += "\tif ret[1] != nil {\n"
 		contents += "\t\terr = ret[1].(error)\n"
@@ -1728,8 +1986,16 @@ func (g *Generator) generateInternalClientMethod(service *parser.Service, method
 
 	contents := ""
 	contents += fmt.Sprintf("func (f *F%sClient) %s(ctx frugal.FContext%s) %s {\n",
-		servTitle, nameLower, g.generateInputArgs(method.Arguments), g.generateReturnArgs(method))
+		servTitle, nameLower, g.generateInputArgs(method.Arguments), g.generateReturnArgs(service, method))
 
+	if method.IsStreaming() {
+		contents += "\ttransport, ok := f.transport.(frugal.FStreamingTransport)\n"
+		contents += "\tif !ok {\n"
+		contents += fmt.Sprintf(
+			"\t\terr = thrift.NewTTransportException(frugal.TRANSPORT_EXCEPTION_UNKNOWN, \"%s failed: transport does not support streaming\")\n", nameLower)
+		contents += "\t\treturn\n"
+		contents += "\t}\n"
+	}
 	contents += "\tbuffer := frugal.NewTMemoryOutputBuffer(f.transport.GetRequestSizeLimit())\n"
 	contents += "\toprot := f.protocolFactory.GetProtocol(buffer)\n"
 	contents += "\tif err = oprot.WriteRequestHeader(ctx); err != nil {\n"
@@ -1762,6 +2028,24 @@ func (g *Generator) generateInternalClientMethod(service *parser.Service, method
 		contents += "}\n\n"
 		return contents
 	}
+	if method.IsStreaming() {
+		if method.RequestStream != nil {
+			contents += "\tvar stream frugal.FStream\n"
+			contents += "\tstream, err = transport.OpenStream(ctx, buffer.Bytes())\n"
+		} else {
+			contents += "\tvar stream frugal.FResponseStream\n"
+			contents += "\tstream, err = transport.RequestStream(ctx, buffer.Bytes())\n"
+		}
+		contents += "\tif err != nil {\n"
+		contents += "\t\treturn\n"
+		contents += "\t}\n"
+		contents += fmt.Sprintf("\tr = &%s{ctx: ctx, stream: stream, protocolFactory: f.protocolFactory}\n",
+			g.streamImplName(service, method, "Stream"))
+		contents += "\treturn\n"
+		contents += "}\n\n"
+		contents += g.generateStreamImpl(service, method)
+		return contents
+	}
 	contents += "\tvar resultTransport thrift.TTransport\n"
 	contents += "\tresultTransport, err = f.transport.Request(ctx, buffer.Bytes())\n"
 	contents += "\tif err != nil {\n"
@@ -1769,6 +2053,26 @@ func (g *Generator) generateInternalClientMethod(service *parser.Service, method
 	contents += "\t}\n"
 
 	contents += "\tiprot := f.protocolFactory.GetProtocol(resultTransport)\n"
+	contents += g.generateReadResult(service, method)
+	if method.ReturnType != nil {
+		contents += "\tr = result.GetSuccess()\n"
+	}
+	contents += "\treturn\n"
+	contents += "}\n\n"
+
+	return contents
+}
+
+// generateReadResult generates the code which reads a method's result from
+// iprot, returning any error or declared exception it contains.
+func (g *Generator) generateReadResult(service *parser.Service, method *parser.Method) string {
+	var (
+		servTitle = snakeToCamel(service.Name)
+		nameTitle = snakeToCamel(method.Name)
+		nameLower = parser.LowercaseFirstLetter(method.Name)
+	)
+
+	contents := ""
 	contents += "\tif err = iprot.ReadResponseHeader(ctx); err != nil {\n"
 	contents += "\t\treturn\n"
 	contents += "\t}\n"
@@ -1817,12 +2121,131 @@ func (g *Generator) generateInternalClientMethod(service *parser.Service, method
 		contents += "\t\treturn\n"
 		contents += "\t}\n"
 	}
+	return contents
+}
+
+// generateStreamImpl generates the client's implementation of a streaming
+// method's stream interface, which writes a request frame for each value sent
+// and reads a result from each response frame.
+func (g *Generator) generateStreamImpl(service *parser.Service, method *parser.Method) string {
+	implName := g.streamImplName(service, method, "Stream")
+
+	contents := fmt.Sprintf("type %s struct {\n", implName)
+	contents += "\tctx             frugal.FContext\n"
+	if method.RequestStream != nil {
+		contents += "\tstream          frugal.FStream\n"
+	} else {
+		contents += "\tstream          frugal.FResponseStream\n"
+	}
+	contents += "\tprotocolFactory *frugal.FProtocolFactory\n"
+	contents += "}\n\n"
+
+	if method.RequestStream != nil {
+		contents += g.generateStreamSend(service, method)
+		if !method.StreamingResponse {
+			contents += g.generateStreamCloseAndRecv(service, method)
+		} else {
+			contents += fmt.Sprintf("func (s *%s) CloseSend() error {\n", implName)
+			contents += "\treturn s.stream.CloseSend()\n"
+			contents += "}\n\n"
+		}
+	}
+
+	if method.StreamingResponse {
+		contents += fmt.Sprintf("func (s *%s) Next() (r %s, err error) {\n",
+			implName, g.getGoTypeFromThriftType(method.ReturnType))
+		contents += "\tctx := s.ctx\n"
+		contents += "\tvar resultTransport thrift.TTransport\n"
+		contents += "\tresultTransport, err = s.stream.Recv()\n"
+		contents += "\tif err != nil {\n"
+		contents += "\t\treturn\n"
+		contents += "\t}\n"
+		contents += "\tiprot := s.protocolFactory.GetProtocol(resultTransport)\n"
+		contents += g.generateReadResult(service, method)
+		// The frame which ends the stream carries a result without a value.
+		contents += "\tif !result.IsSetSuccess() {\n"
+		contents += "\t\terr = io.EOF\n"
+		contents += "\t\treturn\n"
+		contents += "\t}\n"
+		contents += "\tr = result.GetSuccess()\n"
+		contents += "\treturn\n"
+		contents += "}\n\n"
+	}
+
+	contents += fmt.Sprintf("func (s *%s) Close() error {\n", implName)
+	contents += "\treturn s.stream.Close()\n"
+	contents += "}\n\n"
+	return contents
+}
+
+// generateStreamSend generates the Send method of a client's stream, which
+// writes a value to its own request frame.
+func (g *Generator) generateStreamSend(service *parser.Service, method *parser.Method) string {
+	var (
+		servTitle = snakeToCamel(service.Name)
+		nameTitle = snakeToCamel(method.Name)
+		nameLower = parser.LowercaseFirstLetter(method.Name)
+		field     = method.RequestStream
+	)
+
+	value := "value"
+	if g.isPrimitive(field.Type) || g.Frugal.IsEnum(field.Type) {
+		value = "&value"
+	}
+	contents := fmt.Sprintf("func (s *%s) Send(value %s) error {\n",
+		g.streamImplName(service, method, "Stream"), g.getGoTypeFromThriftType(field.Type))
+	contents += "\tbuffer := frugal.NewTMemoryOutputBuffer(0)\n"
+	contents += "\toprot := s.protocolFactory.GetProtocol(buffer)\n"
+	contents += "\tif err := oprot.WriteRequestHeader(s.ctx); err != nil {\n"
+	contents += "\t\treturn err\n"
+	contents += "\t}\n"
+	contents += fmt.Sprintf("\tif err := oprot.WriteMessageBegin(\"%s\", thrift.CALL, 0); err != nil {\n", nameLower)
+	contents += "\t\treturn err\n"
+	contents += "\t}\n"
+	contents += fmt.Sprintf("\trequest := %s%sRequest{%s: %s}\n", servTitle, nameTitle, title(field.Name), value)
+	contents += "\tif err := request.Write(oprot); err != nil {\n"
+	contents += "\t\treturn err\n"
+	contents += "\t}\n"
+	contents += "\tif err := oprot.WriteMessageEnd(); err != nil {\n"
+	contents += "\t\treturn err\n"
+	contents += "\t}\n"
+	contents += "\tif err := oprot.Flush(); err != nil {\n"
+	contents += "\t\treturn err\n"
+	contents += "\t}\n"
+	contents += "\treturn s.stream.Send(buffer.Bytes())\n"
+	contents += "}\n\n"
+	return contents
+}
+
+// generateStreamCloseAndRecv generates the CloseAndRecv method of a client's
+// stream, which half-closes the stream and reads the method's result.
+func (g *Generator) generateStreamCloseAndRecv(service *parser.Service, method *parser.Method) string {
+	implName := g.streamImplName(service, method, "Stream")
+
+	contents := ""
+	if method.ReturnType != nil {
+		contents += fmt.Sprintf("func (s *%s) CloseAndRecv() (r %s, err error) {\n",
+			implName, g.getGoTypeFromThriftType(method.ReturnType))
+	} else {
+		contents += fmt.Sprintf("func (s *%s) CloseAndRecv() (err error) {\n", implName)
+	}
+	contents += "\tdefer s.stream.Close()\n"
+	contents += "\tif err = s.stream.CloseSend(); err != nil {\n"
+	contents += "\t\treturn\n"
+	contents += "\t}\n"
+	contents += "\tctx := s.ctx\n"
+	contents += "\tvar resultTransport thrift.TTransport\n"
+	contents += "\tresultTransport, err = s.stream.Recv()\n"
+	contents += "\tif err != nil {\n"
+	contents += "\t\treturn\n"
+	contents += "\t}\n"
+	contents += "\tiprot := s.protocolFactory.GetProtocol(resultTransport)\n"
+	contents += g.generateReadResult(service, method)
 	if method.ReturnType != nil {
 		contents += "\tr = result.GetSuccess()\n"
 	}
 	contents += "\treturn\n"
 	contents += "}\n\n"
-
 	return contents
 }
 
@@ -1852,6 +2275,10 @@ func (g *Generator) generateProcessor(service *parser.Service) string {
 	}
 	contents += "}\n\n"
 
+	if g.generateReflection() {
+		contents += g.generateServiceDescriptor(service)
+	}
+
 	contents += fmt.Sprintf("func NewF%sProcessor(handler F%s, middleware ...frugal.ServiceMiddleware) *F%sProcessor {\n",
 		servTitle, servTitle, servTitle)
 	if service.Extends != "" {
@@ -1860,11 +2287,17 @@ func (g *Generator) generateProcessor(service *parser.Service) string {
 	} else {
 		contents += fmt.Sprintf("\tp := &F%sProcessor{frugal.NewFBaseProcessor()}\n", servTitle)
 	}
+	if g.generateReflection() {
+		contents += fmt.Sprintf("\tp.AddServiceDescriptor(%s)\n", serviceDescriptorName(service))
+	}
 	for _, method := range service.Methods {
 		methodLower := parser.LowercaseFirstLetter(method.Name)
 		contents += fmt.Sprintf(
 			"\tp.AddToProcessorMap(\"%s\", &%sF%s{frugal.NewFBaseProcessorFunction(p.GetWriteMutex(), frugal.NewMethod(handler, handler.%s, \"%s\", middleware))})\n",
 			methodLower, servLower, snakeToCamel(method.Name), snakeToCamel(method.Name), snakeToCamel(method.Name))
+		if g.generateMultiplex() {
+			contents += fmt.Sprintf("\tp.AddToServiceMap(\"%s\", \"%s\")\n", methodLower, service.Name)
+		}
 		if len(method.Annotations) > 0 {
 			contents += fmt.Sprintf("\tp.AddToAnnotationsMap(\"%s\", map[string]string{\n", methodLower)
 			for _, annotation := range method.Annotations {
@@ -1877,6 +2310,10 @@ func (g *Generator) generateProcessor(service *parser.Service) string {
 	contents += "\treturn p\n"
 	contents += "}\n\n"
 
+	if g.generateMultiplex() {
+		contents += g.generateRegisterProcessor(service)
+	}
+
 	return contents
 }
 
@@ -1892,6 +2329,10 @@ func (g *Generator) generateMethodProcessor(service *parser.Service, method *par
 	contents += "\t*frugal.FBaseProcessorFunction\n"
 	contents += "}\n\n"
 
+	if method.IsStreaming() {
+		return contents + g.generateStreamingMethodProcessor(service, method)
+	}
+
 	contents += fmt.Sprintf("func (p *%sF%s) Process(ctx frugal.FContext, iprot, oprot *frugal.FProtocol) error {\n", servLower, nameTitle)
 
 	if _, ok := method.Annotations.Deprecated(); ok {
@@ -1911,13 +2352,25 @@ func (g *Generator) generateMethodProcessor(service *parser.Service, method *par
 	contents += "\t}\n\n"
 
 	contents += "\tiprot.ReadMessageEnd()\n"
+	if g.generateValidate() {
+		contents += "\tif err = p.ValidateArgs(&args); err != nil {\n"
+		if !method.Oneway {
+			contents += "\t\tp.GetWriteMutex().Lock()\n"
+			contents += fmt.Sprintf("\t\terr = %sWriteApplicationError(ctx, oprot, frugal.APPLICATION_EXCEPTION_VALIDATION_ERROR, \"%s\", err.Error())\n", servLower, nameLower)
+			contents += "\t\tp.GetWriteMutex().Unlock()\n"
+		}
+		contents += "\t\treturn err\n"
+		contents += "\t}\n"
+	}
 	if !method.Oneway {
 		contents += fmt.Sprintf("\tresult := %s%sResult{}\n", servTitle, nameTitle)
 	}
 	contents += "\tvar err2 error\n"
 	if method.ReturnType != nil {
 	}
-	contents += fmt.Sprintf("\tret := p.InvokeMethod(%s)\n", g.generateHandlerArgs(method))
+	contents += g.generateHandlerDeadline("\t")
+	contents += fmt.Sprintf("\tret := p.InvokeMethod(%s)\n", g.generateHandlerArgs(method, g.handlerContext()))
+	contents += g.generateHandlerCancel("\t")
 	numReturn := "2"
 	if method.ReturnType == nil {
 		numReturn = "1"
@@ -1999,8 +2452,145 @@ func (g *Generator) generateMethodProcessor(service *parser.Service, method *par
 	return contents
 }
 
+// generateStreamingMethodProcessor generates the Process method of a
+// streaming method. The handler is called in its own goroutine so values the
+// client streams, and a cancel, are received while it runs. Each value sent by
+// the handler is written as its own frame before the stream is ended.
+func (g *Generator) generateStreamingMethodProcessor(service *parser.Service, method *parser.Method) string {
+	var (
+		servTitle    = snakeToCamel(service.Name)
+		servLower    = strings.ToLower(service.Name)
+		nameTitle    = snakeToCamel(method.Name)
+		nameLower    = parser.LowercaseFirstLetter(method.Name)
+		senderName   = g.streamImplName(service, method, "Sender")
+		receiverName = g.streamImplName(service, method, "Receiver")
+	)
+
+	contents := fmt.Sprintf("func (p *%sF%s) Process(ctx frugal.FContext, iprot, oprot *frugal.FProtocol) error {\n", servLower, nameTitle)
+
+	if _, ok := method.Annotations.Deprecated(); ok {
+		contents += fmt.Sprintf("\tlogrus.Warn(\"Deprecated function '%s.%s' was called by a client\")\n", service.Name, nameTitle)
+	}
+
+	contents += fmt.Sprintf("\twriter := frugal.NewFStreamWriter(ctx, oprot, p.GetWriteMutex(), \"%s\")\n", nameLower)
+	contents += fmt.Sprintf("\targs := %s%sArgs{}\n", servTitle, nameTitle)
+	contents += "\tif err := args.Read(iprot); err != nil {\n"
+	contents += "\t\tiprot.ReadMessageEnd()\n"
+	contents += "\t\treturn writer.WriteError(frugal.APPLICATION_EXCEPTION_PROTOCOL_ERROR, err.Error())\n"
+	contents += "\t}\n\n"
+
+	contents += "\tiprot.ReadMessageEnd()\n"
+	// Streams outlive the timeout of the request, so their handlers get no
+	// deadline.
+	handlerArgs := g.generateHandlerArgs(method, g.incomingContext())
+	handlerArgs = handlerArgs[:len(handlerArgs)-1]
+	if method.RequestStream != nil {
+		contents += "\treader, err := frugal.NewFStreamReader(writer, func(iprot *frugal.FProtocol) (interface{}, error) {\n"
+		contents += fmt.Sprintf("\t\trequest := %s%sRequest{}\n", servTitle, nameTitle)
+		contents += "\t\terr := request.Read(iprot)\n"
+		contents += fmt.Sprintf("\t\treturn request.Get%s(), err\n", title(method.RequestStream.Name))
+		contents += "\t})\n"
+		contents += "\tif err != nil {\n"
+		contents += "\t\treturn err\n"
+		contents += "\t}\n"
+		handlerArgs += fmt.Sprintf(", &%s{reader: reader}", receiverName)
+	}
+	if method.StreamingResponse {
+		handlerArgs += fmt.Sprintf(", &%s{writer: writer}", senderName)
+	}
+	handlerArgs += "}"
+
+	// Handlers of methods which only stream requests return a value.
+	returnsValue := !method.StreamingResponse && method.ReturnType != nil
+	numReturn, errIndex := 1, 0
+	if returnsValue {
+		numReturn, errIndex = 2, 1
+	}
+	contents += "\twriter.Handle(func() error {\n"
+	contents += fmt.Sprintf("\t\tresult := %s%sResult{}\n", servTitle, nameTitle)
+	contents += "\t\tvar err2 error\n"
+	contents += fmt.Sprintf("\t\tret := p.InvokeMethod(%s)\n", handlerArgs)
+	contents += fmt.Sprintf("\t\tif len(ret) != %d {\n", numReturn)
+	contents += fmt.Sprintf("\t\t\tpanic(fmt.Sprintf(\"Middleware returned %%d arguments, expected %d\", len(ret)))\n", numReturn)
+	contents += "\t\t}\n"
+	contents += fmt.Sprintf("\t\tif ret[%d] != nil {\n", errIndex)
+	contents += fmt.Sprintf("\t\t\terr2 = ret[%d].(error)\n", errIndex)
+	contents += "\t\t}\n"
+	contents += "\t\tif err2 != nil {\n"
+	contents += "\t\t\tif err3, ok := err2.(thrift.TApplicationException); ok {\n"
+	contents += "\t\t\t\twriter.WriteError(err3.TypeId(), err3.Error())\n"
+	contents += "\t\t\t\treturn nil\n"
+	contents += "\t\t\t}\n"
+	internalError := fmt.Sprintf(
+		"return writer.WriteError(frugal.APPLICATION_EXCEPTION_INTERNAL_ERROR, \"Internal error processing %s: \"+err2.Error())\n", nameLower)
+	if len(method.Exceptions) > 0 {
+		contents += "\t\t\tswitch v := err2.(type) {\n"
+		for _, err := range method.Exceptions {
+			contents += fmt.Sprintf("\t\t\tcase %s:\n", g.getGoTypeFromThriftType(err.Type))
+			contents += fmt.Sprintf("\t\t\t\tresult.%s = v\n", snakeToCamel(err.Name))
+		}
+		contents += "\t\t\tdefault:\n"
+		contents += "\t\t\t\t" + internalError
+		contents += "\t\t\t}\n"
+	} else {
+		contents += "\t\t\t" + internalError
+	}
+	if returnsValue {
+		contents += "\t\t} else {\n"
+		contents += fmt.Sprintf("\t\t\tvar retval %s = ret[0].(%s)\n",
+			g.getGoTypeFromThriftType(method.ReturnType), g.getGoTypeFromThriftType(method.ReturnType))
+		if g.isPrimitive(method.ReturnType) || g.Frugal.IsEnum(method.ReturnType) {
+			contents += "\t\t\tresult.Success = &retval\n"
+		} else {
+			contents += "\t\t\tresult.Success = retval\n"
+		}
+	}
+	contents += "\t\t}\n"
+	contents += "\t\treturn writer.WriteEnd(&result)\n"
+	contents += "\t})\n"
+	contents += "\treturn nil\n"
+	contents += "}\n\n"
+
+	if method.RequestStream != nil {
+		requestType := g.getGoTypeFromThriftType(method.RequestStream.Type)
+		contents += fmt.Sprintf("type %s struct {\n", receiverName)
+		contents += "\treader *frugal.FStreamReader\n"
+		contents += "}\n\n"
+
+		contents += fmt.Sprintf("func (r *%s) Recv() (value %s, err error) {\n", receiverName, requestType)
+		contents += "\tvar v interface{}\n"
+		contents += "\tif v, err = r.reader.Recv(); err != nil {\n"
+		contents += "\t\treturn\n"
+		contents += "\t}\n"
+		contents += fmt.Sprintf("\treturn v.(%s), nil\n", requestType)
+		contents += "}\n\n"
+	}
+
+	if method.StreamingResponse {
+		contents += fmt.Sprintf("type %s struct {\n", senderName)
+		contents += "\twriter *frugal.FStreamWriter\n"
+		contents += "}\n\n"
+
+		contents += fmt.Sprintf("func (s *%s) Send(value %s) error {\n",
+			senderName, g.getGoTypeFromThriftType(method.ReturnType))
+		success := "value"
+		if g.isPrimitive(method.ReturnType) || g.Frugal.IsEnum(method.ReturnType) {
+			success = "&value"
+		} else {
+			// The client reads a result without a value as the end of the
+			// stream.
+			contents += "\tif value == nil {\n"
+			contents += "\t\treturn frugal.ErrStreamNilValue\n"
+			contents += "\t}\n"
+		}
+		contents += fmt.Sprintf("\treturn s.writer.WriteData(&%s%sResult{Success: %s})\n", servTitle, nameTitle, success)
+		contents += "}\n\n"
+	}
+	return contents
+}
+
 func (g *Generator) generateClientArgs(method *parser.Method) string {
-	args := "[]interface{}{ctx"
+	args := "[]interface{}{" + g.outgoingContext()
 	for _, arg := range method.Arguments {
 		args += ", " + strings.ToLower(arg.Name)
 	}
@@ -2009,7 +2599,7 @@ func (g *Generator) generateClientArgs(method *parser.Method) string {
 }
 
 func (g *Generator) generateScopeArgs(scope *parser.Scope) string {
-	args := "[]interface{}{ctx"
+	args := "[]interface{}{" + g.outgoingContext()
 	for _, v := range scope.Prefix.Variables {
 		args += ", " + v
 	}
@@ -2018,8 +2608,8 @@ func (g *Generator) generateScopeArgs(scope *parser.Scope) string {
 	return args
 }
 
-func (g *Generator) generateHandlerArgs(method *parser.Method) string {
-	args := "[]interface{}{ctx"
+func (g *Generator) generateHandlerArgs(method *parser.Method, ctx string) string {
+	args := "[]interface{}{" + ctx
 	for _, arg := range method.Arguments {
 		args += ", args." + title(arg.Name)
 	}
@@ -2250,6 +2840,9 @@ func (g *Generator) qualifiedTypeName(t *parser.Type) string {
 	if strings.HasPrefix(param, "New") || strings.HasSuffix(param, "Result") || strings.HasSuffix(param, "Args") {
 		param += "_"
 	}
+	if include == "" && g.localPackage != "" {
+		param = fmt.Sprintf("%s.%s", g.localPackage, param)
+	}
 	return param
 }
 
@@ -0,0 +1,449 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package golang
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Workiva/frugal/compiler/generator"
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+func (g *Generator) generateMocks() bool {
+	_, ok := g.Options[mocksOption]
+	return ok
+}
+
+// createMockFile creates the file of the mocks named after the file of the
+// service or scope, f_<name>_mock.go, in the same package, and generates its
+// header.
+func (g *Generator) createMockFile(file *os.File) (*os.File, error) {
+	name := strings.TrimPrefix(strings.TrimSuffix(filepath.Base(file.Name()), "."+lang), generator.FilePrefix)
+	mockFile, err := g.CreateFile(name+mockSuffix, filepath.Dir(file.Name()), lang, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.GenerateDocStringComment(mockFile); err != nil {
+		mockFile.Close()
+		return nil, err
+	}
+	if err := g.GenerateNewline(mockFile, 2); err != nil {
+		mockFile.Close()
+		return nil, err
+	}
+	if err := g.generatePackage(mockFile); err != nil {
+		mockFile.Close()
+		return nil, err
+	}
+	if err := g.GenerateNewline(mockFile, 2); err != nil {
+		mockFile.Close()
+		return nil, err
+	}
+	return mockFile, nil
+}
+
+// generateServiceMockFile generates the mock of the service into its own file
+// next to the given file of the service.
+func (g *Generator) generateServiceMockFile(file *os.File, service *parser.Service) error {
+	mockFile, err := g.createMockFile(file)
+	if err != nil {
+		return err
+	}
+	defer mockFile.Close()
+
+	if err := g.GenerateServiceImports(mockFile, service); err != nil {
+		return err
+	}
+	if err := g.GenerateNewline(mockFile, 2); err != nil {
+		return err
+	}
+	if _, err := mockFile.WriteString(g.generateServiceMock(service)); err != nil {
+		return err
+	}
+	return g.PostProcess(mockFile)
+}
+
+// generateScopeMockFile generates the publisher and subscriber mocks and the
+// publisher fake of the scope into their own file next to the given file of
+// the scope.
+func (g *Generator) generateScopeMockFile(file *os.File, scope *parser.Scope) error {
+	mockFile, err := g.createMockFile(file)
+	if err != nil {
+		return err
+	}
+	defer mockFile.Close()
+
+	if err := g.GenerateScopeImports(mockFile, scope); err != nil {
+		return err
+	}
+	if err := g.GenerateNewline(mockFile, 2); err != nil {
+		return err
+	}
+	contents := g.generatePublisherMock(scope)
+	contents += g.generateSubscriberMock(scope)
+	if _, err := mockFile.WriteString(contents); err != nil {
+		return err
+	}
+	return g.PostProcess(mockFile)
+}
+
+// mockParam is a parameter of a mocked method.
+type mockParam struct {
+	name string
+	typ  string
+}
+
+// generateMockParams generates the parameter list of a mocked method.
+func generateMockParams(params []mockParam) string {
+	list := make([]string, len(params))
+	for i, param := range params {
+		list[i] = param.name + " " + param.typ
+	}
+	return strings.Join(list, ", ")
+}
+
+// generateMockArgs generates the arguments passing the parameters of a mocked
+// method on.
+func generateMockArgs(params []mockParam) string {
+	list := make([]string, len(params))
+	for i, param := range params {
+		list[i] = param.name
+	}
+	return strings.Join(list, ", ")
+}
+
+// generateMockMethod generates a method of the mock, and the type recording
+// its calls, which calls the programmed func of the method, if any, and
+// otherwise returns the given default values.
+func (g *Generator) generateMockMethod(mockName, callName, methodName string, params []mockParam, results, defaults string) string {
+	contents := ""
+
+	contents += fmt.Sprintf("// %s is a call of %s.%s.\n", callName, mockName, methodName)
+	contents += fmt.Sprintf("type %s struct {\n", callName)
+	for _, param := range params {
+		contents += fmt.Sprintf("\t%s %s\n", title(param.name), param.typ)
+	}
+	contents += "}\n\n"
+
+	contents += fmt.Sprintf("func (m *%s) %s(%s) %s {\n", mockName, methodName, generateMockParams(params), results)
+	contents += "\tm.mu.Lock()\n"
+	contents += fmt.Sprintf("\tm.calls%s = append(m.calls%s, %s{", methodName, methodName, callName)
+	for i, param := range params {
+		if i > 0 {
+			contents += ", "
+		}
+		contents += fmt.Sprintf("%s: %s", title(param.name), param.name)
+	}
+	contents += "})\n"
+	contents += fmt.Sprintf("\tfn := m.%sFunc\n", methodName)
+	contents += "\tm.mu.Unlock()\n"
+	contents += "\tif fn != nil {\n"
+	contents += fmt.Sprintf("\t\treturn fn(%s)\n", generateMockArgs(params))
+	contents += "\t}\n"
+	contents += fmt.Sprintf("\treturn %s\n", defaults)
+	contents += "}\n\n"
+
+	contents += fmt.Sprintf("// %sCalls returns the calls of %s in the order they were made.\n", methodName, methodName)
+	contents += fmt.Sprintf("func (m *%s) %sCalls() []%s {\n", mockName, methodName, callName)
+	contents += "\tm.mu.Lock()\n"
+	contents += "\tdefer m.mu.Unlock()\n"
+	contents += fmt.Sprintf("\treturn append([]%s(nil), m.calls%s...)\n", callName, methodName)
+	contents += "}\n\n"
+	return contents
+}
+
+// generateServiceMock generates a mock implementation of the service
+// interface which records calls and returns programmed responses. Like the
+// scope mocks, it is named after the Frugal service, StoreMock, and its calls
+// after the mock and the method, StoreGetAlbumCall.
+func (g *Generator) generateServiceMock(service *parser.Service) string {
+	var (
+		servTitle = snakeToCamel(service.Name)
+		mockName  = fmt.Sprintf("%sMock", servTitle)
+		contents  = ""
+	)
+
+	type mockMethod struct {
+		name    string
+		params  []mockParam
+		results string
+	}
+	methods := []mockMethod{}
+	for _, method := range service.Methods {
+		params := []mockParam{{"ctx", g.contextType()}}
+		for _, arg := range method.Arguments {
+			params = append(params, mockParam{strings.ToLower(arg.Name), g.getGoTypeFromThriftType(arg.Type)})
+		}
+		results := g.generateReturnArgs(service, method)
+		if method.IsStreaming() {
+			if method.RequestStream != nil {
+				params = append(params, mockParam{method.RequestStream.Name, g.streamInterfaceName(service, method, "Receiver")})
+			}
+			if method.StreamingResponse {
+				params = append(params, mockParam{"sender", g.streamInterfaceName(service, method, "Sender")})
+			}
+			results = g.generateStreamHandlerReturnArgs(method)
+		}
+		methods = append(methods, mockMethod{snakeToCamel(method.Name), params, results})
+	}
+
+	contents += fmt.Sprintf("// %s is a mock implementation of F%s for tests. Calls are recorded\n", mockName, servTitle)
+	contents += "// and answered by the func of the method, if set, and otherwise with zero\n"
+	contents += "// values. Funcs should be set before the mock is used.\n"
+	contents += fmt.Sprintf("type %s struct {\n", mockName)
+	if service.Extends != "" {
+		contents += fmt.Sprintf("\t%s%sMock\n\n", g.getServiceExtendsNamespace(service), snakeToCamel(service.ExtendsService()))
+	}
+	for _, method := range methods {
+		contents += fmt.Sprintf("\t%sFunc func(%s) %s\n", method.name, generateMockParams(method.params), method.results)
+	}
+	contents += "\n"
+	contents += "\tmu sync.Mutex\n"
+	for _, method := range methods {
+		contents += fmt.Sprintf("\tcalls%s []%s%sCall\n", method.name, servTitle, method.name)
+	}
+	contents += "}\n\n"
+	contents += fmt.Sprintf("var _ F%s = (*%s)(nil)\n\n", servTitle, mockName)
+
+	for _, method := range methods {
+		callName := fmt.Sprintf("%s%sCall", servTitle, method.name)
+		contents += g.generateMockMethod(mockName, callName, method.name, method.params, method.results, "")
+	}
+	return contents
+}
+
+// generatePublisherMock generates a mock implementation of the publisher
+// interface and a fake publisher recording publishes by topic.
+func (g *Generator) generatePublisherMock(scope *parser.Scope) string {
+	var (
+		scopeCamel = snakeToCamel(scope.Name)
+		mockName   = fmt.Sprintf("%sPublisherMock", scopeCamel)
+		contents   = ""
+	)
+
+	contents += fmt.Sprintf("// %s is a mock implementation of %sPublisher for tests. Calls\n", mockName, scopeCamel)
+	contents += "// are recorded and answered by the func of the method, if set, and otherwise\n"
+	contents += "// with nil. Funcs should be set before the mock is used.\n"
+	contents += fmt.Sprintf("type %s struct {\n", mockName)
+	contents += "\tOpenFunc  func() error\n"
+	contents += "\tCloseFunc func() error\n"
+	for _, op := range scope.Operations {
+		contents += fmt.Sprintf("\tPublish%sFunc func(%s) error\n", op.Name, generateMockParams(g.publishParams(scope, op)))
+	}
+	contents += "\n"
+	contents += "\tmu sync.Mutex\n"
+	contents += fmt.Sprintf("\tcallsOpen []%sPublisherOpenCall\n", scopeCamel)
+	contents += fmt.Sprintf("\tcallsClose []%sPublisherCloseCall\n", scopeCamel)
+	for _, op := range scope.Operations {
+		contents += fmt.Sprintf("\tcallsPublish%s []%sPublisherPublish%sCall\n", op.Name, scopeCamel, op.Name)
+	}
+	contents += "}\n\n"
+	contents += fmt.Sprintf("var _ %sPublisher = (*%s)(nil)\n\n", scopeCamel, mockName)
+
+	contents += g.generateMockMethod(mockName, scopeCamel+"PublisherOpenCall", "Open", nil, "error", "nil")
+	contents += g.generateMockMethod(mockName, scopeCamel+"PublisherCloseCall", "Close", nil, "error", "nil")
+	for _, op := range scope.Operations {
+		contents += g.generateMockMethod(mockName, fmt.Sprintf("%sPublisherPublish%sCall", scopeCamel, op.Name),
+			"Publish"+op.Name, g.publishParams(scope, op), "error", "nil")
+	}
+
+	contents += g.generatePublisherFake(scope)
+	return contents
+}
+
+// generatePublisherFake generates an in-memory publisher which records
+// publishes by the topic they would be published to.
+func (g *Generator) generatePublisherFake(scope *parser.Scope) string {
+	var (
+		scopeCamel = snakeToCamel(scope.Name)
+		scopeTitle = strings.Title(scope.Name)
+		fakeName   = fmt.Sprintf("%sPublisherFake", scopeCamel)
+		pubName    = fmt.Sprintf("%sPublication", scopeCamel)
+		contents   = ""
+	)
+
+	contents += fmt.Sprintf("// %s is a publish recorded by %s.\n", pubName, fakeName)
+	contents += fmt.Sprintf("type %s struct {\n", pubName)
+	contents += fmt.Sprintf("\tCtx   %s\n", g.contextType())
+	contents += "\tTopic string\n"
+	contents += "\tOp    string\n"
+	contents += "\tReq   interface{}\n"
+	contents += "}\n\n"
+
+	contents += fmt.Sprintf("// %s is an in-memory %sPublisher\n", fakeName, scopeCamel)
+	contents += "// for tests which records publishes by the topic they would be published to.\n"
+	contents += "// Like a publisher, it must be opened before publishing and adds the prefix\n"
+	contents += "// variables to the request headers of the FContext.\n"
+	contents += fmt.Sprintf("type %s struct {\n", fakeName)
+	contents += "\tmu           sync.Mutex\n"
+	contents += "\topen         bool\n"
+	contents += fmt.Sprintf("\tpublications []%s\n", pubName)
+	contents += "}\n\n"
+	contents += fmt.Sprintf("var _ %sPublisher = (*%s)(nil)\n\n", scopeCamel, fakeName)
+
+	contents += fmt.Sprintf("// New%s returns a new, closed %s.\n", fakeName, fakeName)
+	contents += fmt.Sprintf("func New%s() *%s {\n", fakeName, fakeName)
+	contents += fmt.Sprintf("\treturn &%s{}\n", fakeName)
+	contents += "}\n\n"
+
+	contents += fmt.Sprintf("func (p *%s) Open() error {\n", fakeName)
+	contents += "\tp.mu.Lock()\n"
+	contents += "\tdefer p.mu.Unlock()\n"
+	contents += "\tif p.open {\n"
+	contents += fmt.Sprintf("\t\treturn thrift.NewTTransportException(frugal.TRANSPORT_EXCEPTION_ALREADY_OPEN, \"%s: publisher already open\")\n", fakeName)
+	contents += "\t}\n"
+	contents += "\tp.open = true\n"
+	contents += "\treturn nil\n"
+	contents += "}\n\n"
+
+	contents += fmt.Sprintf("func (p *%s) Close() error {\n", fakeName)
+	contents += "\tp.mu.Lock()\n"
+	contents += "\tdefer p.mu.Unlock()\n"
+	contents += "\tp.open = false\n"
+	contents += "\treturn nil\n"
+	contents += "}\n\n"
+
+	for _, op := range scope.Operations {
+		params := g.publishParams(scope, op)
+		contents += fmt.Sprintf("func (p *%s) Publish%s(%s) error {\n", fakeName, op.Name, generateMockParams(params))
+		for _, prefixVar := range scope.Prefix.Variables {
+			if g.generateStdContext() {
+				contents += fmt.Sprintf("\tctx = frugal.WithRequestHeader(ctx, \"_topic_%s\", %s)\n", prefixVar, prefixVar)
+			} else {
+				contents += fmt.Sprintf("\tctx.AddRequestHeader(\"_topic_%s\", %s)\n", prefixVar, prefixVar)
+			}
+		}
+		contents += fmt.Sprintf("\tprefix := %s\n", generatePrefixStringTemplate(scope))
+		contents += fmt.Sprintf("\ttopic := fmt.Sprintf(\"%%s%s%%s%s\", prefix, delimiter)\n", scopeTitle, op.Name)
+		contents += fmt.Sprintf("\treturn p.publish(%s{Ctx: ctx, Topic: topic, Op: \"%s\", Req: req})\n", pubName, op.Name)
+		contents += "}\n\n"
+	}
+
+	contents += fmt.Sprintf("func (p *%s) publish(publication %s) error {\n", fakeName, pubName)
+	contents += "\tp.mu.Lock()\n"
+	contents += "\tdefer p.mu.Unlock()\n"
+	contents += "\tif !p.open {\n"
+	contents += fmt.Sprintf("\t\treturn thrift.NewTTransportException(frugal.TRANSPORT_EXCEPTION_NOT_OPEN, \"%s: publisher not open\")\n", fakeName)
+	contents += "\t}\n"
+	contents += "\tp.publications = append(p.publications, publication)\n"
+	contents += "\treturn nil\n"
+	contents += "}\n\n"
+
+	contents += "// Publications returns the publishes to the given topic in the order they\n"
+	contents += "// were made.\n"
+	contents += fmt.Sprintf("func (p *%s) Publications(topic string) []%s {\n", fakeName, pubName)
+	contents += "\tp.mu.Lock()\n"
+	contents += "\tdefer p.mu.Unlock()\n"
+	contents += fmt.Sprintf("\tvar publications []%s\n", pubName)
+	contents += "\tfor _, publication := range p.publications {\n"
+	contents += "\t\tif publication.Topic == topic {\n"
+	contents += "\t\t\tpublications = append(publications, publication)\n"
+	contents += "\t\t}\n"
+	contents += "\t}\n"
+	contents += "\treturn publications\n"
+	contents += "}\n\n"
+
+	contents += "// Topics returns the topics published to in the order they were first\n"
+	contents += "// published to.\n"
+	contents += fmt.Sprintf("func (p *%s) Topics() []string {\n", fakeName)
+	contents += "\tp.mu.Lock()\n"
+	contents += "\tdefer p.mu.Unlock()\n"
+	contents += "\tvar topics []string\n"
+	contents += "\tseen := make(map[string]bool)\n"
+	contents += "\tfor _, publication := range p.publications {\n"
+	contents += "\t\tif !seen[publication.Topic] {\n"
+	contents += "\t\t\tseen[publication.Topic] = true\n"
+	contents += "\t\t\ttopics = append(topics, publication.Topic)\n"
+	contents += "\t\t}\n"
+	contents += "\t}\n"
+	contents += "\treturn topics\n"
+	contents += "}\n\n"
+
+	contents += "// Reset removes the recorded publishes.\n"
+	contents += fmt.Sprintf("func (p *%s) Reset() {\n", fakeName)
+	contents += "\tp.mu.Lock()\n"
+	contents += "\tdefer p.mu.Unlock()\n"
+	contents += "\tp.publications = nil\n"
+	contents += "}\n\n"
+	return contents
+}
+
+// generateSubscriberMock generates a mock implementation of the subscriber
+// interfaces. The recorded calls contain the handlers, so tests can deliver
+// messages to them.
+func (g *Generator) generateSubscriberMock(scope *parser.Scope) string {
+	var (
+		scopeCamel = snakeToCamel(scope.Name)
+		mockName   = fmt.Sprintf("%sSubscriberMock", scopeCamel)
+		contents   = ""
+	)
+
+	type mockMethod struct {
+		name   string
+		params []mockParam
+	}
+	methods := []mockMethod{}
+	for _, op := range scope.Operations {
+		reqType := g.getGoTypeFromThriftType(op.Type)
+		params := g.prefixParams(scope)
+		methods = append(methods, mockMethod{"Subscribe" + op.Name,
+			append(params, mockParam{"handler", fmt.Sprintf("func(%s, %s)", g.contextType(), reqType)})})
+		params = g.prefixParams(scope)
+		methods = append(methods, mockMethod{"Subscribe" + op.Name + "Errorable",
+			append(params, mockParam{"handler", fmt.Sprintf("func(%s, %s) error", g.contextType(), reqType)})})
+	}
+
+	contents += fmt.Sprintf("// %s is a mock implementation of %sSubscriber and\n", mockName, scopeCamel)
+	contents += fmt.Sprintf("// %sErrorableSubscriber for tests. Calls are recorded, including the\n", scopeCamel)
+	contents += "// handlers, and answered by the func of the method, if set, and otherwise with\n"
+	contents += "// a nil FSubscription. Funcs should be set before the mock is used.\n"
+	contents += fmt.Sprintf("type %s struct {\n", mockName)
+	for _, method := range methods {
+		contents += fmt.Sprintf("\t%sFunc func(%s) (*frugal.FSubscription, error)\n", method.name, generateMockParams(method.params))
+	}
+	contents += "\n"
+	contents += "\tmu sync.Mutex\n"
+	for _, method := range methods {
+		contents += fmt.Sprintf("\tcalls%s []%sSubscriber%sCall\n", method.name, scopeCamel, method.name)
+	}
+	contents += "}\n\n"
+	contents += fmt.Sprintf("var _ %sSubscriber = (*%s)(nil)\n", scopeCamel, mockName)
+	contents += fmt.Sprintf("var _ %sErrorableSubscriber = (*%s)(nil)\n\n", scopeCamel, mockName)
+
+	for _, method := range methods {
+		contents += g.generateMockMethod(mockName, scopeCamel+"Subscriber"+method.name+"Call", method.name,
+			method.params, "(*frugal.FSubscription, error)", "nil, nil")
+	}
+	return contents
+}
+
+// prefixParams returns the parameters of the prefix variables of a scope.
+func (g *Generator) prefixParams(scope *parser.Scope) []mockParam {
+	params := []mockParam{}
+	for _, variable := range scope.Prefix.Variables {
+		params = append(params, mockParam{variable, "string"})
+	}
+	return params
+}
+
+// publishParams returns the parameters of the publish method of an
+// operation.
+func (g *Generator) publishParams(scope *parser.Scope, op *parser.Operation) []mockParam {
+	params := []mockParam{{"ctx", g.contextType()}}
+	params = append(params, g.prefixParams(scope)...)
+	return append(params, mockParam{"req", g.getGoTypeFromThriftType(op.Type)})
+}
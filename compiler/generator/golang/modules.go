@@ -0,0 +1,353 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package golang
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/Workiva/frugal/compiler/generator"
+	"github.com/Workiva/frugal/compiler/globals"
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+var goModModuleRegexp = regexp.MustCompile(`(?m)^\s*module\s+("[^"]+"|\S+)`)
+
+// goModule is a module given by a go.mod file.
+type goModule struct {
+	path string // The module path
+	dir  string // The directory containing the go.mod
+}
+
+// findGoModule returns the module of the nearest go.mod in the directory or
+// its parents.
+func findGoModule(dir string) (*goModule, error) {
+	for {
+		contents, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			modulePath, err := parseGoModPath(contents)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %s", filepath.Join(dir, "go.mod"), err)
+			}
+			return &goModule{path: modulePath, dir: dir}, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, fmt.Errorf("No go.mod found in %s or its parents", dir)
+		}
+		dir = parent
+	}
+}
+
+// parseGoModPath returns the module path declared by the contents of a
+// go.mod.
+func parseGoModPath(contents []byte) (string, error) {
+	match := goModModuleRegexp.FindSubmatch(contents)
+	if match == nil {
+		return "", fmt.Errorf("missing module declaration")
+	}
+	modulePath := string(match[1])
+	if strings.HasPrefix(modulePath, `"`) {
+		unquoted, err := strconv.Unquote(modulePath)
+		if err != nil {
+			return "", fmt.Errorf("invalid module path %s", modulePath)
+		}
+		modulePath = unquoted
+	}
+	return modulePath, nil
+}
+
+// packageConfig is the YAML file given by the package_map option which maps
+// the namespaces of Frugal files, or the names of files without one, to the
+// import paths of their packages, such as:
+//
+//	packages:
+//	  music.v1: github.com/acme/music/gen/musicv1
+type packageConfig struct {
+	Packages map[string]string `yaml:"packages"`
+}
+
+// loadPackageConfig reads a packageConfig from the given YAML file.
+func loadPackageConfig(file string) (*packageConfig, error) {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	config := &packageConfig{}
+	if err := yaml.Unmarshal(contents, config); err != nil {
+		return nil, fmt.Errorf("Invalid package map %s: %s", file, err)
+	}
+	for namespace, importPath := range config.Packages {
+		if importPath == "" || strings.ContainsAny(importPath, " \t\\") || strings.HasSuffix(importPath, "/") {
+			return nil, fmt.Errorf("Invalid package map %s: invalid import path \"%s\" for %s",
+				file, importPath, namespace)
+		}
+	}
+	return config, nil
+}
+
+// packageResolver resolves the import paths and output directories of the
+// packages generated for Frugal files.
+type packageResolver struct {
+	out      string            // The output directory given to the compiler
+	prefix   string            // The package_prefix option
+	module   *goModule         // Set by the module option
+	packages map[string]string // Set by the package_map option
+}
+
+// newPackageResolver returns a packageResolver for the given options.
+func newPackageResolver(options map[string]string) (*packageResolver, error) {
+	resolver := &packageResolver{
+		out:      globals.Out,
+		prefix:   options[packagePrefixOption],
+		packages: make(map[string]string),
+	}
+	if resolver.out == "" {
+		resolver.out = defaultOutputDir
+	}
+
+	if file, ok := options[packageMapOption]; ok {
+		if file == "" {
+			return nil, fmt.Errorf("The %s option requires a file", packageMapOption)
+		}
+		config, err := loadPackageConfig(file)
+		if err != nil {
+			return nil, err
+		}
+		resolver.packages = config.Packages
+	}
+
+	if _, ok := options[moduleOption]; ok {
+		if resolver.prefix != "" {
+			return nil, fmt.Errorf("The %s option can't be used with %s", packagePrefixOption, moduleOption)
+		}
+		out, err := filepath.Abs(resolver.out)
+		if err != nil {
+			return nil, err
+		}
+		module, err := findGoModule(out)
+		if err != nil {
+			return nil, err
+		}
+		if rel, err := filepath.Rel(module.dir, out); err != nil || strings.HasPrefix(rel, "..") {
+			return nil, fmt.Errorf("Output directory %s is outside of module %s in %s", out, module.path, module.dir)
+		}
+		resolver.module = module
+	}
+	return resolver, nil
+}
+
+// packageKey returns the key of the package generated for the Frugal, which is
+// its Go namespace or, if it has none, its name.
+func packageKey(f *parser.Frugal) string {
+	if namespace := f.Namespace(lang); namespace != nil {
+		return namespace.Value
+	}
+	return f.Name
+}
+
+// isMapped indicates if the package_map option gives the import path of the
+// package generated for the Frugal.
+func (r *packageResolver) isMapped(f *parser.Frugal) bool {
+	_, ok := r.packages[packageKey(f)]
+	return ok
+}
+
+// importPath returns the import path of the package generated for the Frugal.
+func (r *packageResolver) importPath(f *parser.Frugal) string {
+	key := packageKey(f)
+	if importPath, ok := r.packages[key]; ok {
+		return importPath
+	}
+	if r.module != nil {
+		out, _ := filepath.Abs(r.out)
+		rel, _ := filepath.Rel(r.module.dir, out)
+		return path.Join(r.module.path, filepath.ToSlash(rel), includeNameToImport(key))
+	}
+	return r.prefix + includeNameToImport(key)
+}
+
+// moduleDir returns the directory of the module the package generated for the
+// Frugal belongs to given its import path, if it's mapped to a package of the
+// module given by the module option.
+func (r *packageResolver) moduleDir(f *parser.Frugal) (string, bool) {
+	importPath, ok := r.packages[packageKey(f)]
+	if !ok || r.module == nil {
+		return "", false
+	}
+	if importPath == r.module.path {
+		return r.module.dir, true
+	}
+	if !strings.HasPrefix(importPath, r.module.path+"/") {
+		return "", false
+	}
+	rel := strings.TrimPrefix(importPath, r.module.path+"/")
+	return filepath.Join(r.module.dir, filepath.FromSlash(rel)), true
+}
+
+// packageDir returns the directory the package generated for the Frugal is
+// written to under the given output directory.
+func (r *packageResolver) packageDir(out string, f *parser.Frugal) string {
+	if dir, ok := r.moduleDir(f); ok {
+		return dir
+	}
+	if namespace := f.Namespace(lang); namespace != nil {
+		return filepath.Join(append([]string{out}, generator.GetPackageComponents(namespace.Value)...)...)
+	}
+	return filepath.Join(out, f.Name)
+}
+
+// resolver returns the packageResolver for the options of the Generator.
+func (g *Generator) resolver() (*packageResolver, error) {
+	if g.packages == nil {
+		resolver, err := newPackageResolver(g.Options)
+		if err != nil {
+			return nil, err
+		}
+		g.packages = resolver
+	}
+	return g.packages, nil
+}
+
+// checkImportCycles returns an error if the package generated for the Frugal
+// imports itself through the packages generated for its includes, which go
+// build doesn't allow.
+func (r *packageResolver) checkImportCycles(f *parser.Frugal, useVendor bool) error {
+	root := r.importPath(f)
+	visited := map[string]bool{f.File: true}
+	var visit func(*parser.Frugal, []*parser.Frugal) error
+	visit = func(from *parser.Frugal, trail []*parser.Frugal) error {
+		for _, include := range from.OrderedIncludes() {
+			if _, vendored := include.Annotations.Vendor(); vendored && useVendor {
+				continue
+			}
+			included, ok := from.ParsedIncludes[filepath.Base(include.Name)]
+			if !ok {
+				continue
+			}
+			cycle := append(trail[:len(trail):len(trail)], included)
+			if r.importPath(included) == root {
+				return r.importCycleError(cycle)
+			}
+			if visited[included.File] {
+				continue
+			}
+			visited[included.File] = true
+			if err := visit(included, cycle); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return visit(f, []*parser.Frugal{f})
+}
+
+func (r *packageResolver) importCycleError(cycle []*parser.Frugal) error {
+	description := ""
+	for i, f := range cycle {
+		if i > 0 {
+			description += " imports "
+		}
+		description += fmt.Sprintf("%s (%s)", r.importPath(f), filepath.Base(f.File))
+	}
+	return fmt.Errorf("Import cycle between generated Go packages: %s", description)
+}
+
+// generateGoMod generates a go.mod declaring the package generated for the
+// Frugal as a module. The packages generated for its includes, including
+// those of includes of includes since replacements only apply to the main
+// module, are required and replaced by their directories, so they must be
+// generated with the option too.
+func (g *Generator) generateGoMod(r *packageResolver, dir string) error {
+	contents := fmt.Sprintf("// Autogenerated by Frugal Compiler (%s)\n", globals.Version)
+	contents += "// DO NOT EDIT UNLESS YOU ARE SURE THAT YOU KNOW WHAT YOU ARE DOING\n\n"
+	contents += fmt.Sprintf("module %s\n", r.importPath(g.Frugal))
+
+	replacements := make(map[string]string)
+	visited := map[string]bool{g.Frugal.File: true}
+	var visit func(*parser.Frugal) error
+	visit = func(from *parser.Frugal) error {
+		for _, include := range from.OrderedIncludes() {
+			if _, vendored := include.Annotations.Vendor(); vendored && g.UseVendor() {
+				continue
+			}
+			included, ok := from.ParsedIncludes[filepath.Base(include.Name)]
+			if !ok || visited[included.File] {
+				continue
+			}
+			visited[included.File] = true
+			rel, err := filepath.Rel(dir, r.packageDir(r.out, included))
+			if err != nil {
+				return err
+			}
+			if !strings.HasPrefix(rel, ".") {
+				rel = "./" + rel
+			}
+			if importPath := r.importPath(included); importPath != r.importPath(g.Frugal) {
+				replacements[importPath] = filepath.ToSlash(rel)
+			}
+			if err := visit(included); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := visit(g.Frugal); err != nil {
+		return err
+	}
+	if len(replacements) > 0 {
+		importPaths := make([]string, 0, len(replacements))
+		for importPath := range replacements {
+			importPaths = append(importPaths, importPath)
+		}
+		sort.Strings(importPaths)
+		contents += "\nrequire (\n"
+		for _, importPath := range importPaths {
+			contents += fmt.Sprintf("\t%s v0.0.0\n", importPath)
+		}
+		contents += ")\n\n"
+		for _, importPath := range importPaths {
+			contents += fmt.Sprintf("replace %s => %s\n", importPath, replacements[importPath])
+		}
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(contents), 0644)
+}
+
+// generateDocGo generates a doc.go with the package documentation of the
+// package generated for the Frugal.
+func (g *Generator) generateDocGo(dir string) error {
+	contents := fmt.Sprintf("// Autogenerated by Frugal Compiler (%s)\n", globals.Version)
+	contents += "// DO NOT EDIT UNLESS YOU ARE SURE THAT YOU KNOW WHAT YOU ARE DOING\n\n"
+	if g.Frugal.Namespace(lang) != nil {
+		contents += fmt.Sprintf("// Package %s contains the code generated by the Frugal compiler for the\n", g.packageName())
+		contents += fmt.Sprintf("// %s namespace.\n", packageKey(g.Frugal))
+	} else {
+		contents += fmt.Sprintf("// Package %s contains the code generated by the Frugal compiler for\n", g.packageName())
+		contents += fmt.Sprintf("// %s.\n", filepath.Base(g.Frugal.File))
+	}
+	contents += fmt.Sprintf("package %s\n", g.packageName())
+	return ioutil.WriteFile(filepath.Join(dir, "doc.go"), []byte(contents), 0644)
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package golang
+
+import (
+	"fmt"
+
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+func (g *Generator) generateMultiplex() bool {
+	_, ok := g.Options[multiplexOption]
+	return ok
+}
+
+// generateMultiplexedClient generates a constructor of clients which qualify
+// method names by the name of the service, so their requests are routed by
+// an FMultiplexedProcessor.
+func (g *Generator) generateMultiplexedClient(service *parser.Service) string {
+	servTitle := snakeToCamel(service.Name)
+
+	contents := fmt.Sprintf("// NewF%sMultiplexedClient returns an F%sClient whose requests are routed\n", servTitle, servTitle)
+	contents += fmt.Sprintf("// to the processor registered by RegisterF%sProcessor.\n", servTitle)
+	contents += fmt.Sprintf(
+		"func NewF%sMultiplexedClient(provider *frugal.FServiceProvider, middleware ...frugal.ServiceMiddleware) *F%sClient {\n",
+		servTitle, servTitle)
+	contents += fmt.Sprintf("\treturn NewF%sClient(frugal.NewFMultiplexedServiceProvider(provider, \"%s\"), middleware...)\n",
+		servTitle, service.Name)
+	contents += "}\n\n"
+	return contents
+}
+
+// generateRegisterProcessor generates a function registering a processor of
+// the service with an FMultiplexedProcessor under the name of the service.
+func (g *Generator) generateRegisterProcessor(service *parser.Service) string {
+	servTitle := snakeToCamel(service.Name)
+
+	contents := fmt.Sprintf("// RegisterF%sProcessor registers an F%sProcessor for the handler with the\n", servTitle, servTitle)
+	contents += fmt.Sprintf("// FMultiplexedProcessor under the %s service name.\n", service.Name)
+	contents += fmt.Sprintf(
+		"func RegisterF%sProcessor(processor *frugal.FMultiplexedProcessor, handler F%s, middleware ...frugal.ServiceMiddleware) error {\n",
+		servTitle, servTitle)
+	contents += fmt.Sprintf("\treturn processor.RegisterProcessor(\"%s\", NewF%sProcessor(handler, middleware...))\n",
+		service.Name, servTitle)
+	contents += "}\n\n"
+	return contents
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package golang
+
+import (
+	"github.com/Workiva/frugal/compiler/generator"
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+// Names names the Go code generated for a Frugal as it's referred to from
+// another package, so other generators can produce Go which uses it.
+type Names struct {
+	g *Generator
+}
+
+// NewNames returns the Names of the Go code generated for the given Frugal.
+func NewNames(f *parser.Frugal) *Names {
+	g := &Generator{BaseGenerator: &generator.BaseGenerator{Options: map[string]string{}}}
+	g.SetFrugal(f)
+	g.localPackage = PackageName(f)
+	return &Names{g: g}
+}
+
+// PackageName returns the name of the Go package generated for the Frugal.
+func PackageName(f *parser.Frugal) string {
+	if namespace := f.Namespace(lang); namespace != nil {
+		return includeNameToReference(namespace.Value)
+	}
+	return f.Name
+}
+
+// ImportPath returns the import path of the Go package generated for the
+// Frugal with the given package_prefix.
+func ImportPath(f *parser.Frugal, packagePrefix string) string {
+	if namespace := f.Namespace(lang); namespace != nil {
+		return packagePrefix + includeNameToImport(namespace.Value)
+	}
+	return packagePrefix + includeNameToImport(f.Name)
+}
+
+// Type returns the Go type of the given type used in the Frugal.
+func (n *Names) Type(t *parser.Type) string {
+	return n.g.getGoTypeFromThriftType(t)
+}
+
+// Struct returns the Go type generated for the given struct, union, or
+// exception declared in the Frugal.
+func (n *Names) Struct(s *parser.Struct) string {
+	return n.g.localPackage + "." + title(s.Name)
+}
+
+// Constructor returns the function which creates the given struct, union, or
+// exception declared in the Frugal with its defaults set.
+func (n *Names) Constructor(s *parser.Struct) string {
+	return n.g.localPackage + ".New" + title(s.Name)
+}
+
+// Field returns the name of the Go field for the given struct field.
+func (n *Names) Field(field *parser.Field) string {
+	return title(field.Name)
+}
+
+// IsPointerField indicates if the Go field for the given struct field is a
+// pointer.
+func (n *Names) IsPointerField(field *parser.Field) bool {
+	return n.g.isPointerField(field)
+}
+
+// Service returns the Go interface handlers of the given service implement.
+func (n *Names) Service(service *parser.Service) string {
+	return n.g.localPackage + ".F" + snakeToCamel(service.Name)
+}
+
+// Method returns the name of the Go method for the given service method.
+func (n *Names) Method(method *parser.Method) string {
+	return snakeToCamel(method.Name)
+}
+
+// Args returns the Go type generated for the arguments of the given method of
+// a service declared in the Frugal.
+func (n *Names) Args(service *parser.Service, method *parser.Method) string {
+	return n.g.localPackage + "." + titleServiceName(method.Name+"_args", service.Name)
+}
+
+// ArgsConstructor returns the function which creates the arguments of the
+// given method of a service declared in the Frugal.
+func (n *Names) ArgsConstructor(service *parser.Service, method *parser.Method) string {
+	return n.g.localPackage + ".New" + titleServiceName(method.Name+"_args", service.Name)
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package golang
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/Workiva/frugal/compiler/descriptor"
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+// reflectionMethod is the name of the method served by processors generated
+// with the reflection option, which is frugal.ReflectionMethod.
+const reflectionMethod = "reflection"
+
+func (g *Generator) generateReflection() bool {
+	_, ok := g.Options[reflectionOption]
+	return ok
+}
+
+// checkReflectionMethods returns an error if a service declares a method with
+// the name of the reflection method.
+func (g *Generator) checkReflectionMethods() error {
+	for _, service := range g.Frugal.Services {
+		for _, method := range service.Methods {
+			if parser.LowercaseFirstLetter(method.Name) == reflectionMethod {
+				return fmt.Errorf("Method %s of service %s conflicts with the method generated by the %s option",
+					method.Name, service.Name, reflectionOption)
+			}
+		}
+	}
+	return nil
+}
+
+// generateServiceDescriptor generates a constant holding the JSON encoded
+// frugal.FServiceDescriptor of the service.
+func (g *Generator) generateServiceDescriptor(service *parser.Service) string {
+	encoded, err := json.Marshal(descriptor.NewService(service))
+	if err != nil {
+		panic(err)
+	}
+	contents := fmt.Sprintf("// %s is the JSON encoded frugal.FServiceDescriptor of\n", serviceDescriptorName(service))
+	contents += fmt.Sprintf("// %s.\n", service.Name)
+	contents += fmt.Sprintf("const %s = %s\n\n", serviceDescriptorName(service), strconv.Quote(string(encoded)))
+	return contents
+}
+
+func serviceDescriptorName(service *parser.Service) string {
+	return fmt.Sprintf("f%sServiceDescriptor", snakeToCamel(service.Name))
+}
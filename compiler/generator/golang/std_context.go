@@ -0,0 +1,76 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package golang
+
+func (g *Generator) generateStdContext() bool {
+	_, ok := g.Options[stdContextOption]
+	return ok
+}
+
+// contextType returns the type of the context taken by generated clients,
+// handlers, publishers, and subscriber handlers.
+func (g *Generator) contextType() string {
+	if g.generateStdContext() {
+		return "context.Context"
+	}
+	return "frugal.FContext"
+}
+
+// outgoingContext returns the FContext a request or publish made with the
+// context ctx is sent with. The FContext is passed on to middleware.
+func (g *Generator) outgoingContext() string {
+	if g.generateStdContext() {
+		return "frugal.ToFContext(ctx)"
+	}
+	return "ctx"
+}
+
+// incomingContext returns the context a subscriber handler is called with for
+// a message received with the FContext ctx.
+func (g *Generator) incomingContext() string {
+	if g.generateStdContext() {
+		return "frugal.WithFContext(context.Background(), ctx)"
+	}
+	return "ctx"
+}
+
+// handlerContext returns the context a service handler is called with for a
+// request received with the FContext ctx. With the std_context option, its
+// deadline is the timeout of the request, see generateHandlerDeadline.
+func (g *Generator) handlerContext() string {
+	if g.generateStdContext() {
+		return "frugal.WithFContext(hctx, ctx)"
+	}
+	return "ctx"
+}
+
+// generateHandlerDeadline generates the context.Context, hctx, the context of
+// a service handler derives from when generating with the std_context option,
+// which times out with the request. The generated cancel must be called once
+// the handler returns, see generateHandlerCancel.
+func (g *Generator) generateHandlerDeadline(prefix string) string {
+	if !g.generateStdContext() {
+		return ""
+	}
+	return prefix + "hctx, cancel := context.WithTimeout(context.Background(), ctx.Timeout())\n"
+}
+
+// generateHandlerCancel generates the cancel of the context.Context declared by
+// generateHandlerDeadline.
+func (g *Generator) generateHandlerCancel(prefix string) string {
+	if !g.generateStdContext() {
+		return ""
+	}
+	return prefix + "cancel()\n"
+}
@@ -0,0 +1,325 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package golang
+
+import (
+	"fmt"
+
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+func (g *Generator) generateStructHelpers() bool {
+	_, ok := g.Options[structHelpersOption]
+	return ok
+}
+
+// generateEquals generates a method comparing the fields of two structs.
+// Optional fields are only equal if both or neither are set, and structs in
+// sets and map keys are compared by value rather than by pointer.
+func (g *Generator) generateEquals(s *parser.Struct, sName string) string {
+	contents := ""
+
+	contents += fmt.Sprintf("func (p *%s) Equals(other *%s) bool {\n", sName, sName)
+	contents += "\tif p == other {\n"
+	contents += "\t\treturn true\n"
+	contents += "\t}\n"
+	contents += "\tif p == nil || other == nil {\n"
+	contents += "\t\treturn false\n"
+	contents += "\t}\n"
+	for _, field := range s.Fields {
+		fName := title(field.Name)
+		underlyingType := g.Frugal.UnderlyingType(field.Type)
+		a, b := "p."+fName, "other."+fName
+		if g.isPointerField(field) && !g.Frugal.IsStruct(underlyingType) {
+			contents += fmt.Sprintf("\tif (%s == nil) != (%s == nil) {\n", a, b)
+			contents += "\t\treturn false\n"
+			contents += "\t}\n"
+			contents += fmt.Sprintf("\tif %s != nil {\n", a)
+			if underlyingType.IsContainer() {
+				// Dereference containers once so they can be indexed
+				elemA, elemB := g.GetElem(), g.GetElem()
+				contents += fmt.Sprintf("\t\t%s, %s := *%s, *%s\n", elemA, elemB, a, b)
+				contents += g.generateEqualsRec(underlyingType, elemA, elemB, "\t\t")
+			} else {
+				contents += g.generateEqualsRec(underlyingType, "*"+a, "*"+b, "\t\t")
+			}
+			contents += "\t}\n"
+			continue
+		}
+		if field.Modifier == parser.Optional && (underlyingType.IsContainer() || underlyingType.Name == "binary") {
+			// Unset optional containers and binary are nil, which would
+			// otherwise be equal to empty ones
+			contents += fmt.Sprintf("\tif (%s == nil) != (%s == nil) {\n", a, b)
+			contents += "\t\treturn false\n"
+			contents += "\t}\n"
+		}
+		contents += g.generateEqualsRec(underlyingType, a, b, "\t")
+	}
+	contents += "\treturn true\n"
+	contents += "}\n\n"
+	return contents
+}
+
+// generateEqualsRec generates statements which return false if a and b, of
+// the given type, aren't equal.
+func (g *Generator) generateEqualsRec(t *parser.Type, a, b, indent string) string {
+	underlyingType := g.Frugal.UnderlyingType(t)
+	contents := ""
+
+	if g.Frugal.IsStruct(underlyingType) {
+		contents += fmt.Sprintf("%sif !%s.Equals(%s) {\n", indent, a, b)
+		contents += indent + "\treturn false\n"
+		contents += indent + "}\n"
+		return contents
+	}
+
+	switch underlyingType.Name {
+	case "binary":
+		contents += fmt.Sprintf("%sif !bytes.Equal(%s, %s) {\n", indent, a, b)
+		contents += indent + "\treturn false\n"
+		contents += indent + "}\n"
+	case "list":
+		contents += fmt.Sprintf("%sif len(%s) != len(%s) {\n", indent, a, b)
+		contents += indent + "\treturn false\n"
+		contents += indent + "}\n"
+		i := g.GetElem()
+		contents += fmt.Sprintf("%sfor %s := range %s {\n", indent, i, a)
+		contents += g.generateEqualsRec(underlyingType.ValueType, fmt.Sprintf("%s[%s]", a, i), fmt.Sprintf("%s[%s]", b, i), indent+"\t")
+		contents += indent + "}\n"
+	case "set":
+		contents += fmt.Sprintf("%sif len(%s) != len(%s) {\n", indent, a, b)
+		contents += indent + "\treturn false\n"
+		contents += indent + "}\n"
+		elemA := g.GetElem()
+		contents += fmt.Sprintf("%sfor %s := range %s {\n", indent, elemA, a)
+		if g.Frugal.IsStruct(g.Frugal.UnderlyingType(underlyingType.ValueType)) {
+			// Struct elements are pointers, so look for an equal one
+			elemB := g.GetElem()
+			contents += indent + "\tfound := false\n"
+			contents += fmt.Sprintf("%s\tfor %s := range %s {\n", indent, elemB, b)
+			contents += fmt.Sprintf("%s\t\tif %s.Equals(%s) {\n", indent, elemA, elemB)
+			contents += indent + "\t\t\tfound = true\n"
+			contents += indent + "\t\t\tbreak\n"
+			contents += indent + "\t\t}\n"
+			contents += indent + "\t}\n"
+			contents += indent + "\tif !found {\n"
+		} else {
+			contents += fmt.Sprintf("%s\tif _, ok := %s[%s]; !ok {\n", indent, b, elemA)
+		}
+		contents += indent + "\t\treturn false\n"
+		contents += indent + "\t}\n"
+		contents += indent + "}\n"
+	case "map":
+		contents += fmt.Sprintf("%sif len(%s) != len(%s) {\n", indent, a, b)
+		contents += indent + "\treturn false\n"
+		contents += indent + "}\n"
+		keyA, valA := g.GetElem(), g.GetElem()
+		contents += fmt.Sprintf("%sfor %s, %s := range %s {\n", indent, keyA, valA, a)
+		if g.Frugal.IsStruct(g.Frugal.UnderlyingType(underlyingType.KeyType)) {
+			// Struct keys are pointers, so look for an equal one
+			keyB, valB := g.GetElem(), g.GetElem()
+			contents += indent + "\tfound := false\n"
+			contents += fmt.Sprintf("%s\tfor %s, %s := range %s {\n", indent, keyB, valB, b)
+			contents += fmt.Sprintf("%s\t\tif !%s.Equals(%s) {\n", indent, keyA, keyB)
+			contents += indent + "\t\t\tcontinue\n"
+			contents += indent + "\t\t}\n"
+			contents += g.generateEqualsRec(underlyingType.ValueType, valA, valB, indent+"\t\t")
+			contents += indent + "\t\tfound = true\n"
+			contents += indent + "\t\tbreak\n"
+			contents += indent + "\t}\n"
+			contents += indent + "\tif !found {\n"
+			contents += indent + "\t\treturn false\n"
+			contents += indent + "\t}\n"
+		} else {
+			valB := g.GetElem()
+			contents += fmt.Sprintf("%s\t%s, ok := %s[%s]\n", indent, valB, b, keyA)
+			contents += indent + "\tif !ok {\n"
+			contents += indent + "\t\treturn false\n"
+			contents += indent + "\t}\n"
+			contents += g.generateEqualsRec(underlyingType.ValueType, valA, valB, indent+"\t")
+		}
+		contents += indent + "}\n"
+	default:
+		contents += fmt.Sprintf("%sif %s != %s {\n", indent, a, b)
+		contents += indent + "\treturn false\n"
+		contents += indent + "}\n"
+	}
+	return contents
+}
+
+// generateDeepCopy generates a method returning a copy of a struct which
+// shares no pointers, slices, or maps with it.
+func (g *Generator) generateDeepCopy(s *parser.Struct, sName string) string {
+	contents := ""
+
+	contents += fmt.Sprintf("func (p *%s) DeepCopy() *%s {\n", sName, sName)
+	contents += "\tif p == nil {\n"
+	contents += "\t\treturn nil\n"
+	contents += "\t}\n"
+	contents += "\tc := *p\n"
+	for _, field := range s.Fields {
+		contents += g.generateCopyField(field, "p", "c", "\t")
+	}
+	contents += "\treturn &c\n"
+	contents += "}\n\n"
+	return contents
+}
+
+// generateCopyField generates statements replacing the given field of dst,
+// a shallow copy of src, with a deep copy.
+func (g *Generator) generateCopyField(field *parser.Field, src, dst, indent string) string {
+	fName := title(field.Name)
+	underlyingType := g.Frugal.UnderlyingType(field.Type)
+	from, to := src+"."+fName, dst+"."+fName
+	contents := ""
+
+	if g.Frugal.IsStruct(underlyingType) {
+		return fmt.Sprintf("%s%s = %s.DeepCopy()\n", indent, to, from)
+	}
+	if g.isPointerField(field) {
+		elem := g.GetElem()
+		contents += fmt.Sprintf("%sif %s != nil {\n", indent, from)
+		if g.needsDeepCopy(underlyingType) {
+			orig := g.GetElem()
+			contents += fmt.Sprintf("%s\t%s := *%s\n", indent, orig, from)
+			contents += fmt.Sprintf("%s\tvar %s %s\n", indent, elem, g.getGoTypeFromThriftType(field.Type))
+			contents += g.generateCopyRec(field.Type, orig, elem, indent+"\t")
+		} else {
+			contents += fmt.Sprintf("%s\t%s := *%s\n", indent, elem, from)
+		}
+		contents += fmt.Sprintf("%s\t%s = &%s\n", indent, to, elem)
+		contents += indent + "}\n"
+		return contents
+	}
+	if g.needsDeepCopy(underlyingType) {
+		contents += g.generateCopyRec(field.Type, from, to, indent)
+	}
+	return contents
+}
+
+// generateCopyRec generates statements assigning a deep copy of src, of the
+// given type, to dst. Nil slices and maps stay nil.
+func (g *Generator) generateCopyRec(t *parser.Type, src, dst, indent string) string {
+	underlyingType := g.Frugal.UnderlyingType(t)
+	goType := g.getGoTypeFromThriftType(t)
+	contents := ""
+
+	if g.Frugal.IsStruct(underlyingType) {
+		return fmt.Sprintf("%s%s = %s.DeepCopy()\n", indent, dst, src)
+	}
+
+	switch underlyingType.Name {
+	case "binary":
+		contents += fmt.Sprintf("%sif %s != nil {\n", indent, src)
+		contents += fmt.Sprintf("%s\t%s = append(%s{}, %s...)\n", indent, dst, goType, src)
+		contents += indent + "}\n"
+	case "list":
+		contents += fmt.Sprintf("%sif %s != nil {\n", indent, src)
+		contents += fmt.Sprintf("%s\t%s = make(%s, len(%s))\n", indent, dst, goType, src)
+		if g.needsDeepCopy(g.Frugal.UnderlyingType(underlyingType.ValueType)) {
+			i, elem := g.GetElem(), g.GetElem()
+			contents += fmt.Sprintf("%s\tfor %s, %s := range %s {\n", indent, i, elem, src)
+			contents += g.generateCopyRec(underlyingType.ValueType, elem, fmt.Sprintf("%s[%s]", dst, i), indent+"\t\t")
+			contents += indent + "\t}\n"
+		} else {
+			contents += fmt.Sprintf("%s\tcopy(%s, %s)\n", indent, dst, src)
+		}
+		contents += indent + "}\n"
+	case "set":
+		elem := g.GetElem()
+		contents += fmt.Sprintf("%sif %s != nil {\n", indent, src)
+		contents += fmt.Sprintf("%s\t%s = make(%s, len(%s))\n", indent, dst, goType, src)
+		contents += fmt.Sprintf("%s\tfor %s := range %s {\n", indent, elem, src)
+		contents += fmt.Sprintf("%s\t\t%s[%s] = true\n", indent, dst, g.copyKey(underlyingType.ValueType, elem))
+		contents += indent + "\t}\n"
+		contents += indent + "}\n"
+	case "map":
+		key, val := g.GetElem(), g.GetElem()
+		contents += fmt.Sprintf("%sif %s != nil {\n", indent, src)
+		contents += fmt.Sprintf("%s\t%s = make(%s, len(%s))\n", indent, dst, goType, src)
+		contents += fmt.Sprintf("%s\tfor %s, %s := range %s {\n", indent, key, val, src)
+		valType := g.Frugal.UnderlyingType(underlyingType.ValueType)
+		if g.Frugal.IsStruct(valType) {
+			val += ".DeepCopy()"
+		} else if g.needsDeepCopy(valType) {
+			valCopy := g.GetElem()
+			contents += fmt.Sprintf("%s\t\tvar %s %s\n", indent, valCopy, g.getGoTypeFromThriftType(underlyingType.ValueType))
+			contents += g.generateCopyRec(underlyingType.ValueType, val, valCopy, indent+"\t\t")
+			val = valCopy
+		}
+		contents += fmt.Sprintf("%s\t\t%s[%s] = %s\n", indent, dst, g.copyKey(underlyingType.KeyType, key), val)
+		contents += indent + "\t}\n"
+		contents += indent + "}\n"
+	default:
+		contents += fmt.Sprintf("%s%s = %s\n", indent, dst, src)
+	}
+	return contents
+}
+
+// copyKey returns an expression copying the given set element or map key.
+// Only structs can be keys which need copying.
+func (g *Generator) copyKey(t *parser.Type, key string) string {
+	if g.Frugal.IsStruct(g.Frugal.UnderlyingType(t)) {
+		return key + ".DeepCopy()"
+	}
+	return key
+}
+
+// needsDeepCopy indicates if values of the given underlying type share memory
+// when assigned.
+func (g *Generator) needsDeepCopy(underlyingType *parser.Type) bool {
+	return g.Frugal.IsStruct(underlyingType) || underlyingType.IsContainer() || underlyingType.Name == "binary"
+}
+
+// generateFieldMask generates constants for the IDs of a struct's fields and
+// a mask of them, which can be used to request or apply partial updates.
+func (g *Generator) generateFieldMask(s *parser.Struct, sName string) string {
+	contents := ""
+	mName := sName + "FieldMask"
+
+	if len(s.Fields) > 0 {
+		contents += "const (\n"
+		for _, field := range s.Fields {
+			contents += fmt.Sprintf("\t%s_%s_FIELD_ID int16 = %d\n", sName, title(field.Name), field.ID)
+		}
+		contents += ")\n\n"
+	}
+
+	contents += fmt.Sprintf("// %s is a set of the IDs of %s fields. A set<i16> can be\n", mName, sName)
+	contents += "// converted to one.\n"
+	contents += fmt.Sprintf("type %s map[int16]bool\n\n", mName)
+
+	contents += fmt.Sprintf("func New%s(ids ...int16) %s {\n", mName, mName)
+	contents += fmt.Sprintf("\tm := make(%s, len(ids))\n", mName)
+	contents += "\tfor _, id := range ids {\n"
+	contents += "\t\tm[id] = true\n"
+	contents += "\t}\n"
+	contents += "\treturn m\n"
+	contents += "}\n\n"
+
+	contents += "// Apply sets the fields of dst in the mask to deep copies of those of src,\n"
+	contents += "// including unset optional fields.\n"
+	contents += fmt.Sprintf("func (m %s) Apply(dst, src *%s) {\n", mName, sName)
+	for _, field := range s.Fields {
+		fName := title(field.Name)
+		contents += fmt.Sprintf("\tif m[%s_%s_FIELD_ID] {\n", sName, fName)
+		if !g.Frugal.IsStruct(g.Frugal.UnderlyingType(field.Type)) {
+			contents += fmt.Sprintf("\t\tdst.%s = src.%s\n", fName, fName)
+		}
+		contents += g.generateCopyField(field, "src", "dst", "\t\t")
+		contents += "\t}\n"
+	}
+	contents += "}\n\n"
+	return contents
+}
@@ -0,0 +1,255 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package golang
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+func (g *Generator) generateValidate() bool {
+	_, ok := g.Options[validateOption]
+	return ok
+}
+
+// generateValidateMethod generates a Validate method checking the constraints
+// given by the validate annotations of the struct's fields and validating
+// nested structs.
+func (g *Generator) generateValidateMethod(s *parser.Struct, sName string) string {
+	contents := ""
+
+	for _, field := range s.Fields {
+		if pattern, ok := field.Constraint(parser.ConstraintPattern); ok {
+			contents += fmt.Sprintf("var %s_%s_PATTERN = regexp.MustCompile(%s)\n\n",
+				sName, title(field.Name), strconv.Quote(pattern.Value))
+		}
+	}
+
+	contents += fmt.Sprintf("func (p *%s) Validate() error {\n", sName)
+	for _, field := range s.Fields {
+		contents += g.generateValidateField(s, sName, field)
+	}
+	contents += "\treturn nil\n"
+	contents += "}\n\n"
+	return contents
+}
+
+func (g *Generator) generateValidateField(s *parser.Struct, sName string, field *parser.Field) string {
+	contents := ""
+	fName := title(field.Name)
+
+	if constraint, ok := field.Constraint(parser.ConstraintRequiredIf); ok {
+		if isSet := g.validateIsSet(field); isSet != "" {
+			condition, message := g.validateRequiredIfCondition(s, constraint)
+			contents += fmt.Sprintf("\tif %s && %s {\n", negateIsSet(isSet), condition)
+			contents += fmt.Sprintf("\t\treturn frugal.NewFValidationError(\"%s\", \"%s\", %s)\n",
+				field.Name, parser.ConstraintRequiredIf, strconv.Quote(message))
+			contents += "\t}\n"
+		}
+	}
+
+	value := "p." + fName
+	underlyingType := g.Frugal.UnderlyingType(field.Type)
+	if g.isPointerField(field) && !g.Frugal.IsStruct(underlyingType) {
+		value = "*" + value
+	}
+	checks := ""
+	for _, constraint := range field.Constraints() {
+		checks += g.generateValidateConstraint(sName, field, constraint, value)
+	}
+	checks += g.generateValidateRec(field.Type, value, field.Name, "\t")
+	if checks == "" {
+		return contents
+	}
+
+	// Struct fields are only validated if they aren't nil already
+	if field.Modifier == parser.Optional && !g.Frugal.IsStruct(underlyingType) {
+		contents += fmt.Sprintf("\tif p.IsSet%s() {\n", fName)
+		contents += indentLines(checks)
+		contents += "\t}\n"
+	} else {
+		contents += checks
+	}
+	return contents
+}
+
+// generateValidateConstraint generates a check of a constraint of the field
+// with the given value.
+func (g *Generator) generateValidateConstraint(sName string, field *parser.Field, constraint *parser.Constraint, value string) string {
+	underlyingType := g.Frugal.UnderlyingType(field.Type)
+	isTypedef := field.Type.Name != underlyingType.Name
+
+	condition, message := "", ""
+	switch constraint.Rule {
+	case parser.ConstraintMin:
+		condition = fmt.Sprintf("%s < %s", value, constraint.Value)
+		message = "must be at least " + constraint.Value
+	case parser.ConstraintMax:
+		condition = fmt.Sprintf("%s > %s", value, constraint.Value)
+		message = "must be at most " + constraint.Value
+	case parser.ConstraintMinLen, parser.ConstraintMaxLen:
+		length := fmt.Sprintf("len(%s)", value)
+		if underlyingType.Name == "string" {
+			if isTypedef {
+				value = "string(" + value + ")"
+			}
+			length = fmt.Sprintf("utf8.RuneCountInString(%s)", value)
+		}
+		if constraint.Rule == parser.ConstraintMinLen {
+			condition = fmt.Sprintf("%s < %s", length, constraint.Value)
+			message = "length must be at least " + constraint.Value
+		} else {
+			condition = fmt.Sprintf("%s > %s", length, constraint.Value)
+			message = "length must be at most " + constraint.Value
+		}
+	case parser.ConstraintPattern:
+		if isTypedef {
+			value = "string(" + value + ")"
+		}
+		condition = fmt.Sprintf("!%s_%s_PATTERN.MatchString(%s)", sName, title(field.Name), value)
+		message = "must match " + constraint.Value
+	default:
+		return ""
+	}
+
+	contents := fmt.Sprintf("\tif %s {\n", condition)
+	contents += fmt.Sprintf("\t\treturn frugal.NewFValidationError(\"%s\", \"%s\", %s)\n",
+		field.Name, constraint.Rule, strconv.Quote(message))
+	contents += "\t}\n"
+	return contents
+}
+
+// generateValidateRec generates statements validating the structs in the
+// given value, if any.
+func (g *Generator) generateValidateRec(t *parser.Type, value, name, indent string) string {
+	underlyingType := g.Frugal.UnderlyingType(t)
+	contents := ""
+
+	if g.Frugal.IsStruct(underlyingType) {
+		contents += fmt.Sprintf("%sif %s != nil {\n", indent, value)
+		contents += fmt.Sprintf("%s\tif err := %s.Validate(); err != nil {\n", indent, value)
+		contents += fmt.Sprintf("%s\t\treturn frugal.NestFValidationError(\"%s\", err)\n", indent, name)
+		contents += indent + "\t}\n"
+		contents += indent + "}\n"
+		return contents
+	}
+
+	switch underlyingType.Name {
+	case "list", "set":
+		elem := g.GetElem()
+		inner := g.generateValidateRec(underlyingType.ValueType, elem, name, indent+"\t")
+		if inner == "" {
+			return ""
+		}
+		if underlyingType.Name == "list" {
+			contents += fmt.Sprintf("%sfor _, %s := range %s {\n", indent, elem, value)
+		} else {
+			contents += fmt.Sprintf("%sfor %s := range %s {\n", indent, elem, value)
+		}
+		contents += inner
+		contents += indent + "}\n"
+	case "map":
+		key, val := g.GetElem(), g.GetElem()
+		keyContents := g.generateValidateRec(underlyingType.KeyType, key, name, indent+"\t")
+		valContents := g.generateValidateRec(underlyingType.ValueType, val, name, indent+"\t")
+		if keyContents == "" && valContents == "" {
+			return ""
+		}
+		if keyContents == "" {
+			key = "_"
+		}
+		if valContents == "" {
+			contents += fmt.Sprintf("%sfor %s := range %s {\n", indent, key, value)
+		} else {
+			contents += fmt.Sprintf("%sfor %s, %s := range %s {\n", indent, key, val, value)
+		}
+		contents += keyContents
+		contents += valContents
+		contents += indent + "}\n"
+	}
+	return contents
+}
+
+// validateIsSet returns an expression which is true if the field is set, or
+// an empty string if the field is always set. Optional arguments are default
+// fields in args structs, so the parser rejects constraints on them.
+func (g *Generator) validateIsSet(field *parser.Field) string {
+	fName := title(field.Name)
+	if field.Modifier == parser.Optional {
+		return fmt.Sprintf("p.IsSet%s()", fName)
+	}
+	underlyingType := g.Frugal.UnderlyingType(field.Type)
+	if g.Frugal.IsStruct(underlyingType) || underlyingType.IsContainer() || underlyingType.Name == "binary" {
+		return fmt.Sprintf("p.%s != nil", fName)
+	}
+	return ""
+}
+
+// validateRequiredIfCondition returns the condition under which a field with
+// the required_if constraint must be set and a description of it.
+func (g *Generator) validateRequiredIfCondition(s *parser.Struct, constraint *parser.Constraint) (string, string) {
+	name, literal, hasValue := constraint.RequiredIf()
+	var other *parser.Field
+	for _, field := range s.Fields {
+		if field.Name == name {
+			other = field
+		}
+	}
+	isSet := g.validateIsSet(other)
+	if !hasValue {
+		if isSet == "" {
+			isSet = "true"
+		}
+		return isSet, fmt.Sprintf("must be set when %s is set", name)
+	}
+
+	message := fmt.Sprintf("must be set when %s is %s", name, literal)
+	underlyingType := g.Frugal.UnderlyingType(other.Type)
+	switch {
+	case underlyingType.Name == "string":
+		literal = strconv.Quote(literal)
+	case g.Frugal.IsEnum(underlyingType):
+		literal = fmt.Sprintf("%s_%s", g.getGoTypeFromThriftType(underlyingType), literal)
+		if other.Type.Name != underlyingType.Name {
+			literal = fmt.Sprintf("%s(%s)", g.getGoTypeFromThriftType(other.Type), literal)
+		}
+	}
+	value := "p." + title(other.Name)
+	if g.isPointerField(other) {
+		return fmt.Sprintf("%s && *%s == %s", isSet, value, literal), message
+	}
+	return fmt.Sprintf("%s == %s", value, literal), message
+}
+
+// negateIsSet negates an expression returned by validateIsSet.
+func negateIsSet(isSet string) string {
+	if strings.HasSuffix(isSet, " != nil") {
+		return strings.TrimSuffix(isSet, " != nil") + " == nil"
+	}
+	return "!" + isSet
+}
+
+// indentLines indents each line of the given statements by a tab.
+func indentLines(contents string) string {
+	lines := strings.SplitAfter(contents, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = "\t" + line
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -146,8 +146,17 @@ func (g *Generator) generateModule(file *os.File, module *parser.Frugal) error {
 			if method.ReturnType != nil {
 				returnType = displayType(method.ReturnType, module)
 			}
-			display := fmt.Sprintf("%s %s(%s)", returnType, method.Name,
-				displayMethodArgs(method.Arguments, module))
+			if method.StreamingResponse {
+				returnType = template.HTML(fmt.Sprintf("stream&lt;%s&gt;", returnType))
+			}
+			args := displayMethodArgs(method.Arguments, module)
+			if stream := method.RequestStream; stream != nil {
+				if args != "" {
+					args += ", "
+				}
+				args += fmt.Sprintf("stream&lt;%s&gt; %s", displayType(stream.Type, module), stream.Name)
+			}
+			display := fmt.Sprintf("%s %s(%s)", returnType, method.Name, args)
 			throwsPrefix := "<br />    throws"
 			for _, exception := range method.Exceptions {
 				display += fmt.Sprintf("%s %s", throwsPrefix, displayType(exception.Type, module))
@@ -0,0 +1,445 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package model exports the parsed and resolved model of Frugal files as JSON
+// or YAML for tools which don't want to parse IDL themselves.
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/Workiva/frugal/compiler/generator"
+	"github.com/Workiva/frugal/compiler/globals"
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+const defaultOutputDir = "gen-json"
+
+// Generator implements the ProgramGenerator interface for the JSON model.
+type Generator struct {
+	yaml bool
+}
+
+// NewGenerator creates a new JSON model ProgramGenerator.
+func NewGenerator(options map[string]string) generator.ProgramGenerator {
+	_, yaml := options["yaml"]
+	return &Generator{yaml: yaml}
+}
+
+// Generate writes the model of the Frugal to <name>.json, or <name>.yaml with
+// the yaml option, in the output directory.
+func (g *Generator) Generate(frugal *parser.Frugal, outputDir string) error {
+	ext := "json"
+	if g.yaml {
+		ext = "yaml"
+	}
+	file, err := os.Create(fmt.Sprintf("%s/%s.%s", outputDir, frugal.Name, ext))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	doc := NewDocument(frugal)
+	if g.yaml {
+		out, err := yaml.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		_, err = file.Write(out)
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+func (g *Generator) GetOutputDir(dir string, frugal *parser.Frugal) string {
+	return dir
+}
+
+func (g *Generator) DefaultOutputDir() string {
+	return defaultOutputDir
+}
+
+func (g *Generator) UseVendor() bool {
+	return false
+}
+
+// NewDocument returns the model of the given Frugal and the files it
+// includes.
+func NewDocument(frugal *parser.Frugal) *Document {
+	b := &builder{dir: filepath.Dir(frugal.Path)}
+	includes := map[string]*parser.Frugal{}
+	b.collectIncludes(frugal, includes)
+	paths := make([]string, 0, len(includes))
+	for path := range includes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	doc := &Document{
+		SchemaVersion: SchemaVersion,
+		FrugalVersion: globals.Version,
+		File:          b.newFile(frugal),
+		Includes:      make([]*File, 0, len(paths)),
+	}
+	for _, path := range paths {
+		doc.Includes = append(doc.Includes, b.newFile(includes[path]))
+	}
+	return doc
+}
+
+// builder builds the model of a file and its includes.
+type builder struct {
+	dir string // The directory of the file the model is for
+}
+
+// collectIncludes adds every file included by the Frugal, directly or
+// transitively, to includes by path.
+func (b *builder) collectIncludes(frugal *parser.Frugal, includes map[string]*parser.Frugal) {
+	for _, include := range frugal.ParsedIncludes {
+		path := b.filePath(include)
+		if _, ok := includes[path]; ok {
+			continue
+		}
+		includes[path] = include
+		b.collectIncludes(include, includes)
+	}
+}
+
+func (b *builder) newFile(f *parser.Frugal) *File {
+	file := &File{
+		Name:       f.Name,
+		Path:       b.filePath(f),
+		Namespaces: make([]*Namespace, 0, len(f.Namespaces)),
+		Includes:   make([]*Include, 0, len(f.Includes)),
+		Constants:  make([]*Constant, 0, len(f.Constants)),
+		Typedefs:   make([]*Typedef, 0, len(f.Typedefs)),
+		Enums:      make([]*Enum, 0, len(f.Enums)),
+		Structs:    b.newStructs(f, f.Structs),
+		Unions:     b.newStructs(f, f.Unions),
+		Exceptions: b.newStructs(f, f.Exceptions),
+		Services:   make([]*Service, 0, len(f.Services)),
+		Scopes:     make([]*Scope, 0, len(f.Scopes)),
+	}
+
+	for _, namespace := range f.Namespaces {
+		file.Namespaces = append(file.Namespaces, &Namespace{
+			Scope:       namespace.Scope,
+			Value:       namespace.Value,
+			Annotations: newAnnotations(namespace.Annotations),
+			Pos:         newPos(namespace.Pos),
+		})
+	}
+
+	for _, include := range f.Includes {
+		resolved := ""
+		if parsed, ok := f.ParsedIncludes[include.Name]; ok {
+			resolved = b.filePath(parsed)
+		}
+		file.Includes = append(file.Includes, &Include{
+			Name:        include.Name,
+			Path:        include.Value,
+			File:        resolved,
+			Annotations: newAnnotations(include.Annotations),
+			Pos:         newPos(include.Pos),
+		})
+	}
+
+	for _, constant := range f.Constants {
+		file.Constants = append(file.Constants, &Constant{
+			Name:        constant.Name,
+			Doc:         newDoc(constant.Comment),
+			Type:        b.newType(f, constant.Type),
+			Value:       newValue(f, constant.Type, constant.Value),
+			Annotations: newAnnotations(constant.Annotations),
+			Pos:         newPos(constant.Pos),
+		})
+	}
+
+	for _, typedef := range f.Typedefs {
+		file.Typedefs = append(file.Typedefs, &Typedef{
+			Name:        typedef.Name,
+			Doc:         newDoc(typedef.Comment),
+			Type:        b.newType(f, typedef.Type),
+			Annotations: newAnnotations(typedef.Annotations),
+			Pos:         newPos(typedef.Pos),
+		})
+	}
+
+	for _, enum := range f.Enums {
+		values := make([]*EnumValue, 0, len(enum.Values))
+		for _, value := range enum.Values {
+			values = append(values, &EnumValue{
+				Name:        value.Name,
+				Doc:         newDoc(value.Comment),
+				Value:       value.Value,
+				Implicit:    value.Implicit,
+				Annotations: newAnnotations(value.Annotations),
+				Pos:         newPos(value.Pos),
+			})
+		}
+		file.Enums = append(file.Enums, &Enum{
+			Name:        enum.Name,
+			Doc:         newDoc(enum.Comment),
+			Values:      values,
+			Annotations: newAnnotations(enum.Annotations),
+			Pos:         newPos(enum.Pos),
+		})
+	}
+
+	for _, service := range f.Services {
+		methods := make([]*Method, 0, len(service.Methods))
+		for _, method := range service.Methods {
+			var requestStream *Field
+			if method.RequestStream != nil {
+				requestStream = b.newField(f, method.RequestStream)
+			}
+			methods = append(methods, &Method{
+				Name:              method.Name,
+				Doc:               newDoc(method.Comment),
+				Oneway:            method.Oneway,
+				StreamingResponse: method.StreamingResponse,
+				ReturnType:        b.newType(f, method.ReturnType),
+				Arguments:         b.newFields(f, method.Arguments),
+				RequestStream:     requestStream,
+				Exceptions:        b.newFields(f, method.Exceptions),
+				Annotations:       newAnnotations(method.Annotations),
+				Pos:               newPos(method.Pos),
+			})
+		}
+		file.Services = append(file.Services, &Service{
+			Name:        service.Name,
+			Doc:         newDoc(service.Comment),
+			Extends:     service.Extends,
+			Methods:     methods,
+			Annotations: newAnnotations(service.Annotations),
+			Pos:         newPos(service.Pos),
+		})
+	}
+
+	for _, scope := range f.Scopes {
+		var prefix *Prefix
+		if scope.Prefix != nil {
+			prefix = &Prefix{Template: scope.Prefix.String, Variables: scope.Prefix.Variables}
+			if prefix.Variables == nil {
+				prefix.Variables = []string{}
+			}
+		}
+		operations := make([]*Operation, 0, len(scope.Operations))
+		for _, op := range scope.Operations {
+			operations = append(operations, &Operation{
+				Name:        op.Name,
+				Doc:         newDoc(op.Comment),
+				Type:        b.newType(f, op.Type),
+				Annotations: newAnnotations(op.Annotations),
+				Pos:         newPos(op.Pos),
+			})
+		}
+		file.Scopes = append(file.Scopes, &Scope{
+			Name:        scope.Name,
+			Doc:         newDoc(scope.Comment),
+			Prefix:      prefix,
+			Operations:  operations,
+			Annotations: newAnnotations(scope.Annotations),
+			Pos:         newPos(scope.Pos),
+		})
+	}
+
+	return file
+}
+
+func (b *builder) newStructs(f *parser.Frugal, structs []*parser.Struct) []*Struct {
+	models := make([]*Struct, 0, len(structs))
+	for _, s := range structs {
+		models = append(models, &Struct{
+			Name:        s.Name,
+			Doc:         newDoc(s.Comment),
+			Fields:      b.newFields(f, s.Fields),
+			Annotations: newAnnotations(s.Annotations),
+			Pos:         newPos(s.Pos),
+		})
+	}
+	return models
+}
+
+func (b *builder) newFields(f *parser.Frugal, fields []*parser.Field) []*Field {
+	models := make([]*Field, 0, len(fields))
+	for _, field := range fields {
+		models = append(models, b.newField(f, field))
+	}
+	return models
+}
+
+func (b *builder) newField(f *parser.Frugal, field *parser.Field) *Field {
+	model := &Field{
+		ID:          field.ID,
+		Name:        field.Name,
+		Doc:         newDoc(field.Comment),
+		Modifier:    strings.ToLower(field.Modifier.String()),
+		Type:        b.newType(f, field.Type),
+		Annotations: newAnnotations(field.Annotations),
+		Pos:         newPos(field.Pos),
+	}
+	if field.Default != nil {
+		model.Default = newValue(f, field.Type, field.Default)
+	}
+	return model
+}
+
+// newType returns the model of a type used in the given file.
+func (b *builder) newType(f *parser.Frugal, t *parser.Type) *Type {
+	if t == nil {
+		return nil
+	}
+	model := &Type{Name: t.Name, Annotations: newAnnotations(t.Annotations)}
+	switch {
+	case t.IsPrimitive():
+		model.Kind = KindBase
+		return model
+	case t.IsContainer():
+		model.Kind = t.Name
+		if t.KeyType != nil {
+			model.KeyType = b.newType(f, t.KeyType)
+		}
+		model.ValueType = b.newType(f, t.ValueType)
+		return model
+	}
+
+	declaring := f
+	if include := t.IncludeName(); include != "" {
+		declaring = f.ParsedIncludes[include]
+	}
+	frugal, resolved := f.ResolveType(t)
+	if declaring == nil || frugal == nil {
+		model.Kind = KindUnknown
+		return model
+	}
+	model.File = b.filePath(declaring)
+	if resolved != t {
+		model.Kind = KindTypedef
+		model.Underlying = b.newType(frugal, resolved)
+		return model
+	}
+
+	name := t.ParamName()
+	model.Kind = KindUnknown
+	for _, enum := range frugal.Enums {
+		if enum.Name == name {
+			model.Kind = KindEnum
+		}
+	}
+	for _, s := range frugal.DataStructures() {
+		if s.Name == name {
+			model.Kind = s.Type.String()
+		}
+	}
+	return model
+}
+
+// newValue returns the model of a constant value of the given type used in
+// the given file, with any identifiers resolved.
+func newValue(f *parser.Frugal, t *parser.Type, value interface{}) interface{} {
+	if identifier, ok := value.(parser.Identifier); ok {
+		ctx := f.ContextFromIdentifier(identifier)
+		switch ctx.Type {
+		case parser.LocalConstant:
+			return newValue(f, ctx.Constant.Type, ctx.Constant.Value)
+		case parser.IncludeConstant:
+			return newValue(ctx.Include, ctx.Constant.Type, ctx.Constant.Value)
+		case parser.LocalEnum, parser.IncludeEnum:
+			return ctx.EnumValue.Value
+		}
+		return string(identifier)
+	}
+
+	frugal, t := f.ResolveType(t)
+	if frugal == nil {
+		frugal = f
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		values := make([]interface{}, 0, len(v))
+		for _, elem := range v {
+			values = append(values, newValue(frugal, t.ValueType, elem))
+		}
+		return values
+	case []parser.KeyValue:
+		if t.Name == "map" {
+			entries := make([]*Entry, 0, len(v))
+			for _, pair := range v {
+				entries = append(entries, &Entry{
+					Key:   newValue(frugal, t.KeyType, pair.Key),
+					Value: newValue(frugal, t.ValueType, pair.Value),
+				})
+			}
+			return entries
+		}
+		fields := map[string]*parser.Field{}
+		for _, s := range frugal.DataStructures() {
+			if s.Name == t.ParamName() {
+				for _, field := range s.Fields {
+					fields[field.Name] = field
+				}
+			}
+		}
+		values := make(map[string]interface{}, len(v))
+		for _, pair := range v {
+			name := pair.KeyToString()
+			if field, ok := fields[name]; ok {
+				values[name] = newValue(frugal, field.Type, pair.Value)
+			} else {
+				values[name] = pair.Value
+			}
+		}
+		return values
+	}
+	return value
+}
+
+func newAnnotations(annotations parser.Annotations) []*Annotation {
+	if len(annotations) == 0 {
+		return nil
+	}
+	models := make([]*Annotation, 0, len(annotations))
+	for _, annotation := range annotations {
+		models = append(models, &Annotation{Name: annotation.Name, Value: annotation.Value})
+	}
+	return models
+}
+
+func newDoc(comment []string) string {
+	return strings.Join(comment, "\n")
+}
+
+func newPos(pos parser.Pos) Pos {
+	return Pos{Line: pos.Line, Column: pos.Column}
+}
+
+// filePath returns the path of the Frugal relative to the file the model is
+// for, or its absolute path if it isn't relative to it.
+func (b *builder) filePath(f *parser.Frugal) string {
+	path, err := filepath.Rel(b.dir, f.Path)
+	if err != nil {
+		path = f.Path
+	}
+	return filepath.ToSlash(path)
+}
@@ -0,0 +1,224 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+// SchemaVersion is the version of the model's schema. It's incremented when
+// a field is removed or its meaning changes, but not when fields are added.
+const SchemaVersion = 1
+
+// Kinds of types in the model.
+const (
+	KindBase      = "base"
+	KindList      = "list"
+	KindSet       = "set"
+	KindMap       = "map"
+	KindTypedef   = "typedef"
+	KindEnum      = "enum"
+	KindStruct    = "struct"
+	KindUnion     = "union"
+	KindException = "exception"
+	KindUnknown   = "unknown"
+)
+
+// Document is the model of a Frugal file along with every file it includes,
+// directly or transitively, so it can be used on its own.
+type Document struct {
+	SchemaVersion int     `json:"schemaVersion" yaml:"schemaVersion"`
+	FrugalVersion string  `json:"frugalVersion" yaml:"frugalVersion"`
+	File          *File   `json:"file" yaml:"file"`
+	Includes      []*File `json:"includes" yaml:"includes"` // Ordered by path
+}
+
+// File is the model of a single Frugal file. Paths throughout a Document are
+// relative to the directory of the file it's for.
+type File struct {
+	Name       string       `json:"name" yaml:"name"`
+	Path       string       `json:"path" yaml:"path"`
+	Namespaces []*Namespace `json:"namespaces" yaml:"namespaces"`
+	Includes   []*Include   `json:"includes" yaml:"includes"`
+	Constants  []*Constant  `json:"constants" yaml:"constants"`
+	Typedefs   []*Typedef   `json:"typedefs" yaml:"typedefs"`
+	Enums      []*Enum      `json:"enums" yaml:"enums"`
+	Structs    []*Struct    `json:"structs" yaml:"structs"`
+	Unions     []*Struct    `json:"unions" yaml:"unions"`
+	Exceptions []*Struct    `json:"exceptions" yaml:"exceptions"`
+	Services   []*Service   `json:"services" yaml:"services"`
+	Scopes     []*Scope     `json:"scopes" yaml:"scopes"`
+}
+
+// Pos is where a definition starts in its file.
+type Pos struct {
+	Line   int `json:"line" yaml:"line"`
+	Column int `json:"column" yaml:"column"`
+}
+
+// Annotation is key-value metadata attached to a definition.
+type Annotation struct {
+	Name  string `json:"name" yaml:"name"`
+	Value string `json:"value" yaml:"value"`
+}
+
+// Namespace is a language namespace declaration.
+type Namespace struct {
+	Scope       string        `json:"scope" yaml:"scope"`
+	Value       string        `json:"value" yaml:"value"`
+	Annotations []*Annotation `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Pos         Pos           `json:"pos" yaml:"pos"`
+}
+
+// Include is an included file. Path is the path as written, File is the path
+// it resolved to.
+type Include struct {
+	Name        string        `json:"name" yaml:"name"`
+	Path        string        `json:"path" yaml:"path"`
+	File        string        `json:"file" yaml:"file"`
+	Annotations []*Annotation `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Pos         Pos           `json:"pos" yaml:"pos"`
+}
+
+// Type is a reference to a type. Name is as it's written where it's used.
+// File is the path of the file declaring the type if it isn't a base type or
+// container. Underlying is set for typedefs to the type they resolve to,
+// whose name is relative to the file declaring it.
+type Type struct {
+	Name        string        `json:"name" yaml:"name"`
+	Kind        string        `json:"kind" yaml:"kind"`
+	File        string        `json:"file,omitempty" yaml:"file,omitempty"`
+	KeyType     *Type         `json:"keyType,omitempty" yaml:"keyType,omitempty"`
+	ValueType   *Type         `json:"valueType,omitempty" yaml:"valueType,omitempty"`
+	Underlying  *Type         `json:"underlying,omitempty" yaml:"underlying,omitempty"`
+	Annotations []*Annotation `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+}
+
+// Constant is a constant definition. Value has identifiers resolved to the
+// values they refer to. Lists and sets are arrays, structs are objects keyed
+// by field name, and maps are arrays of entries since keys needn't be
+// strings.
+type Constant struct {
+	Name        string        `json:"name" yaml:"name"`
+	Doc         string        `json:"doc,omitempty" yaml:"doc,omitempty"`
+	Type        *Type         `json:"type" yaml:"type"`
+	Value       interface{}   `json:"value" yaml:"value"`
+	Annotations []*Annotation `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Pos         Pos           `json:"pos" yaml:"pos"`
+}
+
+// Entry is a key-value pair in a map value.
+type Entry struct {
+	Key   interface{} `json:"key" yaml:"key"`
+	Value interface{} `json:"value" yaml:"value"`
+}
+
+// Typedef is a type alias. Its Type has the underlying type it resolves to.
+type Typedef struct {
+	Name        string        `json:"name" yaml:"name"`
+	Doc         string        `json:"doc,omitempty" yaml:"doc,omitempty"`
+	Type        *Type         `json:"type" yaml:"type"`
+	Annotations []*Annotation `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Pos         Pos           `json:"pos" yaml:"pos"`
+}
+
+// Enum is an enum definition.
+type Enum struct {
+	Name        string        `json:"name" yaml:"name"`
+	Doc         string        `json:"doc,omitempty" yaml:"doc,omitempty"`
+	Values      []*EnumValue  `json:"values" yaml:"values"`
+	Annotations []*Annotation `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Pos         Pos           `json:"pos" yaml:"pos"`
+}
+
+// EnumValue is a value of an enum. Implicit values were assigned in order
+// rather than given explicitly.
+type EnumValue struct {
+	Name        string        `json:"name" yaml:"name"`
+	Doc         string        `json:"doc,omitempty" yaml:"doc,omitempty"`
+	Value       int           `json:"value" yaml:"value"`
+	Implicit    bool          `json:"implicit,omitempty" yaml:"implicit,omitempty"`
+	Annotations []*Annotation `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Pos         Pos           `json:"pos" yaml:"pos"`
+}
+
+// Struct is a struct, union, or exception definition.
+type Struct struct {
+	Name        string        `json:"name" yaml:"name"`
+	Doc         string        `json:"doc,omitempty" yaml:"doc,omitempty"`
+	Fields      []*Field      `json:"fields" yaml:"fields"`
+	Annotations []*Annotation `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Pos         Pos           `json:"pos" yaml:"pos"`
+}
+
+// Field is a field of a struct, or an argument or exception of a method.
+// Modifier is one of "required", "optional", or "default".
+type Field struct {
+	ID          int           `json:"id" yaml:"id"`
+	Name        string        `json:"name" yaml:"name"`
+	Doc         string        `json:"doc,omitempty" yaml:"doc,omitempty"`
+	Modifier    string        `json:"modifier" yaml:"modifier"`
+	Type        *Type         `json:"type" yaml:"type"`
+	Default     interface{}   `json:"default,omitempty" yaml:"default,omitempty"`
+	Annotations []*Annotation `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Pos         Pos           `json:"pos" yaml:"pos"`
+}
+
+// Service is a service definition. Extends is the extended service as
+// written.
+type Service struct {
+	Name        string        `json:"name" yaml:"name"`
+	Doc         string        `json:"doc,omitempty" yaml:"doc,omitempty"`
+	Extends     string        `json:"extends,omitempty" yaml:"extends,omitempty"`
+	Methods     []*Method     `json:"methods" yaml:"methods"`
+	Annotations []*Annotation `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Pos         Pos           `json:"pos" yaml:"pos"`
+}
+
+// Method is a service method. ReturnType is omitted for void methods, and
+// RequestStream is only set for methods which stream requests.
+type Method struct {
+	Name              string        `json:"name" yaml:"name"`
+	Doc               string        `json:"doc,omitempty" yaml:"doc,omitempty"`
+	Oneway            bool          `json:"oneway" yaml:"oneway"`
+	StreamingResponse bool          `json:"streamingResponse" yaml:"streamingResponse"`
+	ReturnType        *Type         `json:"returnType,omitempty" yaml:"returnType,omitempty"`
+	Arguments         []*Field      `json:"arguments" yaml:"arguments"`
+	RequestStream     *Field        `json:"requestStream,omitempty" yaml:"requestStream,omitempty"`
+	Exceptions        []*Field      `json:"exceptions" yaml:"exceptions"`
+	Annotations       []*Annotation `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Pos               Pos           `json:"pos" yaml:"pos"`
+}
+
+// Scope is a pub/sub scope definition.
+type Scope struct {
+	Name        string        `json:"name" yaml:"name"`
+	Doc         string        `json:"doc,omitempty" yaml:"doc,omitempty"`
+	Prefix      *Prefix       `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	Operations  []*Operation  `json:"operations" yaml:"operations"`
+	Annotations []*Annotation `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Pos         Pos           `json:"pos" yaml:"pos"`
+}
+
+// Prefix is a scope's topic prefix. Template has variables written as
+// {name}, which are listed in order in Variables.
+type Prefix struct {
+	Template  string   `json:"template" yaml:"template"`
+	Variables []string `json:"variables" yaml:"variables"`
+}
+
+// Operation is a pub/sub operation of a scope.
+type Operation struct {
+	Name        string        `json:"name" yaml:"name"`
+	Doc         string        `json:"doc,omitempty" yaml:"doc,omitempty"`
+	Type        *Type         `json:"type" yaml:"type"`
+	Annotations []*Annotation `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Pos         Pos           `json:"pos" yaml:"pos"`
+}
@@ -0,0 +1,356 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+const (
+	openAPIVersion = "3.0.3"
+	jsonMediaType  = "application/json"
+
+	// errorSchemaName can't be the name of a Frugal definition since it
+	// isn't an identifier.
+	errorSchemaName = "gateway-error"
+)
+
+// Document is an OpenAPI document describing a service.
+type Document struct {
+	OpenAPI    string              `json:"openapi" yaml:"openapi"`
+	Info       *Info               `json:"info" yaml:"info"`
+	Paths      map[string]PathItem `json:"paths" yaml:"paths"`
+	Components *Components         `json:"components,omitempty" yaml:"components,omitempty"`
+}
+
+// Info describes the service.
+type Info struct {
+	Title       string `json:"title" yaml:"title"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string `json:"version" yaml:"version"`
+}
+
+// PathItem has the operation served for each HTTP method of a path, keyed by
+// the lowercase method.
+type PathItem map[string]*Operation
+
+// Operation describes a service method.
+type Operation struct {
+	OperationID string               `json:"operationId" yaml:"operationId"`
+	Description string               `json:"description,omitempty" yaml:"description,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses" yaml:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+// Parameter is a method argument passed in the path or query.
+type Parameter struct {
+	Name        string  `json:"name" yaml:"name"`
+	In          string  `json:"in" yaml:"in"`
+	Description string  `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool    `json:"required,omitempty" yaml:"required,omitempty"`
+	Deprecated  bool    `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	Schema      *Schema `json:"schema" yaml:"schema"`
+}
+
+// RequestBody has the method arguments passed in the body.
+type RequestBody struct {
+	Required bool                  `json:"required" yaml:"required"`
+	Content  map[string]*MediaType `json:"content" yaml:"content"`
+}
+
+// Response is a response a method can return.
+type Response struct {
+	Description string                `json:"description" yaml:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+// MediaType has the schema of a request or response body.
+type MediaType struct {
+	Schema *Schema `json:"schema" yaml:"schema"`
+}
+
+// Components has the schemas of the enums, structs, unions, and exceptions
+// the service uses, keyed by name. Those declared in other files are
+// qualified by their file's name.
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas" yaml:"schemas"`
+}
+
+// Schema describes the JSON a type is encoded as. Lists, sets, maps, and
+// binary are nullable since they're null when unset in Go.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string             `json:"format,omitempty" yaml:"format,omitempty"`
+	Description          string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty" yaml:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty" yaml:"required,omitempty"`
+	MaxProperties        int                `json:"maxProperties,omitempty" yaml:"maxProperties,omitempty"`
+	Deprecated           bool               `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+}
+
+// errorSchema returns a reference to the schema of the body of responses for
+// errors other than exceptions, adding it to the components.
+func errorSchema(components *Components) *Schema {
+	components.Schemas[errorSchemaName] = &Schema{
+		Type:       "object",
+		Properties: map[string]*Schema{"error": {Type: "string"}},
+		Required:   []string{"error"},
+	}
+	return &Schema{Ref: "#/components/schemas/" + errorSchemaName}
+}
+
+// newDocument returns the OpenAPI document for the given service served by
+// the given operations.
+func (g *Generator) newDocument(service *parser.Service, ops []*operation) *Document {
+	version := defaultVersion
+	if v, ok := service.Annotations.Get(versionAnnotation); ok {
+		version = v
+	}
+	doc := &Document{
+		OpenAPI: openAPIVersion,
+		Info: &Info{
+			Title:       service.Name,
+			Description: strings.Join(service.Comment, "\n"),
+			Version:     version,
+		},
+		Paths:      make(map[string]PathItem),
+		Components: &Components{Schemas: make(map[string]*Schema)},
+	}
+	for _, op := range ops {
+		item, ok := doc.Paths[op.path]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[op.path] = item
+		}
+		item[strings.ToLower(op.verb)] = g.newOperation(doc.Components, op)
+	}
+	return doc
+}
+
+func (g *Generator) newOperation(components *Components, op *operation) *Operation {
+	method := op.method
+	_, deprecated := method.Annotations.Deprecated()
+	operation := &Operation{
+		OperationID: method.Name,
+		Description: strings.Join(method.Comment, "\n"),
+		Responses:   make(map[string]*Response),
+		Deprecated:  deprecated,
+	}
+
+	for _, arg := range op.pathArgs {
+		operation.Parameters = append(operation.Parameters, g.newParameter(components, op, arg, "path"))
+	}
+	for _, arg := range op.queryArgs {
+		operation.Parameters = append(operation.Parameters, g.newParameter(components, op, arg, "query"))
+	}
+	if len(op.bodyArgs) > 0 {
+		body := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for _, arg := range op.bodyArgs {
+			body.Properties[arg.Name] = g.fieldSchema(components, op.frugal, arg)
+			if arg.Modifier == parser.Required {
+				body.Required = append(body.Required, arg.Name)
+			}
+		}
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{jsonMediaType: {Schema: body}},
+		}
+	}
+
+	if method.Oneway {
+		operation.Responses["202"] = &Response{Description: "The call was accepted"}
+	} else {
+		success := &Schema{Type: "object"}
+		if method.ReturnType != nil {
+			schema := g.schema(components, op.frugal, method.ReturnType, method.Pos)
+			success.Properties = map[string]*Schema{"success": schema}
+		}
+		operation.Responses["200"] = &Response{
+			Description: "The call succeeded",
+			Content:     map[string]*MediaType{jsonMediaType: {Schema: success}},
+		}
+	}
+
+	// Exceptions returned with the same status code are in one response,
+	// with the one raised set.
+	exceptions := make(map[int][]*parser.Field)
+	for _, exception := range method.Exceptions {
+		status := op.statuses[exception]
+		exceptions[status] = append(exceptions[status], exception)
+	}
+	for status, fields := range exceptions {
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema), MaxProperties: 1}
+		names := make([]string, 0, len(fields))
+		for _, exception := range fields {
+			schema.Properties[exception.Name] = g.fieldSchema(components, op.frugal, exception)
+			names = append(names, exception.Type.Name)
+		}
+		operation.Responses[strconv.Itoa(status)] = &Response{
+			Description: fmt.Sprintf("The call raised %s", strings.Join(names, " or ")),
+			Content:     map[string]*MediaType{jsonMediaType: {Schema: schema}},
+		}
+	}
+
+	// Requests can only be malformed if the method has arguments.
+	if _, ok := operation.Responses["400"]; !ok && len(method.Arguments) > 0 {
+		operation.Responses["400"] = &Response{
+			Description: "The request was malformed",
+			Content:     map[string]*MediaType{jsonMediaType: {Schema: errorSchema(components)}},
+		}
+	}
+	operation.Responses["default"] = &Response{
+		Description: "The call failed",
+		Content:     map[string]*MediaType{jsonMediaType: {Schema: errorSchema(components)}},
+	}
+	return operation
+}
+
+func (g *Generator) newParameter(components *Components, op *operation, arg *parser.Field, in string) *Parameter {
+	_, deprecated := arg.Annotations.Deprecated()
+	return &Parameter{
+		Name:        arg.Name,
+		In:          in,
+		Description: strings.Join(arg.Comment, "\n"),
+		Required:    in == "path" || arg.Modifier == parser.Required,
+		Deprecated:  deprecated,
+		Schema:      g.schema(components, op.frugal, arg.Type, arg.Pos),
+	}
+}
+
+// fieldSchema returns the schema of the given field used in the Frugal, with
+// its doc comment unless it's a reference, whose siblings are ignored.
+func (g *Generator) fieldSchema(components *Components, f *parser.Frugal, field *parser.Field) *Schema {
+	schema := g.schema(components, f, field.Type, field.Pos)
+	if schema.Ref == "" {
+		schema.Description = strings.Join(field.Comment, "\n")
+		_, schema.Deprecated = field.Annotations.Deprecated()
+	}
+	return schema
+}
+
+// schema returns the schema of the JSON the Go gateway encodes the given type
+// used in the Frugal as. Enums, structs, unions, and exceptions are added to
+// the components and referenced. Problems are reported at the given position.
+func (g *Generator) schema(components *Components, f *parser.Frugal, t *parser.Type, pos parser.Pos) *Schema {
+	declaring, resolved := f.ResolveType(t)
+	if declaring == nil {
+		return &Schema{}
+	}
+	switch resolved.Name {
+	case "bool":
+		return &Schema{Type: "boolean"}
+	case "byte", "i8", "i16", "i32":
+		return &Schema{Type: "integer", Format: "int32"}
+	case "i64":
+		return &Schema{Type: "integer", Format: "int64"}
+	case "double":
+		return &Schema{Type: "number", Format: "double"}
+	case "string":
+		return &Schema{Type: "string"}
+	case "binary":
+		return &Schema{Type: "string", Format: "byte", Nullable: true}
+	case "list":
+		return &Schema{Type: "array", Items: g.schema(components, declaring, resolved.ValueType, pos), Nullable: true}
+	case "set":
+		// Sets are maps to true in Go, so they're encoded as objects.
+		g.checkKey(declaring, resolved, resolved.ValueType, pos)
+		return &Schema{Type: "object", AdditionalProperties: &Schema{Type: "boolean"}, Nullable: true}
+	case "map":
+		g.checkKey(declaring, resolved, resolved.KeyType, pos)
+		return &Schema{Type: "object", AdditionalProperties: g.schema(components, declaring, resolved.ValueType, pos), Nullable: true}
+	}
+
+	name := schemaName(g.frugal, declaring, resolved)
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+	if _, ok := components.Schemas[name]; ok {
+		return ref
+	}
+	if enum := findEnum(declaring, resolved); enum != nil {
+		components.Schemas[name] = g.enumSchema(enum)
+		return ref
+	}
+	if s := findStruct(declaring, resolved); s != nil {
+		// Add the schema before its fields so recursive types terminate.
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		components.Schemas[name] = schema
+		g.structSchema(components, declaring, s, schema)
+		return ref
+	}
+	return &Schema{}
+}
+
+func (g *Generator) enumSchema(enum *parser.Enum) *Schema {
+	// Enums are encoded as their names in Go.
+	schema := &Schema{
+		Type:        "string",
+		Description: strings.Join(enum.Comment, "\n"),
+	}
+	for _, value := range enum.Values {
+		schema.Enum = append(schema.Enum, value.Name)
+	}
+	return schema
+}
+
+func (g *Generator) structSchema(components *Components, declaring *parser.Frugal, s *parser.Struct, schema *Schema) {
+	schema.Description = strings.Join(s.Comment, "\n")
+	_, schema.Deprecated = s.Annotations.Deprecated()
+	for _, field := range s.Fields {
+		schema.Properties[field.Name] = g.fieldSchema(components, declaring, field)
+		if field.Modifier == parser.Required {
+			schema.Required = append(schema.Required, field.Name)
+		}
+	}
+	if s.Type == parser.StructTypeUnion {
+		schema.MaxProperties = 1
+	}
+}
+
+// checkKey reports set elements and map keys the Go gateway can't encode as
+// JSON object keys, which must be strings, integers, or enums.
+func (g *Generator) checkKey(f *parser.Frugal, container, key *parser.Type, pos parser.Pos) {
+	declaring, resolved := f.ResolveType(key)
+	if declaring == nil {
+		return
+	}
+	switch resolved.Name {
+	case "string", "byte", "i8", "i16", "i32", "i64":
+		return
+	}
+	if findEnum(declaring, resolved) != nil {
+		return
+	}
+	g.warnf(pos, "%s can't be encoded as JSON since set elements and map keys must be strings, integers, or enums",
+		container.String())
+}
+
+// schemaName returns the name of the schema for the given enum, struct,
+// union, or exception in the components of the document for the Frugal being
+// generated.
+func schemaName(frugal, declaring *parser.Frugal, resolved *parser.Type) string {
+	if declaring == frugal {
+		return resolved.ParamName()
+	}
+	return declaring.Name + "." + resolved.ParamName()
+}
@@ -0,0 +1,364 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openapi
+
+import (
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Workiva/frugal/compiler/generator/golang"
+	"github.com/Workiva/frugal/compiler/globals"
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+const frugalImport = "github.com/Workiva/frugal/lib/go"
+
+// gateway generates the Go package serving the services of a Frugal as JSON
+// over HTTP by calling their Frugal handlers.
+type gateway struct {
+	*Generator
+	imports map[string]string // Import path to package name
+	names   map[*parser.Frugal]*golang.Names
+}
+
+// generateGateway writes the Go gateway package for the services of the
+// Frugal being generated to <name>gateway/<name>_gateway.go in the output
+// directory.
+func (g *Generator) generateGateway(outputDir string, services map[*parser.Service][]*operation) error {
+	gw := &gateway{
+		Generator: g,
+		imports: map[string]string{
+			"bytes":         "",
+			"encoding/json": "",
+			"fmt":           "",
+			"io/ioutil":     "",
+			"net/http":      "",
+			"strings":       "",
+			frugalImport:    "frugal",
+		},
+		names: make(map[*parser.Frugal]*golang.Names),
+	}
+
+	contents := ""
+	for _, service := range g.frugal.Services {
+		contents += gw.generateService(service, services[service])
+	}
+	contents += gatewayHelpers
+
+	header := fmt.Sprintf("// Autogenerated by Frugal Compiler (%s)\n", globals.Version)
+	header += "// DO NOT EDIT UNLESS YOU ARE SURE THAT YOU KNOW WHAT YOU ARE DOING\n\n"
+	header += fmt.Sprintf("package %s\n\n", gatewayPackage(g.frugal))
+	header += gw.generateImports()
+
+	formatted, err := format.Source([]byte(header + contents))
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(outputDir, gatewayPackage(g.frugal))
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, g.frugal.Name+"_gateway.go"), formatted, 0666)
+}
+
+func (gw *gateway) generateImports() string {
+	std, other := []string{}, []string{}
+	for path := range gw.imports {
+		if gw.imports[path] == "" {
+			std = append(std, path)
+		} else {
+			other = append(other, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+
+	contents := "import (\n"
+	for _, path := range std {
+		contents += fmt.Sprintf("\t\"%s\"\n", path)
+	}
+	if len(std) > 0 && len(other) > 0 {
+		contents += "\n"
+	}
+	for _, path := range other {
+		contents += fmt.Sprintf("\t%s \"%s\"\n", gw.imports[path], path)
+	}
+	contents += ")\n\n"
+	return contents
+}
+
+// generateService generates the gateway for the given service, which serves
+// the given operations.
+func (gw *gateway) generateService(service *parser.Service, ops []*operation) string {
+	names := gw.goNames(gw.frugal)
+	name := exported(service.Name) + "Gateway"
+
+	contents := fmt.Sprintf("// %s serves a Frugal %s handler as JSON over HTTP. The handler\n", name, service.Name)
+	contents += fmt.Sprintf("// can be an implementation of the service or an %s calling one\n", strings.TrimPrefix(names.Service(service), golang.PackageName(gw.frugal)+".")+"Client")
+	contents += "// over any FTransport.\n"
+	contents += fmt.Sprintf("type %s struct {\n", name)
+	contents += fmt.Sprintf("\thandler %s\n", names.Service(service))
+	contents += "\troutes  []*route\n"
+	contents += "}\n\n"
+
+	contents += fmt.Sprintf("// New%s returns a %s which calls the given handler.\n", name, name)
+	contents += fmt.Sprintf("func New%s(handler %s) *%s {\n", name, names.Service(service), name)
+	contents += fmt.Sprintf("\tg := &%s{handler: handler}\n", name)
+	contents += "\tg.routes = []*route{\n"
+	for _, op := range ops {
+		contents += fmt.Sprintf("\t\t{%q, %#v, g.serve%s},\n", op.verb, pathSegments(op.path), gw.goNames(op.frugal).Method(op.method))
+	}
+	contents += "\t}\n"
+	contents += "\treturn g\n"
+	contents += "}\n\n"
+
+	contents += "// ServeHTTP calls the method the request is routed to.\n"
+	contents += fmt.Sprintf("func (g *%s) ServeHTTP(w http.ResponseWriter, r *http.Request) {\n", name)
+	contents += "\tserveRoute(g.routes, w, r)\n"
+	contents += "}\n\n"
+
+	for _, op := range ops {
+		contents += gw.generateMethod(name, op)
+	}
+	return contents
+}
+
+// generateMethod generates the function serving the given operation.
+func (gw *gateway) generateMethod(gatewayName string, op *operation) string {
+	names := gw.goNames(op.frugal)
+	method := op.method
+
+	contents := fmt.Sprintf("func (g *%s) serve%s(w http.ResponseWriter, r *http.Request, vars map[string]string) {\n",
+		gatewayName, names.Method(method))
+	callArgs := ""
+	if len(method.Arguments) > 0 {
+		params := ""
+		for _, arg := range append(append([]*parser.Field{}, op.pathArgs...), op.queryArgs...) {
+			params += fmt.Sprintf("%q: %t, ", arg.Name, isQuoted(op.frugal, arg.Type))
+		}
+		contents += fmt.Sprintf("\targs := %s()\n", names.ArgsConstructor(op.service, method))
+		contents += fmt.Sprintf("\tif err := readArgs(r, vars, map[string]bool{%s}, args); err != nil {\n", strings.TrimSuffix(params, ", "))
+		contents += "\t\twriteError(w, http.StatusBadRequest, err)\n"
+		contents += "\t\treturn\n"
+		contents += "\t}\n"
+		for _, arg := range method.Arguments {
+			callArgs += ", args." + names.Field(arg)
+		}
+	}
+
+	result := "err"
+	if method.ReturnType != nil {
+		result = "result, err"
+	}
+	contents += fmt.Sprintf("\t%s := g.handler.%s(newFContext(r)%s)\n", result, names.Method(method), callArgs)
+	contents += "\tif err != nil {\n"
+	if len(method.Exceptions) > 0 {
+		contents += "\t\tswitch e := err.(type) {\n"
+		for _, exception := range method.Exceptions {
+			contents += fmt.Sprintf("\t\tcase %s:\n", gw.goType(op.frugal, exception.Type))
+			contents += fmt.Sprintf("\t\t\twriteJSON(w, %d, map[string]interface{}{%q: e})\n", op.statuses[exception], exception.Name)
+		}
+		contents += "\t\tdefault:\n"
+		contents += "\t\t\twriteError(w, http.StatusInternalServerError, err)\n"
+		contents += "\t\t}\n"
+	} else {
+		contents += "\t\twriteError(w, http.StatusInternalServerError, err)\n"
+	}
+	contents += "\t\treturn\n"
+	contents += "\t}\n"
+	switch {
+	case method.Oneway:
+		contents += "\tw.WriteHeader(http.StatusAccepted)\n"
+	case method.ReturnType != nil:
+		contents += "\twriteJSON(w, http.StatusOK, map[string]interface{}{\"success\": result})\n"
+	default:
+		contents += "\twriteJSON(w, http.StatusOK, map[string]interface{}{})\n"
+	}
+	contents += "}\n\n"
+	return contents
+}
+
+func (gw *gateway) goType(f *parser.Frugal, t *parser.Type) string {
+	if !t.IsPrimitive() && !t.IsContainer() {
+		declaring := f
+		if include := t.IncludeName(); include != "" {
+			declaring = f.ParsedIncludes[include]
+		}
+		if declaring != nil {
+			gw.goNames(declaring)
+		}
+	}
+	return gw.goNames(f).Type(t)
+}
+
+// goNames returns the names of the Go code generated for the given Frugal,
+// importing its package.
+func (gw *gateway) goNames(f *parser.Frugal) *golang.Names {
+	names, ok := gw.names[f]
+	if !ok {
+		names = golang.NewNames(f)
+		gw.names[f] = names
+		gw.imports[golang.ImportPath(f, gw.options[packagePrefixOption])] = golang.PackageName(f)
+	}
+	return names
+}
+
+// gatewayPackage returns the name of the Go gateway package for the given
+// Frugal.
+func gatewayPackage(f *parser.Frugal) string {
+	return strings.ToLower(f.Name) + "gateway"
+}
+
+// exported returns the given name with its first letter capitalized.
+func exported(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// gatewayHelpers are the functions shared by the gateways in a package.
+const gatewayHelpers = `// correlationIDHeader is the header with the correlation ID of the FContext
+// passed to handlers. A random one is used if it isn't set.
+const correlationIDHeader = "X-Correlation-Id"
+
+// route is a method served by a gateway. Variables in its path are written as
+// {name}.
+type route struct {
+	method string
+	path   []string
+	serve  func(http.ResponseWriter, *http.Request, map[string]string)
+}
+
+// serveRoute calls the route the request is for. When several paths match,
+// the one with the most literal segments is used.
+func serveRoute(routes []*route, w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	var matched *route
+	var matchedVars map[string]string
+	matchedLiterals := -1
+	allowed := []string{}
+	for _, rt := range routes {
+		vars, ok := matchPath(rt.path, segments)
+		if !ok {
+			continue
+		}
+		if rt.method != r.Method {
+			allowed = append(allowed, rt.method)
+			continue
+		}
+		if literals := len(rt.path) - len(vars); literals > matchedLiterals {
+			matched, matchedVars, matchedLiterals = rt, vars, literals
+		}
+	}
+	if matched != nil {
+		matched.serve(w, r, matchedVars)
+		return
+	}
+	if len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s isn't allowed for %s", r.Method, r.URL.Path))
+		return
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("no method is served at %s", r.URL.Path))
+}
+
+// matchPath returns the variables in the given path segments if they match
+// the pattern.
+func matchPath(pattern, segments []string) (map[string]string, bool) {
+	if len(pattern) != len(segments) {
+		return nil, false
+	}
+	vars := make(map[string]string)
+	for i, segment := range pattern {
+		if strings.HasPrefix(segment, "{") {
+			vars[segment[1:len(segment)-1]] = segments[i]
+		} else if segment != segments[i] {
+			return nil, false
+		}
+	}
+	return vars, true
+}
+
+// readArgs decodes the JSON body of the request into args, along with the
+// path variables and query parameters named in params. params indicates
+// which are strings or enums, since those are quoted in JSON.
+func readArgs(r *http.Request, vars map[string]string, params map[string]bool, args interface{}) error {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	fields := make(map[string]json.RawMessage)
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &fields); err != nil {
+			return err
+		}
+	}
+	query := r.URL.Query()
+	for name, quoted := range params {
+		value, ok := vars[name]
+		if !ok {
+			if _, ok := query[name]; !ok {
+				continue
+			}
+			value = query.Get(name)
+		}
+		if !quoted {
+			var decoded interface{}
+			if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+				return fmt.Errorf("invalid %s %q", name, value)
+			}
+			fields[name] = json.RawMessage(value)
+			continue
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		fields[name] = encoded
+	}
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, args)
+}
+
+// writeJSON writes the given value as the JSON response body.
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// writeError writes the given error as the JSON response body.
+func writeError(w http.ResponseWriter, status int, err error) {
+	body, _ := json.Marshal(map[string]string{"error": err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// newFContext returns the FContext passed to handlers for the request.
+func newFContext(r *http.Request) frugal.FContext {
+	return frugal.NewFContext(r.Header.Get(correlationIDHeader))
+}
+`
@@ -0,0 +1,404 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package openapi generates OpenAPI 3 documents describing Frugal services as
+// JSON over HTTP, along with an optional Go gateway serving them.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/Workiva/frugal/compiler/generator"
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+const (
+	defaultOutputDir    = "gen-openapi"
+	goGatewayOption     = "go_gateway"
+	packagePrefixOption = "package_prefix"
+	yamlOption          = "yaml"
+
+	// Annotations routing methods and mapping exceptions to status codes.
+	methodAnnotation  = "http.method"
+	pathAnnotation    = "http.path"
+	statusAnnotation  = "http.status"
+	versionAnnotation = "http.version"
+
+	defaultVersion         = "1.0.0"
+	defaultExceptionStatus = 500
+)
+
+// verbs are the HTTP methods a Frugal method can be routed to.
+var verbs = map[string]bool{
+	"GET":    true,
+	"POST":   true,
+	"PUT":    true,
+	"PATCH":  true,
+	"DELETE": true,
+}
+
+// Generator implements the ProgramGenerator interface for OpenAPI.
+type Generator struct {
+	options map[string]string
+
+	// State for the Frugal being generated.
+	frugal   *parser.Frugal
+	report   parser.Diagnostics
+	reported map[string]bool
+}
+
+// NewGenerator creates a new OpenAPI ProgramGenerator.
+func NewGenerator(options map[string]string) generator.ProgramGenerator {
+	if prefix := options[packagePrefixOption]; prefix != "" && !strings.HasSuffix(prefix, "/") {
+		options[packagePrefixOption] = prefix + "/"
+	}
+	return &Generator{options: options}
+}
+
+// Generate writes <name>.<service>.openapi.json, or .yaml with the yaml
+// option, to the output directory for each service in the Frugal, and its Go
+// gateway package with the go_gateway option. Routes which can't be served
+// fail generation, and types which can't be encoded as JSON are reported as
+// warnings.
+func (g *Generator) Generate(frugal *parser.Frugal, outputDir string) error {
+	g.frugal = frugal
+	g.report = nil
+	g.reported = make(map[string]bool)
+
+	services := make(map[*parser.Service][]*operation, len(frugal.Services))
+	for _, service := range frugal.Services {
+		services[service] = g.operations(service)
+	}
+	if g.report.HasErrors() {
+		sort.Stable(g.report)
+		return g.report
+	}
+
+	for _, service := range frugal.Services {
+		if err := g.writeDocument(outputDir, service, services[service]); err != nil {
+			return err
+		}
+	}
+	if _, ok := g.options[goGatewayOption]; ok && len(frugal.Services) > 0 {
+		if err := g.generateGateway(outputDir, services); err != nil {
+			return err
+		}
+	}
+
+	if len(g.report) == 0 {
+		return nil
+	}
+	sort.Stable(g.report)
+	return g.report.Format(os.Stdout)
+}
+
+func (g *Generator) GetOutputDir(dir string, frugal *parser.Frugal) string {
+	return dir
+}
+
+func (g *Generator) DefaultOutputDir() string {
+	return defaultOutputDir
+}
+
+func (g *Generator) UseVendor() bool {
+	return false
+}
+
+func (g *Generator) writeDocument(outputDir string, service *parser.Service, ops []*operation) error {
+	_, useYAML := g.options[yamlOption]
+	ext := "json"
+	if useYAML {
+		ext = "yaml"
+	}
+	file, err := os.Create(fmt.Sprintf("%s/%s.%s.openapi.%s", outputDir, g.frugal.Name, service.Name, ext))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	doc := g.newDocument(service, ops)
+	if useYAML {
+		out, err := yaml.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		_, err = file.Write(out)
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(doc)
+}
+
+// errorf reports a route which can't be served, failing generation.
+func (g *Generator) errorf(pos parser.Pos, format string, args ...interface{}) {
+	g.add(parser.SeverityError, pos, fmt.Sprintf(format, args...))
+}
+
+// warnf reports a construct which can't be represented in JSON.
+func (g *Generator) warnf(pos parser.Pos, format string, args ...interface{}) {
+	g.add(parser.SeverityWarning, pos, fmt.Sprintf(format, args...))
+}
+
+// add adds a diagnostic to the report unless it's already been reported,
+// since types are visited once for each service using them.
+func (g *Generator) add(severity parser.Severity, pos parser.Pos, message string) {
+	key := pos.String() + message
+	if g.reported[key] {
+		return
+	}
+	g.reported[key] = true
+	g.report = append(g.report, &parser.Diagnostic{Severity: severity, Pos: pos, Message: message})
+}
+
+// operation is a service method along with the HTTP route serving it.
+type operation struct {
+	frugal    *parser.Frugal  // Declares the method's service
+	service   *parser.Service // Declares the method, which may be extended
+	method    *parser.Method
+	verb      string
+	path      string
+	pathArgs  []*parser.Field
+	queryArgs []*parser.Field
+	bodyArgs  []*parser.Field
+	statuses  map[*parser.Field]int // Status code of each exception
+}
+
+// operations returns the routes for the methods of the given service,
+// including those it inherits. Streaming methods are skipped.
+func (g *Generator) operations(service *parser.Service) []*operation {
+	ops := []*operation{}
+	routes := make(map[string]*parser.Method)
+	for _, op := range g.methods(g.frugal, service) {
+		method := op.method
+		if method.IsStreaming() {
+			g.warnf(method.Pos, "streaming method %s.%s can't be served over HTTP and was skipped", op.service.Name, method.Name)
+			continue
+		}
+
+		op.verb = "POST"
+		if verb, ok := method.Annotations.Get(methodAnnotation); ok {
+			op.verb = strings.ToUpper(verb)
+			if !verbs[op.verb] {
+				g.errorf(method.Pos, "%s of method %s.%s must be GET, POST, PUT, PATCH, or DELETE, not %q",
+					methodAnnotation, op.service.Name, method.Name, verb)
+				continue
+			}
+		}
+		op.path = fmt.Sprintf("/%s/%s", service.Name, method.Name)
+		if path, ok := method.Annotations.Get(pathAnnotation); ok {
+			op.path = path
+		}
+		route, ok := g.route(op)
+		if !ok {
+			continue
+		}
+		if other, ok := routes[route]; ok {
+			g.errorf(method.Pos, "method %s.%s is routed to %s %s, which is already used by method %s",
+				op.service.Name, method.Name, op.verb, op.path, other.Name)
+			continue
+		}
+		routes[route] = method
+		op.statuses = make(map[*parser.Field]int, len(method.Exceptions))
+		for _, exception := range method.Exceptions {
+			op.statuses[exception] = g.exceptionStatus(op, exception)
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// methods returns the methods of the given service declared in the given
+// Frugal, preceded by those of the services it extends.
+func (g *Generator) methods(frugal *parser.Frugal, service *parser.Service) []*operation {
+	ops := []*operation{}
+	if service.Extends != "" {
+		base := frugal
+		if include := service.ExtendsInclude(); include != "" {
+			base = frugal.ParsedIncludes[include]
+		}
+		if base != nil {
+			for _, baseService := range base.Services {
+				if baseService.Name == service.ExtendsService() {
+					ops = g.methods(base, baseService)
+				}
+			}
+		}
+	}
+	for _, method := range service.Methods {
+		ops = append(ops, &operation{frugal: frugal, service: service, method: method})
+	}
+	return ops
+}
+
+// route validates the path of the given operation, assigning its arguments
+// to the path, query, or body. It returns the route with its variables
+// elided so conflicting routes can be detected.
+func (g *Generator) route(op *operation) (string, bool) {
+	method := op.method
+	if !strings.HasPrefix(op.path, "/") {
+		g.errorf(method.Pos, "%s of method %s.%s must start with /", pathAnnotation, op.service.Name, method.Name)
+		return "", false
+	}
+
+	args := make(map[string]*parser.Field, len(method.Arguments))
+	for _, arg := range method.Arguments {
+		args[arg.Name] = arg
+	}
+	inPath := make(map[string]bool)
+	segments := pathSegments(op.path)
+	for i, segment := range segments {
+		name, ok := pathVariable(segment)
+		if !ok {
+			if strings.ContainsAny(segment, "{}") {
+				g.errorf(method.Pos, "path %s of method %s.%s has a variable which isn't a whole segment",
+					op.path, op.service.Name, method.Name)
+				return "", false
+			}
+			continue
+		}
+		arg, ok := args[name]
+		if !ok {
+			g.errorf(method.Pos, "path %s of method %s.%s has variable %s, which isn't an argument",
+				op.path, op.service.Name, method.Name, name)
+			return "", false
+		}
+		if !isScalar(op.frugal, arg.Type) {
+			g.errorf(method.Pos, "path %s of method %s.%s has variable %s, which isn't a base type or enum",
+				op.path, op.service.Name, method.Name, name)
+			return "", false
+		}
+		if inPath[name] {
+			g.errorf(method.Pos, "path %s of method %s.%s has variable %s more than once",
+				op.path, op.service.Name, method.Name, name)
+			return "", false
+		}
+		inPath[name] = true
+		op.pathArgs = append(op.pathArgs, arg)
+		segments[i] = "{}"
+	}
+
+	for _, arg := range method.Arguments {
+		switch {
+		case inPath[arg.Name]:
+		case !hasBody(op.verb) && isScalar(op.frugal, arg.Type):
+			op.queryArgs = append(op.queryArgs, arg)
+		case !hasBody(op.verb):
+			g.errorf(arg.Pos, "argument %s of method %s.%s can't be passed in the query of a %s request",
+				arg.Name, op.service.Name, method.Name, op.verb)
+			return "", false
+		default:
+			op.bodyArgs = append(op.bodyArgs, arg)
+		}
+	}
+
+	return op.verb + " /" + strings.Join(segments, "/"), true
+}
+
+// exceptionStatus returns the HTTP status code the given exception of the
+// operation's method is returned with.
+func (g *Generator) exceptionStatus(op *operation, exception *parser.Field) int {
+	value, ok := exception.Annotations.Get(statusAnnotation)
+	pos := exception.Pos
+	if !ok {
+		if declaring, resolved := op.frugal.ResolveType(exception.Type); declaring != nil {
+			if s := findStruct(declaring, resolved); s != nil {
+				value, ok = s.Annotations.Get(statusAnnotation)
+				pos = s.Pos
+			}
+		}
+	}
+	if !ok {
+		return defaultExceptionStatus
+	}
+	status, err := strconv.Atoi(value)
+	if err != nil || status < 400 || status > 599 {
+		g.errorf(pos, "%s of exception %s must be a status code from 400 to 599, not %q",
+			statusAnnotation, exception.Type.Name, value)
+		return defaultExceptionStatus
+	}
+	return status
+}
+
+// hasBody indicates if requests with the given HTTP method have a body.
+func hasBody(verb string) bool {
+	return verb != "GET" && verb != "DELETE"
+}
+
+// pathSegments splits the given path on /.
+func pathSegments(path string) []string {
+	return strings.Split(strings.TrimPrefix(path, "/"), "/")
+}
+
+// pathVariable returns the name of the variable the given path segment is,
+// if it's written as {name}.
+func pathVariable(segment string) (string, bool) {
+	if len(segment) < 3 || segment[0] != '{' || segment[len(segment)-1] != '}' {
+		return "", false
+	}
+	name := segment[1 : len(segment)-1]
+	if strings.ContainsAny(name, "{}") {
+		return "", false
+	}
+	return name, true
+}
+
+// isScalar indicates if the given type resolves to a base type or enum,
+// which can be passed in a path or query.
+func isScalar(f *parser.Frugal, t *parser.Type) bool {
+	declaring, resolved := f.ResolveType(t)
+	if declaring == nil || resolved.IsContainer() {
+		return false
+	}
+	return resolved.IsPrimitive() || findEnum(declaring, resolved) != nil
+}
+
+// isQuoted indicates if the given type resolves to a string or enum, which
+// are quoted in JSON.
+func isQuoted(f *parser.Frugal, t *parser.Type) bool {
+	declaring, resolved := f.ResolveType(t)
+	if declaring == nil || resolved.IsContainer() {
+		return false
+	}
+	return resolved.Name == "string" || findEnum(declaring, resolved) != nil
+}
+
+// findEnum returns the enum the given type resolved in its declaring Frugal
+// refers to, if any.
+func findEnum(declaring *parser.Frugal, resolved *parser.Type) *parser.Enum {
+	for _, enum := range declaring.Enums {
+		if enum.Name == resolved.ParamName() {
+			return enum
+		}
+	}
+	return nil
+}
+
+// findStruct returns the struct, union, or exception the given type resolved
+// in its declaring Frugal refers to, if any.
+func findStruct(declaring *parser.Frugal, resolved *parser.Type) *parser.Struct {
+	for _, s := range declaring.DataStructures() {
+		if s.Name == resolved.ParamName() {
+			return s
+		}
+	}
+	return nil
+}
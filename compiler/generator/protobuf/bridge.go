@@ -0,0 +1,570 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package protobuf
+
+import (
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Workiva/frugal/compiler/generator/golang"
+	"github.com/Workiva/frugal/compiler/globals"
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+const frugalImport = "github.com/Workiva/frugal/lib/go"
+
+// goBaseTypes maps Frugal base types to the Go types protoc-gen-go uses for
+// their protobuf scalar types.
+var goBaseTypes = map[string]string{
+	"bool":   "bool",
+	"byte":   "int32",
+	"i8":     "int32",
+	"i16":    "int32",
+	"i32":    "int32",
+	"i64":    "int64",
+	"double": "float64",
+	"string": "string",
+	"binary": "[]byte",
+}
+
+// bridge generates the Go package converting between the Go code generated
+// for a Frugal and the Go code protoc-gen-go generates for its .proto file.
+type bridge struct {
+	*Generator
+	imports map[string]string // Import path to package name
+	names   map[*parser.Frugal]*golang.Names
+}
+
+// generateBridge writes the Go bridge package for the Frugal being generated
+// to <name>bridge/<name>_bridge.go in the output directory.
+func (g *Generator) generateBridge(outputDir string) error {
+	if len(g.frugal.DataStructures()) == 0 && len(g.frugal.Services) == 0 {
+		return nil
+	}
+	b := &bridge{
+		Generator: g,
+		imports:   make(map[string]string),
+		names:     make(map[*parser.Frugal]*golang.Names),
+	}
+
+	contents := ""
+	for _, s := range g.frugal.DataStructures() {
+		contents += b.generateConverters(s)
+	}
+	for _, service := range g.frugal.Services {
+		contents += b.generateServer(service)
+		contents += b.generateHandler(service)
+	}
+	if len(g.frugal.Services) > 0 {
+		contents += b.generateContexts()
+	}
+
+	header := fmt.Sprintf("// Autogenerated by Frugal Compiler (%s)\n", globals.Version)
+	header += "// DO NOT EDIT UNLESS YOU ARE SURE THAT YOU KNOW WHAT YOU ARE DOING\n\n"
+	header += fmt.Sprintf("package %s\n\n", bridgePackage(g.frugal))
+	header += b.generateImports()
+
+	formatted, err := format.Source([]byte(header + contents))
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(outputDir, bridgePackage(g.frugal))
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, g.frugal.Name+"_bridge.go"), formatted, 0666)
+}
+
+func (b *bridge) generateImports() string {
+	std, other := []string{}, []string{}
+	for path := range b.imports {
+		if b.imports[path] == "" {
+			std = append(std, path)
+		} else {
+			other = append(other, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+
+	contents := "import (\n"
+	for _, path := range std {
+		contents += fmt.Sprintf("\t\"%s\"\n", path)
+	}
+	if len(std) > 0 && len(other) > 0 {
+		contents += "\n"
+	}
+	for _, path := range other {
+		contents += fmt.Sprintf("\t%s \"%s\"\n", b.imports[path], path)
+	}
+	contents += ")\n\n"
+	return contents
+}
+
+// generateConverters generates the functions converting the given struct,
+// union, or exception to and from its protobuf message.
+func (b *bridge) generateConverters(s *parser.Struct) string {
+	names := b.goNames(b.frugal)
+	goType := names.Struct(s)
+	pbType := b.pbPackage(b.frugal) + "." + goCamelCase(protoName(s.Name, s.Annotations))
+	suffix := goCamelCase(protoName(s.Name, s.Annotations))
+
+	contents := fmt.Sprintf("// ToProto%s converts the given Frugal %s to its protobuf message.\n", suffix, s.Name)
+	contents += fmt.Sprintf("func ToProto%s(p *%s) *%s {\n", suffix, goType, pbType)
+	contents += "\tif p == nil {\n\t\treturn nil\n\t}\n"
+	contents += fmt.Sprintf("\tm := &%s{}\n", pbType)
+	for _, field := range s.Fields {
+		if b.skipped[field] {
+			continue
+		}
+		if s.Type == parser.StructTypeUnion {
+			contents += b.generateOneofToProto(s, field)
+		} else {
+			contents += b.generateFieldToProto(field)
+		}
+	}
+	contents += "\treturn m\n"
+	contents += "}\n\n"
+
+	contents += fmt.Sprintf("// FromProto%s converts the given protobuf message to its Frugal %s.\n", suffix, s.Name)
+	contents += fmt.Sprintf("func FromProto%s(m *%s) *%s {\n", suffix, pbType, goType)
+	contents += "\tif m == nil {\n\t\treturn nil\n\t}\n"
+	contents += fmt.Sprintf("\tp := %s()\n", names.Constructor(s))
+	if s.Type == parser.StructTypeUnion {
+		contents += b.generateOneofFromProto(s)
+	} else {
+		for _, field := range s.Fields {
+			if !b.skipped[field] {
+				contents += b.generateFieldFromProto(field)
+			}
+		}
+	}
+	contents += "\treturn p\n"
+	contents += "}\n\n"
+	return contents
+}
+
+func (b *bridge) generateFieldToProto(field *parser.Field) string {
+	src := "p." + b.goNames(b.frugal).Field(field)
+	dst := "m." + goCamelCase(fieldName(field))
+	frugalPtr, protoPtr := b.isFrugalPointer(field), b.hasPresence(b.frugal, field)
+	switch {
+	case frugalPtr && protoPtr:
+		return fmt.Sprintf("\tif %s != nil {\n\t\tv := %s\n\t\t%s = &v\n\t}\n", src, b.toProto(b.frugal, field.Type, "*"+src), dst)
+	case frugalPtr:
+		return fmt.Sprintf("\tif %s != nil {\n\t\t%s = %s\n\t}\n", src, dst, b.toProto(b.frugal, field.Type, "*"+src))
+	case protoPtr:
+		return fmt.Sprintf("\t{\n\t\tv := %s\n\t\t%s = &v\n\t}\n", b.toProto(b.frugal, field.Type, src), dst)
+	}
+	return fmt.Sprintf("\t%s = %s\n", dst, b.toProto(b.frugal, field.Type, src))
+}
+
+func (b *bridge) generateFieldFromProto(field *parser.Field) string {
+	src := "m." + goCamelCase(fieldName(field))
+	dst := "p." + b.goNames(b.frugal).Field(field)
+	frugalPtr, protoPtr := b.isFrugalPointer(field), b.hasPresence(b.frugal, field)
+	switch {
+	case frugalPtr && protoPtr:
+		return fmt.Sprintf("\tif %s != nil {\n\t\tv := %s\n\t\t%s = &v\n\t}\n", src, b.fromProto(b.frugal, field.Type, "*"+src), dst)
+	case frugalPtr:
+		return fmt.Sprintf("\tif %s != nil {\n\t\tv := %s\n\t\t%s = &v\n\t}\n", src, b.fromProto(b.frugal, field.Type, src), dst)
+	case protoPtr:
+		return fmt.Sprintf("\tif %s != nil {\n\t\t%s = %s\n\t}\n", src, dst, b.fromProto(b.frugal, field.Type, "*"+src))
+	}
+	return fmt.Sprintf("\t%s = %s\n", dst, b.fromProto(b.frugal, field.Type, src))
+}
+
+func (b *bridge) generateOneofToProto(union *parser.Struct, field *parser.Field) string {
+	src := "p." + b.goNames(b.frugal).Field(field)
+	value := src
+	if b.isFrugalPointer(field) {
+		value = "*" + src
+	}
+	fieldName := goCamelCase(fieldName(field))
+	return fmt.Sprintf("\tif %s != nil {\n\t\tm.%s = &%s{%s: %s}\n\t}\n",
+		src, goCamelCase(oneofName(union)), b.oneofWrapper(union, field), fieldName, b.toProto(b.frugal, field.Type, value))
+}
+
+func (b *bridge) generateOneofFromProto(union *parser.Struct) string {
+	contents := fmt.Sprintf("\tswitch v := m.%s.(type) {\n", goCamelCase(oneofName(union)))
+	for _, field := range union.Fields {
+		if b.skipped[field] {
+			continue
+		}
+		dst := "p." + b.goNames(b.frugal).Field(field)
+		value := b.fromProto(b.frugal, field.Type, "v."+goCamelCase(fieldName(field)))
+		contents += fmt.Sprintf("\tcase *%s:\n", b.oneofWrapper(union, field))
+		if b.isFrugalPointer(field) {
+			contents += fmt.Sprintf("\t\tvalue := %s\n\t\t%s = &value\n", value, dst)
+		} else {
+			contents += fmt.Sprintf("\t\t%s = %s\n", dst, value)
+		}
+	}
+	contents += "\t}\n"
+	return contents
+}
+
+// oneofWrapper returns the type protoc-gen-go generates to hold the given
+// field of a union's oneof.
+func (b *bridge) oneofWrapper(union *parser.Struct, field *parser.Field) string {
+	return fmt.Sprintf("%s.%s_%s", b.pbPackage(b.frugal), goCamelCase(protoName(union.Name, union.Annotations)),
+		goCamelCase(fieldName(field)))
+}
+
+// generateServer generates a gRPC server which calls a Frugal handler.
+func (b *bridge) generateServer(service *parser.Service) string {
+	names := b.goNames(b.frugal)
+	name := goCamelCase(protoName(service.Name, service.Annotations))
+	pb := b.pbPackage(b.frugal)
+	b.imports["context"] = ""
+
+	contents := fmt.Sprintf("// %sGRPCServer serves a Frugal %s handler as a gRPC %sServer.\n", name, service.Name, name)
+	contents += fmt.Sprintf("type %sGRPCServer struct {\n", name)
+	contents += fmt.Sprintf("\t%s.Unimplemented%sServer\n", pb, name)
+	contents += fmt.Sprintf("\thandler %s\n", names.Service(service))
+	contents += "}\n\n"
+	contents += fmt.Sprintf("// New%sGRPCServer returns a gRPC server which calls the given handler.\n", name)
+	contents += fmt.Sprintf("func New%sGRPCServer(handler %s) *%sGRPCServer {\n", name, names.Service(service), name)
+	contents += fmt.Sprintf("\treturn &%sGRPCServer{handler: handler}\n", name)
+	contents += "}\n\n"
+
+	for _, method := range service.Methods {
+		if b.excluded[method] {
+			continue
+		}
+		request := pb + "." + goCamelCase(requestName(service, method))
+		response := pb + "." + goCamelCase(responseName(service, method))
+		contents += fmt.Sprintf("func (s *%sGRPCServer) %s(ctx context.Context, req *%s) (*%s, error) {\n",
+			name, goCamelCase(rpcName(method)), request, response)
+		args := ""
+		for _, arg := range method.Arguments {
+			getter := "req.Get" + goCamelCase(fieldName(arg)) + "()"
+			args += ", " + b.fromProto(b.frugal, arg.Type, getter)
+		}
+		returns := "err"
+		if method.ReturnType != nil {
+			returns = "r, err"
+		}
+		result := goCamelCase(resultOneofName(method))
+		contents += fmt.Sprintf("\t%s := s.handler.%s(newFContext(ctx)%s)\n", returns, names.Method(method), args)
+		contents += fmt.Sprintf("\tresp := &%s{}\n", response)
+		contents += "\tif err != nil {\n"
+		if len(method.Exceptions) > 0 {
+			contents += "\t\tswitch e := err.(type) {\n"
+			for _, exception := range method.Exceptions {
+				contents += fmt.Sprintf("\t\tcase %s:\n", b.goType(b.frugal, exception.Type))
+				contents += fmt.Sprintf("\t\t\tresp.%s = &%s{%s: %s}\n", result, b.resultWrapper(service, method, exception),
+					goCamelCase(fieldName(exception)), b.toProto(b.frugal, exception.Type, "e"))
+			}
+			contents += "\t\tdefault:\n"
+			contents += "\t\t\treturn nil, err\n"
+			contents += "\t\t}\n"
+			contents += "\t\treturn resp, nil\n"
+		} else {
+			contents += "\t\treturn nil, err\n"
+		}
+		contents += "\t}\n"
+		if method.ReturnType != nil {
+			value := b.toProto(b.frugal, method.ReturnType, "r")
+			if b.wrapsSuccess(method) {
+				value = fmt.Sprintf("&%s_%s{Value: %s}", response, successValueName, value)
+			}
+			contents += fmt.Sprintf("\tresp.%s = &%s_Success{Success: %s}\n", result, response, value)
+		}
+		contents += "\treturn resp, nil\n"
+		contents += "}\n\n"
+	}
+	return contents
+}
+
+// generateHandler generates a Frugal handler which calls a gRPC client. It
+// can only be generated when every method of the service is in protobuf.
+func (b *bridge) generateHandler(service *parser.Service) string {
+	if service.Extends != "" {
+		b.unsupported(service.Pos, "the Go bridge can't implement service %s since it extends %s, so no gRPC handler was generated",
+			service.Name, service.Extends)
+		return ""
+	}
+	for _, method := range service.Methods {
+		if b.excluded[method] {
+			b.unsupported(service.Pos, "the Go bridge can't implement service %s without method %s, so no gRPC handler was generated",
+				service.Name, method.Name)
+			return ""
+		}
+	}
+
+	names := b.goNames(b.frugal)
+	name := goCamelCase(protoName(service.Name, service.Annotations))
+	pb := b.pbPackage(b.frugal)
+	b.imports[frugalImport] = "frugal"
+
+	contents := fmt.Sprintf("// %sGRPCHandler implements the Frugal %s handler by calling a gRPC\n", name, service.Name)
+	contents += fmt.Sprintf("// %sClient.\n", name)
+	contents += fmt.Sprintf("type %sGRPCHandler struct {\n", name)
+	contents += fmt.Sprintf("\tclient %s.%sClient\n", pb, name)
+	contents += "}\n\n"
+	contents += fmt.Sprintf("// New%sGRPCHandler returns a Frugal handler which calls the given client.\n", name)
+	contents += fmt.Sprintf("func New%sGRPCHandler(client %s.%sClient) %s {\n", name, pb, name, names.Service(service))
+	contents += fmt.Sprintf("\treturn &%sGRPCHandler{client: client}\n", name)
+	contents += "}\n\n"
+
+	for _, method := range service.Methods {
+		params := ""
+		for _, arg := range method.Arguments {
+			params += fmt.Sprintf(", %s %s", arg.Name, b.goType(b.frugal, arg.Type))
+		}
+		results := "(err error)"
+		if method.ReturnType != nil {
+			results = fmt.Sprintf("(r %s, err error)", b.goType(b.frugal, method.ReturnType))
+		}
+		contents += fmt.Sprintf("func (h *%sGRPCHandler) %s(fctx frugal.FContext%s) %s {\n",
+			name, names.Method(method), params, results)
+		contents += "\tgrpcCtx, grpcCancel := newContext(fctx)\n"
+		contents += "\tdefer grpcCancel()\n"
+		contents += fmt.Sprintf("\tgrpcReq := &%s.%s{}\n", pb, goCamelCase(requestName(service, method)))
+		for _, arg := range method.Arguments {
+			dst := "grpcReq." + goCamelCase(fieldName(arg))
+			if b.hasPresence(b.frugal, arg) {
+				contents += fmt.Sprintf("\t{\n\t\tv := %s\n\t\t%s = &v\n\t}\n", b.toProto(b.frugal, arg.Type, arg.Name), dst)
+			} else {
+				contents += fmt.Sprintf("\t%s = %s\n", dst, b.toProto(b.frugal, arg.Type, arg.Name))
+			}
+		}
+		contents += fmt.Sprintf("\tgrpcResp, err := h.client.%s(grpcCtx, grpcReq)\n", goCamelCase(rpcName(method)))
+		contents += "\tif err != nil {\n\t\treturn\n\t}\n"
+		if len(method.Exceptions) > 0 {
+			contents += fmt.Sprintf("\tswitch v := grpcResp.%s.(type) {\n", goCamelCase(resultOneofName(method)))
+			for _, exception := range method.Exceptions {
+				contents += fmt.Sprintf("\tcase *%s:\n", b.resultWrapper(service, method, exception))
+				contents += fmt.Sprintf("\t\terr = %s\n",
+					b.fromProto(b.frugal, exception.Type, "v."+goCamelCase(fieldName(exception))))
+				contents += "\t\treturn\n"
+			}
+			contents += "\t}\n"
+		}
+		if method.ReturnType != nil {
+			success := "grpcResp.GetSuccess()"
+			if b.wrapsSuccess(method) {
+				success += ".GetValue()"
+			}
+			contents += fmt.Sprintf("\tr = %s\n", b.fromProto(b.frugal, method.ReturnType, success))
+		}
+		contents += "\treturn\n"
+		contents += "}\n\n"
+	}
+	return contents
+}
+
+// resultWrapper returns the type protoc-gen-go generates to hold the given
+// exception in the result oneof of a method's response.
+func (b *bridge) resultWrapper(service *parser.Service, method *parser.Method, exception *parser.Field) string {
+	return fmt.Sprintf("%s.%s_%s", b.pbPackage(b.frugal), goCamelCase(responseName(service, method)),
+		goCamelCase(fieldName(exception)))
+}
+
+// wrapsSuccess returns true if the method's success value is wrapped in a
+// SuccessValue message since it can't be part of a oneof.
+func (b *bridge) wrapsSuccess(method *parser.Method) bool {
+	typ, _ := b.protoType(b.frugal, method.ReturnType)
+	return isRepeated(typ)
+}
+
+// generateContexts generates the functions converting deadlines between
+// gRPC contexts and FContexts.
+func (b *bridge) generateContexts() string {
+	b.imports["context"] = ""
+	b.imports["time"] = ""
+	b.imports[frugalImport] = "frugal"
+
+	contents := "// newFContext returns an FContext whose timeout is the time until the\n"
+	contents += "// deadline of the given context, if it has one.\n"
+	contents += "func newFContext(ctx context.Context) frugal.FContext {\n"
+	contents += "\tfctx := frugal.NewFContext(\"\")\n"
+	contents += "\tif deadline, ok := ctx.Deadline(); ok {\n"
+	contents += "\t\tfctx.SetTimeout(time.Until(deadline))\n"
+	contents += "\t}\n"
+	contents += "\treturn fctx\n"
+	contents += "}\n\n"
+	contents += "// newContext returns a context whose deadline is the timeout of the given\n"
+	contents += "// FContext.\n"
+	contents += "func newContext(fctx frugal.FContext) (context.Context, context.CancelFunc) {\n"
+	contents += "\treturn context.WithTimeout(context.Background(), fctx.Timeout())\n"
+	contents += "}\n"
+	return contents
+}
+
+// toProto returns an expression converting expr, a Go value of the given
+// type used in the given file, to its protobuf Go type.
+func (b *bridge) toProto(f *parser.Frugal, t *parser.Type, expr string) string {
+	frugal, resolved := f.ResolveType(t)
+	switch resolved.Name {
+	case "list", "set":
+		loop := "for _, e := range"
+		if resolved.Name == "set" {
+			loop = "for e := range"
+		}
+		elem := b.pbGoType(frugal, resolved.ValueType)
+		return fmt.Sprintf("func() []%s {\n\t\tif %s == nil {\n\t\t\treturn nil\n\t\t}\n"+
+			"\t\tvalues := make([]%s, 0, len(%s))\n\t\t%s %s {\n\t\t\tvalues = append(values, %s)\n\t\t}\n\t\treturn values\n\t}()",
+			elem, expr, elem, expr, loop, expr, b.toProto(frugal, resolved.ValueType, "e"))
+	case "map":
+		mapType := fmt.Sprintf("map[%s]%s", b.pbGoType(frugal, resolved.KeyType), b.pbGoType(frugal, resolved.ValueType))
+		return fmt.Sprintf("func() %s {\n\t\tif %s == nil {\n\t\t\treturn nil\n\t\t}\n"+
+			"\t\tvalues := make(%s, len(%s))\n\t\tfor k, v := range %s {\n\t\t\tvalues[%s] = %s\n\t\t}\n\t\treturn values\n\t}()",
+			mapType, expr, mapType, expr, expr, b.toProto(frugal, resolved.KeyType, "k"), b.toProto(frugal, resolved.ValueType, "v"))
+	}
+	if base, ok := goBaseTypes[resolved.Name]; ok {
+		return fmt.Sprintf("%s(%s)", base, expr)
+	}
+	name, enum := b.definitionName(frugal, resolved)
+	if enum {
+		return fmt.Sprintf("%s.%s(%s)", b.pbPackage(frugal), name, expr)
+	}
+	return fmt.Sprintf("%sToProto%s(%s)", b.bridgeQualifier(frugal), name, expr)
+}
+
+// fromProto returns an expression converting expr, a protobuf Go value, to
+// the Go type of the given type used in the given file.
+func (b *bridge) fromProto(f *parser.Frugal, t *parser.Type, expr string) string {
+	frugal, resolved := f.ResolveType(t)
+	goType := b.goType(f, t)
+	switch resolved.Name {
+	case "list":
+		return fmt.Sprintf("func() %s {\n\t\tif %s == nil {\n\t\t\treturn nil\n\t\t}\n"+
+			"\t\tvalues := make(%s, 0, len(%s))\n\t\tfor _, e := range %s {\n\t\t\tvalues = append(values, %s)\n\t\t}\n\t\treturn values\n\t}()",
+			goType, expr, goType, expr, expr, b.fromProto(frugal, resolved.ValueType, "e"))
+	case "set":
+		return fmt.Sprintf("func() %s {\n\t\tif %s == nil {\n\t\t\treturn nil\n\t\t}\n"+
+			"\t\tvalues := make(%s, len(%s))\n\t\tfor _, e := range %s {\n\t\t\tvalues[%s] = true\n\t\t}\n\t\treturn values\n\t}()",
+			goType, expr, goType, expr, expr, b.fromProto(frugal, resolved.ValueType, "e"))
+	case "map":
+		return fmt.Sprintf("func() %s {\n\t\tif %s == nil {\n\t\t\treturn nil\n\t\t}\n"+
+			"\t\tvalues := make(%s, len(%s))\n\t\tfor k, v := range %s {\n\t\t\tvalues[%s] = %s\n\t\t}\n\t\treturn values\n\t}()",
+			goType, expr, goType, expr, expr, b.fromProto(frugal, resolved.KeyType, "k"), b.fromProto(frugal, resolved.ValueType, "v"))
+	}
+	if _, ok := goBaseTypes[resolved.Name]; ok {
+		return fmt.Sprintf("%s(%s)", goType, expr)
+	}
+	name, enum := b.definitionName(frugal, resolved)
+	if enum {
+		return fmt.Sprintf("%s(%s)", goType, expr)
+	}
+	return fmt.Sprintf("%sFromProto%s(%s)", b.bridgeQualifier(frugal), name, expr)
+}
+
+// pbGoType returns the protobuf Go type of the given element type, which
+// isn't a container, used in the given file.
+func (b *bridge) pbGoType(f *parser.Frugal, t *parser.Type) string {
+	frugal, resolved := f.ResolveType(t)
+	if base, ok := goBaseTypes[resolved.Name]; ok {
+		return base
+	}
+	name, enum := b.definitionName(frugal, resolved)
+	if enum {
+		return b.pbPackage(frugal) + "." + name
+	}
+	return "*" + b.pbPackage(frugal) + "." + name
+}
+
+// definitionName returns the protobuf Go name of the enum, struct, union, or
+// exception the given type refers to, and whether it's an enum.
+func (b *bridge) definitionName(frugal *parser.Frugal, t *parser.Type) (string, bool) {
+	name := t.ParamName()
+	for _, enum := range frugal.Enums {
+		if enum.Name == name {
+			return goCamelCase(protoName(enum.Name, enum.Annotations)), true
+		}
+	}
+	for _, s := range frugal.DataStructures() {
+		if s.Name == name {
+			return goCamelCase(protoName(s.Name, s.Annotations)), false
+		}
+	}
+	return goCamelCase(name), false
+}
+
+// goType returns the Go type generated for the given type used in the given
+// file, importing the packages it refers to.
+func (b *bridge) goType(f *parser.Frugal, t *parser.Type) string {
+	b.importTypes(f, t)
+	return b.goNames(f).Type(t)
+}
+
+func (b *bridge) importTypes(f *parser.Frugal, t *parser.Type) {
+	if t.KeyType != nil {
+		b.importTypes(f, t.KeyType)
+	}
+	if t.ValueType != nil {
+		b.importTypes(f, t.ValueType)
+	}
+	if t.IsPrimitive() || t.IsContainer() {
+		return
+	}
+	declaring := f
+	if include := t.IncludeName(); include != "" {
+		if declaring = f.ParsedIncludes[include]; declaring == nil {
+			return
+		}
+	}
+	b.imports[golang.ImportPath(declaring, b.options[frugalPackagePrefixOption])] = golang.PackageName(declaring)
+}
+
+func (b *bridge) goNames(f *parser.Frugal) *golang.Names {
+	names, ok := b.names[f]
+	if !ok {
+		names = golang.NewNames(f)
+		b.names[f] = names
+		b.imports[golang.ImportPath(f, b.options[frugalPackagePrefixOption])] = golang.PackageName(f)
+	}
+	return names
+}
+
+// pbPackage returns the name of the protobuf Go package for the given file,
+// importing it.
+func (b *bridge) pbPackage(f *parser.Frugal) string {
+	b.imports[b.protoGoImportPath(f)] = protoGoPackage(f)
+	return protoGoPackage(f)
+}
+
+// bridgeQualifier returns the qualifier for functions in the bridge package
+// of the given file, importing it if it isn't the one being generated.
+func (b *bridge) bridgeQualifier(f *parser.Frugal) string {
+	if f == b.frugal {
+		return ""
+	}
+	b.imports[b.options[packagePrefixOption]+bridgePackage(f)] = bridgePackage(f)
+	return bridgePackage(f) + "."
+}
+
+// isFrugalPointer indicates if the Go field for the given field, declared in
+// the Frugal being generated, is a pointer to the value converted. Structs
+// are always pointers, and are converted as pointers.
+func (b *bridge) isFrugalPointer(field *parser.Field) bool {
+	frugal, t := b.frugal.ResolveType(field.Type)
+	if frugal != nil && t.IsCustom() && !isEnum(frugal, t) {
+		return false
+	}
+	return b.goNames(b.frugal).IsPointerField(field)
+}
+
+// bridgePackage returns the name of the Go bridge package for the given
+// Frugal.
+func bridgePackage(f *parser.Frugal) string {
+	return strings.ToLower(f.Name) + "bridge"
+}
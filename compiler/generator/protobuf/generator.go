@@ -0,0 +1,608 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package protobuf generates proto3 definitions from Frugal IDL, along with
+// an optional Go bridge between Frugal-generated and protobuf-generated code.
+package protobuf
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Workiva/frugal/compiler/generator"
+	"github.com/Workiva/frugal/compiler/globals"
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+const (
+	lang                      = "proto"
+	defaultOutputDir          = "gen-proto"
+	goBridgeOption            = "go_bridge"
+	packagePrefixOption       = "package_prefix"
+	frugalPackagePrefixOption = "frugal_package_prefix"
+	strictOption              = "strict"
+
+	// nameAnnotation overrides the name a definition is given in protobuf.
+	nameAnnotation = "proto.name"
+
+	// Field numbers protobuf allows, excluding those it reserves.
+	maxFieldNumber      = 536870911
+	firstReservedNumber = 19000
+	lastReservedNumber  = 19999
+	// Responses mirror the method's Frugal result struct, whose success
+	// field has ID 0, shifted by one since protobuf field numbers start at 1.
+	successFieldNumber    = 1
+	exceptionNumberOffset = 1
+
+	// successValueName is the message nested in a response to wrap a
+	// success value which can't be part of a oneof.
+	successValueName = "SuccessValue"
+)
+
+// protoBaseTypes maps Frugal base types to protobuf scalar types.
+var protoBaseTypes = map[string]string{
+	"bool":   "bool",
+	"byte":   "int32",
+	"i8":     "int32",
+	"i16":    "int32",
+	"i32":    "int32",
+	"i64":    "int64",
+	"double": "double",
+	"string": "string",
+	"binary": "bytes",
+}
+
+// protoKeyTypes are the base types protobuf allows as map keys.
+var protoKeyTypes = map[string]bool{
+	"bool":   true,
+	"byte":   true,
+	"i8":     true,
+	"i16":    true,
+	"i32":    true,
+	"i64":    true,
+	"string": true,
+}
+
+// Generator implements the ProgramGenerator interface for protobuf.
+type Generator struct {
+	options map[string]string
+
+	// State for the Frugal being generated.
+	frugal   *parser.Frugal
+	imports  map[string]bool
+	report   parser.Diagnostics
+	skipped  map[*parser.Field]bool  // Fields which couldn't be represented
+	excluded map[*parser.Method]bool // Methods which couldn't be represented
+}
+
+// NewGenerator creates a new protobuf ProgramGenerator.
+func NewGenerator(options map[string]string) generator.ProgramGenerator {
+	for _, option := range []string{packagePrefixOption, frugalPackagePrefixOption} {
+		if prefix := options[option]; prefix != "" && !strings.HasSuffix(prefix, "/") {
+			options[option] = prefix + "/"
+		}
+	}
+	return &Generator{options: options}
+}
+
+// Generate writes <name>.proto for the Frugal to the output directory, and
+// its Go bridge package with the go_bridge option. Constructs protobuf can't
+// represent are skipped and reported as warnings, or errors with the strict
+// option.
+func (g *Generator) Generate(frugal *parser.Frugal, outputDir string) error {
+	g.frugal = frugal
+	g.imports = make(map[string]bool)
+	g.report = nil
+	g.skipped = make(map[*parser.Field]bool)
+	g.excluded = make(map[*parser.Method]bool)
+
+	file, err := os.Create(fmt.Sprintf("%s/%s.proto", outputDir, frugal.Name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := file.WriteString(g.generateProto()); err != nil {
+		return err
+	}
+
+	if _, ok := g.options[goBridgeOption]; ok {
+		if err := g.generateBridge(outputDir); err != nil {
+			return err
+		}
+	}
+
+	if len(g.report) == 0 {
+		return nil
+	}
+	sort.Stable(g.report)
+	if _, ok := g.options[strictOption]; ok {
+		for _, diag := range g.report {
+			diag.Severity = parser.SeverityError
+		}
+		return g.report
+	}
+	return g.report.Format(os.Stdout)
+}
+
+func (g *Generator) GetOutputDir(dir string, frugal *parser.Frugal) string {
+	return dir
+}
+
+func (g *Generator) DefaultOutputDir() string {
+	return defaultOutputDir
+}
+
+func (g *Generator) UseVendor() bool {
+	return false
+}
+
+// unsupported reports a construct which can't be represented in protobuf.
+func (g *Generator) unsupported(pos parser.Pos, format string, args ...interface{}) {
+	g.report = append(g.report, &parser.Diagnostic{
+		Severity: parser.SeverityWarning,
+		Pos:      pos,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (g *Generator) generateProto() string {
+	contents := ""
+	for _, constant := range g.frugal.Constants {
+		g.unsupported(constant.Pos, "constant %s isn't supported by protobuf and was skipped", constant.Name)
+	}
+	for _, enum := range g.frugal.Enums {
+		contents += g.generateEnum(enum)
+	}
+	for _, s := range g.frugal.Structs {
+		contents += g.generateMessage(s)
+	}
+	for _, s := range g.frugal.Unions {
+		contents += g.generateMessage(s)
+	}
+	for _, s := range g.frugal.Exceptions {
+		contents += g.generateMessage(s)
+	}
+	for _, service := range g.frugal.Services {
+		contents += g.generateService(service)
+	}
+	for _, scope := range g.frugal.Scopes {
+		g.unsupported(scope.Pos, "scope %s isn't supported by protobuf and was skipped", scope.Name)
+	}
+
+	header := fmt.Sprintf("// Autogenerated by Frugal Compiler (%s)\n", globals.Version)
+	header += "// DO NOT EDIT UNLESS YOU ARE SURE THAT YOU KNOW WHAT YOU ARE DOING\n\n"
+	header += "syntax = \"proto3\";\n\n"
+	header += fmt.Sprintf("package %s;\n\n", protoPackage(g.frugal))
+	imports := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	for _, imp := range imports {
+		header += fmt.Sprintf("import \"%s\";\n", imp)
+	}
+	if len(imports) > 0 {
+		header += "\n"
+	}
+	header += fmt.Sprintf("option go_package = \"%s\";\n", g.protoGoImportPath(g.frugal))
+	return header + contents
+}
+
+func (g *Generator) generateEnum(enum *parser.Enum) string {
+	name := protoName(enum.Name, enum.Annotations)
+	prefix := upperSnake(name) + "_"
+	contents := "\n" + generateComment(enum.Comment, "")
+	contents += fmt.Sprintf("enum %s {\n", name)
+
+	// proto3 requires the first value to be zero.
+	values := []*parser.EnumValue{}
+	for _, value := range enum.Values {
+		if value.Value == 0 {
+			values = append([]*parser.EnumValue{value}, values...)
+		} else {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 || values[0].Value != 0 {
+		contents += fmt.Sprintf("  %sUNSPECIFIED = 0;\n", prefix)
+	}
+	for _, value := range values {
+		valueName := protoName(value.Name, value.Annotations)
+		if _, ok := value.Annotations.Get(nameAnnotation); !ok && !strings.HasPrefix(valueName, prefix) {
+			valueName = prefix + valueName
+		}
+		contents += generateComment(value.Comment, "  ")
+		contents += fmt.Sprintf("  %s = %d;\n", valueName, value.Value)
+	}
+	contents += "}\n"
+	return contents
+}
+
+func (g *Generator) generateMessage(s *parser.Struct) string {
+	name := protoName(s.Name, s.Annotations)
+	contents := "\n" + generateComment(s.Comment, "")
+	contents += fmt.Sprintf("message %s {\n", name)
+	indent := "  "
+	if s.Type == parser.StructTypeUnion {
+		contents += fmt.Sprintf("  oneof %s {\n", oneofName(s))
+		indent = "    "
+	}
+	for _, field := range s.Fields {
+		line, ok := g.generateField(s, field, s.Type == parser.StructTypeUnion)
+		if !ok {
+			g.skipped[field] = true
+			continue
+		}
+		contents += generateComment(field.Comment, indent)
+		contents += indent + line
+	}
+	if s.Type == parser.StructTypeUnion {
+		contents += "  }\n"
+	}
+	contents += "}\n"
+	return contents
+}
+
+// generateField returns the declaration of the given field in a message, or
+// false if it can't be represented.
+func (g *Generator) generateField(s *parser.Struct, field *parser.Field, oneof bool) (string, bool) {
+	if !validFieldNumber(field.ID) {
+		g.unsupported(field.Pos, "field %s.%s has ID %d, which isn't a valid protobuf field number, and was skipped",
+			s.Name, field.Name, field.ID)
+		return "", false
+	}
+	typ, why := g.protoType(g.frugal, field.Type)
+	if why != "" {
+		g.unsupported(field.Pos, "field %s.%s was skipped: %s", s.Name, field.Name, why)
+		return "", false
+	}
+	if oneof && isRepeated(typ) {
+		g.unsupported(field.Pos, "field %s.%s was skipped: protobuf oneofs can't contain lists, sets, or maps",
+			s.Name, field.Name)
+		return "", false
+	}
+	if field.Default != nil {
+		g.unsupported(field.Pos, "default value of field %s.%s isn't supported by proto3 and was dropped",
+			s.Name, field.Name)
+	}
+	label := ""
+	if !oneof && g.hasPresence(g.frugal, field) {
+		label = "optional "
+	}
+	return fmt.Sprintf("%s%s %s = %d;\n", label, typ, fieldName(field), field.ID), true
+}
+
+func (g *Generator) generateService(service *parser.Service) string {
+	name := protoName(service.Name, service.Annotations)
+	if service.Extends != "" {
+		g.unsupported(service.Pos, "service %s extends %s, which protobuf doesn't support, so its methods aren't included",
+			service.Name, service.Extends)
+	}
+
+	rpcs := ""
+	messages := ""
+	for _, method := range service.Methods {
+		if method.IsStreaming() {
+			g.unsupported(method.Pos, "streaming method %s.%s isn't supported by the protobuf generator and was skipped",
+				service.Name, method.Name)
+			g.excluded[method] = true
+			continue
+		}
+		request, response, ok := g.generateMethodMessages(service, method)
+		if !ok {
+			g.excluded[method] = true
+			continue
+		}
+		rpc := rpcName(method)
+		rpcs += generateComment(method.Comment, "  ")
+		rpcs += fmt.Sprintf("  rpc %s(%s) returns (%s);\n", rpc, requestName(service, method), responseName(service, method))
+		messages += request + response
+	}
+
+	contents := "\n" + generateComment(service.Comment, "")
+	contents += fmt.Sprintf("service %s {\n", name)
+	contents += rpcs
+	contents += "}\n"
+	return contents + messages
+}
+
+// generateMethodMessages returns the request and response messages of the
+// given method, or false if they can't be represented.
+func (g *Generator) generateMethodMessages(service *parser.Service, method *parser.Method) (string, string, bool) {
+	request := fmt.Sprintf("\nmessage %s {\n", requestName(service, method))
+	for _, arg := range method.Arguments {
+		typ, why := g.protoType(g.frugal, arg.Type)
+		if why == "" && !validFieldNumber(arg.ID) {
+			why = fmt.Sprintf("argument %s has ID %d, which isn't a valid protobuf field number", arg.Name, arg.ID)
+		}
+		if why != "" {
+			g.unsupported(method.Pos, "method %s.%s was skipped: %s", service.Name, method.Name, why)
+			return "", "", false
+		}
+		label := ""
+		if g.hasPresence(g.frugal, arg) {
+			label = "optional "
+		}
+		request += fmt.Sprintf("  %s%s %s = %d;\n", label, typ, fieldName(arg), arg.ID)
+	}
+	request += "}\n"
+
+	// The success value and exceptions are in a oneof like the fields of
+	// the Frugal result struct, only one of which is ever set.
+	nested := ""
+	fields := []string{}
+	if method.ReturnType != nil {
+		typ, why := g.protoType(g.frugal, method.ReturnType)
+		if why != "" {
+			g.unsupported(method.Pos, "method %s.%s was skipped: %s", service.Name, method.Name, why)
+			return "", "", false
+		}
+		if isRepeated(typ) {
+			nested = fmt.Sprintf("  message %s {\n    %s value = 1;\n  }\n", successValueName, typ)
+			typ = successValueName
+		}
+		fields = append(fields, fmt.Sprintf("%s success = %d;\n", typ, successFieldNumber))
+	}
+	for _, exception := range method.Exceptions {
+		typ, _ := g.protoType(g.frugal, exception.Type)
+		number := exception.ID + exceptionNumberOffset
+		if !validFieldNumber(number) || number == successFieldNumber {
+			g.unsupported(method.Pos, "method %s.%s was skipped: exception %s has ID %d, which can't be numbered in protobuf",
+				service.Name, method.Name, exception.Name, exception.ID)
+			return "", "", false
+		}
+		fields = append(fields, fmt.Sprintf("%s %s = %d;\n", typ, fieldName(exception), number))
+	}
+
+	response := fmt.Sprintf("\nmessage %s {\n", responseName(service, method))
+	response += nested
+	if len(fields) > 0 {
+		response += fmt.Sprintf("  oneof %s {\n", resultOneofName(method))
+		for _, field := range fields {
+			response += "    " + field
+		}
+		response += "  }\n"
+	}
+	response += "}\n"
+	return request, response, true
+}
+
+// protoType returns the protobuf type of the given type used in the given
+// file, or why it can't be represented.
+func (g *Generator) protoType(f *parser.Frugal, t *parser.Type) (string, string) {
+	frugal, t := f.ResolveType(t)
+	if frugal == nil {
+		return "", fmt.Sprintf("type %s couldn't be resolved", t.Name)
+	}
+	switch t.Name {
+	case "list", "set":
+		if _, elem := frugal.ResolveType(t.ValueType); elem.IsContainer() {
+			return "", "protobuf doesn't support nested lists, sets, or maps"
+		}
+		elem, why := g.protoType(frugal, t.ValueType)
+		return "repeated " + elem, why
+	case "map":
+		_, key := frugal.ResolveType(t.KeyType)
+		if !protoKeyTypes[key.Name] {
+			return "", fmt.Sprintf("protobuf map keys must be integers, bools, or strings, not %s", t.KeyType.Name)
+		}
+		if _, value := frugal.ResolveType(t.ValueType); value.IsContainer() {
+			return "", "protobuf doesn't support nested lists, sets, or maps"
+		}
+		keyType, _ := g.protoType(frugal, t.KeyType)
+		valueType, why := g.protoType(frugal, t.ValueType)
+		return fmt.Sprintf("map<%s, %s>", keyType, valueType), why
+	}
+	if typ, ok := protoBaseTypes[t.Name]; ok {
+		return typ, ""
+	}
+
+	name := t.ParamName()
+	annotations := parser.Annotations{}
+	found := false
+	for _, enum := range frugal.Enums {
+		if enum.Name == name {
+			annotations, found = enum.Annotations, true
+		}
+	}
+	for _, s := range frugal.DataStructures() {
+		if s.Name == name {
+			annotations, found = s.Annotations, true
+		}
+	}
+	if !found {
+		return "", fmt.Sprintf("type %s couldn't be resolved", t.Name)
+	}
+	name = protoName(name, annotations)
+	if frugal != g.frugal {
+		g.imports[frugal.Name+".proto"] = true
+		name = protoPackage(frugal) + "." + name
+	}
+	return name, ""
+}
+
+// hasPresence indicates if the given field, used in the given file, is an
+// optional field which protobuf tracks the presence of.
+func (g *Generator) hasPresence(f *parser.Frugal, field *parser.Field) bool {
+	if field.Modifier != parser.Optional {
+		return false
+	}
+	frugal, t := f.ResolveType(field.Type)
+	if frugal == nil || t.IsContainer() {
+		return false
+	}
+	return t.IsPrimitive() || isEnum(frugal, t)
+}
+
+// isEnum indicates if the given resolved type is an enum declared in the
+// given file.
+func isEnum(frugal *parser.Frugal, t *parser.Type) bool {
+	for _, enum := range frugal.Enums {
+		if enum.Name == t.ParamName() {
+			return true
+		}
+	}
+	return false
+}
+
+// protoGoImportPath returns the import path of the Go package generated from
+// the .proto file for the given Frugal.
+func (g *Generator) protoGoImportPath(f *parser.Frugal) string {
+	return g.options[packagePrefixOption] + protoGoPackage(f)
+}
+
+// protoPackage returns the protobuf package of the given Frugal, which is its
+// proto namespace if it has one.
+func protoPackage(f *parser.Frugal) string {
+	if namespace := f.Namespace(lang); namespace != nil {
+		return namespace.Value
+	}
+	return f.Name
+}
+
+// protoGoPackage returns the name of the Go package generated from the .proto
+// file for the given Frugal.
+func protoGoPackage(f *parser.Frugal) string {
+	return strings.ToLower(f.Name) + "pb"
+}
+
+// protoName returns the name of a definition in protobuf, which can be
+// overridden with the proto.name annotation.
+func protoName(name string, annotations parser.Annotations) string {
+	if override, ok := annotations.Get(nameAnnotation); ok && override != "" {
+		return override
+	}
+	return name
+}
+
+// fieldName returns the name of a field in protobuf, which is the field's name
+// in snake_case unless it's overridden with the proto.name annotation.
+func fieldName(field *parser.Field) string {
+	if override, ok := field.Annotations.Get(nameAnnotation); ok && override != "" {
+		return override
+	}
+	return strings.ToLower(upperSnake(field.Name))
+}
+
+func rpcName(method *parser.Method) string {
+	if name, ok := method.Annotations.Get(nameAnnotation); ok && name != "" {
+		return name
+	}
+	return goCamelCase(method.Name)
+}
+
+func requestName(service *parser.Service, method *parser.Method) string {
+	return protoName(service.Name, service.Annotations) + rpcName(method) + "Request"
+}
+
+func responseName(service *parser.Service, method *parser.Method) string {
+	return protoName(service.Name, service.Annotations) + rpcName(method) + "Response"
+}
+
+// oneofName returns the name of the oneof for a union, which is "value"
+// unless a field already has that name.
+func oneofName(union *parser.Struct) string {
+	name := "value"
+	for _, field := range union.Fields {
+		if fieldName(field) == name {
+			return name + "_"
+		}
+	}
+	return name
+}
+
+// resultOneofName returns the name of the oneof holding a method's success
+// value and exceptions, which is "result" unless an exception already has
+// that name.
+func resultOneofName(method *parser.Method) string {
+	name := "result"
+	for _, exception := range method.Exceptions {
+		if fieldName(exception) == name {
+			return name + "_"
+		}
+	}
+	return name
+}
+
+func isRepeated(typ string) bool {
+	return strings.HasPrefix(typ, "repeated ") || strings.HasPrefix(typ, "map<")
+}
+
+func validFieldNumber(number int) bool {
+	return number > 0 && number <= maxFieldNumber &&
+		(number < firstReservedNumber || number > lastReservedNumber)
+}
+
+func generateComment(comment []string, indent string) string {
+	contents := ""
+	for _, line := range comment {
+		if line == "" {
+			contents += indent + "//\n"
+			continue
+		}
+		contents += indent + "// " + line + "\n"
+	}
+	return contents
+}
+
+// upperSnake converts a CamelCase or snake_case name to UPPER_SNAKE_CASE.
+func upperSnake(name string) string {
+	result := []rune{}
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			prev := runes[i-1]
+			if prev >= 'a' && prev <= 'z' || prev >= '0' && prev <= '9' {
+				result = append(result, '_')
+			}
+		}
+		result = append(result, r)
+	}
+	return strings.ToUpper(string(result))
+}
+
+// goCamelCase converts a protobuf name to the Go name protoc-gen-go gives it.
+func goCamelCase(s string) string {
+	b := []byte{}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '.' && i+1 < len(s) && isLower(s[i+1]):
+			// Skip over '.' in ".{{lowercase}}".
+		case c == '.':
+			b = append(b, '_')
+		case c == '_' && (i == 0 || s[i-1] == '.'):
+			// Convert an initial '_' so the name starts with a capital.
+			b = append(b, 'X')
+		case c == '_' && i+1 < len(s) && isLower(s[i+1]):
+			// Skip over '_' in "_{{lowercase}}".
+		case c >= '0' && c <= '9':
+			b = append(b, c)
+		default:
+			if isLower(c) {
+				c -= 'a' - 'A'
+			}
+			b = append(b, c)
+			for ; i+1 < len(s) && isLower(s[i+1]); i++ {
+				b = append(b, s[i+1])
+			}
+		}
+	}
+	return string(b)
+}
+
+func isLower(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
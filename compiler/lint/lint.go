@@ -0,0 +1,249 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package lint checks Frugal files for style and correctness problems with a
+// configurable set of rules.
+package lint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/Workiva/frugal/compiler/parser"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// Off is the severity of rules which aren't run.
+	Off = "off"
+
+	// NolintAnnotation suppresses problems within the definition it
+	// annotates. Its value is a comma-separated list of the rules to
+	// suppress, or empty to suppress all of them.
+	NolintAnnotation = "nolint"
+)
+
+// Rule checks Frugal files for a kind of problem.
+type Rule interface {
+	// Name returns the name used to configure and suppress the rule.
+	Name() string
+
+	// Description returns a short description of what the rule checks.
+	Description() string
+
+	// Check reports the problems the rule finds in the given Frugal.
+	Check(f *parser.Frugal, report Reporter)
+}
+
+// Reporter records a problem found by a Rule at the given position.
+type Reporter func(pos parser.Pos, format string, args ...interface{})
+
+type registration struct {
+	rule     Rule
+	severity string
+}
+
+var rules = make(map[string]registration)
+
+// Register adds a Rule to those run by Lint. Its problems have the given
+// severity, which is either a parser.Severity or Off, unless configured
+// otherwise. Registering a Rule with the same name as another replaces it.
+func Register(rule Rule, severity string) {
+	rules[rule.Name()] = registration{rule: rule, severity: severity}
+}
+
+// Rules returns the registered Rules sorted by name.
+func Rules() []Rule {
+	names := ruleNames()
+	registered := make([]Rule, len(names))
+	for i, name := range names {
+		registered[i] = rules[name].rule
+	}
+	return registered
+}
+
+// DefaultSeverity returns the severity of the named rule's problems when it
+// isn't configured, or an empty string if there is no such rule.
+func DefaultSeverity(name string) string {
+	return rules[name].severity
+}
+
+func ruleNames() []string {
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Config sets the severity of each rule's problems, either "error",
+// "warning", or "off". Rules which aren't configured use their default
+// severity.
+type Config struct {
+	Rules map[string]string `yaml:"rules"`
+}
+
+// LoadConfig reads a Config from the given YAML file, such as:
+//
+//	rules:
+//	  service-doc: error
+//	  field-snake-case: off
+func LoadConfig(path string) (*Config, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{}
+	if err := yaml.Unmarshal(contents, config); err != nil {
+		return nil, fmt.Errorf("lint: invalid config %s: %s", path, err)
+	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("lint: invalid config %s: %s", path, err)
+	}
+	return config, nil
+}
+
+// Validate returns an error if the Config refers to a rule which isn't
+// registered or has an invalid severity.
+func (c *Config) Validate() error {
+	for name, severity := range c.Rules {
+		if _, ok := rules[name]; !ok {
+			return fmt.Errorf("unknown rule %s", name)
+		}
+		switch severity {
+		case string(parser.SeverityError), string(parser.SeverityWarning), Off:
+		default:
+			return fmt.Errorf("invalid severity %s for rule %s", severity, name)
+		}
+	}
+	return nil
+}
+
+func (c *Config) severity(name string) string {
+	if c != nil {
+		if severity, ok := c.Rules[name]; ok {
+			return severity
+		}
+	}
+	return rules[name].severity
+}
+
+// Lint runs the rules enabled by the given Config, which may be nil to use
+// the default severities, on the given Frugal. It returns the problems found
+// sorted by position, leaving out those suppressed with the nolint
+// annotation.
+func Lint(f *parser.Frugal, config *Config) parser.Diagnostics {
+	suppressions := findSuppressions(f)
+	diags := parser.Diagnostics{}
+	for _, name := range ruleNames() {
+		severity := config.severity(name)
+		if severity == Off {
+			continue
+		}
+		rules[name].rule.Check(f, func(pos parser.Pos, format string, args ...interface{}) {
+			if suppressions.suppressed(name, pos) {
+				return
+			}
+			diags = append(diags, &parser.Diagnostic{
+				Severity: parser.Severity(severity),
+				Pos:      pos,
+				Message:  fmt.Sprintf(format, args...),
+				Rule:     name,
+			})
+		})
+	}
+	sort.Stable(diags)
+	return diags
+}
+
+// suppression is the source covered by a definition with the nolint
+// annotation and the rules it suppresses, or nil for all of them.
+type suppression struct {
+	start, end int
+	rules      map[string]bool
+}
+
+type suppressions []suppression
+
+func (s suppressions) suppressed(rule string, pos parser.Pos) bool {
+	for _, sup := range s {
+		if pos.Offset >= sup.start && pos.Offset < sup.end && (sup.rules == nil || sup.rules[rule]) {
+			return true
+		}
+	}
+	return false
+}
+
+// findSuppressions returns the suppressions added by nolint annotations in the
+// given Frugal. A suppression on a definition also covers everything within
+// it, such as a struct's fields.
+func findSuppressions(f *parser.Frugal) suppressions {
+	sups := suppressions{}
+	add := func(pos parser.Pos, annotations parser.Annotations) {
+		value, ok := annotations.Get(NolintAnnotation)
+		if !ok {
+			return
+		}
+		sup := suppression{start: pos.Offset, end: pos.Offset + pos.Span}
+		if value != "" {
+			sup.rules = make(map[string]bool)
+			for _, rule := range strings.Split(value, ",") {
+				sup.rules[strings.TrimSpace(rule)] = true
+			}
+		}
+		sups = append(sups, sup)
+	}
+	addFields := func(fields []*parser.Field) {
+		for _, field := range fields {
+			add(field.Pos, field.Annotations)
+		}
+	}
+
+	for _, namespace := range f.Namespaces {
+		add(namespace.Pos, namespace.Annotations)
+	}
+	for _, constant := range f.Constants {
+		add(constant.Pos, constant.Annotations)
+	}
+	for _, typedef := range f.Typedefs {
+		add(typedef.Pos, typedef.Annotations)
+	}
+	for _, enum := range f.Enums {
+		add(enum.Pos, enum.Annotations)
+		for _, value := range enum.Values {
+			add(value.Pos, value.Annotations)
+		}
+	}
+	for _, s := range f.DataStructures() {
+		add(s.Pos, s.Annotations)
+		addFields(s.Fields)
+	}
+	for _, service := range f.Services {
+		add(service.Pos, service.Annotations)
+		for _, method := range service.Methods {
+			add(method.Pos, method.Annotations)
+			addFields(method.Arguments)
+			addFields(method.Exceptions)
+		}
+	}
+	for _, scope := range f.Scopes {
+		add(scope.Pos, scope.Annotations)
+		for _, op := range scope.Operations {
+			add(op.Pos, op.Annotations)
+		}
+	}
+	return sups
+}
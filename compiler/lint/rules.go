@@ -0,0 +1,151 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lint
+
+import (
+	"regexp"
+
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+var snakeCase = regexp.MustCompile("^[a-z][a-z0-9]*(_[a-z0-9]+)*$")
+
+func init() {
+	Register(serviceDoc, string(parser.SeverityWarning))
+	Register(fieldSnakeCase, string(parser.SeverityWarning))
+	Register(fieldModifier, string(parser.SeverityWarning))
+	Register(enumValue, string(parser.SeverityWarning))
+	Register(scopePrefix, string(parser.SeverityWarning))
+	Register(methodExceptions, Off)
+}
+
+// rule is a Rule implemented by a function.
+type rule struct {
+	name        string
+	description string
+	check       func(f *parser.Frugal, report Reporter)
+}
+
+func (r *rule) Name() string {
+	return r.name
+}
+
+func (r *rule) Description() string {
+	return r.description
+}
+
+func (r *rule) Check(f *parser.Frugal, report Reporter) {
+	r.check(f, report)
+}
+
+var serviceDoc = &rule{
+	name:        "service-doc",
+	description: "Services and their methods should have doc comments",
+	check: func(f *parser.Frugal, report Reporter) {
+		for _, service := range f.Services {
+			if len(service.Comment) == 0 {
+				report(service.Pos, "Service %s has no doc comment", service.Name)
+			}
+			for _, method := range service.Methods {
+				if len(method.Comment) == 0 {
+					report(method.Pos, "Method %s.%s has no doc comment", service.Name, method.Name)
+				}
+			}
+		}
+	},
+}
+
+var fieldSnakeCase = &rule{
+	name:        "field-snake-case",
+	description: "Field, argument, and exception names should be snake_case",
+	check: func(f *parser.Frugal, report Reporter) {
+		checkFields := func(fields []*parser.Field, kind, owner string) {
+			for _, field := range fields {
+				if !snakeCase.MatchString(field.Name) {
+					report(field.Pos, "%s %s of %s should be snake_case", kind, field.Name, owner)
+				}
+			}
+		}
+		for _, s := range f.DataStructures() {
+			checkFields(s.Fields, "Field", s.Name)
+		}
+		for _, service := range f.Services {
+			for _, method := range service.Methods {
+				owner := service.Name + "." + method.Name
+				checkFields(method.Arguments, "Argument", owner)
+				if method.RequestStream != nil {
+					checkFields([]*parser.Field{method.RequestStream}, "Argument", owner)
+				}
+				checkFields(method.Exceptions, "Exception", owner)
+			}
+		}
+	},
+}
+
+var fieldModifier = &rule{
+	name:        "field-modifier",
+	description: "Struct and exception fields should be marked optional or required",
+	check: func(f *parser.Frugal, report Reporter) {
+		// Union fields are always optional.
+		structs := append(append([]*parser.Struct{}, f.Structs...), f.Exceptions...)
+		for _, s := range structs {
+			for _, field := range s.Fields {
+				if field.Modifier == parser.Default {
+					report(field.Pos, "Field %s.%s should be optional or required", s.Name, field.Name)
+				}
+			}
+		}
+	},
+}
+
+var enumValue = &rule{
+	name:        "enum-value",
+	description: "Enum values should be numbered explicitly",
+	check: func(f *parser.Frugal, report Reporter) {
+		for _, enum := range f.Enums {
+			for _, value := range enum.Values {
+				if value.Implicit {
+					report(value.Pos, "Enum value %s.%s should be numbered explicitly, it's %d",
+						enum.Name, value.Name, value.Value)
+				}
+			}
+		}
+	},
+}
+
+var scopePrefix = &rule{
+	name:        "scope-prefix",
+	description: "Scopes should have a prefix",
+	check: func(f *parser.Frugal, report Reporter) {
+		for _, scope := range f.Scopes {
+			if scope.Prefix == nil || scope.Prefix.String == "" {
+				report(scope.Pos, "Scope %s has no prefix", scope.Name)
+			}
+		}
+	},
+}
+
+var methodExceptions = &rule{
+	name:        "method-exceptions",
+	description: "Methods which aren't oneway should declare the exceptions they throw",
+	check: func(f *parser.Frugal, report Reporter) {
+		for _, service := range f.Services {
+			for _, method := range service.Methods {
+				if !method.Oneway && len(method.Exceptions) == 0 {
+					report(method.Pos, "Method %s.%s declares no exceptions", service.Name, method.Name)
+				}
+			}
+		}
+	},
+}
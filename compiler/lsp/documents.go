@@ -0,0 +1,157 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lsp
+
+import (
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+// document is a Frugal file open in the client.
+type document struct {
+	text string
+
+	// frugal is the last successful parse of the document, which is used to
+	// answer requests while the document contains errors.
+	frugal *parser.Frugal
+}
+
+// sources reads Frugal files, preferring the contents of open documents over
+// what's on disk. Files read from disk are cached for the lifetime of the
+// sources, which is a single request.
+type sources struct {
+	docs  map[string]*document
+	files map[string][]byte
+}
+
+func newSources(docs map[string]*document) *sources {
+	return &sources{docs: docs, files: make(map[string][]byte)}
+}
+
+func (s *sources) readFile(path string) ([]byte, error) {
+	if doc, ok := s.docs[path]; ok {
+		return []byte(doc.text), nil
+	}
+	if contents, ok := s.files[path]; ok {
+		return contents, nil
+	}
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s.files[path] = contents
+	return contents, nil
+}
+
+// location returns the Location of the given Pos, or false if the file it
+// refers to can't be read.
+func (s *sources) location(pos parser.Pos) (Location, bool) {
+	contents, err := s.readFile(pos.File)
+	if err != nil {
+		return Location{}, false
+	}
+	return Location{
+		URI: pathToURI(pos.File),
+		Range: Range{
+			Start: offsetToPosition(contents, pos.Offset),
+			End:   offsetToPosition(contents, pos.Offset+pos.Span),
+		},
+	}, true
+}
+
+// uriToPath returns the file path for a file URI.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.Clean(filepath.FromSlash(u.Path))
+}
+
+// pathToURI returns the file URI for a file path.
+func pathToURI(path string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
+
+// offsetToPosition converts a byte offset in contents to a Position.
+func offsetToPosition(contents []byte, offset int) Position {
+	if offset > len(contents) {
+		offset = len(contents)
+	}
+	pos := Position{}
+	lineStart := 0
+	for i := 0; i < offset; i++ {
+		if contents[i] == '\n' {
+			pos.Line++
+			lineStart = i + 1
+		}
+	}
+	pos.Character = utf16Len(string(contents[lineStart:offset]))
+	return pos
+}
+
+// positionToOffset converts a Position in text to a byte offset.
+func positionToOffset(text string, pos Position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+	for units := 0; units < pos.Character && offset < len(text); {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		if r == '\n' {
+			break
+		}
+		units += len(utf16.Encode([]rune{r}))
+		offset += size
+	}
+	return offset
+}
+
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+func isIdentifierByte(b byte) bool {
+	return b == '_' || b == '.' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
+
+// identifierAt returns the identifier in text at the given offset up to the
+// end of the dot-separated part the offset is in, e.g. "base.Thing" for an
+// offset within "Thing" in "base.Thing.ONE". It also returns the offset the
+// identifier starts at.
+func identifierAt(text string, offset int) (string, int) {
+	start := offset
+	for start > 0 && isIdentifierByte(text[start-1]) {
+		start--
+	}
+	end := offset
+	for end < len(text) && isIdentifierByte(text[end]) && text[end] != '.' {
+		end++
+	}
+	for start < end && text[start] == '.' {
+		start++
+	}
+	return strings.TrimRight(text[start:end], "."), start
+}
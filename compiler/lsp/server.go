@@ -0,0 +1,503 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package lsp implements a Language Server Protocol server for Frugal files.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+const diagnosticSource = "frugal"
+
+// errExitWithoutShutdown is returned by Serve if the client exits without
+// shutting the server down first.
+var errExitWithoutShutdown = errors.New("lsp: exit before shutdown")
+
+// Server is a Language Server Protocol server for Frugal files. It publishes
+// diagnostics as documents change and answers go-to-definition, hover, find
+// references, and completion requests. Requests are handled one at a time in
+// the order they're received.
+type Server struct {
+	in          *bufio.Reader
+	out         io.Writer
+	root        string
+	initialized bool
+	shutdown    bool
+	docs        map[string]*document
+
+	// published holds the files diagnostics were last published to for each
+	// open document so they can be cleared once fixed.
+	published map[string][]string
+
+	// workspace caches the Frugal files found under the root for find
+	// references. It's reset whenever a document changes.
+	workspace map[string]*parser.Frugal
+}
+
+// NewServer returns a Server which reads requests from in and writes responses
+// and notifications to out.
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:        bufio.NewReader(in),
+		out:       out,
+		docs:      make(map[string]*document),
+		published: make(map[string][]string),
+	}
+}
+
+// Serve handles requests until the client sends the exit notification or the
+// input is closed.
+func (s *Server) Serve() error {
+	for {
+		content, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		req := &request{}
+		if err := json.Unmarshal(content, req); err != nil {
+			if err := s.reply(nil, nil, &rpcError{Code: errCodeParse, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return errExitWithoutShutdown
+			}
+			return nil
+		}
+
+		result, err := s.handle(req)
+		if req.ID == nil {
+			// Notifications don't have responses.
+			continue
+		}
+		if err := s.reply(req.ID, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(req *request) (interface{}, error) {
+	if !s.initialized && req.Method != "initialize" {
+		return nil, &rpcError{Code: errCodeNotInitialized, Message: "lsp: server not initialized"}
+	}
+	if s.shutdown {
+		return nil, &rpcError{Code: errCodeInvalidRequest, Message: "lsp: server is shutting down"}
+	}
+
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return s.initialize(params), nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		path := uriToPath(params.TextDocument.URI)
+		s.docs[path] = &document{text: params.TextDocument.Text}
+		s.workspace = nil
+		return nil, s.check(path)
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		path := uriToPath(params.TextDocument.URI)
+		doc, ok := s.docs[path]
+		if !ok || len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		// Documents are synced in full so the last change is the content.
+		doc.text = params.ContentChanges[len(params.ContentChanges)-1].Text
+		s.workspace = nil
+		return nil, s.check(path)
+	case "textDocument/didSave":
+		// Saving a file can fix or break other documents which include it.
+		s.workspace = nil
+		return nil, s.checkAll()
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		path := uriToPath(params.TextDocument.URI)
+		delete(s.docs, path)
+		s.workspace = nil
+		return nil, s.publish(path, map[string][]Diagnostic{})
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return s.definition(params), nil
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return s.hover(params), nil
+	case "textDocument/references":
+		var params referenceParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return s.references(params), nil
+	case "textDocument/completion":
+		var params textDocumentPositionParams
+		if err := unmarshalParams(req, &params); err != nil {
+			return nil, err
+		}
+		return s.completion(params), nil
+	}
+	return nil, &rpcError{Code: errCodeMethodNotFound, Message: "lsp: method not found: " + req.Method}
+}
+
+func (s *Server) initialize(params initializeParams) interface{} {
+	s.initialized = true
+	if params.RootURI != "" {
+		s.root = uriToPath(params.RootURI)
+	} else {
+		s.root = params.RootPath
+	}
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync": map[string]interface{}{
+				"openClose": true,
+				"change":    1, // Full
+				"save":      map[string]interface{}{},
+			},
+			"definitionProvider": true,
+			"hoverProvider":      true,
+			"referencesProvider": true,
+			"completionProvider": map[string]interface{}{
+				"triggerCharacters": []string{".", "("},
+			},
+		},
+	}
+}
+
+// check parses the given document and publishes the errors and warnings found
+// in it and its includes.
+func (s *Server) check(path string) error {
+	doc := s.docs[path]
+	src := newSources(s.docs)
+	diags := map[string][]Diagnostic{path: {}}
+	add := func(file string, diag Diagnostic) {
+		diags[file] = append(diags[file], diag)
+	}
+
+	frugal, err := parser.ParseFrugalFrom(path, src.readFile)
+	switch e := err.(type) {
+	case nil:
+		doc.frugal = frugal
+		for _, warning := range frugal.Warnings {
+			file, diag := s.diagnostic(src, path, warning)
+			add(file, diag)
+		}
+	case parser.Diagnostics:
+		for _, d := range e {
+			file, diag := s.diagnostic(src, path, d)
+			add(file, diag)
+		}
+	default:
+		add(path, Diagnostic{Severity: SeverityError, Source: diagnosticSource, Message: err.Error()})
+	}
+
+	// Errors in includes are published to the include, so note them on the
+	// document too or they would go unnoticed.
+	files := []string{}
+	for file := range diags {
+		if file != path {
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		add(path, Diagnostic{
+			Severity: SeverityError,
+			Source:   diagnosticSource,
+			Message:  fmt.Sprintf("Errors in included file %s", file),
+		})
+	}
+
+	return s.publish(path, diags)
+}
+
+// checkAll checks every open document.
+func (s *Server) checkAll() error {
+	paths := make([]string, 0, len(s.docs))
+	for path := range s.docs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if err := s.check(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diagnostic converts a parser Diagnostic to an LSP Diagnostic and returns the
+// file it belongs to. Diagnostics without a position belong to the document
+// being checked.
+func (s *Server) diagnostic(src *sources, path string, d *parser.Diagnostic) (string, Diagnostic) {
+	diag := Diagnostic{Severity: SeverityError, Source: diagnosticSource, Message: d.Message}
+	if d.Severity == parser.SeverityWarning {
+		diag.Severity = SeverityWarning
+	}
+	if !d.Pos.IsValid() || d.Pos.File == "" {
+		return path, diag
+	}
+	loc, ok := src.location(d.Pos)
+	if !ok {
+		return path, diag
+	}
+	diag.Range = loc.Range
+	return d.Pos.File, diag
+}
+
+// publish publishes the given diagnostics, keyed by file, for a document and
+// clears any previously published for it which no longer apply.
+func (s *Server) publish(path string, diags map[string][]Diagnostic) error {
+	for _, file := range s.published[path] {
+		if _, ok := diags[file]; !ok {
+			diags[file] = []Diagnostic{}
+		}
+	}
+	files := make([]string, 0, len(diags))
+	for file := range diags {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	published := []string{}
+	for _, file := range files {
+		params := publishDiagnosticsParams{URI: pathToURI(file), Diagnostics: diags[file]}
+		if err := s.notify("textDocument/publishDiagnostics", params); err != nil {
+			return err
+		}
+		if len(diags[file]) > 0 {
+			published = append(published, file)
+		}
+	}
+	s.published[path] = published
+	return nil
+}
+
+// symbolAt returns the symbol referenced at the given position in a document
+// along with the offset and length of the reference.
+func (s *Server) symbolAt(params textDocumentPositionParams) (*document, *symbol, int, int) {
+	doc, ok := s.docs[uriToPath(params.TextDocument.URI)]
+	if !ok || doc.frugal == nil {
+		return nil, nil, 0, 0
+	}
+	offset := positionToOffset(doc.text, params.Position)
+	name, start := identifierAt(doc.text, offset)
+	if name == "" {
+		return nil, nil, 0, 0
+	}
+	sym := resolve(doc.frugal, name)
+	if sym == nil {
+		return nil, nil, 0, 0
+	}
+	return doc, sym, start, len(name)
+}
+
+func (s *Server) definition(params textDocumentPositionParams) interface{} {
+	_, sym, _, _ := s.symbolAt(params)
+	if sym == nil {
+		return nil
+	}
+	loc, ok := newSources(s.docs).location(sym.pos)
+	if !ok {
+		return nil
+	}
+	return loc
+}
+
+func (s *Server) hover(params textDocumentPositionParams) interface{} {
+	doc, sym, start, length := s.symbolAt(params)
+	if sym == nil {
+		return nil
+	}
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: sym.hover()},
+		Range: &Range{
+			Start: offsetToPosition([]byte(doc.text), start),
+			End:   offsetToPosition([]byte(doc.text), start+length),
+		},
+	}
+}
+
+func (s *Server) references(params referenceParams) interface{} {
+	locations := []Location{}
+	_, sym, _, _ := s.symbolAt(params.textDocumentPositionParams)
+	if sym == nil || !sym.isType {
+		return locations
+	}
+
+	src := newSources(s.docs)
+	if params.Context.IncludeDeclaration {
+		if loc, ok := src.location(sym.pos); ok {
+			locations = append(locations, loc)
+		}
+	}
+	workspace := s.workspaceFiles(src)
+	files := make([]string, 0, len(workspace))
+	for file := range workspace {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		for _, pos := range references(workspace[file], sym.pos.File, sym.name) {
+			if loc, ok := src.location(pos); ok {
+				locations = append(locations, loc)
+			}
+		}
+	}
+	return locations
+}
+
+// workspaceFiles returns the Frugal files under the root and any open
+// documents, parsing them if they haven't been since the last change. Files
+// which fail to parse are skipped unless they're open and parsed before.
+func (s *Server) workspaceFiles(src *sources) map[string]*parser.Frugal {
+	if s.workspace != nil {
+		return s.workspace
+	}
+	s.workspace = make(map[string]*parser.Frugal)
+	paths := []string{}
+	if s.root != "" {
+		filepath.Walk(s.root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if path != s.root && strings.HasPrefix(info.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(path, ".frugal") || strings.HasSuffix(path, ".thrift") {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+	}
+	for path := range s.docs {
+		paths = append(paths, path)
+	}
+	for _, path := range paths {
+		if _, ok := s.workspace[path]; ok {
+			continue
+		}
+		if frugal, err := parser.ParseFrugalFrom(path, src.readFile); err == nil {
+			s.workspace[path] = frugal
+		} else if doc, ok := s.docs[path]; ok && doc.frugal != nil {
+			s.workspace[path] = doc.frugal
+		}
+	}
+	return s.workspace
+}
+
+func (s *Server) completion(params textDocumentPositionParams) interface{} {
+	doc, ok := s.docs[uriToPath(params.TextDocument.URI)]
+	if !ok {
+		return []CompletionItem{}
+	}
+	offset := positionToOffset(doc.text, params.Position)
+	lineStart := strings.LastIndex(doc.text[:offset], "\n") + 1
+	line := doc.text[lineStart:offset]
+	if inAnnotations(line) {
+		return annotationCompletions()
+	}
+
+	typed := line
+	for i := len(line); i > 0; i-- {
+		if !isIdentifierByte(line[i-1]) {
+			typed = line[i:]
+			break
+		}
+	}
+	if i := strings.Index(typed, "."); i >= 0 {
+		if doc.frugal == nil {
+			return []CompletionItem{}
+		}
+		return typeCompletions(doc.frugal, typed[:i])
+	}
+	return typeCompletions(doc.frugal, "")
+}
+
+// inAnnotations indicates if the end of the given line is within a list of
+// annotations, e.g. "1: string foo (depr", rather than a method's arguments or
+// exceptions.
+func inAnnotations(line string) bool {
+	open := strings.LastIndex(line, "(")
+	if open < 0 || strings.Contains(line[open:], ")") {
+		return false
+	}
+	before := strings.TrimRight(line[:open], " \t")
+	if strings.HasSuffix(before, "throws") {
+		return false
+	}
+	// Method arguments directly follow the method name.
+	return before == "" || len(before) < open || !isIdentifierByte(before[len(before)-1])
+}
+
+func (s *Server) reply(id *json.RawMessage, result interface{}, err error) error {
+	if err == nil {
+		return writeMessage(s.out, &response{JSONRPC: "2.0", ID: id, Result: result})
+	}
+	rpcErr, ok := err.(*rpcError)
+	if !ok {
+		rpcErr = &rpcError{Code: errCodeInternal, Message: err.Error()}
+	}
+	return writeMessage(s.out, &errorResponse{JSONRPC: "2.0", ID: id, Error: rpcErr})
+}
+
+func (s *Server) notify(method string, params interface{}) error {
+	return writeMessage(s.out, &notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func unmarshalParams(req *request, params interface{}) error {
+	if err := json.Unmarshal(req.Params, params); err != nil {
+		return &rpcError{Code: errCodeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
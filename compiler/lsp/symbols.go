@@ -0,0 +1,352 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package lsp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Workiva/frugal/compiler/parser"
+)
+
+// baseTypes are the built-in types offered for completion.
+var baseTypes = []string{
+	"bool", "byte", "i8", "i16", "i32", "i64", "double", "string", "binary",
+	"list", "set", "map",
+}
+
+// annotations are the annotations understood by the compiler, offered for
+// completion.
+var annotations = map[string]string{
+	parser.VendorAnnotation:     "Location of the generated code for this namespace, or use it for this include",
+	parser.DeprecatedAnnotation: "Marks a method or field as deprecated",
+}
+
+// symbol is a definition in a Frugal file which can be referenced by name.
+type symbol struct {
+	name        string
+	kind        int
+	detail      string
+	comment     []string
+	annotations parser.Annotations
+	pos         parser.Pos
+	isType      bool // Whether the symbol can be used as a field type
+}
+
+// hover returns the Markdown shown when hovering over a reference to the
+// symbol.
+func (s *symbol) hover() string {
+	value := "```frugal\n" + s.detail + "\n```"
+	if deprecation, ok := s.annotations.Deprecated(); ok {
+		value += "\n\n**Deprecated**"
+		if deprecation != "" {
+			value += ": " + deprecation
+		}
+	}
+	if len(s.comment) > 0 {
+		value += "\n\n" + strings.Join(s.comment, "\n")
+	}
+	return value
+}
+
+// definitions returns the symbols defined in the given Frugal keyed by name.
+// Scope operations are keyed by their name qualified with the scope name.
+// Constants and enum values are resolved with ContextFromIdentifier instead.
+func definitions(f *parser.Frugal) map[string]*symbol {
+	symbols := make(map[string]*symbol)
+	add := func(s *symbol) {
+		symbols[s.name] = s
+	}
+	for _, include := range f.Includes {
+		if parsed, ok := f.ParsedIncludes[include.Name]; ok {
+			add(&symbol{
+				name:   include.Name,
+				kind:   CompletionKindModule,
+				detail: fmt.Sprintf("include \"%s\"", include.Value),
+				pos:    parser.Pos{File: parsed.File, Line: 1, Column: 1},
+			})
+		}
+	}
+	for _, typedef := range f.Typedefs {
+		add(&symbol{
+			name:        typedef.Name,
+			kind:        CompletionKindClass,
+			detail:      fmt.Sprintf("typedef %s %s", typedef.Type, typedef.Name),
+			comment:     typedef.Comment,
+			annotations: typedef.Annotations,
+			pos:         typedef.Pos,
+			isType:      true,
+		})
+	}
+	for _, enum := range f.Enums {
+		add(&symbol{
+			name:        enum.Name,
+			kind:        CompletionKindEnum,
+			detail:      "enum " + enum.Name,
+			comment:     enum.Comment,
+			annotations: enum.Annotations,
+			pos:         enum.Pos,
+			isType:      true,
+		})
+	}
+	for _, s := range f.DataStructures() {
+		kind := CompletionKindStruct
+		if s.Type == parser.StructTypeException {
+			kind = CompletionKindClass
+		}
+		add(&symbol{
+			name:        s.Name,
+			kind:        kind,
+			detail:      fmt.Sprintf("%s %s", s.Type, s.Name),
+			comment:     s.Comment,
+			annotations: s.Annotations,
+			pos:         s.Pos,
+			isType:      true,
+		})
+	}
+	for _, service := range f.Services {
+		detail := "service " + service.Name
+		if service.Extends != "" {
+			detail += " extends " + service.Extends
+		}
+		add(&symbol{
+			name:        service.Name,
+			kind:        CompletionKindInterface,
+			detail:      detail,
+			comment:     service.Comment,
+			annotations: service.Annotations,
+			pos:         service.Pos,
+		})
+	}
+	for _, scope := range f.Scopes {
+		detail := "scope " + scope.Name
+		if scope.Prefix.String != "" {
+			detail += " prefix " + scope.Prefix.String
+		}
+		add(&symbol{
+			name:        scope.Name,
+			kind:        CompletionKindInterface,
+			detail:      detail,
+			comment:     scope.Comment,
+			annotations: scope.Annotations,
+			pos:         scope.Pos,
+		})
+		for _, op := range scope.Operations {
+			add(&symbol{
+				name:        scope.Name + "." + op.Name,
+				kind:        CompletionKindEvent,
+				detail:      fmt.Sprintf("%s.%s: %s", scope.Name, op.Name, op.Type),
+				comment:     op.Comment,
+				annotations: op.Annotations,
+				pos:         op.Pos,
+			})
+		}
+	}
+	return symbols
+}
+
+// resolve returns the symbol the given name refers to from within the given
+// Frugal, or nil if there isn't one.
+func resolve(f *parser.Frugal, name string) *symbol {
+	if s, ok := definitions(f)[name]; ok {
+		return s
+	}
+	if i := strings.Index(name, "."); i > 0 {
+		if include, ok := f.ParsedIncludes[name[:i]]; ok {
+			if s, ok := definitions(include)[name[i+1:]]; ok {
+				return s
+			}
+		}
+	}
+	return resolveValue(f, name)
+}
+
+// resolveValue returns the symbol for the constant or enum value the given
+// name refers to, or nil if there isn't one.
+func resolveValue(f *parser.Frugal, name string) *symbol {
+	ctx := identifierContext(f, name)
+	if ctx == nil {
+		return nil
+	}
+	switch ctx.Type {
+	case parser.LocalConstant, parser.IncludeConstant:
+		return &symbol{
+			name:        name,
+			kind:        CompletionKindProperty,
+			detail:      fmt.Sprintf("const %s %s", ctx.Constant.Type, ctx.Constant.Name),
+			comment:     ctx.Constant.Comment,
+			annotations: ctx.Constant.Annotations,
+			pos:         ctx.Constant.Pos,
+		}
+	case parser.LocalEnum, parser.IncludeEnum:
+		return &symbol{
+			name:        name,
+			kind:        CompletionKindProperty,
+			detail:      fmt.Sprintf("%s.%s = %d", ctx.Enum.Name, ctx.EnumValue.Name, ctx.EnumValue.Value),
+			comment:     ctx.EnumValue.Comment,
+			annotations: ctx.EnumValue.Annotations,
+			pos:         ctx.EnumValue.Pos,
+		}
+	}
+	return nil
+}
+
+// identifierContext returns the IdentifierContext for the given name, or nil
+// if it doesn't refer to a constant or enum value.
+func identifierContext(f *parser.Frugal, name string) (ctx *parser.IdentifierContext) {
+	// ContextFromIdentifier panics if the identifier doesn't exist, which is
+	// common for partially typed names.
+	defer func() {
+		if recover() != nil {
+			ctx = nil
+		}
+	}()
+	return f.ContextFromIdentifier(parser.Identifier(name))
+}
+
+// references returns the positions in the given Frugal which refer to the
+// type with the given name defined in the given file. These are types used
+// by typedefs, constants, fields, service methods, and scope operations, and
+// constants and field defaults which use a value of the type if it's an enum.
+func references(f *parser.Frugal, file, name string) []parser.Pos {
+	positions := []parser.Pos{}
+	var addType func(t *parser.Type)
+	addType = func(t *parser.Type) {
+		if t == nil {
+			return
+		}
+		if refFile, refName, ok := typeDefinition(f, t); ok && refFile == file && refName == name {
+			positions = append(positions, t.Pos)
+		}
+		addType(t.KeyType)
+		addType(t.ValueType)
+	}
+	addValue := func(value interface{}, pos parser.Pos) {
+		identifier, ok := value.(parser.Identifier)
+		if !ok {
+			return
+		}
+		ctx := identifierContext(f, string(identifier))
+		if ctx != nil && ctx.Enum != nil && ctx.Enum.Pos.File == file && ctx.Enum.Name == name {
+			positions = append(positions, pos)
+		}
+	}
+	addFields := func(fields []*parser.Field) {
+		for _, field := range fields {
+			addType(field.Type)
+			addValue(field.Default, field.Pos)
+		}
+	}
+
+	for _, typedef := range f.Typedefs {
+		addType(typedef.Type)
+	}
+	for _, constant := range f.Constants {
+		addType(constant.Type)
+		addValue(constant.Value, constant.Pos)
+	}
+	for _, s := range f.DataStructures() {
+		addFields(s.Fields)
+	}
+	for _, service := range f.Services {
+		for _, method := range service.Methods {
+			addType(method.ReturnType)
+			addFields(method.Arguments)
+			if method.RequestStream != nil {
+				addFields([]*parser.Field{method.RequestStream})
+			}
+			addFields(method.Exceptions)
+		}
+	}
+	for _, scope := range f.Scopes {
+		for _, op := range scope.Operations {
+			addType(op.Type)
+		}
+	}
+	return positions
+}
+
+// typeDefinition returns the file and name of the definition the given Type
+// refers to from within the given Frugal.
+func typeDefinition(f *parser.Frugal, t *parser.Type) (string, string, bool) {
+	if include := t.IncludeName(); include != "" {
+		parsed, ok := f.ParsedIncludes[include]
+		if !ok {
+			return "", "", false
+		}
+		return parsed.File, t.ParamName(), true
+	}
+	return f.File, t.Name, true
+}
+
+// typeCompletions returns the types which can be used in the given Frugal.
+// If include is set, only the types defined in that include are returned.
+func typeCompletions(f *parser.Frugal, include string) []CompletionItem {
+	items := []CompletionItem{}
+	if include != "" {
+		parsed, ok := f.ParsedIncludes[include]
+		if !ok {
+			return items
+		}
+		return append(items, symbolCompletions(parsed, false)...)
+	}
+	for _, name := range baseTypes {
+		items = append(items, CompletionItem{Label: name, Kind: CompletionKindKeyword})
+	}
+	if f != nil {
+		items = append(items, symbolCompletions(f, true)...)
+	}
+	return items
+}
+
+func symbolCompletions(f *parser.Frugal, includes bool) []CompletionItem {
+	items := []CompletionItem{}
+	for _, s := range definitions(f) {
+		if !s.isType && !(includes && s.kind == CompletionKindModule) {
+			continue
+		}
+		item := CompletionItem{Label: s.name, Kind: s.kind, Detail: s.detail}
+		if len(s.comment) > 0 {
+			item.Documentation = &MarkupContent{Kind: "markdown", Value: strings.Join(s.comment, "\n")}
+		}
+		items = append(items, item)
+	}
+	sort.Sort(byLabel(items))
+	return items
+}
+
+// annotationCompletions returns the annotations understood by the compiler.
+func annotationCompletions() []CompletionItem {
+	items := []CompletionItem{}
+	for name, description := range annotations {
+		items = append(items, CompletionItem{Label: name, Kind: CompletionKindProperty, Detail: description})
+	}
+	sort.Sort(byLabel(items))
+	return items
+}
+
+type byLabel []CompletionItem
+
+func (b byLabel) Len() int {
+	return len(b)
+}
+
+func (b byLabel) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
+func (b byLabel) Less(i, j int) bool {
+	return b[i].Label < b[j].Label
+}
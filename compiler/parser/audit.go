@@ -15,7 +15,9 @@ package parser
 
 import (
 	"fmt"
+	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -49,45 +51,100 @@ func (s *stdOutLogger) ErrorsLogged() bool {
 	return s.errorsLogged
 }
 
+// AuditError is returned when an audit finds breaking changes which aren't
+// suppressed.
+type AuditError struct {
+	Old, New string
+}
+
+func (e *AuditError) Error() string {
+	return fmt.Sprintf("FAILED: audit of %s against %s", e.New, e.Old)
+}
+
 // Auditor provides an interface for auditing one frugal file against another
-// for breaking API changes.
+// for breaking API changes. Each change it finds is recorded in its Report
+// and, unless suppressed by its AuditPolicy, logged.
 type Auditor struct {
 	logger    ValidationLogger
+	policy    *AuditPolicy
+	results   *AuditReport
 	oldFrugal *Frugal
 	newFrugal *Frugal
+	file      string
+	failed    bool
+
+	// compared caches why structs declared in includes changed
+	// incompatibly, by their qualified name in the old file.
+	compared map[string]string
+
+	// oldRoot is replaced with the oldLabel revision in the paths of old
+	// files, which are extracted to a temporary directory when auditing a
+	// git revision.
+	oldRoot  string
+	oldLabel string
 }
 
 // NewAuditor constructs an auditor that logs warnings and errors to
 // standard output
 func NewAuditor() *Auditor {
-	return &Auditor{
-		logger: &stdOutLogger{},
-	}
+	return NewAuditorWithLogger(&stdOutLogger{})
 }
 
 // NewAuditorWithLogger constructs an auditor which uses the given logger
 // to log warnings and errors
 func NewAuditorWithLogger(logger ValidationLogger) *Auditor {
+	return NewAuditorWithPolicy(logger, nil)
+}
+
+// NewAuditorWithPolicy constructs an auditor which uses the given policy to
+// decide the severity of changes and which to suppress. The policy may be nil
+// to use the default severities, and the logger may be nil to only record
+// changes in the Report.
+func NewAuditorWithPolicy(logger ValidationLogger, policy *AuditPolicy) *Auditor {
 	return &Auditor{
-		logger: logger,
+		logger:  logger,
+		policy:  policy,
+		results: &AuditReport{Files: []*AuditedFile{}, Findings: []*AuditFinding{}},
 	}
 }
 
-// Compare checks the contents of newFile for breaking changes with respect to
+// Report returns the files audited and the changes found so far.
+func (a *Auditor) Report() *AuditReport {
+	return a.results
+}
+
+// Audit checks the contents of newFile for breaking changes with respect to
 // oldFile
 func (a *Auditor) Audit(oldFile, newFile string) error {
-	newFrugal, err := ParseFrugal(newFile)
+	failed, err := a.audit(oldFile, newFile)
 	if err != nil {
 		return err
 	}
+	if failed {
+		return &AuditError{Old: a.label(oldFile), New: newFile}
+	}
+	return nil
+}
+
+// audit checks newFile against oldFile and returns true if any unsuppressed
+// errors were found.
+func (a *Auditor) audit(oldFile, newFile string) (bool, error) {
+	newFrugal, err := ParseFrugal(newFile)
+	if err != nil {
+		return false, err
+	}
 
 	oldFrugal, err := ParseFrugal(oldFile)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	a.oldFrugal = oldFrugal
 	a.newFrugal = newFrugal
+	a.file = newFile
+	a.failed = false
+	a.compared = make(map[string]string)
+	a.results.Files = append(a.results.Files, &AuditedFile{Old: a.label(oldFile), New: newFile})
 
 	a.checkScopes(oldFrugal.Scopes, newFrugal.Scopes)
 
@@ -99,10 +156,58 @@ func (a *Auditor) Audit(oldFile, newFile string) error {
 	a.checkStructLike(oldFrugal.Unions, newFrugal.Unions)
 	a.checkServices(oldFrugal.Services, newFrugal.Services)
 
-	if a.logger.ErrorsLogged() {
-		return fmt.Errorf("FAILED: audit of %s against %s", newFile, oldFile)
+	return a.failed, nil
+}
+
+// report records a change found by the given rule in the definition named by
+// subject at pos, which is in the old file if the definition was removed. The
+// message logged and recorded is the given pieces joined by spaces.
+func (a *Auditor) report(rule, subject string, pos Pos, oldValue, newValue string, pieces ...string) {
+	severity := a.policy.severity(rule)
+	if severity == AuditOff {
+		return
+	}
+	pos.File = a.label(pos.File)
+	finding := &AuditFinding{
+		Rule:        rule,
+		Severity:    Severity(severity),
+		File:        a.file,
+		Subject:     subject,
+		Pos:         pos,
+		Message:     strings.Join(pieces, " "),
+		Explanation: AuditRuleExplanation(rule),
+		Old:         oldValue,
+		New:         newValue,
+	}
+	if suppression := a.policy.suppression(finding); suppression != nil {
+		finding.Suppressed = true
+		finding.Reason = suppression.Reason
+	}
+	a.results.Findings = append(a.results.Findings, finding)
+	if finding.Suppressed {
+		return
+	}
+
+	if finding.Severity == SeverityError {
+		a.failed = true
+		if a.logger != nil {
+			a.logger.LogError(pieces...)
+		}
+	} else if a.logger != nil {
+		a.logger.LogWarning(pieces...)
 	}
-	return nil
+}
+
+// label returns the path of an old file as it should be reported.
+func (a *Auditor) label(path string) string {
+	if a.oldRoot == "" || !strings.HasPrefix(path, a.oldRoot) {
+		return path
+	}
+	rel := strings.TrimPrefix(filepath.ToSlash(strings.TrimPrefix(path, a.oldRoot)), "/")
+	if rel == "" {
+		rel = "."
+	}
+	return a.oldLabel + ":" + rel
 }
 
 // checkScopes requirements:
@@ -120,22 +225,24 @@ func (a *Auditor) checkScopes(oldScopes, newScopes []*Scope) {
 	for _, oldScope := range oldScopes {
 		if newScope, ok := newMap[oldScope.Name]; ok {
 			context := fmt.Sprintf("scope %s:", oldScope.Name)
-			a.checkScopePrefix(oldScope.Prefix, newScope.Prefix, context)
-			a.checkOperations(oldScope.Operations, newScope.Operations, context)
+			a.checkScopePrefix(oldScope.Prefix, newScope.Prefix, oldScope.Name, newScope.Pos, context)
+			a.checkOperations(oldScope.Operations, newScope.Operations, oldScope.Name, context)
 		} else {
-			a.logger.LogError("missing scope:", oldScope.Name)
+			a.report("scope-removed", oldScope.Name, oldScope.Pos, oldScope.Name, "",
+				"missing scope:", oldScope.Name)
 		}
 	}
 }
 
-func (a *Auditor) checkScopePrefix(oldPrefix, newPrefix *ScopePrefix, context string) {
+func (a *Auditor) checkScopePrefix(oldPrefix, newPrefix *ScopePrefix, subject string, pos Pos, context string) {
 	// variable names in scope prefixes should be able to change,
 	// but nothing else should be able to. Changing all the variables
 	// to '{}' allows this
 	oldNorm := normalizeScopePrefix(oldPrefix.String)
 	newNorm := normalizeScopePrefix(newPrefix.String)
 	if oldNorm != newNorm {
-		a.logger.LogError(context, fmt.Sprintf("prefix changed: '%s' -> '%s'", oldNorm, newNorm))
+		a.report("scope-prefix-changed", subject, pos, oldNorm, newNorm,
+			context, fmt.Sprintf("prefix changed: '%s' -> '%s'", oldNorm, newNorm))
 	}
 }
 
@@ -151,18 +258,20 @@ func normalizeScopePrefix(s string) string {
 	return strings.Join(separated, ".")
 }
 
-func (a *Auditor) checkOperations(oldOps, newOps []*Operation, context string) {
+func (a *Auditor) checkOperations(oldOps, newOps []*Operation, scope, context string) {
 	newMap := make(map[string]*Operation)
 	for _, op := range newOps {
 		newMap[op.Name] = op
 	}
 
 	for _, oldOp := range oldOps {
+		subject := scope + "." + oldOp.Name
 		if newOp, ok := newMap[oldOp.Name]; ok {
 			opContext := fmt.Sprintf("%s operation %s:", context, oldOp.Name)
-			a.checkType(oldOp.Type, newOp.Type, false, opContext)
+			a.checkType(oldOp.Type, newOp.Type, "type-changed", subject, newOp.Pos, opContext)
 		} else {
-			a.logger.LogError(context, "operation removed:", oldOp.Name)
+			a.report("operation-removed", subject, oldOp.Pos, oldOp.Name, "",
+				context, "operation removed:", oldOp.Name)
 		}
 	}
 }
@@ -180,12 +289,15 @@ func (a *Auditor) checkNamespaces(oldNamespace, newNamespace []*Namespace) {
 	// These are warnings as namespace information isn't sent over the
 	// network
 	for _, oldNamespace := range oldNamespace {
+		subject := "namespace." + oldNamespace.Scope
 		if newNamespace, ok := newMap[oldNamespace.Scope]; ok {
 			if oldNamespace.Value != newNamespace.Value {
-				a.logger.LogWarning("namespace changed:", oldNamespace.Scope)
+				a.report("namespace-changed", subject, newNamespace.Pos, oldNamespace.Value, newNamespace.Value,
+					"namespace changed:", oldNamespace.Scope)
 			}
 		} else {
-			a.logger.LogWarning("namespace removed:", oldNamespace.Scope)
+			a.report("namespace-removed", subject, oldNamespace.Pos, oldNamespace.Value, "",
+				"namespace removed:", oldNamespace.Scope)
 		}
 	}
 }
@@ -205,12 +317,16 @@ func (a *Auditor) checkConstants(oldConstants, newConstants []*Constant) {
 	for _, oldConstant := range oldConstants {
 		if newConstant, ok := newMap[oldConstant.Name]; ok {
 			context := fmt.Sprintf("constant %s:", oldConstant.Name)
-			a.checkType(oldConstant.Type, newConstant.Type, true, context)
+			a.checkType(oldConstant.Type, newConstant.Type, "constant-type-changed", oldConstant.Name,
+				newConstant.Pos, context)
 			if !reflect.DeepEqual(oldConstant.Value, newConstant.Value) {
-				a.logger.LogWarning("constant value changed:", oldConstant.Name)
+				a.report("constant-value-changed", oldConstant.Name, newConstant.Pos,
+					auditValue(oldConstant.Value), auditValue(newConstant.Value),
+					"constant value changed:", oldConstant.Name)
 			}
 		} else {
-			a.logger.LogWarning("constant value removed:", oldConstant.Name)
+			a.report("constant-removed", oldConstant.Name, oldConstant.Pos, auditValue(oldConstant.Value), "",
+				"constant value removed:", oldConstant.Name)
 		}
 	}
 }
@@ -230,30 +346,33 @@ func (a *Auditor) checkEnums(oldEnums, newEnums []*Enum) {
 	for _, oldEnum := range oldEnums {
 		if newEnum, ok := newMap[oldEnum.Name]; ok {
 			context := fmt.Sprintf("enum %s:", oldEnum.Name)
-			a.checkEnumValues(oldEnum.Values, newEnum.Values, context)
+			a.checkEnumValues(oldEnum.Values, newEnum.Values, oldEnum.Name, context)
 		} else {
-			a.logger.LogWarning("enum removed:", oldEnum.Name)
+			a.report("enum-removed", oldEnum.Name, oldEnum.Pos, oldEnum.Name, "",
+				"enum removed:", oldEnum.Name)
 		}
 	}
 }
 
-func (a *Auditor) checkEnumValues(oldValues, newValues []*EnumValue, context string) {
+func (a *Auditor) checkEnumValues(oldValues, newValues []*EnumValue, enum, context string) {
 	newMap := make(map[int]*EnumValue)
 	for _, value := range newValues {
 		newMap[value.Value] = value
 	}
 
 	for _, oldValue := range oldValues {
+		subject := enum + "." + oldValue.Name
 		if newValue, ok := newMap[oldValue.Value]; ok {
 			if oldValue.Name != newValue.Name {
 				// enum variant names are allowed to change as
 				// only the numeric value is sent over the
 				// network
-				a.logger.LogWarning("enum variant name changed:", oldValue.Name)
+				a.report("enum-value-renamed", subject, newValue.Pos, oldValue.Name, newValue.Name,
+					"enum variant name changed:", oldValue.Name)
 			}
 		} else {
-			a.logger.LogError(fmt.Sprintf("%s variant %s: removed with ID=%d",
-				context, oldValue.Name, oldValue.Value))
+			a.report("enum-value-removed", subject, oldValue.Pos, strconv.Itoa(oldValue.Value), "",
+				fmt.Sprintf("%s variant %s: removed with ID=%d", context, oldValue.Name, oldValue.Value))
 		}
 	}
 }
@@ -278,11 +397,26 @@ func (a *Auditor) checkStructLike(oldStructs, newStructs []*Struct) {
 	for _, oldStruct := range oldStructs {
 		if newStruct, ok := newMap[oldStruct.Name]; ok {
 			context := fmt.Sprintf("struct %s:", oldStruct.Name)
-			a.checkFields(oldStruct.Fields, newStruct.Fields, context)
+			a.checkFields(oldStruct.Fields, newStruct.Fields, oldStruct.Name, context)
+		} else if changed := a.findDataStructure(oldStruct.Name); changed != nil {
+			a.report("struct-kind-changed", oldStruct.Name, changed.Pos, oldStruct.Type.String(), changed.Type.String(),
+				fmt.Sprintf("struct %s: kind changed: '%s' -> '%s'", oldStruct.Name, oldStruct.Type, changed.Type))
 		} else {
-			a.logger.LogError("missing struct:", oldStruct.Name)
+			a.report("struct-removed", oldStruct.Name, oldStruct.Pos, oldStruct.Name, "",
+				"missing struct:", oldStruct.Name)
+		}
+	}
+}
+
+// findDataStructure returns the struct, union, or exception in the new file
+// with the given name, or nil if there isn't one.
+func (a *Auditor) findDataStructure(name string) *Struct {
+	for _, s := range a.newFrugal.DataStructures() {
+		if s.Name == name {
+			return s
 		}
 	}
+	return nil
 }
 
 // checkService requirements:
@@ -311,60 +445,110 @@ func (a *Auditor) checkServices(oldServices, newServices []*Service) {
 		if newService, ok := newMap[oldService.Name]; ok {
 			// It's fine to add inheritance, but not change it if it already exists
 			if oldService.Extends != "" && oldService.Extends != newService.Extends {
-				a.logger.LogError(fmt.Sprintf("service %s: extends changed: '%s' -> '%s'",
-					oldService.Name, oldService.Extends, newService.Extends))
+				a.report("extends-changed", oldService.Name, newService.Pos, oldService.Extends, newService.Extends,
+					fmt.Sprintf("service %s: extends changed: '%s' -> '%s'",
+						oldService.Name, oldService.Extends, newService.Extends))
 			}
 			context := fmt.Sprintf("service %s:", oldService.Name)
-			a.checkServiceMethods(oldService.Methods, newService.Methods, context)
+			a.checkServiceMethods(oldService.Methods, newService.Methods, oldService.Name, context)
 		} else {
-			a.logger.LogError("missing service:", oldService.Name)
+			a.report("service-removed", oldService.Name, oldService.Pos, oldService.Name, "",
+				"missing service:", oldService.Name)
 		}
 	}
 }
 
-func (a *Auditor) checkServiceMethods(oldMethods, newMethods []*Method, context string) {
+func (a *Auditor) checkServiceMethods(oldMethods, newMethods []*Method, service, context string) {
 	newMap := make(map[string]*Method)
 	for _, method := range newMethods {
 		newMap[method.Name] = method
 	}
 
 	for _, oldMethod := range oldMethods {
+		subject := service + "." + oldMethod.Name
 		if newMethod, ok := newMap[oldMethod.Name]; ok {
+			pos := newMethod.Pos
 			methodContext := fmt.Sprintf("%s method %s:", context, oldMethod.Name)
 			if oldMethod.Oneway != newMethod.Oneway {
-				a.logger.LogError(methodContext, "one way modifier changed")
+				a.report("oneway-changed", subject, pos,
+					strconv.FormatBool(oldMethod.Oneway), strconv.FormatBool(newMethod.Oneway),
+					methodContext, "one way modifier changed")
+			}
+			if oldMethod.StreamingResponse != newMethod.StreamingResponse {
+				a.report("stream-changed", subject, pos,
+					strconv.FormatBool(oldMethod.StreamingResponse), strconv.FormatBool(newMethod.StreamingResponse),
+					methodContext, "stream modifier changed")
+			}
+			switch {
+			case oldMethod.RequestStream == nil && newMethod.RequestStream != nil:
+				a.report("request-stream-added", subject, pos, "", auditTypeName(newMethod.RequestStream.Type),
+					methodContext, "request stream added")
+			case oldMethod.RequestStream != nil && newMethod.RequestStream == nil:
+				a.report("request-stream-removed", subject, pos, auditTypeName(oldMethod.RequestStream.Type), "",
+					methodContext, "request stream removed")
+			case oldMethod.RequestStream != nil:
+				a.checkType(oldMethod.RequestStream.Type, newMethod.RequestStream.Type, "type-changed", subject,
+					pos, methodContext+" request stream type:")
 			}
 
-			a.checkType(oldMethod.ReturnType, newMethod.ReturnType, false, methodContext+" return type:")
+			a.checkType(oldMethod.ReturnType, newMethod.ReturnType, "type-changed", subject, pos,
+				methodContext+" return type:")
 
-			a.checkFields(oldMethod.Arguments, newMethod.Arguments, methodContext)
-			a.checkFields(oldMethod.Exceptions, newMethod.Exceptions, methodContext)
+			a.checkFields(oldMethod.Arguments, newMethod.Arguments, subject, methodContext)
+			a.checkFields(oldMethod.Exceptions, newMethod.Exceptions, subject, methodContext)
 
 			// If the return type is nil and not exceptions exist,
 			// the generated code doesn't expect anything to be
 			// returned, so transitioning between the states of
 			// "nothing can be returned" and "something can be
 			// returned" isn't allowed
-			if oldMethod.ReturnType == nil && len(oldMethod.Exceptions) == 0 && len(newMethod.Exceptions) > 0 {
-				a.logger.LogError(methodContext, "can't add exceptions with nil return type")
-			}
-
-			if newMethod.ReturnType == nil && len(newMethod.Exceptions) == 0 && len(oldMethod.Exceptions) > 0 {
-				a.logger.LogError(methodContext, "can't remove exceptions with nil return type")
+			switch {
+			case oldMethod.ReturnType == nil && len(oldMethod.Exceptions) == 0 && len(newMethod.Exceptions) > 0:
+				a.report("exceptions-added", subject, pos, "", "",
+					methodContext, "can't add exceptions with nil return type")
+			case newMethod.ReturnType == nil && len(newMethod.Exceptions) == 0 && len(oldMethod.Exceptions) > 0:
+				a.report("exceptions-removed", subject, pos, "", "",
+					methodContext, "can't remove exceptions with nil return type")
+			default:
+				a.checkThrows(oldMethod.Exceptions, newMethod.Exceptions, subject, methodContext)
 			}
 		} else {
-			a.logger.LogError(context, "missing method: "+oldMethod.Name)
+			a.report("method-removed", subject, oldMethod.Pos, oldMethod.Name, "",
+				context, "missing method: "+oldMethod.Name)
+		}
+	}
+}
+
+// checkThrows reports exceptions added to or removed from a method. Since
+// exceptions are optional, these aren't reported by checkFields.
+func (a *Auditor) checkThrows(oldExceptions, newExceptions []*Field, method, context string) {
+	oldMap := makeFieldsMap(oldExceptions)
+	newMap := makeFieldsMap(newExceptions)
+	for _, oldException := range oldExceptions {
+		if _, ok := newMap[oldException.ID]; !ok {
+			a.report("exception-removed", method+"."+oldException.Name, oldException.Pos,
+				auditTypeName(oldException.Type), "",
+				context, fmt.Sprintf("exception %s removed with ID=%d", oldException.Name, oldException.ID))
+		}
+	}
+	for _, newException := range newExceptions {
+		if _, ok := oldMap[newException.ID]; !ok {
+			a.report("exception-added", method+"."+newException.Name, newException.Pos,
+				"", auditTypeName(newException.Type),
+				context, fmt.Sprintf("exception %s added with ID=%d", newException.Name, newException.ID))
 		}
 	}
 }
 
-func (a *Auditor) checkFields(oldFields, newFields []*Field, context string) {
+func (a *Auditor) checkFields(oldFields, newFields []*Field, owner, context string) {
 	oldMap := makeFieldsMap(oldFields)
 	newMap := makeFieldsMap(newFields)
 
 	min := int(^uint(0) >> 1)
 	max := 0
-	for _, oldField := range oldMap {
+	// Iterate over the fields rather than the maps so changes are reported in
+	// a stable order.
+	for _, oldField := range oldFields {
 		if oldField.ID < min {
 			min = oldField.ID
 		}
@@ -372,39 +556,54 @@ func (a *Auditor) checkFields(oldFields, newFields []*Field, context string) {
 			max = oldField.ID
 		}
 
+		subject := owner + "." + oldField.Name
 		fieldContext := fmt.Sprintf("%s field %s:", context, oldField.Name)
 		if newField, ok := newMap[oldField.ID]; ok {
-			a.checkType(oldField.Type, newField.Type, false, fieldContext)
+			a.checkType(oldField.Type, newField.Type, "type-changed", subject, newField.Pos, fieldContext)
 
 			oldFieldReq := oldField.Modifier == Required
 			newFieldReq := newField.Modifier == Required
 			if oldFieldReq != newFieldReq {
-				a.logger.LogError(fieldContext, fmt.Sprintf("field presence modifier changed: '%s' -> '%s'",
-					oldField.Modifier.String(), newField.Modifier.String()))
+				a.report("presence-changed", subject, newField.Pos,
+					oldField.Modifier.String(), newField.Modifier.String(),
+					fieldContext, fmt.Sprintf("field presence modifier changed: '%s' -> '%s'",
+						oldField.Modifier.String(), newField.Modifier.String()))
 			}
 
 			if !reflect.DeepEqual(oldField.Default, newField.Default) {
-				a.logger.LogWarning(fieldContext, "default value changed")
+				a.report("default-value-changed", subject, newField.Pos,
+					auditValue(oldField.Default), auditValue(newField.Default),
+					fieldContext, "default value changed")
 			}
 			if oldField.Name != newField.Name {
-				a.logger.LogWarning(fieldContext, "name changed")
+				a.report("field-renamed", subject, newField.Pos, oldField.Name, newField.Name,
+					fieldContext, "name changed")
 			}
+		} else if oldField.Modifier == Required {
+			a.report("required-field-removed", subject, oldField.Pos, strconv.Itoa(oldField.ID), "",
+				fieldContext, fmt.Sprintf("field removed with ID=%d", oldField.ID))
 		} else if oldField.Modifier != Optional {
-			a.logger.LogError(fieldContext, fmt.Sprintf("field removed with ID=%d", oldField.ID))
+			// Optional fields may be unset, so readers built from the old
+			// version are unaffected by their removal.
+			a.report("field-removed", subject, oldField.Pos, strconv.Itoa(oldField.ID), "",
+				fieldContext, fmt.Sprintf("field removed with ID=%d", oldField.ID))
 		}
 	}
 
-	for _, newField := range newMap {
+	for _, newField := range newFields {
 		if _, ok := oldMap[newField.ID]; !ok {
+			subject := owner + "." + newField.Name
 			fieldContext := fmt.Sprintf("%s field %s:", context, newField.Name)
 			// Adding a field "in the middle" is generally a sign
 			// of field ID reuse, which isn't allowed
 			if min < newField.ID && newField.ID < max {
-				a.logger.LogWarning(fieldContext, fmt.Sprintf("added field in the middle with ID=%d", newField.ID))
+				a.report("field-added-in-middle", subject, newField.Pos, "", strconv.Itoa(newField.ID),
+					fieldContext, fmt.Sprintf("added field in the middle with ID=%d", newField.ID))
 			}
 
 			if newField.Modifier == Required {
-				a.logger.LogError(fieldContext, "added field is required")
+				a.report("required-field-added", subject, newField.Pos, "", strconv.Itoa(newField.ID),
+					fieldContext, "added field is required")
 			}
 		}
 	}
@@ -418,29 +617,56 @@ func makeFieldsMap(fields []*Field) map[int]*Field {
 	return fieldsMap
 }
 
-func (a *Auditor) checkType(oldType, newType *Type, warn bool, context string) {
-	logMismatch := a.logger.LogWarning
-	if !warn {
-		logMismatch = a.logger.LogError
-	}
+func (a *Auditor) checkType(oldType, newType *Type, rule, subject string, pos Pos, context string) {
+	a.compareType(a.oldFrugal, oldType, a.newFrugal, newType, rule, subject, pos, context)
+}
 
+// compareType reports how oldType, used in oldFrugal, changed to newType, used
+// in newFrugal. Changes to constants are all reported by the given rule since
+// constants aren't sent over the network, otherwise the rule depends on how
+// the type changed.
+func (a *Auditor) compareType(oldFrugal *Frugal, oldType *Type, newFrugal *Frugal, newType *Type,
+	rule, subject string, pos Pos, context string) {
 	// guarding here makes recursive calls easier
 	if oldType == nil || newType == nil {
 		if oldType != newType {
-			logMismatch(context, fmt.Sprintf("types not equal: '%v' -> '%v'", oldType, newType))
+			a.report(rule, subject, pos, auditTypeName(oldType), auditTypeName(newType),
+				context, fmt.Sprintf("types not equal: '%v' -> '%v'", oldType, newType))
 		}
 		return
 	}
 
-	underlyingOldType := a.oldFrugal.UnderlyingType(oldType)
-	underlyingNewType := a.newFrugal.UnderlyingType(newType)
-	// TODO should this exclude the include name?
-	if underlyingOldType.Name != underlyingNewType.Name {
-		logMismatch(context, fmt.Sprintf("types not equal: '%s' -> '%s'",
-			underlyingOldType.Name, underlyingNewType.Name))
+	old, new := resolveAuditType(oldFrugal, oldType), resolveAuditType(newFrugal, newType)
+	oldName, newName := old.qualifiedName(a.oldFrugal), new.qualifiedName(a.newFrugal)
+	change, reason := a.compareTypes(old, new)
+	changeRule, message := "type-changed", fmt.Sprintf("types not equal: '%s' -> '%s'", oldName, newName)
+	switch change {
+	case typeUnchanged:
+		if old.IsContainer() {
+			if old.KeyType != nil {
+				a.compareType(old.frugal, old.KeyType, new.frugal, new.KeyType, rule, subject, pos,
+					context+" key type:")
+			}
+			a.compareType(old.frugal, old.ValueType, new.frugal, new.ValueType, rule, subject, pos,
+				context+" value type:")
+		}
 		return
+	case typeCompatible:
+		changeRule = "wire-compatible-type-changed"
+		message = fmt.Sprintf("types not equal: '%s' -> '%s', but both are encoded as %s",
+			oldName, newName, old.wireName())
+	case typeMoved:
+		changeRule = "type-moved"
+		message = fmt.Sprintf("type moved: '%s' -> '%s'", oldName, newName)
+	case typeKindChanged:
+		changeRule = "struct-kind-changed"
+		message = fmt.Sprintf("type kind changed: %s '%s' -> %s '%s'", old.def.Type, oldName, new.def.Type, newName)
+	case typeIncompatible:
+		changeRule = "struct-incompatible"
+		message = fmt.Sprintf("type '%s' changed incompatibly: %s", newName, reason)
 	}
-
-	a.checkType(underlyingOldType.KeyType, underlyingNewType.KeyType, warn, context+" key type:")
-	a.checkType(underlyingOldType.ValueType, underlyingNewType.ValueType, warn, context+" value type:")
+	if rule == "type-changed" {
+		rule = changeRule
+	}
+	a.report(rule, subject, pos, oldName, newName, context, message)
 }
@@ -0,0 +1,415 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parser
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// AuditOff is the severity of audit rules which aren't checked.
+const AuditOff = "off"
+
+// auditRule is the default severity of a rule checked by an audit and why
+// the changes it finds break callers.
+type auditRule struct {
+	severity    Severity
+	explanation string
+}
+
+// auditRules maps the id of each change an audit checks for to its rule.
+var auditRules = map[string]auditRule{
+	"constant-removed":             {SeverityWarning, "Code referring to the constant no longer compiles. Constants aren't sent over the network, so this isn't a wire change."},
+	"constant-type-changed":        {SeverityWarning, "Code using the constant may no longer compile. Constants aren't sent over the network, so this isn't a wire change."},
+	"constant-value-changed":       {SeverityWarning, "Code built from different versions uses different values. Constants aren't sent over the network, so this isn't a wire change."},
+	"default-value-changed":        {SeverityWarning, "Readers built from different versions fill in different values when the field isn't set."},
+	"enum-removed":                 {SeverityWarning, "Code referring to the enum no longer compiles. Only its values are sent over the network."},
+	"enum-value-removed":           {SeverityError, "Readers which don't know the value can't map it to the enum when they receive it."},
+	"enum-value-renamed":           {SeverityWarning, "Code referring to the old name no longer compiles. Only the number is sent over the network."},
+	"exception-added":              {SeverityWarning, "Clients built from the old version don't know the exception, so calls fail with a missing result when it's thrown."},
+	"exception-removed":            {SeverityError, "Clients built from the new version don't know the exception, so calls fail with a missing result when a server built from the old version throws it."},
+	"exceptions-added":             {SeverityError, "Clients of a void method without exceptions don't read a result, so they never see the exceptions."},
+	"exceptions-removed":           {SeverityError, "Clients of a void method without exceptions don't read a result, so clients built from the old version wait for one which is never sent."},
+	"extends-changed":              {SeverityError, "Methods inherited from the old base service are no longer served."},
+	"field-added-in-middle":        {SeverityWarning, "A field added between existing IDs usually reuses the ID of a removed field, which readers built from the old version decode as that field."},
+	"field-removed":                {SeverityError, "Readers built from the old version accept messages without the field, but read it as unset and silently use its default value instead."},
+	"field-renamed":                {SeverityWarning, "Code referring to the old name no longer compiles. Only the field ID is sent over the network."},
+	"file-removed":                 {SeverityError, "Every definition in the file was removed."},
+	"method-removed":               {SeverityError, "Calls to the method fail with an unknown method error."},
+	"namespace-changed":            {SeverityWarning, "Generated code moves to another package, so code importing it no longer compiles."},
+	"namespace-removed":            {SeverityWarning, "Generated code moves to another package, so code importing it no longer compiles."},
+	"oneway-changed":               {SeverityError, "Oneway callers don't wait for a response, so clients and servers built from different versions hang waiting for a response or leave one unread."},
+	"operation-removed":            {SeverityError, "Subscribers to the operation no longer receive messages."},
+	"presence-changed":             {SeverityError, "Readers reject messages without a required field, so messages from writers built from the version where it's optional are rejected."},
+	"request-stream-added":         {SeverityError, "Clients and servers built from different versions disagree on how requests are sent."},
+	"request-stream-removed":       {SeverityError, "Clients and servers built from different versions disagree on how requests are sent."},
+	"required-field-added":         {SeverityError, "Writers built from the old version don't set the field, so readers built from the new version reject their messages."},
+	"required-field-removed":       {SeverityError, "Readers built from the old version expect the field to be set and reject messages without it."},
+	"scope-prefix-changed":         {SeverityError, "Publishers and subscribers built from different versions use different topics, so messages aren't delivered."},
+	"scope-removed":                {SeverityError, "Subscribers to the scope no longer receive messages."},
+	"service-removed":              {SeverityError, "Calls to the service fail with unknown method errors."},
+	"stream-changed":               {SeverityError, "Clients and servers built from different versions disagree on how responses are sent."},
+	"struct-incompatible":          {SeverityError, "The struct was changed in an include so that readers and writers built from different versions disagree on its fields, so messages fail to decode."},
+	"struct-kind-changed":          {SeverityError, "Structs, unions, and exceptions are generated differently and unions must have exactly one field set, so values written as one can't be read as the other."},
+	"struct-removed":               {SeverityError, "Code referring to the struct no longer compiles and messages containing it can't be decoded."},
+	"type-changed":                 {SeverityError, "The value is encoded differently, so readers built from a different version fail to decode it or skip it."},
+	"type-moved":                   {SeverityError, "The type is declared in another file, so its generated code moves to another package and code referring to it no longer compiles."},
+	"wire-compatible-type-changed": {SeverityWarning, "The value is encoded the same way, but its generated type changes, so code using it may no longer compile."},
+}
+
+// AuditRules returns the ids of the rules checked by an audit, sorted.
+func AuditRules() []string {
+	rules := make([]string, 0, len(auditRules))
+	for rule := range auditRules {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	return rules
+}
+
+// DefaultAuditSeverity returns the severity of the given audit rule when a
+// policy doesn't set it, or an empty string if there's no such rule.
+func DefaultAuditSeverity(rule string) string {
+	return string(auditRules[rule].severity)
+}
+
+// AuditRuleExplanation returns why the changes found by the given audit rule
+// break callers, or an empty string if there's no such rule.
+func AuditRuleExplanation(rule string) string {
+	return auditRules[rule].explanation
+}
+
+// AuditPolicy sets the severity of audit rules, either "error", "warning", or
+// "off", and suppresses specific changes. Rules which aren't set use their
+// default severity.
+type AuditPolicy struct {
+	Rules        map[string]string   `yaml:"rules"`
+	Suppressions []*AuditSuppression `yaml:"suppressions"`
+}
+
+// AuditSuppression suppresses the changes found by Rule in the definition
+// named by Subject, or any definition within it, in files matching the File
+// glob. Empty fields match anything, but at least one must be set.
+type AuditSuppression struct {
+	Rule    string `yaml:"rule"`
+	Subject string `yaml:"subject"`
+	File    string `yaml:"file"`
+	Reason  string `yaml:"reason"`
+}
+
+// LoadAuditPolicy reads an AuditPolicy from the given YAML file, such as:
+//
+//	rules:
+//	  default-value-changed: error
+//	suppressions:
+//	  - rule: field-removed
+//	    subject: Album.title
+//	    reason: no clients read the title
+func LoadAuditPolicy(path string) (*AuditPolicy, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := &AuditPolicy{}
+	if err := yaml.Unmarshal(contents, policy); err != nil {
+		return nil, fmt.Errorf("audit: invalid policy %s: %s", path, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("audit: invalid policy %s: %s", path, err)
+	}
+	return policy, nil
+}
+
+// Validate returns an error if the AuditPolicy refers to a rule which doesn't
+// exist, sets an invalid severity, or has an empty suppression.
+func (p *AuditPolicy) Validate() error {
+	for rule, severity := range p.Rules {
+		if _, ok := auditRules[rule]; !ok {
+			return fmt.Errorf("unknown rule %s", rule)
+		}
+		switch severity {
+		case string(SeverityError), string(SeverityWarning), AuditOff:
+		default:
+			return fmt.Errorf("invalid severity %s for rule %s", severity, rule)
+		}
+	}
+	for i, s := range p.Suppressions {
+		if s.Rule == "" && s.Subject == "" && s.File == "" {
+			return fmt.Errorf("suppression %d matches every change", i+1)
+		}
+		if _, ok := auditRules[s.Rule]; s.Rule != "" && !ok {
+			return fmt.Errorf("unknown rule %s in suppression %d", s.Rule, i+1)
+		}
+		if _, err := filepath.Match(s.File, ""); err != nil {
+			return fmt.Errorf("invalid file pattern %s in suppression %d", s.File, i+1)
+		}
+	}
+	return nil
+}
+
+func (p *AuditPolicy) severity(rule string) string {
+	if p != nil {
+		if severity, ok := p.Rules[rule]; ok {
+			return severity
+		}
+	}
+	return string(auditRules[rule].severity)
+}
+
+// suppression returns the first suppression matching the finding, or nil.
+func (p *AuditPolicy) suppression(finding *AuditFinding) *AuditSuppression {
+	if p == nil {
+		return nil
+	}
+	for _, s := range p.Suppressions {
+		if s.matches(finding) {
+			return s
+		}
+	}
+	return nil
+}
+
+// matches indicates if the suppression applies to the finding. File patterns
+// are matched against the path of the audited file and its base name.
+func (s *AuditSuppression) matches(finding *AuditFinding) bool {
+	if s.Rule != "" && s.Rule != finding.Rule {
+		return false
+	}
+	if s.Subject != "" && s.Subject != finding.Subject && !strings.HasPrefix(finding.Subject, s.Subject+".") {
+		return false
+	}
+	if s.File != "" {
+		path := filepath.ToSlash(finding.File)
+		matched, _ := filepath.Match(s.File, path)
+		if !matched {
+			matched, _ = filepath.Match(s.File, filepath.Base(path))
+		}
+		return matched
+	}
+	return true
+}
+
+// AuditFinding is a change found by an audit. Subject is the dotted name of
+// the changed definition, such as Album.title, or namespace.go for a
+// namespace. Pos is its position in the new file, or in the old file if it
+// was removed. Old and New are the values which changed, if any, and
+// Explanation is why the rule's changes break callers.
+type AuditFinding struct {
+	Rule        string   `json:"rule"`
+	Severity    Severity `json:"severity"`
+	File        string   `json:"file"`
+	Subject     string   `json:"subject"`
+	Pos         Pos      `json:"pos"`
+	Message     string   `json:"message"`
+	Explanation string   `json:"explanation"`
+	Old         string   `json:"old,omitempty"`
+	New         string   `json:"new,omitempty"`
+	Suppressed  bool     `json:"suppressed,omitempty"`
+	Reason      string   `json:"reason,omitempty"`
+}
+
+// AuditedFile is a file audited against an old version of it.
+type AuditedFile struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// AuditReport is the result of auditing one or more files.
+type AuditReport struct {
+	Files    []*AuditedFile  `json:"files"`
+	Findings []*AuditFinding `json:"findings"`
+}
+
+// Failed indicates if any unsuppressed findings are errors.
+func (r *AuditReport) Failed() bool {
+	for _, finding := range r.Findings {
+		if !finding.Suppressed && finding.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Diagnostics returns the unsuppressed findings as Diagnostics so they can be
+// printed with their source lines.
+func (r *AuditReport) Diagnostics() Diagnostics {
+	diags := Diagnostics{}
+	for _, finding := range r.Findings {
+		if finding.Suppressed {
+			continue
+		}
+		diags = append(diags, &Diagnostic{
+			Severity: finding.Severity,
+			Pos:      finding.Pos,
+			Message:  finding.Message,
+			Rule:     finding.Rule,
+		})
+	}
+	return diags
+}
+
+// WriteJSON writes the AuditReport to w as indented JSON.
+func (r *AuditReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+type junitSuites struct {
+	XMLName  xml.Name      `xml:"testsuites"`
+	Name     string        `xml:"name,attr"`
+	Tests    int           `xml:"tests,attr"`
+	Failures int           `xml:"failures,attr"`
+	Suites   []*junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Properties []*junitProperty `xml:"properties>property"`
+	Cases      []*junitCase     `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the AuditReport to w as JUnit XML with a test suite for
+// each audited file and a test case for each rule checked in it. A test case
+// fails if its rule found unsuppressed errors. Warnings and suppressed
+// findings are included in its output.
+func (r *AuditReport) WriteJUnit(w io.Writer, policy *AuditPolicy) error {
+	byFile := make(map[string][]*AuditFinding)
+	for _, finding := range r.Findings {
+		byFile[finding.File] = append(byFile[finding.File], finding)
+	}
+
+	suites := &junitSuites{Name: "frugal audit"}
+	for _, file := range r.Files {
+		suite := &junitSuite{
+			Name:       file.New,
+			Properties: []*junitProperty{{Name: "old", Value: file.Old}},
+		}
+		for _, rule := range AuditRules() {
+			if policy.severity(rule) == AuditOff {
+				continue
+			}
+			testCase := &junitCase{Name: rule, ClassName: file.New}
+			errors, output := []string{}, []string{}
+			for _, finding := range byFile[file.New] {
+				if finding.Rule != rule {
+					continue
+				}
+				line := finding.Pos.String() + ": " + finding.Message
+				switch {
+				case finding.Suppressed:
+					output = append(output, "suppressed: "+line+suppressionReason(finding))
+				case finding.Severity == SeverityError:
+					errors = append(errors, line)
+				default:
+					output = append(output, "warning: "+line)
+				}
+			}
+			if len(errors) > 0 {
+				testCase.Failure = &junitFailure{
+					Message: strconv.Itoa(len(errors)) + " breaking change(s): " + AuditRuleExplanation(rule),
+					Type:    rule,
+					Text:    strings.Join(errors, "\n"),
+				}
+				suite.Failures++
+			}
+			testCase.SystemOut = strings.Join(output, "\n")
+			suite.Cases = append(suite.Cases, testCase)
+			suite.Tests++
+		}
+		suites.Suites = append(suites.Suites, suite)
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func suppressionReason(finding *AuditFinding) string {
+	if finding.Reason == "" {
+		return ""
+	}
+	return " (" + finding.Reason + ")"
+}
+
+// auditTypeName returns the name of a type for a report, or void if there isn't
+// one.
+func auditTypeName(t *Type) string {
+	if t == nil {
+		return "void"
+	}
+	return t.String()
+}
+
+// auditValue returns a constant or default value for a report.
+func auditValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strconv.Quote(v)
+	case []interface{}:
+		values := make([]string, len(v))
+		for i, elem := range v {
+			values[i] = auditValue(elem)
+		}
+		return "[" + strings.Join(values, ", ") + "]"
+	case []KeyValue:
+		pairs := make([]string, len(v))
+		for i, pair := range v {
+			pairs[i] = auditValue(pair.Key) + ": " + auditValue(pair.Value)
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,198 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parser
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// AuditTree audits every Frugal and Thrift file under oldDir against the file
+// at the same path under newDir. Includes are resolved within each tree, and
+// files which were removed are reported. Files which were added aren't
+// audited since there's nothing to compare them to.
+func (a *Auditor) AuditTree(oldDir, newDir string) error {
+	files := []string{}
+	err := filepath.Walk(oldDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && isIDLFile(path) {
+			rel, err := filepath.Rel(oldDir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	for _, rel := range files {
+		oldFile, newFile := filepath.Join(oldDir, rel), filepath.Join(newDir, rel)
+		if _, err := os.Stat(newFile); os.IsNotExist(err) {
+			a.file = newFile
+			a.failed = false
+			a.results.Files = append(a.results.Files, &AuditedFile{Old: a.label(oldFile), New: newFile})
+			subject := filepath.ToSlash(rel)
+			a.report("file-removed", subject, Pos{File: oldFile}, subject, "", "file removed:", subject)
+			failed = failed || a.failed
+			continue
+		}
+		fileFailed, err := a.audit(oldFile, newFile)
+		if err != nil {
+			return err
+		}
+		failed = failed || fileFailed
+	}
+
+	if failed {
+		return &AuditError{Old: a.label(oldDir), New: newDir}
+	}
+	return nil
+}
+
+// AuditRevision audits the file or directory at path against its contents at
+// the given git revision. Files in the revision are reported as rev:path,
+// relative to the root of the repository.
+func (a *Auditor) AuditRevision(rev, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if abs, err = filepath.EvalSymlinks(abs); err != nil {
+		return err
+	}
+	dir := abs
+	if !info.IsDir() {
+		dir = filepath.Dir(abs)
+	}
+	top, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return err
+	}
+	if top, err = filepath.EvalSymlinks(strings.TrimSpace(top)); err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(top, abs)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempDir("", "frugal-audit")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	if err := extractRevision(top, rev, tmp); err != nil {
+		return err
+	}
+
+	a.oldRoot, a.oldLabel = tmp, rev
+	defer func() {
+		a.oldRoot, a.oldLabel = "", ""
+	}()
+	old := filepath.Join(tmp, rel)
+	if _, err := os.Stat(old); err != nil {
+		return fmt.Errorf("%s doesn't exist at %s", path, rev)
+	}
+	if info.IsDir() {
+		return a.AuditTree(old, path)
+	}
+	return a.Audit(old, path)
+}
+
+// extractRevision writes the Frugal and Thrift files in the git repository at
+// top as of the given revision to dir.
+func extractRevision(top, rev, dir string) error {
+	cmd := exec.Command("git", "archive", "--format=tar", rev)
+	cmd.Dir = top
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	archive := tar.NewReader(stdout)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			cmd.Wait()
+			return fmt.Errorf("git archive %s: %s", rev, strings.TrimSpace(stderr.String()))
+		}
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if !header.FileInfo().Mode().IsRegular() || !isIDLFile(name) ||
+			filepath.IsAbs(name) || strings.HasPrefix(name, "..") {
+			continue
+		}
+		target := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			cmd.Wait()
+			return err
+		}
+		contents, err := ioutil.ReadAll(archive)
+		if err != nil {
+			cmd.Wait()
+			return err
+		}
+		if err := ioutil.WriteFile(target, contents, 0644); err != nil {
+			cmd.Wait()
+			return err
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("git archive %s: %s", rev, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// git runs a git command in dir and returns its output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+func isIDLFile(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".frugal" || ext == ".thrift"
+}
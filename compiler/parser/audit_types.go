@@ -0,0 +1,220 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parser
+
+import "fmt"
+
+// wireTypes maps base types to the base type they're encoded as when it's
+// shared with another base type.
+var wireTypes = map[string]string{
+	"i8":     "byte",
+	"binary": "string",
+}
+
+// auditType is a Type with its typedefs resolved in the file which declares
+// it, rather than the file being audited.
+type auditType struct {
+	*Type
+	frugal *Frugal // The file defining the type, nil if it couldn't be resolved
+	name   string  // The type name without any include prefix
+	enum   bool
+	def    *Struct // The struct, union, or exception, if the type is one
+}
+
+// resolveAuditType resolves the given Type used in the given file.
+func resolveAuditType(f *Frugal, t *Type) *auditType {
+	frugal, t := f.ResolveType(t)
+	if frugal == nil {
+		return &auditType{Type: t, name: t.Name}
+	}
+	if t.IsPrimitive() || t.IsContainer() {
+		return &auditType{Type: t, frugal: frugal, name: t.Name}
+	}
+
+	name := t.ParamName()
+	resolved := &auditType{Type: t, frugal: frugal, name: name}
+	for _, enum := range frugal.Enums {
+		if enum.Name == name {
+			resolved.enum = true
+		}
+	}
+	for _, s := range frugal.DataStructures() {
+		if s.Name == name {
+			resolved.def = s
+		}
+	}
+	return resolved
+}
+
+// qualifiedName returns the name of a resolved type as it's written in the
+// given file, prefixed by the include which declares it if it isn't declared
+// in that file.
+func (t *auditType) qualifiedName(in *Frugal) string {
+	if t.frugal == nil || t.frugal == in || t.IsPrimitive() || t.IsContainer() {
+		return t.name
+	}
+	return t.frugal.Name + "." + t.name
+}
+
+// wireName returns the base type the type is encoded as, or its name if it
+// isn't a base type or enum.
+func (t *auditType) wireName() string {
+	if t.enum {
+		return "i32"
+	}
+	if wire, ok := wireTypes[t.name]; ok {
+		return wire
+	}
+	return t.name
+}
+
+// typeChange is how the type of a definition changed between versions.
+type typeChange int
+
+const (
+	typeUnchanged typeChange = iota
+	typeCompatible
+	typeChanged
+	typeMoved
+	typeKindChanged
+	typeIncompatible
+)
+
+// compareTypes returns how a type changed from old, used in the old file, to
+// new, used in the new file, and why if it's incompatible. Containers are
+// unchanged if they're the same kind of container, their key and value types
+// are compared separately.
+func (a *Auditor) compareTypes(old, new *auditType) (typeChange, string) {
+	if old.frugal == nil || new.frugal == nil {
+		// An include couldn't be resolved, so only the names can be
+		// compared.
+		if old.Name != new.Name {
+			return typeChanged, ""
+		}
+		return typeUnchanged, ""
+	}
+
+	oldName, newName := old.qualifiedName(a.oldFrugal), new.qualifiedName(a.newFrugal)
+	switch {
+	case oldName == newName && (old.def == nil) == (new.def == nil) && old.enum == new.enum:
+		if old.def == nil {
+			return typeUnchanged, ""
+		}
+		if old.def.Type != new.def.Type {
+			return typeKindChanged, ""
+		}
+		if old.frugal == a.oldFrugal {
+			// Definitions in the audited file are checked on
+			// their own.
+			return typeUnchanged, ""
+		}
+		if reason := a.compareStructs(old, new); reason != "" {
+			return typeIncompatible, reason
+		}
+		return typeUnchanged, ""
+	case old.name == new.name && old.def != nil && new.def != nil && old.def.Type != new.def.Type:
+		return typeKindChanged, ""
+	case old.name == new.name && (old.enum || old.def != nil) && old.enum == new.enum && (old.def == nil) == (new.def == nil):
+		return typeMoved, ""
+	case old.def == nil && new.def == nil && !old.IsContainer() && !new.IsContainer() &&
+		old.wireName() == new.wireName() && (old.enum != new.enum || old.IsPrimitive() && new.IsPrimitive()):
+		// Enums are encoded as i32s, so they can be swapped for
+		// one, as can base types which are encoded the same way.
+		return typeCompatible, ""
+	}
+	return typeChanged, ""
+}
+
+// compareStructs returns why the new version of a struct, union, or exception
+// declared in an include can't be read or written by the old version, or an
+// empty string if it can. Results are cached for the current audit, and
+// recursive definitions are assumed to be compatible while being compared.
+func (a *Auditor) compareStructs(old, new *auditType) string {
+	key := old.frugal.Name + "." + old.name
+	if reason, ok := a.compared[key]; ok {
+		return reason
+	}
+	a.compared[key] = ""
+
+	reason := ""
+	newFields := makeFieldsMap(new.def.Fields)
+	for _, oldField := range old.def.Fields {
+		newField, ok := newFields[oldField.ID]
+		if !ok {
+			if oldField.Modifier == Required {
+				reason = fmt.Sprintf("required field %s removed with ID=%d", oldField.Name, oldField.ID)
+				break
+			}
+			continue
+		}
+		if (oldField.Modifier == Required) != (newField.Modifier == Required) {
+			reason = fmt.Sprintf("field %s presence modifier changed: '%s' -> '%s'",
+				oldField.Name, oldField.Modifier.String(), newField.Modifier.String())
+			break
+		}
+		if why := a.compareFieldTypes(old.frugal, oldField.Type, new.frugal, newField.Type); why != "" {
+			reason = fmt.Sprintf("field %s %s", oldField.Name, why)
+			break
+		}
+	}
+	if reason == "" {
+		oldFields := makeFieldsMap(old.def.Fields)
+		for _, newField := range new.def.Fields {
+			if _, ok := oldFields[newField.ID]; !ok && newField.Modifier == Required {
+				reason = fmt.Sprintf("required field %s added with ID=%d", newField.Name, newField.ID)
+				break
+			}
+		}
+	}
+
+	a.compared[key] = reason
+	return reason
+}
+
+// compareFieldTypes returns why the type of a field in an included struct
+// can't be read or written by its old type, or an empty string if it can.
+func (a *Auditor) compareFieldTypes(oldFrugal *Frugal, oldType *Type, newFrugal *Frugal, newType *Type) string {
+	old, new := resolveAuditType(oldFrugal, oldType), resolveAuditType(newFrugal, newType)
+	if old.IsContainer() && new.IsContainer() && old.Name == new.Name {
+		if old.KeyType != nil {
+			if why := a.compareFieldTypes(old.frugal, old.KeyType, new.frugal, new.KeyType); why != "" {
+				return "key type: " + why
+			}
+		}
+		if why := a.compareFieldTypes(old.frugal, old.ValueType, new.frugal, new.ValueType); why != "" {
+			return "value type: " + why
+		}
+		return ""
+	}
+
+	// Names are compared within the declaring files, since the field
+	// isn't in the audited file.
+	oldName, newName := old.qualifiedName(oldFrugal), new.qualifiedName(newFrugal)
+	switch {
+	case old.def != nil && new.def != nil && old.name == new.name:
+		if old.def.Type != new.def.Type {
+			return fmt.Sprintf("changed from %s to %s", old.def.Type, new.def.Type)
+		}
+		if why := a.compareStructs(old, new); why != "" {
+			return fmt.Sprintf("type %s changed: %s", newName, why)
+		}
+		return ""
+	case old.def == nil && new.def == nil && !old.IsContainer() && !new.IsContainer() &&
+		old.wireName() == new.wireName() && (old.enum != new.enum || old.IsPrimitive() && new.IsPrimitive()):
+		return ""
+	case oldName == newName, old.enum && new.enum && old.name == new.name:
+		return ""
+	}
+	return fmt.Sprintf("types not equal: '%s' -> '%s'", oldName, newName)
+}
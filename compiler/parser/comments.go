@@ -0,0 +1,88 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parser
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// scanComments returns the comments in the given contents of a Frugal file
+// which has already been parsed. The grammar skips comments as whitespace, so
+// they're found by scanning for comment delimiters outside of string literals
+// the same way the grammar matches them.
+func scanComments(file string, contents []byte) []*Comment {
+	comments := []*Comment{}
+	line, col := 1, 1
+	advance := func(from, to int) {
+		for _, r := range string(contents[from:to]) {
+			if r == '\n' {
+				line++
+				col = 1
+			} else {
+				col++
+			}
+		}
+	}
+
+	for i := 0; i < len(contents); {
+		end := i + 1
+		comment := true
+		rest := contents[i:]
+		switch {
+		case rest[0] == '"' || rest[0] == '\'':
+			end = literalEnd(contents, i)
+			comment = false
+		case bytes.HasPrefix(rest, []byte("//")) || rest[0] == '#':
+			end = len(contents)
+			if eol := bytes.IndexByte(rest, '\n'); eol >= 0 {
+				end = i + eol
+			}
+		case bytes.HasPrefix(rest, []byte("/*")):
+			end = len(contents)
+			if close := bytes.Index(rest[2:], []byte("*/")); close >= 0 {
+				end = i + 2 + close + 2
+			}
+		default:
+			_, size := utf8.DecodeRune(rest)
+			end = i + size
+			comment = false
+		}
+
+		if comment {
+			comments = append(comments, &Comment{
+				Text: string(contents[i:end]),
+				Pos:  Pos{File: file, Line: line, Column: col, Offset: i, Span: end - i},
+			})
+		}
+		advance(i, end)
+		i = end
+	}
+	return comments
+}
+
+// literalEnd returns the offset just past the string literal starting at the
+// given offset. Like the grammar, only the quote character can be escaped.
+func literalEnd(contents []byte, start int) int {
+	quote := contents[start]
+	for i := start + 1; i < len(contents); i++ {
+		switch {
+		case contents[i] == '\\' && i+1 < len(contents) && contents[i+1] == quote:
+			i++
+		case contents[i] == quote:
+			return i + 1
+		}
+	}
+	return len(contents)
+}
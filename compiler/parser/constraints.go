@@ -0,0 +1,287 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ConstraintAnnotationPrefix is the prefix of field annotations which
+// constrain the value of the field, e.g. validate.max_len = "64".
+const ConstraintAnnotationPrefix = "validate."
+
+// Supported constraint rules.
+const (
+	// ConstraintMin is the minimum value of a number.
+	ConstraintMin = "min"
+
+	// ConstraintMax is the maximum value of a number.
+	ConstraintMax = "max"
+
+	// ConstraintMinLen is the minimum length of a string, in characters, of
+	// binary, in bytes, or of a container.
+	ConstraintMinLen = "min_len"
+
+	// ConstraintMaxLen is the maximum length of a string, in characters, of
+	// binary, in bytes, or of a container.
+	ConstraintMaxLen = "max_len"
+
+	// ConstraintPattern is a regular expression a string must match.
+	ConstraintPattern = "pattern"
+
+	// ConstraintRequiredIf makes an optional field required when another
+	// field of the struct is set, given as "field", or has a value, given as
+	// "field=value".
+	ConstraintRequiredIf = "required_if"
+)
+
+// Constraint is a rule on the value of a field given by a validate
+// annotation.
+type Constraint struct {
+	Rule  string
+	Value string
+	Pos   Pos
+}
+
+// RequiredIf splits the value of a required_if Constraint into the name of
+// the field it depends on and the value that field must have, if any.
+func (c *Constraint) RequiredIf() (field, value string, hasValue bool) {
+	parts := strings.SplitN(c.Value, "=", 2)
+	field = strings.TrimSpace(parts[0])
+	if len(parts) == 1 {
+		return field, "", false
+	}
+	return field, strings.TrimSpace(parts[1]), true
+}
+
+// Constraints returns the Constraints given by the validate annotations of
+// the Field in the order they're declared.
+func (f *Field) Constraints() []*Constraint {
+	var constraints []*Constraint
+	for _, annotation := range f.Annotations {
+		if !strings.HasPrefix(annotation.Name, ConstraintAnnotationPrefix) {
+			continue
+		}
+		constraints = append(constraints, &Constraint{
+			Rule:  strings.TrimPrefix(annotation.Name, ConstraintAnnotationPrefix),
+			Value: annotation.Value,
+			Pos:   annotation.Pos,
+		})
+	}
+	return constraints
+}
+
+// Constraint returns the Constraint of the Field with the given rule, if any.
+func (f *Field) Constraint(rule string) (*Constraint, bool) {
+	for _, constraint := range f.Constraints() {
+		if constraint.Rule == rule {
+			return constraint, true
+		}
+	}
+	return nil, false
+}
+
+// HasConstraints indicates if any Field of the Struct has a Constraint.
+func (s *Struct) HasConstraints() bool {
+	for _, field := range s.Fields {
+		if len(field.Constraints()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// validateConstraints ensures the Constraints of the given fields are known
+// and apply to the types of the fields. The owner is the struct or method the
+// fields belong to. Optional method arguments are generated as plain values,
+// so whether they were set isn't known and they can't have Constraints.
+func (f *Frugal) validateConstraints(owner string, fields []*Field, arguments bool, diags *Diagnostics) {
+	for _, field := range fields {
+		if arguments && field.Modifier == Optional {
+			for _, constraint := range field.Constraints() {
+				diags.addError(constraint.Pos, "%s%s does not apply to optional argument %s.%s, make it default or required",
+					ConstraintAnnotationPrefix, constraint.Rule, owner, field.Name)
+			}
+			continue
+		}
+		rules := make(map[string]*Constraint)
+		for _, constraint := range field.Constraints() {
+			if _, ok := rules[constraint.Rule]; ok {
+				diags.addError(constraint.Pos, "Duplicate validation rule %s%s on field %s.%s",
+					ConstraintAnnotationPrefix, constraint.Rule, owner, field.Name)
+				continue
+			}
+			rules[constraint.Rule] = constraint
+			f.validateConstraint(owner, fields, field, constraint, diags)
+		}
+		if min, ok := rules[ConstraintMin]; ok {
+			if max, ok := rules[ConstraintMax]; ok && f.constraintGreater(field.Type, min.Value, max.Value) {
+				diags.addError(min.Pos, "%smin is greater than %smax on field %s.%s",
+					ConstraintAnnotationPrefix, ConstraintAnnotationPrefix, owner, field.Name)
+			}
+		}
+		if min, ok := rules[ConstraintMinLen]; ok {
+			if max, ok := rules[ConstraintMaxLen]; ok && f.constraintGreater(&Type{Name: "i32"}, min.Value, max.Value) {
+				diags.addError(min.Pos, "%smin_len is greater than %smax_len on field %s.%s",
+					ConstraintAnnotationPrefix, ConstraintAnnotationPrefix, owner, field.Name)
+			}
+		}
+	}
+}
+
+func (f *Frugal) validateConstraint(owner string, fields []*Field, field *Field, constraint *Constraint, diags *Diagnostics) {
+	frugal, typ := f.ResolveType(field.Type)
+	if frugal == nil {
+		// The invalid type is reported by validateStructLike.
+		return
+	}
+	applies := false
+	switch constraint.Rule {
+	case ConstraintMin, ConstraintMax:
+		applies = isNumeric(typ)
+		if applies && !isValidNumber(typ, constraint.Value) {
+			diags.addError(constraint.Pos, "Invalid %s%s value \"%s\" for %s field %s.%s",
+				ConstraintAnnotationPrefix, constraint.Rule, constraint.Value, typ.Name, owner, field.Name)
+		}
+	case ConstraintMinLen, ConstraintMaxLen:
+		applies = typ.Name == "string" || typ.Name == "binary" || typ.IsContainer()
+		if n, err := strconv.ParseInt(constraint.Value, 10, 32); applies && (err != nil || n < 0) {
+			diags.addError(constraint.Pos, "Invalid %s%s value \"%s\" on field %s.%s, expected a non-negative integer",
+				ConstraintAnnotationPrefix, constraint.Rule, constraint.Value, owner, field.Name)
+		}
+	case ConstraintPattern:
+		applies = typ.Name == "string"
+		if _, err := regexp.Compile(constraint.Value); applies && err != nil {
+			diags.addError(constraint.Pos, "Invalid %spattern on field %s.%s: %s",
+				ConstraintAnnotationPrefix, owner, field.Name, err)
+		}
+	case ConstraintRequiredIf:
+		if field.Modifier != Optional {
+			diags.addError(constraint.Pos, "%srequired_if only applies to optional fields, %s.%s is not optional",
+				ConstraintAnnotationPrefix, owner, field.Name)
+			return
+		}
+		f.validateRequiredIf(owner, fields, field, constraint, diags)
+		return
+	default:
+		diags.addError(constraint.Pos, "Unknown validation rule %s%s on field %s.%s",
+			ConstraintAnnotationPrefix, constraint.Rule, owner, field.Name)
+		return
+	}
+	if !applies {
+		diags.addError(constraint.Pos, "%s%s does not apply to field %s.%s of type %s",
+			ConstraintAnnotationPrefix, constraint.Rule, owner, field.Name, field.Type)
+	}
+}
+
+func (f *Frugal) validateRequiredIf(owner string, fields []*Field, field *Field, constraint *Constraint, diags *Diagnostics) {
+	name, value, hasValue := constraint.RequiredIf()
+	var other *Field
+	for _, candidate := range fields {
+		if candidate.Name == name && candidate != field {
+			other = candidate
+		}
+	}
+	if other == nil {
+		diags.addError(constraint.Pos, "%srequired_if on field %s.%s refers to unknown field \"%s\"",
+			ConstraintAnnotationPrefix, owner, field.Name, name)
+		return
+	}
+	if !hasValue {
+		return
+	}
+	frugal, typ := f.ResolveType(other.Type)
+	if frugal == nil {
+		return
+	}
+	valid := false
+	switch {
+	case typ.Name == "string":
+		valid = true
+	case typ.Name == "bool":
+		_, err := strconv.ParseBool(value)
+		valid = err == nil
+	case isNumeric(typ):
+		valid = isValidNumber(typ, value)
+	case frugal.IsEnum(typ):
+		valid = frugal.enumHasValue(typ, value)
+	default:
+		diags.addError(constraint.Pos, "%srequired_if on field %s.%s can't compare the value of field %s of type %s",
+			ConstraintAnnotationPrefix, owner, field.Name, other.Name, other.Type)
+		return
+	}
+	if !valid {
+		diags.addError(constraint.Pos, "%srequired_if on field %s.%s compares field %s of type %s to invalid value \"%s\"",
+			ConstraintAnnotationPrefix, owner, field.Name, other.Name, other.Type, value)
+	}
+}
+
+// constraintGreater indicates if the number a is greater than b. Both are
+// expected to be valid for the numeric Type.
+func (f *Frugal) constraintGreater(t *Type, a, b string) bool {
+	_, typ := f.ResolveType(t)
+	if typ.Name == "double" {
+		x, errX := strconv.ParseFloat(a, 64)
+		y, errY := strconv.ParseFloat(b, 64)
+		return errX == nil && errY == nil && x > y
+	}
+	x, errX := strconv.ParseInt(a, 10, 64)
+	y, errY := strconv.ParseInt(b, 10, 64)
+	return errX == nil && errY == nil && x > y
+}
+
+// enumHasValue indicates if the enum Type, declared in this Frugal, has a
+// value with the given name.
+func (f *Frugal) enumHasValue(t *Type, name string) bool {
+	for _, enum := range f.Enums {
+		if enum.Name != t.ParamName() {
+			continue
+		}
+		for _, value := range enum.Values {
+			if value.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isNumeric(t *Type) bool {
+	switch t.Name {
+	case "byte", "i8", "i16", "i32", "i64", "double":
+		return true
+	}
+	return false
+}
+
+// isValidNumber indicates if the value is a number which fits the numeric
+// Type.
+func isValidNumber(t *Type, value string) bool {
+	var err error
+	switch t.Name {
+	case "byte", "i8":
+		_, err = strconv.ParseInt(value, 10, 8)
+	case "i16":
+		_, err = strconv.ParseInt(value, 10, 16)
+	case "i32":
+		_, err = strconv.ParseInt(value, 10, 32)
+	case "i64":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "double":
+		_, err = strconv.ParseFloat(value, 64)
+	}
+	return err == nil
+}
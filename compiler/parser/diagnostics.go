@@ -0,0 +1,259 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Severity indicates how serious a Diagnostic is.
+type Severity string
+
+// Supported Severities.
+const (
+	// SeverityError is used for problems which prevent a Frugal file from
+	// being compiled.
+	SeverityError Severity = "error"
+
+	// SeverityWarning is used for problems which don't prevent a Frugal file
+	// from being compiled but are likely mistakes.
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic is an error or warning found in a Frugal file. Rule is set for
+// problems reported by a named check, such as a lint rule.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Pos      Pos      `json:"pos"`
+	Message  string   `json:"message"`
+	Rule     string   `json:"rule,omitempty"`
+}
+
+// Error returns the Diagnostic message prefixed with its position, if known.
+func (d *Diagnostic) Error() string {
+	if pos := d.Pos.String(); pos != "" {
+		return pos + ": " + d.Message
+	}
+	return d.Message
+}
+
+// Diagnostics is a list of Diagnostics. ParseFrugal returns Diagnostics as its
+// error when a Frugal file contains errors so that all of them can be reported
+// at once.
+type Diagnostics []*Diagnostic
+
+// HasErrors indicates if any of the Diagnostics are errors.
+func (d Diagnostics) HasErrors() bool {
+	for _, diag := range d {
+		if diag.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Warnings returns the Diagnostics which are warnings.
+func (d Diagnostics) Warnings() Diagnostics {
+	var warnings Diagnostics
+	for _, diag := range d {
+		if diag.Severity == SeverityWarning {
+			warnings = append(warnings, diag)
+		}
+	}
+	return warnings
+}
+
+// Error returns the errors contained in the Diagnostics, one per line.
+func (d Diagnostics) Error() string {
+	msgs := []string{}
+	for _, diag := range d {
+		if diag.Severity == SeverityError {
+			msgs = append(msgs, diag.Error())
+		}
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Format writes the Diagnostics to w. Each one is followed by the source line
+// it refers to with the offending text marked by carets.
+func (d Diagnostics) Format(w io.Writer) error {
+	sources := make(map[string][]string)
+	for _, diag := range d {
+		header := fmt.Sprintf("%s: %s", diag.Severity, diag.Message)
+		if diag.Rule != "" {
+			header += fmt.Sprintf(" (%s)", diag.Rule)
+		}
+		if pos := diag.Pos.String(); pos != "" {
+			header = pos + ": " + header
+		}
+		if _, err := fmt.Fprintln(w, header); err != nil {
+			return err
+		}
+		line, ok := sourceLine(sources, diag.Pos)
+		if !ok {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "\t%s\n\t%s\n", line, caret(line, diag.Pos)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d Diagnostics) Len() int {
+	return len(d)
+}
+
+func (d Diagnostics) Swap(i, j int) {
+	d[i], d[j] = d[j], d[i]
+}
+
+func (d Diagnostics) Less(i, j int) bool {
+	if d[i].Pos.File != d[j].Pos.File {
+		return d[i].Pos.File < d[j].Pos.File
+	}
+	return d[i].Pos.Offset < d[j].Pos.Offset
+}
+
+func (d *Diagnostics) addError(pos Pos, format string, args ...interface{}) {
+	*d = append(*d, &Diagnostic{Severity: SeverityError, Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+func (d *Diagnostics) addWarning(pos Pos, format string, args ...interface{}) {
+	*d = append(*d, &Diagnostic{Severity: SeverityWarning, Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns the Diagnostics sorted by position if they contain any errors,
+// otherwise nil.
+func (d Diagnostics) err() error {
+	if !d.HasErrors() {
+		return nil
+	}
+	sort.Stable(d)
+	return d
+}
+
+// newParseDiagnostics converts the errors returned by the generated parser
+// into Diagnostics. Errors for input which was skipped because it couldn't be
+// parsed are dropped if a more specific error was reported within or directly
+// after it, such as for a statement which failed to parse because of a
+// missing brace.
+func newParseDiagnostics(file string, err error) Diagnostics {
+	errs, ok := err.(errList)
+	if !ok {
+		errs = errList{err}
+	}
+	all := make(Diagnostics, 0, len(errs))
+	extents := make(map[*Diagnostic]int, len(errs))
+	for _, e := range errs {
+		diag := &Diagnostic{Severity: SeverityError, Pos: Pos{File: file}, Message: e.Error()}
+		if pe, ok := e.(*parserError); ok {
+			diag.Pos.Line = pe.pos.line
+			diag.Pos.Column = pe.pos.col
+			diag.Pos.Offset = pe.pos.offset
+			diag.Message = pe.Inner.Error()
+			if se, ok := pe.Inner.(*syntaxError); ok {
+				diag.Pos.Span = se.span
+				extents[diag] = se.extent
+			}
+		}
+		all = append(all, diag)
+	}
+
+	diags := Diagnostics{}
+	for _, diag := range all {
+		if !coversSpecific(diag, extents, all) {
+			diags = append(diags, diag)
+		}
+	}
+	sort.Stable(diags)
+	return diags
+}
+
+// coversSpecific indicates if a Diagnostic for a single character starts
+// within or directly after the input skipped by diag.
+func coversSpecific(diag *Diagnostic, extents map[*Diagnostic]int, diags Diagnostics) bool {
+	extent := extents[diag]
+	if extent <= 1 {
+		return false
+	}
+	for _, other := range diags {
+		if other == diag || extents[other] > 1 {
+			continue
+		}
+		if other.Pos.Offset >= diag.Pos.Offset && other.Pos.Offset <= diag.Pos.Offset+extent {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceLine returns the line of source the given Pos refers to, reading and
+// caching the file's contents if necessary.
+func sourceLine(sources map[string][]string, pos Pos) (string, bool) {
+	if !pos.IsValid() || pos.File == "" {
+		return "", false
+	}
+	lines, ok := sources[pos.File]
+	if !ok {
+		contents, err := ioutil.ReadFile(pos.File)
+		if err == nil {
+			scanner := bufio.NewScanner(bytes.NewReader(contents))
+			for scanner.Scan() {
+				lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+			}
+		}
+		sources[pos.File] = lines
+	}
+	if pos.Line > len(lines) {
+		return "", false
+	}
+	return lines[pos.Line-1], true
+}
+
+// caret returns a marker for the text the given Pos covers on line. Tabs
+// before the marker are kept so it lines up with the source.
+func caret(line string, pos Pos) string {
+	var marker bytes.Buffer
+	start := len(line)
+	col := 1
+	for i, r := range line {
+		if col == pos.Column {
+			start = i
+			break
+		}
+		if r == '\t' {
+			marker.WriteRune('\t')
+		} else {
+			marker.WriteRune(' ')
+		}
+		col++
+	}
+	end := start + pos.Span
+	if end > len(line) {
+		end = len(line)
+	}
+	marker.WriteRune('^')
+	for i := 1; i < utf8.RuneCountInString(line[start:end]); i++ {
+		marker.WriteRune('~')
+	}
+	return marker.String()
+}
@@ -30,6 +30,67 @@ type exception *Struct
 
 type union *Struct
 
+type streamType *Type
+
+// syntaxError is returned by rules which match input that isn't valid
+// Frugal. It records the length of the offending input so diagnostics can
+// cover all of it, as well as the length of any whitespace skipped after
+// it.
+type syntaxError struct {
+	msg    string
+	span   int
+	extent int
+}
+
+func (e *syntaxError) Error() string {
+	return e.msg
+}
+
+// syntaxError returns a syntaxError covering the text matched by the
+// current rule.
+func (c *current) syntaxError(msg string) error {
+	return &syntaxError{
+		msg:    msg,
+		span:   len(bytes.TrimRight(c.text, " \t\r\n")),
+		extent: len(c.text),
+	}
+}
+
+// nodePos returns the position of the text matched by the current rule.
+func (c *current) nodePos() Pos {
+	return Pos{
+		Line:   c.pos.line,
+		Column: c.pos.col,
+		Offset: c.pos.offset,
+		Span:   len(bytes.TrimRight(c.text, " \t\r\n")),
+	}
+}
+
+// structPos returns the position of a struct, exception, or union
+// definition including its keyword.
+func (c *current) structPos(st *Struct) Pos {
+	pos := c.nodePos()
+	pos.Span = st.Pos.Offset + st.Pos.Span - pos.Offset
+	return pos
+}
+
+// nodePosBetween returns the position of the text matched by the current
+// rule between the given Marks. Either may be nil to use the start or end
+// of the match. This is used to leave leading doc comments and trailing
+// line comments out of a node's position.
+func (c *current) nodePosBetween(start, end interface{}) Pos {
+	pos := c.nodePos()
+	if start != nil {
+		pos = start.(Pos)
+	}
+	text := c.text[pos.Offset-c.pos.offset:]
+	if end != nil {
+		text = text[:end.(Pos).Offset-pos.Offset]
+	}
+	pos.Span = len(bytes.TrimRight(text, " \t\r\n"))
+	return pos
+}
+
 func newScopePrefix(prefix string) (*ScopePrefix, error) {
 	variables := []string{}
 	for _, variable := range prefixVariable.FindAllString(prefix, -1) {
@@ -88,49 +149,49 @@ var g = &grammar{
 	rules: []*rule{
 		{
 			name: "Grammar",
-			pos:  position{line: 85, col: 1, offset: 2393},
+			pos:  position{line: 146, col: 1, offset: 4391},
 			expr: &actionExpr{
-				pos: position{line: 85, col: 12, offset: 2404},
+				pos: position{line: 146, col: 12, offset: 4402},
 				run: (*parser).callonGrammar1,
 				expr: &seqExpr{
-					pos: position{line: 85, col: 12, offset: 2404},
+					pos: position{line: 146, col: 12, offset: 4402},
 					exprs: []interface{}{
 						&ruleRefExpr{
-							pos:  position{line: 85, col: 12, offset: 2404},
+							pos:  position{line: 146, col: 12, offset: 4402},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 85, col: 15, offset: 2407},
+							pos:   position{line: 146, col: 15, offset: 4405},
 							label: "statements",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 85, col: 26, offset: 2418},
+								pos: position{line: 146, col: 26, offset: 4416},
 								expr: &seqExpr{
-									pos: position{line: 85, col: 28, offset: 2420},
+									pos: position{line: 146, col: 28, offset: 4418},
 									exprs: []interface{}{
-										&ruleRefExpr{
-											pos:  position{line: 85, col: 28, offset: 2420},
-											name: "Statement",
+										&choiceExpr{
+											pos: position{line: 146, col: 30, offset: 4420},
+											alternatives: []interface{}{
+												&ruleRefExpr{
+													pos:  position{line: 146, col: 30, offset: 4420},
+													name: "Statement",
+												},
+												&ruleRefExpr{
+													pos:  position{line: 146, col: 42, offset: 4432},
+													name: "SyntaxError",
+												},
+											},
 										},
 										&ruleRefExpr{
-											pos:  position{line: 85, col: 38, offset: 2430},
+											pos:  position{line: 146, col: 56, offset: 4446},
 											name: "__",
 										},
 									},
 								},
 							},
 						},
-						&choiceExpr{
-							pos: position{line: 85, col: 45, offset: 2437},
-							alternatives: []interface{}{
-								&ruleRefExpr{
-									pos:  position{line: 85, col: 45, offset: 2437},
-									name: "EOF",
-								},
-								&ruleRefExpr{
-									pos:  position{line: 85, col: 51, offset: 2443},
-									name: "SyntaxError",
-								},
-							},
+						&ruleRefExpr{
+							pos:  position{line: 146, col: 62, offset: 4452},
+							name: "EOF",
 						},
 					},
 				},
@@ -138,38 +199,174 @@ var g = &grammar{
 		},
 		{
 			name: "SyntaxError",
-			pos:  position{line: 150, col: 1, offset: 4792},
+			pos:  position{line: 218, col: 1, offset: 7095},
 			expr: &actionExpr{
-				pos: position{line: 150, col: 16, offset: 4807},
+				pos: position{line: 218, col: 16, offset: 7110},
 				run: (*parser).callonSyntaxError1,
-				expr: &anyMatcher{
-					line: 150, col: 16, offset: 4807,
+				expr: &seqExpr{
+					pos: position{line: 218, col: 16, offset: 7110},
+					exprs: []interface{}{
+						&anyMatcher{
+							line: 218, col: 16, offset: 7110,
+						},
+						&zeroOrMoreExpr{
+							pos: position{line: 218, col: 18, offset: 7112},
+							expr: &seqExpr{
+								pos: position{line: 218, col: 20, offset: 7114},
+								exprs: []interface{}{
+									&notExpr{
+										pos: position{line: 218, col: 20, offset: 7114},
+										expr: &seqExpr{
+											pos: position{line: 218, col: 23, offset: 7117},
+											exprs: []interface{}{
+												&ruleRefExpr{
+													pos:  position{line: 218, col: 23, offset: 7117},
+													name: "EOL",
+												},
+												&ruleRefExpr{
+													pos:  position{line: 218, col: 27, offset: 7121},
+													name: "StatementStart",
+												},
+											},
+										},
+									},
+									&anyMatcher{
+										line: 218, col: 44, offset: 7138,
+									},
+								},
+							},
+						},
+						&zeroOrOneExpr{
+							pos: position{line: 218, col: 49, offset: 7143},
+							expr: &ruleRefExpr{
+								pos:  position{line: 218, col: 49, offset: 7143},
+								name: "EOL",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "StatementStart",
+			pos:  position{line: 222, col: 1, offset: 7207},
+			expr: &choiceExpr{
+				pos: position{line: 222, col: 19, offset: 7225},
+				alternatives: []interface{}{
+					&seqExpr{
+						pos: position{line: 222, col: 19, offset: 7225},
+						exprs: []interface{}{
+							&choiceExpr{
+								pos: position{line: 222, col: 21, offset: 7227},
+								alternatives: []interface{}{
+									&litMatcher{
+										pos:        position{line: 222, col: 21, offset: 7227},
+										val:        "include",
+										ignoreCase: false,
+									},
+									&litMatcher{
+										pos:        position{line: 222, col: 33, offset: 7239},
+										val:        "namespace",
+										ignoreCase: false,
+									},
+									&litMatcher{
+										pos:        position{line: 222, col: 47, offset: 7253},
+										val:        "const",
+										ignoreCase: false,
+									},
+									&litMatcher{
+										pos:        position{line: 222, col: 57, offset: 7263},
+										val:        "typedef",
+										ignoreCase: false,
+									},
+									&litMatcher{
+										pos:        position{line: 222, col: 69, offset: 7275},
+										val:        "enum",
+										ignoreCase: false,
+									},
+									&litMatcher{
+										pos:        position{line: 222, col: 78, offset: 7284},
+										val:        "struct",
+										ignoreCase: false,
+									},
+									&litMatcher{
+										pos:        position{line: 222, col: 89, offset: 7295},
+										val:        "union",
+										ignoreCase: false,
+									},
+									&litMatcher{
+										pos:        position{line: 222, col: 99, offset: 7305},
+										val:        "exception",
+										ignoreCase: false,
+									},
+									&litMatcher{
+										pos:        position{line: 222, col: 113, offset: 7319},
+										val:        "service",
+										ignoreCase: false,
+									},
+									&litMatcher{
+										pos:        position{line: 222, col: 125, offset: 7331},
+										val:        "scope",
+										ignoreCase: false,
+									},
+								},
+							},
+							&notExpr{
+								pos: position{line: 222, col: 135, offset: 7341},
+								expr: &choiceExpr{
+									pos: position{line: 222, col: 138, offset: 7344},
+									alternatives: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 222, col: 138, offset: 7344},
+											name: "Letter",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 222, col: 147, offset: 7353},
+											name: "Digit",
+										},
+										&charClassMatcher{
+											pos:        position{line: 222, col: 155, offset: 7361},
+											val:        "[._]",
+											chars:      []rune{'.', '_'},
+											ignoreCase: false,
+											inverted:   false,
+										},
+									},
+								},
+							},
+						},
+					},
+					&litMatcher{
+						pos:        position{line: 222, col: 164, offset: 7370},
+						val:        "/**@",
+						ignoreCase: false,
+					},
 				},
 			},
 		},
 		{
 			name: "Statement",
-			pos:  position{line: 154, col: 1, offset: 4865},
+			pos:  position{line: 224, col: 1, offset: 7378},
 			expr: &actionExpr{
-				pos: position{line: 154, col: 14, offset: 4878},
+				pos: position{line: 224, col: 14, offset: 7391},
 				run: (*parser).callonStatement1,
 				expr: &seqExpr{
-					pos: position{line: 154, col: 14, offset: 4878},
+					pos: position{line: 224, col: 14, offset: 7391},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 154, col: 14, offset: 4878},
+							pos:   position{line: 224, col: 14, offset: 7391},
 							label: "docstr",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 154, col: 21, offset: 4885},
+								pos: position{line: 224, col: 21, offset: 7398},
 								expr: &seqExpr{
-									pos: position{line: 154, col: 22, offset: 4886},
+									pos: position{line: 224, col: 22, offset: 7399},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 154, col: 22, offset: 4886},
+											pos:  position{line: 224, col: 22, offset: 7399},
 											name: "DocString",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 154, col: 32, offset: 4896},
+											pos:  position{line: 224, col: 32, offset: 7409},
 											name: "__",
 										},
 									},
@@ -177,10 +374,10 @@ var g = &grammar{
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 154, col: 37, offset: 4901},
+							pos:   position{line: 224, col: 37, offset: 7414},
 							label: "statement",
 							expr: &ruleRefExpr{
-								pos:  position{line: 154, col: 47, offset: 4911},
+								pos:  position{line: 224, col: 47, offset: 7424},
 								name: "FrugalStatement",
 							},
 						},
@@ -190,48 +387,48 @@ var g = &grammar{
 		},
 		{
 			name: "FrugalStatement",
-			pos:  position{line: 167, col: 1, offset: 5381},
+			pos:  position{line: 237, col: 1, offset: 7894},
 			expr: &choiceExpr{
-				pos: position{line: 167, col: 20, offset: 5400},
+				pos: position{line: 237, col: 20, offset: 7913},
 				alternatives: []interface{}{
 					&ruleRefExpr{
-						pos:  position{line: 167, col: 20, offset: 5400},
+						pos:  position{line: 237, col: 20, offset: 7913},
 						name: "Include",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 167, col: 30, offset: 5410},
+						pos:  position{line: 237, col: 30, offset: 7923},
 						name: "Namespace",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 167, col: 42, offset: 5422},
+						pos:  position{line: 237, col: 42, offset: 7935},
 						name: "Const",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 167, col: 50, offset: 5430},
+						pos:  position{line: 237, col: 50, offset: 7943},
 						name: "Enum",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 167, col: 57, offset: 5437},
+						pos:  position{line: 237, col: 57, offset: 7950},
 						name: "TypeDef",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 167, col: 67, offset: 5447},
+						pos:  position{line: 237, col: 67, offset: 7960},
 						name: "Struct",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 167, col: 76, offset: 5456},
+						pos:  position{line: 237, col: 76, offset: 7969},
 						name: "Exception",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 167, col: 88, offset: 5468},
+						pos:  position{line: 237, col: 88, offset: 7981},
 						name: "Union",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 167, col: 96, offset: 5476},
+						pos:  position{line: 237, col: 96, offset: 7989},
 						name: "Service",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 167, col: 106, offset: 5486},
+						pos:  position{line: 237, col: 106, offset: 7999},
 						name: "Scope",
 					},
 				},
@@ -239,47 +436,55 @@ var g = &grammar{
 		},
 		{
 			name: "Include",
-			pos:  position{line: 169, col: 1, offset: 5493},
+			pos:  position{line: 239, col: 1, offset: 8006},
 			expr: &actionExpr{
-				pos: position{line: 169, col: 12, offset: 5504},
+				pos: position{line: 239, col: 12, offset: 8017},
 				run: (*parser).callonInclude1,
 				expr: &seqExpr{
-					pos: position{line: 169, col: 12, offset: 5504},
+					pos: position{line: 239, col: 12, offset: 8017},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 169, col: 12, offset: 5504},
+							pos:        position{line: 239, col: 12, offset: 8017},
 							val:        "include",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 169, col: 22, offset: 5514},
+							pos:  position{line: 239, col: 22, offset: 8027},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 169, col: 24, offset: 5516},
+							pos:   position{line: 239, col: 24, offset: 8029},
 							label: "file",
 							expr: &ruleRefExpr{
-								pos:  position{line: 169, col: 29, offset: 5521},
+								pos:  position{line: 239, col: 29, offset: 8034},
 								name: "Literal",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 169, col: 37, offset: 5529},
+							pos:  position{line: 239, col: 37, offset: 8042},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 169, col: 39, offset: 5531},
+							pos:   position{line: 239, col: 39, offset: 8044},
 							label: "annotations",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 169, col: 51, offset: 5543},
+								pos: position{line: 239, col: 51, offset: 8056},
 								expr: &ruleRefExpr{
-									pos:  position{line: 169, col: 51, offset: 5543},
+									pos:  position{line: 239, col: 51, offset: 8056},
 									name: "TypeAnnotations",
 								},
 							},
 						},
+						&labeledExpr{
+							pos:   position{line: 239, col: 68, offset: 8073},
+							label: "end",
+							expr: &ruleRefExpr{
+								pos:  position{line: 239, col: 72, offset: 8077},
+								name: "Mark",
+							},
+						},
 						&ruleRefExpr{
-							pos:  position{line: 169, col: 68, offset: 5560},
+							pos:  position{line: 239, col: 77, offset: 8082},
 							name: "EOS",
 						},
 					},
@@ -288,29 +493,29 @@ var g = &grammar{
 		},
 		{
 			name: "Namespace",
-			pos:  position{line: 181, col: 1, offset: 5837},
+			pos:  position{line: 252, col: 1, offset: 8408},
 			expr: &actionExpr{
-				pos: position{line: 181, col: 14, offset: 5850},
+				pos: position{line: 252, col: 14, offset: 8421},
 				run: (*parser).callonNamespace1,
 				expr: &seqExpr{
-					pos: position{line: 181, col: 14, offset: 5850},
+					pos: position{line: 252, col: 14, offset: 8421},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 181, col: 14, offset: 5850},
+							pos:        position{line: 252, col: 14, offset: 8421},
 							val:        "namespace",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 181, col: 26, offset: 5862},
+							pos:  position{line: 252, col: 26, offset: 8433},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 181, col: 28, offset: 5864},
+							pos:   position{line: 252, col: 28, offset: 8435},
 							label: "scope",
 							expr: &oneOrMoreExpr{
-								pos: position{line: 181, col: 34, offset: 5870},
+								pos: position{line: 252, col: 34, offset: 8441},
 								expr: &charClassMatcher{
-									pos:        position{line: 181, col: 34, offset: 5870},
+									pos:        position{line: 252, col: 34, offset: 8441},
 									val:        "[*a-z.-]",
 									chars:      []rune{'*', '.', '-'},
 									ranges:     []rune{'a', 'z'},
@@ -320,34 +525,42 @@ var g = &grammar{
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 181, col: 44, offset: 5880},
+							pos:  position{line: 252, col: 44, offset: 8451},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 181, col: 46, offset: 5882},
+							pos:   position{line: 252, col: 46, offset: 8453},
 							label: "ns",
 							expr: &ruleRefExpr{
-								pos:  position{line: 181, col: 49, offset: 5885},
+								pos:  position{line: 252, col: 49, offset: 8456},
 								name: "Identifier",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 181, col: 60, offset: 5896},
+							pos:  position{line: 252, col: 60, offset: 8467},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 181, col: 62, offset: 5898},
+							pos:   position{line: 252, col: 62, offset: 8469},
 							label: "annotations",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 181, col: 74, offset: 5910},
+								pos: position{line: 252, col: 74, offset: 8481},
 								expr: &ruleRefExpr{
-									pos:  position{line: 181, col: 74, offset: 5910},
+									pos:  position{line: 252, col: 74, offset: 8481},
 									name: "TypeAnnotations",
 								},
 							},
 						},
+						&labeledExpr{
+							pos:   position{line: 252, col: 91, offset: 8498},
+							label: "end",
+							expr: &ruleRefExpr{
+								pos:  position{line: 252, col: 95, offset: 8502},
+								name: "Mark",
+							},
+						},
 						&ruleRefExpr{
-							pos:  position{line: 181, col: 91, offset: 5927},
+							pos:  position{line: 252, col: 100, offset: 8507},
 							name: "EOS",
 						},
 					},
@@ -356,80 +569,88 @@ var g = &grammar{
 		},
 		{
 			name: "Const",
-			pos:  position{line: 189, col: 1, offset: 6113},
+			pos:  position{line: 261, col: 1, offset: 8742},
 			expr: &actionExpr{
-				pos: position{line: 189, col: 10, offset: 6122},
+				pos: position{line: 261, col: 10, offset: 8751},
 				run: (*parser).callonConst1,
 				expr: &seqExpr{
-					pos: position{line: 189, col: 10, offset: 6122},
+					pos: position{line: 261, col: 10, offset: 8751},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 189, col: 10, offset: 6122},
+							pos:        position{line: 261, col: 10, offset: 8751},
 							val:        "const",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 189, col: 18, offset: 6130},
+							pos:  position{line: 261, col: 18, offset: 8759},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 189, col: 20, offset: 6132},
+							pos:   position{line: 261, col: 20, offset: 8761},
 							label: "typ",
 							expr: &ruleRefExpr{
-								pos:  position{line: 189, col: 24, offset: 6136},
+								pos:  position{line: 261, col: 24, offset: 8765},
 								name: "FieldType",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 189, col: 34, offset: 6146},
+							pos:  position{line: 261, col: 34, offset: 8775},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 189, col: 36, offset: 6148},
+							pos:   position{line: 261, col: 36, offset: 8777},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 189, col: 41, offset: 6153},
+								pos:  position{line: 261, col: 41, offset: 8782},
 								name: "Identifier",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 189, col: 52, offset: 6164},
+							pos:  position{line: 261, col: 52, offset: 8793},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 189, col: 54, offset: 6166},
+							pos:        position{line: 261, col: 54, offset: 8795},
 							val:        "=",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 189, col: 58, offset: 6170},
+							pos:  position{line: 261, col: 58, offset: 8799},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 189, col: 60, offset: 6172},
+							pos:   position{line: 261, col: 60, offset: 8801},
 							label: "value",
 							expr: &ruleRefExpr{
-								pos:  position{line: 189, col: 66, offset: 6178},
+								pos:  position{line: 261, col: 66, offset: 8807},
 								name: "ConstValue",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 189, col: 77, offset: 6189},
+							pos:  position{line: 261, col: 77, offset: 8818},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 189, col: 79, offset: 6191},
+							pos:   position{line: 261, col: 79, offset: 8820},
 							label: "annotations",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 189, col: 91, offset: 6203},
+								pos: position{line: 261, col: 91, offset: 8832},
 								expr: &ruleRefExpr{
-									pos:  position{line: 189, col: 91, offset: 6203},
+									pos:  position{line: 261, col: 91, offset: 8832},
 									name: "TypeAnnotations",
 								},
 							},
 						},
+						&labeledExpr{
+							pos:   position{line: 261, col: 108, offset: 8849},
+							label: "end",
+							expr: &ruleRefExpr{
+								pos:  position{line: 261, col: 112, offset: 8853},
+								name: "Mark",
+							},
+						},
 						&ruleRefExpr{
-							pos:  position{line: 189, col: 108, offset: 6220},
+							pos:  position{line: 261, col: 117, offset: 8858},
 							name: "EOS",
 						},
 					},
@@ -438,114 +659,151 @@ var g = &grammar{
 		},
 		{
 			name: "Enum",
-			pos:  position{line: 198, col: 1, offset: 6414},
+			pos:  position{line: 271, col: 1, offset: 9108},
 			expr: &actionExpr{
-				pos: position{line: 198, col: 9, offset: 6422},
+				pos: position{line: 271, col: 9, offset: 9116},
 				run: (*parser).callonEnum1,
 				expr: &seqExpr{
-					pos: position{line: 198, col: 9, offset: 6422},
+					pos: position{line: 271, col: 9, offset: 9116},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 198, col: 9, offset: 6422},
+							pos:        position{line: 271, col: 9, offset: 9116},
 							val:        "enum",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 198, col: 16, offset: 6429},
+							pos:  position{line: 271, col: 16, offset: 9123},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 198, col: 18, offset: 6431},
+							pos:   position{line: 271, col: 18, offset: 9125},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 198, col: 23, offset: 6436},
+								pos:  position{line: 271, col: 23, offset: 9130},
 								name: "Identifier",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 198, col: 34, offset: 6447},
+							pos:  position{line: 271, col: 34, offset: 9141},
 							name: "__",
 						},
 						&litMatcher{
-							pos:        position{line: 198, col: 37, offset: 6450},
+							pos:        position{line: 271, col: 37, offset: 9144},
 							val:        "{",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 198, col: 41, offset: 6454},
+							pos:  position{line: 271, col: 41, offset: 9148},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 198, col: 44, offset: 6457},
+							pos:   position{line: 271, col: 44, offset: 9151},
 							label: "values",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 198, col: 51, offset: 6464},
+								pos: position{line: 271, col: 51, offset: 9158},
 								expr: &seqExpr{
-									pos: position{line: 198, col: 52, offset: 6465},
+									pos: position{line: 271, col: 52, offset: 9159},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 198, col: 52, offset: 6465},
+											pos:  position{line: 271, col: 52, offset: 9159},
 											name: "EnumValue",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 198, col: 62, offset: 6475},
+											pos:  position{line: 271, col: 62, offset: 9169},
 											name: "__",
 										},
 									},
 								},
 							},
 						},
-						&litMatcher{
-							pos:        position{line: 198, col: 67, offset: 6480},
-							val:        "}",
-							ignoreCase: false,
+						&choiceExpr{
+							pos: position{line: 271, col: 68, offset: 9175},
+							alternatives: []interface{}{
+								&litMatcher{
+									pos:        position{line: 271, col: 68, offset: 9175},
+									val:        "}",
+									ignoreCase: false,
+								},
+								&ruleRefExpr{
+									pos:  position{line: 271, col: 74, offset: 9181},
+									name: "EndOfEnumError",
+								},
+							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 198, col: 71, offset: 6484},
+							pos:  position{line: 271, col: 90, offset: 9197},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 198, col: 73, offset: 6486},
+							pos:   position{line: 271, col: 92, offset: 9199},
 							label: "annotations",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 198, col: 85, offset: 6498},
+								pos: position{line: 271, col: 104, offset: 9211},
 								expr: &ruleRefExpr{
-									pos:  position{line: 198, col: 85, offset: 6498},
+									pos:  position{line: 271, col: 104, offset: 9211},
 									name: "TypeAnnotations",
 								},
 							},
 						},
+						&labeledExpr{
+							pos:   position{line: 271, col: 121, offset: 9228},
+							label: "end",
+							expr: &ruleRefExpr{
+								pos:  position{line: 271, col: 125, offset: 9232},
+								name: "Mark",
+							},
+						},
 						&ruleRefExpr{
-							pos:  position{line: 198, col: 102, offset: 6515},
+							pos:  position{line: 271, col: 130, offset: 9237},
 							name: "EOS",
 						},
 					},
 				},
 			},
 		},
+		{
+			name: "EndOfEnumError",
+			pos:  position{line: 296, col: 1, offset: 9948},
+			expr: &actionExpr{
+				pos: position{line: 296, col: 19, offset: 9966},
+				run: (*parser).callonEndOfEnumError1,
+				expr: &choiceExpr{
+					pos: position{line: 296, col: 21, offset: 9968},
+					alternatives: []interface{}{
+						&anyMatcher{
+							line: 296, col: 21, offset: 9968,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 296, col: 25, offset: 9972},
+							name: "EOF",
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "EnumValue",
-			pos:  position{line: 222, col: 1, offset: 7177},
+			pos:  position{line: 300, col: 1, offset: 10045},
 			expr: &actionExpr{
-				pos: position{line: 222, col: 14, offset: 7190},
+				pos: position{line: 300, col: 14, offset: 10058},
 				run: (*parser).callonEnumValue1,
 				expr: &seqExpr{
-					pos: position{line: 222, col: 14, offset: 7190},
+					pos: position{line: 300, col: 14, offset: 10058},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 222, col: 14, offset: 7190},
+							pos:   position{line: 300, col: 14, offset: 10058},
 							label: "docstr",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 222, col: 21, offset: 7197},
+								pos: position{line: 300, col: 21, offset: 10065},
 								expr: &seqExpr{
-									pos: position{line: 222, col: 22, offset: 7198},
+									pos: position{line: 300, col: 22, offset: 10066},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 222, col: 22, offset: 7198},
+											pos:  position{line: 300, col: 22, offset: 10066},
 											name: "DocString",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 222, col: 32, offset: 7208},
+											pos:  position{line: 300, col: 32, offset: 10076},
 											name: "__",
 										},
 									},
@@ -553,36 +811,44 @@ var g = &grammar{
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 222, col: 37, offset: 7213},
+							pos:   position{line: 300, col: 37, offset: 10081},
+							label: "start",
+							expr: &ruleRefExpr{
+								pos:  position{line: 300, col: 43, offset: 10087},
+								name: "Mark",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 300, col: 48, offset: 10092},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 222, col: 42, offset: 7218},
+								pos:  position{line: 300, col: 53, offset: 10097},
 								name: "Identifier",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 222, col: 53, offset: 7229},
+							pos:  position{line: 300, col: 64, offset: 10108},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 222, col: 55, offset: 7231},
+							pos:   position{line: 300, col: 66, offset: 10110},
 							label: "value",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 222, col: 61, offset: 7237},
+								pos: position{line: 300, col: 72, offset: 10116},
 								expr: &seqExpr{
-									pos: position{line: 222, col: 62, offset: 7238},
+									pos: position{line: 300, col: 73, offset: 10117},
 									exprs: []interface{}{
 										&litMatcher{
-											pos:        position{line: 222, col: 62, offset: 7238},
+											pos:        position{line: 300, col: 73, offset: 10117},
 											val:        "=",
 											ignoreCase: false,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 222, col: 66, offset: 7242},
+											pos:  position{line: 300, col: 77, offset: 10121},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 222, col: 68, offset: 7244},
+											pos:  position{line: 300, col: 79, offset: 10123},
 											name: "IntConstant",
 										},
 									},
@@ -590,24 +856,24 @@ var g = &grammar{
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 222, col: 82, offset: 7258},
+							pos:  position{line: 300, col: 93, offset: 10137},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 222, col: 84, offset: 7260},
+							pos:   position{line: 300, col: 95, offset: 10139},
 							label: "annotations",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 222, col: 96, offset: 7272},
+								pos: position{line: 300, col: 107, offset: 10151},
 								expr: &ruleRefExpr{
-									pos:  position{line: 222, col: 96, offset: 7272},
+									pos:  position{line: 300, col: 107, offset: 10151},
 									name: "TypeAnnotations",
 								},
 							},
 						},
 						&zeroOrOneExpr{
-							pos: position{line: 222, col: 113, offset: 7289},
+							pos: position{line: 300, col: 124, offset: 10168},
 							expr: &ruleRefExpr{
-								pos:  position{line: 222, col: 113, offset: 7289},
+								pos:  position{line: 300, col: 124, offset: 10168},
 								name: "ListSeparator",
 							},
 						},
@@ -617,59 +883,67 @@ var g = &grammar{
 		},
 		{
 			name: "TypeDef",
-			pos:  position{line: 238, col: 1, offset: 7687},
+			pos:  position{line: 319, col: 1, offset: 10657},
 			expr: &actionExpr{
-				pos: position{line: 238, col: 12, offset: 7698},
+				pos: position{line: 319, col: 12, offset: 10668},
 				run: (*parser).callonTypeDef1,
 				expr: &seqExpr{
-					pos: position{line: 238, col: 12, offset: 7698},
+					pos: position{line: 319, col: 12, offset: 10668},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 238, col: 12, offset: 7698},
+							pos:        position{line: 319, col: 12, offset: 10668},
 							val:        "typedef",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 238, col: 22, offset: 7708},
+							pos:  position{line: 319, col: 22, offset: 10678},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 238, col: 24, offset: 7710},
+							pos:   position{line: 319, col: 24, offset: 10680},
 							label: "typ",
 							expr: &ruleRefExpr{
-								pos:  position{line: 238, col: 28, offset: 7714},
+								pos:  position{line: 319, col: 28, offset: 10684},
 								name: "FieldType",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 238, col: 38, offset: 7724},
+							pos:  position{line: 319, col: 38, offset: 10694},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 238, col: 40, offset: 7726},
+							pos:   position{line: 319, col: 40, offset: 10696},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 238, col: 45, offset: 7731},
+								pos:  position{line: 319, col: 45, offset: 10701},
 								name: "Identifier",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 238, col: 56, offset: 7742},
+							pos:  position{line: 319, col: 56, offset: 10712},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 238, col: 58, offset: 7744},
+							pos:   position{line: 319, col: 58, offset: 10714},
 							label: "annotations",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 238, col: 70, offset: 7756},
+								pos: position{line: 319, col: 70, offset: 10726},
 								expr: &ruleRefExpr{
-									pos:  position{line: 238, col: 70, offset: 7756},
+									pos:  position{line: 319, col: 70, offset: 10726},
 									name: "TypeAnnotations",
 								},
 							},
 						},
+						&labeledExpr{
+							pos:   position{line: 319, col: 87, offset: 10743},
+							label: "end",
+							expr: &ruleRefExpr{
+								pos:  position{line: 319, col: 91, offset: 10747},
+								name: "Mark",
+							},
+						},
 						&ruleRefExpr{
-							pos:  position{line: 238, col: 87, offset: 7773},
+							pos:  position{line: 319, col: 96, offset: 10752},
 							name: "EOS",
 						},
 					},
@@ -678,27 +952,27 @@ var g = &grammar{
 		},
 		{
 			name: "Struct",
-			pos:  position{line: 246, col: 1, offset: 7945},
+			pos:  position{line: 328, col: 1, offset: 10973},
 			expr: &actionExpr{
-				pos: position{line: 246, col: 11, offset: 7955},
+				pos: position{line: 328, col: 11, offset: 10983},
 				run: (*parser).callonStruct1,
 				expr: &seqExpr{
-					pos: position{line: 246, col: 11, offset: 7955},
+					pos: position{line: 328, col: 11, offset: 10983},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 246, col: 11, offset: 7955},
+							pos:        position{line: 328, col: 11, offset: 10983},
 							val:        "struct",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 246, col: 20, offset: 7964},
+							pos:  position{line: 328, col: 20, offset: 10992},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 246, col: 22, offset: 7966},
+							pos:   position{line: 328, col: 22, offset: 10994},
 							label: "st",
 							expr: &ruleRefExpr{
-								pos:  position{line: 246, col: 25, offset: 7969},
+								pos:  position{line: 328, col: 25, offset: 10997},
 								name: "StructLike",
 							},
 						},
@@ -708,27 +982,27 @@ var g = &grammar{
 		},
 		{
 			name: "Exception",
-			pos:  position{line: 247, col: 1, offset: 8009},
+			pos:  position{line: 333, col: 1, offset: 11079},
 			expr: &actionExpr{
-				pos: position{line: 247, col: 14, offset: 8022},
+				pos: position{line: 333, col: 14, offset: 11092},
 				run: (*parser).callonException1,
 				expr: &seqExpr{
-					pos: position{line: 247, col: 14, offset: 8022},
+					pos: position{line: 333, col: 14, offset: 11092},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 247, col: 14, offset: 8022},
+							pos:        position{line: 333, col: 14, offset: 11092},
 							val:        "exception",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 247, col: 26, offset: 8034},
+							pos:  position{line: 333, col: 26, offset: 11104},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 247, col: 28, offset: 8036},
+							pos:   position{line: 333, col: 28, offset: 11106},
 							label: "st",
 							expr: &ruleRefExpr{
-								pos:  position{line: 247, col: 31, offset: 8039},
+								pos:  position{line: 333, col: 31, offset: 11109},
 								name: "StructLike",
 							},
 						},
@@ -738,27 +1012,27 @@ var g = &grammar{
 		},
 		{
 			name: "Union",
-			pos:  position{line: 248, col: 1, offset: 8090},
+			pos:  position{line: 338, col: 1, offset: 11202},
 			expr: &actionExpr{
-				pos: position{line: 248, col: 10, offset: 8099},
+				pos: position{line: 338, col: 10, offset: 11211},
 				run: (*parser).callonUnion1,
 				expr: &seqExpr{
-					pos: position{line: 248, col: 10, offset: 8099},
+					pos: position{line: 338, col: 10, offset: 11211},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 248, col: 10, offset: 8099},
+							pos:        position{line: 338, col: 10, offset: 11211},
 							val:        "union",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 248, col: 18, offset: 8107},
+							pos:  position{line: 338, col: 18, offset: 11219},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 248, col: 20, offset: 8109},
+							pos:   position{line: 338, col: 20, offset: 11221},
 							label: "st",
 							expr: &ruleRefExpr{
-								pos:  position{line: 248, col: 23, offset: 8112},
+								pos:  position{line: 338, col: 23, offset: 11224},
 								name: "StructLike",
 							},
 						},
@@ -768,90 +1042,127 @@ var g = &grammar{
 		},
 		{
 			name: "StructLike",
-			pos:  position{line: 249, col: 1, offset: 8159},
+			pos:  position{line: 343, col: 1, offset: 11313},
 			expr: &actionExpr{
-				pos: position{line: 249, col: 15, offset: 8173},
+				pos: position{line: 343, col: 15, offset: 11327},
 				run: (*parser).callonStructLike1,
 				expr: &seqExpr{
-					pos: position{line: 249, col: 15, offset: 8173},
+					pos: position{line: 343, col: 15, offset: 11327},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 249, col: 15, offset: 8173},
+							pos:   position{line: 343, col: 15, offset: 11327},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 249, col: 20, offset: 8178},
+								pos:  position{line: 343, col: 20, offset: 11332},
 								name: "Identifier",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 249, col: 31, offset: 8189},
+							pos:  position{line: 343, col: 31, offset: 11343},
 							name: "__",
 						},
 						&litMatcher{
-							pos:        position{line: 249, col: 34, offset: 8192},
+							pos:        position{line: 343, col: 34, offset: 11346},
 							val:        "{",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 249, col: 38, offset: 8196},
+							pos:  position{line: 343, col: 38, offset: 11350},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 249, col: 41, offset: 8199},
+							pos:   position{line: 343, col: 41, offset: 11353},
 							label: "fields",
 							expr: &ruleRefExpr{
-								pos:  position{line: 249, col: 48, offset: 8206},
+								pos:  position{line: 343, col: 48, offset: 11360},
 								name: "FieldList",
 							},
 						},
-						&litMatcher{
-							pos:        position{line: 249, col: 58, offset: 8216},
-							val:        "}",
-							ignoreCase: false,
+						&choiceExpr{
+							pos: position{line: 343, col: 59, offset: 11371},
+							alternatives: []interface{}{
+								&litMatcher{
+									pos:        position{line: 343, col: 59, offset: 11371},
+									val:        "}",
+									ignoreCase: false,
+								},
+								&ruleRefExpr{
+									pos:  position{line: 343, col: 65, offset: 11377},
+									name: "EndOfStructError",
+								},
+							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 249, col: 62, offset: 8220},
+							pos:  position{line: 343, col: 83, offset: 11395},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 249, col: 64, offset: 8222},
+							pos:   position{line: 343, col: 85, offset: 11397},
 							label: "annotations",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 249, col: 76, offset: 8234},
+								pos: position{line: 343, col: 97, offset: 11409},
 								expr: &ruleRefExpr{
-									pos:  position{line: 249, col: 76, offset: 8234},
+									pos:  position{line: 343, col: 97, offset: 11409},
 									name: "TypeAnnotations",
 								},
 							},
 						},
+						&labeledExpr{
+							pos:   position{line: 343, col: 114, offset: 11426},
+							label: "end",
+							expr: &ruleRefExpr{
+								pos:  position{line: 343, col: 118, offset: 11430},
+								name: "Mark",
+							},
+						},
 						&ruleRefExpr{
-							pos:  position{line: 249, col: 93, offset: 8251},
+							pos:  position{line: 343, col: 123, offset: 11435},
 							name: "EOS",
 						},
 					},
 				},
 			},
 		},
+		{
+			name: "EndOfStructError",
+			pos:  position{line: 355, col: 1, offset: 11701},
+			expr: &actionExpr{
+				pos: position{line: 355, col: 21, offset: 11721},
+				run: (*parser).callonEndOfStructError1,
+				expr: &choiceExpr{
+					pos: position{line: 355, col: 23, offset: 11723},
+					alternatives: []interface{}{
+						&anyMatcher{
+							line: 355, col: 23, offset: 11723,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 355, col: 27, offset: 11727},
+							name: "EOF",
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "FieldList",
-			pos:  position{line: 260, col: 1, offset: 8468},
+			pos:  position{line: 359, col: 1, offset: 11802},
 			expr: &actionExpr{
-				pos: position{line: 260, col: 14, offset: 8481},
+				pos: position{line: 359, col: 14, offset: 11815},
 				run: (*parser).callonFieldList1,
 				expr: &labeledExpr{
-					pos:   position{line: 260, col: 14, offset: 8481},
+					pos:   position{line: 359, col: 14, offset: 11815},
 					label: "fields",
 					expr: &zeroOrMoreExpr{
-						pos: position{line: 260, col: 21, offset: 8488},
+						pos: position{line: 359, col: 21, offset: 11822},
 						expr: &seqExpr{
-							pos: position{line: 260, col: 22, offset: 8489},
+							pos: position{line: 359, col: 22, offset: 11823},
 							exprs: []interface{}{
 								&ruleRefExpr{
-									pos:  position{line: 260, col: 22, offset: 8489},
+									pos:  position{line: 359, col: 22, offset: 11823},
 									name: "Field",
 								},
 								&ruleRefExpr{
-									pos:  position{line: 260, col: 28, offset: 8495},
+									pos:  position{line: 359, col: 28, offset: 11829},
 									name: "__",
 								},
 							},
@@ -862,27 +1173,27 @@ var g = &grammar{
 		},
 		{
 			name: "Field",
-			pos:  position{line: 269, col: 1, offset: 8676},
+			pos:  position{line: 368, col: 1, offset: 12010},
 			expr: &actionExpr{
-				pos: position{line: 269, col: 10, offset: 8685},
+				pos: position{line: 368, col: 10, offset: 12019},
 				run: (*parser).callonField1,
 				expr: &seqExpr{
-					pos: position{line: 269, col: 10, offset: 8685},
+					pos: position{line: 368, col: 10, offset: 12019},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 269, col: 10, offset: 8685},
+							pos:   position{line: 368, col: 10, offset: 12019},
 							label: "docstr",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 269, col: 17, offset: 8692},
+								pos: position{line: 368, col: 17, offset: 12026},
 								expr: &seqExpr{
-									pos: position{line: 269, col: 18, offset: 8693},
+									pos: position{line: 368, col: 18, offset: 12027},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 269, col: 18, offset: 8693},
+											pos:  position{line: 368, col: 18, offset: 12027},
 											name: "DocString",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 269, col: 28, offset: 8703},
+											pos:  position{line: 368, col: 28, offset: 12037},
 											name: "__",
 										},
 									},
@@ -890,84 +1201,92 @@ var g = &grammar{
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 269, col: 33, offset: 8708},
+							pos:   position{line: 368, col: 33, offset: 12042},
+							label: "start",
+							expr: &ruleRefExpr{
+								pos:  position{line: 368, col: 39, offset: 12048},
+								name: "Mark",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 368, col: 44, offset: 12053},
 							label: "id",
 							expr: &ruleRefExpr{
-								pos:  position{line: 269, col: 36, offset: 8711},
+								pos:  position{line: 368, col: 47, offset: 12056},
 								name: "IntConstant",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 269, col: 48, offset: 8723},
+							pos:  position{line: 368, col: 59, offset: 12068},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 269, col: 50, offset: 8725},
+							pos:        position{line: 368, col: 61, offset: 12070},
 							val:        ":",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 269, col: 54, offset: 8729},
+							pos:  position{line: 368, col: 65, offset: 12074},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 269, col: 56, offset: 8731},
+							pos:   position{line: 368, col: 67, offset: 12076},
 							label: "mod",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 269, col: 60, offset: 8735},
+								pos: position{line: 368, col: 71, offset: 12080},
 								expr: &ruleRefExpr{
-									pos:  position{line: 269, col: 60, offset: 8735},
+									pos:  position{line: 368, col: 71, offset: 12080},
 									name: "FieldModifier",
 								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 269, col: 75, offset: 8750},
+							pos:  position{line: 368, col: 86, offset: 12095},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 269, col: 77, offset: 8752},
+							pos:   position{line: 368, col: 88, offset: 12097},
 							label: "typ",
 							expr: &ruleRefExpr{
-								pos:  position{line: 269, col: 81, offset: 8756},
+								pos:  position{line: 368, col: 92, offset: 12101},
 								name: "FieldType",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 269, col: 91, offset: 8766},
+							pos:  position{line: 368, col: 102, offset: 12111},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 269, col: 93, offset: 8768},
+							pos:   position{line: 368, col: 104, offset: 12113},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 269, col: 98, offset: 8773},
+								pos:  position{line: 368, col: 109, offset: 12118},
 								name: "Identifier",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 269, col: 109, offset: 8784},
+							pos:  position{line: 368, col: 120, offset: 12129},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 269, col: 112, offset: 8787},
+							pos:   position{line: 368, col: 123, offset: 12132},
 							label: "def",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 269, col: 116, offset: 8791},
+								pos: position{line: 368, col: 127, offset: 12136},
 								expr: &seqExpr{
-									pos: position{line: 269, col: 117, offset: 8792},
+									pos: position{line: 368, col: 128, offset: 12137},
 									exprs: []interface{}{
 										&litMatcher{
-											pos:        position{line: 269, col: 117, offset: 8792},
+											pos:        position{line: 368, col: 128, offset: 12137},
 											val:        "=",
 											ignoreCase: false,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 269, col: 121, offset: 8796},
+											pos:  position{line: 368, col: 132, offset: 12141},
 											name: "_",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 269, col: 123, offset: 8798},
+											pos:  position{line: 368, col: 134, offset: 12143},
 											name: "ConstValue",
 										},
 									},
@@ -975,24 +1294,24 @@ var g = &grammar{
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 269, col: 136, offset: 8811},
+							pos:  position{line: 368, col: 147, offset: 12156},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 269, col: 138, offset: 8813},
+							pos:   position{line: 368, col: 149, offset: 12158},
 							label: "annotations",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 269, col: 150, offset: 8825},
+								pos: position{line: 368, col: 161, offset: 12170},
 								expr: &ruleRefExpr{
-									pos:  position{line: 269, col: 150, offset: 8825},
+									pos:  position{line: 368, col: 161, offset: 12170},
 									name: "TypeAnnotations",
 								},
 							},
 						},
 						&zeroOrOneExpr{
-							pos: position{line: 269, col: 167, offset: 8842},
+							pos: position{line: 368, col: 178, offset: 12187},
 							expr: &ruleRefExpr{
-								pos:  position{line: 269, col: 167, offset: 8842},
+								pos:  position{line: 368, col: 178, offset: 12187},
 								name: "ListSeparator",
 							},
 						},
@@ -1002,20 +1321,20 @@ var g = &grammar{
 		},
 		{
 			name: "FieldModifier",
-			pos:  position{line: 292, col: 1, offset: 9374},
+			pos:  position{line: 392, col: 1, offset: 12770},
 			expr: &actionExpr{
-				pos: position{line: 292, col: 18, offset: 9391},
+				pos: position{line: 392, col: 18, offset: 12787},
 				run: (*parser).callonFieldModifier1,
 				expr: &choiceExpr{
-					pos: position{line: 292, col: 19, offset: 9392},
+					pos: position{line: 392, col: 19, offset: 12788},
 					alternatives: []interface{}{
 						&litMatcher{
-							pos:        position{line: 292, col: 19, offset: 9392},
+							pos:        position{line: 392, col: 19, offset: 12788},
 							val:        "required",
 							ignoreCase: false,
 						},
 						&litMatcher{
-							pos:        position{line: 292, col: 32, offset: 9405},
+							pos:        position{line: 392, col: 32, offset: 12801},
 							val:        "optional",
 							ignoreCase: false,
 						},
@@ -1025,57 +1344,57 @@ var g = &grammar{
 		},
 		{
 			name: "Service",
-			pos:  position{line: 300, col: 1, offset: 9548},
+			pos:  position{line: 400, col: 1, offset: 12944},
 			expr: &actionExpr{
-				pos: position{line: 300, col: 12, offset: 9559},
+				pos: position{line: 400, col: 12, offset: 12955},
 				run: (*parser).callonService1,
 				expr: &seqExpr{
-					pos: position{line: 300, col: 12, offset: 9559},
+					pos: position{line: 400, col: 12, offset: 12955},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 300, col: 12, offset: 9559},
+							pos:        position{line: 400, col: 12, offset: 12955},
 							val:        "service",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 300, col: 22, offset: 9569},
+							pos:  position{line: 400, col: 22, offset: 12965},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 300, col: 24, offset: 9571},
+							pos:   position{line: 400, col: 24, offset: 12967},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 300, col: 29, offset: 9576},
+								pos:  position{line: 400, col: 29, offset: 12972},
 								name: "Identifier",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 300, col: 40, offset: 9587},
+							pos:  position{line: 400, col: 40, offset: 12983},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 300, col: 42, offset: 9589},
+							pos:   position{line: 400, col: 42, offset: 12985},
 							label: "extends",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 300, col: 50, offset: 9597},
+								pos: position{line: 400, col: 50, offset: 12993},
 								expr: &seqExpr{
-									pos: position{line: 300, col: 51, offset: 9598},
+									pos: position{line: 400, col: 51, offset: 12994},
 									exprs: []interface{}{
 										&litMatcher{
-											pos:        position{line: 300, col: 51, offset: 9598},
+											pos:        position{line: 400, col: 51, offset: 12994},
 											val:        "extends",
 											ignoreCase: false,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 300, col: 61, offset: 9608},
+											pos:  position{line: 400, col: 61, offset: 13004},
 											name: "__",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 300, col: 64, offset: 9611},
+											pos:  position{line: 400, col: 64, offset: 13007},
 											name: "Identifier",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 300, col: 75, offset: 9622},
+											pos:  position{line: 400, col: 75, offset: 13018},
 											name: "__",
 										},
 									},
@@ -1083,32 +1402,32 @@ var g = &grammar{
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 300, col: 80, offset: 9627},
+							pos:  position{line: 400, col: 80, offset: 13023},
 							name: "__",
 						},
 						&litMatcher{
-							pos:        position{line: 300, col: 83, offset: 9630},
+							pos:        position{line: 400, col: 83, offset: 13026},
 							val:        "{",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 300, col: 87, offset: 9634},
+							pos:  position{line: 400, col: 87, offset: 13030},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 300, col: 90, offset: 9637},
+							pos:   position{line: 400, col: 90, offset: 13033},
 							label: "methods",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 300, col: 98, offset: 9645},
+								pos: position{line: 400, col: 98, offset: 13041},
 								expr: &seqExpr{
-									pos: position{line: 300, col: 99, offset: 9646},
+									pos: position{line: 400, col: 99, offset: 13042},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 300, col: 99, offset: 9646},
+											pos:  position{line: 400, col: 99, offset: 13042},
 											name: "Function",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 300, col: 108, offset: 9655},
+											pos:  position{line: 400, col: 108, offset: 13051},
 											name: "__",
 										},
 									},
@@ -1116,36 +1435,44 @@ var g = &grammar{
 							},
 						},
 						&choiceExpr{
-							pos: position{line: 300, col: 114, offset: 9661},
+							pos: position{line: 400, col: 114, offset: 13057},
 							alternatives: []interface{}{
 								&litMatcher{
-									pos:        position{line: 300, col: 114, offset: 9661},
+									pos:        position{line: 400, col: 114, offset: 13057},
 									val:        "}",
 									ignoreCase: false,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 300, col: 120, offset: 9667},
+									pos:  position{line: 400, col: 120, offset: 13063},
 									name: "EndOfServiceError",
 								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 300, col: 139, offset: 9686},
+							pos:  position{line: 400, col: 139, offset: 13082},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 300, col: 141, offset: 9688},
+							pos:   position{line: 400, col: 141, offset: 13084},
 							label: "annotations",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 300, col: 153, offset: 9700},
+								pos: position{line: 400, col: 153, offset: 13096},
 								expr: &ruleRefExpr{
-									pos:  position{line: 300, col: 153, offset: 9700},
+									pos:  position{line: 400, col: 153, offset: 13096},
 									name: "TypeAnnotations",
 								},
 							},
 						},
+						&labeledExpr{
+							pos:   position{line: 400, col: 170, offset: 13113},
+							label: "end",
+							expr: &ruleRefExpr{
+								pos:  position{line: 400, col: 174, offset: 13117},
+								name: "Mark",
+							},
+						},
 						&ruleRefExpr{
-							pos:  position{line: 300, col: 170, offset: 9717},
+							pos:  position{line: 400, col: 179, offset: 13122},
 							name: "EOS",
 						},
 					},
@@ -1154,38 +1481,47 @@ var g = &grammar{
 		},
 		{
 			name: "EndOfServiceError",
-			pos:  position{line: 317, col: 1, offset: 10158},
+			pos:  position{line: 418, col: 1, offset: 13612},
 			expr: &actionExpr{
-				pos: position{line: 317, col: 22, offset: 10179},
+				pos: position{line: 418, col: 22, offset: 13633},
 				run: (*parser).callonEndOfServiceError1,
-				expr: &anyMatcher{
-					line: 317, col: 22, offset: 10179,
+				expr: &choiceExpr{
+					pos: position{line: 418, col: 24, offset: 13635},
+					alternatives: []interface{}{
+						&anyMatcher{
+							line: 418, col: 24, offset: 13635,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 418, col: 28, offset: 13639},
+							name: "EOF",
+						},
+					},
 				},
 			},
 		},
 		{
 			name: "Function",
-			pos:  position{line: 321, col: 1, offset: 10248},
+			pos:  position{line: 422, col: 1, offset: 13715},
 			expr: &actionExpr{
-				pos: position{line: 321, col: 13, offset: 10260},
+				pos: position{line: 422, col: 13, offset: 13727},
 				run: (*parser).callonFunction1,
 				expr: &seqExpr{
-					pos: position{line: 321, col: 13, offset: 10260},
+					pos: position{line: 422, col: 13, offset: 13727},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 321, col: 13, offset: 10260},
+							pos:   position{line: 422, col: 13, offset: 13727},
 							label: "docstr",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 321, col: 20, offset: 10267},
+								pos: position{line: 422, col: 20, offset: 13734},
 								expr: &seqExpr{
-									pos: position{line: 321, col: 21, offset: 10268},
+									pos: position{line: 422, col: 21, offset: 13735},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 321, col: 21, offset: 10268},
+											pos:  position{line: 422, col: 21, offset: 13735},
 											name: "DocString",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 321, col: 31, offset: 10278},
+											pos:  position{line: 422, col: 31, offset: 13745},
 											name: "__",
 										},
 									},
@@ -1193,20 +1529,28 @@ var g = &grammar{
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 321, col: 36, offset: 10283},
+							pos:   position{line: 422, col: 36, offset: 13750},
+							label: "start",
+							expr: &ruleRefExpr{
+								pos:  position{line: 422, col: 42, offset: 13756},
+								name: "Mark",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 422, col: 47, offset: 13761},
 							label: "oneway",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 321, col: 43, offset: 10290},
+								pos: position{line: 422, col: 54, offset: 13768},
 								expr: &seqExpr{
-									pos: position{line: 321, col: 44, offset: 10291},
+									pos: position{line: 422, col: 55, offset: 13769},
 									exprs: []interface{}{
 										&litMatcher{
-											pos:        position{line: 321, col: 44, offset: 10291},
+											pos:        position{line: 422, col: 55, offset: 13769},
 											val:        "oneway",
 											ignoreCase: false,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 321, col: 53, offset: 10300},
+											pos:  position{line: 422, col: 64, offset: 13778},
 											name: "__",
 										},
 									},
@@ -1214,85 +1558,96 @@ var g = &grammar{
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 321, col: 58, offset: 10305},
+							pos:   position{line: 422, col: 69, offset: 13783},
 							label: "typ",
 							expr: &ruleRefExpr{
-								pos:  position{line: 321, col: 62, offset: 10309},
+								pos:  position{line: 422, col: 73, offset: 13787},
 								name: "FunctionType",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 321, col: 75, offset: 10322},
+							pos:  position{line: 422, col: 86, offset: 13800},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 321, col: 78, offset: 10325},
+							pos:   position{line: 422, col: 89, offset: 13803},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 321, col: 83, offset: 10330},
+								pos:  position{line: 422, col: 94, offset: 13808},
 								name: "Identifier",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 321, col: 94, offset: 10341},
+							pos:  position{line: 422, col: 105, offset: 13819},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 321, col: 96, offset: 10343},
+							pos:        position{line: 422, col: 107, offset: 13821},
 							val:        "(",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 321, col: 100, offset: 10347},
+							pos:  position{line: 422, col: 111, offset: 13825},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 321, col: 103, offset: 10350},
+							pos:   position{line: 422, col: 114, offset: 13828},
 							label: "arguments",
 							expr: &ruleRefExpr{
-								pos:  position{line: 321, col: 113, offset: 10360},
+								pos:  position{line: 422, col: 124, offset: 13838},
 								name: "FieldList",
 							},
 						},
+						&labeledExpr{
+							pos:   position{line: 422, col: 134, offset: 13848},
+							label: "requestStream",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 422, col: 148, offset: 13862},
+								expr: &ruleRefExpr{
+									pos:  position{line: 422, col: 148, offset: 13862},
+									name: "RequestStreamField",
+								},
+							},
+						},
 						&litMatcher{
-							pos:        position{line: 321, col: 123, offset: 10370},
+							pos:        position{line: 422, col: 168, offset: 13882},
 							val:        ")",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 321, col: 127, offset: 10374},
+							pos:  position{line: 422, col: 172, offset: 13886},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 321, col: 130, offset: 10377},
+							pos:   position{line: 422, col: 175, offset: 13889},
 							label: "exceptions",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 321, col: 141, offset: 10388},
+								pos: position{line: 422, col: 186, offset: 13900},
 								expr: &ruleRefExpr{
-									pos:  position{line: 321, col: 141, offset: 10388},
+									pos:  position{line: 422, col: 186, offset: 13900},
 									name: "Throws",
 								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 321, col: 149, offset: 10396},
+							pos:  position{line: 422, col: 194, offset: 13908},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 321, col: 151, offset: 10398},
+							pos:   position{line: 422, col: 196, offset: 13910},
 							label: "annotations",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 321, col: 163, offset: 10410},
+								pos: position{line: 422, col: 208, offset: 13922},
 								expr: &ruleRefExpr{
-									pos:  position{line: 321, col: 163, offset: 10410},
+									pos:  position{line: 422, col: 208, offset: 13922},
 									name: "TypeAnnotations",
 								},
 							},
 						},
 						&zeroOrOneExpr{
-							pos: position{line: 321, col: 180, offset: 10427},
+							pos: position{line: 422, col: 225, offset: 13939},
 							expr: &ruleRefExpr{
-								pos:  position{line: 321, col: 180, offset: 10427},
+								pos:  position{line: 422, col: 225, offset: 13939},
 								name: "ListSeparator",
 							},
 						},
@@ -1302,23 +1657,27 @@ var g = &grammar{
 		},
 		{
 			name: "FunctionType",
-			pos:  position{line: 349, col: 1, offset: 11078},
+			pos:  position{line: 459, col: 1, offset: 14858},
 			expr: &actionExpr{
-				pos: position{line: 349, col: 17, offset: 11094},
+				pos: position{line: 459, col: 17, offset: 14874},
 				run: (*parser).callonFunctionType1,
 				expr: &labeledExpr{
-					pos:   position{line: 349, col: 17, offset: 11094},
+					pos:   position{line: 459, col: 17, offset: 14874},
 					label: "typ",
 					expr: &choiceExpr{
-						pos: position{line: 349, col: 22, offset: 11099},
+						pos: position{line: 459, col: 22, offset: 14879},
 						alternatives: []interface{}{
 							&litMatcher{
-								pos:        position{line: 349, col: 22, offset: 11099},
+								pos:        position{line: 459, col: 22, offset: 14879},
 								val:        "void",
 								ignoreCase: false,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 349, col: 31, offset: 11108},
+								pos:  position{line: 459, col: 31, offset: 14888},
+								name: "StreamType",
+							},
+							&ruleRefExpr{
+								pos:  position{line: 459, col: 44, offset: 14901},
 								name: "FieldType",
 							},
 						},
@@ -1326,43 +1685,208 @@ var g = &grammar{
 				},
 			},
 		},
+		{
+			name: "StreamType",
+			pos:  position{line: 469, col: 1, offset: 15095},
+			expr: &actionExpr{
+				pos: position{line: 469, col: 15, offset: 15109},
+				run: (*parser).callonStreamType1,
+				expr: &seqExpr{
+					pos: position{line: 469, col: 15, offset: 15109},
+					exprs: []interface{}{
+						&litMatcher{
+							pos:        position{line: 469, col: 15, offset: 15109},
+							val:        "stream<",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 469, col: 25, offset: 15119},
+							name: "WS",
+						},
+						&labeledExpr{
+							pos:   position{line: 469, col: 28, offset: 15122},
+							label: "typ",
+							expr: &ruleRefExpr{
+								pos:  position{line: 469, col: 32, offset: 15126},
+								name: "FieldType",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 469, col: 42, offset: 15136},
+							name: "WS",
+						},
+						&litMatcher{
+							pos:        position{line: 469, col: 45, offset: 15139},
+							val:        ">",
+							ignoreCase: false,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "RequestStreamField",
+			pos:  position{line: 473, col: 1, offset: 15188},
+			expr: &actionExpr{
+				pos: position{line: 473, col: 23, offset: 15210},
+				run: (*parser).callonRequestStreamField1,
+				expr: &seqExpr{
+					pos: position{line: 473, col: 23, offset: 15210},
+					exprs: []interface{}{
+						&labeledExpr{
+							pos:   position{line: 473, col: 23, offset: 15210},
+							label: "docstr",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 473, col: 30, offset: 15217},
+								expr: &seqExpr{
+									pos: position{line: 473, col: 31, offset: 15218},
+									exprs: []interface{}{
+										&ruleRefExpr{
+											pos:  position{line: 473, col: 31, offset: 15218},
+											name: "DocString",
+										},
+										&ruleRefExpr{
+											pos:  position{line: 473, col: 41, offset: 15228},
+											name: "__",
+										},
+									},
+								},
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 473, col: 46, offset: 15233},
+							label: "start",
+							expr: &ruleRefExpr{
+								pos:  position{line: 473, col: 52, offset: 15239},
+								name: "Mark",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 473, col: 57, offset: 15244},
+							label: "id",
+							expr: &ruleRefExpr{
+								pos:  position{line: 473, col: 60, offset: 15247},
+								name: "IntConstant",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 473, col: 72, offset: 15259},
+							name: "_",
+						},
+						&litMatcher{
+							pos:        position{line: 473, col: 74, offset: 15261},
+							val:        ":",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 473, col: 78, offset: 15265},
+							name: "_",
+						},
+						&litMatcher{
+							pos:        position{line: 473, col: 80, offset: 15267},
+							val:        "stream<",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 473, col: 90, offset: 15277},
+							name: "WS",
+						},
+						&labeledExpr{
+							pos:   position{line: 473, col: 93, offset: 15280},
+							label: "typ",
+							expr: &ruleRefExpr{
+								pos:  position{line: 473, col: 97, offset: 15284},
+								name: "FieldType",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 473, col: 107, offset: 15294},
+							name: "WS",
+						},
+						&litMatcher{
+							pos:        position{line: 473, col: 110, offset: 15297},
+							val:        ">",
+							ignoreCase: false,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 473, col: 114, offset: 15301},
+							name: "_",
+						},
+						&labeledExpr{
+							pos:   position{line: 473, col: 116, offset: 15303},
+							label: "name",
+							expr: &ruleRefExpr{
+								pos:  position{line: 473, col: 121, offset: 15308},
+								name: "Identifier",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 473, col: 132, offset: 15319},
+							name: "__",
+						},
+						&labeledExpr{
+							pos:   position{line: 473, col: 135, offset: 15322},
+							label: "annotations",
+							expr: &zeroOrOneExpr{
+								pos: position{line: 473, col: 147, offset: 15334},
+								expr: &ruleRefExpr{
+									pos:  position{line: 473, col: 147, offset: 15334},
+									name: "TypeAnnotations",
+								},
+							},
+						},
+						&zeroOrOneExpr{
+							pos: position{line: 473, col: 164, offset: 15351},
+							expr: &ruleRefExpr{
+								pos:  position{line: 473, col: 164, offset: 15351},
+								name: "ListSeparator",
+							},
+						},
+						&ruleRefExpr{
+							pos:  position{line: 473, col: 179, offset: 15366},
+							name: "__",
+						},
+					},
+				},
+			},
+		},
 		{
 			name: "Throws",
-			pos:  position{line: 356, col: 1, offset: 11230},
+			pos:  position{line: 489, col: 1, offset: 15789},
 			expr: &actionExpr{
-				pos: position{line: 356, col: 11, offset: 11240},
+				pos: position{line: 489, col: 11, offset: 15799},
 				run: (*parser).callonThrows1,
 				expr: &seqExpr{
-					pos: position{line: 356, col: 11, offset: 11240},
+					pos: position{line: 489, col: 11, offset: 15799},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 356, col: 11, offset: 11240},
+							pos:        position{line: 489, col: 11, offset: 15799},
 							val:        "throws",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 356, col: 20, offset: 11249},
+							pos:  position{line: 489, col: 20, offset: 15808},
 							name: "__",
 						},
 						&litMatcher{
-							pos:        position{line: 356, col: 23, offset: 11252},
+							pos:        position{line: 489, col: 23, offset: 15811},
 							val:        "(",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 356, col: 27, offset: 11256},
+							pos:  position{line: 489, col: 27, offset: 15815},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 356, col: 30, offset: 11259},
+							pos:   position{line: 489, col: 30, offset: 15818},
 							label: "exceptions",
 							expr: &ruleRefExpr{
-								pos:  position{line: 356, col: 41, offset: 11270},
+								pos:  position{line: 489, col: 41, offset: 15829},
 								name: "FieldList",
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 356, col: 51, offset: 11280},
+							pos:        position{line: 489, col: 51, offset: 15839},
 							val:        ")",
 							ignoreCase: false,
 						},
@@ -1372,26 +1896,26 @@ var g = &grammar{
 		},
 		{
 			name: "FieldType",
-			pos:  position{line: 360, col: 1, offset: 11316},
+			pos:  position{line: 493, col: 1, offset: 15875},
 			expr: &actionExpr{
-				pos: position{line: 360, col: 14, offset: 11329},
+				pos: position{line: 493, col: 14, offset: 15888},
 				run: (*parser).callonFieldType1,
 				expr: &labeledExpr{
-					pos:   position{line: 360, col: 14, offset: 11329},
+					pos:   position{line: 493, col: 14, offset: 15888},
 					label: "typ",
 					expr: &choiceExpr{
-						pos: position{line: 360, col: 19, offset: 11334},
+						pos: position{line: 493, col: 19, offset: 15893},
 						alternatives: []interface{}{
 							&ruleRefExpr{
-								pos:  position{line: 360, col: 19, offset: 11334},
+								pos:  position{line: 493, col: 19, offset: 15893},
 								name: "BaseType",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 360, col: 30, offset: 11345},
+								pos:  position{line: 493, col: 30, offset: 15904},
 								name: "ContainerType",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 360, col: 46, offset: 11361},
+								pos:  position{line: 493, col: 46, offset: 15920},
 								name: "Identifier",
 							},
 						},
@@ -1401,32 +1925,32 @@ var g = &grammar{
 		},
 		{
 			name: "BaseType",
-			pos:  position{line: 367, col: 1, offset: 11486},
+			pos:  position{line: 502, col: 1, offset: 16106},
 			expr: &actionExpr{
-				pos: position{line: 367, col: 13, offset: 11498},
+				pos: position{line: 502, col: 13, offset: 16118},
 				run: (*parser).callonBaseType1,
 				expr: &seqExpr{
-					pos: position{line: 367, col: 13, offset: 11498},
+					pos: position{line: 502, col: 13, offset: 16118},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 367, col: 13, offset: 11498},
+							pos:   position{line: 502, col: 13, offset: 16118},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 367, col: 18, offset: 11503},
+								pos:  position{line: 502, col: 18, offset: 16123},
 								name: "BaseTypeName",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 367, col: 31, offset: 11516},
+							pos:  position{line: 502, col: 31, offset: 16136},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 367, col: 33, offset: 11518},
+							pos:   position{line: 502, col: 33, offset: 16138},
 							label: "annotations",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 367, col: 45, offset: 11530},
+								pos: position{line: 502, col: 45, offset: 16150},
 								expr: &ruleRefExpr{
-									pos:  position{line: 367, col: 45, offset: 11530},
+									pos:  position{line: 502, col: 45, offset: 16150},
 									name: "TypeAnnotations",
 								},
 							},
@@ -1437,50 +1961,50 @@ var g = &grammar{
 		},
 		{
 			name: "BaseTypeName",
-			pos:  position{line: 374, col: 1, offset: 11666},
+			pos:  position{line: 509, col: 1, offset: 16286},
 			expr: &actionExpr{
-				pos: position{line: 374, col: 17, offset: 11682},
+				pos: position{line: 509, col: 17, offset: 16302},
 				run: (*parser).callonBaseTypeName1,
 				expr: &choiceExpr{
-					pos: position{line: 374, col: 18, offset: 11683},
+					pos: position{line: 509, col: 18, offset: 16303},
 					alternatives: []interface{}{
 						&litMatcher{
-							pos:        position{line: 374, col: 18, offset: 11683},
+							pos:        position{line: 509, col: 18, offset: 16303},
 							val:        "bool",
 							ignoreCase: false,
 						},
 						&litMatcher{
-							pos:        position{line: 374, col: 27, offset: 11692},
+							pos:        position{line: 509, col: 27, offset: 16312},
 							val:        "byte",
 							ignoreCase: false,
 						},
 						&litMatcher{
-							pos:        position{line: 374, col: 36, offset: 11701},
+							pos:        position{line: 509, col: 36, offset: 16321},
 							val:        "i16",
 							ignoreCase: false,
 						},
 						&litMatcher{
-							pos:        position{line: 374, col: 44, offset: 11709},
+							pos:        position{line: 509, col: 44, offset: 16329},
 							val:        "i32",
 							ignoreCase: false,
 						},
 						&litMatcher{
-							pos:        position{line: 374, col: 52, offset: 11717},
+							pos:        position{line: 509, col: 52, offset: 16337},
 							val:        "i64",
 							ignoreCase: false,
 						},
 						&litMatcher{
-							pos:        position{line: 374, col: 60, offset: 11725},
+							pos:        position{line: 509, col: 60, offset: 16345},
 							val:        "double",
 							ignoreCase: false,
 						},
 						&litMatcher{
-							pos:        position{line: 374, col: 71, offset: 11736},
+							pos:        position{line: 509, col: 71, offset: 16356},
 							val:        "string",
 							ignoreCase: false,
 						},
 						&litMatcher{
-							pos:        position{line: 374, col: 82, offset: 11747},
+							pos:        position{line: 509, col: 82, offset: 16367},
 							val:        "binary",
 							ignoreCase: false,
 						},
@@ -1490,26 +2014,26 @@ var g = &grammar{
 		},
 		{
 			name: "ContainerType",
-			pos:  position{line: 378, col: 1, offset: 11794},
+			pos:  position{line: 513, col: 1, offset: 16414},
 			expr: &actionExpr{
-				pos: position{line: 378, col: 18, offset: 11811},
+				pos: position{line: 513, col: 18, offset: 16431},
 				run: (*parser).callonContainerType1,
 				expr: &labeledExpr{
-					pos:   position{line: 378, col: 18, offset: 11811},
+					pos:   position{line: 513, col: 18, offset: 16431},
 					label: "typ",
 					expr: &choiceExpr{
-						pos: position{line: 378, col: 23, offset: 11816},
+						pos: position{line: 513, col: 23, offset: 16436},
 						alternatives: []interface{}{
 							&ruleRefExpr{
-								pos:  position{line: 378, col: 23, offset: 11816},
+								pos:  position{line: 513, col: 23, offset: 16436},
 								name: "MapType",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 378, col: 33, offset: 11826},
+								pos:  position{line: 513, col: 33, offset: 16446},
 								name: "SetType",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 378, col: 43, offset: 11836},
+								pos:  position{line: 513, col: 43, offset: 16456},
 								name: "ListType",
 							},
 						},
@@ -1519,78 +2043,78 @@ var g = &grammar{
 		},
 		{
 			name: "MapType",
-			pos:  position{line: 382, col: 1, offset: 11871},
+			pos:  position{line: 517, col: 1, offset: 16491},
 			expr: &actionExpr{
-				pos: position{line: 382, col: 12, offset: 11882},
+				pos: position{line: 517, col: 12, offset: 16502},
 				run: (*parser).callonMapType1,
 				expr: &seqExpr{
-					pos: position{line: 382, col: 12, offset: 11882},
+					pos: position{line: 517, col: 12, offset: 16502},
 					exprs: []interface{}{
 						&zeroOrOneExpr{
-							pos: position{line: 382, col: 12, offset: 11882},
+							pos: position{line: 517, col: 12, offset: 16502},
 							expr: &ruleRefExpr{
-								pos:  position{line: 382, col: 12, offset: 11882},
+								pos:  position{line: 517, col: 12, offset: 16502},
 								name: "CppType",
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 382, col: 21, offset: 11891},
+							pos:        position{line: 517, col: 21, offset: 16511},
 							val:        "map<",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 382, col: 28, offset: 11898},
+							pos:  position{line: 517, col: 28, offset: 16518},
 							name: "WS",
 						},
 						&labeledExpr{
-							pos:   position{line: 382, col: 31, offset: 11901},
+							pos:   position{line: 517, col: 31, offset: 16521},
 							label: "key",
 							expr: &ruleRefExpr{
-								pos:  position{line: 382, col: 35, offset: 11905},
+								pos:  position{line: 517, col: 35, offset: 16525},
 								name: "FieldType",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 382, col: 45, offset: 11915},
+							pos:  position{line: 517, col: 45, offset: 16535},
 							name: "WS",
 						},
 						&litMatcher{
-							pos:        position{line: 382, col: 48, offset: 11918},
+							pos:        position{line: 517, col: 48, offset: 16538},
 							val:        ",",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 382, col: 52, offset: 11922},
+							pos:  position{line: 517, col: 52, offset: 16542},
 							name: "WS",
 						},
 						&labeledExpr{
-							pos:   position{line: 382, col: 55, offset: 11925},
+							pos:   position{line: 517, col: 55, offset: 16545},
 							label: "value",
 							expr: &ruleRefExpr{
-								pos:  position{line: 382, col: 61, offset: 11931},
+								pos:  position{line: 517, col: 61, offset: 16551},
 								name: "FieldType",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 382, col: 71, offset: 11941},
+							pos:  position{line: 517, col: 71, offset: 16561},
 							name: "WS",
 						},
 						&litMatcher{
-							pos:        position{line: 382, col: 74, offset: 11944},
+							pos:        position{line: 517, col: 74, offset: 16564},
 							val:        ">",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 382, col: 78, offset: 11948},
+							pos:  position{line: 517, col: 78, offset: 16568},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 382, col: 80, offset: 11950},
+							pos:   position{line: 517, col: 80, offset: 16570},
 							label: "annotations",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 382, col: 92, offset: 11962},
+								pos: position{line: 517, col: 92, offset: 16582},
 								expr: &ruleRefExpr{
-									pos:  position{line: 382, col: 92, offset: 11962},
+									pos:  position{line: 517, col: 92, offset: 16582},
 									name: "TypeAnnotations",
 								},
 							},
@@ -1601,57 +2125,57 @@ var g = &grammar{
 		},
 		{
 			name: "SetType",
-			pos:  position{line: 391, col: 1, offset: 12160},
+			pos:  position{line: 526, col: 1, offset: 16780},
 			expr: &actionExpr{
-				pos: position{line: 391, col: 12, offset: 12171},
+				pos: position{line: 526, col: 12, offset: 16791},
 				run: (*parser).callonSetType1,
 				expr: &seqExpr{
-					pos: position{line: 391, col: 12, offset: 12171},
+					pos: position{line: 526, col: 12, offset: 16791},
 					exprs: []interface{}{
 						&zeroOrOneExpr{
-							pos: position{line: 391, col: 12, offset: 12171},
+							pos: position{line: 526, col: 12, offset: 16791},
 							expr: &ruleRefExpr{
-								pos:  position{line: 391, col: 12, offset: 12171},
+								pos:  position{line: 526, col: 12, offset: 16791},
 								name: "CppType",
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 391, col: 21, offset: 12180},
+							pos:        position{line: 526, col: 21, offset: 16800},
 							val:        "set<",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 391, col: 28, offset: 12187},
+							pos:  position{line: 526, col: 28, offset: 16807},
 							name: "WS",
 						},
 						&labeledExpr{
-							pos:   position{line: 391, col: 31, offset: 12190},
+							pos:   position{line: 526, col: 31, offset: 16810},
 							label: "typ",
 							expr: &ruleRefExpr{
-								pos:  position{line: 391, col: 35, offset: 12194},
+								pos:  position{line: 526, col: 35, offset: 16814},
 								name: "FieldType",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 391, col: 45, offset: 12204},
+							pos:  position{line: 526, col: 45, offset: 16824},
 							name: "WS",
 						},
 						&litMatcher{
-							pos:        position{line: 391, col: 48, offset: 12207},
+							pos:        position{line: 526, col: 48, offset: 16827},
 							val:        ">",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 391, col: 52, offset: 12211},
+							pos:  position{line: 526, col: 52, offset: 16831},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 391, col: 54, offset: 12213},
+							pos:   position{line: 526, col: 54, offset: 16833},
 							label: "annotations",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 391, col: 66, offset: 12225},
+								pos: position{line: 526, col: 66, offset: 16845},
 								expr: &ruleRefExpr{
-									pos:  position{line: 391, col: 66, offset: 12225},
+									pos:  position{line: 526, col: 66, offset: 16845},
 									name: "TypeAnnotations",
 								},
 							},
@@ -1662,50 +2186,50 @@ var g = &grammar{
 		},
 		{
 			name: "ListType",
-			pos:  position{line: 399, col: 1, offset: 12387},
+			pos:  position{line: 534, col: 1, offset: 17007},
 			expr: &actionExpr{
-				pos: position{line: 399, col: 13, offset: 12399},
+				pos: position{line: 534, col: 13, offset: 17019},
 				run: (*parser).callonListType1,
 				expr: &seqExpr{
-					pos: position{line: 399, col: 13, offset: 12399},
+					pos: position{line: 534, col: 13, offset: 17019},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 399, col: 13, offset: 12399},
+							pos:        position{line: 534, col: 13, offset: 17019},
 							val:        "list<",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 399, col: 21, offset: 12407},
+							pos:  position{line: 534, col: 21, offset: 17027},
 							name: "WS",
 						},
 						&labeledExpr{
-							pos:   position{line: 399, col: 24, offset: 12410},
+							pos:   position{line: 534, col: 24, offset: 17030},
 							label: "typ",
 							expr: &ruleRefExpr{
-								pos:  position{line: 399, col: 28, offset: 12414},
+								pos:  position{line: 534, col: 28, offset: 17034},
 								name: "FieldType",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 399, col: 38, offset: 12424},
+							pos:  position{line: 534, col: 38, offset: 17044},
 							name: "WS",
 						},
 						&litMatcher{
-							pos:        position{line: 399, col: 41, offset: 12427},
+							pos:        position{line: 534, col: 41, offset: 17047},
 							val:        ">",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 399, col: 45, offset: 12431},
+							pos:  position{line: 534, col: 45, offset: 17051},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 399, col: 47, offset: 12433},
+							pos:   position{line: 534, col: 47, offset: 17053},
 							label: "annotations",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 399, col: 59, offset: 12445},
+								pos: position{line: 534, col: 59, offset: 17065},
 								expr: &ruleRefExpr{
-									pos:  position{line: 399, col: 59, offset: 12445},
+									pos:  position{line: 534, col: 59, offset: 17065},
 									name: "TypeAnnotations",
 								},
 							},
@@ -1716,23 +2240,23 @@ var g = &grammar{
 		},
 		{
 			name: "CppType",
-			pos:  position{line: 407, col: 1, offset: 12608},
+			pos:  position{line: 542, col: 1, offset: 17228},
 			expr: &actionExpr{
-				pos: position{line: 407, col: 12, offset: 12619},
+				pos: position{line: 542, col: 12, offset: 17239},
 				run: (*parser).callonCppType1,
 				expr: &seqExpr{
-					pos: position{line: 407, col: 12, offset: 12619},
+					pos: position{line: 542, col: 12, offset: 17239},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 407, col: 12, offset: 12619},
+							pos:        position{line: 542, col: 12, offset: 17239},
 							val:        "cpp_type",
 							ignoreCase: false,
 						},
 						&labeledExpr{
-							pos:   position{line: 407, col: 23, offset: 12630},
+							pos:   position{line: 542, col: 23, offset: 17250},
 							label: "cppType",
 							expr: &ruleRefExpr{
-								pos:  position{line: 407, col: 31, offset: 12638},
+								pos:  position{line: 542, col: 31, offset: 17258},
 								name: "Literal",
 							},
 						},
@@ -1742,36 +2266,36 @@ var g = &grammar{
 		},
 		{
 			name: "ConstValue",
-			pos:  position{line: 411, col: 1, offset: 12675},
+			pos:  position{line: 546, col: 1, offset: 17295},
 			expr: &choiceExpr{
-				pos: position{line: 411, col: 15, offset: 12689},
+				pos: position{line: 546, col: 15, offset: 17309},
 				alternatives: []interface{}{
 					&ruleRefExpr{
-						pos:  position{line: 411, col: 15, offset: 12689},
+						pos:  position{line: 546, col: 15, offset: 17309},
 						name: "Literal",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 411, col: 25, offset: 12699},
+						pos:  position{line: 546, col: 25, offset: 17319},
 						name: "BoolConstant",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 411, col: 40, offset: 12714},
+						pos:  position{line: 546, col: 40, offset: 17334},
 						name: "DoubleConstant",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 411, col: 57, offset: 12731},
+						pos:  position{line: 546, col: 57, offset: 17351},
 						name: "IntConstant",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 411, col: 71, offset: 12745},
+						pos:  position{line: 546, col: 71, offset: 17365},
 						name: "ConstMap",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 411, col: 82, offset: 12756},
+						pos:  position{line: 546, col: 82, offset: 17376},
 						name: "ConstList",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 411, col: 94, offset: 12768},
+						pos:  position{line: 546, col: 94, offset: 17388},
 						name: "Identifier",
 					},
 				},
@@ -1779,35 +2303,35 @@ var g = &grammar{
 		},
 		{
 			name: "TypeAnnotations",
-			pos:  position{line: 413, col: 1, offset: 12780},
+			pos:  position{line: 548, col: 1, offset: 17400},
 			expr: &actionExpr{
-				pos: position{line: 413, col: 20, offset: 12799},
+				pos: position{line: 548, col: 20, offset: 17419},
 				run: (*parser).callonTypeAnnotations1,
 				expr: &seqExpr{
-					pos: position{line: 413, col: 20, offset: 12799},
+					pos: position{line: 548, col: 20, offset: 17419},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 413, col: 20, offset: 12799},
+							pos:        position{line: 548, col: 20, offset: 17419},
 							val:        "(",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 413, col: 24, offset: 12803},
+							pos:  position{line: 548, col: 24, offset: 17423},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 413, col: 27, offset: 12806},
+							pos:   position{line: 548, col: 27, offset: 17426},
 							label: "annotations",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 413, col: 39, offset: 12818},
+								pos: position{line: 548, col: 39, offset: 17438},
 								expr: &ruleRefExpr{
-									pos:  position{line: 413, col: 39, offset: 12818},
+									pos:  position{line: 548, col: 39, offset: 17438},
 									name: "TypeAnnotation",
 								},
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 413, col: 55, offset: 12834},
+							pos:        position{line: 548, col: 55, offset: 17454},
 							val:        ")",
 							ignoreCase: false,
 						},
@@ -1817,50 +2341,50 @@ var g = &grammar{
 		},
 		{
 			name: "TypeAnnotation",
-			pos:  position{line: 421, col: 1, offset: 12998},
+			pos:  position{line: 556, col: 1, offset: 17618},
 			expr: &actionExpr{
-				pos: position{line: 421, col: 19, offset: 13016},
+				pos: position{line: 556, col: 19, offset: 17636},
 				run: (*parser).callonTypeAnnotation1,
 				expr: &seqExpr{
-					pos: position{line: 421, col: 19, offset: 13016},
+					pos: position{line: 556, col: 19, offset: 17636},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 421, col: 19, offset: 13016},
+							pos:   position{line: 556, col: 19, offset: 17636},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 421, col: 24, offset: 13021},
+								pos:  position{line: 556, col: 24, offset: 17641},
 								name: "Identifier",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 421, col: 35, offset: 13032},
+							pos:  position{line: 556, col: 35, offset: 17652},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 421, col: 37, offset: 13034},
+							pos:   position{line: 556, col: 37, offset: 17654},
 							label: "value",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 421, col: 43, offset: 13040},
+								pos: position{line: 556, col: 43, offset: 17660},
 								expr: &actionExpr{
-									pos: position{line: 421, col: 44, offset: 13041},
+									pos: position{line: 556, col: 44, offset: 17661},
 									run: (*parser).callonTypeAnnotation8,
 									expr: &seqExpr{
-										pos: position{line: 421, col: 44, offset: 13041},
+										pos: position{line: 556, col: 44, offset: 17661},
 										exprs: []interface{}{
 											&litMatcher{
-												pos:        position{line: 421, col: 44, offset: 13041},
+												pos:        position{line: 556, col: 44, offset: 17661},
 												val:        "=",
 												ignoreCase: false,
 											},
 											&ruleRefExpr{
-												pos:  position{line: 421, col: 48, offset: 13045},
+												pos:  position{line: 556, col: 48, offset: 17665},
 												name: "__",
 											},
 											&labeledExpr{
-												pos:   position{line: 421, col: 51, offset: 13048},
+												pos:   position{line: 556, col: 51, offset: 17668},
 												label: "value",
 												expr: &ruleRefExpr{
-													pos:  position{line: 421, col: 57, offset: 13054},
+													pos:  position{line: 556, col: 57, offset: 17674},
 													name: "Literal",
 												},
 											},
@@ -1870,14 +2394,14 @@ var g = &grammar{
 							},
 						},
 						&zeroOrOneExpr{
-							pos: position{line: 421, col: 89, offset: 13086},
+							pos: position{line: 556, col: 89, offset: 17706},
 							expr: &ruleRefExpr{
-								pos:  position{line: 421, col: 89, offset: 13086},
+								pos:  position{line: 556, col: 89, offset: 17706},
 								name: "ListSeparator",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 421, col: 104, offset: 13101},
+							pos:  position{line: 556, col: 104, offset: 17721},
 							name: "__",
 						},
 					},
@@ -1886,20 +2410,20 @@ var g = &grammar{
 		},
 		{
 			name: "BoolConstant",
-			pos:  position{line: 432, col: 1, offset: 13297},
+			pos:  position{line: 568, col: 1, offset: 17945},
 			expr: &actionExpr{
-				pos: position{line: 432, col: 17, offset: 13313},
+				pos: position{line: 568, col: 17, offset: 17961},
 				run: (*parser).callonBoolConstant1,
 				expr: &choiceExpr{
-					pos: position{line: 432, col: 18, offset: 13314},
+					pos: position{line: 568, col: 18, offset: 17962},
 					alternatives: []interface{}{
 						&litMatcher{
-							pos:        position{line: 432, col: 18, offset: 13314},
+							pos:        position{line: 568, col: 18, offset: 17962},
 							val:        "true",
 							ignoreCase: false,
 						},
 						&litMatcher{
-							pos:        position{line: 432, col: 27, offset: 13323},
+							pos:        position{line: 568, col: 27, offset: 17971},
 							val:        "false",
 							ignoreCase: false,
 						},
@@ -1909,17 +2433,17 @@ var g = &grammar{
 		},
 		{
 			name: "IntConstant",
-			pos:  position{line: 436, col: 1, offset: 13378},
+			pos:  position{line: 572, col: 1, offset: 18026},
 			expr: &actionExpr{
-				pos: position{line: 436, col: 16, offset: 13393},
+				pos: position{line: 572, col: 16, offset: 18041},
 				run: (*parser).callonIntConstant1,
 				expr: &seqExpr{
-					pos: position{line: 436, col: 16, offset: 13393},
+					pos: position{line: 572, col: 16, offset: 18041},
 					exprs: []interface{}{
 						&zeroOrOneExpr{
-							pos: position{line: 436, col: 16, offset: 13393},
+							pos: position{line: 572, col: 16, offset: 18041},
 							expr: &charClassMatcher{
-								pos:        position{line: 436, col: 16, offset: 13393},
+								pos:        position{line: 572, col: 16, offset: 18041},
 								val:        "[-+]",
 								chars:      []rune{'-', '+'},
 								ignoreCase: false,
@@ -1927,9 +2451,9 @@ var g = &grammar{
 							},
 						},
 						&oneOrMoreExpr{
-							pos: position{line: 436, col: 22, offset: 13399},
+							pos: position{line: 572, col: 22, offset: 18047},
 							expr: &ruleRefExpr{
-								pos:  position{line: 436, col: 22, offset: 13399},
+								pos:  position{line: 572, col: 22, offset: 18047},
 								name: "Digit",
 							},
 						},
@@ -1939,17 +2463,17 @@ var g = &grammar{
 		},
 		{
 			name: "DoubleConstant",
-			pos:  position{line: 440, col: 1, offset: 13463},
+			pos:  position{line: 576, col: 1, offset: 18111},
 			expr: &actionExpr{
-				pos: position{line: 440, col: 19, offset: 13481},
+				pos: position{line: 576, col: 19, offset: 18129},
 				run: (*parser).callonDoubleConstant1,
 				expr: &seqExpr{
-					pos: position{line: 440, col: 19, offset: 13481},
+					pos: position{line: 576, col: 19, offset: 18129},
 					exprs: []interface{}{
 						&zeroOrOneExpr{
-							pos: position{line: 440, col: 19, offset: 13481},
+							pos: position{line: 576, col: 19, offset: 18129},
 							expr: &charClassMatcher{
-								pos:        position{line: 440, col: 19, offset: 13481},
+								pos:        position{line: 576, col: 19, offset: 18129},
 								val:        "[+-]",
 								chars:      []rune{'+', '-'},
 								ignoreCase: false,
@@ -1957,38 +2481,38 @@ var g = &grammar{
 							},
 						},
 						&zeroOrMoreExpr{
-							pos: position{line: 440, col: 25, offset: 13487},
+							pos: position{line: 576, col: 25, offset: 18135},
 							expr: &ruleRefExpr{
-								pos:  position{line: 440, col: 25, offset: 13487},
+								pos:  position{line: 576, col: 25, offset: 18135},
 								name: "Digit",
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 440, col: 32, offset: 13494},
+							pos:        position{line: 576, col: 32, offset: 18142},
 							val:        ".",
 							ignoreCase: false,
 						},
 						&zeroOrMoreExpr{
-							pos: position{line: 440, col: 36, offset: 13498},
+							pos: position{line: 576, col: 36, offset: 18146},
 							expr: &ruleRefExpr{
-								pos:  position{line: 440, col: 36, offset: 13498},
+								pos:  position{line: 576, col: 36, offset: 18146},
 								name: "Digit",
 							},
 						},
 						&zeroOrOneExpr{
-							pos: position{line: 440, col: 43, offset: 13505},
+							pos: position{line: 576, col: 43, offset: 18153},
 							expr: &seqExpr{
-								pos: position{line: 440, col: 45, offset: 13507},
+								pos: position{line: 576, col: 45, offset: 18155},
 								exprs: []interface{}{
 									&charClassMatcher{
-										pos:        position{line: 440, col: 45, offset: 13507},
+										pos:        position{line: 576, col: 45, offset: 18155},
 										val:        "['Ee']",
 										chars:      []rune{'\'', 'E', 'e', '\''},
 										ignoreCase: false,
 										inverted:   false,
 									},
 									&ruleRefExpr{
-										pos:  position{line: 440, col: 52, offset: 13514},
+										pos:  position{line: 576, col: 52, offset: 18162},
 										name: "IntConstant",
 									},
 								},
@@ -2000,47 +2524,47 @@ var g = &grammar{
 		},
 		{
 			name: "ConstList",
-			pos:  position{line: 444, col: 1, offset: 13584},
+			pos:  position{line: 580, col: 1, offset: 18232},
 			expr: &actionExpr{
-				pos: position{line: 444, col: 14, offset: 13597},
+				pos: position{line: 580, col: 14, offset: 18245},
 				run: (*parser).callonConstList1,
 				expr: &seqExpr{
-					pos: position{line: 444, col: 14, offset: 13597},
+					pos: position{line: 580, col: 14, offset: 18245},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 444, col: 14, offset: 13597},
+							pos:        position{line: 580, col: 14, offset: 18245},
 							val:        "[",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 444, col: 18, offset: 13601},
+							pos:  position{line: 580, col: 18, offset: 18249},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 444, col: 21, offset: 13604},
+							pos:   position{line: 580, col: 21, offset: 18252},
 							label: "values",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 444, col: 28, offset: 13611},
+								pos: position{line: 580, col: 28, offset: 18259},
 								expr: &seqExpr{
-									pos: position{line: 444, col: 29, offset: 13612},
+									pos: position{line: 580, col: 29, offset: 18260},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 444, col: 29, offset: 13612},
+											pos:  position{line: 580, col: 29, offset: 18260},
 											name: "ConstValue",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 444, col: 40, offset: 13623},
+											pos:  position{line: 580, col: 40, offset: 18271},
 											name: "__",
 										},
 										&zeroOrOneExpr{
-											pos: position{line: 444, col: 43, offset: 13626},
+											pos: position{line: 580, col: 43, offset: 18274},
 											expr: &ruleRefExpr{
-												pos:  position{line: 444, col: 43, offset: 13626},
+												pos:  position{line: 580, col: 43, offset: 18274},
 												name: "ListSeparator",
 											},
 										},
 										&ruleRefExpr{
-											pos:  position{line: 444, col: 58, offset: 13641},
+											pos:  position{line: 580, col: 58, offset: 18289},
 											name: "__",
 										},
 									},
@@ -2048,11 +2572,11 @@ var g = &grammar{
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 444, col: 63, offset: 13646},
+							pos:  position{line: 580, col: 63, offset: 18294},
 							name: "__",
 						},
 						&litMatcher{
-							pos:        position{line: 444, col: 66, offset: 13649},
+							pos:        position{line: 580, col: 66, offset: 18297},
 							val:        "]",
 							ignoreCase: false,
 						},
@@ -2062,67 +2586,67 @@ var g = &grammar{
 		},
 		{
 			name: "ConstMap",
-			pos:  position{line: 453, col: 1, offset: 13843},
+			pos:  position{line: 589, col: 1, offset: 18491},
 			expr: &actionExpr{
-				pos: position{line: 453, col: 13, offset: 13855},
+				pos: position{line: 589, col: 13, offset: 18503},
 				run: (*parser).callonConstMap1,
 				expr: &seqExpr{
-					pos: position{line: 453, col: 13, offset: 13855},
+					pos: position{line: 589, col: 13, offset: 18503},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 453, col: 13, offset: 13855},
+							pos:        position{line: 589, col: 13, offset: 18503},
 							val:        "{",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 453, col: 17, offset: 13859},
+							pos:  position{line: 589, col: 17, offset: 18507},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 453, col: 20, offset: 13862},
+							pos:   position{line: 589, col: 20, offset: 18510},
 							label: "values",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 453, col: 27, offset: 13869},
+								pos: position{line: 589, col: 27, offset: 18517},
 								expr: &seqExpr{
-									pos: position{line: 453, col: 28, offset: 13870},
+									pos: position{line: 589, col: 28, offset: 18518},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 453, col: 28, offset: 13870},
+											pos:  position{line: 589, col: 28, offset: 18518},
 											name: "ConstValue",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 453, col: 39, offset: 13881},
+											pos:  position{line: 589, col: 39, offset: 18529},
 											name: "__",
 										},
 										&litMatcher{
-											pos:        position{line: 453, col: 42, offset: 13884},
+											pos:        position{line: 589, col: 42, offset: 18532},
 											val:        ":",
 											ignoreCase: false,
 										},
 										&ruleRefExpr{
-											pos:  position{line: 453, col: 46, offset: 13888},
+											pos:  position{line: 589, col: 46, offset: 18536},
 											name: "__",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 453, col: 49, offset: 13891},
+											pos:  position{line: 589, col: 49, offset: 18539},
 											name: "ConstValue",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 453, col: 60, offset: 13902},
+											pos:  position{line: 589, col: 60, offset: 18550},
 											name: "__",
 										},
 										&choiceExpr{
-											pos: position{line: 453, col: 64, offset: 13906},
+											pos: position{line: 589, col: 64, offset: 18554},
 											alternatives: []interface{}{
 												&litMatcher{
-													pos:        position{line: 453, col: 64, offset: 13906},
+													pos:        position{line: 589, col: 64, offset: 18554},
 													val:        ",",
 													ignoreCase: false,
 												},
 												&andExpr{
-													pos: position{line: 453, col: 70, offset: 13912},
+													pos: position{line: 589, col: 70, offset: 18560},
 													expr: &litMatcher{
-														pos:        position{line: 453, col: 71, offset: 13913},
+														pos:        position{line: 589, col: 71, offset: 18561},
 														val:        "}",
 														ignoreCase: false,
 													},
@@ -2130,7 +2654,7 @@ var g = &grammar{
 											},
 										},
 										&ruleRefExpr{
-											pos:  position{line: 453, col: 76, offset: 13918},
+											pos:  position{line: 589, col: 76, offset: 18566},
 											name: "__",
 										},
 									},
@@ -2138,7 +2662,7 @@ var g = &grammar{
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 453, col: 81, offset: 13923},
+							pos:        position{line: 589, col: 81, offset: 18571},
 							val:        "}",
 							ignoreCase: false,
 						},
@@ -2148,92 +2672,100 @@ var g = &grammar{
 		},
 		{
 			name: "Scope",
-			pos:  position{line: 473, col: 1, offset: 14473},
+			pos:  position{line: 609, col: 1, offset: 19121},
 			expr: &actionExpr{
-				pos: position{line: 473, col: 10, offset: 14482},
+				pos: position{line: 609, col: 10, offset: 19130},
 				run: (*parser).callonScope1,
 				expr: &seqExpr{
-					pos: position{line: 473, col: 10, offset: 14482},
+					pos: position{line: 609, col: 10, offset: 19130},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 473, col: 10, offset: 14482},
+							pos:   position{line: 609, col: 10, offset: 19130},
 							label: "docstr",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 473, col: 17, offset: 14489},
+								pos: position{line: 609, col: 17, offset: 19137},
 								expr: &seqExpr{
-									pos: position{line: 473, col: 18, offset: 14490},
+									pos: position{line: 609, col: 18, offset: 19138},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 473, col: 18, offset: 14490},
+											pos:  position{line: 609, col: 18, offset: 19138},
 											name: "DocString",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 473, col: 28, offset: 14500},
+											pos:  position{line: 609, col: 28, offset: 19148},
 											name: "__",
 										},
 									},
 								},
 							},
 						},
+						&labeledExpr{
+							pos:   position{line: 609, col: 33, offset: 19153},
+							label: "start",
+							expr: &ruleRefExpr{
+								pos:  position{line: 609, col: 39, offset: 19159},
+								name: "Mark",
+							},
+						},
 						&litMatcher{
-							pos:        position{line: 473, col: 33, offset: 14505},
+							pos:        position{line: 609, col: 44, offset: 19164},
 							val:        "scope",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 473, col: 41, offset: 14513},
+							pos:  position{line: 609, col: 52, offset: 19172},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 473, col: 44, offset: 14516},
+							pos:   position{line: 609, col: 55, offset: 19175},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 473, col: 49, offset: 14521},
+								pos:  position{line: 609, col: 60, offset: 19180},
 								name: "Identifier",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 473, col: 60, offset: 14532},
+							pos:  position{line: 609, col: 71, offset: 19191},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 473, col: 63, offset: 14535},
+							pos:   position{line: 609, col: 74, offset: 19194},
 							label: "prefix",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 473, col: 70, offset: 14542},
+								pos: position{line: 609, col: 81, offset: 19201},
 								expr: &ruleRefExpr{
-									pos:  position{line: 473, col: 70, offset: 14542},
+									pos:  position{line: 609, col: 81, offset: 19201},
 									name: "Prefix",
 								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 473, col: 78, offset: 14550},
+							pos:  position{line: 609, col: 89, offset: 19209},
 							name: "__",
 						},
 						&litMatcher{
-							pos:        position{line: 473, col: 81, offset: 14553},
+							pos:        position{line: 609, col: 92, offset: 19212},
 							val:        "{",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 473, col: 85, offset: 14557},
+							pos:  position{line: 609, col: 96, offset: 19216},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 473, col: 88, offset: 14560},
+							pos:   position{line: 609, col: 99, offset: 19219},
 							label: "operations",
 							expr: &zeroOrMoreExpr{
-								pos: position{line: 473, col: 99, offset: 14571},
+								pos: position{line: 609, col: 110, offset: 19230},
 								expr: &seqExpr{
-									pos: position{line: 473, col: 100, offset: 14572},
+									pos: position{line: 609, col: 111, offset: 19231},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 473, col: 100, offset: 14572},
+											pos:  position{line: 609, col: 111, offset: 19231},
 											name: "Operation",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 473, col: 110, offset: 14582},
+											pos:  position{line: 609, col: 121, offset: 19241},
 											name: "__",
 										},
 									},
@@ -2241,36 +2773,44 @@ var g = &grammar{
 							},
 						},
 						&choiceExpr{
-							pos: position{line: 473, col: 116, offset: 14588},
+							pos: position{line: 609, col: 127, offset: 19247},
 							alternatives: []interface{}{
 								&litMatcher{
-									pos:        position{line: 473, col: 116, offset: 14588},
+									pos:        position{line: 609, col: 127, offset: 19247},
 									val:        "}",
 									ignoreCase: false,
 								},
 								&ruleRefExpr{
-									pos:  position{line: 473, col: 122, offset: 14594},
+									pos:  position{line: 609, col: 133, offset: 19253},
 									name: "EndOfScopeError",
 								},
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 473, col: 139, offset: 14611},
+							pos:  position{line: 609, col: 150, offset: 19270},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 473, col: 141, offset: 14613},
+							pos:   position{line: 609, col: 152, offset: 19272},
 							label: "annotations",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 473, col: 153, offset: 14625},
+								pos: position{line: 609, col: 164, offset: 19284},
 								expr: &ruleRefExpr{
-									pos:  position{line: 473, col: 153, offset: 14625},
+									pos:  position{line: 609, col: 164, offset: 19284},
 									name: "TypeAnnotations",
 								},
 							},
 						},
+						&labeledExpr{
+							pos:   position{line: 609, col: 181, offset: 19301},
+							label: "end",
+							expr: &ruleRefExpr{
+								pos:  position{line: 609, col: 185, offset: 19305},
+								name: "Mark",
+							},
+						},
 						&ruleRefExpr{
-							pos:  position{line: 473, col: 170, offset: 14642},
+							pos:  position{line: 609, col: 190, offset: 19310},
 							name: "EOS",
 						},
 					},
@@ -2279,49 +2819,58 @@ var g = &grammar{
 		},
 		{
 			name: "EndOfScopeError",
-			pos:  position{line: 495, col: 1, offset: 15239},
+			pos:  position{line: 632, col: 1, offset: 19958},
 			expr: &actionExpr{
-				pos: position{line: 495, col: 20, offset: 15258},
+				pos: position{line: 632, col: 20, offset: 19977},
 				run: (*parser).callonEndOfScopeError1,
-				expr: &anyMatcher{
-					line: 495, col: 20, offset: 15258,
+				expr: &choiceExpr{
+					pos: position{line: 632, col: 22, offset: 19979},
+					alternatives: []interface{}{
+						&anyMatcher{
+							line: 632, col: 22, offset: 19979,
+						},
+						&ruleRefExpr{
+							pos:  position{line: 632, col: 26, offset: 19983},
+							name: "EOF",
+						},
+					},
 				},
 			},
 		},
 		{
 			name: "Prefix",
-			pos:  position{line: 499, col: 1, offset: 15325},
+			pos:  position{line: 636, col: 1, offset: 20057},
 			expr: &actionExpr{
-				pos: position{line: 499, col: 11, offset: 15335},
+				pos: position{line: 636, col: 11, offset: 20067},
 				run: (*parser).callonPrefix1,
 				expr: &seqExpr{
-					pos: position{line: 499, col: 11, offset: 15335},
+					pos: position{line: 636, col: 11, offset: 20067},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 499, col: 11, offset: 15335},
+							pos:        position{line: 636, col: 11, offset: 20067},
 							val:        "prefix",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 499, col: 20, offset: 15344},
+							pos:  position{line: 636, col: 20, offset: 20076},
 							name: "__",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 499, col: 23, offset: 15347},
+							pos:  position{line: 636, col: 23, offset: 20079},
 							name: "PrefixToken",
 						},
 						&zeroOrMoreExpr{
-							pos: position{line: 499, col: 35, offset: 15359},
+							pos: position{line: 636, col: 35, offset: 20091},
 							expr: &seqExpr{
-								pos: position{line: 499, col: 36, offset: 15360},
+								pos: position{line: 636, col: 36, offset: 20092},
 								exprs: []interface{}{
 									&litMatcher{
-										pos:        position{line: 499, col: 36, offset: 15360},
+										pos:        position{line: 636, col: 36, offset: 20092},
 										val:        ".",
 										ignoreCase: false,
 									},
 									&ruleRefExpr{
-										pos:  position{line: 499, col: 40, offset: 15364},
+										pos:  position{line: 636, col: 40, offset: 20096},
 										name: "PrefixToken",
 									},
 								},
@@ -2333,31 +2882,31 @@ var g = &grammar{
 		},
 		{
 			name: "PrefixToken",
-			pos:  position{line: 504, col: 1, offset: 15495},
+			pos:  position{line: 641, col: 1, offset: 20227},
 			expr: &choiceExpr{
-				pos: position{line: 504, col: 16, offset: 15510},
+				pos: position{line: 641, col: 16, offset: 20242},
 				alternatives: []interface{}{
 					&seqExpr{
-						pos: position{line: 504, col: 17, offset: 15511},
+						pos: position{line: 641, col: 17, offset: 20243},
 						exprs: []interface{}{
 							&litMatcher{
-								pos:        position{line: 504, col: 17, offset: 15511},
+								pos:        position{line: 641, col: 17, offset: 20243},
 								val:        "{",
 								ignoreCase: false,
 							},
 							&ruleRefExpr{
-								pos:  position{line: 504, col: 21, offset: 15515},
+								pos:  position{line: 641, col: 21, offset: 20247},
 								name: "PrefixWord",
 							},
 							&litMatcher{
-								pos:        position{line: 504, col: 32, offset: 15526},
+								pos:        position{line: 641, col: 32, offset: 20258},
 								val:        "}",
 								ignoreCase: false,
 							},
 						},
 					},
 					&ruleRefExpr{
-						pos:  position{line: 504, col: 39, offset: 15533},
+						pos:  position{line: 641, col: 39, offset: 20265},
 						name: "PrefixWord",
 					},
 				},
@@ -2365,11 +2914,11 @@ var g = &grammar{
 		},
 		{
 			name: "PrefixWord",
-			pos:  position{line: 506, col: 1, offset: 15545},
+			pos:  position{line: 643, col: 1, offset: 20277},
 			expr: &oneOrMoreExpr{
-				pos: position{line: 506, col: 15, offset: 15559},
+				pos: position{line: 643, col: 15, offset: 20291},
 				expr: &charClassMatcher{
-					pos:        position{line: 506, col: 15, offset: 15559},
+					pos:        position{line: 643, col: 15, offset: 20291},
 					val:        "[^\\r\\n\\t\\f .{}]",
 					chars:      []rune{'\r', '\n', '\t', '\f', ' ', '.', '{', '}'},
 					ignoreCase: false,
@@ -2379,27 +2928,27 @@ var g = &grammar{
 		},
 		{
 			name: "Operation",
-			pos:  position{line: 508, col: 1, offset: 15577},
+			pos:  position{line: 645, col: 1, offset: 20309},
 			expr: &actionExpr{
-				pos: position{line: 508, col: 14, offset: 15590},
+				pos: position{line: 645, col: 14, offset: 20322},
 				run: (*parser).callonOperation1,
 				expr: &seqExpr{
-					pos: position{line: 508, col: 14, offset: 15590},
+					pos: position{line: 645, col: 14, offset: 20322},
 					exprs: []interface{}{
 						&labeledExpr{
-							pos:   position{line: 508, col: 14, offset: 15590},
+							pos:   position{line: 645, col: 14, offset: 20322},
 							label: "docstr",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 508, col: 21, offset: 15597},
+								pos: position{line: 645, col: 21, offset: 20329},
 								expr: &seqExpr{
-									pos: position{line: 508, col: 22, offset: 15598},
+									pos: position{line: 645, col: 22, offset: 20330},
 									exprs: []interface{}{
 										&ruleRefExpr{
-											pos:  position{line: 508, col: 22, offset: 15598},
+											pos:  position{line: 645, col: 22, offset: 20330},
 											name: "DocString",
 										},
 										&ruleRefExpr{
-											pos:  position{line: 508, col: 32, offset: 15608},
+											pos:  position{line: 645, col: 32, offset: 20340},
 											name: "__",
 										},
 									},
@@ -2407,53 +2956,61 @@ var g = &grammar{
 							},
 						},
 						&labeledExpr{
-							pos:   position{line: 508, col: 37, offset: 15613},
+							pos:   position{line: 645, col: 37, offset: 20345},
+							label: "start",
+							expr: &ruleRefExpr{
+								pos:  position{line: 645, col: 43, offset: 20351},
+								name: "Mark",
+							},
+						},
+						&labeledExpr{
+							pos:   position{line: 645, col: 48, offset: 20356},
 							label: "name",
 							expr: &ruleRefExpr{
-								pos:  position{line: 508, col: 42, offset: 15618},
+								pos:  position{line: 645, col: 53, offset: 20361},
 								name: "Identifier",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 508, col: 53, offset: 15629},
+							pos:  position{line: 645, col: 64, offset: 20372},
 							name: "_",
 						},
 						&litMatcher{
-							pos:        position{line: 508, col: 55, offset: 15631},
+							pos:        position{line: 645, col: 66, offset: 20374},
 							val:        ":",
 							ignoreCase: false,
 						},
 						&ruleRefExpr{
-							pos:  position{line: 508, col: 59, offset: 15635},
+							pos:  position{line: 645, col: 70, offset: 20378},
 							name: "__",
 						},
 						&labeledExpr{
-							pos:   position{line: 508, col: 62, offset: 15638},
+							pos:   position{line: 645, col: 73, offset: 20381},
 							label: "typ",
 							expr: &ruleRefExpr{
-								pos:  position{line: 508, col: 66, offset: 15642},
+								pos:  position{line: 645, col: 77, offset: 20385},
 								name: "FieldType",
 							},
 						},
 						&ruleRefExpr{
-							pos:  position{line: 508, col: 76, offset: 15652},
+							pos:  position{line: 645, col: 87, offset: 20395},
 							name: "_",
 						},
 						&labeledExpr{
-							pos:   position{line: 508, col: 78, offset: 15654},
+							pos:   position{line: 645, col: 89, offset: 20397},
 							label: "annotations",
 							expr: &zeroOrOneExpr{
-								pos: position{line: 508, col: 90, offset: 15666},
+								pos: position{line: 645, col: 101, offset: 20409},
 								expr: &ruleRefExpr{
-									pos:  position{line: 508, col: 90, offset: 15666},
+									pos:  position{line: 645, col: 101, offset: 20409},
 									name: "TypeAnnotations",
 								},
 							},
 						},
 						&zeroOrOneExpr{
-							pos: position{line: 508, col: 107, offset: 15683},
+							pos: position{line: 645, col: 118, offset: 20426},
 							expr: &ruleRefExpr{
-								pos:  position{line: 508, col: 107, offset: 15683},
+								pos:  position{line: 645, col: 118, offset: 20426},
 								name: "ListSeparator",
 							},
 						},
@@ -2463,33 +3020,33 @@ var g = &grammar{
 		},
 		{
 			name: "Literal",
-			pos:  position{line: 525, col: 1, offset: 16243},
+			pos:  position{line: 663, col: 1, offset: 21037},
 			expr: &actionExpr{
-				pos: position{line: 525, col: 12, offset: 16254},
+				pos: position{line: 663, col: 12, offset: 21048},
 				run: (*parser).callonLiteral1,
 				expr: &choiceExpr{
-					pos: position{line: 525, col: 13, offset: 16255},
+					pos: position{line: 663, col: 13, offset: 21049},
 					alternatives: []interface{}{
 						&seqExpr{
-							pos: position{line: 525, col: 14, offset: 16256},
+							pos: position{line: 663, col: 14, offset: 21050},
 							exprs: []interface{}{
 								&litMatcher{
-									pos:        position{line: 525, col: 14, offset: 16256},
+									pos:        position{line: 663, col: 14, offset: 21050},
 									val:        "\"",
 									ignoreCase: false,
 								},
 								&zeroOrMoreExpr{
-									pos: position{line: 525, col: 18, offset: 16260},
+									pos: position{line: 663, col: 18, offset: 21054},
 									expr: &choiceExpr{
-										pos: position{line: 525, col: 19, offset: 16261},
+										pos: position{line: 663, col: 19, offset: 21055},
 										alternatives: []interface{}{
 											&litMatcher{
-												pos:        position{line: 525, col: 19, offset: 16261},
+												pos:        position{line: 663, col: 19, offset: 21055},
 												val:        "\\\"",
 												ignoreCase: false,
 											},
 											&charClassMatcher{
-												pos:        position{line: 525, col: 26, offset: 16268},
+												pos:        position{line: 663, col: 26, offset: 21062},
 												val:        "[^\"]",
 												chars:      []rune{'"'},
 												ignoreCase: false,
@@ -2499,32 +3056,32 @@ var g = &grammar{
 									},
 								},
 								&litMatcher{
-									pos:        position{line: 525, col: 33, offset: 16275},
+									pos:        position{line: 663, col: 33, offset: 21069},
 									val:        "\"",
 									ignoreCase: false,
 								},
 							},
 						},
 						&seqExpr{
-							pos: position{line: 525, col: 41, offset: 16283},
+							pos: position{line: 663, col: 41, offset: 21077},
 							exprs: []interface{}{
 								&litMatcher{
-									pos:        position{line: 525, col: 41, offset: 16283},
+									pos:        position{line: 663, col: 41, offset: 21077},
 									val:        "'",
 									ignoreCase: false,
 								},
 								&zeroOrMoreExpr{
-									pos: position{line: 525, col: 46, offset: 16288},
+									pos: position{line: 663, col: 46, offset: 21082},
 									expr: &choiceExpr{
-										pos: position{line: 525, col: 47, offset: 16289},
+										pos: position{line: 663, col: 47, offset: 21083},
 										alternatives: []interface{}{
 											&litMatcher{
-												pos:        position{line: 525, col: 47, offset: 16289},
+												pos:        position{line: 663, col: 47, offset: 21083},
 												val:        "\\'",
 												ignoreCase: false,
 											},
 											&charClassMatcher{
-												pos:        position{line: 525, col: 54, offset: 16296},
+												pos:        position{line: 663, col: 54, offset: 21090},
 												val:        "[^']",
 												chars:      []rune{'\''},
 												ignoreCase: false,
@@ -2534,7 +3091,7 @@ var g = &grammar{
 									},
 								},
 								&litMatcher{
-									pos:        position{line: 525, col: 61, offset: 16303},
+									pos:        position{line: 663, col: 61, offset: 21097},
 									val:        "'",
 									ignoreCase: false,
 								},
@@ -2546,24 +3103,24 @@ var g = &grammar{
 		},
 		{
 			name: "Identifier",
-			pos:  position{line: 534, col: 1, offset: 16589},
+			pos:  position{line: 672, col: 1, offset: 21383},
 			expr: &actionExpr{
-				pos: position{line: 534, col: 15, offset: 16603},
+				pos: position{line: 672, col: 15, offset: 21397},
 				run: (*parser).callonIdentifier1,
 				expr: &seqExpr{
-					pos: position{line: 534, col: 15, offset: 16603},
+					pos: position{line: 672, col: 15, offset: 21397},
 					exprs: []interface{}{
 						&oneOrMoreExpr{
-							pos: position{line: 534, col: 15, offset: 16603},
+							pos: position{line: 672, col: 15, offset: 21397},
 							expr: &choiceExpr{
-								pos: position{line: 534, col: 16, offset: 16604},
+								pos: position{line: 672, col: 16, offset: 21398},
 								alternatives: []interface{}{
 									&ruleRefExpr{
-										pos:  position{line: 534, col: 16, offset: 16604},
+										pos:  position{line: 672, col: 16, offset: 21398},
 										name: "Letter",
 									},
 									&litMatcher{
-										pos:        position{line: 534, col: 25, offset: 16613},
+										pos:        position{line: 672, col: 25, offset: 21407},
 										val:        "_",
 										ignoreCase: false,
 									},
@@ -2571,20 +3128,20 @@ var g = &grammar{
 							},
 						},
 						&zeroOrMoreExpr{
-							pos: position{line: 534, col: 31, offset: 16619},
+							pos: position{line: 672, col: 31, offset: 21413},
 							expr: &choiceExpr{
-								pos: position{line: 534, col: 32, offset: 16620},
+								pos: position{line: 672, col: 32, offset: 21414},
 								alternatives: []interface{}{
 									&ruleRefExpr{
-										pos:  position{line: 534, col: 32, offset: 16620},
+										pos:  position{line: 672, col: 32, offset: 21414},
 										name: "Letter",
 									},
 									&ruleRefExpr{
-										pos:  position{line: 534, col: 41, offset: 16629},
+										pos:  position{line: 672, col: 41, offset: 21423},
 										name: "Digit",
 									},
 									&charClassMatcher{
-										pos:        position{line: 534, col: 49, offset: 16637},
+										pos:        position{line: 672, col: 49, offset: 21431},
 										val:        "[._]",
 										chars:      []rune{'.', '_'},
 										ignoreCase: false,
@@ -2597,11 +3154,24 @@ var g = &grammar{
 				},
 			},
 		},
+		{
+			name: "Mark",
+			pos:  position{line: 678, col: 1, offset: 21621},
+			expr: &actionExpr{
+				pos: position{line: 678, col: 9, offset: 21629},
+				run: (*parser).callonMark1,
+				expr: &litMatcher{
+					pos:        position{line: 678, col: 9, offset: 21629},
+					val:        "",
+					ignoreCase: false,
+				},
+			},
+		},
 		{
 			name: "ListSeparator",
-			pos:  position{line: 538, col: 1, offset: 16692},
+			pos:  position{line: 682, col: 1, offset: 21716},
 			expr: &charClassMatcher{
-				pos:        position{line: 538, col: 18, offset: 16709},
+				pos:        position{line: 682, col: 18, offset: 21733},
 				val:        "[,;]",
 				chars:      []rune{',', ';'},
 				ignoreCase: false,
@@ -2610,9 +3180,9 @@ var g = &grammar{
 		},
 		{
 			name: "Letter",
-			pos:  position{line: 539, col: 1, offset: 16714},
+			pos:  position{line: 683, col: 1, offset: 21738},
 			expr: &charClassMatcher{
-				pos:        position{line: 539, col: 11, offset: 16724},
+				pos:        position{line: 683, col: 11, offset: 21748},
 				val:        "[A-Za-z]",
 				ranges:     []rune{'A', 'Z', 'a', 'z'},
 				ignoreCase: false,
@@ -2621,9 +3191,9 @@ var g = &grammar{
 		},
 		{
 			name: "Digit",
-			pos:  position{line: 540, col: 1, offset: 16733},
+			pos:  position{line: 684, col: 1, offset: 21757},
 			expr: &charClassMatcher{
-				pos:        position{line: 540, col: 10, offset: 16742},
+				pos:        position{line: 684, col: 10, offset: 21766},
 				val:        "[0-9]",
 				ranges:     []rune{'0', '9'},
 				ignoreCase: false,
@@ -2632,47 +3202,47 @@ var g = &grammar{
 		},
 		{
 			name: "SourceChar",
-			pos:  position{line: 542, col: 1, offset: 16749},
+			pos:  position{line: 686, col: 1, offset: 21773},
 			expr: &anyMatcher{
-				line: 542, col: 15, offset: 16763,
+				line: 686, col: 15, offset: 21787,
 			},
 		},
 		{
 			name: "DocString",
-			pos:  position{line: 543, col: 1, offset: 16765},
+			pos:  position{line: 687, col: 1, offset: 21789},
 			expr: &actionExpr{
-				pos: position{line: 543, col: 14, offset: 16778},
+				pos: position{line: 687, col: 14, offset: 21802},
 				run: (*parser).callonDocString1,
 				expr: &seqExpr{
-					pos: position{line: 543, col: 14, offset: 16778},
+					pos: position{line: 687, col: 14, offset: 21802},
 					exprs: []interface{}{
 						&litMatcher{
-							pos:        position{line: 543, col: 14, offset: 16778},
+							pos:        position{line: 687, col: 14, offset: 21802},
 							val:        "/**@",
 							ignoreCase: false,
 						},
 						&zeroOrMoreExpr{
-							pos: position{line: 543, col: 21, offset: 16785},
+							pos: position{line: 687, col: 21, offset: 21809},
 							expr: &seqExpr{
-								pos: position{line: 543, col: 23, offset: 16787},
+								pos: position{line: 687, col: 23, offset: 21811},
 								exprs: []interface{}{
 									&notExpr{
-										pos: position{line: 543, col: 23, offset: 16787},
+										pos: position{line: 687, col: 23, offset: 21811},
 										expr: &litMatcher{
-											pos:        position{line: 543, col: 24, offset: 16788},
+											pos:        position{line: 687, col: 24, offset: 21812},
 											val:        "*/",
 											ignoreCase: false,
 										},
 									},
 									&ruleRefExpr{
-										pos:  position{line: 543, col: 29, offset: 16793},
+										pos:  position{line: 687, col: 29, offset: 21817},
 										name: "SourceChar",
 									},
 								},
 							},
 						},
 						&litMatcher{
-							pos:        position{line: 543, col: 43, offset: 16807},
+							pos:        position{line: 687, col: 43, offset: 21831},
 							val:        "*/",
 							ignoreCase: false,
 						},
@@ -2682,16 +3252,16 @@ var g = &grammar{
 		},
 		{
 			name: "Comment",
-			pos:  position{line: 549, col: 1, offset: 16987},
+			pos:  position{line: 693, col: 1, offset: 22011},
 			expr: &choiceExpr{
-				pos: position{line: 549, col: 12, offset: 16998},
+				pos: position{line: 693, col: 12, offset: 22022},
 				alternatives: []interface{}{
 					&ruleRefExpr{
-						pos:  position{line: 549, col: 12, offset: 16998},
+						pos:  position{line: 693, col: 12, offset: 22022},
 						name: "MultiLineComment",
 					},
 					&ruleRefExpr{
-						pos:  position{line: 549, col: 31, offset: 17017},
+						pos:  position{line: 693, col: 31, offset: 22041},
 						name: "SingleLineComment",
 					},
 				},
@@ -2699,44 +3269,44 @@ var g = &grammar{
 		},
 		{
 			name: "MultiLineComment",
-			pos:  position{line: 550, col: 1, offset: 17035},
+			pos:  position{line: 694, col: 1, offset: 22059},
 			expr: &seqExpr{
-				pos: position{line: 550, col: 21, offset: 17055},
+				pos: position{line: 694, col: 21, offset: 22079},
 				exprs: []interface{}{
 					&notExpr{
-						pos: position{line: 550, col: 21, offset: 17055},
+						pos: position{line: 694, col: 21, offset: 22079},
 						expr: &ruleRefExpr{
-							pos:  position{line: 550, col: 22, offset: 17056},
+							pos:  position{line: 694, col: 22, offset: 22080},
 							name: "DocString",
 						},
 					},
 					&litMatcher{
-						pos:        position{line: 550, col: 32, offset: 17066},
+						pos:        position{line: 694, col: 32, offset: 22090},
 						val:        "/*",
 						ignoreCase: false,
 					},
 					&zeroOrMoreExpr{
-						pos: position{line: 550, col: 37, offset: 17071},
+						pos: position{line: 694, col: 37, offset: 22095},
 						expr: &seqExpr{
-							pos: position{line: 550, col: 39, offset: 17073},
+							pos: position{line: 694, col: 39, offset: 22097},
 							exprs: []interface{}{
 								&notExpr{
-									pos: position{line: 550, col: 39, offset: 17073},
+									pos: position{line: 694, col: 39, offset: 22097},
 									expr: &litMatcher{
-										pos:        position{line: 550, col: 40, offset: 17074},
+										pos:        position{line: 694, col: 40, offset: 22098},
 										val:        "*/",
 										ignoreCase: false,
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 550, col: 45, offset: 17079},
+									pos:  position{line: 694, col: 45, offset: 22103},
 									name: "SourceChar",
 								},
 							},
 						},
 					},
 					&litMatcher{
-						pos:        position{line: 550, col: 59, offset: 17093},
+						pos:        position{line: 694, col: 59, offset: 22117},
 						val:        "*/",
 						ignoreCase: false,
 					},
@@ -2745,53 +3315,53 @@ var g = &grammar{
 		},
 		{
 			name: "MultiLineCommentNoLineTerminator",
-			pos:  position{line: 551, col: 1, offset: 17098},
+			pos:  position{line: 695, col: 1, offset: 22122},
 			expr: &seqExpr{
-				pos: position{line: 551, col: 37, offset: 17134},
+				pos: position{line: 695, col: 37, offset: 22158},
 				exprs: []interface{}{
 					&notExpr{
-						pos: position{line: 551, col: 37, offset: 17134},
+						pos: position{line: 695, col: 37, offset: 22158},
 						expr: &ruleRefExpr{
-							pos:  position{line: 551, col: 38, offset: 17135},
+							pos:  position{line: 695, col: 38, offset: 22159},
 							name: "DocString",
 						},
 					},
 					&litMatcher{
-						pos:        position{line: 551, col: 48, offset: 17145},
+						pos:        position{line: 695, col: 48, offset: 22169},
 						val:        "/*",
 						ignoreCase: false,
 					},
 					&zeroOrMoreExpr{
-						pos: position{line: 551, col: 53, offset: 17150},
+						pos: position{line: 695, col: 53, offset: 22174},
 						expr: &seqExpr{
-							pos: position{line: 551, col: 55, offset: 17152},
+							pos: position{line: 695, col: 55, offset: 22176},
 							exprs: []interface{}{
 								&notExpr{
-									pos: position{line: 551, col: 55, offset: 17152},
+									pos: position{line: 695, col: 55, offset: 22176},
 									expr: &choiceExpr{
-										pos: position{line: 551, col: 58, offset: 17155},
+										pos: position{line: 695, col: 58, offset: 22179},
 										alternatives: []interface{}{
 											&litMatcher{
-												pos:        position{line: 551, col: 58, offset: 17155},
+												pos:        position{line: 695, col: 58, offset: 22179},
 												val:        "*/",
 												ignoreCase: false,
 											},
 											&ruleRefExpr{
-												pos:  position{line: 551, col: 65, offset: 17162},
+												pos:  position{line: 695, col: 65, offset: 22186},
 												name: "EOL",
 											},
 										},
 									},
 								},
 								&ruleRefExpr{
-									pos:  position{line: 551, col: 71, offset: 17168},
+									pos:  position{line: 695, col: 71, offset: 22192},
 									name: "SourceChar",
 								},
 							},
 						},
 					},
 					&litMatcher{
-						pos:        position{line: 551, col: 85, offset: 17182},
+						pos:        position{line: 695, col: 85, offset: 22206},
 						val:        "*/",
 						ignoreCase: false,
 					},
@@ -2800,32 +3370,32 @@ var g = &grammar{
 		},
 		{
 			name: "SingleLineComment",
-			pos:  position{line: 552, col: 1, offset: 17187},
+			pos:  position{line: 696, col: 1, offset: 22211},
 			expr: &choiceExpr{
-				pos: position{line: 552, col: 22, offset: 17208},
+				pos: position{line: 696, col: 22, offset: 22232},
 				alternatives: []interface{}{
 					&seqExpr{
-						pos: position{line: 552, col: 23, offset: 17209},
+						pos: position{line: 696, col: 23, offset: 22233},
 						exprs: []interface{}{
 							&litMatcher{
-								pos:        position{line: 552, col: 23, offset: 17209},
+								pos:        position{line: 696, col: 23, offset: 22233},
 								val:        "//",
 								ignoreCase: false,
 							},
 							&zeroOrMoreExpr{
-								pos: position{line: 552, col: 28, offset: 17214},
+								pos: position{line: 696, col: 28, offset: 22238},
 								expr: &seqExpr{
-									pos: position{line: 552, col: 30, offset: 17216},
+									pos: position{line: 696, col: 30, offset: 22240},
 									exprs: []interface{}{
 										&notExpr{
-											pos: position{line: 552, col: 30, offset: 17216},
+											pos: position{line: 696, col: 30, offset: 22240},
 											expr: &ruleRefExpr{
-												pos:  position{line: 552, col: 31, offset: 17217},
+												pos:  position{line: 696, col: 31, offset: 22241},
 												name: "EOL",
 											},
 										},
 										&ruleRefExpr{
-											pos:  position{line: 552, col: 35, offset: 17221},
+											pos:  position{line: 696, col: 35, offset: 22245},
 											name: "SourceChar",
 										},
 									},
@@ -2834,27 +3404,27 @@ var g = &grammar{
 						},
 					},
 					&seqExpr{
-						pos: position{line: 552, col: 53, offset: 17239},
+						pos: position{line: 696, col: 53, offset: 22263},
 						exprs: []interface{}{
 							&litMatcher{
-								pos:        position{line: 552, col: 53, offset: 17239},
+								pos:        position{line: 696, col: 53, offset: 22263},
 								val:        "#",
 								ignoreCase: false,
 							},
 							&zeroOrMoreExpr{
-								pos: position{line: 552, col: 57, offset: 17243},
+								pos: position{line: 696, col: 57, offset: 22267},
 								expr: &seqExpr{
-									pos: position{line: 552, col: 59, offset: 17245},
+									pos: position{line: 696, col: 59, offset: 22269},
 									exprs: []interface{}{
 										&notExpr{
-											pos: position{line: 552, col: 59, offset: 17245},
+											pos: position{line: 696, col: 59, offset: 22269},
 											expr: &ruleRefExpr{
-												pos:  position{line: 552, col: 60, offset: 17246},
+												pos:  position{line: 696, col: 60, offset: 22270},
 												name: "EOL",
 											},
 										},
 										&ruleRefExpr{
-											pos:  position{line: 552, col: 64, offset: 17250},
+											pos:  position{line: 696, col: 64, offset: 22274},
 											name: "SourceChar",
 										},
 									},
@@ -2867,22 +3437,22 @@ var g = &grammar{
 		},
 		{
 			name: "__",
-			pos:  position{line: 554, col: 1, offset: 17266},
+			pos:  position{line: 698, col: 1, offset: 22290},
 			expr: &zeroOrMoreExpr{
-				pos: position{line: 554, col: 7, offset: 17272},
+				pos: position{line: 698, col: 7, offset: 22296},
 				expr: &choiceExpr{
-					pos: position{line: 554, col: 9, offset: 17274},
+					pos: position{line: 698, col: 9, offset: 22298},
 					alternatives: []interface{}{
 						&ruleRefExpr{
-							pos:  position{line: 554, col: 9, offset: 17274},
+							pos:  position{line: 698, col: 9, offset: 22298},
 							name: "Whitespace",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 554, col: 22, offset: 17287},
+							pos:  position{line: 698, col: 22, offset: 22311},
 							name: "EOL",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 554, col: 28, offset: 17293},
+							pos:  position{line: 698, col: 28, offset: 22317},
 							name: "Comment",
 						},
 					},
@@ -2891,18 +3461,18 @@ var g = &grammar{
 		},
 		{
 			name: "_",
-			pos:  position{line: 555, col: 1, offset: 17304},
+			pos:  position{line: 699, col: 1, offset: 22328},
 			expr: &zeroOrMoreExpr{
-				pos: position{line: 555, col: 6, offset: 17309},
+				pos: position{line: 699, col: 6, offset: 22333},
 				expr: &choiceExpr{
-					pos: position{line: 555, col: 8, offset: 17311},
+					pos: position{line: 699, col: 8, offset: 22335},
 					alternatives: []interface{}{
 						&ruleRefExpr{
-							pos:  position{line: 555, col: 8, offset: 17311},
+							pos:  position{line: 699, col: 8, offset: 22335},
 							name: "Whitespace",
 						},
 						&ruleRefExpr{
-							pos:  position{line: 555, col: 21, offset: 17324},
+							pos:  position{line: 699, col: 21, offset: 22348},
 							name: "MultiLineCommentNoLineTerminator",
 						},
 					},
@@ -2911,20 +3481,20 @@ var g = &grammar{
 		},
 		{
 			name: "WS",
-			pos:  position{line: 556, col: 1, offset: 17360},
+			pos:  position{line: 700, col: 1, offset: 22384},
 			expr: &zeroOrMoreExpr{
-				pos: position{line: 556, col: 7, offset: 17366},
+				pos: position{line: 700, col: 7, offset: 22390},
 				expr: &ruleRefExpr{
-					pos:  position{line: 556, col: 7, offset: 17366},
+					pos:  position{line: 700, col: 7, offset: 22390},
 					name: "Whitespace",
 				},
 			},
 		},
 		{
 			name: "Whitespace",
-			pos:  position{line: 558, col: 1, offset: 17379},
+			pos:  position{line: 702, col: 1, offset: 22403},
 			expr: &charClassMatcher{
-				pos:        position{line: 558, col: 15, offset: 17393},
+				pos:        position{line: 702, col: 15, offset: 22417},
 				val:        "[ \\t\\r]",
 				chars:      []rune{' ', '\t', '\r'},
 				ignoreCase: false,
@@ -2933,62 +3503,62 @@ var g = &grammar{
 		},
 		{
 			name: "EOL",
-			pos:  position{line: 559, col: 1, offset: 17401},
+			pos:  position{line: 703, col: 1, offset: 22425},
 			expr: &litMatcher{
-				pos:        position{line: 559, col: 8, offset: 17408},
+				pos:        position{line: 703, col: 8, offset: 22432},
 				val:        "\n",
 				ignoreCase: false,
 			},
 		},
 		{
 			name: "EOS",
-			pos:  position{line: 560, col: 1, offset: 17413},
+			pos:  position{line: 704, col: 1, offset: 22437},
 			expr: &choiceExpr{
-				pos: position{line: 560, col: 8, offset: 17420},
+				pos: position{line: 704, col: 8, offset: 22444},
 				alternatives: []interface{}{
 					&seqExpr{
-						pos: position{line: 560, col: 8, offset: 17420},
+						pos: position{line: 704, col: 8, offset: 22444},
 						exprs: []interface{}{
 							&ruleRefExpr{
-								pos:  position{line: 560, col: 8, offset: 17420},
+								pos:  position{line: 704, col: 8, offset: 22444},
 								name: "__",
 							},
 							&litMatcher{
-								pos:        position{line: 560, col: 11, offset: 17423},
+								pos:        position{line: 704, col: 11, offset: 22447},
 								val:        ";",
 								ignoreCase: false,
 							},
 						},
 					},
 					&seqExpr{
-						pos: position{line: 560, col: 17, offset: 17429},
+						pos: position{line: 704, col: 17, offset: 22453},
 						exprs: []interface{}{
 							&ruleRefExpr{
-								pos:  position{line: 560, col: 17, offset: 17429},
+								pos:  position{line: 704, col: 17, offset: 22453},
 								name: "_",
 							},
 							&zeroOrOneExpr{
-								pos: position{line: 560, col: 19, offset: 17431},
+								pos: position{line: 704, col: 19, offset: 22455},
 								expr: &ruleRefExpr{
-									pos:  position{line: 560, col: 19, offset: 17431},
+									pos:  position{line: 704, col: 19, offset: 22455},
 									name: "SingleLineComment",
 								},
 							},
 							&ruleRefExpr{
-								pos:  position{line: 560, col: 38, offset: 17450},
+								pos:  position{line: 704, col: 38, offset: 22474},
 								name: "EOL",
 							},
 						},
 					},
 					&seqExpr{
-						pos: position{line: 560, col: 44, offset: 17456},
+						pos: position{line: 704, col: 44, offset: 22480},
 						exprs: []interface{}{
 							&ruleRefExpr{
-								pos:  position{line: 560, col: 44, offset: 17456},
+								pos:  position{line: 704, col: 44, offset: 22480},
 								name: "__",
 							},
 							&ruleRefExpr{
-								pos:  position{line: 560, col: 47, offset: 17459},
+								pos:  position{line: 704, col: 47, offset: 22483},
 								name: "EOF",
 							},
 						},
@@ -2998,11 +3568,11 @@ var g = &grammar{
 		},
 		{
 			name: "EOF",
-			pos:  position{line: 562, col: 1, offset: 17464},
+			pos:  position{line: 706, col: 1, offset: 22488},
 			expr: &notExpr{
-				pos: position{line: 562, col: 8, offset: 17471},
+				pos: position{line: 706, col: 8, offset: 22495},
 				expr: &anyMatcher{
-					line: 562, col: 9, offset: 17472,
+					line: 706, col: 9, offset: 22496,
 				},
 			},
 		},
@@ -3028,7 +3598,11 @@ func (c *current) onGrammar1(statements interface{}) (interface{}, error) {
 	}
 
 	for _, st := range stmts {
-		wrapper := st.([]interface{})[0].(*statementWrapper)
+		wrapper, ok := st.([]interface{})[0].(*statementWrapper)
+		if !ok {
+			// Statements which failed to parse have already been reported.
+			continue
+		}
 		switch v := wrapper.statement.(type) {
 		case *Namespace:
 			frugal.Namespaces = append(frugal.Namespaces, v)
@@ -3081,7 +3655,7 @@ func (p *parser) callonGrammar1() (interface{}, error) {
 }
 
 func (c *current) onSyntaxError1() (interface{}, error) {
-	return nil, errors.New("parser: syntax error")
+	return nil, c.syntaxError("parser: syntax error")
 }
 
 func (p *parser) callonSyntaxError1() (interface{}, error) {
@@ -3105,7 +3679,7 @@ func (p *parser) callonStatement1() (interface{}, error) {
 	return p.cur.onStatement1(stack["docstr"], stack["statement"])
 }
 
-func (c *current) onInclude1(file, annotations interface{}) (interface{}, error) {
+func (c *current) onInclude1(file, annotations, end interface{}) (interface{}, error) {
 	name := filepath.Base(file.(string))
 	if ix := strings.LastIndex(name, "."); ix > 0 {
 		name = name[:ix]
@@ -3114,50 +3688,54 @@ func (c *current) onInclude1(file, annotations interface{}) (interface{}, error)
 		Name:        name,
 		Value:       file.(string),
 		Annotations: toAnnotations(annotations),
+		Pos:         c.nodePosBetween(nil, end),
 	}, nil
 }
 
 func (p *parser) callonInclude1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onInclude1(stack["file"], stack["annotations"])
+	return p.cur.onInclude1(stack["file"], stack["annotations"], stack["end"])
 }
 
-func (c *current) onNamespace1(scope, ns, annotations interface{}) (interface{}, error) {
+func (c *current) onNamespace1(scope, ns, annotations, end interface{}) (interface{}, error) {
 	return &Namespace{
 		Scope:       ifaceSliceToString(scope),
 		Value:       string(ns.(Identifier)),
 		Annotations: toAnnotations(annotations),
+		Pos:         c.nodePosBetween(nil, end),
 	}, nil
 }
 
 func (p *parser) callonNamespace1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onNamespace1(stack["scope"], stack["ns"], stack["annotations"])
+	return p.cur.onNamespace1(stack["scope"], stack["ns"], stack["annotations"], stack["end"])
 }
 
-func (c *current) onConst1(typ, name, value, annotations interface{}) (interface{}, error) {
+func (c *current) onConst1(typ, name, value, annotations, end interface{}) (interface{}, error) {
 	return &Constant{
 		Name:        string(name.(Identifier)),
 		Type:        typ.(*Type),
 		Value:       value,
 		Annotations: toAnnotations(annotations),
+		Pos:         c.nodePosBetween(nil, end),
 	}, nil
 }
 
 func (p *parser) callonConst1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onConst1(stack["typ"], stack["name"], stack["value"], stack["annotations"])
+	return p.cur.onConst1(stack["typ"], stack["name"], stack["value"], stack["annotations"], stack["end"])
 }
 
-func (c *current) onEnum1(name, values, annotations interface{}) (interface{}, error) {
+func (c *current) onEnum1(name, values, annotations, end interface{}) (interface{}, error) {
 	vs := toIfaceSlice(values)
 	en := &Enum{
 		Name:        string(name.(Identifier)),
 		Values:      make([]*EnumValue, len(vs)),
 		Annotations: toAnnotations(annotations),
+		Pos:         c.nodePosBetween(nil, end),
 	}
 	// Assigns numbers in order. This will behave badly if some values are
 	// defined and other are not, but I think that's ok since that's a silly
@@ -3179,14 +3757,25 @@ func (c *current) onEnum1(name, values, annotations interface{}) (interface{}, e
 func (p *parser) callonEnum1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onEnum1(stack["name"], stack["values"], stack["annotations"])
+	return p.cur.onEnum1(stack["name"], stack["values"], stack["annotations"], stack["end"])
+}
+
+func (c *current) onEndOfEnumError1() (interface{}, error) {
+	return nil, c.syntaxError("parser: expected end of enum")
 }
 
-func (c *current) onEnumValue1(docstr, name, value, annotations interface{}) (interface{}, error) {
+func (p *parser) callonEndOfEnumError1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onEndOfEnumError1()
+}
+
+func (c *current) onEnumValue1(docstr, start, name, value, annotations interface{}) (interface{}, error) {
 	ev := &EnumValue{
 		Name:        string(name.(Identifier)),
 		Value:       -1,
 		Annotations: toAnnotations(annotations),
+		Pos:         c.nodePosBetween(start, nil),
 	}
 	if docstr != nil {
 		raw := docstr.([]interface{})[0].(string)
@@ -3194,6 +3783,8 @@ func (c *current) onEnumValue1(docstr, name, value, annotations interface{}) (in
 	}
 	if value != nil {
 		ev.Value = int(value.([]interface{})[2].(int64))
+	} else {
+		ev.Implicit = true
 	}
 	return ev, nil
 }
@@ -3201,25 +3792,28 @@ func (c *current) onEnumValue1(docstr, name, value, annotations interface{}) (in
 func (p *parser) callonEnumValue1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onEnumValue1(stack["docstr"], stack["name"], stack["value"], stack["annotations"])
+	return p.cur.onEnumValue1(stack["docstr"], stack["start"], stack["name"], stack["value"], stack["annotations"])
 }
 
-func (c *current) onTypeDef1(typ, name, annotations interface{}) (interface{}, error) {
+func (c *current) onTypeDef1(typ, name, annotations, end interface{}) (interface{}, error) {
 	return &TypeDef{
 		Name:        string(name.(Identifier)),
 		Type:        typ.(*Type),
 		Annotations: toAnnotations(annotations),
+		Pos:         c.nodePosBetween(nil, end),
 	}, nil
 }
 
 func (p *parser) callonTypeDef1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onTypeDef1(stack["typ"], stack["name"], stack["annotations"])
+	return p.cur.onTypeDef1(stack["typ"], stack["name"], stack["annotations"], stack["end"])
 }
 
 func (c *current) onStruct1(st interface{}) (interface{}, error) {
-	return st.(*Struct), nil
+	s := st.(*Struct)
+	s.Pos = c.structPos(s)
+	return s, nil
 }
 
 func (p *parser) callonStruct1() (interface{}, error) {
@@ -3229,7 +3823,9 @@ func (p *parser) callonStruct1() (interface{}, error) {
 }
 
 func (c *current) onException1(st interface{}) (interface{}, error) {
-	return exception(st.(*Struct)), nil
+	s := st.(*Struct)
+	s.Pos = c.structPos(s)
+	return exception(s), nil
 }
 
 func (p *parser) callonException1() (interface{}, error) {
@@ -3239,7 +3835,9 @@ func (p *parser) callonException1() (interface{}, error) {
 }
 
 func (c *current) onUnion1(st interface{}) (interface{}, error) {
-	return union(st.(*Struct)), nil
+	s := st.(*Struct)
+	s.Pos = c.structPos(s)
+	return union(s), nil
 }
 
 func (p *parser) callonUnion1() (interface{}, error) {
@@ -3248,10 +3846,11 @@ func (p *parser) callonUnion1() (interface{}, error) {
 	return p.cur.onUnion1(stack["st"])
 }
 
-func (c *current) onStructLike1(name, fields, annotations interface{}) (interface{}, error) {
+func (c *current) onStructLike1(name, fields, annotations, end interface{}) (interface{}, error) {
 	st := &Struct{
 		Name:        string(name.(Identifier)),
 		Annotations: toAnnotations(annotations),
+		Pos:         c.nodePosBetween(nil, end),
 	}
 	if fields != nil {
 		st.Fields = fields.([]*Field)
@@ -3262,7 +3861,17 @@ func (c *current) onStructLike1(name, fields, annotations interface{}) (interfac
 func (p *parser) callonStructLike1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onStructLike1(stack["name"], stack["fields"], stack["annotations"])
+	return p.cur.onStructLike1(stack["name"], stack["fields"], stack["annotations"], stack["end"])
+}
+
+func (c *current) onEndOfStructError1() (interface{}, error) {
+	return nil, c.syntaxError("parser: expected end of struct")
+}
+
+func (p *parser) callonEndOfStructError1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onEndOfStructError1()
 }
 
 func (c *current) onFieldList1(fields interface{}) (interface{}, error) {
@@ -3280,12 +3889,13 @@ func (p *parser) callonFieldList1() (interface{}, error) {
 	return p.cur.onFieldList1(stack["fields"])
 }
 
-func (c *current) onField1(docstr, id, mod, typ, name, def, annotations interface{}) (interface{}, error) {
+func (c *current) onField1(docstr, start, id, mod, typ, name, def, annotations interface{}) (interface{}, error) {
 	f := &Field{
 		ID:          int(id.(int64)),
 		Name:        string(name.(Identifier)),
 		Type:        typ.(*Type),
 		Annotations: toAnnotations(annotations),
+		Pos:         c.nodePosBetween(start, nil),
 	}
 	if docstr != nil {
 		raw := docstr.([]interface{})[0].(string)
@@ -3306,7 +3916,7 @@ func (c *current) onField1(docstr, id, mod, typ, name, def, annotations interfac
 func (p *parser) callonField1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onField1(stack["docstr"], stack["id"], stack["mod"], stack["typ"], stack["name"], stack["def"], stack["annotations"])
+	return p.cur.onField1(stack["docstr"], stack["start"], stack["id"], stack["mod"], stack["typ"], stack["name"], stack["def"], stack["annotations"])
 }
 
 func (c *current) onFieldModifier1() (interface{}, error) {
@@ -3323,12 +3933,13 @@ func (p *parser) callonFieldModifier1() (interface{}, error) {
 	return p.cur.onFieldModifier1()
 }
 
-func (c *current) onService1(name, extends, methods, annotations interface{}) (interface{}, error) {
+func (c *current) onService1(name, extends, methods, annotations, end interface{}) (interface{}, error) {
 	ms := methods.([]interface{})
 	svc := &Service{
 		Name:        string(name.(Identifier)),
 		Methods:     make([]*Method, len(ms)),
 		Annotations: toAnnotations(annotations),
+		Pos:         c.nodePosBetween(nil, end),
 	}
 	if extends != nil {
 		svc.Extends = string(extends.([]interface{})[2].(Identifier))
@@ -3343,11 +3954,11 @@ func (c *current) onService1(name, extends, methods, annotations interface{}) (i
 func (p *parser) callonService1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onService1(stack["name"], stack["extends"], stack["methods"], stack["annotations"])
+	return p.cur.onService1(stack["name"], stack["extends"], stack["methods"], stack["annotations"], stack["end"])
 }
 
 func (c *current) onEndOfServiceError1() (interface{}, error) {
-	return nil, errors.New("parser: expected end of service")
+	return nil, c.syntaxError("parser: expected end of service")
 }
 
 func (p *parser) callonEndOfServiceError1() (interface{}, error) {
@@ -3356,18 +3967,24 @@ func (p *parser) callonEndOfServiceError1() (interface{}, error) {
 	return p.cur.onEndOfServiceError1()
 }
 
-func (c *current) onFunction1(docstr, oneway, typ, name, arguments, exceptions, annotations interface{}) (interface{}, error) {
+func (c *current) onFunction1(docstr, start, oneway, typ, name, arguments, requestStream, exceptions, annotations interface{}) (interface{}, error) {
 	m := &Method{
 		Name:        string(name.(Identifier)),
 		Annotations: toAnnotations(annotations),
+		Pos:         c.nodePosBetween(start, nil),
 	}
 	if docstr != nil {
 		raw := docstr.([]interface{})[0].(string)
 		m.Comment = rawCommentToDocStr(raw)
 	}
-	t := typ.(*Type)
-	if t.Name != "void" {
-		m.ReturnType = t
+	switch t := typ.(type) {
+	case streamType:
+		m.ReturnType = (*Type)(t)
+		m.StreamingResponse = true
+	case *Type:
+		if t.Name != "void" {
+			m.ReturnType = t
+		}
 	}
 	if oneway != nil {
 		m.Oneway = true
@@ -3375,6 +3992,9 @@ func (c *current) onFunction1(docstr, oneway, typ, name, arguments, exceptions,
 	if arguments != nil {
 		m.Arguments = arguments.([]*Field)
 	}
+	if requestStream != nil {
+		m.RequestStream = requestStream.(*Field)
+	}
 	if exceptions != nil {
 		m.Exceptions = exceptions.([]*Field)
 		for _, e := range m.Exceptions {
@@ -3387,14 +4007,17 @@ func (c *current) onFunction1(docstr, oneway, typ, name, arguments, exceptions,
 func (p *parser) callonFunction1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onFunction1(stack["docstr"], stack["oneway"], stack["typ"], stack["name"], stack["arguments"], stack["exceptions"], stack["annotations"])
+	return p.cur.onFunction1(stack["docstr"], stack["start"], stack["oneway"], stack["typ"], stack["name"], stack["arguments"], stack["requestStream"], stack["exceptions"], stack["annotations"])
 }
 
 func (c *current) onFunctionType1(typ interface{}) (interface{}, error) {
-	if t, ok := typ.(*Type); ok {
+	switch t := typ.(type) {
+	case streamType:
+		return t, nil
+	case *Type:
 		return t, nil
 	}
-	return &Type{Name: string(c.text)}, nil
+	return &Type{Name: string(c.text), Pos: c.nodePos()}, nil
 }
 
 func (p *parser) callonFunctionType1() (interface{}, error) {
@@ -3403,6 +4026,38 @@ func (p *parser) callonFunctionType1() (interface{}, error) {
 	return p.cur.onFunctionType1(stack["typ"])
 }
 
+func (c *current) onStreamType1(typ interface{}) (interface{}, error) {
+	return streamType(typ.(*Type)), nil
+}
+
+func (p *parser) callonStreamType1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onStreamType1(stack["typ"])
+}
+
+func (c *current) onRequestStreamField1(docstr, start, id, typ, name, annotations interface{}) (interface{}, error) {
+	f := &Field{
+		ID:          int(id.(int64)),
+		Name:        string(name.(Identifier)),
+		Type:        typ.(*Type),
+		Modifier:    Optional,
+		Annotations: toAnnotations(annotations),
+		Pos:         c.nodePosBetween(start, nil),
+	}
+	if docstr != nil {
+		raw := docstr.([]interface{})[0].(string)
+		f.Comment = rawCommentToDocStr(raw)
+	}
+	return f, nil
+}
+
+func (p *parser) callonRequestStreamField1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onRequestStreamField1(stack["docstr"], stack["start"], stack["id"], stack["typ"], stack["name"], stack["annotations"])
+}
+
 func (c *current) onThrows1(exceptions interface{}) (interface{}, error) {
 	return exceptions, nil
 }
@@ -3415,9 +4070,11 @@ func (p *parser) callonThrows1() (interface{}, error) {
 
 func (c *current) onFieldType1(typ interface{}) (interface{}, error) {
 	if t, ok := typ.(Identifier); ok {
-		return &Type{Name: string(t)}, nil
+		return &Type{Name: string(t), Pos: c.nodePos()}, nil
 	}
-	return typ, nil
+	t := typ.(*Type)
+	t.Pos = c.nodePos()
+	return t, nil
 }
 
 func (p *parser) callonFieldType1() (interface{}, error) {
@@ -3544,6 +4201,7 @@ func (c *current) onTypeAnnotation1(name, value interface{}) (interface{}, error
 	return &Annotation{
 		Name:  string(name.(Identifier)),
 		Value: optValue,
+		Pos:   c.nodePos(),
 	}, nil
 }
 
@@ -3620,13 +4278,14 @@ func (p *parser) callonConstMap1() (interface{}, error) {
 	return p.cur.onConstMap1(stack["values"])
 }
 
-func (c *current) onScope1(docstr, name, prefix, operations, annotations interface{}) (interface{}, error) {
+func (c *current) onScope1(docstr, start, name, prefix, operations, annotations, end interface{}) (interface{}, error) {
 	ops := operations.([]interface{})
 	scope := &Scope{
 		Name:        string(name.(Identifier)),
 		Operations:  make([]*Operation, len(ops)),
 		Prefix:      defaultPrefix,
 		Annotations: toAnnotations(annotations),
+		Pos:         c.nodePosBetween(start, end),
 	}
 	if docstr != nil {
 		raw := docstr.([]interface{})[0].(string)
@@ -3645,11 +4304,11 @@ func (c *current) onScope1(docstr, name, prefix, operations, annotations interfa
 func (p *parser) callonScope1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onScope1(stack["docstr"], stack["name"], stack["prefix"], stack["operations"], stack["annotations"])
+	return p.cur.onScope1(stack["docstr"], stack["start"], stack["name"], stack["prefix"], stack["operations"], stack["annotations"], stack["end"])
 }
 
 func (c *current) onEndOfScopeError1() (interface{}, error) {
-	return nil, errors.New("parser: expected end of scope")
+	return nil, c.syntaxError("parser: expected end of scope")
 }
 
 func (p *parser) callonEndOfScopeError1() (interface{}, error) {
@@ -3669,11 +4328,12 @@ func (p *parser) callonPrefix1() (interface{}, error) {
 	return p.cur.onPrefix1()
 }
 
-func (c *current) onOperation1(docstr, name, typ, annotations interface{}) (interface{}, error) {
+func (c *current) onOperation1(docstr, start, name, typ, annotations interface{}) (interface{}, error) {
 	o := &Operation{
 		Name:        string(name.(Identifier)),
 		Type:        typ.(*Type),
 		Annotations: toAnnotations(annotations),
+		Pos:         c.nodePosBetween(start, nil),
 	}
 	if docstr != nil {
 		raw := docstr.([]interface{})[0].(string)
@@ -3685,7 +4345,7 @@ func (c *current) onOperation1(docstr, name, typ, annotations interface{}) (inte
 func (p *parser) callonOperation1() (interface{}, error) {
 	stack := p.vstack[len(p.vstack)-1]
 	_ = stack
-	return p.cur.onOperation1(stack["docstr"], stack["name"], stack["typ"], stack["annotations"])
+	return p.cur.onOperation1(stack["docstr"], stack["start"], stack["name"], stack["typ"], stack["annotations"])
 }
 
 func (c *current) onLiteral1() (interface{}, error) {
@@ -3713,6 +4373,16 @@ func (p *parser) callonIdentifier1() (interface{}, error) {
 	return p.cur.onIdentifier1()
 }
 
+func (c *current) onMark1() (interface{}, error) {
+	return Pos{Line: c.pos.line, Column: c.pos.col, Offset: c.pos.offset}, nil
+}
+
+func (p *parser) callonMark1() (interface{}, error) {
+	stack := p.vstack[len(p.vstack)-1]
+	_ = stack
+	return p.cur.onMark1()
+}
+
 func (c *current) onDocString1() (interface{}, error) {
 	comment := string(c.text)
 	comment = strings.TrimPrefix(comment, "/**@")
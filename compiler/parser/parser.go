@@ -15,7 +15,7 @@ package parser
 
 import (
 	"fmt"
-	"os"
+	"io/ioutil"
 	"path/filepath"
 	"strings"
 )
@@ -42,18 +42,26 @@ const (
 )
 
 // ParseFrugal parses the given Frugal file into its semantic representation.
+// If the file or one of its includes is invalid, the returned error is
+// Diagnostics describing every problem found in it where possible.
 func ParseFrugal(filePath string) (*Frugal, error) {
-	return parseFrugal(filePath, []string{})
+	return ParseFrugalFrom(filePath, ioutil.ReadFile)
 }
 
-func parseFrugal(filePath string, visitedIncludes []string) (*Frugal, error) {
-	file, err := os.Open(filePath)
+// ParseFrugalFrom parses the given Frugal file like ParseFrugal but reads it
+// and its includes with readFile. This allows contents which haven't been
+// saved, such as files open in an editor, to be parsed.
+func ParseFrugalFrom(filePath string, readFile func(string) ([]byte, error)) (*Frugal, error) {
+	return parseFrugal(filePath, []string{}, readFile)
+}
+
+func parseFrugal(filePath string, visitedIncludes []string, readFile func(string) ([]byte, error)) (*Frugal, error) {
+	contents, err := readFile(filePath)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 
-	name, err := getName(file)
+	name, err := getName(filePath)
 	if err != nil {
 		return nil, err
 	}
@@ -63,23 +71,35 @@ func parseFrugal(filePath string, visitedIncludes []string) (*Frugal, error) {
 	}
 	visitedIncludes = append(visitedIncludes, name)
 
-	parsed, err := ParseReader(filePath, file)
+	parsed, err := Parse(filePath, contents)
 	if err != nil {
-		return nil, err
+		return nil, newParseDiagnostics(filePath, err)
 	}
 
 	frugal := parsed.(*Frugal)
 	frugal.Name = name
 	frugal.File = filePath
-	frugal.Dir = filepath.Dir(file.Name())
+	frugal.Dir = filepath.Dir(filePath)
 	frugal.Path = filePath
+	frugal.Comments = scanComments(filePath, contents)
+	frugal.assignFile(filePath)
+
+	// Problems in includes are collected so they can be reported together,
+	// but this file isn't validated if any are found since it would report
+	// errors for every reference to the broken include.
+	includeDiags := Diagnostics{}
 	for _, incl := range frugal.Includes {
 		include := incl.Value
 		if !strings.HasSuffix(include, ".thrift") && !strings.HasSuffix(include, ".frugal") {
-			return nil, fmt.Errorf("Bad include name: %s", include)
+			includeDiags.addError(incl.Pos, "Bad include name: %s", include)
+			continue
 		}
 
-		parsedIncl, err := parseFrugal(filepath.Join(frugal.Dir, include), visitedIncludes)
+		parsedIncl, err := parseFrugal(filepath.Join(frugal.Dir, include), visitedIncludes, readFile)
+		if diags, ok := err.(Diagnostics); ok {
+			includeDiags = append(includeDiags, diags...)
+			continue
+		}
 		if err != nil {
 			return nil, fmt.Errorf("Include %s: %s", include, err)
 		}
@@ -93,6 +113,10 @@ func parseFrugal(filePath string, visitedIncludes []string) (*Frugal, error) {
 		frugal.ParsedIncludes[includeName] = parsedIncl
 	}
 
+	if err := includeDiags.err(); err != nil {
+		return nil, err
+	}
+
 	if err := frugal.validate(); err != nil {
 		return nil, err
 	}
@@ -103,14 +127,10 @@ func parseFrugal(filePath string, visitedIncludes []string) (*Frugal, error) {
 	return frugal, nil
 }
 
-func getName(f *os.File) (string, error) {
-	info, err := f.Stat()
-	if err != nil {
-		return "", err
-	}
-	parts := strings.Split(info.Name(), ".")
+func getName(filePath string) (string, error) {
+	parts := strings.Split(filepath.Base(filePath), ".")
 	if len(parts) != 2 {
-		return "", fmt.Errorf("Invalid file: %s", f.Name())
+		return "", fmt.Errorf("Invalid file: %s", filePath)
 	}
 	return parts[0], nil
 }
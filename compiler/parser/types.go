@@ -73,6 +73,33 @@ func (f *FieldModifier) String() string {
 	}
 }
 
+// Pos is the position of a definition in a Frugal file. Line and Column are
+// 1-based, Column counts characters, and Offset and Span count bytes.
+type Pos struct {
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Offset int    `json:"offset"`
+	Span   int    `json:"span"`
+}
+
+// IsValid indicates if the Pos was set by the parser.
+func (p Pos) IsValid() bool {
+	return p.Line > 0
+}
+
+// String returns the Pos in the form file:line:column.
+func (p Pos) String() string {
+	s := p.File
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return s
+}
+
 // FieldFromType returns a new Field from the given Type and name.
 func FieldFromType(t *Type, name string) *Field {
 	return &Field{
@@ -99,6 +126,7 @@ type Include struct {
 	Name        string
 	Value       string
 	Annotations Annotations
+	Pos         Pos
 }
 
 type byIncludeName []Include
@@ -120,6 +148,7 @@ type Namespace struct {
 	Scope       string
 	Value       string
 	Annotations Annotations
+	Pos         Pos
 }
 
 // Wildcard indicates if this Namespace is a wildcard (*).
@@ -133,6 +162,7 @@ type Type struct {
 	KeyType     *Type // If map
 	ValueType   *Type // If map, list, or set
 	Annotations Annotations
+	Pos         Pos
 }
 
 // IsPrimitive indicates if the type is a Frugal primitive type.
@@ -183,12 +213,24 @@ func (t *Type) String() string {
 	return t.Name
 }
 
+func (t *Type) assignFile(file string) {
+	t.Pos.File = file
+	t.Annotations.assignFile(file)
+	if t.KeyType != nil {
+		t.KeyType.assignFile(file)
+	}
+	if t.ValueType != nil {
+		t.ValueType.assignFile(file)
+	}
+}
+
 // TypeDef represents an IDL typedef.
 type TypeDef struct {
 	Comment     []string
 	Name        string
 	Type        *Type
 	Annotations Annotations
+	Pos         Pos
 }
 
 // EnumValue represents an IDL enum value.
@@ -196,7 +238,9 @@ type EnumValue struct {
 	Comment     []string
 	Name        string
 	Value       int
+	Implicit    bool // Whether Value was assigned in order rather than given
 	Annotations Annotations
+	Pos         Pos
 }
 
 // Enum represents an IDL enum.
@@ -205,6 +249,7 @@ type Enum struct {
 	Name        string
 	Values      []*EnumValue
 	Annotations Annotations
+	Pos         Pos
 }
 
 // Constant represents an IDL constant.
@@ -214,6 +259,7 @@ type Constant struct {
 	Type        *Type
 	Value       interface{}
 	Annotations Annotations
+	Pos         Pos
 }
 
 // Field represents an IDL field on a struct or method.
@@ -225,6 +271,7 @@ type Field struct {
 	Type        *Type
 	Default     interface{}
 	Annotations Annotations
+	Pos         Pos
 }
 
 // StructType represents what "type" a struct is (struct, exception, or union).
@@ -258,17 +305,26 @@ type Struct struct {
 	Fields      []*Field
 	Type        StructType
 	Annotations Annotations
+	Pos         Pos
 }
 
 // Method represents an IDL service method.
 type Method struct {
-	Comment     []string
-	Name        string
-	Oneway      bool
-	ReturnType  *Type
-	Arguments   []*Field
-	Exceptions  []*Field
-	Annotations Annotations
+	Comment           []string
+	Name              string
+	Oneway            bool
+	StreamingResponse bool
+	ReturnType        *Type
+	Arguments         []*Field
+	RequestStream     *Field // nil unless the method streams requests
+	Exceptions        []*Field
+	Annotations       Annotations
+	Pos               Pos
+}
+
+// IsStreaming returns true if the method streams its requests or responses.
+func (m *Method) IsStreaming() bool {
+	return m.StreamingResponse || m.RequestStream != nil
 }
 
 // Service represents an IDL service.
@@ -278,6 +334,7 @@ type Service struct {
 	Extends     string
 	Methods     []*Method
 	Annotations Annotations
+	Pos         Pos
 	Frugal      *Frugal // Pointer back to containing Frugal
 }
 
@@ -313,6 +370,18 @@ func (s *Service) TwowayMethods() []*Method {
 	return methods
 }
 
+// StreamingMethods returns a slice of the methods defined in this Service which
+// stream their requests or responses.
+func (s *Service) StreamingMethods() []*Method {
+	methods := []*Method{}
+	for _, method := range s.Methods {
+		if method.IsStreaming() {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
 // ReferencedIncludes returns a slice containing the referenced includes which
 // will need to be imported in generated code for this Service.
 func (s *Service) ReferencedIncludes() ([]*Include, error) {
@@ -343,6 +412,13 @@ func (s *Service) ReferencedIncludes() ([]*Include, error) {
 				return nil, err
 			}
 		}
+		// Check request stream type.
+		if method.RequestStream != nil {
+			includesSet, includes, err = addInclude(includesSet, includes, method.RequestStream.Type, s.Frugal)
+			if err != nil {
+				return nil, err
+			}
+		}
 		// Check return type.
 		if method.ReturnType != nil {
 			includesSet, includes, err = addInclude(includesSet, includes, method.ReturnType, s.Frugal)
@@ -414,16 +490,24 @@ func (s *Service) ReferencedInternals() []string {
 
 // validate ensures Service oneways don't return anything and field ids aren't
 // duplicated.
-func (s *Service) validate() error {
+func (s *Service) validate(diags *Diagnostics) {
 	for _, method := range s.Methods {
 		// Ensure oneways don't return anything.
 		if method.Oneway {
+			if method.StreamingResponse {
+				diags.addError(method.Pos, "Oneway method %s.%s cannot stream a response",
+					s.Name, method.Name)
+			}
+			if method.RequestStream != nil {
+				diags.addError(method.RequestStream.Pos, "Oneway method %s.%s cannot stream requests",
+					s.Name, method.Name)
+			}
 			if len(method.Exceptions) > 0 {
-				return fmt.Errorf("Oneway method %s.%s cannot throw an exception",
+				diags.addError(method.Pos, "Oneway method %s.%s cannot throw an exception",
 					s.Name, method.Name)
 			}
-			if method.ReturnType != nil {
-				return fmt.Errorf("Void method %s.%s cannot return %s",
+			if method.ReturnType != nil && !method.StreamingResponse {
+				diags.addError(method.ReturnType.Pos, "Void method %s.%s cannot return %s",
 					s.Name, method.Name, method.ReturnType)
 			}
 		}
@@ -432,13 +516,18 @@ func (s *Service) validate() error {
 		ids := make(map[int]struct{})
 		for _, arg := range method.Arguments {
 			if _, ok := ids[arg.ID]; ok {
-				return fmt.Errorf("Duplicate field id %d in method %s.%s",
+				diags.addError(arg.Pos, "Duplicate field id %d in method %s.%s",
 					arg.ID, s.Name, method.Name)
 			}
 			ids[arg.ID] = struct{}{}
 		}
+		if method.RequestStream != nil {
+			if _, ok := ids[method.RequestStream.ID]; ok {
+				diags.addError(method.RequestStream.Pos, "Duplicate field id %d in method %s.%s",
+					method.RequestStream.ID, s.Name, method.Name)
+			}
+		}
 	}
-	return nil
 }
 
 // Identifier represents an IDL identifier.
@@ -464,6 +553,7 @@ func (k *KeyValue) KeyToString() string {
 type Annotation struct {
 	Name  string
 	Value string
+	Pos   Pos
 }
 
 // Annotations is the collection of Annotations present on an IDL definition.
@@ -505,6 +595,12 @@ func (a Annotations) DeprecationValue() string {
 	return v
 }
 
+func (a Annotations) assignFile(file string) {
+	for _, annotation := range a {
+		annotation.Pos.File = file
+	}
+}
+
 func getImports(t *Type) []string {
 	list := []string{}
 	switch t.Name {
@@ -554,6 +650,7 @@ type Operation struct {
 	Name        string
 	Type        *Type
 	Annotations Annotations
+	Pos         Pos
 	Scope       *Scope // Pointer back to containing Scope
 }
 
@@ -578,6 +675,7 @@ type Scope struct {
 	Prefix      *ScopePrefix
 	Operations  []*Operation
 	Annotations Annotations
+	Pos         Pos
 	Frugal      *Frugal // Pointer back to containing Frugal
 }
 
@@ -623,6 +721,13 @@ type IdentifierContext struct {
 	Include   *Frugal
 }
 
+// Comment is a comment in a Frugal file. Text is the comment as written,
+// including its delimiters.
+type Comment struct {
+	Text string
+	Pos  Pos
+}
+
 // Frugal contains the complete IDL parse tree.
 type Frugal struct {
 	Name           string
@@ -642,6 +747,9 @@ type Frugal struct {
 	Services   []*Service
 	Scopes     []*Scope
 
+	Warnings Diagnostics // Warnings found while validating this file
+	Comments []*Comment  // Every comment in this file, including doc comments, in order
+
 	typedefIndex   map[string]*TypeDef
 	namespaceIndex map[string]*Namespace
 }
@@ -837,6 +945,29 @@ func (f *Frugal) UnderlyingType(t *Type) *Type {
 	return t
 }
 
+// ResolveType follows any typedefs to get the base IDL type, like
+// UnderlyingType, but resolves typedefs in the file which declares them. It
+// returns the resolved Type along with the Frugal which declares it, which is
+// nil if an include couldn't be resolved. Base types and containers are
+// returned with the Frugal they're used in.
+func (f *Frugal) ResolveType(t *Type) (*Frugal, *Type) {
+	if t.IsPrimitive() || t.IsContainer() {
+		return f, t
+	}
+	frugal := f
+	if include := t.IncludeName(); include != "" {
+		parsed, ok := f.ParsedIncludes[include]
+		if !ok {
+			return nil, t
+		}
+		frugal = parsed
+	}
+	if typedef, ok := frugal.typedefIndex[t.ParamName()]; ok {
+		return frugal.ResolveType(typedef.Type)
+	}
+	return frugal, t
+}
+
 // ConstantFromField returns a new Constant from the given Field and value.
 func (f *Frugal) ConstantFromField(field *Field, value interface{}) *Constant {
 	return &Constant{
@@ -968,9 +1099,81 @@ func (f *Frugal) assignFrugal() {
 	}
 }
 
+// assignFile records the file each definition was parsed from in its Pos.
+func (f *Frugal) assignFile(file string) {
+	for _, include := range f.Includes {
+		include.Pos.File = file
+		include.Annotations.assignFile(file)
+	}
+	for _, namespace := range f.Namespaces {
+		namespace.Pos.File = file
+		namespace.Annotations.assignFile(file)
+	}
+	for _, typedef := range f.Typedefs {
+		typedef.Pos.File = file
+		typedef.Type.assignFile(file)
+		typedef.Annotations.assignFile(file)
+	}
+	for _, constant := range f.Constants {
+		constant.Pos.File = file
+		constant.Type.assignFile(file)
+		constant.Annotations.assignFile(file)
+	}
+	for _, enum := range f.Enums {
+		enum.Pos.File = file
+		enum.Annotations.assignFile(file)
+		for _, value := range enum.Values {
+			value.Pos.File = file
+			value.Annotations.assignFile(file)
+		}
+	}
+	for _, s := range f.DataStructures() {
+		s.Pos.File = file
+		s.Annotations.assignFile(file)
+		assignFieldsFile(s.Fields, file)
+	}
+	for _, service := range f.Services {
+		service.Pos.File = file
+		service.Annotations.assignFile(file)
+		for _, method := range service.Methods {
+			method.Pos.File = file
+			method.Annotations.assignFile(file)
+			if method.ReturnType != nil {
+				method.ReturnType.assignFile(file)
+			}
+			assignFieldsFile(method.Arguments, file)
+			if method.RequestStream != nil {
+				assignFieldsFile([]*Field{method.RequestStream}, file)
+			}
+			assignFieldsFile(method.Exceptions, file)
+		}
+	}
+	for _, scope := range f.Scopes {
+		scope.Pos.File = file
+		scope.Annotations.assignFile(file)
+		for _, op := range scope.Operations {
+			op.Pos.File = file
+			op.Type.assignFile(file)
+			op.Annotations.assignFile(file)
+		}
+	}
+}
+
+func assignFieldsFile(fields []*Field, file string) {
+	for _, field := range fields {
+		field.Pos.File = file
+		field.Type.assignFile(file)
+		field.Annotations.assignFile(file)
+	}
+}
+
 // validate parsed Frugal IDL by ensuring there are no duplicate service/scope
-// names and the Frugal IDL is valid.
+// names and the Frugal IDL is valid. Every problem found is returned as
+// Diagnostics rather than stopping at the first, and any warnings are kept on
+// the Frugal.
 func (f *Frugal) validate() error {
+	diags := Diagnostics{}
+
 	// Ensure there are no duplicate names between services and scopes.
 	names := make(map[string]string)
 	for _, service := range f.Services {
@@ -979,9 +1182,10 @@ func (f *Frugal) validate() error {
 		lowercaseService := LowercaseFirstLetter(service.Name)
 		if providedService, ok := names[lowercaseService]; ok {
 			if service.Name == providedService {
-				return fmt.Errorf("Duplicate service name %s", service.Name)
+				diags.addError(service.Pos, "Duplicate service name %s", service.Name)
+			} else {
+				diags.addError(service.Pos, "%s", getConflictMessage("Services", service.Name, providedService))
 			}
-			return getConflictError("Services", service.Name, providedService)
 		}
 		names[lowercaseService] = service.Name
 
@@ -992,9 +1196,10 @@ func (f *Frugal) validate() error {
 			lowercaseMethod := LowercaseFirstLetter(method.Name)
 			if providedMethod, ok := methodNames[lowercaseMethod]; ok {
 				if method.Name == providedMethod {
-					return fmt.Errorf("Duplicate method name %s", method.Name)
+					diags.addError(method.Pos, "Duplicate method name %s", method.Name)
+				} else {
+					diags.addError(method.Pos, "%s", getConflictMessage("Methods", method.Name, providedMethod))
 				}
-				return getConflictError("Methods", method.Name, providedMethod)
 			}
 			methodNames[lowercaseMethod] = method.Name
 		}
@@ -1007,9 +1212,10 @@ func (f *Frugal) validate() error {
 		lowercaseScope := LowercaseFirstLetter(scope.Name)
 		if providedScope, ok := names[lowercaseScope]; ok {
 			if scope.Name == providedScope {
-				return fmt.Errorf("Duplicate scope name %s", scope.Name)
+				diags.addError(scope.Pos, "Duplicate scope name %s", scope.Name)
+			} else {
+				diags.addError(scope.Pos, "%s", getConflictMessage("Scopes", scope.Name, providedScope))
 			}
-			return getConflictError("Scopes", scope.Name, providedScope)
 		}
 		names[lowercaseScope] = scope.Name
 
@@ -1020,70 +1226,55 @@ func (f *Frugal) validate() error {
 			lowercaseOp := LowercaseFirstLetter(op.Name)
 			if providedOp, ok := opNames[lowercaseOp]; ok {
 				if op.Name == providedOp {
-					return fmt.Errorf("Duplicate operation name %s", op.Name)
+					diags.addError(op.Pos, "Duplicate operation name %s", op.Name)
+				} else {
+					diags.addError(op.Pos, "%s", getConflictMessage("Operations", op.Name, providedOp))
 				}
-				return getConflictError("Operations", op.Name, providedOp)
 			}
 			opNames[lowercaseOp] = op.Name
 		}
 	}
 
-	if err := f.validateNamespaces(); err != nil {
-		return err
-	}
-	if err := f.validateIncludes(); err != nil {
-		return err
-	}
-	if err := f.validateConstants(); err != nil {
-		return err
-	}
-	if err := f.validateTypedefs(); err != nil {
-		return err
-	}
-	if err := f.validateStructs(); err != nil {
-		return err
-	}
-	if err := f.validateUnions(); err != nil {
-		return err
-	}
-	if err := f.validateExceptions(); err != nil {
-		return err
-	}
-	if err := f.validateServices(f.ParsedIncludes); err != nil {
-		return err
-	}
-	return nil
+	f.validateNamespaces(&diags)
+	f.validateIncludes(&diags)
+	f.validateConstants(&diags)
+	f.validateTypedefs(&diags)
+	f.validateEnums(&diags)
+	f.validateStructs(&diags)
+	f.validateUnions(&diags)
+	f.validateExceptions(&diags)
+	f.validateServices(&diags)
+
+	f.Warnings = diags.Warnings()
+	sort.Stable(f.Warnings)
+	return diags.err()
 }
 
-func (f *Frugal) validateNamespaces() error {
+func (f *Frugal) validateNamespaces(diags *Diagnostics) {
 	for _, namespace := range f.Namespaces {
 		_, vendor := namespace.Annotations.Vendor()
 		if namespace.Wildcard() && vendor {
-			return fmt.Errorf("\"%s\" annotation not compatible with * namespace", VendorAnnotation)
+			diags.addError(namespace.Pos, "\"%s\" annotation not compatible with * namespace", VendorAnnotation)
 		}
 	}
-	return nil
 }
 
-func (f *Frugal) validateIncludes() error {
+func (f *Frugal) validateIncludes(diags *Diagnostics) {
 	includes := map[string]struct{}{}
 	for _, include := range f.Includes {
 		if _, ok := includes[include.Name]; ok {
-			return fmt.Errorf("Duplicate include: %s", include.Name)
+			diags.addError(include.Pos, "Duplicate include: %s", include.Name)
 		}
 		includes[include.Name] = struct{}{}
 	}
-	return nil
 }
 
-func (f *Frugal) validateConstants() error {
+func (f *Frugal) validateConstants(diags *Diagnostics) {
 	for _, constant := range f.Constants {
 		if err := f.validateConstant(constant); err != nil {
-			return err
+			diags.addError(constant.Pos, "%s", err)
 		}
 	}
-
-	return nil
 }
 
 func (f *Frugal) validateConstant(constant *Constant) error {
@@ -1136,55 +1327,62 @@ func (f *Frugal) validateConstant(constant *Constant) error {
 	return fmt.Errorf("Invalid constant name %s", name)
 }
 
-func (f *Frugal) validateTypedefs() error {
+func (f *Frugal) validateTypedefs(diags *Diagnostics) {
 	for _, typedef := range f.Typedefs {
 		if !f.isValidType(typedef.Type) {
-			return fmt.Errorf("Invalid alias %s, type %s doesn't exist",
+			diags.addError(typedef.Type.Pos, "Invalid alias %s, type %s doesn't exist",
 				typedef.Name, typedef.Type.Name)
 		}
 	}
-	return nil
 }
 
-func (f *Frugal) validateStructs() error {
-	for _, s := range f.Structs {
-		if err := f.validateStructLike(s); err != nil {
-			return err
+// validateEnums warns about enum values which share a value with an earlier
+// value in the same enum. This is usually the result of mixing explicit and
+// implicit values.
+func (f *Frugal) validateEnums(diags *Diagnostics) {
+	for _, enum := range f.Enums {
+		values := make(map[int]string)
+		for _, value := range enum.Values {
+			if other, ok := values[value.Value]; ok {
+				diags.addWarning(value.Pos, "Enum value %s.%s has the same value %d as %s",
+					enum.Name, value.Name, value.Value, other)
+				continue
+			}
+			values[value.Value] = value.Name
 		}
 	}
-	return nil
 }
 
-func (f *Frugal) validateUnions() error {
+func (f *Frugal) validateStructs(diags *Diagnostics) {
+	for _, s := range f.Structs {
+		f.validateStructLike(s, diags)
+	}
+}
+
+func (f *Frugal) validateUnions(diags *Diagnostics) {
 	for _, union := range f.Unions {
-		if err := f.validateStructLike(union); err != nil {
-			return err
-		}
+		f.validateStructLike(union, diags)
 	}
-	return nil
 }
 
-func (f *Frugal) validateExceptions() error {
+func (f *Frugal) validateExceptions(diags *Diagnostics) {
 	for _, exception := range f.Exceptions {
-		if err := f.validateStructLike(exception); err != nil {
-			return err
-		}
+		f.validateStructLike(exception, diags)
 	}
-	return nil
 }
 
-func (f *Frugal) validateStructLike(s *Struct) error {
+func (f *Frugal) validateStructLike(s *Struct, diags *Diagnostics) {
 	ids := make(map[int]struct{})
 	for _, field := range s.Fields {
 		if !f.isValidType(field.Type) {
-			return fmt.Errorf("Invalid type %s on struct %s", field.Type.String(), s.Name)
+			diags.addError(field.Type.Pos, "Invalid type %s on struct %s", field.Type.String(), s.Name)
 		}
 		if _, ok := ids[field.ID]; ok {
-			return fmt.Errorf("Duplicate field id %d in struct %s", field.ID, s.Name)
+			diags.addError(field.Pos, "Duplicate field id %d in struct %s", field.ID, s.Name)
 		}
 		ids[field.ID] = struct{}{}
 	}
-	return nil
+	f.validateConstraints(s.Name, s.Fields, false, diags)
 }
 
 func (f *Frugal) isValidType(typ *Type) bool {
@@ -1249,44 +1447,43 @@ func (f *Frugal) isValidType(typ *Type) bool {
 	return false
 }
 
-func (f *Frugal) validateServices(includes map[string]*Frugal) error {
+func (f *Frugal) validateServices(diags *Diagnostics) {
 	for _, service := range f.Services {
-		if err := f.validateServiceTypes(service, includes); err != nil {
-			return err
-		}
-		if err := service.validate(); err != nil {
-			return err
-		}
+		f.validateServiceTypes(service, diags)
+		service.validate(diags)
 	}
-	return nil
 }
 
-func (f *Frugal) validateServiceTypes(service *Service, includes map[string]*Frugal) error {
+func (f *Frugal) validateServiceTypes(service *Service, diags *Diagnostics) {
 	for _, method := range service.Methods {
 		if method.ReturnType != nil {
 			if !f.isValidType(method.ReturnType) {
-				return fmt.Errorf("Invalid return type %s for %s.%s",
+				diags.addError(method.ReturnType.Pos, "Invalid return type %s for %s.%s",
 					method.ReturnType.Name, service.Name, method.Name)
 			}
 		}
 		for _, field := range method.Arguments {
 			if !f.isValidType(field.Type) {
-				return fmt.Errorf("Invalid argument type %s for %s.%s",
+				diags.addError(field.Type.Pos, "Invalid argument type %s for %s.%s",
 					field.Type.Name, service.Name, method.Name)
 			}
 		}
+		f.validateConstraints(service.Name+"."+method.Name, method.Arguments, true, diags)
+		if method.RequestStream != nil && !f.isValidType(method.RequestStream.Type) {
+			diags.addError(method.RequestStream.Type.Pos, "Invalid request stream type %s for %s.%s",
+				method.RequestStream.Type.Name, service.Name, method.Name)
+		}
 		for _, field := range method.Exceptions {
 			if !f.isValidType(field.Type) {
-				return fmt.Errorf("Invalid exception type %s for %s.%s",
+				diags.addError(field.Type.Pos, "Invalid exception type %s for %s.%s",
 					field.Type.Name, service.Name, method.Name)
 			}
 		}
 	}
-	return nil
 }
 
-func getConflictError(type_, name1, name2 string) error {
-	return fmt.Errorf("%s %s and %s conflict. Some languages do not support"+
+func getConflictMessage(type_, name1, name2 string) string {
+	return fmt.Sprintf("%s %s and %s conflict. Some languages do not support"+
 		" exported lowercase classes/methods. Only one of %s or %s may be used.",
 		type_, name1, name2, name1, name2)
 }
@@ -53,6 +53,9 @@ class FrugalTApplicationErrorType extends TApplicationErrorType {
 
   /// Indicates the response was too large for the transport.
   static const int RESPONSE_TOO_LARGE = 100;
+
+  /// Indicates the request arguments failed validation.
+  static const int VALIDATION_ERROR = 101;
 }
 
 /// Contains [TTransportError] types used in frugal instantiated
@@ -42,6 +42,7 @@ type fAdapterTransport struct {
 	transport          thrift.TTransport
 	isOpen             bool
 	mu                 sync.RWMutex
+	writeMu            sync.Mutex
 	closeSignal        chan struct{}
 	closeChan          chan error
 	monitorCloseSignal chan<- error
@@ -218,9 +219,43 @@ func (f *fAdapterTransport) Request(ctx FContext, payload []byte) (thrift.TTrans
 	}
 }
 
+// RequestStream transmits the given data and returns an FResponseStream
+// which receives each response sent for it.
+func (f *fAdapterTransport) RequestStream(ctx FContext, payload []byte) (FResponseStream, error) {
+	return f.OpenStream(ctx, payload)
+}
+
+// OpenStream transmits the given data and returns an FStream which sends
+// further request frames on the same connection and receives each response
+// sent for it.
+func (f *fAdapterTransport) OpenStream(ctx FContext, payload []byte) (FStream, error) {
+	frames := make(chan []byte, streamBufferSize)
+	var stream *fStream
+	// Nothing is received until the request is sent, so the stream is
+	// created before it can overflow.
+	if err := f.registry.RegisterStream(ctx, frames, func() { stream.overflow() }); err != nil {
+		return nil, thrift.NewTTransportException(TRANSPORT_EXCEPTION_UNKNOWN, err.Error())
+	}
+	stream = newFStream(ctx, frames, func(frame []byte) error {
+		return f.Oneway(ctx, frame)
+	}, func() {
+		f.registry.Unregister(ctx)
+	})
+
+	if err := f.Oneway(ctx, payload); err != nil {
+		stream.Close()
+		return nil, err
+	}
+	return stream, nil
+}
+
 func (f *fAdapterTransport) send(payload []byte, errorC chan error, oneway bool) {
 	// TODO: does this need to be called in a goroutine?
 	// i.e. can Write() and Flush() block?
+	// Streams send frames concurrently with requests, so writes are
+	// serialized to keep frames from interleaving.
+	f.writeMu.Lock()
+	defer f.writeMu.Unlock()
 	if _, err := f.transport.Write(payload); err != nil {
 		errorC <- err
 		return
@@ -42,6 +42,15 @@ func (m *mockFRegistry) Register(ctx FContext, resultC chan []byte) error {
 	return m.Called(ctx, resultC).Error(0)
 }
 
+func (m *mockFRegistry) RegisterStream(ctx FContext, frames chan []byte, overflow func()) error {
+	opID, err := getOpID(ctx)
+	if err == nil {
+		m.channels[opID] = frames
+	}
+
+	return m.Called(ctx, frames).Error(0)
+}
+
 func (m *mockFRegistry) Unregister(ctx FContext) {
 	m.Called(ctx)
 }
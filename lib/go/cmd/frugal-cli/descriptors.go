@@ -0,0 +1,75 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/Workiva/frugal/compiler/descriptor"
+	"github.com/Workiva/frugal/compiler/parser"
+	"github.com/Workiva/frugal/lib/go"
+)
+
+// loadDescriptors parses the Frugal file and returns the descriptors of the
+// services and scopes it and its includes declare, scopes keyed by name.
+func loadDescriptors(file string) ([]*frugal.FServiceDescriptor, map[string]*frugal.FScopeDescriptor, error) {
+	f, err := parser.ParseFrugal(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	services := []*descriptor.Service{}
+	scopes := []*descriptor.Scope{}
+	visited := make(map[*parser.Frugal]bool)
+	var visit func(*parser.Frugal)
+	visit = func(f *parser.Frugal) {
+		if visited[f] {
+			return
+		}
+		visited[f] = true
+		for _, service := range f.Services {
+			services = append(services, descriptor.NewService(service))
+		}
+		for _, scope := range f.Scopes {
+			scopes = append(scopes, descriptor.NewScope(scope))
+		}
+		for _, include := range f.ParsedIncludes {
+			visit(include)
+		}
+	}
+	visit(f)
+
+	// The descriptors have the same JSON encoding as those of the library.
+	serviceDescriptors := []*frugal.FServiceDescriptor{}
+	if err := convert(services, &serviceDescriptors); err != nil {
+		return nil, nil, err
+	}
+	scopeDescriptors := []*frugal.FScopeDescriptor{}
+	if err := convert(scopes, &scopeDescriptors); err != nil {
+		return nil, nil, err
+	}
+	scopesByName := make(map[string]*frugal.FScopeDescriptor, len(scopeDescriptors))
+	for _, scope := range scopeDescriptors {
+		scopesByName[scope.Name] = scope
+	}
+	return serviceDescriptors, scopesByName, nil
+}
+
+func convert(from, to interface{}) error {
+	encoded, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, to)
+}
@@ -0,0 +1,414 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Command frugal-cli calls methods of services, and publishes and subscribes
+// to operations of scopes, described by Frugal files without generated code.
+// Values are given and printed as JSON, shaped like those of
+// frugal.FDynamicClient. The frugal command's call, publish, and subscribe
+// commands run it.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/Workiva/frugal/lib/go"
+	"github.com/nats-io/go-nats"
+)
+
+const usage = `Usage: frugal-cli <command> [flags] file args...
+
+Commands:
+  call       call a method with a JSON object of arguments and print the result
+  publish    publish a JSON value to an operation of a scope
+  subscribe  print the values published to an operation of a scope
+
+Run "frugal-cli <command> -h" for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(1)
+	}
+	var err error
+	switch command := os.Args[1]; command {
+	case "call":
+		err = call(os.Args[2:])
+	case "publish":
+		err = publish(os.Args[2:])
+	case "subscribe":
+		err = subscribe(os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n%s", command, usage)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// options are the flags common to all commands.
+type options struct {
+	nats     string
+	http     string
+	protocol string
+	headers  keyValues
+	timeout  time.Duration
+}
+
+func newOptions(flags *flag.FlagSet) *options {
+	o := &options{headers: keyValues{}}
+	flags.StringVar(&o.nats, "nats", nats.DefaultURL, "connect to the NATS server at this URL")
+	flags.StringVar(&o.http, "http", "", "use HTTP with this URL instead of NATS")
+	flags.StringVar(&o.protocol, "protocol", "binary", "set the protocol (binary, compact, or json)")
+	flags.Var(o.headers, "header", "set a request header as name=value (repeatable)")
+	flags.DurationVar(&o.timeout, "timeout", 5*time.Second, "set the request timeout")
+	return o
+}
+
+func (o *options) protocolFactory() (*frugal.FProtocolFactory, error) {
+	switch o.protocol {
+	case "binary":
+		return frugal.NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault()), nil
+	case "compact":
+		return frugal.NewFProtocolFactory(thrift.NewTCompactProtocolFactory()), nil
+	case "json":
+		return frugal.NewFProtocolFactory(thrift.NewTJSONProtocolFactory()), nil
+	}
+	return nil, fmt.Errorf("Invalid protocol: %s", o.protocol)
+}
+
+func (o *options) context() frugal.FContext {
+	ctx := frugal.NewFContext("")
+	ctx.SetTimeout(o.timeout)
+	for name, value := range o.headers {
+		ctx.AddRequestHeader(name, value)
+	}
+	return ctx
+}
+
+// keyValues is a repeatable flag of name=value pairs.
+type keyValues map[string]string
+
+func (k keyValues) String() string {
+	pairs := make([]string, 0, len(k))
+	for name, value := range k {
+		pairs = append(pairs, name+"="+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (k keyValues) Set(s string) error {
+	pair := strings.SplitN(s, "=", 2)
+	if len(pair) != 2 || pair[0] == "" {
+		return fmt.Errorf("expected name=value, got %s", s)
+	}
+	k[pair[0]] = pair[1]
+	return nil
+}
+
+// output is what's printed for responses and messages.
+type output struct {
+	Topic     string            `json:"topic,omitempty"`
+	Headers   map[string]string `json:"headers"`
+	Result    interface{}       `json:"result,omitempty"`
+	Exception *exception        `json:"exception,omitempty"`
+	Value     interface{}       `json:"value,omitempty"`
+}
+
+type exception struct {
+	Field string                 `json:"field"`
+	Type  string                 `json:"type"`
+	Value map[string]interface{} `json:"value"`
+}
+
+func printOutput(o *output) error {
+	encoded, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Printf("%s\n", encoded)
+	return err
+}
+
+func parseFlags(flags *flag.FlagSet, args []string, argsUsage string, minArgs, maxArgs int) {
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: frugal-cli %s [flags] %s\n\nFlags:\n", flags.Name(), argsUsage)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < minArgs || flags.NArg() > maxArgs {
+		flags.Usage()
+		os.Exit(1)
+	}
+}
+
+// decodeInput decodes the JSON input, which is read from stdin if it's "-".
+// Numbers are decoded as json.Numbers so large integers aren't rounded.
+func decodeInput(input string) (interface{}, error) {
+	var reader io.Reader = strings.NewReader(input)
+	if input == "-" {
+		reader = os.Stdin
+	}
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("Invalid JSON input: %s", err)
+	}
+	return value, nil
+}
+
+// splitName splits a name of the form Service.method or Scope.Operation.
+func splitName(name string) (string, string, error) {
+	parts := strings.Split(name, ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("Invalid name %s, expected Service.method or Scope.Operation", name)
+	}
+	return parts[0], parts[1], nil
+}
+
+func call(args []string) error {
+	flags := flag.NewFlagSet("call", flag.ExitOnError)
+	opts := newOptions(flags)
+	subject := flags.String("subject", "", "send requests to this NATS subject (required with NATS)")
+	multiplex := flags.String("multiplex", "", "qualify method names by this service name for a multiplexed processor")
+	parseFlags(flags, args, "file Service.method [json|-]", 2, 3)
+
+	services, _, err := loadDescriptors(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	service, method, err := splitName(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	input := "{}"
+	if flags.NArg() == 3 {
+		input = flags.Arg(2)
+	}
+	value, err := decodeInput(input)
+	if err != nil {
+		return err
+	}
+	arguments, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("Invalid JSON input: expected an object of arguments")
+	}
+	protocolFactory, err := opts.protocolFactory()
+	if err != nil {
+		return err
+	}
+
+	var transport frugal.FTransport
+	if opts.http != "" {
+		transport = frugal.NewFHTTPTransportBuilder(http.DefaultClient, opts.http).Build()
+	} else {
+		if *subject == "" {
+			return fmt.Errorf("A NATS subject is required, set it with -subject")
+		}
+		conn, err := nats.Connect(opts.nats)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		transport = frugal.NewFNatsTransport(conn, *subject, "")
+	}
+	if err := transport.Open(); err != nil {
+		return err
+	}
+	defer transport.Close()
+
+	provider := frugal.NewFServiceProvider(transport, protocolFactory)
+	if *multiplex != "" {
+		provider = frugal.NewFMultiplexedServiceProvider(provider, *multiplex)
+	}
+	client := frugal.NewFDynamicClient(provider, services...)
+	ctx := opts.context()
+	result, err := client.Call(ctx, service, method, arguments)
+	if ex, ok := err.(*frugal.FDynamicException); ok {
+		if err := printOutput(&output{
+			Headers:   ctx.ResponseHeaders(),
+			Exception: &exception{Field: ex.Field, Type: ex.Type, Value: ex.Value},
+		}); err != nil {
+			return err
+		}
+		return ex
+	}
+	if err != nil {
+		return err
+	}
+	return printOutput(&output{Headers: ctx.ResponseHeaders(), Result: result})
+}
+
+func publish(args []string) error {
+	flags := flag.NewFlagSet("publish", flag.ExitOnError)
+	opts := newOptions(flags)
+	variables := keyValues{}
+	flags.Var(variables, "var", "set a prefix variable of the scope as name=value (repeatable)")
+	parseFlags(flags, args, "file Scope.Operation json|-", 3, 3)
+
+	_, scopes, err := loadDescriptors(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	scope, op, err := findScope(scopes, flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	value, err := decodeInput(flags.Arg(2))
+	if err != nil {
+		return err
+	}
+	protocolFactory, err := opts.protocolFactory()
+	if err != nil {
+		return err
+	}
+
+	var (
+		factory frugal.FPublisherTransportFactory
+		conn    *nats.Conn
+	)
+	if opts.http != "" {
+		builder := frugal.NewFHTTPPublisherTransportBuilder(http.DefaultClient, opts.http).WithTimeout(opts.timeout)
+		factory = frugal.NewFHTTPPublisherTransportFactory(builder)
+	} else {
+		conn, err = nats.Connect(opts.nats)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		factory = frugal.NewFNatsPublisherTransportFactory(conn)
+	}
+
+	provider := frugal.NewFScopeProvider(factory, nil, protocolFactory)
+	publisher := frugal.NewFDynamicPublisher(provider, scope)
+	if err := publisher.Open(); err != nil {
+		return err
+	}
+	defer publisher.Close()
+	ctx := opts.context()
+	if err := publisher.Publish(ctx, op, variables, value); err != nil {
+		return err
+	}
+	if conn != nil {
+		if err := conn.FlushTimeout(opts.timeout); err != nil {
+			return err
+		}
+	}
+	topic, _ := scope.Topic(op, variables)
+	return printOutput(&output{Topic: topic, Headers: ctx.RequestHeaders()})
+}
+
+func subscribe(args []string) error {
+	flags := flag.NewFlagSet("subscribe", flag.ExitOnError)
+	opts := newOptions(flags)
+	variables := keyValues{}
+	flags.Var(variables, "var", "set a prefix variable of the scope as name=value (repeatable)")
+	listen := flags.String("listen", "", "receive messages published over HTTP by serving this address instead of using NATS")
+	queue := flags.String("queue", "", "subscribe with this NATS queue group")
+	count := flags.Int("count", 0, "exit after printing this many messages (0 for no limit)")
+	parseFlags(flags, args, "file Scope.Operation", 2, 2)
+
+	_, scopes, err := loadDescriptors(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	scope, op, err := findScope(scopes, flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	protocolFactory, err := opts.protocolFactory()
+	if err != nil {
+		return err
+	}
+
+	errs := make(chan error, 1)
+	var factory frugal.FSubscriberTransportFactory
+	if *listen != "" {
+		handler := frugal.NewFHTTPSubscriberHandlerBuilder().Build()
+		factory = handler.SubscriberTransportFactory()
+		go func() {
+			errs <- http.ListenAndServe(*listen, handler)
+		}()
+	} else {
+		conn, err := nats.Connect(opts.nats)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if *queue != "" {
+			factory = frugal.NewFNatsSubscriberTransportFactoryWithQueue(conn, *queue)
+		} else {
+			factory = frugal.NewFNatsSubscriberTransportFactory(conn)
+		}
+	}
+
+	messages := make(chan *output)
+	provider := frugal.NewFScopeProvider(nil, factory, protocolFactory)
+	subscriber := frugal.NewFDynamicSubscriber(provider, scope)
+	sub, err := subscriber.Subscribe(op, variables, func(ctx frugal.FContext, value interface{}) error {
+		messages <- &output{Headers: ctx.RequestHeaders(), Value: value}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	for received := 0; *count <= 0 || received < *count; received++ {
+		select {
+		case message := <-messages:
+			message.Topic = sub.Topic()
+			if err := printOutput(message); err != nil {
+				return err
+			}
+		case err := <-errs:
+			return err
+		case <-interrupt:
+			return nil
+		}
+	}
+	return nil
+}
+
+// findScope returns the descriptor of the scope and the name of the operation
+// given a name of the form Scope.Operation.
+func findScope(scopes map[string]*frugal.FScopeDescriptor, name string) (*frugal.FScopeDescriptor, string, error) {
+	scopeName, op, err := splitName(name)
+	if err != nil {
+		return nil, "", err
+	}
+	scope, ok := scopes[scopeName]
+	if !ok {
+		return nil, "", fmt.Errorf("Unknown scope: %s", scopeName)
+	}
+	if _, ok := scope.Operation(op); !ok {
+		return nil, "", fmt.Errorf("Unknown operation %s of scope %s", op, scopeName)
+	}
+	return scope, op, nil
+}
@@ -0,0 +1,767 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// FDynamicException is an exception declared by a method called with an
+// FDynamicClient.
+type FDynamicException struct {
+	Field string                 // The name of the method's exception field
+	Type  string                 // The key of the exception's FTypeDefinition
+	Value map[string]interface{} // The fields of the exception
+}
+
+// Error returns the type and JSON encoded fields of the exception.
+func (e *FDynamicException) Error() string {
+	value, _ := json.Marshal(e.Value)
+	return fmt.Sprintf("%s: %s", e.Type, value)
+}
+
+// FDynamicClient calls methods of services described by FServiceDescriptors
+// without generated code, taking and returning JSON-shaped values: structs
+// are maps keyed by field name, lists and sets are slices, enums are names or
+// numbers, binary is base64, and maps are maps if their keys are strings or
+// enums and otherwise slices of entries with "key" and "value".
+type FDynamicClient struct {
+	transport       FTransport
+	protocolFactory *FProtocolFactory
+	services        map[string]*FServiceDescriptor
+	method          *Method
+}
+
+// NewFDynamicClient returns a new FDynamicClient which can call the methods
+// of the given services. ServiceMiddleware of the provider is applied to
+// calls.
+func NewFDynamicClient(provider *FServiceProvider, services ...*FServiceDescriptor) *FDynamicClient {
+	client := &FDynamicClient{
+		transport:       provider.GetTransport(),
+		protocolFactory: provider.GetProtocolFactory(),
+		services:        make(map[string]*FServiceDescriptor),
+	}
+	client.AddServices(services...)
+	client.method = NewMethod(client, client.call, "call", provider.GetMiddleware())
+	return client
+}
+
+// AddServices adds the descriptors of services whose methods can be called.
+func (c *FDynamicClient) AddServices(services ...*FServiceDescriptor) {
+	for _, service := range services {
+		c.services[service.Name] = service
+	}
+}
+
+// Services returns the descriptors of the services whose methods can be
+// called, sorted by name.
+func (c *FDynamicClient) Services() []*FServiceDescriptor {
+	names := make([]string, 0, len(c.services))
+	for name := range c.services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	services := make([]*FServiceDescriptor, len(names))
+	for i, name := range names {
+		services[i] = c.services[name]
+	}
+	return services
+}
+
+// Reflect calls the reflection method of the server, served by processors
+// generated with the Go reflection option, and adds the returned descriptors
+// to those of the client.
+func (c *FDynamicClient) Reflect(ctx FContext) ([]*FServiceDescriptor, error) {
+	reflection := &FMethodDescriptor{
+		Name:       ReflectionMethod,
+		ReturnType: &FTypeDescriptor{Kind: DescriptorKindBase, Name: "string"},
+	}
+	results := c.method.Invoke(Arguments{ctx, &FServiceDescriptor{}, reflection, map[string]interface{}{}})
+	if err := results.Error(); err != nil {
+		return nil, err
+	}
+	services := []*FServiceDescriptor{}
+	if err := json.Unmarshal([]byte(results[0].(string)), &services); err != nil {
+		return nil, thrift.NewTApplicationException(APPLICATION_EXCEPTION_PROTOCOL_ERROR,
+			fmt.Sprintf("reflection failed: invalid service descriptors: %s", err))
+	}
+	c.AddServices(services...)
+	return services, nil
+}
+
+// Method returns the descriptor of the named method of the service, which may
+// be declared by a service it extends, along with the descriptor of the
+// service declaring it.
+func (c *FDynamicClient) Method(service, method string) (*FServiceDescriptor, *FMethodDescriptor, error) {
+	name := service
+	for name != "" {
+		descriptor, ok := c.services[name]
+		if !ok {
+			if name == service {
+				return nil, nil, fmt.Errorf("frugal: unknown service %s", name)
+			}
+			return nil, nil, fmt.Errorf("frugal: unknown service %s extended by %s", name, service)
+		}
+		if m, ok := descriptor.Method(method); ok {
+			return descriptor, m, nil
+		}
+		name = descriptor.Extends
+	}
+	return nil, nil, fmt.Errorf("frugal: unknown method %s of service %s", method, service)
+}
+
+// Call calls the named method of the service with the given arguments, keyed
+// by argument name, and returns its result, which is nil for void and oneway
+// methods. Exceptions declared by the method are returned as
+// FDynamicExceptions. Streaming methods aren't supported.
+func (c *FDynamicClient) Call(ctx FContext, service, method string, args map[string]interface{}) (interface{}, error) {
+	serviceDescriptor, methodDescriptor, err := c.Method(service, method)
+	if err != nil {
+		return nil, err
+	}
+	if methodDescriptor.IsStreaming() {
+		return nil, fmt.Errorf("frugal: streaming method %s can't be called dynamically", method)
+	}
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	results := c.method.Invoke(Arguments{ctx, serviceDescriptor, methodDescriptor, args})
+	return results[0], results.Error()
+}
+
+func (c *FDynamicClient) call(ctx FContext, service *FServiceDescriptor, method *FMethodDescriptor, args map[string]interface{}) (interface{}, error) {
+	codec := &dynamicCodec{types: service.Types}
+	buffer := NewTMemoryOutputBuffer(c.transport.GetRequestSizeLimit())
+	oprot := c.protocolFactory.GetProtocol(buffer)
+	if err := oprot.WriteRequestHeader(ctx); err != nil {
+		return nil, err
+	}
+	msgType := thrift.CALL
+	if method.Oneway {
+		msgType = thrift.ONEWAY
+	}
+	if err := oprot.WriteMessageBegin(method.Name, msgType, 0); err != nil {
+		return nil, err
+	}
+	if err := codec.writeStruct(oprot, method.Name+"_args", method.Arguments, args, method.Name); err != nil {
+		return nil, err
+	}
+	if err := oprot.WriteMessageEnd(); err != nil {
+		return nil, err
+	}
+	if err := oprot.Flush(); err != nil {
+		return nil, err
+	}
+
+	if method.Oneway {
+		return nil, c.transport.Oneway(ctx, buffer.Bytes())
+	}
+	resultTransport, err := c.transport.Request(ctx, buffer.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	iprot := c.protocolFactory.GetProtocol(resultTransport)
+	if err := iprot.ReadResponseHeader(ctx); err != nil {
+		return nil, err
+	}
+	name, mTypeID, _, err := iprot.ReadMessageBegin()
+	if err != nil {
+		return nil, err
+	}
+	if name != method.Name {
+		return nil, thrift.NewTApplicationException(APPLICATION_EXCEPTION_WRONG_METHOD_NAME, method.Name+" failed: wrong method name")
+	}
+	if mTypeID == thrift.EXCEPTION {
+		ex, err := thrift.NewTApplicationException(APPLICATION_EXCEPTION_UNKNOWN, "Unknown Exception").Read(iprot)
+		if err != nil {
+			return nil, err
+		}
+		if err := iprot.ReadMessageEnd(); err != nil {
+			return nil, err
+		}
+		if ex.TypeId() == APPLICATION_EXCEPTION_RESPONSE_TOO_LARGE {
+			return nil, thrift.NewTTransportException(TRANSPORT_EXCEPTION_RESPONSE_TOO_LARGE, ex.Error())
+		}
+		return nil, ex
+	}
+	if mTypeID != thrift.REPLY {
+		return nil, thrift.NewTApplicationException(APPLICATION_EXCEPTION_INVALID_MESSAGE_TYPE, method.Name+" failed: invalid message type")
+	}
+	result, err := codec.readResult(iprot, method)
+	if err != nil {
+		return nil, err
+	}
+	if err := iprot.ReadMessageEnd(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// dynamicCodec writes and reads JSON-shaped values of the types of a service
+// descriptor.
+type dynamicCodec struct {
+	types map[string]*FTypeDefinition
+}
+
+func (d *dynamicCodec) definition(t *FTypeDescriptor) (*FTypeDefinition, error) {
+	definition, ok := d.types[t.Name]
+	if !ok {
+		return nil, fmt.Errorf("frugal: unknown type %s", t.Name)
+	}
+	return definition, nil
+}
+
+func (d *dynamicCodec) ttype(t *FTypeDescriptor) (thrift.TType, error) {
+	switch t.Kind {
+	case DescriptorKindBase:
+		switch t.Name {
+		case "bool":
+			return thrift.BOOL, nil
+		case "byte", "i8":
+			return thrift.BYTE, nil
+		case "i16":
+			return thrift.I16, nil
+		case "i32":
+			return thrift.I32, nil
+		case "i64":
+			return thrift.I64, nil
+		case "double":
+			return thrift.DOUBLE, nil
+		case "string", "binary":
+			return thrift.STRING, nil
+		}
+	case DescriptorKindEnum:
+		return thrift.I32, nil
+	case DescriptorKindStruct, DescriptorKindUnion, DescriptorKindException:
+		return thrift.STRUCT, nil
+	case DescriptorKindList:
+		return thrift.LIST, nil
+	case DescriptorKindSet:
+		return thrift.SET, nil
+	case DescriptorKindMap:
+		return thrift.MAP, nil
+	}
+	return thrift.STOP, fmt.Errorf("frugal: unknown type %s %s", t.Kind, t.Name)
+}
+
+func (d *dynamicCodec) writeStruct(oprot thrift.TProtocol, name string, fields []*FFieldDescriptor, value map[string]interface{}, path string) error {
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[field.Name] = true
+	}
+	for key := range value {
+		if !known[key] {
+			return fmt.Errorf("frugal: unknown field %s.%s", path, key)
+		}
+	}
+
+	if err := oprot.WriteStructBegin(name); err != nil {
+		return err
+	}
+	for _, field := range fields {
+		fieldValue, ok := value[field.Name]
+		if !ok || fieldValue == nil {
+			if field.Required {
+				return fmt.Errorf("frugal: missing required field %s.%s", path, field.Name)
+			}
+			continue
+		}
+		ttype, err := d.ttype(field.Type)
+		if err != nil {
+			return err
+		}
+		if err := oprot.WriteFieldBegin(field.Name, ttype, field.ID); err != nil {
+			return err
+		}
+		if err := d.writeValue(oprot, field.Type, fieldValue, path+"."+field.Name); err != nil {
+			return err
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return err
+	}
+	return oprot.WriteStructEnd()
+}
+
+func (d *dynamicCodec) writeValue(oprot thrift.TProtocol, t *FTypeDescriptor, value interface{}, path string) error {
+	switch t.Kind {
+	case DescriptorKindBase:
+		switch t.Name {
+		case "bool":
+			b, ok := value.(bool)
+			if !ok {
+				return dynamicTypeError(path, t, value)
+			}
+			return oprot.WriteBool(b)
+		case "byte", "i8":
+			i, err := dynamicInt(value, math.MinInt8, math.MaxInt8)
+			if err != nil {
+				return dynamicValueError(path, err)
+			}
+			return oprot.WriteByte(int8(i))
+		case "i16":
+			i, err := dynamicInt(value, math.MinInt16, math.MaxInt16)
+			if err != nil {
+				return dynamicValueError(path, err)
+			}
+			return oprot.WriteI16(int16(i))
+		case "i32":
+			i, err := dynamicInt(value, math.MinInt32, math.MaxInt32)
+			if err != nil {
+				return dynamicValueError(path, err)
+			}
+			return oprot.WriteI32(int32(i))
+		case "i64":
+			i, err := dynamicInt(value, math.MinInt64, math.MaxInt64)
+			if err != nil {
+				return dynamicValueError(path, err)
+			}
+			return oprot.WriteI64(i)
+		case "double":
+			f, err := dynamicFloat(value)
+			if err != nil {
+				return dynamicValueError(path, err)
+			}
+			return oprot.WriteDouble(f)
+		case "string":
+			s, ok := value.(string)
+			if !ok {
+				return dynamicTypeError(path, t, value)
+			}
+			return oprot.WriteString(s)
+		case "binary":
+			switch v := value.(type) {
+			case []byte:
+				return oprot.WriteBinary(v)
+			case string:
+				b, err := base64.StdEncoding.DecodeString(v)
+				if err != nil {
+					return dynamicValueError(path, err)
+				}
+				return oprot.WriteBinary(b)
+			}
+			return dynamicTypeError(path, t, value)
+		}
+	case DescriptorKindEnum:
+		definition, err := d.definition(t)
+		if err != nil {
+			return err
+		}
+		if name, ok := value.(string); ok {
+			if i, ok := definition.Values[name]; ok {
+				return oprot.WriteI32(i)
+			}
+		}
+		i, err := dynamicInt(value, math.MinInt32, math.MaxInt32)
+		if err != nil {
+			return fmt.Errorf("frugal: invalid value %v for %s: not a value of %s", value, path, t.Name)
+		}
+		return oprot.WriteI32(int32(i))
+	case DescriptorKindStruct, DescriptorKindUnion, DescriptorKindException:
+		definition, err := d.definition(t)
+		if err != nil {
+			return err
+		}
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return dynamicTypeError(path, t, value)
+		}
+		return d.writeStruct(oprot, t.Name, definition.Fields, fields, path)
+	case DescriptorKindList, DescriptorKindSet:
+		elems, ok := value.([]interface{})
+		if !ok {
+			return dynamicTypeError(path, t, value)
+		}
+		ttype, err := d.ttype(t.ValueType)
+		if err != nil {
+			return err
+		}
+		if t.Kind == DescriptorKindList {
+			err = oprot.WriteListBegin(ttype, len(elems))
+		} else {
+			err = oprot.WriteSetBegin(ttype, len(elems))
+		}
+		if err != nil {
+			return err
+		}
+		for i, elem := range elems {
+			if err := d.writeValue(oprot, t.ValueType, elem, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		if t.Kind == DescriptorKindList {
+			return oprot.WriteListEnd()
+		}
+		return oprot.WriteSetEnd()
+	case DescriptorKindMap:
+		return d.writeMap(oprot, t, value, path)
+	}
+	return fmt.Errorf("frugal: unknown type %s %s", t.Kind, t.Name)
+}
+
+// writeMap writes a map given as a map keyed by strings, which are parsed if
+// the key type isn't a string, or as a slice of entries.
+func (d *dynamicCodec) writeMap(oprot thrift.TProtocol, t *FTypeDescriptor, value interface{}, path string) error {
+	var keys, values []interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		sorted := make([]string, 0, len(v))
+		for key := range v {
+			sorted = append(sorted, key)
+		}
+		sort.Strings(sorted)
+		for _, key := range sorted {
+			keys = append(keys, dynamicMapKey(t.KeyType, key))
+			values = append(values, v[key])
+		}
+	case []interface{}:
+		for i, e := range v {
+			entry, ok := e.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("frugal: invalid value for %s[%d]: expected an entry with key and value", path, i)
+			}
+			keys = append(keys, entry["key"])
+			values = append(values, entry["value"])
+		}
+	default:
+		return dynamicTypeError(path, t, value)
+	}
+
+	keyType, err := d.ttype(t.KeyType)
+	if err != nil {
+		return err
+	}
+	valueType, err := d.ttype(t.ValueType)
+	if err != nil {
+		return err
+	}
+	if err := oprot.WriteMapBegin(keyType, valueType, len(keys)); err != nil {
+		return err
+	}
+	for i := range keys {
+		elemPath := fmt.Sprintf("%s[%v]", path, keys[i])
+		if err := d.writeValue(oprot, t.KeyType, keys[i], elemPath); err != nil {
+			return err
+		}
+		if err := d.writeValue(oprot, t.ValueType, values[i], elemPath); err != nil {
+			return err
+		}
+	}
+	return oprot.WriteMapEnd()
+}
+
+// readResult reads the result struct of the method, returning its success
+// value or declared exception.
+func (d *dynamicCodec) readResult(iprot thrift.TProtocol, method *FMethodDescriptor) (interface{}, error) {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return nil, err
+	}
+	var (
+		result    interface{}
+		exception error
+	)
+	for {
+		_, fieldType, fieldID, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return nil, err
+		}
+		if fieldType == thrift.STOP {
+			break
+		}
+		var field *FFieldDescriptor
+		if fieldID == 0 && method.ReturnType != nil {
+			field = &FFieldDescriptor{Name: "success", Type: method.ReturnType}
+		}
+		for _, ex := range method.Exceptions {
+			if ex.ID == fieldID {
+				field = ex
+			}
+		}
+		if field == nil {
+			if err := iprot.Skip(fieldType); err != nil {
+				return nil, err
+			}
+		} else {
+			value, err := d.readValue(iprot, field.Type)
+			if err != nil {
+				return nil, err
+			}
+			if fieldID == 0 {
+				result = value
+			} else {
+				fields, _ := value.(map[string]interface{})
+				exception = &FDynamicException{Field: field.Name, Type: field.Type.Name, Value: fields}
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return nil, err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return nil, err
+	}
+	if exception != nil {
+		return nil, exception
+	}
+	return result, nil
+}
+
+func (d *dynamicCodec) readValue(iprot thrift.TProtocol, t *FTypeDescriptor) (interface{}, error) {
+	switch t.Kind {
+	case DescriptorKindBase:
+		switch t.Name {
+		case "bool":
+			return iprot.ReadBool()
+		case "byte", "i8":
+			i, err := iprot.ReadByte()
+			return int64(i), err
+		case "i16":
+			i, err := iprot.ReadI16()
+			return int64(i), err
+		case "i32":
+			i, err := iprot.ReadI32()
+			return int64(i), err
+		case "i64":
+			return iprot.ReadI64()
+		case "double":
+			return iprot.ReadDouble()
+		case "string":
+			return iprot.ReadString()
+		case "binary":
+			return iprot.ReadBinary()
+		}
+	case DescriptorKindEnum:
+		definition, err := d.definition(t)
+		if err != nil {
+			return nil, err
+		}
+		i, err := iprot.ReadI32()
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range definition.Values {
+			if value == i {
+				return name, nil
+			}
+		}
+		return int64(i), nil
+	case DescriptorKindStruct, DescriptorKindUnion, DescriptorKindException:
+		definition, err := d.definition(t)
+		if err != nil {
+			return nil, err
+		}
+		return d.readStruct(iprot, definition.Fields)
+	case DescriptorKindList, DescriptorKindSet:
+		var (
+			size int
+			err  error
+		)
+		if t.Kind == DescriptorKindList {
+			_, size, err = iprot.ReadListBegin()
+		} else {
+			_, size, err = iprot.ReadSetBegin()
+		}
+		if err != nil {
+			return nil, err
+		}
+		elems := make([]interface{}, 0, size)
+		for i := 0; i < size; i++ {
+			elem, err := d.readValue(iprot, t.ValueType)
+			if err != nil {
+				return nil, err
+			}
+			elems = append(elems, elem)
+		}
+		if t.Kind == DescriptorKindList {
+			err = iprot.ReadListEnd()
+		} else {
+			err = iprot.ReadSetEnd()
+		}
+		return elems, err
+	case DescriptorKindMap:
+		return d.readMap(iprot, t)
+	}
+	return nil, fmt.Errorf("frugal: unknown type %s %s", t.Kind, t.Name)
+}
+
+func (d *dynamicCodec) readStruct(iprot thrift.TProtocol, fields []*FFieldDescriptor) (map[string]interface{}, error) {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return nil, err
+	}
+	value := make(map[string]interface{})
+	for {
+		_, fieldType, fieldID, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return nil, err
+		}
+		if fieldType == thrift.STOP {
+			break
+		}
+		var field *FFieldDescriptor
+		for _, f := range fields {
+			if f.ID == fieldID {
+				field = f
+			}
+		}
+		if field == nil {
+			if err := iprot.Skip(fieldType); err != nil {
+				return nil, err
+			}
+		} else {
+			fieldValue, err := d.readValue(iprot, field.Type)
+			if err != nil {
+				return nil, err
+			}
+			value[field.Name] = fieldValue
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return nil, err
+		}
+	}
+	return value, iprot.ReadStructEnd()
+}
+
+// readMap reads a map as a map keyed by strings if its keys are strings or
+// enums and otherwise as a slice of entries.
+func (d *dynamicCodec) readMap(iprot thrift.TProtocol, t *FTypeDescriptor) (interface{}, error) {
+	_, _, size, err := iprot.ReadMapBegin()
+	if err != nil {
+		return nil, err
+	}
+	stringKeys := t.KeyType.Kind == DescriptorKindEnum ||
+		(t.KeyType.Kind == DescriptorKindBase && t.KeyType.Name == "string")
+	byKey := make(map[string]interface{}, size)
+	entries := make([]interface{}, 0, size)
+	for i := 0; i < size; i++ {
+		key, err := d.readValue(iprot, t.KeyType)
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.readValue(iprot, t.ValueType)
+		if err != nil {
+			return nil, err
+		}
+		if stringKeys {
+			byKey[fmt.Sprint(key)] = value
+		} else {
+			entries = append(entries, map[string]interface{}{"key": key, "value": value})
+		}
+	}
+	if err := iprot.ReadMapEnd(); err != nil {
+		return nil, err
+	}
+	if stringKeys {
+		return byKey, nil
+	}
+	return entries, nil
+}
+
+// dynamicMapKey converts a key of a map given as a map keyed by strings to a
+// value of the key type.
+func dynamicMapKey(t *FTypeDescriptor, key string) interface{} {
+	if t.Kind != DescriptorKindBase {
+		return key
+	}
+	switch t.Name {
+	case "bool":
+		if b, err := strconv.ParseBool(key); err == nil {
+			return b
+		}
+	case "byte", "i8", "i16", "i32", "i64", "double":
+		return json.Number(key)
+	}
+	return key
+}
+
+// dynamicInt converts a JSON-shaped number to an integer within the given
+// range.
+func dynamicInt(value interface{}, min, max int64) (int64, error) {
+	var i int64
+	switch v := value.(type) {
+	case int:
+		i = int64(v)
+	case int8:
+		i = int64(v)
+	case int16:
+		i = int64(v)
+	case int32:
+		i = int64(v)
+	case int64:
+		i = v
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, fmt.Errorf("%v is not an integer", v)
+		}
+		i = int64(v)
+	case json.Number:
+		n, err := strconv.ParseInt(string(v), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s is not an integer", v)
+		}
+		i = n
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("\"%s\" is not an integer", v)
+		}
+		i = n
+	default:
+		return 0, fmt.Errorf("%v is not an integer", value)
+	}
+	if i < min || i > max {
+		return 0, fmt.Errorf("%d is out of range", i)
+	}
+	return i, nil
+}
+
+// dynamicFloat converts a JSON-shaped number to a float.
+func dynamicFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+	i, err := dynamicInt(value, math.MinInt64, math.MaxInt64)
+	if err != nil {
+		return 0, fmt.Errorf("%v is not a number", value)
+	}
+	return float64(i), nil
+}
+
+func dynamicTypeError(path string, t *FTypeDescriptor, value interface{}) error {
+	name := t.Name
+	if name == "" {
+		name = t.Kind
+	}
+	return fmt.Errorf("frugal: invalid value %v for %s: expected %s", value, path, name)
+}
+
+func dynamicValueError(path string, err error) error {
+	return fmt.Errorf("frugal: invalid value for %s: %s", path, err)
+}
@@ -0,0 +1,189 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/stretchr/testify/assert"
+)
+
+// dynamicProcessorFunction serves a method of a service descriptor by calling
+// handle with the arguments read by a dynamicCodec.
+type dynamicProcessorFunction struct {
+	t       *testing.T
+	service *FServiceDescriptor
+	method  *FMethodDescriptor
+	handle  func(args map[string]interface{}) (interface{}, *FDynamicException)
+}
+
+func (d *dynamicProcessorFunction) Process(ctx FContext, iprot, oprot *FProtocol) error {
+	codec := &dynamicCodec{types: d.service.Types}
+	args, err := codec.readStruct(iprot, d.method.Arguments)
+	assert.Nil(d.t, err)
+	assert.Nil(d.t, iprot.ReadMessageEnd())
+	result, ex := d.handle(args)
+
+	fields := []*FFieldDescriptor{}
+	value := map[string]interface{}{}
+	if d.method.ReturnType != nil {
+		fields = append(fields, &FFieldDescriptor{ID: 0, Name: "success", Type: d.method.ReturnType})
+		value["success"] = result
+	}
+	fields = append(fields, d.method.Exceptions...)
+	if ex != nil {
+		value[ex.Field] = ex.Value
+	}
+	assert.Nil(d.t, oprot.WriteResponseHeader(ctx))
+	assert.Nil(d.t, oprot.WriteMessageBegin(d.method.Name, thrift.REPLY, 0))
+	assert.Nil(d.t, codec.writeStruct(oprot, "result", fields, value, "result"))
+	assert.Nil(d.t, oprot.WriteMessageEnd())
+	return oprot.Flush()
+}
+
+func (d *dynamicProcessorFunction) AddMiddleware(ServiceMiddleware) {}
+
+// newDynamicTestServer returns a server for the Base and Store services of the
+// test descriptors.
+func newDynamicTestServer(t *testing.T) *httptest.Server {
+	processor := NewFBaseProcessor()
+	processor.AddServiceDescriptor(baseDescriptor)
+	processor.AddServiceDescriptor(storeDescriptor)
+	descriptors := processor.ServiceDescriptors()
+	ping, _ := descriptors[0].Method("ping")
+	processor.AddToProcessorMap("ping", &dynamicProcessorFunction{
+		t: t, service: descriptors[0], method: ping,
+		handle: func(map[string]interface{}) (interface{}, *FDynamicException) { return nil, nil },
+	})
+	getAlbum, _ := descriptors[1].Method("getAlbum")
+	processor.AddToProcessorMap("getAlbum", &dynamicProcessorFunction{
+		t: t, service: descriptors[1], method: getAlbum,
+		handle: func(args map[string]interface{}) (interface{}, *FDynamicException) {
+			if args["ASIN"] == "missing" {
+				return nil, &FDynamicException{Field: "notFound", Value: map[string]interface{}{"message": "no album"}}
+			}
+			return map[string]interface{}{"ASIN": args["ASIN"], "genre": "JAZZ"}, nil
+		},
+	})
+	protocolFactory := NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault())
+	return httptest.NewServer(NewFrugalHandlerFunc(processor, protocolFactory))
+}
+
+func newDynamicTestClient(t *testing.T, url string) *FDynamicClient {
+	transport := NewFHTTPTransportBuilder(http.DefaultClient, url).Build()
+	assert.Nil(t, transport.Open())
+	protocolFactory := NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault())
+	return NewFDynamicClient(NewFServiceProvider(transport, protocolFactory))
+}
+
+// Ensures FDynamicClient gets descriptors from the reflection method and calls
+// methods, including those of extended services, with JSON-shaped values.
+func TestFDynamicClientCall(t *testing.T) {
+	server := newDynamicTestServer(t)
+	defer server.Close()
+	client := newDynamicTestClient(t, server.URL)
+
+	services, err := client.Reflect(NewFContext(""))
+	assert.Nil(t, err)
+	assert.Len(t, services, 2)
+	assert.Equal(t, services, client.Services())
+
+	result, err := client.Call(NewFContext(""), "Store", "getAlbum", map[string]interface{}{"ASIN": "abc"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"ASIN": "abc", "genre": "JAZZ"}, result)
+
+	result, err = client.Call(NewFContext(""), "Store", "ping", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, result)
+
+	_, err = client.Call(NewFContext(""), "Store", "getAlbum", map[string]interface{}{"ASIN": "missing"})
+	assert.Equal(t, &FDynamicException{
+		Field: "notFound",
+		Type:  "music.NotFound",
+		Value: map[string]interface{}{"message": "no album"},
+	}, err)
+	assert.Equal(t, `music.NotFound: {"message":"no album"}`, err.Error())
+}
+
+// Ensures FDynamicClient reports unknown services, methods, and arguments.
+func TestFDynamicClientCallErrors(t *testing.T) {
+	client := newDynamicTestClient(t, "http://localhost")
+	store := &FServiceDescriptor{}
+	assert.Nil(t, json.Unmarshal([]byte(storeDescriptor), store))
+	client.AddServices(store)
+
+	_, err := client.Call(NewFContext(""), "Catalog", "getAlbum", nil)
+	assert.EqualError(t, err, "frugal: unknown service Catalog")
+	_, err = client.Call(NewFContext(""), "Store", "ping", nil)
+	assert.EqualError(t, err, "frugal: unknown service Base extended by Store")
+	_, err = client.Call(NewFContext(""), "Store", "getAlbum", map[string]interface{}{"asin": "abc"})
+	assert.EqualError(t, err, "frugal: unknown field getAlbum.asin")
+	_, err = client.Call(NewFContext(""), "Store", "getAlbum", map[string]interface{}{"ASIN": 1.0})
+	assert.EqualError(t, err, "frugal: invalid value 1 for getAlbum.ASIN: expected string")
+}
+
+// Ensures dynamicCodec writes values read back by it, converting JSON-shaped
+// numbers, enums, binary, and maps.
+func TestDynamicCodecRoundTrip(t *testing.T) {
+	codec := &dynamicCodec{types: map[string]*FTypeDefinition{
+		"music.Genre": {Kind: DescriptorKindEnum, Values: map[string]int32{"ROCK": 0, "JAZZ": 1}},
+	}}
+	i64 := &FTypeDescriptor{Kind: DescriptorKindBase, Name: "i64"}
+	str := &FTypeDescriptor{Kind: DescriptorKindBase, Name: "string"}
+	fields := []*FFieldDescriptor{
+		{ID: 1, Name: "count", Type: &FTypeDescriptor{Kind: DescriptorKindBase, Name: "i32"}},
+		{ID: 2, Name: "id", Type: i64, Required: true},
+		{ID: 3, Name: "cover", Type: &FTypeDescriptor{Kind: DescriptorKindBase, Name: "binary"}},
+		{ID: 4, Name: "genres", Type: &FTypeDescriptor{Kind: DescriptorKindSet,
+			ValueType: &FTypeDescriptor{Kind: DescriptorKindEnum, Name: "music.Genre"}}},
+		{ID: 5, Name: "byID", Type: &FTypeDescriptor{Kind: DescriptorKindMap, KeyType: i64, ValueType: str}},
+		{ID: 6, Name: "byName", Type: &FTypeDescriptor{Kind: DescriptorKindMap, KeyType: str, ValueType: i64}},
+		{ID: 7, Name: "ratio", Type: &FTypeDescriptor{Kind: DescriptorKindBase, Name: "double"}},
+	}
+	value := map[string]interface{}{
+		"count":  json.Number("3"),
+		"id":     "9007199254740993",
+		"cover":  "AQI=",
+		"genres": []interface{}{"JAZZ", 0.0},
+		"byID":   map[string]interface{}{"7": "seven"},
+		"byName": map[string]interface{}{"seven": 7},
+		"ratio":  1,
+	}
+	proto := thrift.NewTCompactProtocol(thrift.NewTMemoryBuffer())
+	assert.Nil(t, codec.writeStruct(proto, "s", fields, value, "s"))
+
+	actual, err := codec.readStruct(proto, fields)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"count":  int64(3),
+		"id":     int64(9007199254740993),
+		"cover":  []byte{1, 2},
+		"genres": []interface{}{"JAZZ", "ROCK"},
+		"byID":   []interface{}{map[string]interface{}{"key": int64(7), "value": "seven"}},
+		"byName": map[string]interface{}{"seven": int64(7)},
+		"ratio":  1.0,
+	}, actual)
+
+	delete(value, "id")
+	assert.EqualError(t, codec.writeStruct(proto, "s", fields, value, "s"), "frugal: missing required field s.id")
+	value["id"] = 1.5
+	assert.EqualError(t, codec.writeStruct(proto, "s", fields, value, "s"), "frugal: invalid value for s.id: 1.5 is not an integer")
+	value["id"] = 1
+	value["count"] = 1 << 40
+	assert.EqualError(t, codec.writeStruct(proto, "s", fields, value, "s"), "frugal: invalid value for s.count: 1099511627776 is out of range")
+}
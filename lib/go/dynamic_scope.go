@@ -0,0 +1,158 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"fmt"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// FDynamicPublisher publishes to operations of a scope described by an
+// FScopeDescriptor without generated code, taking values shaped like those
+// of FDynamicClient. Messages are the same as those of generated publishers.
+type FDynamicPublisher struct {
+	transport       FPublisherTransport
+	protocolFactory *FProtocolFactory
+	scope           *FScopeDescriptor
+	method          *Method
+}
+
+// NewFDynamicPublisher returns a new FDynamicPublisher for the scope.
+// ServiceMiddleware of the provider is applied to publishes.
+func NewFDynamicPublisher(provider *FScopeProvider, scope *FScopeDescriptor) *FDynamicPublisher {
+	transport, protocolFactory := provider.NewPublisher()
+	publisher := &FDynamicPublisher{
+		transport:       transport,
+		protocolFactory: protocolFactory,
+		scope:           scope,
+	}
+	publisher.method = NewMethod(publisher, publisher.publish, "publish", provider.GetMiddleware())
+	return publisher
+}
+
+// Open opens the publisher's transport.
+func (p *FDynamicPublisher) Open() error {
+	return p.transport.Open()
+}
+
+// Close closes the publisher's transport.
+func (p *FDynamicPublisher) Close() error {
+	return p.transport.Close()
+}
+
+// Publish publishes the value to the named operation, given the values of the
+// scope's prefix variables.
+func (p *FDynamicPublisher) Publish(ctx FContext, op string, variables map[string]string, value interface{}) error {
+	operation, ok := p.scope.Operation(op)
+	if !ok {
+		return fmt.Errorf("frugal: unknown operation %s of scope %s", op, p.scope.Name)
+	}
+	if value == nil {
+		return fmt.Errorf("frugal: missing value of operation %s", op)
+	}
+	topic, err := p.scope.Topic(op, variables)
+	if err != nil {
+		return err
+	}
+	for _, variable := range p.scope.Variables {
+		ctx.AddRequestHeader("_topic_"+variable, variables[variable])
+	}
+	return p.method.Invoke(Arguments{ctx, topic, operation, value}).Error()
+}
+
+func (p *FDynamicPublisher) publish(ctx FContext, topic string, op *FOperationDescriptor, value interface{}) error {
+	codec := &dynamicCodec{types: p.scope.Types}
+	buffer := NewTMemoryOutputBuffer(p.transport.GetPublishSizeLimit())
+	oprot := p.protocolFactory.GetProtocol(buffer)
+	if err := oprot.WriteRequestHeader(ctx); err != nil {
+		return err
+	}
+	if err := oprot.WriteMessageBegin(op.Name, thrift.CALL, 0); err != nil {
+		return err
+	}
+	if err := codec.writeValue(oprot, op.Type, value, op.Name); err != nil {
+		return err
+	}
+	if err := oprot.WriteMessageEnd(); err != nil {
+		return err
+	}
+	if err := oprot.Flush(); err != nil {
+		return err
+	}
+	return p.transport.Publish(topic, buffer.Bytes())
+}
+
+// FDynamicSubscriber subscribes to operations of a scope described by an
+// FScopeDescriptor without generated code, passing handlers values shaped
+// like those returned by FDynamicClient.
+type FDynamicSubscriber struct {
+	provider *FScopeProvider
+	scope    *FScopeDescriptor
+}
+
+// NewFDynamicSubscriber returns a new FDynamicSubscriber for the scope.
+// ServiceMiddleware of the provider is applied to handlers.
+func NewFDynamicSubscriber(provider *FScopeProvider, scope *FScopeDescriptor) *FDynamicSubscriber {
+	return &FDynamicSubscriber{provider: provider, scope: scope}
+}
+
+// Subscribe subscribes to the named operation, given the values of the
+// scope's prefix variables, calling the handler with the context and value of
+// each message received.
+func (s *FDynamicSubscriber) Subscribe(op string, variables map[string]string, handler func(FContext, interface{}) error) (*FSubscription, error) {
+	operation, ok := s.scope.Operation(op)
+	if !ok {
+		return nil, fmt.Errorf("frugal: unknown operation %s of scope %s", op, s.scope.Name)
+	}
+	topic, err := s.scope.Topic(op, variables)
+	if err != nil {
+		return nil, err
+	}
+	transport, protocolFactory := s.provider.NewSubscriber()
+	if err := transport.Subscribe(topic, s.recv(operation, protocolFactory, handler)); err != nil {
+		return nil, err
+	}
+	return NewFSubscription(topic, transport), nil
+}
+
+func (s *FDynamicSubscriber) recv(op *FOperationDescriptor, pf *FProtocolFactory, handler func(FContext, interface{}) error) FAsyncCallback {
+	codec := &dynamicCodec{types: s.scope.Types}
+	method := NewMethod(s, handler, "Subscribe", s.provider.GetMiddleware())
+	return func(transport thrift.TTransport) error {
+		iprot := pf.GetProtocol(transport)
+		ctx, err := iprot.ReadRequestHeader()
+		if err != nil {
+			return err
+		}
+
+		name, _, _, err := iprot.ReadMessageBegin()
+		if err != nil {
+			return err
+		}
+
+		if name != op.Name {
+			iprot.Skip(thrift.STRUCT)
+			iprot.ReadMessageEnd()
+			return thrift.NewTApplicationException(APPLICATION_EXCEPTION_UNKNOWN_METHOD, "Unknown function"+name)
+		}
+		value, err := codec.readValue(iprot, op.Type)
+		if err != nil {
+			return err
+		}
+		iprot.ReadMessageEnd()
+
+		return method.Invoke(Arguments{ctx, value}).Error()
+	}
+}
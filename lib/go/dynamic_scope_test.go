@@ -0,0 +1,162 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/stretchr/testify/assert"
+)
+
+const winnersDescriptor = `{
+	"name": "AlbumWinners",
+	"prefix": "v1.music.{region}",
+	"variables": ["region"],
+	"operations": [
+		{"name": "Winner", "type": {"kind": "struct", "name": "music.Album"}},
+		{"name": "ContestStart", "type": {"kind": "list", "valueType": {"kind": "struct", "name": "music.Album"}}},
+		{"name": "TimeLeft", "type": {"kind": "base", "name": "double"}}
+	],
+	"types": {
+		"music.Album": {"kind": "struct", "fields": [
+			{"id": 1, "name": "ASIN", "type": {"kind": "base", "name": "string"}},
+			{"id": 2, "name": "genre", "type": {"kind": "enum", "name": "music.Genre"}}
+		]},
+		"music.Genre": {"kind": "enum", "values": {"ROCK": 0, "JAZZ": 1}}
+	}
+}`
+
+// loopbackScopeTransport delivers published messages to the subscriber of
+// their topic, if any.
+type loopbackScopeTransport struct {
+	subscribers map[string]FAsyncCallback
+	topic       string
+}
+
+func (l *loopbackScopeTransport) GetTransport() FPublisherTransport { return l }
+func (l *loopbackScopeTransport) Open() error                       { return nil }
+func (l *loopbackScopeTransport) Close() error                      { return nil }
+func (l *loopbackScopeTransport) IsOpen() bool                      { return true }
+func (l *loopbackScopeTransport) GetPublishSizeLimit() uint         { return 0 }
+
+func (l *loopbackScopeTransport) Publish(topic string, data []byte) error {
+	callback, ok := l.subscribers[topic]
+	if !ok {
+		return nil
+	}
+	return callback(&thrift.TMemoryBuffer{Buffer: bytes.NewBuffer(data[4:])})
+}
+
+type loopbackSubscriberFactory struct {
+	subscribers map[string]FAsyncCallback
+}
+
+func (l *loopbackSubscriberFactory) GetTransport() FSubscriberTransport {
+	return &loopbackScopeTransport{subscribers: l.subscribers}
+}
+
+func (l *loopbackScopeTransport) Subscribe(topic string, callback FAsyncCallback) error {
+	l.subscribers[topic] = callback
+	l.topic = topic
+	return nil
+}
+
+func (l *loopbackScopeTransport) Unsubscribe() error {
+	delete(l.subscribers, l.topic)
+	return nil
+}
+
+func (l *loopbackScopeTransport) IsSubscribed() bool {
+	_, ok := l.subscribers[l.topic]
+	return ok
+}
+
+func newLoopbackScopeProvider() *FScopeProvider {
+	subscribers := make(map[string]FAsyncCallback)
+	return NewFScopeProvider(
+		&loopbackScopeTransport{subscribers: subscribers},
+		&loopbackSubscriberFactory{subscribers: subscribers},
+		NewFProtocolFactory(thrift.NewTCompactProtocolFactory()))
+}
+
+func newWinnersDescriptor(t *testing.T) *FScopeDescriptor {
+	scope := &FScopeDescriptor{}
+	assert.Nil(t, json.Unmarshal([]byte(winnersDescriptor), scope))
+	return scope
+}
+
+// Ensures published values are received by subscribers of the operation with
+// the prefix variables set as headers.
+func TestFDynamicPublisherSubscriber(t *testing.T) {
+	provider := newLoopbackScopeProvider()
+	scope := newWinnersDescriptor(t)
+	publisher := NewFDynamicPublisher(provider, scope)
+	assert.Nil(t, publisher.Open())
+	subscriber := NewFDynamicSubscriber(provider, scope)
+
+	var (
+		received []interface{}
+		headers  map[string]string
+	)
+	handler := func(ctx FContext, value interface{}) error {
+		received = append(received, value)
+		headers = ctx.RequestHeaders()
+		return nil
+	}
+	sub, err := subscriber.Subscribe("Winner", map[string]string{"region": "us"}, handler)
+	assert.Nil(t, err)
+	assert.Equal(t, "v1.music.us.AlbumWinners.Winner", sub.Topic())
+	_, err = subscriber.Subscribe("TimeLeft", map[string]string{"region": "us"}, handler)
+	assert.Nil(t, err)
+
+	album := map[string]interface{}{"ASIN": "B000002UAU", "genre": "JAZZ"}
+	ctx := NewFContext("cid")
+	assert.Nil(t, publisher.Publish(ctx, "Winner", map[string]string{"region": "us"}, album))
+	assert.Nil(t, publisher.Publish(NewFContext(""), "TimeLeft", map[string]string{"region": "us"}, 1.5))
+	assert.Nil(t, publisher.Publish(NewFContext(""), "Winner", map[string]string{"region": "eu"}, album))
+
+	assert.Equal(t, []interface{}{album, 1.5}, received)
+	assert.Equal(t, "us", headers["_topic_region"])
+
+	assert.Nil(t, sub.Unsubscribe())
+	assert.Nil(t, publisher.Publish(ctx, "Winner", map[string]string{"region": "us"}, album))
+	assert.Len(t, received, 2)
+	assert.Nil(t, publisher.Close())
+}
+
+// Ensures invalid operations, variables, and values are rejected.
+func TestFDynamicPublisherSubscriberErrors(t *testing.T) {
+	provider := newLoopbackScopeProvider()
+	scope := newWinnersDescriptor(t)
+	publisher := NewFDynamicPublisher(provider, scope)
+	subscriber := NewFDynamicSubscriber(provider, scope)
+	variables := map[string]string{"region": "us"}
+
+	err := publisher.Publish(NewFContext(""), "Loser", variables, 1)
+	assert.Equal(t, "frugal: unknown operation Loser of scope AlbumWinners", err.Error())
+	err = publisher.Publish(NewFContext(""), "Winner", nil, map[string]interface{}{})
+	assert.Equal(t, "frugal: missing value of prefix variable region of scope AlbumWinners", err.Error())
+	err = publisher.Publish(NewFContext(""), "Winner", variables, nil)
+	assert.Equal(t, "frugal: missing value of operation Winner", err.Error())
+	err = publisher.Publish(NewFContext(""), "Winner", variables, map[string]interface{}{"year": 1959})
+	assert.Equal(t, "frugal: unknown field Winner.year", err.Error())
+
+	_, err = subscriber.Subscribe("Loser", variables, nil)
+	assert.Equal(t, "frugal: unknown operation Loser of scope AlbumWinners", err.Error())
+	_, err = subscriber.Subscribe("Winner", nil, nil)
+	assert.Equal(t, "frugal: missing value of prefix variable region of scope AlbumWinners", err.Error())
+}
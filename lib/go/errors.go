@@ -55,6 +55,10 @@ const (
 	// APPLICATION_EXCEPTION_RESPONSE_TOO_LARGE is a TApplicationException
 	// error type indicating the response exceeded the size limit.
 	APPLICATION_EXCEPTION_RESPONSE_TOO_LARGE = 100
+
+	// APPLICATION_EXCEPTION_VALIDATION_ERROR is a TApplicationException
+	// error type indicating the request arguments failed validation.
+	APPLICATION_EXCEPTION_VALIDATION_ERROR = 101
 )
 
 // IsErrTooLarge indicates if the given error is a TTransportException
@@ -0,0 +1,800 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// Compact protocol type identifiers.
+const (
+	compactBooleanTrue  = 0x01
+	compactBooleanFalse = 0x02
+	compactByte         = 0x03
+	compactI16          = 0x04
+	compactI32          = 0x05
+	compactI64          = 0x06
+	compactDouble       = 0x07
+	compactBinary       = 0x08
+	compactList         = 0x09
+	compactSet          = 0x0A
+	compactMap          = 0x0B
+	compactStruct       = 0x0C
+)
+
+const (
+	// fastWriterBufferSize is the initial size of pooled writer buffers.
+	fastWriterBufferSize = 512
+
+	// maxPooledBufferSize is the largest writer buffer returned to the pool,
+	// so an occasional large struct doesn't pin its memory.
+	maxPooledBufferSize = 64 * 1024
+)
+
+var (
+	errFastUnexpectedEOF = thrift.NewTProtocolExceptionWithType(
+		thrift.INVALID_DATA, errors.New("frugal: unexpected end of data"))
+	errFastNegativeSize = thrift.NewTProtocolExceptionWithType(
+		thrift.NEGATIVE_SIZE, errors.New("frugal: negative size"))
+	errFastSizeLimit = thrift.NewTProtocolExceptionWithType(
+		thrift.SIZE_LIMIT, errors.New("frugal: size exceeds remaining data"))
+	errFastDepthLimit = thrift.NewTProtocolExceptionWithType(
+		thrift.DEPTH_LIMIT, errors.New("frugal: depth limit exceeded"))
+)
+
+var fastWriterPool = sync.Pool{
+	New: func() interface{} {
+		return &FFastWriter{buf: make([]byte, 0, fastWriterBufferSize)}
+	},
+}
+
+var fastReaderPool = sync.Pool{
+	New: func() interface{} {
+		return &FFastReader{}
+	},
+}
+
+// FFastCodec is implemented by structs generated with the Go generator's
+// fast_codec option. They encode and decode themselves directly to and from
+// byte slices in the binary or compact protocol.
+type FFastCodec interface {
+	// FastWrite appends the struct to the FFastWriter.
+	FastWrite(w *FFastWriter) error
+
+	// FastRead decodes the struct from the FFastReader.
+	FastRead(r *FFastReader) error
+}
+
+// FastWrite writes the struct to the protocol with its fast codec if the
+// protocol is a TBinaryProtocol or TCompactProtocol, which may be wrapped in
+// an FProtocol. It returns false without writing anything for other
+// protocols, which the struct's generic Write must be used with.
+func FastWrite(oprot thrift.TProtocol, s FFastCodec) (bool, error) {
+	compact, ok := fastProtocol(oprot)
+	if !ok {
+		return false, nil
+	}
+
+	w := fastWriterPool.Get().(*FFastWriter)
+	w.reset(compact)
+	defer func() {
+		if cap(w.buf) <= maxPooledBufferSize {
+			fastWriterPool.Put(w)
+		}
+	}()
+
+	if err := s.FastWrite(w); err != nil {
+		return true, err
+	}
+	if _, err := oprot.Transport().Write(w.buf); err != nil {
+		return true, thrift.NewTProtocolException(err)
+	}
+	return true, nil
+}
+
+// FastRead reads the struct from the protocol with its fast codec if the
+// protocol is a TBinaryProtocol or TCompactProtocol, which may be wrapped in
+// an FProtocol, reading from a TMemoryBuffer, as the protocols Frugal
+// processors and subscribers are given do. It returns false without reading
+// anything otherwise, and the struct's generic Read must be used.
+func FastRead(iprot thrift.TProtocol, s FFastCodec) (bool, error) {
+	compact, ok := fastProtocol(iprot)
+	if !ok {
+		return false, nil
+	}
+	buffer, ok := iprot.Transport().(*thrift.TMemoryBuffer)
+	if !ok {
+		return false, nil
+	}
+
+	r := fastReaderPool.Get().(*FFastReader)
+	r.reset(buffer.Bytes(), compact)
+	err := s.FastRead(r)
+	buffer.Next(r.pos)
+	r.reset(nil, false)
+	fastReaderPool.Put(r)
+	return true, err
+}
+
+// fastProtocol returns if the given protocol is one the fast codec supports
+// and, if so, whether it's the compact protocol.
+func fastProtocol(prot thrift.TProtocol) (compact, ok bool) {
+	if fprot, isFProtocol := prot.(*FProtocol); isFProtocol {
+		prot = fprot.TProtocol
+	}
+	switch prot.(type) {
+	case *thrift.TBinaryProtocol:
+		return false, true
+	case *thrift.TCompactProtocol:
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+// FFastWriter appends values to a byte slice in the binary or compact
+// protocol. Writes can't fail, so they don't return errors.
+type FFastWriter struct {
+	buf     []byte
+	compact bool
+
+	// Compact field IDs are written as deltas from the previous field of the
+	// struct, and boolean fields have their values in their headers.
+	lastFieldID int16
+	lastFields  []int16
+	boolFieldID int16
+	boolPending bool
+}
+
+// NewFFastWriter returns an FFastWriter for the compact protocol if compact
+// is true and the binary protocol otherwise.
+func NewFFastWriter(compact bool) *FFastWriter {
+	w := &FFastWriter{buf: make([]byte, 0, fastWriterBufferSize)}
+	w.reset(compact)
+	return w
+}
+
+func (w *FFastWriter) reset(compact bool) {
+	w.buf = w.buf[:0]
+	w.compact = compact
+	w.lastFieldID = 0
+	w.lastFields = w.lastFields[:0]
+	w.boolPending = false
+}
+
+// Bytes returns the values written.
+func (w *FFastWriter) Bytes() []byte {
+	return w.buf
+}
+
+// WriteStructBegin begins writing a struct.
+func (w *FFastWriter) WriteStructBegin() {
+	if w.compact {
+		w.lastFields = append(w.lastFields, w.lastFieldID)
+		w.lastFieldID = 0
+	}
+}
+
+// WriteStructEnd ends writing a struct.
+func (w *FFastWriter) WriteStructEnd() {
+	if w.compact {
+		w.lastFieldID = w.lastFields[len(w.lastFields)-1]
+		w.lastFields = w.lastFields[:len(w.lastFields)-1]
+	}
+}
+
+// WriteFieldBegin writes the header of a field with the given type and ID.
+// The header of a compact boolean field is written by WriteBool.
+func (w *FFastWriter) WriteFieldBegin(fieldType thrift.TType, id int16) {
+	if !w.compact {
+		w.buf = append(w.buf, byte(fieldType), byte(id>>8), byte(id))
+		return
+	}
+	if fieldType == thrift.BOOL {
+		w.boolFieldID = id
+		w.boolPending = true
+		return
+	}
+	w.writeCompactFieldHeader(compactType(fieldType), id)
+}
+
+func (w *FFastWriter) writeCompactFieldHeader(typ byte, id int16) {
+	if id > w.lastFieldID && id-w.lastFieldID <= 15 {
+		w.buf = append(w.buf, byte(id-w.lastFieldID)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.writeVarint(uint64(zigzag32(int32(id))))
+	}
+	w.lastFieldID = id
+}
+
+// WriteFieldStop ends the fields of a struct.
+func (w *FFastWriter) WriteFieldStop() {
+	w.buf = append(w.buf, byte(thrift.STOP))
+}
+
+// WriteListBegin writes the header of a list.
+func (w *FFastWriter) WriteListBegin(elemType thrift.TType, size int) {
+	w.writeCollectionBegin(elemType, size)
+}
+
+// WriteSetBegin writes the header of a set.
+func (w *FFastWriter) WriteSetBegin(elemType thrift.TType, size int) {
+	w.writeCollectionBegin(elemType, size)
+}
+
+func (w *FFastWriter) writeCollectionBegin(elemType thrift.TType, size int) {
+	if !w.compact {
+		w.buf = append(w.buf, byte(elemType))
+		w.writeI32(int32(size))
+		return
+	}
+	if size <= 14 {
+		w.buf = append(w.buf, byte(size)<<4|compactType(elemType))
+		return
+	}
+	w.buf = append(w.buf, 0xf0|compactType(elemType))
+	w.writeVarint(uint64(size))
+}
+
+// WriteMapBegin writes the header of a map.
+func (w *FFastWriter) WriteMapBegin(keyType, valueType thrift.TType, size int) {
+	if !w.compact {
+		w.buf = append(w.buf, byte(keyType), byte(valueType))
+		w.writeI32(int32(size))
+		return
+	}
+	if size == 0 {
+		w.buf = append(w.buf, 0)
+		return
+	}
+	w.writeVarint(uint64(size))
+	w.buf = append(w.buf, compactType(keyType)<<4|compactType(valueType))
+}
+
+// WriteBool writes a bool, which is the header of a pending compact boolean
+// field.
+func (w *FFastWriter) WriteBool(value bool) {
+	if w.compact {
+		typ := byte(compactBooleanFalse)
+		if value {
+			typ = compactBooleanTrue
+		}
+		if w.boolPending {
+			w.boolPending = false
+			w.writeCompactFieldHeader(typ, w.boolFieldID)
+			return
+		}
+		w.buf = append(w.buf, typ)
+		return
+	}
+	if value {
+		w.buf = append(w.buf, 1)
+	} else {
+		w.buf = append(w.buf, 0)
+	}
+}
+
+// WriteI8 writes a byte.
+func (w *FFastWriter) WriteI8(value int8) {
+	w.buf = append(w.buf, byte(value))
+}
+
+// WriteI16 writes an i16.
+func (w *FFastWriter) WriteI16(value int16) {
+	if w.compact {
+		w.writeVarint(uint64(zigzag32(int32(value))))
+		return
+	}
+	w.buf = append(w.buf, byte(value>>8), byte(value))
+}
+
+// WriteI32 writes an i32.
+func (w *FFastWriter) WriteI32(value int32) {
+	if w.compact {
+		w.writeVarint(uint64(zigzag32(value)))
+		return
+	}
+	w.writeI32(value)
+}
+
+// WriteI64 writes an i64.
+func (w *FFastWriter) WriteI64(value int64) {
+	if w.compact {
+		w.writeVarint(uint64(value<<1) ^ uint64(value>>63))
+		return
+	}
+	w.buf = append(w.buf, byte(value>>56), byte(value>>48), byte(value>>40), byte(value>>32),
+		byte(value>>24), byte(value>>16), byte(value>>8), byte(value))
+}
+
+// WriteDouble writes a double.
+func (w *FFastWriter) WriteDouble(value float64) {
+	bits := math.Float64bits(value)
+	if w.compact {
+		w.buf = append(w.buf, byte(bits), byte(bits>>8), byte(bits>>16), byte(bits>>24),
+			byte(bits>>32), byte(bits>>40), byte(bits>>48), byte(bits>>56))
+		return
+	}
+	w.buf = append(w.buf, byte(bits>>56), byte(bits>>48), byte(bits>>40), byte(bits>>32),
+		byte(bits>>24), byte(bits>>16), byte(bits>>8), byte(bits))
+}
+
+// WriteString writes a string.
+func (w *FFastWriter) WriteString(value string) {
+	w.writeSize(len(value))
+	w.buf = append(w.buf, value...)
+}
+
+// WriteBinary writes binary.
+func (w *FFastWriter) WriteBinary(value []byte) {
+	w.writeSize(len(value))
+	w.buf = append(w.buf, value...)
+}
+
+func (w *FFastWriter) writeSize(size int) {
+	if w.compact {
+		w.writeVarint(uint64(size))
+		return
+	}
+	w.writeI32(int32(size))
+}
+
+func (w *FFastWriter) writeI32(value int32) {
+	w.buf = append(w.buf, byte(value>>24), byte(value>>16), byte(value>>8), byte(value))
+}
+
+func (w *FFastWriter) writeVarint(value uint64) {
+	for value >= 0x80 {
+		w.buf = append(w.buf, byte(value)|0x80)
+		value >>= 7
+	}
+	w.buf = append(w.buf, byte(value))
+}
+
+// FFastReader decodes values from a byte slice in the binary or compact
+// protocol. The first error encountered is kept and returned by Err, and
+// reads after it return zero values, so callers only check it once per
+// field.
+type FFastReader struct {
+	buf     []byte
+	pos     int
+	compact bool
+	err     error
+
+	lastFieldID int16
+	lastFields  []int16
+	boolValue   bool
+	boolPending bool
+}
+
+// NewFFastReader returns an FFastReader decoding the given bytes in the
+// compact protocol if compact is true and the binary protocol otherwise.
+func NewFFastReader(buf []byte, compact bool) *FFastReader {
+	r := &FFastReader{}
+	r.reset(buf, compact)
+	return r
+}
+
+func (r *FFastReader) reset(buf []byte, compact bool) {
+	r.buf = buf
+	r.pos = 0
+	r.compact = compact
+	r.err = nil
+	r.lastFieldID = 0
+	r.lastFields = r.lastFields[:0]
+	r.boolPending = false
+}
+
+// Err returns the first error encountered, if any.
+func (r *FFastReader) Err() error {
+	return r.err
+}
+
+// Pos returns the number of bytes read.
+func (r *FFastReader) Pos() int {
+	return r.pos
+}
+
+func (r *FFastReader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+	// Stop reading
+	r.pos = len(r.buf)
+}
+
+// next returns the next n bytes, or nil if there aren't enough.
+func (r *FFastReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n > len(r.buf)-r.pos {
+		r.fail(errFastUnexpectedEOF)
+		return nil
+	}
+	b := r.buf[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+// ReadStructBegin begins reading a struct.
+func (r *FFastReader) ReadStructBegin() {
+	if r.compact {
+		r.lastFields = append(r.lastFields, r.lastFieldID)
+		r.lastFieldID = 0
+	}
+}
+
+// ReadStructEnd ends reading a struct.
+func (r *FFastReader) ReadStructEnd() {
+	if r.compact && len(r.lastFields) > 0 {
+		r.lastFieldID = r.lastFields[len(r.lastFields)-1]
+		r.lastFields = r.lastFields[:len(r.lastFields)-1]
+	}
+}
+
+// ReadFieldBegin reads the header of a field, returning its type and ID. The
+// type is STOP after the last field or if there's an error.
+func (r *FFastReader) ReadFieldBegin() (thrift.TType, int16) {
+	b := r.next(1)
+	if b == nil || b[0] == byte(thrift.STOP) {
+		return thrift.STOP, 0
+	}
+	if !r.compact {
+		id := r.next(2)
+		if id == nil {
+			return thrift.STOP, 0
+		}
+		return thrift.TType(b[0]), int16(binary.BigEndian.Uint16(id))
+	}
+
+	var id int16
+	if delta := int16(b[0] >> 4); delta != 0 {
+		id = r.lastFieldID + delta
+	} else {
+		id = r.ReadI16()
+	}
+	r.lastFieldID = id
+	typ := b[0] & 0x0f
+	if typ == compactBooleanTrue || typ == compactBooleanFalse {
+		r.boolValue = typ == compactBooleanTrue
+		r.boolPending = true
+	}
+	fieldType := r.ttype(typ)
+	if r.err != nil {
+		return thrift.STOP, 0
+	}
+	return fieldType, id
+}
+
+// ReadListBegin reads the header of a list, returning its element type and
+// size.
+func (r *FFastReader) ReadListBegin() (thrift.TType, int) {
+	return r.readCollectionBegin()
+}
+
+// ReadSetBegin reads the header of a set, returning its element type and
+// size.
+func (r *FFastReader) ReadSetBegin() (thrift.TType, int) {
+	return r.readCollectionBegin()
+}
+
+func (r *FFastReader) readCollectionBegin() (thrift.TType, int) {
+	b := r.next(1)
+	if b == nil {
+		return thrift.STOP, 0
+	}
+	if !r.compact {
+		return thrift.TType(b[0]), r.checkSize(int64(r.readI32()))
+	}
+	size := int64(b[0] >> 4)
+	if size == 15 {
+		size = int64(r.readVarint())
+	}
+	return r.ttype(b[0] & 0x0f), r.checkSize(size)
+}
+
+// ReadMapBegin reads the header of a map, returning its key type, value
+// type, and size.
+func (r *FFastReader) ReadMapBegin() (thrift.TType, thrift.TType, int) {
+	if !r.compact {
+		b := r.next(2)
+		if b == nil {
+			return thrift.STOP, thrift.STOP, 0
+		}
+		return thrift.TType(b[0]), thrift.TType(b[1]), r.checkSize(int64(r.readI32()))
+	}
+	size := r.checkSize(int64(r.readVarint()))
+	if size == 0 {
+		return thrift.STOP, thrift.STOP, 0
+	}
+	b := r.next(1)
+	if b == nil {
+		return thrift.STOP, thrift.STOP, 0
+	}
+	return r.ttype(b[0] >> 4), r.ttype(b[0] & 0x0f), size
+}
+
+// checkSize returns the given size of a container, which can't be negative
+// and, since every element takes at least a byte, can't exceed the remaining
+// bytes.
+func (r *FFastReader) checkSize(size int64) int {
+	if r.err != nil {
+		return 0
+	}
+	if size < 0 {
+		r.fail(errFastNegativeSize)
+		return 0
+	}
+	if size > int64(len(r.buf)-r.pos) {
+		r.fail(errFastSizeLimit)
+		return 0
+	}
+	return int(size)
+}
+
+// ReadBool reads a bool, which is the value of the field if its header was
+// a compact boolean field.
+func (r *FFastReader) ReadBool() bool {
+	if r.compact && r.boolPending {
+		r.boolPending = false
+		return r.boolValue
+	}
+	b := r.next(1)
+	if b == nil {
+		return false
+	}
+	if r.compact {
+		return b[0] == compactBooleanTrue
+	}
+	return b[0] == 1
+}
+
+// ReadI8 reads a byte.
+func (r *FFastReader) ReadI8() int8 {
+	b := r.next(1)
+	if b == nil {
+		return 0
+	}
+	return int8(b[0])
+}
+
+// ReadI16 reads an i16.
+func (r *FFastReader) ReadI16() int16 {
+	if r.compact {
+		return int16(unzigzag32(uint32(r.readVarint())))
+	}
+	b := r.next(2)
+	if b == nil {
+		return 0
+	}
+	return int16(binary.BigEndian.Uint16(b))
+}
+
+// ReadI32 reads an i32.
+func (r *FFastReader) ReadI32() int32 {
+	if r.compact {
+		return unzigzag32(uint32(r.readVarint()))
+	}
+	return r.readI32()
+}
+
+// ReadI64 reads an i64.
+func (r *FFastReader) ReadI64() int64 {
+	if r.compact {
+		v := r.readVarint()
+		return int64(v>>1) ^ -int64(v&1)
+	}
+	b := r.next(8)
+	if b == nil {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+// ReadDouble reads a double.
+func (r *FFastReader) ReadDouble() float64 {
+	b := r.next(8)
+	if b == nil {
+		return 0
+	}
+	if r.compact {
+		return math.Float64frombits(binary.LittleEndian.Uint64(b))
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(b))
+}
+
+// ReadString reads a string.
+func (r *FFastReader) ReadString() string {
+	return string(r.next(r.readSize()))
+}
+
+// ReadBinary reads binary. The returned slice is a copy.
+func (r *FFastReader) ReadBinary() []byte {
+	b := r.next(r.readSize())
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+func (r *FFastReader) readSize() int {
+	var size int64
+	if r.compact {
+		size = int64(r.readVarint())
+	} else {
+		size = int64(r.readI32())
+	}
+	if size < 0 {
+		r.fail(errFastNegativeSize)
+		return 0
+	}
+	if size > int64(len(r.buf)-r.pos) {
+		r.fail(errFastUnexpectedEOF)
+		return 0
+	}
+	return int(size)
+}
+
+func (r *FFastReader) readI32() int32 {
+	b := r.next(4)
+	if b == nil {
+		return 0
+	}
+	return int32(binary.BigEndian.Uint32(b))
+}
+
+func (r *FFastReader) readVarint() uint64 {
+	var value uint64
+	for shift := uint(0); shift < 64; shift += 7 {
+		b := r.next(1)
+		if b == nil {
+			return 0
+		}
+		value |= uint64(b[0]&0x7f) << shift
+		if b[0]&0x80 == 0 {
+			return value
+		}
+	}
+	r.fail(thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, errors.New("frugal: varint too long")))
+	return 0
+}
+
+// ttype returns the TType of the given compact type.
+func (r *FFastReader) ttype(typ byte) thrift.TType {
+	switch typ {
+	case 0:
+		return thrift.STOP
+	case compactBooleanTrue, compactBooleanFalse:
+		return thrift.BOOL
+	case compactByte:
+		return thrift.BYTE
+	case compactI16:
+		return thrift.I16
+	case compactI32:
+		return thrift.I32
+	case compactI64:
+		return thrift.I64
+	case compactDouble:
+		return thrift.DOUBLE
+	case compactBinary:
+		return thrift.STRING
+	case compactList:
+		return thrift.LIST
+	case compactSet:
+		return thrift.SET
+	case compactMap:
+		return thrift.MAP
+	case compactStruct:
+		return thrift.STRUCT
+	}
+	r.fail(thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("frugal: unknown compact type %d", typ)))
+	return thrift.STOP
+}
+
+// Skip skips a value of the given type.
+func (r *FFastReader) Skip(fieldType thrift.TType) {
+	r.skip(fieldType, thrift.DEFAULT_RECURSION_DEPTH)
+}
+
+func (r *FFastReader) skip(fieldType thrift.TType, maxDepth int) {
+	if maxDepth <= 0 {
+		r.fail(errFastDepthLimit)
+		return
+	}
+	switch fieldType {
+	case thrift.BOOL:
+		r.ReadBool()
+	case thrift.BYTE:
+		r.ReadI8()
+	case thrift.I16:
+		r.ReadI16()
+	case thrift.I32:
+		r.ReadI32()
+	case thrift.I64:
+		r.ReadI64()
+	case thrift.DOUBLE:
+		r.ReadDouble()
+	case thrift.STRING:
+		r.next(r.readSize())
+	case thrift.STRUCT:
+		r.ReadStructBegin()
+		for {
+			typ, _ := r.ReadFieldBegin()
+			if typ == thrift.STOP {
+				break
+			}
+			r.skip(typ, maxDepth-1)
+		}
+		r.ReadStructEnd()
+	case thrift.MAP:
+		keyType, valueType, size := r.ReadMapBegin()
+		for i := 0; i < size && r.err == nil; i++ {
+			r.skip(keyType, maxDepth-1)
+			r.skip(valueType, maxDepth-1)
+		}
+	case thrift.LIST, thrift.SET:
+		elemType, size := r.readCollectionBegin()
+		for i := 0; i < size && r.err == nil; i++ {
+			r.skip(elemType, maxDepth-1)
+		}
+	default:
+		r.fail(thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("frugal: unknown type %d", fieldType)))
+	}
+}
+
+// compactType returns the compact type of the given TType.
+func compactType(fieldType thrift.TType) byte {
+	switch fieldType {
+	case thrift.BOOL:
+		return compactBooleanTrue
+	case thrift.BYTE:
+		return compactByte
+	case thrift.I16:
+		return compactI16
+	case thrift.I32:
+		return compactI32
+	case thrift.I64:
+		return compactI64
+	case thrift.DOUBLE:
+		return compactDouble
+	case thrift.STRING:
+		return compactBinary
+	case thrift.LIST:
+		return compactList
+	case thrift.SET:
+		return compactSet
+	case thrift.MAP:
+		return compactMap
+	case thrift.STRUCT:
+		return compactStruct
+	default:
+		return 0
+	}
+}
+
+func zigzag32(value int32) uint32 {
+	return uint32(value<<1) ^ uint32(value>>31)
+}
+
+func unzigzag32(value uint32) int32 {
+	return int32(value>>1) ^ -int32(value&1)
+}
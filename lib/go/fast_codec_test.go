@@ -0,0 +1,390 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/stretchr/testify/assert"
+)
+
+// fastTestStruct is a struct like those generated with the fast_codec
+// option:
+//
+//	struct fastTestStruct {
+//	    1: bool flag,
+//	    2: byte b,
+//	    3: i16 small,
+//	    20: i32 medium,
+//	    4: i64 large,
+//	    5: double ratio,
+//	    6: string name,
+//	    7: binary data,
+//	    8: list<bool> flags,
+//	    9: set<i32> ids,
+//	    10: map<string, i64> counts,
+//	    11: fastTestStruct child,
+//	    300: bool last,
+//	}
+type fastTestStruct struct {
+	Flag   bool
+	B      int8
+	Small  int16
+	Medium int32
+	Large  int64
+	Ratio  float64
+	Name   string
+	Data   []byte
+	Flags  []bool
+	IDs    []int32
+	Counts map[string]int64
+	Child  *fastTestStruct
+	Last   bool
+}
+
+func newFastTestStruct() *fastTestStruct {
+	return &fastTestStruct{
+		Flag:   true,
+		B:      -3,
+		Small:  -300,
+		Medium: math.MaxInt32,
+		Large:  math.MinInt64,
+		Ratio:  3.25,
+		Name:   "frugal",
+		Data:   []byte{0, 1, 2},
+		Flags:  []bool{true, false, true},
+		IDs:    []int32{1, -1, 1 << 20, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		Counts: map[string]int64{"a": 1},
+		Child: &fastTestStruct{
+			Name:   strings.Repeat("x", 200),
+			Data:   []byte{},
+			Flags:  []bool{},
+			IDs:    []int32{},
+			Counts: map[string]int64{},
+			Last:   true,
+		},
+		Last: false,
+	}
+}
+
+func (p *fastTestStruct) Write(oprot thrift.TProtocol) error {
+	if ok, err := FastWrite(oprot, p); ok {
+		return err
+	}
+	oprot.WriteStructBegin("fastTestStruct")
+	oprot.WriteFieldBegin("flag", thrift.BOOL, 1)
+	oprot.WriteBool(p.Flag)
+	oprot.WriteFieldBegin("b", thrift.BYTE, 2)
+	oprot.WriteByte(p.B)
+	oprot.WriteFieldBegin("small", thrift.I16, 3)
+	oprot.WriteI16(p.Small)
+	oprot.WriteFieldBegin("medium", thrift.I32, 20)
+	oprot.WriteI32(p.Medium)
+	oprot.WriteFieldBegin("large", thrift.I64, 4)
+	oprot.WriteI64(p.Large)
+	oprot.WriteFieldBegin("ratio", thrift.DOUBLE, 5)
+	oprot.WriteDouble(p.Ratio)
+	oprot.WriteFieldBegin("name", thrift.STRING, 6)
+	oprot.WriteString(p.Name)
+	oprot.WriteFieldBegin("data", thrift.STRING, 7)
+	oprot.WriteBinary(p.Data)
+	oprot.WriteFieldBegin("flags", thrift.LIST, 8)
+	oprot.WriteListBegin(thrift.BOOL, len(p.Flags))
+	for _, v := range p.Flags {
+		oprot.WriteBool(v)
+	}
+	oprot.WriteListEnd()
+	oprot.WriteFieldBegin("ids", thrift.SET, 9)
+	oprot.WriteSetBegin(thrift.I32, len(p.IDs))
+	for _, v := range p.IDs {
+		oprot.WriteI32(v)
+	}
+	oprot.WriteSetEnd()
+	oprot.WriteFieldBegin("counts", thrift.MAP, 10)
+	oprot.WriteMapBegin(thrift.STRING, thrift.I64, len(p.Counts))
+	for k, v := range p.Counts {
+		oprot.WriteString(k)
+		oprot.WriteI64(v)
+	}
+	oprot.WriteMapEnd()
+	if p.Child != nil {
+		oprot.WriteFieldBegin("child", thrift.STRUCT, 11)
+		// Use the generic path to check mixing it with the fast one
+		if err := p.Child.Write(genericProtocol{oprot}); err != nil {
+			return err
+		}
+	}
+	oprot.WriteFieldBegin("last", thrift.BOOL, 300)
+	oprot.WriteBool(p.Last)
+	oprot.WriteFieldStop()
+	return oprot.WriteStructEnd()
+}
+
+func (p *fastTestStruct) FastWrite(w *FFastWriter) error {
+	w.WriteStructBegin()
+	w.WriteFieldBegin(thrift.BOOL, 1)
+	w.WriteBool(p.Flag)
+	w.WriteFieldBegin(thrift.BYTE, 2)
+	w.WriteI8(p.B)
+	w.WriteFieldBegin(thrift.I16, 3)
+	w.WriteI16(p.Small)
+	w.WriteFieldBegin(thrift.I32, 20)
+	w.WriteI32(p.Medium)
+	w.WriteFieldBegin(thrift.I64, 4)
+	w.WriteI64(p.Large)
+	w.WriteFieldBegin(thrift.DOUBLE, 5)
+	w.WriteDouble(p.Ratio)
+	w.WriteFieldBegin(thrift.STRING, 6)
+	w.WriteString(p.Name)
+	w.WriteFieldBegin(thrift.STRING, 7)
+	w.WriteBinary(p.Data)
+	w.WriteFieldBegin(thrift.LIST, 8)
+	w.WriteListBegin(thrift.BOOL, len(p.Flags))
+	for _, v := range p.Flags {
+		w.WriteBool(v)
+	}
+	w.WriteFieldBegin(thrift.SET, 9)
+	w.WriteSetBegin(thrift.I32, len(p.IDs))
+	for _, v := range p.IDs {
+		w.WriteI32(v)
+	}
+	w.WriteFieldBegin(thrift.MAP, 10)
+	w.WriteMapBegin(thrift.STRING, thrift.I64, len(p.Counts))
+	for k, v := range p.Counts {
+		w.WriteString(k)
+		w.WriteI64(v)
+	}
+	if p.Child != nil {
+		w.WriteFieldBegin(thrift.STRUCT, 11)
+		if err := p.Child.FastWrite(w); err != nil {
+			return err
+		}
+	}
+	w.WriteFieldBegin(thrift.BOOL, 300)
+	w.WriteBool(p.Last)
+	w.WriteFieldStop()
+	w.WriteStructEnd()
+	return nil
+}
+
+func (p *fastTestStruct) Read(iprot thrift.TProtocol) error {
+	if ok, err := FastRead(iprot, p); ok {
+		return err
+	}
+	// The generic path is only used with the JSON protocol in these tests
+	return thrift.Skip(iprot, thrift.STRUCT, thrift.DEFAULT_RECURSION_DEPTH)
+}
+
+func (p *fastTestStruct) FastRead(r *FFastReader) error {
+	r.ReadStructBegin()
+	for {
+		fieldType, id := r.ReadFieldBegin()
+		if fieldType == thrift.STOP {
+			break
+		}
+		switch id {
+		case 1:
+			p.Flag = r.ReadBool()
+		case 2:
+			p.B = r.ReadI8()
+		case 3:
+			p.Small = r.ReadI16()
+		case 20:
+			p.Medium = r.ReadI32()
+		case 4:
+			p.Large = r.ReadI64()
+		case 5:
+			p.Ratio = r.ReadDouble()
+		case 6:
+			p.Name = r.ReadString()
+		case 7:
+			p.Data = r.ReadBinary()
+		case 8:
+			_, size := r.ReadListBegin()
+			p.Flags = make([]bool, 0, size)
+			for i := 0; i < size; i++ {
+				p.Flags = append(p.Flags, r.ReadBool())
+			}
+		case 9:
+			_, size := r.ReadSetBegin()
+			p.IDs = make([]int32, 0, size)
+			for i := 0; i < size; i++ {
+				p.IDs = append(p.IDs, r.ReadI32())
+			}
+		case 10:
+			_, _, size := r.ReadMapBegin()
+			p.Counts = make(map[string]int64, size)
+			for i := 0; i < size; i++ {
+				k := r.ReadString()
+				p.Counts[k] = r.ReadI64()
+			}
+		case 11:
+			p.Child = &fastTestStruct{}
+			if err := p.Child.FastRead(r); err != nil {
+				return err
+			}
+		case 300:
+			p.Last = r.ReadBool()
+		default:
+			r.Skip(fieldType)
+		}
+		if err := r.Err(); err != nil {
+			return err
+		}
+	}
+	r.ReadStructEnd()
+	return r.Err()
+}
+
+// genericProtocol hides the protocol it wraps from the fast codec.
+type genericProtocol struct {
+	thrift.TProtocol
+}
+
+func encodeGeneric(t *testing.T, protoFactory thrift.TProtocolFactory, s *fastTestStruct) []byte {
+	buffer := thrift.NewTMemoryBuffer()
+	assert.Nil(t, s.Write(genericProtocol{protoFactory.GetProtocol(buffer)}))
+	return buffer.Bytes()
+}
+
+// Ensures FastWrite produces the same bytes as the binary and compact
+// protocols, including when nested in structs written with them.
+func TestFastWriteMatchesProtocols(t *testing.T) {
+	s := newFastTestStruct()
+	for _, protoFactory := range []thrift.TProtocolFactory{
+		thrift.NewTBinaryProtocolFactoryDefault(),
+		thrift.NewTCompactProtocolFactory(),
+	} {
+		expected := encodeGeneric(t, protoFactory, s)
+
+		buffer := thrift.NewTMemoryBuffer()
+		assert.Nil(t, s.Write(NewFProtocolFactory(protoFactory).GetProtocol(buffer)))
+		assert.Equal(t, expected, buffer.Bytes())
+	}
+}
+
+// Ensures FastRead decodes structs written by the binary and compact
+// protocols, consuming only their bytes.
+func TestFastReadMatchesProtocols(t *testing.T) {
+	s := newFastTestStruct()
+	for _, protoFactory := range []thrift.TProtocolFactory{
+		thrift.NewTBinaryProtocolFactoryDefault(),
+		thrift.NewTCompactProtocolFactory(),
+	} {
+		data := append(encodeGeneric(t, protoFactory, s), 0xff)
+
+		buffer := &thrift.TMemoryBuffer{Buffer: bytes.NewBuffer(data)}
+		read := &fastTestStruct{}
+		assert.Nil(t, read.Read(protoFactory.GetProtocol(buffer)))
+		assert.Equal(t, s, read)
+		assert.Equal(t, []byte{0xff}, buffer.Bytes())
+	}
+}
+
+// Ensures unknown fields of every type are skipped.
+func TestFastReaderSkip(t *testing.T) {
+	s := newFastTestStruct()
+	for _, compact := range []bool{false, true} {
+		w := NewFFastWriter(compact)
+		assert.Nil(t, s.FastWrite(w))
+
+		r := NewFFastReader(w.Bytes(), compact)
+		r.Skip(thrift.STRUCT)
+		assert.Nil(t, r.Err())
+		assert.Equal(t, len(w.Bytes()), r.Pos())
+	}
+}
+
+// Ensures truncated and malformed data returns errors rather than panicking
+// or allocating containers larger than the data.
+func TestFastReadInvalidData(t *testing.T) {
+	s := newFastTestStruct()
+	for _, compact := range []bool{false, true} {
+		w := NewFFastWriter(compact)
+		assert.Nil(t, s.FastWrite(w))
+		data := w.Bytes()
+
+		for i := 0; i < len(data); i++ {
+			read := &fastTestStruct{}
+			assert.NotNil(t, read.FastRead(NewFFastReader(data[:i], compact)))
+		}
+	}
+
+	// A list claiming more elements than there are bytes
+	r := NewFFastReader([]byte{byte(thrift.I32), 0x7f, 0xff, 0xff, 0xff}, false)
+	_, size := r.ReadListBegin()
+	assert.Equal(t, 0, size)
+	assert.Equal(t, thrift.SIZE_LIMIT, r.Err().(thrift.TProtocolException).TypeId())
+
+	r = NewFFastReader([]byte{0xff, 0xff, 0xff, 0xfe}, false)
+	assert.Equal(t, "", r.ReadString())
+	assert.Equal(t, thrift.NEGATIVE_SIZE, r.Err().(thrift.TProtocolException).TypeId())
+}
+
+// Ensures FastWrite and FastRead report other protocols and transports
+// aren't supported, leaving them untouched.
+func TestFastCodecUnsupported(t *testing.T) {
+	s := newFastTestStruct()
+
+	buffer := thrift.NewTMemoryBuffer()
+	ok, err := FastWrite(thrift.NewTJSONProtocol(buffer), s)
+	assert.False(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, buffer.Len())
+	ok, err = FastRead(thrift.NewTJSONProtocol(buffer), s)
+	assert.False(t, ok)
+	assert.Nil(t, err)
+
+	framed := thrift.NewTFramedTransport(thrift.NewTMemoryBuffer())
+	ok, err = FastRead(thrift.NewTBinaryProtocolTransport(framed), s)
+	assert.False(t, ok)
+	assert.Nil(t, err)
+}
+
+func BenchmarkFastWriteBinary(b *testing.B) {
+	benchmarkWrite(b, thrift.NewTBinaryProtocolFactoryDefault(), false)
+}
+
+func BenchmarkGenericWriteBinary(b *testing.B) {
+	benchmarkWrite(b, thrift.NewTBinaryProtocolFactoryDefault(), true)
+}
+
+func BenchmarkFastWriteCompact(b *testing.B) {
+	benchmarkWrite(b, thrift.NewTCompactProtocolFactory(), false)
+}
+
+func BenchmarkGenericWriteCompact(b *testing.B) {
+	benchmarkWrite(b, thrift.NewTCompactProtocolFactory(), true)
+}
+
+func benchmarkWrite(b *testing.B, protoFactory thrift.TProtocolFactory, generic bool) {
+	b.ReportAllocs()
+	s := newFastTestStruct()
+	buffer := thrift.NewTMemoryBuffer()
+	var oprot thrift.TProtocol = protoFactory.GetProtocol(buffer)
+	if generic {
+		oprot = genericProtocol{oprot}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buffer.Reset()
+		if err := s.Write(oprot); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
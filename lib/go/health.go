@@ -0,0 +1,250 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// HealthServiceName is the name the health service is registered under with
+// processors, whose check method clients call as "Health:check". The service
+// is defined by health.frugal, so clients in any language can be generated
+// for it.
+const HealthServiceName = "Health"
+
+// FServingStatus is the serving status of a service, which is the
+// ServingStatus enum of health.frugal.
+type FServingStatus int32
+
+// Serving statuses.
+const (
+	FServingStatusUnknown        FServingStatus = 0
+	FServingStatusServing        FServingStatus = 1
+	FServingStatusNotServing     FServingStatus = 2
+	FServingStatusServiceUnknown FServingStatus = 3
+)
+
+var servingStatusNames = map[FServingStatus]string{
+	FServingStatusUnknown:        "UNKNOWN",
+	FServingStatusServing:        "SERVING",
+	FServingStatusNotServing:     "NOT_SERVING",
+	FServingStatusServiceUnknown: "SERVICE_UNKNOWN",
+}
+
+// String returns the name of the status in health.frugal.
+func (s FServingStatus) String() string {
+	if name, ok := servingStatusNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("FServingStatus(%d)", int32(s))
+}
+
+// healthServiceDescriptor is the JSON encoded FServiceDescriptor of the
+// Health service in health.frugal, as generated with the Go reflection
+// option. The compiler tests ensure it matches the generated one.
+const healthServiceDescriptor = "{\"name\":\"Health\",\"doc\":\"The health service served alongside services by Frugal servers with health\\nchecking enabled. It's registered under the multiplexed service name\\n\\\"Health\\\", so clients call it as \\\"Health:check\\\", e.g. with a client\\ngenerated with the Go multiplex option.\",\"methods\":[{\"name\":\"check\",\"doc\":\"Returns the serving status of the named service, or of the server as a\\nwhole if the name is empty.\",\"returnType\":{\"kind\":\"enum\",\"name\":\"health.ServingStatus\"},\"arguments\":[{\"id\":1,\"name\":\"service\",\"type\":{\"kind\":\"base\",\"name\":\"string\"}}]}],\"types\":{\"health.ServingStatus\":{\"kind\":\"enum\",\"doc\":\"The serving status of a service.\",\"values\":{\"NOT_SERVING\":2,\"SERVICE_UNKNOWN\":3,\"SERVING\":1,\"UNKNOWN\":0}}}}"
+
+// healthService is the decoded healthServiceDescriptor, used to read and
+// write health checks.
+var healthService = func() *FServiceDescriptor {
+	service := &FServiceDescriptor{}
+	if err := json.Unmarshal([]byte(healthServiceDescriptor), service); err != nil {
+		panic(err)
+	}
+	return service
+}()
+
+// FHealthServer tracks the serving status of services and serves it with the
+// health service of health.frugal. The status of the server as a whole is
+// that of the empty service name, which starts as serving. Handlers can update
+// statuses at any time, e.g. when a dependency becomes unavailable.
+type FHealthServer struct {
+	mu       sync.RWMutex
+	statuses map[string]FServingStatus
+	shutdown bool
+}
+
+// NewFHealthServer returns a new FHealthServer whose server as a whole is
+// serving.
+func NewFHealthServer() *FHealthServer {
+	return &FHealthServer{
+		statuses: map[string]FServingStatus{"": FServingStatusServing},
+	}
+}
+
+// SetServingStatus sets the serving status of the named service, or of the
+// server as a whole if the name is empty. This is ignored after Shutdown
+// until Resume is called.
+func (h *FHealthServer) SetServingStatus(service string, status FServingStatus) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.shutdown {
+		logger().Infof("frugal: health status of service \"%s\" not changed to %s since the server is shutting down",
+			service, status)
+		return
+	}
+	h.statuses[service] = status
+}
+
+// ServingStatus returns the serving status of the named service, or of the
+// server as a whole if the name is empty. It's FServingStatusServiceUnknown
+// for services whose status was never set.
+func (h *FHealthServer) ServingStatus(service string) FServingStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if status, ok := h.statuses[service]; ok {
+		return status
+	}
+	return FServingStatusServiceUnknown
+}
+
+// Services returns the sorted names of the services with a status, including
+// the empty name of the server as a whole.
+func (h *FHealthServer) Services() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	services := make([]string, 0, len(h.statuses))
+	for service := range h.statuses {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services
+}
+
+// Shutdown sets the status of every service to not serving and ignores
+// further updates until Resume is called. Servers call it when stopping so
+// checks fail while in-flight requests finish.
+func (h *FHealthServer) Shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.shutdown = true
+	for service := range h.statuses {
+		h.statuses[service] = FServingStatusNotServing
+	}
+}
+
+// Resume sets the status of every service to serving and allows updates
+// again after Shutdown.
+func (h *FHealthServer) Resume() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.shutdown = false
+	for service := range h.statuses {
+		h.statuses[service] = FServingStatusServing
+	}
+}
+
+// Processor returns an FServiceProcessor serving the health service, which
+// can be registered with an FMultiplexedProcessor under HealthServiceName.
+func (h *FHealthServer) Processor() FServiceProcessor {
+	processor := NewFBaseProcessor()
+	for _, method := range healthService.Methods {
+		processor.AddToProcessorMap(method.Name, &healthProcessorFunction{health: h, method: method})
+		processor.AddToServiceMap(method.Name, HealthServiceName)
+	}
+	return processor
+}
+
+// Register registers the health service with the processor under
+// HealthServiceName and sets the services the processor serves as serving,
+// unless their status is already set. The processor must embed
+// FBaseProcessor, as generated processors do. This should only be called
+// before the server is started.
+func (h *FHealthServer) Register(processor FProcessor) error {
+	registry, ok := processor.(interface {
+		AddToProcessorMap(string, FProcessorFunction)
+	})
+	if !ok {
+		return fmt.Errorf("frugal: can't register the health service with processor %T", processor)
+	}
+	health := h.Processor()
+	for _, method := range health.Methods() {
+		proc, _ := health.GetProcessorFunction(method)
+		registry.AddToProcessorMap(HealthServiceName+thrift.MULTIPLEXED_SEPARATOR+method, proc)
+	}
+
+	if serviceProcessor, ok := processor.(FServiceProcessor); ok {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		for service := range serviceProcessor.Services() {
+			if _, ok := h.statuses[service]; !ok && service != HealthServiceName {
+				h.statuses[service] = FServingStatusServing
+			}
+		}
+	}
+	return nil
+}
+
+// ServeHTTP answers HTTP health probes with the serving status of the service
+// named by the "service" query parameter, or of the server as a whole if it's
+// absent. The status is 200 if the service is serving and 503 otherwise, and
+// the body is the name of the serving status.
+func (h *FHealthServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	status := h.ServingStatus(r.URL.Query().Get("service"))
+	code := http.StatusOK
+	if status != FServingStatusServing {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set(contentTypeHeader, "text/plain")
+	w.WriteHeader(code)
+	fmt.Fprintln(w, status)
+}
+
+// healthProcessorFunction is the FProcessorFunction of the check method of
+// the health service.
+type healthProcessorFunction struct {
+	health  *FHealthServer
+	method  *FMethodDescriptor
+	writeMu sync.Mutex
+}
+
+func (p *healthProcessorFunction) Process(ctx FContext, iprot, oprot *FProtocol) error {
+	codec := &dynamicCodec{types: healthService.Types}
+	args, err := codec.readStruct(iprot, p.method.Arguments)
+	if err != nil {
+		return err
+	}
+	if err := iprot.ReadMessageEnd(); err != nil {
+		return err
+	}
+	service, _ := args["service"].(string)
+	status := p.health.ServingStatus(service)
+
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	if err := oprot.WriteResponseHeader(ctx); err != nil {
+		return err
+	}
+	if err := oprot.WriteMessageBegin(p.method.Name, thrift.REPLY, 0); err != nil {
+		return err
+	}
+	fields := []*FFieldDescriptor{{ID: 0, Name: "success", Type: p.method.ReturnType}}
+	value := map[string]interface{}{"success": int32(status)}
+	if err := codec.writeStruct(oprot, p.method.Name+"_result", fields, value, p.method.Name); err != nil {
+		return err
+	}
+	if err := oprot.WriteMessageEnd(); err != nil {
+		return err
+	}
+	return oprot.Flush()
+}
+
+// AddMiddleware is a no-op since the check method doesn't invoke a handler.
+func (p *healthProcessorFunction) AddMiddleware(ServiceMiddleware) {}
@@ -0,0 +1,146 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"sync"
+	"time"
+)
+
+// FHealthMonitor is notified by an FHealthChecker when the serving status of
+// the service it watches changes, like an FTransportMonitor is notified of
+// changes to the state of a transport.
+type FHealthMonitor interface {
+	// OnServing is called when the service becomes serving, including when
+	// the first check finds it serving.
+	OnServing()
+
+	// OnNotServing is called when the status of the service changes to one
+	// other than serving, including when the first check finds it isn't
+	// serving. If the check failed, the status is FServingStatusUnknown and
+	// cause is the error it failed with.
+	OnNotServing(status FServingStatus, cause error)
+}
+
+// BaseFHealthMonitor is a default FHealthMonitor which logs changes of the
+// serving status. Its behavior can be customized by embedding this struct
+// type in a new struct which "overrides" desired callbacks.
+type BaseFHealthMonitor struct {
+	Service string
+}
+
+// OnServing is called when the service becomes serving.
+func (m *BaseFHealthMonitor) OnServing() {
+	logger().Infof("frugal: FHealthMonitor signaled service \"%s\" is serving", m.Service)
+}
+
+// OnNotServing is called when the status of the service changes to one other
+// than serving.
+func (m *BaseFHealthMonitor) OnNotServing(status FServingStatus, cause error) {
+	if cause != nil {
+		logger().Warnf("frugal: FHealthMonitor signaled health check of service \"%s\" failed: %v", m.Service, cause)
+		return
+	}
+	logger().Warnf("frugal: FHealthMonitor signaled service \"%s\" is %s", m.Service, status)
+}
+
+// FHealthChecker checks the serving status of a service, or of a server as a
+// whole, with the health service served by servers with an FHealthServer.
+type FHealthChecker struct {
+	client  *FDynamicClient
+	service string
+	mu      sync.Mutex
+	stop    chan struct{}
+}
+
+// NewFHealthChecker returns a new FHealthChecker checking the named service
+// using the provider, or the server as a whole if the name is empty. The
+// provider shouldn't qualify method names by a service name.
+func NewFHealthChecker(provider *FServiceProvider, service string) *FHealthChecker {
+	return &FHealthChecker{
+		client:  NewFDynamicClient(NewFMultiplexedServiceProvider(provider, HealthServiceName), healthService),
+		service: service,
+	}
+}
+
+// Check returns the serving status of the service.
+func (c *FHealthChecker) Check(ctx FContext) (FServingStatus, error) {
+	method := healthService.Methods[0]
+	result, err := c.client.Call(ctx, HealthServiceName, method.Name, map[string]interface{}{"service": c.service})
+	if err != nil {
+		return FServingStatusUnknown, err
+	}
+	switch status := result.(type) {
+	case string:
+		return FServingStatus(healthService.Types[method.ReturnType.Name].Values[status]), nil
+	case int64:
+		return FServingStatus(status), nil
+	}
+	return FServingStatusUnknown, nil
+}
+
+// Watch checks the service every interval, with the interval as the timeout,
+// notifying the monitor when its status changes, until Stop is called.
+// Calling Watch again stops the previous watch.
+func (c *FHealthChecker) Watch(interval time.Duration, monitor FHealthMonitor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stop != nil {
+		close(c.stop)
+	}
+	c.stop = make(chan struct{})
+	go c.watch(interval, monitor, c.stop)
+}
+
+func (c *FHealthChecker) watch(interval time.Duration, monitor FHealthMonitor, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	checked := false
+	last := FServingStatusUnknown
+	for {
+		ctx := NewFContext("")
+		ctx.SetTimeout(interval)
+		status, err := c.Check(ctx)
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		if !checked || status != last {
+			if status == FServingStatusServing {
+				monitor.OnServing()
+			} else {
+				monitor.OnNotServing(status, err)
+			}
+		}
+		checked = true
+		last = status
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop stops watching the service.
+func (c *FHealthChecker) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stop != nil {
+		close(c.stop)
+		c.stop = nil
+	}
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/stretchr/testify/assert"
+)
+
+func newHealthTestChecker(t *testing.T, url, service string) *FHealthChecker {
+	transport := NewFHTTPTransportBuilder(http.DefaultClient, url).Build()
+	assert.Nil(t, transport.Open())
+	provider := NewFServiceProvider(transport, NewFProtocolFactory(thrift.NewTJSONProtocolFactory()))
+	return NewFHealthChecker(provider, service)
+}
+
+// healthEvent is a call of a recordingHealthMonitor.
+type healthEvent struct {
+	status FServingStatus
+	failed bool
+}
+
+type recordingHealthMonitor struct {
+	events chan healthEvent
+}
+
+func (m *recordingHealthMonitor) OnServing() {
+	m.events <- healthEvent{status: FServingStatusServing}
+}
+
+func (m *recordingHealthMonitor) OnNotServing(status FServingStatus, cause error) {
+	m.events <- healthEvent{status: status, failed: cause != nil}
+}
+
+func (m *recordingHealthMonitor) next(t *testing.T) healthEvent {
+	select {
+	case event := <-m.events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("expected a health event")
+	}
+	return healthEvent{}
+}
+
+// Ensures servers built with NewFrugalHandlerFuncWithHealth serve checks and
+// probes alongside the processor's methods.
+func TestNewFrugalHandlerFuncWithHealth(t *testing.T) {
+	health := NewFHealthServer()
+	processor := NewFBaseProcessor()
+	processor.AddToProcessorMap("ping", &pingProcessor{t: t})
+	processor.AddToServiceMap("ping", "Base")
+	server := httptest.NewServer(NewFrugalHandlerFuncWithHealth(
+		processor, NewFProtocolFactory(thrift.NewTJSONProtocolFactory()), health))
+	defer server.Close()
+
+	status, err := newHealthTestChecker(t, server.URL, "Base").Check(NewFContext(""))
+	assert.Nil(t, err)
+	assert.Equal(t, FServingStatusServing, status)
+
+	health.SetServingStatus("", FServingStatusNotServing)
+	resp, err := http.Get(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	resp.Body.Close()
+}
+
+// Ensures NewFrugalHandlerFuncWithHealth panics if the health service can't
+// be registered with the processor.
+func TestNewFrugalHandlerFuncWithHealthRegisterError(t *testing.T) {
+	assert.PanicsWithValue(t,
+		"frugal: can't serve the health service: frugal: can't register the health service with processor *frugal.processor",
+		func() {
+			NewFrugalHandlerFuncWithHealth(&processor{t}, NewFProtocolFactory(thrift.NewTJSONProtocolFactory()), NewFHealthServer())
+		})
+}
+
+// Ensures Watch notifies the monitor when the status changes or checks fail.
+func TestFHealthCheckerWatch(t *testing.T) {
+	health := NewFHealthServer()
+	processor := NewFBaseProcessor()
+	server := httptest.NewServer(NewFrugalHandlerFuncWithHealth(
+		processor, NewFProtocolFactory(thrift.NewTJSONProtocolFactory()), health))
+
+	checker := newHealthTestChecker(t, server.URL, "")
+	monitor := &recordingHealthMonitor{events: make(chan healthEvent, 10)}
+	checker.Watch(5*time.Millisecond, monitor)
+	defer checker.Stop()
+	assert.Equal(t, healthEvent{status: FServingStatusServing}, monitor.next(t))
+
+	health.SetServingStatus("", FServingStatusNotServing)
+	assert.Equal(t, healthEvent{status: FServingStatusNotServing}, monitor.next(t))
+	health.SetServingStatus("", FServingStatusServing)
+	assert.Equal(t, healthEvent{status: FServingStatusServing}, monitor.next(t))
+
+	server.Close()
+	assert.Equal(t, healthEvent{status: FServingStatusUnknown, failed: true}, monitor.next(t))
+
+	checker.Stop()
+	select {
+	case event := <-monitor.events:
+		t.Fatalf("unexpected health event %v", event)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
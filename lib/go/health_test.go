@@ -0,0 +1,114 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/stretchr/testify/assert"
+)
+
+// Ensures statuses can be set and read, and Shutdown and Resume override
+// them.
+func TestFHealthServerStatuses(t *testing.T) {
+	health := NewFHealthServer()
+	assert.Equal(t, FServingStatusServing, health.ServingStatus(""))
+	assert.Equal(t, FServingStatusServiceUnknown, health.ServingStatus("Store"))
+
+	health.SetServingStatus("Store", FServingStatusNotServing)
+	assert.Equal(t, FServingStatusNotServing, health.ServingStatus("Store"))
+	assert.Equal(t, []string{"", "Store"}, health.Services())
+
+	health.Shutdown()
+	assert.Equal(t, FServingStatusNotServing, health.ServingStatus(""))
+	health.SetServingStatus("Store", FServingStatusServing)
+	assert.Equal(t, FServingStatusNotServing, health.ServingStatus("Store"))
+
+	health.Resume()
+	assert.Equal(t, FServingStatusServing, health.ServingStatus(""))
+	assert.Equal(t, FServingStatusServing, health.ServingStatus("Store"))
+
+	assert.Equal(t, "NOT_SERVING", FServingStatusNotServing.String())
+	assert.Equal(t, "FServingStatus(9)", FServingStatus(9).String())
+}
+
+// Ensures the serving statuses are the values of the ServingStatus enum of the
+// health service descriptor, which the compiler tests check against
+// health.frugal.
+func TestFServingStatusDescriptor(t *testing.T) {
+	values := healthService.Types["health.ServingStatus"].Values
+	assert.Equal(t, len(servingStatusNames), len(values))
+	for status, name := range servingStatusNames {
+		assert.Equal(t, int32(status), values[name], name)
+	}
+}
+
+// Ensures Register adds the check method to processors under the health
+// service name and marks their services as serving.
+func TestFHealthServerRegister(t *testing.T) {
+	health := NewFHealthServer()
+	health.SetServingStatus("Base", FServingStatusNotServing)
+	base := NewFBaseProcessor()
+	base.AddToProcessorMap("ping", &pingProcessor{t: t})
+	base.AddToServiceMap("ping", "Base")
+	base.AddToProcessorMap("getAlbum", &pingProcessor{t: t})
+	base.AddToServiceMap("getAlbum", "Store")
+
+	assert.Nil(t, health.Register(base))
+	assert.Equal(t, []string{"Health:check", "getAlbum", "ping"}, base.Methods())
+	assert.Equal(t, FServingStatusNotServing, health.ServingStatus("Base"))
+	assert.Equal(t, FServingStatusServing, health.ServingStatus("Store"))
+
+	err := health.Register(&processor{t})
+	assert.Equal(t, "frugal: can't register the health service with processor *frugal.processor", err.Error())
+}
+
+// Ensures the check method returns the status of the requested service.
+func TestHealthProcessorFunction(t *testing.T) {
+	health := NewFHealthServer()
+	health.SetServingStatus("Store", FServingStatusNotServing)
+	processor := NewFMultiplexedProcessor()
+	assert.Nil(t, processor.RegisterProcessor(HealthServiceName, health.Processor()))
+	server := httptest.NewServer(NewFrugalHandlerFunc(processor, NewFProtocolFactory(thrift.NewTJSONProtocolFactory())))
+	defer server.Close()
+
+	checker := newHealthTestChecker(t, server.URL, "Store")
+	status, err := checker.Check(NewFContext(""))
+	assert.Nil(t, err)
+	assert.Equal(t, FServingStatusNotServing, status)
+
+	checker = newHealthTestChecker(t, server.URL, "Catalog")
+	status, err = checker.Check(NewFContext(""))
+	assert.Nil(t, err)
+	assert.Equal(t, FServingStatusServiceUnknown, status)
+}
+
+// Ensures ServeHTTP answers probes with the status of the requested service.
+func TestFHealthServerServeHTTP(t *testing.T) {
+	health := NewFHealthServer()
+	health.SetServingStatus("Store", FServingStatusNotServing)
+
+	recorder := httptest.NewRecorder()
+	health.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "SERVING\n", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	health.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health?service=Store", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	assert.Equal(t, "NOT_SERVING\n", recorder.Body.String())
+}
@@ -0,0 +1,693 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+const (
+	topicHeader          = "x-frugal-topic"
+	idempotencyKeyHeader = "x-frugal-idempotency-key"
+	timestampHeader      = "x-frugal-timestamp"
+	signatureHeader      = "x-frugal-signature"
+
+	// topicURLPlaceholder is replaced with the topic in publisher URLs.
+	topicURLPlaceholder = "{topic}"
+	signaturePrefix     = "sha256="
+
+	defaultHTTPPublishMaxAttempts    = 3
+	defaultHTTPPublishBackoff        = 100 * time.Millisecond
+	defaultHTTPPublishTimeout        = 10 * time.Second
+	defaultHTTPSubscriberTolerance   = 5 * time.Minute
+	defaultHTTPSubscriberIdempotency = 10 * time.Minute
+	defaultHTTPSubscriberSizeLimit   = 1024 * 1024
+
+	// httpSubscriberPruneThreshold is the number of remembered idempotency
+	// keys which triggers pruning expired keys before the next scheduled
+	// prune.
+	httpSubscriberPruneThreshold = 10000
+)
+
+// generateIdempotencyKey returns a random key identifying a single publish
+// across retries. It's assigned to a var for testability purposes.
+var generateIdempotencyKey = generateCorrelationID
+
+// FHTTPPublisherTransportBuilder configures and builds HTTP
+// FPublisherTransport instances.
+type FHTTPPublisherTransportBuilder struct {
+	client           *http.Client
+	url              string
+	publishSizeLimit uint
+	requestHeaders   map[string]string
+	secret           []byte
+	maxAttempts      int
+	backoff          time.Duration
+	timeout          time.Duration
+}
+
+// NewFHTTPPublisherTransportBuilder creates a builder which configures and
+// builds HTTP FPublisherTransport instances. Each message is POSTed to the
+// given URL. Any occurrence of "{topic}" in the URL is replaced with the
+// path-escaped topic, which allows routing topics to different endpoints.
+// The topic is also sent in the x-frugal-topic header.
+func NewFHTTPPublisherTransportBuilder(client *http.Client, url string) *FHTTPPublisherTransportBuilder {
+	return &FHTTPPublisherTransportBuilder{
+		client:      client,
+		url:         url,
+		maxAttempts: defaultHTTPPublishMaxAttempts,
+		backoff:     defaultHTTPPublishBackoff,
+		timeout:     defaultHTTPPublishTimeout,
+	}
+}
+
+// WithPublishSizeLimit adds a publish size limit. If set to 0 (the default),
+// there is no size limit on published messages.
+func (h *FHTTPPublisherTransportBuilder) WithPublishSizeLimit(publishSizeLimit uint) *FHTTPPublisherTransportBuilder {
+	h.publishSizeLimit = publishSizeLimit
+	return h
+}
+
+// WithRequestHeaders adds custom headers to each publish request.
+func (h *FHTTPPublisherTransportBuilder) WithRequestHeaders(requestHeaders map[string]string) *FHTTPPublisherTransportBuilder {
+	h.requestHeaders = requestHeaders
+	return h
+}
+
+// WithSigningSecret signs each publish request with an HMAC-SHA256 of the
+// timestamp, idempotency key, topic, and body using the given secret. The
+// receiving FHTTPSubscriberHandler must be configured with the same secret.
+func (h *FHTTPPublisherTransportBuilder) WithSigningSecret(secret []byte) *FHTTPPublisherTransportBuilder {
+	h.secret = secret
+	return h
+}
+
+// WithRetry sets the maximum number of attempts made to deliver a message
+// and the backoff before the first retry, which doubles with each subsequent
+// retry. Requests are retried on network errors, 429, and 5xx responses.
+// Every attempt carries the same idempotency key so the receiver can discard
+// duplicates. Defaults to 3 attempts with a 100ms backoff.
+func (h *FHTTPPublisherTransportBuilder) WithRetry(maxAttempts int, backoff time.Duration) *FHTTPPublisherTransportBuilder {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	h.maxAttempts = maxAttempts
+	h.backoff = backoff
+	return h
+}
+
+// WithTimeout sets the timeout for each publish attempt. Defaults to 10
+// seconds.
+func (h *FHTTPPublisherTransportBuilder) WithTimeout(timeout time.Duration) *FHTTPPublisherTransportBuilder {
+	h.timeout = timeout
+	return h
+}
+
+// Build a new configured HTTP FPublisherTransport.
+func (h *FHTTPPublisherTransportBuilder) Build() FPublisherTransport {
+	client := h.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &fHTTPPublisherTransport{
+		client:           client,
+		url:              h.url,
+		publishSizeLimit: h.publishSizeLimit,
+		requestHeaders:   h.requestHeaders,
+		secret:           h.secret,
+		maxAttempts:      h.maxAttempts,
+		backoff:          h.backoff,
+		timeout:          h.timeout,
+	}
+}
+
+// FHTTPPublisherTransportFactory creates HTTP FPublisherTransports.
+type FHTTPPublisherTransportFactory struct {
+	builder *FHTTPPublisherTransportBuilder
+}
+
+// NewFHTTPPublisherTransportFactory creates an FHTTPPublisherTransportFactory
+// which builds FPublisherTransports using the given builder.
+func NewFHTTPPublisherTransportFactory(builder *FHTTPPublisherTransportBuilder) *FHTTPPublisherTransportFactory {
+	return &FHTTPPublisherTransportFactory{builder: builder}
+}
+
+// GetTransport creates a new HTTP FPublisherTransport.
+func (h *FHTTPPublisherTransportFactory) GetTransport() FPublisherTransport {
+	return h.builder.Build()
+}
+
+// fHTTPPublisherTransport implements FPublisherTransport by POSTing each
+// message to a webhook.
+type fHTTPPublisherTransport struct {
+	client           *http.Client
+	url              string
+	publishSizeLimit uint
+	requestHeaders   map[string]string
+	secret           []byte
+	maxAttempts      int
+	backoff          time.Duration
+	timeout          time.Duration
+	openMu           sync.RWMutex
+	isOpen           bool
+}
+
+// Open initializes the transport.
+func (h *fHTTPPublisherTransport) Open() error {
+	h.openMu.Lock()
+	defer h.openMu.Unlock()
+	h.isOpen = true
+	return nil
+}
+
+// IsOpen returns true if the transport is open, false otherwise.
+func (h *fHTTPPublisherTransport) IsOpen() bool {
+	h.openMu.RLock()
+	defer h.openMu.RUnlock()
+	return h.isOpen
+}
+
+// Close closes the transport.
+func (h *fHTTPPublisherTransport) Close() error {
+	h.openMu.Lock()
+	defer h.openMu.Unlock()
+	h.isOpen = false
+	return nil
+}
+
+// GetPublishSizeLimit returns the maximum allowable size of a payload
+// to be published. A non-positive number is returned to indicate an
+// unbounded allowable size.
+func (h *fHTTPPublisherTransport) GetPublishSizeLimit() uint {
+	return h.publishSizeLimit
+}
+
+// Publish sends the given payload with the transport, retrying transient
+// failures.
+func (h *fHTTPPublisherTransport) Publish(topic string, data []byte) error {
+	if !h.IsOpen() {
+		return thrift.NewTTransportException(TRANSPORT_EXCEPTION_NOT_OPEN,
+			"frugal: HTTP FPublisherTransport not open")
+	}
+
+	if h.publishSizeLimit > 0 && len(data) > int(h.publishSizeLimit) {
+		return thrift.NewTTransportException(
+			TRANSPORT_EXCEPTION_REQUEST_TOO_LARGE,
+			fmt.Sprintf("Message exceeds %d bytes, was %d bytes", h.publishSizeLimit, len(data)))
+	}
+
+	subject := fmt.Sprintf("%s%s", frugalPrefix, topic)
+	body := []byte(base64.StdEncoding.EncodeToString(data))
+	key := generateIdempotencyKey()
+	backoff := h.backoff
+
+	var err error
+	for attempt := 1; attempt <= h.maxAttempts; attempt++ {
+		var retry bool
+		if retry, err = h.post(subject, key, body); err == nil {
+			return nil
+		}
+		if !retry || attempt == h.maxAttempts {
+			break
+		}
+		logger().Warnf("frugal: error publishing to %s, retrying in %s: %s", subject, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	return thrift.NewTTransportExceptionFromError(err)
+}
+
+// post makes a single publish attempt. It returns whether the attempt should
+// be retried if it failed.
+func (h *fHTTPPublisherTransport) post(topic, key string, body []byte) (bool, error) {
+	target := strings.Replace(h.url, topicURLPlaceholder, url.PathEscape(topic), -1)
+	request, err := http.NewRequest("POST", target, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	// Add user supplied headers first so they can't clobber the headers
+	// the subscriber relies on.
+	for name, value := range h.requestHeaders {
+		request.Header.Set(name, value)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set(contentTypeHeader, frugalContentType)
+	request.Header.Set(contentTransferEncodingHeader, base64Encoding)
+	request.Header.Set(topicHeader, topic)
+	request.Header.Set(idempotencyKeyHeader, key)
+	request.Header.Set(timestampHeader, timestamp)
+	if h.secret != nil {
+		request.Header.Set(signatureHeader, signPublish(h.secret, timestamp, key, topic, body))
+	}
+
+	client := *h.client
+	if h.timeout > 0 {
+		client.Timeout = h.timeout
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return true, err
+	}
+	defer response.Body.Close()
+	io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+	retry := response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+	return retry, fmt.Errorf("frugal: publish to %s failed with status %d", topic, response.StatusCode)
+}
+
+// signPublish returns the signature header value for a publish request.
+func signPublish(secret []byte, timestamp, key, topic string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write([]byte(key))
+	mac.Write([]byte("."))
+	mac.Write([]byte(topic))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// FHTTPSubscriberHandler is an http.Handler which receives messages POSTed by
+// an HTTP FPublisherTransport and dispatches them to the FAsyncCallbacks
+// subscribed to their topic. Subscriptions are made with FSubscriberTransports
+// produced by SubscriberTransportFactory, so generated subscribers can be used
+// as webhook receivers.
+type FHTTPSubscriberHandler interface {
+	http.Handler
+
+	// SubscriberTransportFactory returns an FSubscriberTransportFactory which
+	// produces FSubscriberTransports that receive messages from this handler.
+	SubscriberTransportFactory() FSubscriberTransportFactory
+}
+
+// FHTTPSubscriberHandlerBuilder configures and builds FHTTPSubscriberHandlers.
+type FHTTPSubscriberHandlerBuilder struct {
+	secret            []byte
+	tolerance         time.Duration
+	idempotencyWindow time.Duration
+	messageSizeLimit  uint
+}
+
+// NewFHTTPSubscriberHandlerBuilder creates a builder which configures and
+// builds FHTTPSubscriberHandlers.
+func NewFHTTPSubscriberHandlerBuilder() *FHTTPSubscriberHandlerBuilder {
+	return &FHTTPSubscriberHandlerBuilder{
+		tolerance:         defaultHTTPSubscriberTolerance,
+		idempotencyWindow: defaultHTTPSubscriberIdempotency,
+		messageSizeLimit:  defaultHTTPSubscriberSizeLimit,
+	}
+}
+
+// WithSigningSecret requires every request to be signed with the given
+// secret. Requests with a missing or invalid signature, or with a timestamp
+// outside the tolerance, are rejected with 401.
+func (h *FHTTPSubscriberHandlerBuilder) WithSigningSecret(secret []byte) *FHTTPSubscriberHandlerBuilder {
+	h.secret = secret
+	return h
+}
+
+// WithTimestampTolerance sets how far a signed request's timestamp may be
+// from the current time before it's rejected, which limits replay attacks.
+// Defaults to 5 minutes.
+func (h *FHTTPSubscriberHandlerBuilder) WithTimestampTolerance(tolerance time.Duration) *FHTTPSubscriberHandlerBuilder {
+	h.tolerance = tolerance
+	return h
+}
+
+// WithIdempotencyWindow sets how long idempotency keys of delivered messages
+// are remembered. Retried messages received within the window are
+// acknowledged without being dispatched again, duplicates received while the
+// message is being dispatched are rejected with 503 so they're retried, and
+// retries of a partially failed delivery only invoke the callbacks which
+// failed. Defaults to 10 minutes. If set to 0, duplicates are not detected.
+func (h *FHTTPSubscriberHandlerBuilder) WithIdempotencyWindow(window time.Duration) *FHTTPSubscriberHandlerBuilder {
+	h.idempotencyWindow = window
+	return h
+}
+
+// WithMessageSizeLimit sets the largest request body, in bytes, the handler
+// reads. Larger requests are rejected with 413 before their signature is
+// verified. Defaults to 1MB. If set to 0, there is no size limit.
+func (h *FHTTPSubscriberHandlerBuilder) WithMessageSizeLimit(messageSizeLimit uint) *FHTTPSubscriberHandlerBuilder {
+	h.messageSizeLimit = messageSizeLimit
+	return h
+}
+
+// Build a new configured FHTTPSubscriberHandler.
+func (h *FHTTPSubscriberHandlerBuilder) Build() FHTTPSubscriberHandler {
+	return &fHTTPSubscriberHandler{
+		secret:            h.secret,
+		tolerance:         h.tolerance,
+		idempotencyWindow: h.idempotencyWindow,
+		messageSizeLimit:  h.messageSizeLimit,
+		subscribers:       make(map[string]map[*fHTTPSubscriberTransport]FAsyncCallback),
+		deliveries:        make(map[string]*httpDelivery),
+	}
+}
+
+// fHTTPSubscriberHandler implements FHTTPSubscriberHandler.
+type fHTTPSubscriberHandler struct {
+	secret            []byte
+	tolerance         time.Duration
+	idempotencyWindow time.Duration
+	messageSizeLimit  uint
+	mu                sync.RWMutex
+	subscribers       map[string]map[*fHTTPSubscriberTransport]FAsyncCallback
+	deliveriesMu      sync.Mutex
+	deliveries        map[string]*httpDelivery
+	nextPrune         time.Time
+}
+
+// httpDelivery tracks the delivery of the message with an idempotency key.
+type httpDelivery struct {
+	// inFlight is true while a request carrying the key is dispatching.
+	inFlight bool
+
+	// delivered holds the subscribers whose callbacks succeeded, so a retry
+	// after a partial failure only invokes the remaining callbacks. It's only
+	// accessed by the request which reserved the key.
+	delivered map[*fHTTPSubscriberTransport]bool
+
+	// complete is true once every callback has succeeded.
+	complete bool
+
+	// expiry is when the key is forgotten.
+	expiry time.Time
+}
+
+// SubscriberTransportFactory returns an FSubscriberTransportFactory which
+// produces FSubscriberTransports that receive messages from this handler.
+func (h *fHTTPSubscriberHandler) SubscriberTransportFactory() FSubscriberTransportFactory {
+	return &fHTTPSubscriberTransportFactory{handler: h}
+}
+
+// ServeHTTP verifies the request and invokes the callbacks subscribed to its
+// topic. A non-2xx status is returned if the message should be redelivered.
+func (h *fHTTPSubscriberHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	limit := int64(h.messageSizeLimit)
+	if limit > 0 {
+		if r.ContentLength > limit {
+			http.Error(w, fmt.Sprintf("Request size (%d) larger than the limit (%d)", r.ContentLength, limit),
+				http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		// A body without a Content-Length fails once it exceeds the limit.
+		if limit > 0 && int64(len(body)) >= limit {
+			http.Error(w, fmt.Sprintf("Request larger than the limit (%d)", limit),
+				http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, fmt.Sprintf("Could not read request body %s", err), http.StatusBadRequest)
+		return
+	}
+
+	topic := r.Header.Get(topicHeader)
+	key := r.Header.Get(idempotencyKeyHeader)
+	if topic == "" {
+		http.Error(w, fmt.Sprintf("Missing %s header", topicHeader), http.StatusBadRequest)
+		return
+	}
+
+	if h.secret != nil {
+		if err := h.verify(r, topic, key, body); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if r.Header.Get(contentTransferEncodingHeader) == base64Encoding {
+		if body, err = base64.StdEncoding.DecodeString(string(body)); err != nil {
+			http.Error(w, fmt.Sprintf("Problem decoding frugal bytes from base64 %s", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Need 4 bytes for the frame size, at a minimum.
+	if len(body) < 4 {
+		http.Error(w, fmt.Sprintf("Invalid request size %d", len(body)), http.StatusBadRequest)
+		return
+	}
+
+	callbacks := h.callbacks(topic)
+	if len(callbacks) == 0 {
+		http.Error(w, fmt.Sprintf("No subscribers for topic %s", topic), http.StatusNotFound)
+		return
+	}
+
+	var delivery *httpDelivery
+	if key != "" && h.idempotencyWindow > 0 {
+		var ok bool
+		if delivery, ok = h.reserve(key); !ok {
+			if delivery == nil {
+				// Already delivered, acknowledge the duplicate.
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			// Another request is delivering the message. Ask for
+			// redelivery in case that delivery fails.
+			http.Error(w, "Delivery in progress", http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	for transport, callback := range callbacks {
+		if delivery != nil && delivery.delivered[transport] {
+			continue
+		}
+		buffer := &thrift.TMemoryBuffer{Buffer: bytes.NewBuffer(body[4:])} // Discard frame size
+		if err := callback(buffer); err != nil {
+			logger().Warn("frugal: error executing callback: ", err)
+			if delivery != nil {
+				h.release(key, delivery)
+			}
+			http.Error(w, fmt.Sprintf("Error processing message: %s", err), http.StatusInternalServerError)
+			return
+		}
+		if delivery != nil {
+			delivery.delivered[transport] = true
+		}
+	}
+
+	if delivery != nil {
+		h.complete(delivery)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// verify checks the request's signature and timestamp.
+func (h *fHTTPSubscriberHandler) verify(r *http.Request, topic, key string, body []byte) error {
+	timestamp := r.Header.Get(timestampHeader)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s header not an integer", timestampHeader)
+	}
+	skew := time.Since(time.Unix(seconds, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if h.tolerance > 0 && skew > h.tolerance {
+		return fmt.Errorf("%s header outside tolerance", timestampHeader)
+	}
+
+	expected := signPublish(h.secret, timestamp, key, topic, body)
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get(signatureHeader))) {
+		return fmt.Errorf("Invalid %s header", signatureHeader)
+	}
+	return nil
+}
+
+// callbacks returns a copy of the callbacks subscribed to the topic, keyed by
+// their subscriber.
+func (h *fHTTPSubscriberHandler) callbacks(topic string) map[*fHTTPSubscriberTransport]FAsyncCallback {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	callbacks := make(map[*fHTTPSubscriberTransport]FAsyncCallback, len(h.subscribers[topic]))
+	for transport, callback := range h.subscribers[topic] {
+		callbacks[transport] = callback
+	}
+	return callbacks
+}
+
+// reserve claims the idempotency key for dispatch. If the key is claimed, the
+// delivery tracking it is returned with true. Otherwise, a nil delivery is
+// returned if the message was already delivered, or the in-flight delivery if
+// another request is dispatching it.
+func (h *fHTTPSubscriberHandler) reserve(key string) (*httpDelivery, bool) {
+	h.deliveriesMu.Lock()
+	defer h.deliveriesMu.Unlock()
+	now := time.Now()
+	h.prune(now)
+
+	delivery, ok := h.deliveries[key]
+	switch {
+	case !ok || (!delivery.inFlight && now.After(delivery.expiry)):
+		delivery = &httpDelivery{delivered: make(map[*fHTTPSubscriberTransport]bool)}
+		h.deliveries[key] = delivery
+	case delivery.inFlight:
+		return delivery, false
+	case delivery.complete:
+		return nil, false
+	}
+	delivery.inFlight = true
+	return delivery, true
+}
+
+// release gives up the key after a failed delivery. The key is kept if any
+// callback succeeded so a retry doesn't invoke it again.
+func (h *fHTTPSubscriberHandler) release(key string, delivery *httpDelivery) {
+	h.deliveriesMu.Lock()
+	defer h.deliveriesMu.Unlock()
+	delivery.inFlight = false
+	if len(delivery.delivered) == 0 {
+		delete(h.deliveries, key)
+		return
+	}
+	delivery.expiry = time.Now().Add(h.idempotencyWindow)
+}
+
+// complete records the key's message as delivered for the idempotency window.
+func (h *fHTTPSubscriberHandler) complete(delivery *httpDelivery) {
+	h.deliveriesMu.Lock()
+	defer h.deliveriesMu.Unlock()
+	delivery.inFlight = false
+	delivery.complete = true
+	delivery.expiry = time.Now().Add(h.idempotencyWindow)
+}
+
+// prune removes expired keys once per idempotency window, or sooner if the
+// number of remembered keys reaches httpSubscriberPruneThreshold. The caller
+// must hold deliveriesMu.
+func (h *fHTTPSubscriberHandler) prune(now time.Time) {
+	if now.Before(h.nextPrune) && len(h.deliveries) < httpSubscriberPruneThreshold {
+		return
+	}
+	for key, delivery := range h.deliveries {
+		if !delivery.inFlight && now.After(delivery.expiry) {
+			delete(h.deliveries, key)
+		}
+	}
+	h.nextPrune = now.Add(h.idempotencyWindow)
+}
+
+func (h *fHTTPSubscriberHandler) subscribe(topic string, transport *fHTTPSubscriberTransport, callback FAsyncCallback) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	subscribers, ok := h.subscribers[topic]
+	if !ok {
+		subscribers = make(map[*fHTTPSubscriberTransport]FAsyncCallback)
+		h.subscribers[topic] = subscribers
+	}
+	subscribers[transport] = callback
+}
+
+func (h *fHTTPSubscriberHandler) unsubscribe(topic string, transport *fHTTPSubscriberTransport) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers[topic], transport)
+	if len(h.subscribers[topic]) == 0 {
+		delete(h.subscribers, topic)
+	}
+}
+
+// fHTTPSubscriberTransportFactory implements FSubscriberTransportFactory.
+type fHTTPSubscriberTransportFactory struct {
+	handler *fHTTPSubscriberHandler
+}
+
+// GetTransport creates a new FSubscriberTransport which receives messages
+// from the handler.
+func (f *fHTTPSubscriberTransportFactory) GetTransport() FSubscriberTransport {
+	return &fHTTPSubscriberTransport{handler: f.handler}
+}
+
+// fHTTPSubscriberTransport implements FSubscriberTransport by registering
+// its callback with an fHTTPSubscriberHandler.
+type fHTTPSubscriberTransport struct {
+	handler      *fHTTPSubscriberHandler
+	topic        string
+	openMu       sync.RWMutex
+	isSubscribed bool
+}
+
+// Subscribe registers the callback to receive messages POSTed to the handler
+// for the topic.
+func (s *fHTTPSubscriberTransport) Subscribe(topic string, callback FAsyncCallback) error {
+	s.openMu.Lock()
+	defer s.openMu.Unlock()
+
+	if s.isSubscribed {
+		return thrift.NewTTransportException(TRANSPORT_EXCEPTION_ALREADY_OPEN,
+			"frugal: HTTP transport already open")
+	}
+
+	if topic == "" {
+		return thrift.NewTTransportException(TRANSPORT_EXCEPTION_UNKNOWN,
+			"cannot subscribe to empty topic")
+	}
+
+	s.topic = fmt.Sprintf("%s%s", frugalPrefix, topic)
+	s.handler.subscribe(s.topic, s, callback)
+	s.isSubscribed = true
+	return nil
+}
+
+// IsSubscribed returns true if the transport is subscribed to a topic, false
+// otherwise.
+func (s *fHTTPSubscriberTransport) IsSubscribed() bool {
+	s.openMu.RLock()
+	defer s.openMu.RUnlock()
+	return s.isSubscribed
+}
+
+// Unsubscribe removes the callback from the handler.
+func (s *fHTTPSubscriberTransport) Unsubscribe() error {
+	s.openMu.Lock()
+	defer s.openMu.Unlock()
+	if !s.isSubscribed {
+		return nil
+	}
+	s.handler.unsubscribe(s.topic, s)
+	s.isSubscribed = false
+	return nil
+}
@@ -0,0 +1,299 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/stretchr/testify/assert"
+)
+
+// Ensures Publish returns an error if the transport is not open or the
+// message is too large.
+func TestHTTPPublisherPublishErrors(t *testing.T) {
+	tr := NewFHTTPPublisherTransportBuilder(nil, "http://localhost").
+		WithPublishSizeLimit(5).
+		Build()
+
+	err := tr.Publish("foo", make([]byte, 10))
+	assert.Equal(t, TRANSPORT_EXCEPTION_NOT_OPEN, err.(thrift.TTransportException).TypeId())
+
+	assert.Nil(t, tr.Open())
+	assert.True(t, tr.IsOpen())
+	assert.Equal(t, uint(5), tr.GetPublishSizeLimit())
+	assert.True(t, IsErrTooLarge(tr.Publish("foo", make([]byte, 10))))
+	assert.Nil(t, tr.Close())
+	assert.False(t, tr.IsOpen())
+}
+
+// Ensures a message published over HTTP is delivered to the callbacks
+// subscribed to its topic, using the topic-routed URL.
+func TestHTTPPublisherSubscriberHandler(t *testing.T) {
+	handler := NewFHTTPSubscriberHandlerBuilder().WithSigningSecret([]byte("secret")).Build()
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		assert.Equal(t, "bar", r.Header.Get("foo"))
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	received := make(chan []byte, 2)
+	cb := func(transport thrift.TTransport) error {
+		data, err := ioutil.ReadAll(transport)
+		assert.Nil(t, err)
+		received <- data
+		return nil
+	}
+	factory := handler.SubscriberTransportFactory()
+	sub1, sub2 := factory.GetTransport(), factory.GetTransport()
+	assert.Nil(t, sub1.Subscribe("foo", cb))
+	assert.Nil(t, sub2.Subscribe("foo", cb))
+	assert.True(t, sub1.IsSubscribed())
+	err := sub1.Subscribe("foo", cb)
+	assert.Equal(t, TRANSPORT_EXCEPTION_ALREADY_OPEN, err.(thrift.TTransportException).TypeId())
+
+	builder := NewFHTTPPublisherTransportBuilder(&http.Client{}, server.URL+"/hooks/{topic}").
+		WithSigningSecret([]byte("secret")).
+		WithRequestHeaders(map[string]string{"foo": "bar"})
+	pub := NewFHTTPPublisherTransportFactory(builder).GetTransport()
+	assert.Nil(t, pub.Open())
+	assert.Nil(t, pub.Publish("foo", []byte{0, 0, 0, 3, 1, 2, 3}))
+	assert.Equal(t, "/hooks/frugal.foo", path)
+	assert.Equal(t, []byte{1, 2, 3}, <-received)
+	assert.Equal(t, []byte{1, 2, 3}, <-received)
+
+	// Once every subscriber leaves, the handler rejects the topic.
+	assert.Nil(t, sub1.Unsubscribe())
+	assert.Nil(t, sub2.Unsubscribe())
+	assert.False(t, sub1.IsSubscribed())
+	assert.Nil(t, sub1.Unsubscribe())
+	err = pub.Publish("foo", []byte{0, 0, 0, 3, 1, 2, 3})
+	assert.True(t, strings.HasSuffix(err.Error(), "status 404"))
+}
+
+// Ensures Publish retries transient failures with the same idempotency key
+// and the handler discards the duplicate delivery.
+func TestHTTPPublisherRetryIdempotency(t *testing.T) {
+	oldKey := generateIdempotencyKey
+	defer func() { generateIdempotencyKey = oldKey }()
+	generateIdempotencyKey = func() string { return "key" }
+
+	handler := NewFHTTPSubscriberHandlerBuilder().Build()
+	var mu sync.Mutex
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		attempt := calls
+		mu.Unlock()
+		assert.Equal(t, "key", r.Header.Get(idempotencyKeyHeader))
+		if attempt == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	delivered := 0
+	sub := handler.SubscriberTransportFactory().GetTransport()
+	assert.Nil(t, sub.Subscribe("foo", func(thrift.TTransport) error {
+		delivered++
+		return nil
+	}))
+
+	pub := NewFHTTPPublisherTransportBuilder(nil, server.URL).
+		WithRetry(3, time.Millisecond).
+		Build()
+	assert.Nil(t, pub.Open())
+	assert.Nil(t, pub.Publish("foo", []byte{0, 0, 0, 1, 1}))
+	assert.Nil(t, pub.Publish("foo", []byte{0, 0, 0, 1, 1}))
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, 1, delivered)
+}
+
+// Ensures Publish gives up after the maximum attempts and doesn't retry
+// client errors.
+func TestHTTPPublisherRetryExhausted(t *testing.T) {
+	status := http.StatusInternalServerError
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	pub := NewFHTTPPublisherTransportBuilder(nil, server.URL).
+		WithRetry(2, time.Millisecond).
+		WithTimeout(time.Second).
+		Build()
+	assert.Nil(t, pub.Open())
+	err := pub.Publish("foo", []byte{0, 0, 0, 0})
+	_, ok := err.(thrift.TTransportException)
+	assert.True(t, ok)
+	assert.Equal(t, 2, calls)
+
+	status = http.StatusBadRequest
+	calls = 0
+	assert.NotNil(t, pub.Publish("foo", []byte{0, 0, 0, 0}))
+	assert.Equal(t, 1, calls)
+}
+
+// Ensures the handler rejects requests with invalid signatures, stale
+// timestamps, or bad bodies and asks for redelivery when a callback fails.
+func TestHTTPSubscriberHandlerErrors(t *testing.T) {
+	handler := NewFHTTPSubscriberHandlerBuilder().
+		WithSigningSecret([]byte("secret")).
+		WithTimestampTolerance(time.Minute).
+		WithIdempotencyWindow(0).
+		Build()
+	sub := handler.SubscriberTransportFactory().GetTransport()
+	err := sub.Subscribe("", nil)
+	assert.Equal(t, TRANSPORT_EXCEPTION_UNKNOWN, err.(thrift.TTransportException).TypeId())
+	assert.Nil(t, sub.Subscribe("foo", func(thrift.TTransport) error { return errors.New("error") }))
+
+	send := func(method, topic, timestamp, signature, body string) int {
+		r := httptest.NewRequest(method, "/", strings.NewReader(body))
+		r.Header.Set(topicHeader, topic)
+		r.Header.Set(timestampHeader, timestamp)
+		r.Header.Set(signatureHeader, signature)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	sign := func(timestamp, body string) string {
+		return signPublish([]byte("secret"), timestamp, "", "frugal.foo", []byte(body))
+	}
+	frame := string([]byte{0, 0, 0, 1, 1})
+
+	assert.Equal(t, http.StatusMethodNotAllowed, send("GET", "frugal.foo", now, sign(now, frame), frame))
+	assert.Equal(t, http.StatusBadRequest, send("POST", "", now, sign(now, frame), frame))
+	assert.Equal(t, http.StatusUnauthorized, send("POST", "frugal.foo", now, "sha256=00", frame))
+	assert.Equal(t, http.StatusUnauthorized, send("POST", "frugal.foo", stale, sign(stale, frame), frame))
+	assert.Equal(t, http.StatusUnauthorized, send("POST", "frugal.foo", "abc", sign("abc", frame), frame))
+	assert.Equal(t, http.StatusBadRequest, send("POST", "frugal.foo", now, sign(now, "ab"), "ab"))
+	assert.Equal(t, http.StatusInternalServerError, send("POST", "frugal.foo", now, sign(now, frame), frame))
+}
+
+// Ensures requests larger than the message size limit are rejected with 413
+// whether or not they have a Content-Length.
+func TestHTTPSubscriberHandlerMessageSizeLimit(t *testing.T) {
+	handler := NewFHTTPSubscriberHandlerBuilder().
+		WithMessageSizeLimit(5).
+		WithIdempotencyWindow(0).
+		Build()
+	delivered := 0
+	sub := handler.SubscriberTransportFactory().GetTransport()
+	assert.Nil(t, sub.Subscribe("foo", func(thrift.TTransport) error {
+		delivered++
+		return nil
+	}))
+
+	send := func(body []byte, contentLength int64) int {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(string(body)))
+		r.ContentLength = contentLength
+		r.Header.Set(topicHeader, "frugal.foo")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	frame := []byte{0, 0, 0, 1, 1}
+	assert.Equal(t, http.StatusOK, send(frame, int64(len(frame))))
+	large := []byte{0, 0, 0, 2, 1, 2}
+	assert.Equal(t, http.StatusRequestEntityTooLarge, send(large, int64(len(large))))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, send(large, -1))
+	assert.Equal(t, 1, delivered)
+}
+
+// Ensures concurrent deliveries with the same idempotency key only dispatch
+// the message once and the duplicate is asked to redeliver.
+func TestHTTPSubscriberHandlerConcurrentIdempotency(t *testing.T) {
+	handler := NewFHTTPSubscriberHandlerBuilder().Build()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	delivered := 0
+	sub := handler.SubscriberTransportFactory().GetTransport()
+	assert.Nil(t, sub.Subscribe("foo", func(thrift.TTransport) error {
+		delivered++
+		close(started)
+		<-release
+		return nil
+	}))
+
+	send := func() int {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(string([]byte{0, 0, 0, 1, 1})))
+		r.Header.Set(topicHeader, "frugal.foo")
+		r.Header.Set(idempotencyKeyHeader, "key")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	first := make(chan int)
+	go func() { first <- send() }()
+	<-started
+	assert.Equal(t, http.StatusServiceUnavailable, send())
+	close(release)
+	assert.Equal(t, http.StatusOK, <-first)
+	assert.Equal(t, http.StatusOK, send())
+	assert.Equal(t, 1, delivered)
+}
+
+// Ensures a retry after a partial failure only invokes the callbacks which
+// failed.
+func TestHTTPSubscriberHandlerPartialRedelivery(t *testing.T) {
+	handler := NewFHTTPSubscriberHandlerBuilder().Build()
+	okCalls, failCalls := 0, 0
+	ok := handler.SubscriberTransportFactory().GetTransport()
+	assert.Nil(t, ok.Subscribe("foo", func(thrift.TTransport) error {
+		okCalls++
+		return nil
+	}))
+	failing := handler.SubscriberTransportFactory().GetTransport()
+	assert.Nil(t, failing.Subscribe("foo", func(thrift.TTransport) error {
+		failCalls++
+		if failCalls == 1 {
+			return errors.New("error")
+		}
+		return nil
+	}))
+
+	send := func() int {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(string([]byte{0, 0, 0, 1, 1})))
+		r.Header.Set(topicHeader, "frugal.foo")
+		r.Header.Set(idempotencyKeyHeader, "key")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, send())
+	assert.Equal(t, http.StatusOK, send())
+	assert.Equal(t, http.StatusOK, send())
+	assert.Equal(t, 1, okCalls)
+	assert.Equal(t, 2, failCalls)
+}
@@ -42,6 +42,25 @@ var newEncoder = func(buf *bytes.Buffer) io.WriteCloser {
 	return base64.NewEncoder(base64.StdEncoding, buf)
 }
 
+// NewFrugalHandlerFuncWithHealth creates a Frugal handler function like
+// NewFrugalHandlerFunc which also serves the health service with the given
+// FHealthServer, registering it with the processor. GET requests are answered
+// like HTTP health probes by FHealthServer.ServeHTTP. It panics if the health
+// service can't be registered with the processor.
+func NewFrugalHandlerFuncWithHealth(processor FProcessor, protocolFactory *FProtocolFactory, health *FHealthServer) http.HandlerFunc {
+	if err := health.Register(processor); err != nil {
+		panic(fmt.Sprintf("frugal: can't serve the health service: %s", err))
+	}
+	handler := NewFrugalHandlerFunc(processor, protocolFactory)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			health.ServeHTTP(w, r)
+			return
+		}
+		handler(w, r)
+	}
+}
+
 // NewFrugalHandlerFunc is a function that creates a ready to use Frugal handler
 // function.
 func NewFrugalHandlerFunc(processor FProcessor, protocolFactory *FProtocolFactory) http.HandlerFunc {
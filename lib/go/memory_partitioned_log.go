@@ -0,0 +1,271 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// fMemoryPartitionedLog implements FPartitionedLog by keeping every topic in
+// memory. It is intended for tests.
+type fMemoryPartitionedLog struct {
+	mu         sync.Mutex
+	partitions int32
+	topics     map[string]*memoryLogTopic
+}
+
+// memoryLogTopic holds the records and consumer groups of a single topic.
+type memoryLogTopic struct {
+	name       string
+	partitions [][]*FLogRecord
+	groups     map[string]*memoryLogGroup
+}
+
+// memoryLogGroup tracks the next offset to deliver for each partition of a
+// topic and the members the partitions are assigned to.
+type memoryLogGroup struct {
+	offsets  []int64
+	inFlight []bool
+	members  []*memoryLogConsumer
+}
+
+// memoryLogConsumer is a single member of a memoryLogGroup.
+type memoryLogConsumer struct {
+	log     *fMemoryPartitionedLog
+	topic   *memoryLogTopic
+	group   *memoryLogGroup
+	handler FLogRecordHandler
+	notify  chan struct{}
+	quit    chan struct{}
+	closed  bool
+}
+
+// NewFMemoryPartitionedLog returns an FPartitionedLog which keeps records in
+// memory, creating topics with the given number of partitions on first use.
+// Like a NATS subscription, consumers only receive records appended after they
+// join. This is useful for testing partitioned log scopes without a broker.
+func NewFMemoryPartitionedLog(partitions int32) FPartitionedLog {
+	if partitions <= 0 {
+		partitions = 1
+	}
+	return &fMemoryPartitionedLog{
+		partitions: partitions,
+		topics:     make(map[string]*memoryLogTopic),
+	}
+}
+
+// Partitions returns the number of partitions for the given topic.
+func (m *fMemoryPartitionedLog) Partitions(topic string) (int32, error) {
+	return m.partitions, nil
+}
+
+// Append writes the key and value to the end of the given partition of the
+// topic.
+func (m *fMemoryPartitionedLog) Append(topic string, partition int32, key, value []byte) error {
+	if partition < 0 || partition >= m.partitions {
+		return fmt.Errorf("frugal: partition %d out of range for topic %s", partition, topic)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := m.getTopic(topic)
+	t.partitions[partition] = append(t.partitions[partition], &FLogRecord{
+		Topic:     topic,
+		Partition: partition,
+		Offset:    int64(len(t.partitions[partition])),
+		Key:       key,
+		Value:     value,
+	})
+	for _, group := range t.groups {
+		for _, member := range group.members {
+			member.signal()
+		}
+	}
+	return nil
+}
+
+// Consume delivers records appended to the topic to the given handler until
+// the returned io.Closer is closed. An empty group gives the consumer a
+// private group, so it receives records from every partition.
+func (m *fMemoryPartitionedLog) Consume(topic, group string, handler FLogRecordHandler) (io.Closer, error) {
+	if handler == nil {
+		return nil, errors.New("frugal: nil record handler")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t := m.getTopic(topic)
+	consumer := &memoryLogConsumer{
+		log:     m,
+		topic:   t,
+		handler: handler,
+		notify:  make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+	}
+	if group == "" {
+		// Private groups are keyed by the consumer itself so they are never
+		// shared.
+		group = fmt.Sprintf("%p", consumer)
+	}
+	g, ok := t.groups[group]
+	if !ok {
+		g = &memoryLogGroup{
+			offsets:  make([]int64, m.partitions),
+			inFlight: make([]bool, m.partitions),
+		}
+		// New groups start at the end of each partition.
+		for i, records := range t.partitions {
+			g.offsets[i] = int64(len(records))
+		}
+		t.groups[group] = g
+	}
+	consumer.group = g
+	g.members = append(g.members, consumer)
+
+	go consumer.run()
+	return consumer, nil
+}
+
+// getTopic returns the named topic, creating it if necessary. The caller must
+// hold the log's mutex.
+func (m *fMemoryPartitionedLog) getTopic(name string) *memoryLogTopic {
+	t, ok := m.topics[name]
+	if !ok {
+		t = &memoryLogTopic{
+			name:       name,
+			partitions: make([][]*FLogRecord, m.partitions),
+			groups:     make(map[string]*memoryLogGroup),
+		}
+		m.topics[name] = t
+	}
+	return t
+}
+
+// Close leaves the consumer group, handing its partitions to the remaining
+// members, and stops delivering records.
+func (c *memoryLogConsumer) Close() error {
+	c.log.mu.Lock()
+	defer c.log.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	close(c.quit)
+
+	members := c.group.members
+	for i, member := range members {
+		if member == c {
+			c.group.members = append(members[:i:i], members[i+1:]...)
+			break
+		}
+	}
+	if len(c.group.members) == 0 {
+		for name, group := range c.topic.groups {
+			if group == c.group {
+				delete(c.topic.groups, name)
+			}
+		}
+	}
+	// Remaining members may have been assigned new partitions.
+	for _, member := range c.group.members {
+		member.signal()
+	}
+	return nil
+}
+
+// signal wakes the consumer's delivery goroutine without blocking.
+func (c *memoryLogConsumer) signal() {
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+// run delivers records until the consumer is closed.
+func (c *memoryLogConsumer) run() {
+	for {
+		for c.deliverNext() {
+		}
+		select {
+		case <-c.quit:
+			return
+		case <-c.notify:
+		}
+	}
+}
+
+// deliverNext passes the next pending record from one of the consumer's
+// assigned partitions to the handler. It returns false if there was nothing to
+// deliver.
+func (c *memoryLogConsumer) deliverNext() bool {
+	c.log.mu.Lock()
+	if c.closed {
+		c.log.mu.Unlock()
+		return false
+	}
+	record := c.claimRecord()
+	c.log.mu.Unlock()
+	if record == nil {
+		return false
+	}
+
+	if err := c.handler(record); err != nil {
+		logger().Warnf("frugal: error handling record at offset %d of %s[%d]: %s",
+			record.Offset, record.Topic, record.Partition, err)
+	}
+
+	c.log.mu.Lock()
+	c.group.offsets[record.Partition] = record.Offset + 1
+	c.group.inFlight[record.Partition] = false
+	// Another member may have been waiting on this partition after a
+	// rebalance.
+	for _, member := range c.group.members {
+		if member != c {
+			member.signal()
+		}
+	}
+	c.log.mu.Unlock()
+	return true
+}
+
+// claimRecord finds the next record in a partition assigned to the consumer
+// which is not already being handled and marks the partition in flight. The
+// caller must hold the log's mutex.
+func (c *memoryLogConsumer) claimRecord() *FLogRecord {
+	index := -1
+	for i, member := range c.group.members {
+		if member == c {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil
+	}
+
+	for partition, records := range c.topic.partitions {
+		if partition%len(c.group.members) != index || c.group.inFlight[partition] {
+			continue
+		}
+		offset := c.group.offsets[partition]
+		if offset < int64(len(records)) {
+			c.group.inFlight[partition] = true
+			return records[offset]
+		}
+	}
+	return nil
+}
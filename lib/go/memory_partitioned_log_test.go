@@ -0,0 +1,145 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordCollector gathers records delivered to a consumer.
+type recordCollector struct {
+	mu      sync.Mutex
+	records []*FLogRecord
+}
+
+func (r *recordCollector) handle(record *FLogRecord) error {
+	r.mu.Lock()
+	r.records = append(r.records, record)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *recordCollector) get() []*FLogRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records := make([]*FLogRecord, len(r.records))
+	copy(records, r.records)
+	return records
+}
+
+func waitForRecords(t *testing.T, count int, collectors ...*recordCollector) {
+	end := time.Now().Add(time.Second)
+	for time.Now().Before(end) {
+		total := 0
+		for _, c := range collectors {
+			total += len(c.get())
+		}
+		if total >= count {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Did not receive %d records", count)
+}
+
+// Ensures consumers without a group each receive every record, in order, and
+// only records appended after they joined.
+func TestMemoryPartitionedLogConsumeNoGroup(t *testing.T) {
+	log := NewFMemoryPartitionedLog(2)
+	partitions, err := log.Partitions("foo")
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), partitions)
+
+	assert.Nil(t, log.Append("foo", 0, nil, []byte("before")))
+
+	c1, c2 := &recordCollector{}, &recordCollector{}
+	closer1, err := log.Consume("foo", "", c1.handle)
+	assert.Nil(t, err)
+	closer2, err := log.Consume("foo", "", c2.handle)
+	assert.Nil(t, err)
+
+	assert.Nil(t, log.Append("foo", 0, []byte("k"), []byte("a")))
+	assert.Nil(t, log.Append("foo", 0, []byte("k"), []byte("b")))
+	assert.Nil(t, log.Append("foo", 1, nil, []byte("c")))
+	waitForRecords(t, 3, c1)
+	waitForRecords(t, 3, c2)
+
+	for _, c := range []*recordCollector{c1, c2} {
+		var partition0 []string
+		for _, record := range c.get() {
+			assert.Equal(t, "foo", record.Topic)
+			if record.Partition == 0 {
+				partition0 = append(partition0, string(record.Value))
+			}
+		}
+		assert.Equal(t, []string{"a", "b"}, partition0)
+	}
+
+	assert.Nil(t, closer1.Close())
+	assert.Nil(t, closer1.Close())
+	assert.Nil(t, closer2.Close())
+}
+
+// Ensures members of a group split partitions so each record is delivered once
+// and records from a partition are delivered to a single member in order.
+func TestMemoryPartitionedLogConsumeGroup(t *testing.T) {
+	log := NewFMemoryPartitionedLog(4)
+	c1, c2 := &recordCollector{}, &recordCollector{}
+	closer1, err := log.Consume("foo", "group", c1.handle)
+	assert.Nil(t, err)
+	closer2, err := log.Consume("foo", "group", c2.handle)
+	assert.Nil(t, err)
+
+	for i := 0; i < 10; i++ {
+		for p := int32(0); p < 4; p++ {
+			assert.Nil(t, log.Append("foo", p, nil, []byte{byte(i)}))
+		}
+	}
+	waitForRecords(t, 40, c1, c2)
+	time.Sleep(10 * time.Millisecond)
+	assert.Len(t, append(c1.get(), c2.get()...), 40)
+
+	for _, c := range []*recordCollector{c1, c2} {
+		next := map[int32]int64{}
+		for _, record := range c.get() {
+			assert.Equal(t, next[record.Partition], record.Offset)
+			next[record.Partition] = record.Offset + 1
+		}
+		assert.Len(t, next, 2)
+	}
+
+	// Closing a member hands its partitions to the remaining member.
+	assert.Nil(t, closer1.Close())
+	for p := int32(0); p < 4; p++ {
+		assert.Nil(t, log.Append("foo", p, nil, []byte("after")))
+	}
+	waitForRecords(t, 24, c2)
+	assert.Nil(t, closer2.Close())
+}
+
+// Ensures Append rejects out of range partitions and Consume rejects a nil
+// handler.
+func TestMemoryPartitionedLogErrors(t *testing.T) {
+	log := NewFMemoryPartitionedLog(0)
+	assert.NotNil(t, log.Append("foo", 1, nil, nil))
+	assert.NotNil(t, log.Append("foo", -1, nil, nil))
+	assert.Nil(t, log.Append("foo", 0, nil, nil))
+
+	_, err := log.Consume("foo", "", nil)
+	assert.NotNil(t, err)
+}
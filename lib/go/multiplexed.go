@@ -0,0 +1,147 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// FServiceProcessor is an FProcessor which exposes the FProcessorFunctions of
+// the methods it serves. Generated processors implement it by embedding
+// FBaseProcessor.
+type FServiceProcessor interface {
+	FProcessor
+
+	// Methods returns the sorted names of the methods registered with the
+	// processor.
+	Methods() []string
+
+	// Services returns a map of service name to the sorted names of the
+	// methods of the service registered with the processor.
+	Services() map[string][]string
+
+	// GetProcessorFunction returns the FProcessorFunction registered for the
+	// given method, if any.
+	GetProcessorFunction(method string) (FProcessorFunction, bool)
+}
+
+// FMultiplexedProcessor is an FProcessor serving several services, which
+// allows them to share an FServer, NATS subject, or HTTP endpoint. Requests
+// are routed by method names qualified by the name a service is registered
+// under, such as "Store:getAlbum", which clients send when they are created
+// with a provider returned by NewFMultiplexedServiceProvider or with the
+// NewF<Service>MultiplexedClient constructors generated by the Go multiplex
+// option. Responses carry the unqualified method name like they do for a
+// processor serving a single service.
+type FMultiplexedProcessor struct {
+	*FBaseProcessor
+	processors map[string]FServiceProcessor
+}
+
+// NewFMultiplexedProcessor returns a new FMultiplexedProcessor with no
+// services registered.
+func NewFMultiplexedProcessor() *FMultiplexedProcessor {
+	return &FMultiplexedProcessor{
+		FBaseProcessor: NewFBaseProcessor(),
+		processors:     make(map[string]FServiceProcessor),
+	}
+}
+
+// RegisterProcessor registers the methods of the FServiceProcessor, including
+// those of services it extends, under the given service name. This should
+// only be called before the server is started.
+func (f *FMultiplexedProcessor) RegisterProcessor(serviceName string, processor FServiceProcessor) error {
+	if serviceName == "" || strings.Contains(serviceName, thrift.MULTIPLEXED_SEPARATOR) {
+		return fmt.Errorf("frugal: invalid service name \"%s\"", serviceName)
+	}
+	if _, ok := f.processors[serviceName]; ok {
+		return fmt.Errorf("frugal: service %s is already registered", serviceName)
+	}
+	f.processors[serviceName] = processor
+	f.register(serviceName+thrift.MULTIPLEXED_SEPARATOR, processor)
+	return nil
+}
+
+// RegisterDefault registers the methods of the FServiceProcessor under their
+// unqualified names, which serves clients which don't qualify method names.
+// This should only be called before the server is started.
+func (f *FMultiplexedProcessor) RegisterDefault(processor FServiceProcessor) error {
+	for _, method := range processor.Methods() {
+		if _, ok := f.GetProcessorFunction(method); ok {
+			return errors.New("frugal: a default processor is already registered")
+		}
+	}
+	f.register("", processor)
+	return nil
+}
+
+func (f *FMultiplexedProcessor) register(prefix string, processor FServiceProcessor) {
+	annotations := processor.Annotations()
+	for _, method := range processor.Methods() {
+		proc, _ := processor.GetProcessorFunction(method)
+		f.AddToProcessorMap(prefix+method, proc)
+		if methodAnnotations, ok := annotations[method]; ok {
+			f.AddToAnnotationsMap(prefix+method, methodAnnotations)
+		}
+	}
+}
+
+// Services returns a map of the names the services are registered under to
+// the sorted names of the methods they serve. Methods of the default
+// processor aren't included.
+func (f *FMultiplexedProcessor) Services() map[string][]string {
+	services := make(map[string][]string, len(f.processors))
+	for serviceName, processor := range f.processors {
+		services[serviceName] = processor.Methods()
+	}
+	return services
+}
+
+// ServiceNames returns the sorted names the services are registered under.
+func (f *FMultiplexedProcessor) ServiceNames() []string {
+	names := make([]string, 0, len(f.processors))
+	for serviceName := range f.processors {
+		names = append(names, serviceName)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// multiplexedProtocolFactory is a TProtocolFactory whose TProtocols qualify
+// the method names of requests by a service name.
+type multiplexedProtocolFactory struct {
+	protoFactory thrift.TProtocolFactory
+	serviceName  string
+}
+
+func (m *multiplexedProtocolFactory) GetProtocol(tr thrift.TTransport) thrift.TProtocol {
+	return thrift.NewTMultiplexedProtocol(m.protoFactory.GetProtocol(tr), m.serviceName)
+}
+
+// NewFMultiplexedServiceProvider returns a copy of the FServiceProvider whose
+// clients qualify method names by the given service name, so their requests
+// are routed to the service registered under it with an
+// FMultiplexedProcessor.
+func NewFMultiplexedServiceProvider(provider *FServiceProvider, serviceName string) *FServiceProvider {
+	protocolFactory := NewFProtocolFactory(&multiplexedProtocolFactory{
+		protoFactory: provider.protocolFactory.protoFactory,
+		serviceName:  serviceName,
+	})
+	return NewFServiceProvider(provider.transport, protocolFactory, provider.GetMiddleware()...)
+}
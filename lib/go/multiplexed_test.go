@@ -0,0 +1,134 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"testing"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/stretchr/testify/assert"
+)
+
+var _ FServiceProcessor = (*FBaseProcessor)(nil)
+
+// writeMultiplexedRequest returns a protocol containing a request for the
+// given method with correlation id 123.
+func writeMultiplexedRequest(t *testing.T, method string) *FProtocol {
+	proto := &FProtocol{thrift.NewTJSONProtocol(thrift.NewTMemoryBuffer())}
+	assert.Nil(t, proto.WriteRequestHeader(NewFContext("123")))
+	assert.Nil(t, proto.WriteMessageBegin(method, thrift.CALL, 0))
+	assert.Nil(t, proto.WriteStructBegin(""))
+	assert.Nil(t, proto.WriteFieldStop())
+	assert.Nil(t, proto.WriteStructEnd())
+	assert.Nil(t, proto.WriteMessageEnd())
+	assert.Nil(t, proto.Flush())
+	return proto
+}
+
+// Ensures FMultiplexedProcessor routes requests by service-qualified method
+// names to the processor the service is registered with.
+func TestFMultiplexedProcessorRoutes(t *testing.T) {
+	store := NewFBaseProcessor()
+	storePing := &pingProcessor{t: t}
+	store.AddToProcessorMap("ping", storePing)
+	album := NewFBaseProcessor()
+	albumPing := &pingProcessor{t: t}
+	album.AddToProcessorMap("ping", albumPing)
+	processor := NewFMultiplexedProcessor()
+	assert.Nil(t, processor.RegisterProcessor("Store", store))
+	assert.Nil(t, processor.RegisterProcessor("Album", album))
+
+	proto := writeMultiplexedRequest(t, "Album:ping")
+	albumPing.expectedProto = proto
+	assert.Nil(t, processor.Process(proto, proto))
+	assert.True(t, albumPing.called)
+	assert.False(t, storePing.called)
+
+	proto = writeMultiplexedRequest(t, "Store:ping")
+	storePing.expectedProto = proto
+	assert.Nil(t, processor.Process(proto, proto))
+	assert.True(t, storePing.called)
+}
+
+// Ensures FMultiplexedProcessor routes unqualified method names to the default
+// processor and answers unknown ones with an UNKNOWN_METHOD exception.
+func TestFMultiplexedProcessorDefault(t *testing.T) {
+	store := NewFBaseProcessor()
+	ping := &pingProcessor{t: t}
+	store.AddToProcessorMap("ping", ping)
+	processor := NewFMultiplexedProcessor()
+	assert.Nil(t, processor.RegisterDefault(store))
+	assert.Error(t, processor.RegisterDefault(store))
+
+	proto := writeMultiplexedRequest(t, "ping")
+	ping.expectedProto = proto
+	assert.Nil(t, processor.Process(proto, proto))
+	assert.True(t, ping.called)
+
+	proto = writeMultiplexedRequest(t, "Store:ping")
+	assert.Nil(t, processor.Process(proto, proto))
+	assert.Nil(t, proto.ReadResponseHeader(NewFContext("")))
+	name, typeID, _, err := proto.ReadMessageBegin()
+	assert.Nil(t, err)
+	assert.Equal(t, "Store:ping", name)
+	assert.Equal(t, thrift.EXCEPTION, typeID)
+	ex, err := thrift.NewTApplicationException(0, "").Read(proto)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(APPLICATION_EXCEPTION_UNKNOWN_METHOD), ex.TypeId())
+}
+
+// Ensures FMultiplexedProcessor lists the registered services and rejects
+// invalid or duplicate service names.
+func TestFMultiplexedProcessorRegistry(t *testing.T) {
+	store := NewFBaseProcessor()
+	store.AddToProcessorMap("ping", &pingProcessor{t: t})
+	store.AddToProcessorMap("getAlbum", &pingProcessor{t: t})
+	store.AddToAnnotationsMap("getAlbum", map[string]string{"deprecated": ""})
+	processor := NewFMultiplexedProcessor()
+
+	assert.Nil(t, processor.RegisterProcessor("Store", store))
+	assert.Error(t, processor.RegisterProcessor("Store", store))
+	assert.Error(t, processor.RegisterProcessor("", store))
+	assert.Error(t, processor.RegisterProcessor("Store:v2", store))
+
+	assert.Equal(t, []string{"Store"}, processor.ServiceNames())
+	assert.Equal(t, map[string][]string{"Store": {"getAlbum", "ping"}}, processor.Services())
+	assert.Equal(t, []string{"Store:getAlbum", "Store:ping"}, processor.Methods())
+	assert.Equal(t, map[string]map[string]string{"Store:getAlbum": {"deprecated": ""}}, processor.Annotations())
+}
+
+// Ensures clients created with a multiplexed FServiceProvider qualify the
+// method names of requests but not of responses.
+func TestNewFMultiplexedServiceProvider(t *testing.T) {
+	transport := NewAdapterTransport(thrift.NewTMemoryBuffer())
+	provider := NewFServiceProvider(transport, NewFProtocolFactory(thrift.NewTJSONProtocolFactory()))
+	multiplexed := NewFMultiplexedServiceProvider(provider, "Store")
+	assert.Equal(t, transport, multiplexed.GetTransport())
+
+	buffer := thrift.NewTMemoryBuffer()
+	proto := multiplexed.GetProtocolFactory().GetProtocol(buffer)
+	assert.Nil(t, proto.WriteMessageBegin("ping", thrift.CALL, 0))
+	assert.Nil(t, proto.WriteMessageEnd())
+	assert.Nil(t, proto.WriteMessageBegin("ping", thrift.REPLY, 0))
+	assert.Nil(t, proto.WriteMessageEnd())
+	assert.Nil(t, proto.Flush())
+
+	name, _, _, err := proto.ReadMessageBegin()
+	assert.Nil(t, err)
+	assert.Equal(t, "Store:ping", name)
+	assert.Nil(t, proto.ReadMessageEnd())
+	name, _, _, err = proto.ReadMessageBegin()
+	assert.Nil(t, err)
+	assert.Equal(t, "ping", name)
+}
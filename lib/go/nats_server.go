@@ -15,6 +15,7 @@ package frugal
 
 import (
 	"bytes"
+	"fmt"
 	"time"
 
 	"git.apache.org/thrift.git/lib/go/thrift"
@@ -42,6 +43,7 @@ type FNatsServerBuilder struct {
 	workerCount   uint
 	queueLen      uint
 	highWatermark time.Duration
+	health        *FHealthServer
 }
 
 // NewFNatsServerBuilder creates a builder which configures and builds NATS
@@ -85,8 +87,22 @@ func (f *FNatsServerBuilder) WithHighWatermark(highWatermark time.Duration) *FNa
 	return f
 }
 
+// WithHealth serves the health service with the given FHealthServer, which is
+// registered with the processor when the server is built. Build panics if it
+// can't be registered. The statuses of the services are set to not serving
+// when the server is stopped.
+func (f *FNatsServerBuilder) WithHealth(health *FHealthServer) *FNatsServerBuilder {
+	f.health = health
+	return f
+}
+
 // Build a new configured NATS FServer.
 func (f *FNatsServerBuilder) Build() FServer {
+	if f.health != nil {
+		if err := f.health.Register(f.processor); err != nil {
+			panic(fmt.Sprintf("frugal: can't serve the health service: %s", err))
+		}
+	}
 	return &fNatsServer{
 		conn:          f.conn,
 		processor:     f.processor,
@@ -97,6 +113,8 @@ func (f *FNatsServerBuilder) Build() FServer {
 		workC:         make(chan *frameWrapper, f.queueLen),
 		quit:          make(chan struct{}),
 		highWatermark: f.highWatermark,
+		inbox:         nats.NewInbox(),
+		health:        f.health,
 	}
 }
 
@@ -112,6 +130,12 @@ type fNatsServer struct {
 	workC         chan *frameWrapper
 	quit          chan struct{}
 	highWatermark time.Duration
+	health        *FHealthServer
+
+	// inbox receives the frames clients send on streams after the request
+	// which opened them. It's the reply subject of every response so clients
+	// send those frames to the server handling the stream.
+	inbox string
 }
 
 // Serve starts the server.
@@ -124,6 +148,11 @@ func (f *fNatsServer) Serve() error {
 		}
 		subscriptions = append(subscriptions, sub)
 	}
+	sub, err := f.conn.Subscribe(f.inbox, f.streamHandler)
+	if err != nil {
+		return err
+	}
+	subscriptions = append(subscriptions, sub)
 
 	for i := uint(0); i < f.workerCount; i++ {
 		go f.worker()
@@ -142,6 +171,9 @@ func (f *fNatsServer) Serve() error {
 
 // Stop the server.
 func (f *fNatsServer) Stop() error {
+	if f.health != nil {
+		f.health.Shutdown()
+	}
 	close(f.quit)
 	return nil
 }
@@ -160,6 +192,20 @@ func (f *fNatsServer) handler(msg *nats.Msg) {
 	}
 }
 
+// streamHandler is invoked when a frame is sent on a stream. Stream frames
+// are processed as they're received rather than by a worker so they stay in
+// order. Their reply subject is the inbox of the request which opened the
+// stream.
+func (f *fNatsServer) streamHandler(msg *nats.Msg) {
+	if len(msg.Data) < 4 {
+		logger().Warn("frugal: discarding invalid NATS stream frame")
+		return
+	}
+	if err := f.processFrame(msg.Data, msg.Reply); err != nil {
+		logger().Errorf("frugal: error processing stream frame: %s", err.Error())
+	}
+}
+
 // worker should be called as a goroutine. It reads requests off the work
 // channel and processes them.
 func (f *fNatsServer) worker() {
@@ -184,18 +230,24 @@ func (f *fNatsServer) worker() {
 func (f *fNatsServer) processFrame(frame []byte, reply string) error {
 	// Read and process frame.
 	input := &thrift.TMemoryBuffer{Buffer: bytes.NewBuffer(frame[4:])} // Discard frame size
-	// Only allow 1MB to be buffered.
-	output := NewTMemoryOutputBuffer(natsMaxMessageSize)
+	// Only allow 1MB to be buffered. Each flushed response is published to
+	// the reply subject, which allows streaming methods to send several. The
+	// reply subject is the client's inbox, so it also identifies the client
+	// the streams it opens belong to.
+	output := newTFramedOutputSender(natsMaxMessageSize, reply, func(data []byte) error {
+		return f.conn.PublishRequest(reply, f.inbox, data)
+	})
 	iprot := f.protoFactory.GetProtocol(input)
 	oprot := f.protoFactory.GetProtocol(output)
 	if err := f.processor.Process(iprot, oprot); err != nil {
 		return err
 	}
 
-	if !output.HasWriteData() {
+	// A stream handler still writing to the output flushes it itself.
+	if output.detached {
 		return nil
 	}
 
-	// Send response.
-	return f.conn.Publish(reply, output.Bytes())
+	// Send any response which wasn't flushed.
+	return output.Flush()
 }
@@ -86,3 +86,45 @@ func (p *processor) AddMiddleware(middleware ServiceMiddleware) {}
 func (p *processor) Annotations() map[string]map[string]string {
 	return nil
 }
+
+// Ensures servers built with a health server serve checks and report not
+// serving once stopped.
+func TestFNatsServerHealth(t *testing.T) {
+	s := runServer(nil)
+	defer s.Shutdown()
+	conn, err := nats.Connect(fmt.Sprintf("nats://localhost:%d", defaultOptions.Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	health := NewFHealthServer()
+	processor := NewFBaseProcessor()
+	processor.AddToProcessorMap("ping", &pingProcessor{t: t})
+	processor.AddToServiceMap("ping", "Base")
+	protoFactory := NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault())
+	server := NewFNatsServerBuilder(conn, processor, protoFactory, []string{"foo"}).WithHealth(health).Build()
+	go func() {
+		assert.Nil(t, server.Serve())
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	tr := NewFNatsTransport(conn, "foo", "")
+	assert.Nil(t, tr.Open())
+	defer tr.Close()
+	status, err := NewFHealthChecker(NewFServiceProvider(tr, protoFactory), "Base").Check(NewFContext(""))
+	assert.Nil(t, err)
+	assert.Equal(t, FServingStatusServing, status)
+
+	assert.Nil(t, server.Stop())
+	assert.Equal(t, FServingStatusNotServing, health.ServingStatus("Base"))
+}
+
+// Ensures Build panics if the health service can't be registered with the
+// processor.
+func TestFNatsServerHealthRegisterError(t *testing.T) {
+	protoFactory := NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault())
+	builder := NewFNatsServerBuilder(nil, &processor{t}, protoFactory, []string{"foo"}).WithHealth(NewFHealthServer())
+	assert.PanicsWithValue(t,
+		"frugal: can't serve the health service: frugal: can't register the health service with processor *frugal.processor",
+		func() { builder.Build() })
+}
@@ -16,6 +16,7 @@ package frugal
 import (
 	"bytes"
 	"fmt"
+	"sync"
 	"time"
 
 	"git.apache.org/thrift.git/lib/go/thrift"
@@ -171,6 +172,90 @@ func (f *fNatsTransport) Request(ctx FContext, data []byte) (thrift.TTransport,
 	}
 }
 
+// RequestStream transmits the given data and returns an FResponseStream
+// which receives each response published for it. The data is expected to
+// already be framed.
+func (f *fNatsTransport) RequestStream(ctx FContext, data []byte) (FResponseStream, error) {
+	return f.OpenStream(ctx, data)
+}
+
+// OpenStream transmits the given data and returns an FStream which receives
+// each response published for it. Each stream subscribes to its own inbox.
+// Further request frames are published to the reply subject of the responses,
+// which is the inbox of the server handling the stream. The data is expected
+// to already be framed.
+func (f *fNatsTransport) OpenStream(ctx FContext, data []byte) (FStream, error) {
+	if !f.IsOpen() {
+		return nil, f.getClosedConditionError("request:")
+	}
+
+	if err := f.checkMessageSize(data); err != nil {
+		return nil, err
+	}
+
+	var (
+		mu     sync.Mutex
+		server string
+		sub    *nats.Subscription
+		inbox  = nats.NewInbox()
+	)
+	stream := newFStream(ctx, make(chan []byte, streamBufferSize), func(frame []byte) error {
+		mu.Lock()
+		subject := server
+		mu.Unlock()
+		if subject == "" {
+			// Nothing has been received from the server, so it can't be
+			// told about the stream yet.
+			logger().Debug("frugal: discarding stream frame sent before the server responded")
+			return nil
+		}
+		if err := f.checkMessageSize(frame); err != nil {
+			return err
+		}
+		// The server identifies the stream by the reply subject as well as
+		// the frame's headers, so it's the same as the request's.
+		return f.conn.PublishRequest(subject, inbox, frame)
+	}, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if sub != nil {
+			sub.Unsubscribe()
+		}
+	})
+
+	s, err := f.conn.Subscribe(inbox, func(msg *nats.Msg) {
+		if len(msg.Data) < 4 {
+			logger().Warn("frugal: discarding invalid stream frame")
+			return
+		}
+		mu.Lock()
+		if server == "" {
+			server = msg.Reply
+		}
+		mu.Unlock()
+		select {
+		case stream.frames <- msg.Data[4:]:
+		case <-stream.done:
+		default:
+			logger().Warn("frugal: stream exceeded its buffer, closing it")
+			go stream.overflow()
+		}
+	})
+	if err != nil {
+		stream.Close()
+		return nil, thrift.NewTTransportExceptionFromError(err)
+	}
+	mu.Lock()
+	sub = s
+	mu.Unlock()
+
+	if err := f.conn.PublishRequest(f.subject, inbox, data); err != nil {
+		stream.Close()
+		return nil, thrift.NewTTransportExceptionFromError(err)
+	}
+	return stream, nil
+}
+
 // GetRequestSizeLimit returns the maximum number of bytes that can be
 // transmitted. Returns a non-positive number to indicate an unbounded
 // allowable size.
@@ -37,6 +37,10 @@ func (m *mockRegistry) Register(ctx FContext, resultC chan []byte) error {
 	return nil
 }
 
+func (m *mockRegistry) RegisterStream(ctx FContext, frames chan []byte, overflow func()) error {
+	return nil
+}
+
 func (m *mockRegistry) Unregister(ctx FContext) {
 }
 
@@ -250,7 +254,7 @@ func newClientAndServer(t *testing.T, isTTransport bool) (*fNatsTransport, *fNat
 	mockTransport := new(mockFTransport)
 	proto := thrift.NewTJSONProtocol(mockTransport)
 	mockTProtocolFactory.On("GetProtocol", mock.AnythingOfType("*thrift.TMemoryBuffer")).Return(proto).Once()
-	mockTProtocolFactory.On("GetProtocol", mock.AnythingOfType("*frugal.TMemoryOutputBuffer")).Return(proto).Once()
+	mockTProtocolFactory.On("GetProtocol", mock.AnythingOfType("*frugal.tFramedOutputSender")).Return(proto).Once()
 	fproto := &FProtocol{proto}
 	mockProcessor.On("Process", fproto, fproto).Return(nil)
 
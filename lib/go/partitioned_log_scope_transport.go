@@ -0,0 +1,330 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// topicVariableHeaderPrefix is prepended to the name of a scope prefix
+// variable to form the request header generated publishers store the
+// variable's value in.
+const topicVariableHeaderPrefix = "_topic_"
+
+// FLogRecord is a single message stored in a partition of an FPartitionedLog.
+type FLogRecord struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+}
+
+// FLogRecordHandler is invoked by an FPartitionedLog for each record consumed
+// from a topic. Records within a partition are passed to the handler in
+// offset order and the next record is not delivered until the handler
+// returns.
+type FLogRecordHandler func(*FLogRecord) error
+
+// FPartitionedLog is a client for a partitioned commit log, such as Kafka.
+// Messages appended to the same partition of a topic are delivered to
+// consumers in the order they were appended. Implementations of
+// FPartitionedLog should be threadsafe.
+type FPartitionedLog interface {
+	// Partitions returns the number of partitions for the given topic.
+	Partitions(topic string) (int32, error)
+
+	// Append writes the key and value to the end of the given partition of
+	// the topic.
+	Append(topic string, partition int32, key, value []byte) error
+
+	// Consume delivers records appended to the topic to the given handler
+	// until the returned io.Closer is closed. Consumers which share a
+	// non-empty group split the topic's partitions between them such that
+	// each partition is consumed by exactly one member of the group. A
+	// consumer with an empty group receives records from every partition.
+	Consume(topic, group string, handler FLogRecordHandler) (io.Closer, error)
+}
+
+// FPartitionKeyFunc returns the partition key for a message published to the
+// given topic with the given request headers. Messages with the same key are
+// written to the same partition and are therefore delivered in order. A nil
+// key spreads messages across partitions in a round-robin fashion.
+type FPartitionKeyFunc func(topic string, headers map[string]string) []byte
+
+// PartitionKeyFromHeader returns an FPartitionKeyFunc which uses the value of
+// the named FContext request header as the partition key.
+func PartitionKeyFromHeader(name string) FPartitionKeyFunc {
+	return func(topic string, headers map[string]string) []byte {
+		value, ok := headers[name]
+		if !ok {
+			return nil
+		}
+		return []byte(value)
+	}
+}
+
+// PartitionKeyFromPrefixVariable returns an FPartitionKeyFunc which uses the
+// value of the named scope prefix variable as the partition key. For example,
+// a scope with the prefix "foo.{user}" can be partitioned by user with
+// PartitionKeyFromPrefixVariable("user").
+func PartitionKeyFromPrefixVariable(variable string) FPartitionKeyFunc {
+	return PartitionKeyFromHeader(topicVariableHeaderPrefix + variable)
+}
+
+// FPartitionedLogPublisherTransportFactory creates partitioned log
+// FPublisherTransports.
+type FPartitionedLogPublisherTransportFactory struct {
+	log     FPartitionedLog
+	keyFunc FPartitionKeyFunc
+}
+
+// NewFPartitionedLogPublisherTransportFactory creates an
+// FPartitionedLogPublisherTransportFactory using the provided FPartitionedLog.
+// The FPartitionKeyFunc determines the partition each message is written to
+// and may be nil, in which case messages are spread across partitions.
+func NewFPartitionedLogPublisherTransportFactory(log FPartitionedLog, keyFunc FPartitionKeyFunc) *FPartitionedLogPublisherTransportFactory {
+	return &FPartitionedLogPublisherTransportFactory{log: log, keyFunc: keyFunc}
+}
+
+// GetTransport creates a new partitioned log FPublisherTransport.
+func (f *FPartitionedLogPublisherTransportFactory) GetTransport() FPublisherTransport {
+	return NewPartitionedLogFPublisherTransport(f.log, f.keyFunc)
+}
+
+// fPartitionedLogPublisherTransport implements FPublisherTransport.
+type fPartitionedLogPublisherTransport struct {
+	log     FPartitionedLog
+	keyFunc FPartitionKeyFunc
+	next    uint32
+	openMu  sync.RWMutex
+	isOpen  bool
+}
+
+// NewPartitionedLogFPublisherTransport creates a new FPublisherTransport which
+// is used for publishing with scopes to a partitioned commit log. The
+// FPartitionKeyFunc may be nil, in which case messages are spread across
+// partitions.
+func NewPartitionedLogFPublisherTransport(log FPartitionedLog, keyFunc FPartitionKeyFunc) FPublisherTransport {
+	return &fPartitionedLogPublisherTransport{log: log, keyFunc: keyFunc}
+}
+
+// Open initializes the transport.
+func (p *fPartitionedLogPublisherTransport) Open() error {
+	p.openMu.Lock()
+	defer p.openMu.Unlock()
+	p.isOpen = true
+	return nil
+}
+
+// IsOpen returns true if the transport is open, false otherwise.
+func (p *fPartitionedLogPublisherTransport) IsOpen() bool {
+	p.openMu.RLock()
+	defer p.openMu.RUnlock()
+	return p.isOpen
+}
+
+// Close closes the transport.
+func (p *fPartitionedLogPublisherTransport) Close() error {
+	p.openMu.Lock()
+	defer p.openMu.Unlock()
+	p.isOpen = false
+	return nil
+}
+
+// GetPublishSizeLimit returns the maximum allowable size of a payload
+// to be published. A non-positive number is returned to indicate an
+// unbounded allowable size.
+func (p *fPartitionedLogPublisherTransport) GetPublishSizeLimit() uint {
+	return 0
+}
+
+// Publish sends the given payload with the transport. The payload is
+// appended to the partition selected by the transport's FPartitionKeyFunc.
+func (p *fPartitionedLogPublisherTransport) Publish(topic string, data []byte) error {
+	if !p.IsOpen() {
+		return thrift.NewTTransportException(TRANSPORT_EXCEPTION_NOT_OPEN,
+			"frugal: partitioned log FPublisherTransport not open")
+	}
+
+	if len(data) < 4 {
+		return thrift.NewTTransportException(TRANSPORT_EXCEPTION_UNKNOWN,
+			"frugal: invalid scope message frame")
+	}
+
+	subject := fmt.Sprintf("%s%s", frugalPrefix, topic)
+	partitions, err := p.log.Partitions(subject)
+	if err != nil {
+		return thrift.NewTTransportExceptionFromError(err)
+	}
+	if partitions <= 0 {
+		return thrift.NewTTransportException(TRANSPORT_EXCEPTION_UNKNOWN,
+			fmt.Sprintf("frugal: topic %s has no partitions", subject))
+	}
+
+	key, err := p.partitionKey(topic, data)
+	if err != nil {
+		return err
+	}
+
+	var partition int32
+	if key == nil {
+		partition = int32((atomic.AddUint32(&p.next, 1) - 1) % uint32(partitions))
+	} else {
+		hash := fnv.New32a()
+		hash.Write(key)
+		partition = int32(hash.Sum32() % uint32(partitions))
+	}
+
+	if err := p.log.Append(subject, partition, key, data); err != nil {
+		return thrift.NewTTransportExceptionFromError(err)
+	}
+	return nil
+}
+
+// partitionKey reads the request headers from the frame and passes them to
+// the FPartitionKeyFunc, if any.
+func (p *fPartitionedLogPublisherTransport) partitionKey(topic string, data []byte) ([]byte, error) {
+	if p.keyFunc == nil {
+		return nil, nil
+	}
+	headers, err := getHeadersFromFrame(data[4:]) // Discard frame size
+	if err != nil {
+		return nil, err
+	}
+	return p.keyFunc(topic, headers), nil
+}
+
+// FPartitionedLogSubscriberTransportFactory creates partitioned log
+// FSubscriberTransports.
+type FPartitionedLogSubscriberTransportFactory struct {
+	log   FPartitionedLog
+	group string
+}
+
+// NewFPartitionedLogSubscriberTransportFactory creates an
+// FPartitionedLogSubscriberTransportFactory using the provided FPartitionedLog.
+// Subscribers using this transport will not join a consumer group and will
+// receive messages from every partition.
+func NewFPartitionedLogSubscriberTransportFactory(log FPartitionedLog) *FPartitionedLogSubscriberTransportFactory {
+	return &FPartitionedLogSubscriberTransportFactory{log: log}
+}
+
+// NewFPartitionedLogSubscriberTransportFactoryWithGroup creates an
+// FPartitionedLogSubscriberTransportFactory using the provided FPartitionedLog.
+// Subscribers using this transport will join the provided consumer group.
+// Like a NATS queue group, only one member of a consumer group receives each
+// message. Unlike a queue group, each partition is consumed by a single
+// member, so messages with the same partition key are received in order.
+func NewFPartitionedLogSubscriberTransportFactoryWithGroup(log FPartitionedLog, group string) *FPartitionedLogSubscriberTransportFactory {
+	return &FPartitionedLogSubscriberTransportFactory{log: log, group: group}
+}
+
+// GetTransport creates a new partitioned log FSubscriberTransport.
+func (f *FPartitionedLogSubscriberTransportFactory) GetTransport() FSubscriberTransport {
+	return NewPartitionedLogFSubscriberTransportWithGroup(f.log, f.group)
+}
+
+// fPartitionedLogSubscriberTransport implements FSubscriberTransport.
+type fPartitionedLogSubscriberTransport struct {
+	log          FPartitionedLog
+	group        string
+	consumer     io.Closer
+	openMu       sync.RWMutex
+	isSubscribed bool
+}
+
+// NewPartitionedLogFSubscriberTransport creates a new FSubscriberTransport
+// which is used for pub/sub over a partitioned commit log. Subscribers using
+// this transport will not join a consumer group.
+func NewPartitionedLogFSubscriberTransport(log FPartitionedLog) FSubscriberTransport {
+	return &fPartitionedLogSubscriberTransport{log: log}
+}
+
+// NewPartitionedLogFSubscriberTransportWithGroup creates a new
+// FSubscriberTransport which is used for pub/sub over a partitioned commit
+// log. Subscribers using this transport will join the provided consumer group.
+func NewPartitionedLogFSubscriberTransportWithGroup(log FPartitionedLog, group string) FSubscriberTransport {
+	return &fPartitionedLogSubscriberTransport{log: log, group: group}
+}
+
+// Subscribe sets the subscribe topic and opens the transport.
+func (s *fPartitionedLogSubscriberTransport) Subscribe(topic string, callback FAsyncCallback) error {
+	s.openMu.Lock()
+	defer s.openMu.Unlock()
+
+	if s.isSubscribed {
+		return thrift.NewTTransportException(TRANSPORT_EXCEPTION_ALREADY_OPEN,
+			"frugal: partitioned log transport already open")
+	}
+
+	if topic == "" {
+		return thrift.NewTTransportException(TRANSPORT_EXCEPTION_UNKNOWN,
+			"cannot subscribe to empty topic")
+	}
+
+	consumer, err := s.log.Consume(fmt.Sprintf("%s%s", frugalPrefix, topic), s.group, handleRecord(callback))
+	if err != nil {
+		return thrift.NewTTransportExceptionFromError(err)
+	}
+	s.consumer = consumer
+	s.isSubscribed = true
+	return nil
+}
+
+func handleRecord(callback FAsyncCallback) FLogRecordHandler {
+	return func(record *FLogRecord) error {
+		if len(record.Value) < 4 {
+			logger().Warn("frugal: Discarding invalid scope message frame")
+			return nil
+		}
+		transport := &thrift.TMemoryBuffer{Buffer: bytes.NewBuffer(record.Value[4:])}
+		if err := callback(transport); err != nil {
+			logger().Warn("frugal: error executing callback: ", err)
+		}
+		return nil
+	}
+}
+
+// IsSubscribed returns true if the transport is subscribed to a topic, false
+// otherwise.
+func (s *fPartitionedLogSubscriberTransport) IsSubscribed() bool {
+	s.openMu.RLock()
+	defer s.openMu.RUnlock()
+	return s.isSubscribed
+}
+
+// Unsubscribe stops consuming from the topic and leaves the consumer group, if
+// any.
+func (s *fPartitionedLogSubscriberTransport) Unsubscribe() error {
+	s.openMu.Lock()
+	defer s.openMu.Unlock()
+	if !s.isSubscribed {
+		return nil
+	}
+
+	if err := s.consumer.Close(); err != nil {
+		return thrift.NewTTransportExceptionFromError(err)
+	}
+	s.consumer = nil
+	s.isSubscribed = false
+	return nil
+}
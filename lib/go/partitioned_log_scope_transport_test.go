@@ -0,0 +1,192 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockPartitionedLog struct {
+	mock.Mock
+}
+
+func (m *mockPartitionedLog) Partitions(topic string) (int32, error) {
+	args := m.Called(topic)
+	return args.Get(0).(int32), args.Error(1)
+}
+
+func (m *mockPartitionedLog) Append(topic string, partition int32, key, value []byte) error {
+	return m.Called(topic, partition, key, value).Error(0)
+}
+
+func (m *mockPartitionedLog) Consume(topic, group string, handler FLogRecordHandler) (io.Closer, error) {
+	args := m.Called(topic, group, handler)
+	if closer := args.Get(0); closer != nil {
+		return closer.(io.Closer), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+// scopeFrame returns a framed scope message containing the request headers of
+// the given FContext followed by the given payload.
+func scopeFrame(t *testing.T, ctx FContext, payload []byte) []byte {
+	buffer := NewTMemoryOutputBuffer(0)
+	proto := NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault()).GetProtocol(buffer)
+	assert.Nil(t, proto.WriteRequestHeader(ctx))
+	_, err := buffer.Write(payload)
+	assert.Nil(t, err)
+	return buffer.Bytes()
+}
+
+// Ensures Publish returns an error if the transport is not open.
+func TestPartitionedLogPublisherPublishNotOpen(t *testing.T) {
+	log := new(mockPartitionedLog)
+	tr := NewPartitionedLogFPublisherTransport(log, nil)
+
+	err := tr.Publish("foo", make([]byte, 10))
+	assert.Equal(t, TRANSPORT_EXCEPTION_NOT_OPEN, err.(thrift.TTransportException).TypeId())
+	log.AssertExpectations(t)
+}
+
+// Ensures Publish appends to the partition selected by hashing the configured
+// FContext header so messages with the same key land on the same partition.
+func TestPartitionedLogPublisherPublishHeaderKey(t *testing.T) {
+	log := new(mockPartitionedLog)
+	tr := NewFPartitionedLogPublisherTransportFactory(log, PartitionKeyFromHeader("key")).GetTransport()
+	assert.Nil(t, tr.Open())
+	assert.True(t, tr.IsOpen())
+	assert.Equal(t, uint(0), tr.GetPublishSizeLimit())
+
+	frame := scopeFrame(t, NewFContext("cid").AddRequestHeader("key", "abc"), []byte("hello"))
+	// fnv32a("abc") % 8 == 3
+	log.On("Partitions", "frugal.foo").Return(int32(8), nil)
+	log.On("Append", "frugal.foo", int32(3), []byte("abc"), frame).Return(nil).Twice()
+
+	assert.Nil(t, tr.Publish("foo", frame))
+	assert.Nil(t, tr.Publish("foo", frame))
+	log.AssertExpectations(t)
+	assert.Nil(t, tr.Close())
+	assert.False(t, tr.IsOpen())
+}
+
+// Ensures Publish uses the scope prefix variable header written by generated
+// publishers as the partition key.
+func TestPartitionedLogPublisherPublishPrefixVariableKey(t *testing.T) {
+	log := new(mockPartitionedLog)
+	tr := NewPartitionedLogFPublisherTransport(log, PartitionKeyFromPrefixVariable("user"))
+	assert.Nil(t, tr.Open())
+
+	frame := scopeFrame(t, NewFContext("cid").AddRequestHeader("_topic_user", "abc"), []byte("hello"))
+	log.On("Partitions", "frugal.foo.abc.Events.Created").Return(int32(8), nil)
+	log.On("Append", "frugal.foo.abc.Events.Created", int32(3), []byte("abc"), frame).Return(nil)
+
+	assert.Nil(t, tr.Publish("foo.abc.Events.Created", frame))
+	log.AssertExpectations(t)
+}
+
+// Ensures Publish spreads messages without a partition key across partitions.
+func TestPartitionedLogPublisherPublishNoKey(t *testing.T) {
+	log := new(mockPartitionedLog)
+	tr := NewPartitionedLogFPublisherTransport(log, PartitionKeyFromHeader("missing"))
+	assert.Nil(t, tr.Open())
+
+	frame := scopeFrame(t, NewFContext("cid"), []byte("hello"))
+	log.On("Partitions", "frugal.foo").Return(int32(2), nil)
+	log.On("Append", "frugal.foo", int32(0), []byte(nil), frame).Return(nil).Once()
+	log.On("Append", "frugal.foo", int32(1), []byte(nil), frame).Return(nil).Once()
+
+	assert.Nil(t, tr.Publish("foo", frame))
+	assert.Nil(t, tr.Publish("foo", frame))
+	log.AssertExpectations(t)
+}
+
+// Ensures Publish returns a TTransportException if the log fails.
+func TestPartitionedLogPublisherPublishError(t *testing.T) {
+	log := new(mockPartitionedLog)
+	tr := NewPartitionedLogFPublisherTransport(log, nil)
+	assert.Nil(t, tr.Open())
+
+	log.On("Partitions", "frugal.foo").Return(int32(0), errors.New("no leader"))
+	err := tr.Publish("foo", make([]byte, 10))
+	assert.Equal(t, "no leader", err.Error())
+	_, ok := err.(thrift.TTransportException)
+	assert.True(t, ok)
+	log.AssertExpectations(t)
+}
+
+// Ensures Subscribe consumes the topic with the configured group and invokes
+// the callback with the frame size removed.
+func TestPartitionedLogSubscriberSubscribe(t *testing.T) {
+	log := NewFMemoryPartitionedLog(4)
+	tr := NewFPartitionedLogSubscriberTransportFactoryWithGroup(log, "group").GetTransport()
+
+	received := make(chan []byte, 1)
+	assert.Nil(t, tr.Subscribe("foo", func(transport thrift.TTransport) error {
+		data, err := ioutil.ReadAll(transport)
+		assert.Nil(t, err)
+		received <- data
+		return nil
+	}))
+	assert.True(t, tr.IsSubscribed())
+
+	pub := NewPartitionedLogFPublisherTransport(log, nil)
+	assert.Nil(t, pub.Open())
+	assert.Nil(t, pub.Publish("foo", []byte{0, 0, 0, 3, 1, 2, 3}))
+
+	select {
+	case data := <-received:
+		assert.Equal(t, []byte{1, 2, 3}, data)
+	case <-time.After(time.Second):
+		t.Fatal("Callback was not called")
+	}
+
+	assert.Nil(t, tr.Unsubscribe())
+	assert.False(t, tr.IsSubscribed())
+	assert.Nil(t, tr.Unsubscribe())
+}
+
+// Ensures Subscribe returns an error when already subscribed or given an empty
+// topic.
+func TestPartitionedLogSubscriberSubscribeErrors(t *testing.T) {
+	tr := NewPartitionedLogFSubscriberTransport(NewFMemoryPartitionedLog(1))
+	cb := func(thrift.TTransport) error { return nil }
+
+	err := tr.Subscribe("", cb)
+	assert.Equal(t, TRANSPORT_EXCEPTION_UNKNOWN, err.(thrift.TTransportException).TypeId())
+
+	assert.Nil(t, tr.Subscribe("foo", cb))
+	err = tr.Subscribe("foo", cb)
+	assert.Equal(t, TRANSPORT_EXCEPTION_ALREADY_OPEN, err.(thrift.TTransportException).TypeId())
+	assert.Nil(t, tr.Unsubscribe())
+}
+
+// Ensures Subscribe returns a TTransportException if the log fails.
+func TestPartitionedLogSubscriberSubscribeConsumeError(t *testing.T) {
+	log := new(mockPartitionedLog)
+	tr := NewFPartitionedLogSubscriberTransportFactory(log).GetTransport()
+	log.On("Consume", "frugal.foo", "", mock.Anything).Return(nil, errors.New("error"))
+
+	err := tr.Subscribe("foo", func(thrift.TTransport) error { return nil })
+	assert.Equal(t, "error", err.Error())
+	assert.False(t, tr.IsSubscribed())
+	log.AssertExpectations(t)
+}
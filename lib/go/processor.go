@@ -14,6 +14,7 @@
 package frugal
 
 import (
+	"sort"
 	"sync"
 
 	"git.apache.org/thrift.git/lib/go/thrift"
@@ -44,6 +45,8 @@ type FBaseProcessor struct {
 	writeMu        sync.Mutex
 	processMap     map[string]FProcessorFunction
 	annotationsMap map[string]map[string]string
+	serviceMap     map[string]string
+	descriptors    []*FServiceDescriptor
 }
 
 // NewFBaseProcessor returns a new FBaseProcessor which FProcessors can extend.
@@ -51,6 +54,7 @@ func NewFBaseProcessor() *FBaseProcessor {
 	return &FBaseProcessor{
 		processMap:     make(map[string]FProcessorFunction),
 		annotationsMap: make(map[string]map[string]string),
+		serviceMap:     make(map[string]string),
 	}
 }
 
@@ -61,6 +65,19 @@ func (f *FBaseProcessor) Process(iprot, oprot *FProtocol) error {
 	if err != nil {
 		return err
 	}
+	if kind, ok := ctx.RequestHeader(streamHeader); ok {
+		// Frames sent on a stream after the request which opened it are
+		// handled by the stream rather than a processor function.
+		if err := serverStreams.execute(ctx, kind, iprot, oprot); err != nil {
+			if _, ok := err.(thrift.TProtocolException); ok {
+				return err
+			}
+			logger().Errorf(
+				"frugal: error occurred while processing stream frame with correlation id %s: %s",
+				ctx.CorrelationID(), err.Error())
+		}
+		return nil
+	}
 	name, _, _, err := iprot.ReadMessageBegin()
 	if err != nil {
 		return err
@@ -118,6 +135,19 @@ func (f *FBaseProcessor) AddMiddleware(middleware ServiceMiddleware) {
 	}
 }
 
+// SetValidation sets whether the FProcessor validates the arguments of
+// requests before invoking the handler. Arguments which fail validation are
+// answered with an APPLICATION_EXCEPTION_VALIDATION_ERROR
+// TApplicationException. This only applies to processors generated with the
+// Go validate option and should only be called before the server is started.
+func (f *FBaseProcessor) SetValidation(enabled bool) {
+	for _, p := range f.processMap {
+		if v, ok := p.(validatingProcessorFunction); ok {
+			v.setValidation(enabled)
+		}
+	}
+}
+
 // AddToProcessorMap registers the given FProcessorFunction.
 func (f *FBaseProcessor) AddToProcessorMap(key string, proc FProcessorFunction) {
 	f.processMap[key] = proc
@@ -128,6 +158,45 @@ func (f *FBaseProcessor) AddToAnnotationsMap(method string, annotations map[stri
 	f.annotationsMap[method] = annotations
 }
 
+// AddToServiceMap registers the name of the service which declares the given
+// method.
+func (f *FBaseProcessor) AddToServiceMap(method, service string) {
+	f.serviceMap[method] = service
+}
+
+// GetProcessorFunction returns the FProcessorFunction registered for the given
+// method, if any.
+func (f *FBaseProcessor) GetProcessorFunction(method string) (FProcessorFunction, bool) {
+	proc, ok := f.processMap[method]
+	return proc, ok
+}
+
+// Methods returns the sorted names of the methods registered with this
+// processor.
+func (f *FBaseProcessor) Methods() []string {
+	methods := make([]string, 0, len(f.processMap))
+	for method := range f.processMap {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// Services returns a map of service name to the sorted names of the methods
+// the service declares which are registered with this processor. Processors
+// generated with the Go multiplex option register the service of each
+// method, including those of extended services.
+func (f *FBaseProcessor) Services() map[string][]string {
+	services := make(map[string][]string)
+	for method, service := range f.serviceMap {
+		services[service] = append(services[service], method)
+	}
+	for _, methods := range services {
+		sort.Strings(methods)
+	}
+	return services
+}
+
 // Annotations returns a map of method name to annotations as defined in
 // the service IDL that is serviced by this processor.
 func (f *FBaseProcessor) Annotations() map[string]map[string]string {
@@ -167,14 +236,21 @@ type FProcessorFunction interface {
 // FProcessorFunctions should embed this. This should only be used by generated
 // code.
 type FBaseProcessorFunction struct {
-	handler *Method
-	writeMu *sync.Mutex
+	handler  *Method
+	writeMu  *sync.Mutex
+	validate bool
+}
+
+// validatingProcessorFunction is implemented by FProcessorFunctions embedding
+// FBaseProcessorFunction.
+type validatingProcessorFunction interface {
+	setValidation(enabled bool)
 }
 
 // NewFBaseProcessorFunction returns a new FBaseProcessorFunction which
 // FProcessorFunctions can extend.
 func NewFBaseProcessorFunction(writeMu *sync.Mutex, handler *Method) *FBaseProcessorFunction {
-	return &FBaseProcessorFunction{handler, writeMu}
+	return &FBaseProcessorFunction{handler: handler, writeMu: writeMu}
 }
 
 // GetWriteMutex returns the Mutex which should be used to synchronize access
@@ -193,3 +269,17 @@ func (f *FBaseProcessorFunction) AddMiddleware(middleware ServiceMiddleware) {
 func (f *FBaseProcessorFunction) InvokeMethod(args []interface{}) Results {
 	return f.handler.Invoke(args)
 }
+
+func (f *FBaseProcessorFunction) setValidation(enabled bool) {
+	f.validate = enabled
+}
+
+// ValidateArgs returns the error from validating the given request arguments
+// if validation is enabled on the FProcessor, otherwise nil. This should only
+// be used by generated code.
+func (f *FBaseProcessorFunction) ValidateArgs(args FValidator) error {
+	if !f.validate {
+		return nil
+	}
+	return args.Validate()
+}
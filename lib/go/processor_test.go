@@ -262,3 +262,22 @@ func TestFBaseProcessorAnnotations(t *testing.T) {
 	assert.Equal("baz", annoMap["foo"]["bar"])
 	assert.Equal("boom", annoMap["foo"]["boosh"])
 }
+
+// Ensures FBaseProcessor lists the registered methods and the services which
+// declare them.
+func TestFBaseProcessorRegistry(t *testing.T) {
+	processor := NewFBaseProcessor()
+	ping := &pingProcessor{t: t}
+	processor.AddToProcessorMap("ping", ping)
+	processor.AddToProcessorMap("getAlbum", &pingProcessor{t: t})
+	processor.AddToServiceMap("ping", "Base")
+	processor.AddToServiceMap("getAlbum", "Store")
+
+	assert.Equal(t, []string{"getAlbum", "ping"}, processor.Methods())
+	assert.Equal(t, map[string][]string{"Base": {"ping"}, "Store": {"getAlbum"}}, processor.Services())
+	proc, ok := processor.GetProcessorFunction("ping")
+	assert.True(t, ok)
+	assert.Equal(t, ping, proc)
+	_, ok = processor.GetProcessorFunction("foo")
+	assert.False(t, ok)
+}
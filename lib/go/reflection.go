@@ -0,0 +1,243 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+// ReflectionMethod is the name of the method which processors generated with
+// the Go reflection option serve to return the descriptors of their services.
+// It takes no arguments and returns the JSON encoding of a list of
+// FServiceDescriptors, so it can be called like a method declared as
+// "string reflection()" by clients in any language.
+const ReflectionMethod = "reflection"
+
+// Kinds of types in service descriptors.
+const (
+	DescriptorKindBase      = "base"
+	DescriptorKindList      = "list"
+	DescriptorKindSet       = "set"
+	DescriptorKindMap       = "map"
+	DescriptorKindEnum      = "enum"
+	DescriptorKindStruct    = "struct"
+	DescriptorKindUnion     = "union"
+	DescriptorKindException = "exception"
+)
+
+// FServiceDescriptor describes a service, giving what's needed to call its
+// methods without generated code. Types holds the definitions of the structs,
+// unions, exceptions, and enums the methods use, directly or through other
+// types, keyed by the name of the file declaring them and their name, e.g.
+// "music.Album". Typedefs are resolved.
+type FServiceDescriptor struct {
+	Name        string                      `json:"name"`
+	Doc         string                      `json:"doc,omitempty"`
+	Extends     string                      `json:"extends,omitempty"`
+	Methods     []*FMethodDescriptor        `json:"methods"`
+	Types       map[string]*FTypeDefinition `json:"types,omitempty"`
+	Annotations map[string]string           `json:"annotations,omitempty"`
+}
+
+// Method returns the descriptor of the named method, if the service declares
+// it.
+func (s *FServiceDescriptor) Method(name string) (*FMethodDescriptor, bool) {
+	for _, method := range s.Methods {
+		if method.Name == name {
+			return method, true
+		}
+	}
+	return nil, false
+}
+
+// FMethodDescriptor describes a method of a service. Name is the name clients
+// send in requests. ReturnType is nil for void methods.
+type FMethodDescriptor struct {
+	Name              string              `json:"name"`
+	Doc               string              `json:"doc,omitempty"`
+	Oneway            bool                `json:"oneway,omitempty"`
+	StreamingResponse bool                `json:"streamingResponse,omitempty"`
+	ReturnType        *FTypeDescriptor    `json:"returnType,omitempty"`
+	Arguments         []*FFieldDescriptor `json:"arguments"`
+	RequestStream     *FFieldDescriptor   `json:"requestStream,omitempty"`
+	Exceptions        []*FFieldDescriptor `json:"exceptions,omitempty"`
+	Annotations       map[string]string   `json:"annotations,omitempty"`
+}
+
+// IsStreaming returns true if the method streams its requests or responses.
+func (m *FMethodDescriptor) IsStreaming() bool {
+	return m.StreamingResponse || m.RequestStream != nil
+}
+
+// FFieldDescriptor describes a field of a struct, or an argument or exception
+// of a method.
+type FFieldDescriptor struct {
+	ID          int16             `json:"id"`
+	Name        string            `json:"name"`
+	Doc         string            `json:"doc,omitempty"`
+	Required    bool              `json:"required,omitempty"`
+	Type        *FTypeDescriptor  `json:"type"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// FTypeDescriptor describes a type. Name is the IDL name of base types and
+// the key of the FTypeDefinition of other types which aren't containers.
+type FTypeDescriptor struct {
+	Kind      string           `json:"kind"`
+	Name      string           `json:"name,omitempty"`
+	KeyType   *FTypeDescriptor `json:"keyType,omitempty"`
+	ValueType *FTypeDescriptor `json:"valueType,omitempty"`
+}
+
+// FTypeDefinition describes a struct, union, exception, or enum.
+type FTypeDefinition struct {
+	Kind        string              `json:"kind"`
+	Doc         string              `json:"doc,omitempty"`
+	Fields      []*FFieldDescriptor `json:"fields,omitempty"`
+	Values      map[string]int32    `json:"values,omitempty"`
+	Annotations map[string]string   `json:"annotations,omitempty"`
+}
+
+// FScopeDescriptor describes a scope, giving what's needed to publish and
+// subscribe to its operations without generated code. Prefix is the topic
+// prefix template, whose Variables have the form {foo}. Types is keyed like it
+// is for FServiceDescriptor.
+type FScopeDescriptor struct {
+	Name        string                      `json:"name"`
+	Doc         string                      `json:"doc,omitempty"`
+	Prefix      string                      `json:"prefix,omitempty"`
+	Variables   []string                    `json:"variables,omitempty"`
+	Operations  []*FOperationDescriptor     `json:"operations"`
+	Types       map[string]*FTypeDefinition `json:"types,omitempty"`
+	Annotations map[string]string           `json:"annotations,omitempty"`
+}
+
+// Operation returns the descriptor of the named operation, if the scope
+// declares it.
+func (s *FScopeDescriptor) Operation(name string) (*FOperationDescriptor, bool) {
+	for _, op := range s.Operations {
+		if op.Name == name {
+			return op, true
+		}
+	}
+	return nil, false
+}
+
+// Topic returns the topic of the named operation given the values of the
+// prefix variables, which is the topic generated publishers and subscribers
+// use.
+func (s *FScopeDescriptor) Topic(op string, variables map[string]string) (string, error) {
+	prefix := s.Prefix
+	for _, variable := range s.Variables {
+		value, ok := variables[variable]
+		if !ok {
+			return "", fmt.Errorf("frugal: missing value of prefix variable %s of scope %s", variable, s.Name)
+		}
+		prefix = strings.Replace(prefix, "{"+variable+"}", value, -1)
+	}
+	if prefix != "" {
+		prefix += "."
+	}
+	return prefix + s.Name + "." + op, nil
+}
+
+// FOperationDescriptor describes an operation of a scope.
+type FOperationDescriptor struct {
+	Name        string            `json:"name"`
+	Doc         string            `json:"doc,omitempty"`
+	Type        *FTypeDescriptor  `json:"type"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// AddServiceDescriptor registers the JSON encoded FServiceDescriptor of a
+// service the processor serves, and registers the reflection method returning
+// the descriptors. This panics if the descriptor is invalid and should only be
+// used by generated code.
+func (f *FBaseProcessor) AddServiceDescriptor(descriptor string) {
+	service := &FServiceDescriptor{}
+	if err := json.Unmarshal([]byte(descriptor), service); err != nil {
+		panic(fmt.Sprintf("frugal: invalid service descriptor: %s", err))
+	}
+	f.descriptors = append(f.descriptors, service)
+	if _, ok := f.processMap[ReflectionMethod]; !ok {
+		f.AddToProcessorMap(ReflectionMethod, &reflectionProcessorFunction{processor: f})
+	}
+}
+
+// ServiceDescriptors returns the descriptors of the services the processor
+// serves, those of extended services first. Only processors generated with the
+// Go reflection option have descriptors.
+func (f *FBaseProcessor) ServiceDescriptors() []*FServiceDescriptor {
+	descriptors := make([]*FServiceDescriptor, len(f.descriptors))
+	copy(descriptors, f.descriptors)
+	return descriptors
+}
+
+// reflectionProcessorFunction is the FProcessorFunction of the reflection
+// method.
+type reflectionProcessorFunction struct {
+	processor *FBaseProcessor
+}
+
+func (r *reflectionProcessorFunction) Process(ctx FContext, iprot, oprot *FProtocol) error {
+	if err := iprot.Skip(thrift.STRUCT); err != nil {
+		return err
+	}
+	if err := iprot.ReadMessageEnd(); err != nil {
+		return err
+	}
+	descriptors, err := json.Marshal(r.processor.ServiceDescriptors())
+	if err != nil {
+		return err
+	}
+
+	r.processor.writeMu.Lock()
+	defer r.processor.writeMu.Unlock()
+	if err := oprot.WriteResponseHeader(ctx); err != nil {
+		return err
+	}
+	if err := oprot.WriteMessageBegin(ReflectionMethod, thrift.REPLY, 0); err != nil {
+		return err
+	}
+	if err := oprot.WriteStructBegin("reflection_result"); err != nil {
+		return err
+	}
+	if err := oprot.WriteFieldBegin("success", thrift.STRING, 0); err != nil {
+		return err
+	}
+	if err := oprot.WriteString(string(descriptors)); err != nil {
+		return err
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return err
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return err
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return err
+	}
+	if err := oprot.WriteMessageEnd(); err != nil {
+		return err
+	}
+	return oprot.Flush()
+}
+
+// AddMiddleware is a no-op since the reflection method doesn't invoke a
+// handler.
+func (r *reflectionProcessorFunction) AddMiddleware(ServiceMiddleware) {}
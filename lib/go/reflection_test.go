@@ -0,0 +1,110 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"encoding/json"
+	"testing"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/stretchr/testify/assert"
+)
+
+const baseDescriptor = `{"name":"Base","methods":[{"name":"ping","arguments":[]}]}`
+
+const storeDescriptor = `{
+	"name": "Store",
+	"doc": "Sells albums.",
+	"extends": "Base",
+	"methods": [{
+		"name": "getAlbum",
+		"returnType": {"kind": "struct", "name": "music.Album"},
+		"arguments": [{"id": 1, "name": "ASIN", "type": {"kind": "base", "name": "string"}}],
+		"exceptions": [{"id": 1, "name": "notFound", "type": {"kind": "exception", "name": "music.NotFound"}}],
+		"annotations": {"deprecated": ""}
+	}],
+	"types": {
+		"music.Album": {"kind": "struct", "fields": [
+			{"id": 1, "name": "ASIN", "type": {"kind": "base", "name": "string"}},
+			{"id": 2, "name": "genre", "type": {"kind": "enum", "name": "music.Genre"}}
+		]},
+		"music.Genre": {"kind": "enum", "values": {"ROCK": 0, "JAZZ": 1}},
+		"music.NotFound": {"kind": "exception", "fields": [
+			{"id": 1, "name": "message", "type": {"kind": "base", "name": "string"}}
+		]}
+	}
+}`
+
+// Ensures AddServiceDescriptor registers the descriptors and the reflection
+// method.
+func TestFBaseProcessorServiceDescriptors(t *testing.T) {
+	processor := NewFBaseProcessor()
+	processor.AddServiceDescriptor(baseDescriptor)
+	processor.AddServiceDescriptor(storeDescriptor)
+
+	descriptors := processor.ServiceDescriptors()
+	assert.Len(t, descriptors, 2)
+	assert.Equal(t, "Base", descriptors[0].Name)
+	assert.Equal(t, "Store", descriptors[1].Name)
+	assert.Equal(t, "Base", descriptors[1].Extends)
+	method, ok := descriptors[1].Method("getAlbum")
+	assert.True(t, ok)
+	assert.Equal(t, "music.Album", method.ReturnType.Name)
+	assert.Equal(t, map[string]string{"deprecated": ""}, method.Annotations)
+	assert.Equal(t, map[string]int32{"ROCK": 0, "JAZZ": 1}, descriptors[1].Types["music.Genre"].Values)
+	assert.Equal(t, []string{ReflectionMethod}, processor.Methods())
+
+	assert.Panics(t, func() { processor.AddServiceDescriptor("{") })
+}
+
+// Ensures the reflection method returns the JSON encoded descriptors.
+func TestReflectionProcessorFunction(t *testing.T) {
+	processor := NewFBaseProcessor()
+	processor.AddServiceDescriptor(baseDescriptor)
+	proto := writeMultiplexedRequest(t, ReflectionMethod)
+
+	assert.Nil(t, processor.Process(proto, proto))
+	assert.Nil(t, proto.ReadResponseHeader(NewFContext("")))
+	name, typeID, _, err := proto.ReadMessageBegin()
+	assert.Nil(t, err)
+	assert.Equal(t, ReflectionMethod, name)
+	assert.Equal(t, thrift.REPLY, typeID)
+	_, err = proto.ReadStructBegin()
+	assert.Nil(t, err)
+	_, fieldType, fieldID, err := proto.ReadFieldBegin()
+	assert.Nil(t, err)
+	assert.Equal(t, thrift.TType(thrift.STRING), fieldType)
+	assert.Equal(t, int16(0), fieldID)
+	descriptors, err := proto.ReadString()
+	assert.Nil(t, err)
+
+	expected, _ := json.Marshal(processor.ServiceDescriptors())
+	assert.Equal(t, string(expected), descriptors)
+}
+
+// Ensures Topic fills the prefix variables like generated scopes do.
+func TestFScopeDescriptorTopic(t *testing.T) {
+	scope := &FScopeDescriptor{Name: "AlbumWinners", Prefix: "v1.music.{region}", Variables: []string{"region"}}
+	topic, err := scope.Topic("Winner", map[string]string{"region": "us"})
+	assert.Nil(t, err)
+	assert.Equal(t, "v1.music.us.AlbumWinners.Winner", topic)
+
+	_, err = scope.Topic("Winner", nil)
+	assert.Equal(t, "frugal: missing value of prefix variable region of scope AlbumWinners", err.Error())
+
+	scope = &FScopeDescriptor{Name: "Events"}
+	topic, err = scope.Topic("Created", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "Events.Created", topic)
+}
@@ -46,6 +46,10 @@ type FAsyncCallback func(thrift.TTransport) error
 type fRegistry interface {
 	// Register a channel for the given Context.
 	Register(ctx FContext, resultC chan []byte) error
+	// RegisterStream registers a channel which receives the frames of a
+	// stream for the given Context. If the channel's buffer is full when a
+	// frame arrives, the Context is unregistered and overflow is called.
+	RegisterStream(ctx FContext, frames chan []byte, overflow func()) error
 	// Unregister a callback for the given Context.
 	Unregister(FContext)
 	// Execute dispatches a single Thrift message frame.
@@ -54,17 +58,35 @@ type fRegistry interface {
 
 type fRegistryImpl struct {
 	mu       sync.RWMutex
-	channels map[uint64]chan []byte
+	channels map[uint64]*registration
+}
+
+// registration is a channel registered for an operation id. Streams also
+// register a function which is called if they can't keep up with the frames
+// delivered to them.
+type registration struct {
+	resultC  chan []byte
+	overflow func()
 }
 
 // NewFRegistry creates a Registry intended for use by Frugal clients.
 // This is only to be called by generated code.
 func newFRegistry() fRegistry {
-	return &fRegistryImpl{channels: make(map[uint64]chan []byte)}
+	return &fRegistryImpl{channels: make(map[uint64]*registration)}
 }
 
 // Register a channel for the given Context.
 func (c *fRegistryImpl) Register(ctx FContext, resultC chan []byte) error {
+	return c.register(ctx, &registration{resultC: resultC})
+}
+
+// RegisterStream registers a channel which receives the frames of a stream
+// for the given Context.
+func (c *fRegistryImpl) RegisterStream(ctx FContext, frames chan []byte, overflow func()) error {
+	return c.register(ctx, &registration{resultC: frames, overflow: overflow})
+}
+
+func (c *fRegistryImpl) register(ctx FContext, reg *registration) error {
 	// An FContext can be reused for multiple requests. Because of this,
 	// FContext's have a monotonically increasing atomic uint64. We check
 	// the channels map to ensure that request is not still in-flight.
@@ -78,7 +100,7 @@ func (c *fRegistryImpl) Register(ctx FContext, resultC chan []byte) error {
 			return fmt.Errorf("frugal: context already registered, opid %d is in-flight for another request", opID)
 		}
 	}
-	c.channels[opID] = resultC
+	c.channels[opID] = reg
 	return nil
 }
 
@@ -109,7 +131,7 @@ func (c *fRegistryImpl) Execute(frame []byte) error {
 	}
 
 	c.mu.RLock()
-	resultC, ok := c.channels[opid]
+	reg, ok := c.channels[opid]
 	if !ok {
 		logger().Warn("frugal: unregistered context")
 		c.mu.RUnlock()
@@ -117,6 +139,27 @@ func (c *fRegistryImpl) Execute(frame []byte) error {
 	}
 	c.mu.RUnlock()
 
-	resultC <- frame
+	// Execute is called by the transport's read loop, so it never blocks on
+	// a slow consumer.
+	select {
+	case reg.resultC <- frame:
+		return nil
+	default:
+	}
+
+	if reg.overflow == nil {
+		logger().Warnf("frugal: discarding frame for opid %d, a response was already received", opid)
+		return nil
+	}
+
+	// The stream can't keep up with its frames, so it's failed rather than
+	// silently dropping frames.
+	c.mu.Lock()
+	if c.channels[opid] == reg {
+		delete(c.channels, opid)
+	}
+	c.mu.Unlock()
+	logger().Warnf("frugal: stream with opid %d exceeded its buffer, closing it", opid)
+	go reg.overflow()
 	return nil
 }
@@ -78,6 +78,41 @@ func TestClientRegistry(t *testing.T) {
 	assert.Nil(err)
 }
 
+// Ensures Execute doesn't block when a registered channel is full. Extra
+// responses are dropped and a stream which overflows is unregistered and
+// told so.
+func TestClientRegistryOverflow(t *testing.T) {
+	assert := assert.New(t)
+	registry := newFRegistry()
+	frame := func(ctx FContext) []byte {
+		transport := &thrift.TMemoryBuffer{Buffer: new(bytes.Buffer)}
+		proto := &FProtocol{tProtocolFactory.GetProtocol(transport)}
+		assert.Nil(proto.writeHeader(ctx.RequestHeaders()))
+		return transport.Bytes()
+	}
+
+	ctx := NewFContext("")
+	resultC := make(chan []byte, 1)
+	assert.Nil(registry.Register(ctx, resultC))
+	assert.Nil(registry.Execute(frame(ctx)))
+	assert.Nil(registry.Execute(frame(ctx)))
+	assert.Equal(1, len(resultC))
+
+	ctx = NewFContext("")
+	frames := make(chan []byte, 2)
+	overflowed := make(chan struct{})
+	assert.Nil(registry.RegisterStream(ctx, frames, func() { close(overflowed) }))
+	for i := 0; i < 3; i++ {
+		assert.Nil(registry.Execute(frame(ctx)))
+	}
+	<-overflowed
+	assert.Equal(2, len(frames))
+	opID, err := getOpID(ctx)
+	assert.Nil(err)
+	_, ok := registry.(*fRegistryImpl).channels[opID]
+	assert.False(ok)
+}
+
 type mockProcessor struct {
 	iprot *FProtocol
 	oprot *FProtocol
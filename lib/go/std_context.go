@@ -0,0 +1,205 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// fContextKey is the key of the FContext carried by a context.Context.
+type fContextKey struct{}
+
+// stdContext is a context.Context carrying an FContext. It implements
+// FContext too, so it can be passed as the FContext of middleware Arguments
+// while handlers generated with the std_context option receive it as a
+// context.Context.
+type stdContext struct {
+	context.Context
+	FContext
+}
+
+// Value returns the carried FContext for its key and otherwise defers to the
+// parent context.Context.
+func (c *stdContext) Value(key interface{}) interface{} {
+	if key == (fContextKey{}) {
+		return c.FContext
+	}
+	return c.Context.Value(key)
+}
+
+// WithFContext returns a copy of parent carrying the FContext, which holds the
+// headers, correlation id, and timeout of a request. The returned
+// context.Context also implements FContext.
+func WithFContext(parent context.Context, fctx FContext) context.Context {
+	if c, ok := fctx.(*stdContext); ok {
+		fctx = c.FContext
+	}
+	return &stdContext{Context: parent, FContext: fctx}
+}
+
+// FContextFromContext returns the FContext carried by the context.Context, if
+// any.
+func FContextFromContext(ctx context.Context) (FContext, bool) {
+	fctx, ok := ctx.Value(fContextKey{}).(FContext)
+	return fctx, ok
+}
+
+// deadlineFContext is an FContext with its own timeout, the time remaining
+// until the deadline of a context.Context. The correlation id and the other
+// headers are read from and written to the wrapped FContext, whose timeout is
+// left unchanged.
+type deadlineFContext struct {
+	FContext
+	mu      sync.RWMutex
+	timeout time.Duration
+}
+
+// AddRequestHeader adds a request header to the wrapped FContext.
+func (c *deadlineFContext) AddRequestHeader(name, value string) FContext {
+	c.FContext.AddRequestHeader(name, value)
+	return c
+}
+
+// RequestHeader gets the named request header, the timeout of c for the
+// timeout header.
+func (c *deadlineFContext) RequestHeader(name string) (string, bool) {
+	if name == timeoutHeader {
+		return strconv.FormatInt(int64(c.Timeout()/time.Millisecond), 10), true
+	}
+	return c.FContext.RequestHeader(name)
+}
+
+// RequestHeaders returns the request headers of the wrapped FContext with the
+// timeout of c.
+func (c *deadlineFContext) RequestHeaders() map[string]string {
+	headers := c.FContext.RequestHeaders()
+	headers[timeoutHeader] = strconv.FormatInt(int64(c.Timeout()/time.Millisecond), 10)
+	return headers
+}
+
+// AddResponseHeader adds a response header to the wrapped FContext.
+func (c *deadlineFContext) AddResponseHeader(name, value string) FContext {
+	c.FContext.AddResponseHeader(name, value)
+	return c
+}
+
+// SetTimeout sets the timeout of c without changing the wrapped FContext.
+func (c *deadlineFContext) SetTimeout(timeout time.Duration) FContext {
+	c.mu.Lock()
+	c.timeout = timeout
+	c.mu.Unlock()
+	return c
+}
+
+// Timeout returns the timeout of c.
+func (c *deadlineFContext) Timeout() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.timeout
+}
+
+// ToFContext returns the FContext a request made with the context.Context is
+// sent with. This is the FContext carried by the context.Context or, if there
+// is none, a new FContext. If the context.Context has a deadline, the returned
+// FContext has the time remaining until it as its timeout, while sharing the
+// correlation id and headers of the carried FContext, whose own timeout isn't
+// changed. The returned FContext also implements context.Context, giving
+// middleware access to the values of the context.Context. This should only be
+// called by generated code.
+func ToFContext(ctx context.Context) FContext {
+	fctx, ok := FContextFromContext(ctx)
+	if !ok {
+		fctx = NewFContext("")
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout := deadline.Sub(time.Now())
+		if timeout < 0 {
+			timeout = 0
+		}
+		fctx = &deadlineFContext{FContext: fctx, timeout: timeout}
+	}
+	return &stdContext{Context: ctx, FContext: fctx}
+}
+
+// CorrelationID returns the correlation id of the FContext carried by the
+// context.Context, or an empty string if there is none.
+func CorrelationID(ctx context.Context) string {
+	fctx, ok := FContextFromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return fctx.CorrelationID()
+}
+
+// WithCorrelationID sets the correlation id of the FContext carried by the
+// context.Context. If there is none, a copy of ctx carrying a new FContext
+// with the correlation id is returned.
+func WithCorrelationID(ctx context.Context, correlationID string) context.Context {
+	fctx, ok := FContextFromContext(ctx)
+	if !ok {
+		return WithFContext(ctx, NewFContext(correlationID))
+	}
+	fctx.AddRequestHeader(cidHeader, correlationID)
+	return ctx
+}
+
+// RequestHeader gets the named request header of the FContext carried by the
+// context.Context.
+func RequestHeader(ctx context.Context, name string) (string, bool) {
+	fctx, ok := FContextFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	return fctx.RequestHeader(name)
+}
+
+// WithRequestHeader adds a request header to the FContext carried by the
+// context.Context. If there is none, a copy of ctx carrying a new FContext
+// with the header is returned.
+func WithRequestHeader(ctx context.Context, name, value string) context.Context {
+	fctx, ok := FContextFromContext(ctx)
+	if !ok {
+		fctx = NewFContext("")
+		ctx = WithFContext(ctx, fctx)
+	}
+	fctx.AddRequestHeader(name, value)
+	return ctx
+}
+
+// ResponseHeader gets the named response header of the FContext carried by
+// the context.Context.
+func ResponseHeader(ctx context.Context, name string) (string, bool) {
+	fctx, ok := FContextFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	return fctx.ResponseHeader(name)
+}
+
+// WithResponseHeader adds a response header to the FContext carried by the
+// context.Context. Handlers can ignore the returned context.Context since the
+// context.Context they receive always carries an FContext. If there is none,
+// a copy of ctx carrying a new FContext with the header is returned.
+func WithResponseHeader(ctx context.Context, name, value string) context.Context {
+	fctx, ok := FContextFromContext(ctx)
+	if !ok {
+		fctx = NewFContext("")
+		ctx = WithFContext(ctx, fctx)
+	}
+	fctx.AddResponseHeader(name, value)
+	return ctx
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stdContextKey string
+
+// Ensures a context.Context carries an FContext and implements FContext
+// itself.
+func TestWithFContext(t *testing.T) {
+	parent := context.WithValue(context.Background(), stdContextKey("user"), "alice")
+	fctx := NewFContext("cid")
+	ctx := WithFContext(parent, fctx)
+
+	actual, ok := FContextFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, fctx, actual)
+	assert.Equal(t, "alice", ctx.Value(stdContextKey("user")))
+	assert.Equal(t, "cid", ctx.(FContext).CorrelationID())
+
+	// Wrapping the context again doesn't nest the FContext.
+	ctx = WithFContext(context.Background(), ctx.(FContext))
+	actual, _ = FContextFromContext(ctx)
+	assert.Equal(t, fctx, actual)
+
+	_, ok = FContextFromContext(context.Background())
+	assert.False(t, ok)
+}
+
+// Ensures ToFContext uses the carried FContext or creates one and takes the
+// timeout from the deadline without changing the carried FContext.
+func TestToFContext(t *testing.T) {
+	fctx := NewFContext("cid")
+	fctx.SetTimeout(time.Minute)
+	actual := ToFContext(WithFContext(context.Background(), fctx))
+	assert.Equal(t, "cid", actual.CorrelationID())
+	assert.Equal(t, time.Minute, actual.Timeout())
+
+	ctx, cancel := context.WithTimeout(WithFContext(context.Background(), fctx), 10*time.Second)
+	defer cancel()
+	actual = ToFContext(ctx)
+	assert.True(t, actual.Timeout() <= 10*time.Second)
+	assert.True(t, actual.Timeout() > 9*time.Second)
+	assert.Equal(t, ctx.Done(), actual.(context.Context).Done())
+	timeout, _ := actual.RequestHeader(timeoutHeader)
+	assert.Equal(t, timeout, actual.RequestHeaders()[timeoutHeader])
+	assert.Equal(t, strconv.FormatInt(int64(actual.Timeout()/time.Millisecond), 10), timeout)
+
+	// The timeout of the carried FContext isn't changed, while the correlation
+	// id and headers are shared with it.
+	assert.Equal(t, time.Minute, fctx.Timeout())
+	assert.Equal(t, "cid", actual.CorrelationID())
+	actual.AddRequestHeader("foo", "bar")
+	value, _ := fctx.RequestHeader("foo")
+	assert.Equal(t, "bar", value)
+	actual.AddResponseHeader("baz", "qux")
+	value, _ = fctx.ResponseHeader("baz")
+	assert.Equal(t, "qux", value)
+
+	actual = ToFContext(context.Background())
+	assert.NotEqual(t, "", actual.CorrelationID())
+	assert.Equal(t, defaultTimeout, actual.Timeout())
+}
+
+// Ensures the header accessors read and write the carried FContext.
+func TestStdContextHeaders(t *testing.T) {
+	fctx := NewFContext("cid")
+	ctx := WithFContext(context.Background(), fctx)
+
+	assert.Equal(t, "cid", CorrelationID(ctx))
+	assert.Equal(t, ctx, WithCorrelationID(ctx, "other"))
+	assert.Equal(t, "other", fctx.CorrelationID())
+
+	assert.Equal(t, ctx, WithRequestHeader(ctx, "foo", "bar"))
+	value, ok := RequestHeader(ctx, "foo")
+	assert.True(t, ok)
+	assert.Equal(t, "bar", value)
+
+	assert.Equal(t, ctx, WithResponseHeader(ctx, "baz", "qux"))
+	value, ok = ResponseHeader(ctx, "baz")
+	assert.True(t, ok)
+	assert.Equal(t, "qux", value)
+}
+
+// Ensures the header accessors create an FContext when the context.Context
+// doesn't carry one.
+func TestStdContextHeadersWithoutFContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "", CorrelationID(ctx))
+	_, ok := RequestHeader(ctx, "foo")
+	assert.False(t, ok)
+	_, ok = ResponseHeader(ctx, "foo")
+	assert.False(t, ok)
+
+	assert.Equal(t, "cid", CorrelationID(WithCorrelationID(ctx, "cid")))
+	value, _ := RequestHeader(WithRequestHeader(ctx, "foo", "bar"), "foo")
+	assert.Equal(t, "bar", value)
+	value, _ = ResponseHeader(WithResponseHeader(ctx, "foo", "bar"), "foo")
+	assert.Equal(t, "bar", value)
+}
+
+// Ensures middleware sees the FContext of Arguments while a handler taking a
+// context.Context is invoked with it.
+func TestStdContextMiddleware(t *testing.T) {
+	var seen string
+	middleware := func(next InvocationHandler) InvocationHandler {
+		return func(service reflect.Value, method reflect.Method, args Arguments) Results {
+			seen = args.Context().CorrelationID()
+			return next(service, method, args)
+		}
+	}
+	handler := func(ctx context.Context, name string) (string, error) {
+		return CorrelationID(ctx) + ":" + name, nil
+	}
+	method := NewMethod(handler, handler, "handle", []ServiceMiddleware{middleware})
+
+	ctx := WithFContext(context.Background(), NewFContext("cid"))
+	results := method.Invoke(Arguments{ctx, "foo"})
+	assert.Equal(t, "cid", seen)
+	assert.Equal(t, Results{"cid:foo", nil}, results)
+}
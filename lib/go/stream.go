@@ -0,0 +1,789 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+)
+
+const (
+	// streamHeader is set on every frame which belongs to a stream after the
+	// request which opens it. Its value is one of the stream frame kinds
+	// below.
+	streamHeader = "_stream"
+
+	// streamData frames carry a single streamed value.
+	streamData = "data"
+	// streamEnd frames end one direction of a stream. Sent by a server, they
+	// carry the method's result or an exception. Sent by a client, they carry
+	// nothing and half-close the stream.
+	streamEnd = "end"
+	// streamOpen frames are sent by a server when it's ready to receive the
+	// request frames of a stream.
+	streamOpen = "open"
+	// streamCredit frames are sent by a server to allow a client to send
+	// more request frames.
+	streamCredit = "credit"
+	// streamCancel frames are sent by a client which abandons a stream
+	// before it ends.
+	streamCancel = "cancel"
+
+	// streamCreditHeader is set on open and credit frames. Its value is the
+	// number of additional request frames the client may send.
+	streamCreditHeader = "_stream_credit"
+
+	// streamBufferSize is the number of frames a stream buffers as they're
+	// received and again for Recv. A stream which falls further behind is
+	// closed with ErrStreamOverflow rather than blocking the transport. It's
+	// also the number of request frames a client may send before receiving
+	// more credit.
+	streamBufferSize = 64
+)
+
+var (
+	// ErrStreamClosed is returned when sending on or receiving from a stream
+	// which has ended or was closed.
+	ErrStreamClosed = errors.New("frugal: stream closed")
+
+	// ErrStreamCanceled is returned to a handler sending on or receiving
+	// from a stream which the client canceled.
+	ErrStreamCanceled = errors.New("frugal: stream canceled")
+
+	// ErrStreamOverflow is returned when receiving from a stream which was
+	// closed because it wasn't keeping up with the frames sent to it.
+	ErrStreamOverflow = errors.New("frugal: stream receive buffer overflowed")
+
+	// ErrStreamNilValue is returned by a handler's stream sender when asked to
+	// send a nil struct, container, or binary value. A response without a
+	// value is how the client recognizes the end of a stream, so nil can't be
+	// sent.
+	ErrStreamNilValue = errors.New("frugal: can't send a nil value on a stream")
+)
+
+// FStreamingTransport is an FTransport which supports service methods that
+// stream their requests or responses. The frames of a stream are correlated
+// by the operation id of the request which opens it, just like a single
+// response.
+type FStreamingTransport interface {
+	FTransport
+
+	// RequestStream transmits the given data and returns an FResponseStream
+	// which receives each response sent for it. Implementations of
+	// RequestStream should be threadsafe. The data is expected to already be
+	// framed.
+	RequestStream(ctx FContext, payload []byte) (FResponseStream, error)
+
+	// OpenStream transmits the given data and returns an FStream which
+	// sends further request frames and receives each response sent for it.
+	// Implementations of OpenStream should be threadsafe. The data is
+	// expected to already be framed.
+	OpenStream(ctx FContext, payload []byte) (FStream, error)
+}
+
+// FResponseStream receives the response frames sent for a single streaming
+// request. It's used by generated code.
+type FResponseStream interface {
+	// Recv blocks until the next response frame is received and returns it.
+	// It respects the timeout present on the context for each frame. Once
+	// the frame which ends the stream has been returned, Recv returns
+	// io.EOF.
+	Recv() (thrift.TTransport, error)
+
+	// Close stops receiving frames. If the stream hasn't ended, the server
+	// is told to cancel it. Frames which arrive afterwards are dropped.
+	// Close can be called multiple times.
+	Close() error
+}
+
+// FStream is an FResponseStream which also sends request frames. It's used
+// by generated code.
+type FStream interface {
+	FResponseStream
+
+	// Send transmits a request frame on the stream. It blocks until the
+	// server is ready to receive it, respecting the timeout present on the
+	// context. The data is expected to already be framed.
+	Send(payload []byte) error
+
+	// CloseSend tells the server no more request frames will be sent. Like
+	// Send, it waits until the server is ready to receive request frames.
+	// Responses can still be received.
+	CloseSend() error
+}
+
+// streamFrame is a response frame received by an fStream.
+type streamFrame struct {
+	data []byte
+	end  bool
+}
+
+// fStream implements FStream. Transports deliver the frames received for the
+// stream to its buffered frames channel without blocking, calling overflow if
+// it's full, and send the frames it produces with the send function.
+type fStream struct {
+	ctx        FContext
+	frames     chan []byte
+	responses  chan streamFrame
+	send       func([]byte) error
+	unregister func()
+	done       chan struct{}
+	creditC    chan struct{}
+	opened     chan struct{}
+	openOnce   sync.Once
+	closeOnce  sync.Once
+	recvMu     sync.Mutex
+	mu         sync.Mutex
+	credit     int
+	sendClosed bool
+	ended      bool
+	closed     bool
+	err        error
+}
+
+// newFStream returns a new fStream for the context which receives the frames
+// delivered to the given buffered channel. The send function transmits framed
+// data to the server and the unregister function is called once the stream is
+// closed.
+func newFStream(ctx FContext, frames chan []byte, send func([]byte) error, unregister func()) *fStream {
+	stream := &fStream{
+		ctx:        ctx,
+		frames:     frames,
+		responses:  make(chan streamFrame, streamBufferSize),
+		send:       send,
+		unregister: unregister,
+		done:       make(chan struct{}),
+		creditC:    make(chan struct{}, 1),
+		opened:     make(chan struct{}),
+	}
+	go stream.receive()
+	return stream
+}
+
+// receive handles the frames delivered to the stream until it's closed.
+// Credit is applied as soon as it arrives so a client which is only sending
+// isn't blocked by responses it hasn't read.
+func (s *fStream) receive() {
+	for {
+		select {
+		case frame := <-s.frames:
+			headers, err := getHeadersFromFrame(frame)
+			if err != nil {
+				logger().Warn("frugal: invalid stream frame headers:", err)
+				continue
+			}
+			kind := headers[streamHeader]
+			if kind == streamOpen || kind == streamCredit {
+				credit, err := strconv.Atoi(headers[streamCreditHeader])
+				if err != nil {
+					logger().Warn("frugal: invalid stream credit:", err)
+					continue
+				}
+				s.addCredit(credit)
+				if kind == streamOpen {
+					s.openOnce.Do(func() { close(s.opened) })
+				}
+				continue
+			}
+			select {
+			case s.responses <- streamFrame{data: frame, end: kind == streamEnd}:
+			case <-s.done:
+				return
+			}
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Recv blocks until the next response frame is received and returns it.
+func (s *fStream) Recv() (thrift.TTransport, error) {
+	s.recvMu.Lock()
+	defer s.recvMu.Unlock()
+	s.mu.Lock()
+	ended, closed := s.ended, s.closed
+	s.mu.Unlock()
+	if ended {
+		return nil, io.EOF
+	}
+	if closed {
+		return nil, s.closedError()
+	}
+
+	select {
+	case frame := <-s.responses:
+		if frame.end {
+			s.mu.Lock()
+			s.ended = true
+			s.mu.Unlock()
+			s.Close()
+		}
+		return &thrift.TMemoryBuffer{Buffer: bytes.NewBuffer(frame.data)}, nil
+	case <-s.done:
+		return nil, s.closedError()
+	case <-time.After(s.ctx.Timeout()):
+		return nil, thrift.NewTTransportException(TRANSPORT_EXCEPTION_TIMED_OUT, "frugal: stream timed out")
+	}
+}
+
+// overflow closes the stream because a frame couldn't be delivered to it
+// without blocking the transport.
+func (s *fStream) overflow() {
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = ErrStreamOverflow
+	}
+	s.mu.Unlock()
+	s.Close()
+}
+
+// closedError returns the error Recv returns once the stream is closed.
+func (s *fStream) closedError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	return ErrStreamClosed
+}
+
+// Send transmits a request frame on the stream once the server has granted
+// credit for it.
+func (s *fStream) Send(payload []byte) error {
+	if err := s.acquireCredit(); err != nil {
+		return err
+	}
+	frame, err := addHeadersToFrame(payload, map[string]string{streamHeader: streamData})
+	if err != nil {
+		return err
+	}
+	return s.send(frame)
+}
+
+// CloseSend tells the server no more request frames will be sent once it's
+// ready to receive them.
+func (s *fStream) CloseSend() error {
+	select {
+	case <-s.opened:
+	case <-s.done:
+		return nil
+	case <-time.After(s.ctx.Timeout()):
+		return thrift.NewTTransportException(TRANSPORT_EXCEPTION_TIMED_OUT, "frugal: stream send timed out")
+	}
+
+	s.mu.Lock()
+	if s.sendClosed || s.ended || s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.sendClosed = true
+	s.mu.Unlock()
+	return s.send(streamControlFrame(s.ctx, streamEnd))
+}
+
+// Close stops receiving frames and cancels the stream if it hasn't ended.
+func (s *fStream) Close() error {
+	s.closeOnce.Do(func() {
+		s.mu.Lock()
+		ended := s.ended
+		s.closed = true
+		s.mu.Unlock()
+		if !ended {
+			if err := s.send(streamControlFrame(s.ctx, streamCancel)); err != nil {
+				logger().Warn("frugal: could not cancel stream:", err)
+			}
+		}
+		close(s.done)
+		s.unregister()
+	})
+	return nil
+}
+
+// acquireCredit blocks until the stream has credit to send a request frame
+// and consumes it.
+func (s *fStream) acquireCredit() error {
+	timeout := time.After(s.ctx.Timeout())
+	for {
+		s.mu.Lock()
+		if s.sendClosed || s.ended || s.closed {
+			s.mu.Unlock()
+			return ErrStreamClosed
+		}
+		if s.credit > 0 {
+			s.credit--
+			s.mu.Unlock()
+			return nil
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-s.creditC:
+		case <-s.done:
+			return ErrStreamClosed
+		case <-timeout:
+			return thrift.NewTTransportException(TRANSPORT_EXCEPTION_TIMED_OUT, "frugal: stream send timed out")
+		}
+	}
+}
+
+// addCredit allows the given number of additional request frames to be sent.
+func (s *fStream) addCredit(credit int) {
+	s.mu.Lock()
+	s.credit += credit
+	s.mu.Unlock()
+	select {
+	case s.creditC <- struct{}{}:
+	default:
+	}
+}
+
+// streamControlFrame returns a frame sent by a client which contains only the
+// headers identifying the stream and the given frame kind.
+func streamControlFrame(ctx FContext, kind string) []byte {
+	opID, _ := ctx.RequestHeader(opIDHeader)
+	headers := map[string]string{
+		opIDHeader:   opID,
+		cidHeader:    ctx.CorrelationID(),
+		streamHeader: kind,
+	}
+	return prependFrameSize(writeMarshaler.marshalHeaders(headers))
+}
+
+// serverStream is a stream being served, which receives request frames if
+// its method streams requests.
+type serverStream struct {
+	writer *FStreamWriter
+	reader *FStreamReader
+}
+
+// fServerStreams holds the streams being served. Streams are keyed by the
+// connection the request which opened them was received on as well as its
+// correlation id and operation id, since clients choose correlation ids and
+// operation ids are only unique within a client process.
+type fServerStreams struct {
+	mu      sync.Mutex
+	streams map[string]*serverStream
+}
+
+// serverStreams holds every stream being served by processors in this process.
+var serverStreams = &fServerStreams{streams: make(map[string]*serverStream)}
+
+// serverStreamKey returns the key of the stream a request received on the
+// given output protocol belongs to. A tFramedOutputSender is created for each
+// request, so its origin identifies the connection. Otherwise, the output
+// transport is shared by every request on the connection.
+func serverStreamKey(ctx FContext, oprot *FProtocol) string {
+	var origin string
+	if output, ok := oprot.Transport().(*tFramedOutputSender); ok {
+		origin = output.origin
+	} else {
+		origin = fmt.Sprintf("%p", oprot.Transport())
+	}
+	opID, _ := ctx.ResponseHeader(opIDHeader)
+	return origin + ":" + ctx.CorrelationID() + ":" + opID
+}
+
+func (f *fServerStreams) get(key string) *serverStream {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.streams[key]
+}
+
+func (f *fServerStreams) add(key string, writer *FStreamWriter) {
+	f.mu.Lock()
+	f.streams[key] = &serverStream{writer: writer}
+	f.mu.Unlock()
+}
+
+func (f *fServerStreams) setReader(key string, reader *FStreamReader) {
+	f.mu.Lock()
+	if stream, ok := f.streams[key]; ok {
+		stream.reader = reader
+	}
+	f.mu.Unlock()
+}
+
+func (f *fServerStreams) remove(key string, writer *FStreamWriter) {
+	f.mu.Lock()
+	if stream, ok := f.streams[key]; ok && stream.writer == writer {
+		delete(f.streams, key)
+	}
+	f.mu.Unlock()
+}
+
+// execute handles a frame a client sent on a stream after the request which
+// opened it. The frame's headers have already been read from iprot.
+func (f *fServerStreams) execute(ctx FContext, kind string, iprot, oprot *FProtocol) error {
+	stream := f.get(serverStreamKey(ctx, oprot))
+	if kind == streamData && (stream == nil || stream.reader == nil) {
+		logger().Debugf("frugal: discarding frame for unknown stream with correlation id %s", ctx.CorrelationID())
+		return skipMessage(iprot)
+	}
+	if stream == nil {
+		return nil
+	}
+
+	switch kind {
+	case streamData:
+		return stream.reader.receive(iprot)
+	case streamEnd:
+		if stream.reader != nil {
+			stream.reader.finish(io.EOF)
+		}
+	case streamCancel:
+		stream.writer.cancel()
+		if stream.reader != nil {
+			stream.reader.finish(ErrStreamCanceled)
+		}
+	default:
+		logger().Warnf("frugal: discarding stream frame of unknown kind %s", kind)
+	}
+	return nil
+}
+
+// skipMessage reads and discards a Thrift message.
+func skipMessage(iprot *FProtocol) error {
+	if _, _, _, err := iprot.ReadMessageBegin(); err != nil {
+		return err
+	}
+	if err := iprot.Skip(thrift.STRUCT); err != nil {
+		return err
+	}
+	return iprot.ReadMessageEnd()
+}
+
+// FStreamWriter writes the response frames of a streaming service method.
+// Each value is written as its own frame and the stream is ended by a final
+// frame carrying the method's result or an exception. This is only to be used
+// by generated code.
+type FStreamWriter struct {
+	ctx      FContext
+	oprot    *FProtocol
+	writeMu  *sync.Mutex
+	method   string
+	key      string
+	ended    bool
+	canceled bool
+}
+
+// NewFStreamWriter returns a new FStreamWriter which writes the responses of
+// the given method to the output protocol. The write mutex synchronizes
+// access to the output protocol. The stream can be canceled by the client
+// until it ends.
+func NewFStreamWriter(ctx FContext, oprot *FProtocol, writeMu *sync.Mutex, method string) *FStreamWriter {
+	writer := &FStreamWriter{
+		ctx:     ctx,
+		oprot:   oprot,
+		writeMu: writeMu,
+		method:  method,
+		key:     serverStreamKey(ctx, oprot),
+	}
+	serverStreams.add(writer.key, writer)
+	return writer
+}
+
+// WriteData writes a frame containing a result which carries a single
+// streamed value.
+func (w *FStreamWriter) WriteData(result thrift.TStruct) error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+	if w.canceled {
+		return ErrStreamCanceled
+	}
+	if w.ended {
+		return ErrStreamClosed
+	}
+	err := w.writeMessage(streamData, thrift.REPLY, result)
+	if IsErrTooLarge(err) {
+		return thrift.NewTTransportException(TRANSPORT_EXCEPTION_RESPONSE_TOO_LARGE, err.Error())
+	}
+	return err
+}
+
+// WriteEnd ends the stream with a frame containing the given result, which
+// may carry a declared exception.
+func (w *FStreamWriter) WriteEnd(result thrift.TStruct) error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+	if w.canceled {
+		return ErrStreamCanceled
+	}
+	if w.ended {
+		return ErrStreamClosed
+	}
+	w.end()
+	return w.writeMessage(streamEnd, thrift.REPLY, result)
+}
+
+// WriteError ends the stream with a frame containing a
+// TApplicationException with the given type and message. The
+// TApplicationException is returned.
+func (w *FStreamWriter) WriteError(typeID int32, message string) error {
+	x := thrift.NewTApplicationException(typeID, message)
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+	if w.ended || w.canceled {
+		return x
+	}
+	w.end()
+	w.writeMessage(streamEnd, thrift.EXCEPTION, x)
+	return x
+}
+
+// Handle calls the handler in a new goroutine so frames the client sends on
+// the stream, including a cancel, are processed while it runs. An error
+// returned by the handler is logged.
+func (w *FStreamWriter) Handle(handler func() error) {
+	w.writeMu.Lock()
+	// The handler keeps writing after the request is processed, so any
+	// output the server flushes once processing completes belongs to it.
+	if output, ok := w.oprot.Transport().(*tFramedOutputSender); ok {
+		output.detached = true
+	}
+	w.writeMu.Unlock()
+
+	go func() {
+		err := handler()
+		if err == nil {
+			return
+		}
+		w.writeMu.Lock()
+		canceled := w.canceled
+		w.writeMu.Unlock()
+		if canceled {
+			logger().Debugf("frugal: stream with correlation id %s was canceled: %s",
+				w.ctx.CorrelationID(), err.Error())
+			return
+		}
+		logger().Errorf(
+			"frugal: error occurred while processing stream with correlation id %s: %s",
+			w.ctx.CorrelationID(), err.Error())
+	}()
+}
+
+// end marks the stream ended so frames the client sends are discarded. The
+// write mutex must be held.
+func (w *FStreamWriter) end() {
+	w.ended = true
+	serverStreams.remove(w.key, w)
+}
+
+// cancel marks the stream canceled so nothing more is written to it.
+func (w *FStreamWriter) cancel() {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+	w.canceled = true
+	serverStreams.remove(w.key, w)
+}
+
+func (w *FStreamWriter) writeMessage(kind string, typeID thrift.TMessageType, body interface {
+	Write(thrift.TProtocol) error
+}) error {
+	w.ctx.AddResponseHeader(streamHeader, kind)
+	if err := w.oprot.WriteResponseHeader(w.ctx); err != nil {
+		return err
+	}
+	if err := w.oprot.WriteMessageBegin(w.method, typeID, 0); err != nil {
+		return err
+	}
+	if err := body.Write(w.oprot); err != nil {
+		return err
+	}
+	if err := w.oprot.WriteMessageEnd(); err != nil {
+		return err
+	}
+	return w.oprot.Flush()
+}
+
+// writeCredit writes a frame of the given kind which allows the client to
+// send the given number of additional request frames. The write mutex must
+// be held.
+func (w *FStreamWriter) writeCredit(kind string, credit int) error {
+	if w.ended || w.canceled {
+		return nil
+	}
+	opID, _ := w.ctx.ResponseHeader(opIDHeader)
+	headers := map[string]string{
+		opIDHeader:         opID,
+		cidHeader:          w.ctx.CorrelationID(),
+		streamHeader:       kind,
+		streamCreditHeader: strconv.Itoa(credit),
+	}
+	if err := w.oprot.writeHeader(headers); err != nil {
+		return err
+	}
+	return w.oprot.Flush()
+}
+
+// FStreamReader receives the values a client sends on a stream. The client
+// may only send as many values as the reader has granted it credit for,
+// which is replenished as values are received. This is only to be used by
+// generated code.
+type FStreamReader struct {
+	writer   *FStreamWriter
+	read     func(*FProtocol) (interface{}, error)
+	values   chan interface{}
+	finished chan struct{}
+	once     sync.Once
+	err      error
+	mu       sync.Mutex
+	received int
+}
+
+// NewFStreamReader returns a new FStreamReader for the stream written by the
+// given FStreamWriter and tells the client it's ready to receive values. The
+// read function reads a single value from the request struct in a request
+// frame.
+func NewFStreamReader(writer *FStreamWriter, read func(*FProtocol) (interface{}, error)) (*FStreamReader, error) {
+	reader := &FStreamReader{
+		writer:   writer,
+		read:     read,
+		values:   make(chan interface{}, streamBufferSize),
+		finished: make(chan struct{}),
+	}
+	serverStreams.setReader(writer.key, reader)
+
+	writer.writeMu.Lock()
+	defer writer.writeMu.Unlock()
+	return reader, writer.writeCredit(streamOpen, streamBufferSize)
+}
+
+// Recv blocks until the next value is received and returns it. It returns
+// io.EOF once the client has closed its side of the stream and
+// ErrStreamCanceled if the client canceled it. It respects the timeout
+// present on the context.
+func (r *FStreamReader) Recv() (interface{}, error) {
+	select {
+	case value := <-r.values:
+		return value, r.grantCredit()
+	default:
+	}
+
+	select {
+	case value := <-r.values:
+		return value, r.grantCredit()
+	case <-r.finished:
+		// Values received before the client closed its side are still
+		// returned.
+		if r.err == io.EOF {
+			select {
+			case value := <-r.values:
+				return value, r.grantCredit()
+			default:
+			}
+		}
+		return nil, r.err
+	case <-time.After(r.writer.ctx.Timeout()):
+		return nil, thrift.NewTTransportException(TRANSPORT_EXCEPTION_TIMED_OUT, "frugal: stream receive timed out")
+	}
+}
+
+// receive reads a value from a request frame and buffers it.
+func (r *FStreamReader) receive(iprot *FProtocol) error {
+	if _, _, _, err := iprot.ReadMessageBegin(); err != nil {
+		return err
+	}
+	value, err := r.read(iprot)
+	if err != nil {
+		return err
+	}
+	if err := iprot.ReadMessageEnd(); err != nil {
+		return err
+	}
+
+	select {
+	case r.values <- value:
+		return nil
+	default:
+		// The client sent more values than it was granted credit for.
+		logger().Warnf("frugal: canceling stream with correlation id %s which exceeded its credit",
+			r.writer.ctx.CorrelationID())
+		r.writer.cancel()
+		r.finish(ErrStreamCanceled)
+		return nil
+	}
+}
+
+// finish stops the reader, causing Recv to return the given error once
+// buffered values are consumed.
+func (r *FStreamReader) finish(err error) {
+	r.once.Do(func() {
+		r.err = err
+		close(r.finished)
+	})
+}
+
+// grantCredit grants the client more credit once half of the buffer has been
+// consumed.
+func (r *FStreamReader) grantCredit() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.received++
+	if r.received < streamBufferSize/2 {
+		return nil
+	}
+	credit := r.received
+	r.received = 0
+	r.writer.writeMu.Lock()
+	defer r.writer.writeMu.Unlock()
+	return r.writer.writeCredit(streamCredit, credit)
+}
+
+// tFramedOutputSender is a TMemoryOutputBuffer which sends its framed
+// contents each time it's flushed rather than once processing completes.
+// This allows an FProcessor to stream several responses to a single request.
+type tFramedOutputSender struct {
+	*TMemoryOutputBuffer
+	send func([]byte) error
+
+	// origin identifies the client connection the request was received
+	// from, which scopes the streams it opens.
+	origin string
+
+	// detached is set when a stream keeps writing to the output after the
+	// request which opened it is processed.
+	detached bool
+}
+
+// newTFramedOutputSender returns a new tFramedOutputSender with the given
+// size limit for each frame, for a request received from the given origin.
+// The send function is called with each frame and must not retain it.
+func newTFramedOutputSender(size uint, origin string, send func([]byte) error) *tFramedOutputSender {
+	return &tFramedOutputSender{TMemoryOutputBuffer: NewTMemoryOutputBuffer(size), send: send, origin: origin}
+}
+
+// Flush sends the buffered frame, if any, and resets the buffer. Protocols
+// may write strings directly to the underlying buffer, so the size limit is
+// enforced again before sending.
+func (t *tFramedOutputSender) Flush() error {
+	if !t.HasWriteData() {
+		return nil
+	}
+	if t.limit > 0 && uint(t.Len()) > t.limit {
+		t.Reset()
+		return thrift.NewTTransportException(
+			TRANSPORT_EXCEPTION_REQUEST_TOO_LARGE,
+			fmt.Sprintf("Buffer size reached (%d)", t.limit))
+	}
+	err := t.send(t.Bytes())
+	t.Reset()
+	return err
+}
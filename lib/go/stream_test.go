@@ -0,0 +1,481 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/nats-io/go-nats"
+	"github.com/stretchr/testify/assert"
+)
+
+// stringStruct is a TStruct which holds a single string.
+type stringStruct struct {
+	value string
+}
+
+func (s *stringStruct) Write(p thrift.TProtocol) error {
+	return p.WriteString(s.value)
+}
+
+func (s *stringStruct) Read(p thrift.TProtocol) (err error) {
+	s.value, err = p.ReadString()
+	return err
+}
+
+// streamingProcessor streams each value followed by an end frame containing
+// "done".
+type streamingProcessor struct {
+	values  []string
+	writeMu sync.Mutex
+}
+
+func (s *streamingProcessor) Process(iprot, oprot *FProtocol) error {
+	ctx, err := iprot.ReadRequestHeader()
+	if err != nil {
+		return err
+	}
+	writer := NewFStreamWriter(ctx, oprot, &s.writeMu, "stream")
+	for _, value := range s.values {
+		if err := writer.WriteData(&stringStruct{value}); err != nil {
+			return err
+		}
+	}
+	return writer.WriteEnd(&stringStruct{"done"})
+}
+
+func (s *streamingProcessor) AddMiddleware(middleware ServiceMiddleware) {}
+
+func (s *streamingProcessor) Annotations() map[string]map[string]string {
+	return nil
+}
+
+// requestFrame returns a request frame containing only the FContext headers.
+func requestFrame(t *testing.T, ctx FContext) []byte {
+	buffer := NewTMemoryOutputBuffer(0)
+	proto := NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault()).GetProtocol(buffer)
+	assert.Nil(t, proto.WriteRequestHeader(ctx))
+	return buffer.Bytes()
+}
+
+// readValues reads every frame from the stream and returns the values they
+// contain, including the value of the end frame.
+func readValues(t *testing.T, ctx FContext, method string, stream FResponseStream) []string {
+	protoFactory := NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault())
+	var values []string
+	for {
+		tr, err := stream.Recv()
+		if err == io.EOF {
+			return values
+		}
+		if !assert.Nil(t, err) {
+			return values
+		}
+		iprot := protoFactory.GetProtocol(tr)
+		assert.Nil(t, iprot.ReadResponseHeader(ctx))
+		name, _, _, err := iprot.ReadMessageBegin()
+		assert.Nil(t, err)
+		assert.Equal(t, method, name)
+		value := &stringStruct{}
+		assert.Nil(t, value.Read(iprot))
+		values = append(values, value.value)
+	}
+}
+
+// registerStream returns an fStream registered with the registry for the
+// context which records the frames it sends.
+func registerStream(t *testing.T, ctx FContext, registry fRegistry, sent *[][]byte) *fStream {
+	frames := make(chan []byte, streamBufferSize)
+	var stream *fStream
+	assert.Nil(t, registry.RegisterStream(ctx, frames, func() { stream.overflow() }))
+	stream = newFStream(ctx, frames, func(frame []byte) error {
+		*sent = append(*sent, frame)
+		return nil
+	}, func() {
+		registry.Unregister(ctx)
+	})
+	return stream
+}
+
+// Ensures frames written by an FStreamWriter are received in order by an
+// fStream, which returns io.EOF after the end frame.
+func TestStreamWriterResponseStream(t *testing.T) {
+	registry := newFRegistry()
+	ctx := NewFContext("")
+	var sent [][]byte
+	stream := registerStream(t, ctx, registry, &sent)
+
+	output := newTFramedOutputSender(0, "", func(frame []byte) error {
+		data := make([]byte, len(frame)-4)
+		copy(data, frame[4:])
+		return registry.Execute(data)
+	})
+	processor := &streamingProcessor{values: []string{"a", "b"}}
+	protoFactory := NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault())
+	input := &thrift.TMemoryBuffer{Buffer: bytes.NewBuffer(requestFrame(t, ctx)[4:])}
+	assert.Nil(t, processor.Process(protoFactory.GetProtocol(input), protoFactory.GetProtocol(output)))
+
+	assert.Equal(t, []string{"a", "b", "done"}, readValues(t, ctx, "stream", stream))
+	_, err := stream.Recv()
+	assert.Equal(t, io.EOF, err)
+	assert.Nil(t, stream.Close())
+
+	// The context is unregistered once the stream ends and the server isn't
+	// told to cancel it.
+	opID, err := getOpID(ctx)
+	assert.Nil(t, err)
+	_, ok := registry.(*fRegistryImpl).channels[opID]
+	assert.False(t, ok)
+	assert.Len(t, sent, 0)
+}
+
+// Ensures Recv times out if no frame arrives within the context timeout and
+// closing a stream which hasn't ended sends a cancel frame.
+func TestStreamTimeoutCancel(t *testing.T) {
+	ctx := NewFContext("").SetTimeout(5 * time.Millisecond)
+	registry := newFRegistry()
+	var sent [][]byte
+	stream := registerStream(t, ctx, registry, &sent)
+	_, err := stream.Recv()
+	assert.Equal(t, TRANSPORT_EXCEPTION_TIMED_OUT, err.(thrift.TTransportException).TypeId())
+
+	// The server hasn't opened the stream, so sending times out too.
+	err = stream.Send(requestFrame(t, ctx))
+	assert.Equal(t, TRANSPORT_EXCEPTION_TIMED_OUT, err.(thrift.TTransportException).TypeId())
+	err = stream.CloseSend()
+	assert.Equal(t, TRANSPORT_EXCEPTION_TIMED_OUT, err.(thrift.TTransportException).TypeId())
+
+	// The context can't be registered twice.
+	assert.NotNil(t, registry.Register(ctx, make(chan []byte)))
+	assert.Nil(t, stream.Close())
+	assert.Nil(t, stream.Close())
+	_, err = stream.Recv()
+	assert.Equal(t, ErrStreamClosed, err)
+	assert.Equal(t, ErrStreamClosed, stream.Send(requestFrame(t, ctx)))
+
+	assert.Len(t, sent, 1)
+	headers, err := getHeadersFromFrame(sent[0][4:])
+	assert.Nil(t, err)
+	assert.Equal(t, streamCancel, headers[streamHeader])
+	assert.Equal(t, ctx.CorrelationID(), headers[cidHeader])
+	opID, _ := ctx.RequestHeader(opIDHeader)
+	assert.Equal(t, opID, headers[opIDHeader])
+}
+
+// Ensures an FStreamWriter rejects writes after the stream ends and converts
+// oversized frames to RESPONSE_TOO_LARGE errors.
+func TestStreamWriterErrors(t *testing.T) {
+	var frames [][]byte
+	output := newTFramedOutputSender(200, "", func(frame []byte) error {
+		frames = append(frames, append([]byte(nil), frame...))
+		return nil
+	})
+	oprot := NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault()).GetProtocol(output)
+	writer := NewFStreamWriter(NewFContext(""), oprot, &sync.Mutex{}, "stream")
+
+	err := writer.WriteData(&stringStruct{strings.Repeat("a", 200)})
+	assert.Equal(t, TRANSPORT_EXCEPTION_RESPONSE_TOO_LARGE, err.(thrift.TTransportException).TypeId())
+	assert.Len(t, frames, 0)
+
+	err = writer.WriteError(APPLICATION_EXCEPTION_INTERNAL_ERROR, "error")
+	assert.Equal(t, int32(APPLICATION_EXCEPTION_INTERNAL_ERROR), err.(thrift.TApplicationException).TypeId())
+	assert.Len(t, frames, 1)
+	headers, err := getHeadersFromFrame(frames[0][4:])
+	assert.Nil(t, err)
+	assert.Equal(t, streamEnd, headers[streamHeader])
+
+	assert.Equal(t, ErrStreamClosed, writer.WriteData(&stringStruct{"a"}))
+	assert.Equal(t, ErrStreamClosed, writer.WriteEnd(&stringStruct{"a"}))
+	assert.NotNil(t, writer.WriteError(APPLICATION_EXCEPTION_INTERNAL_ERROR, "error"))
+	assert.Len(t, frames, 1)
+}
+
+// Ensures a NATS server publishes each streamed response to the inbox and
+// the NATS transport receives them.
+func TestNatsTransportRequestStream(t *testing.T) {
+	s := runServer(nil)
+	defer s.Shutdown()
+	conn, err := nats.Connect(fmt.Sprintf("nats://localhost:%d", defaultOptions.Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	protoFactory := NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault())
+	processor := &streamingProcessor{values: []string{"a", "b", "c"}}
+	server := NewFNatsServerBuilder(conn, processor, protoFactory, []string{"stream"}).Build()
+	go func() {
+		assert.Nil(t, server.Serve())
+	}()
+	time.Sleep(10 * time.Millisecond)
+	defer server.Stop()
+
+	tr := NewFNatsTransport(conn, "stream", "").(FStreamingTransport)
+	ctx := NewFContext("")
+	_, err = tr.RequestStream(ctx, requestFrame(t, ctx))
+	assert.Equal(t, TRANSPORT_EXCEPTION_NOT_OPEN, err.(thrift.TTransportException).TypeId())
+
+	assert.Nil(t, tr.Open())
+	defer tr.Close()
+	stream, err := tr.RequestStream(ctx, requestFrame(t, ctx))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "done"}, readValues(t, ctx, "stream", stream))
+}
+
+// Ensures the adapter transport receives streamed responses written by an
+// FSimpleServer over a socket.
+func TestAdapterTransportRequestStream(t *testing.T) {
+	serverSocket, err := thrift.NewTServerSocket("localhost:0")
+	assert.Nil(t, err)
+	protoFactory := NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault())
+	processor := &streamingProcessor{values: []string{"a", "b"}}
+	server := NewFSimpleServer(processor, serverSocket, protoFactory)
+	assert.Nil(t, serverSocket.Listen())
+	go server.Serve()
+	defer server.Stop()
+
+	socket, err := thrift.NewTSocket(serverSocket.Addr().String())
+	assert.Nil(t, err)
+	tr := NewAdapterTransport(socket).(FStreamingTransport)
+	assert.Nil(t, tr.Open())
+	defer tr.Close()
+
+	ctx := NewFContext("")
+	stream, err := tr.RequestStream(ctx, requestFrame(t, ctx))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "done"}, readValues(t, ctx, "stream", stream))
+}
+
+// bidiFunction is an FProcessorFunction for a method which streams requests.
+// The "echo" method streams back each value received followed by "done". The
+// "count" method ends the stream with the number of values received. Each
+// handler's final error is sent to errors.
+type bidiFunction struct {
+	method  string
+	writeMu *sync.Mutex
+	errors  chan error
+}
+
+func (b *bidiFunction) Process(ctx FContext, iprot, oprot *FProtocol) error {
+	args := &stringStruct{}
+	if err := args.Read(iprot); err != nil {
+		return err
+	}
+	if err := iprot.ReadMessageEnd(); err != nil {
+		return err
+	}
+	writer := NewFStreamWriter(ctx, oprot, b.writeMu, b.method)
+	reader, err := NewFStreamReader(writer, func(iprot *FProtocol) (interface{}, error) {
+		value := &stringStruct{}
+		err := value.Read(iprot)
+		return value.value, err
+	})
+	if err != nil {
+		return err
+	}
+	writer.Handle(func() error {
+		err := b.handle(writer, reader)
+		b.errors <- err
+		return err
+	})
+	return nil
+}
+
+func (b *bidiFunction) handle(writer *FStreamWriter, reader *FStreamReader) error {
+	count := 0
+	for {
+		value, err := reader.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		count++
+		if b.method == "echo" {
+			if err := writer.WriteData(&stringStruct{value.(string)}); err != nil {
+				return err
+			}
+		}
+	}
+	if b.method == "count" {
+		return writer.WriteEnd(&stringStruct{fmt.Sprint(count)})
+	}
+	return writer.WriteEnd(&stringStruct{"done"})
+}
+
+func (b *bidiFunction) AddMiddleware(middleware ServiceMiddleware) {}
+
+// newBidiProcessor returns an FProcessor with the "echo" and "count" methods.
+func newBidiProcessor(errors chan error) FProcessor {
+	processor := NewFBaseProcessor()
+	for _, method := range []string{"echo", "count"} {
+		processor.AddToProcessorMap(method, &bidiFunction{method: method, writeMu: processor.GetWriteMutex(), errors: errors})
+	}
+	return processor
+}
+
+// callFrame returns a frame calling the method with a string.
+func callFrame(t *testing.T, ctx FContext, method, value string) []byte {
+	buffer := NewTMemoryOutputBuffer(0)
+	proto := NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault()).GetProtocol(buffer)
+	assert.Nil(t, proto.WriteRequestHeader(ctx))
+	assert.Nil(t, proto.WriteMessageBegin(method, thrift.CALL, 0))
+	assert.Nil(t, (&stringStruct{value}).Write(proto))
+	assert.Nil(t, proto.WriteMessageEnd())
+	return buffer.Bytes()
+}
+
+// testBidiStreams runs streams which send requests over the transport, which
+// must be connected to a server using the processor from newBidiProcessor.
+func testBidiStreams(t *testing.T, tr FStreamingTransport, errors chan error) {
+	// Values are echoed and the stream ends once the client half-closes it.
+	ctx := NewFContext("")
+	stream, err := tr.OpenStream(ctx, callFrame(t, ctx, "echo", ""))
+	assert.Nil(t, err)
+	assert.Nil(t, stream.Send(callFrame(t, ctx, "echo", "x")))
+	assert.Nil(t, stream.Send(callFrame(t, ctx, "echo", "y")))
+	assert.Nil(t, stream.CloseSend())
+	assert.Equal(t, ErrStreamClosed, stream.Send(callFrame(t, ctx, "echo", "z")))
+	assert.Equal(t, []string{"x", "y", "done"}, readValues(t, ctx, "echo", stream))
+	assert.Nil(t, <-errors)
+
+	// Sending more values than the initial credit waits for the server to
+	// grant more.
+	ctx = NewFContext("")
+	stream, err = tr.OpenStream(ctx, callFrame(t, ctx, "count", ""))
+	assert.Nil(t, err)
+	for i := 0; i < 3*streamBufferSize; i++ {
+		assert.Nil(t, stream.Send(callFrame(t, ctx, "count", "x")))
+	}
+	assert.Nil(t, stream.CloseSend())
+	assert.Equal(t, []string{fmt.Sprint(3 * streamBufferSize)}, readValues(t, ctx, "count", stream))
+	assert.Nil(t, <-errors)
+
+	// Closing the stream before it ends cancels it on the server.
+	ctx = NewFContext("")
+	stream, err = tr.OpenStream(ctx, callFrame(t, ctx, "echo", ""))
+	assert.Nil(t, err)
+	assert.Nil(t, stream.Send(callFrame(t, ctx, "echo", "x")))
+	_, err = stream.Recv()
+	assert.Nil(t, err)
+	assert.Nil(t, stream.Close())
+	select {
+	case err := <-errors:
+		assert.Equal(t, ErrStreamCanceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("expected the stream to be canceled")
+	}
+}
+
+// Ensures streams which send requests work over NATS.
+func TestNatsTransportOpenStream(t *testing.T) {
+	s := runServer(nil)
+	defer s.Shutdown()
+	conn, err := nats.Connect(fmt.Sprintf("nats://localhost:%d", defaultOptions.Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	errors := make(chan error, 1)
+	protoFactory := NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault())
+	server := NewFNatsServerBuilder(conn, newBidiProcessor(errors), protoFactory, []string{"bidi"}).Build()
+	go func() {
+		assert.Nil(t, server.Serve())
+	}()
+	time.Sleep(10 * time.Millisecond)
+	defer server.Stop()
+
+	tr := NewFNatsTransport(conn, "bidi", "").(FStreamingTransport)
+	assert.Nil(t, tr.Open())
+	defer tr.Close()
+	testBidiStreams(t, tr, errors)
+}
+
+// Ensures streams which send requests work over the adapter transport and an
+// FSimpleServer.
+func TestAdapterTransportOpenStream(t *testing.T) {
+	serverSocket, err := thrift.NewTServerSocket("localhost:0")
+	assert.Nil(t, err)
+	errors := make(chan error, 1)
+	protoFactory := NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault())
+	server := NewFSimpleServer(newBidiProcessor(errors), serverSocket, protoFactory)
+	assert.Nil(t, serverSocket.Listen())
+	go server.Serve()
+	defer server.Stop()
+
+	socket, err := thrift.NewTSocket(serverSocket.Addr().String())
+	assert.Nil(t, err)
+	tr := NewAdapterTransport(socket).(FStreamingTransport)
+	assert.Nil(t, tr.Open())
+	defer tr.Close()
+	testBidiStreams(t, tr, errors)
+}
+
+// Ensures streams opened by clients on different connections which reuse
+// the same correlation id and operation id don't interfere with each other.
+func TestServerStreamsScopedByOrigin(t *testing.T) {
+	errors := make(chan error, 1)
+	processor := newBidiProcessor(errors)
+	protoFactory := NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault())
+	var mu sync.Mutex
+	sent := make(map[string][][]byte)
+	process := func(origin string, frame []byte) {
+		input := &thrift.TMemoryBuffer{Buffer: bytes.NewBuffer(frame[4:])} // Discard frame size
+		output := newTFramedOutputSender(0, origin, func(data []byte) error {
+			mu.Lock()
+			sent[origin] = append(sent[origin], append([]byte(nil), data...))
+			mu.Unlock()
+			return nil
+		})
+		assert.Nil(t, processor.Process(protoFactory.GetProtocol(input), protoFactory.GetProtocol(output)))
+	}
+	dataFrame := func(ctx FContext, value string) []byte {
+		frame, err := addHeadersToFrame(callFrame(t, ctx, "count", value), map[string]string{streamHeader: streamData})
+		assert.Nil(t, err)
+		return frame
+	}
+
+	// Both clients use the same context, so their streams have the same
+	// correlation id and operation id.
+	ctx := NewFContext("cid")
+	process("a", callFrame(t, ctx, "count", ""))
+	process("b", callFrame(t, ctx, "count", ""))
+	process("a", dataFrame(ctx, "x"))
+	process("a", dataFrame(ctx, "y"))
+	process("b", dataFrame(ctx, "z"))
+
+	// Canceling one client's stream leaves the other's open.
+	process("b", streamControlFrame(ctx, streamCancel))
+	assert.Equal(t, ErrStreamCanceled, <-errors)
+	process("a", streamControlFrame(ctx, streamEnd))
+	assert.Nil(t, <-errors)
+
+	mu.Lock()
+	defer mu.Unlock()
+	frames := sent["a"]
+	iprot := protoFactory.GetProtocol(&thrift.TMemoryBuffer{Buffer: bytes.NewBuffer(frames[len(frames)-1][4:])})
+	assert.Nil(t, iprot.ReadResponseHeader(ctx))
+	_, _, _, err := iprot.ReadMessageBegin()
+	assert.Nil(t, err)
+	value := &stringStruct{}
+	assert.Nil(t, value.Read(iprot))
+	assert.Equal(t, "2", value.value)
+}
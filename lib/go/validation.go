@@ -0,0 +1,60 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import "fmt"
+
+// FValidator is implemented by structs generated with the Go validate option.
+type FValidator interface {
+	// Validate returns an *FValidationError for the first field which
+	// violates a constraint given by a validate annotation in the IDL, or
+	// nil if there is none.
+	Validate() error
+}
+
+// FValidationError is returned by Validate when a field violates a
+// constraint.
+type FValidationError struct {
+	// Field is the name of the field as given in the IDL. Fields of nested
+	// structs are prefixed with the names of the fields containing them,
+	// e.g. "track.artist.name".
+	Field string
+
+	// Rule is the violated rule, e.g. "max_len".
+	Rule string
+
+	// Message describes the violation.
+	Message string
+}
+
+// NewFValidationError returns an FValidationError for the given field and
+// rule.
+func NewFValidationError(field, rule, message string) *FValidationError {
+	return &FValidationError{Field: field, Rule: rule, Message: message}
+}
+
+// Error returns the field and message of the FValidationError.
+func (e *FValidationError) Error() string {
+	return fmt.Sprintf("invalid field %s: %s", e.Field, e.Message)
+}
+
+// NestFValidationError prefixes the field of the given error, if it's an
+// *FValidationError, with the name of the field containing the struct which
+// returned it. This should only be used by generated code.
+func NestFValidationError(field string, err error) error {
+	if e, ok := err.(*FValidationError); ok {
+		return &FValidationError{Field: field + "." + e.Field, Rule: e.Rule, Message: e.Message}
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type validatedArgs struct {
+	err error
+}
+
+func (v *validatedArgs) Validate() error {
+	return v.err
+}
+
+type validatedProcessorFunction struct {
+	*FBaseProcessorFunction
+}
+
+func (v *validatedProcessorFunction) Process(ctx FContext, in, out *FProtocol) error {
+	return nil
+}
+
+// Ensures FValidationErrors describe the field and are nested by field name.
+func TestValidationError(t *testing.T) {
+	err := NewFValidationError("name", "max_len", "length must be at most 64")
+	assert.Equal(t, "invalid field name: length must be at most 64", err.Error())
+
+	nested := NestFValidationError("artist", NestFValidationError("track", err))
+	assert.Equal(t, &FValidationError{Field: "artist.track.name", Rule: "max_len", Message: "length must be at most 64"}, nested)
+	assert.Equal(t, "invalid field name: length must be at most 64", err.Error())
+
+	other := errors.New("other")
+	assert.Equal(t, other, NestFValidationError("artist", other))
+}
+
+// Ensures request arguments are only validated once validation is enabled on
+// the processor.
+func TestProcessorSetValidation(t *testing.T) {
+	processor := NewFBaseProcessor()
+	function := &validatedProcessorFunction{NewFBaseProcessorFunction(processor.GetWriteMutex(), nil)}
+	processor.AddToProcessorMap("ping", function)
+	args := &validatedArgs{err: NewFValidationError("name", "min_len", "length must be at least 1")}
+
+	assert.Nil(t, function.ValidateArgs(args))
+
+	processor.SetValidation(true)
+	assert.Equal(t, args.err, function.ValidateArgs(args))
+
+	processor.SetValidation(false)
+	assert.Nil(t, function.ValidateArgs(args))
+}
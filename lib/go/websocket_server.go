@@ -0,0 +1,279 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/gorilla/websocket"
+)
+
+// FWebSocketHandlerBuilder configures and builds http.Handlers which serve
+// Frugal requests and scope subscriptions over WebSocket connections.
+type FWebSocketHandlerBuilder struct {
+	processor         FProcessor
+	protoFactory      *FProtocolFactory
+	upgrader          *websocket.Upgrader
+	publisher         FPublisherTransport
+	subscriberFactory FSubscriberTransportFactory
+}
+
+// NewFWebSocketHandlerBuilder creates a builder which configures and builds
+// WebSocket http.Handlers using the given FProcessor and FProtocolFactory.
+// The processor may be nil if the handler only serves scopes.
+func NewFWebSocketHandlerBuilder(processor FProcessor, protoFactory *FProtocolFactory) *FWebSocketHandlerBuilder {
+	return &FWebSocketHandlerBuilder{
+		processor:    processor,
+		protoFactory: protoFactory,
+		upgrader:     &websocket.Upgrader{},
+	}
+}
+
+// WithUpgrader sets the websocket.Upgrader used to upgrade HTTP requests,
+// which controls buffer sizes and origin checking.
+func (w *FWebSocketHandlerBuilder) WithUpgrader(upgrader *websocket.Upgrader) *FWebSocketHandlerBuilder {
+	w.upgrader = upgrader
+	return w
+}
+
+// WithPublisherTransport bridges scope messages published by clients to the
+// given FPublisherTransport, which must already be open. If not set, client
+// publishes are discarded.
+func (w *FWebSocketHandlerBuilder) WithPublisherTransport(publisher FPublisherTransport) *FWebSocketHandlerBuilder {
+	w.publisher = publisher
+	return w
+}
+
+// WithSubscriberTransportFactory bridges client scope subscriptions to
+// FSubscriberTransports produced by the given factory. If not set, client
+// subscriptions are rejected.
+func (w *FWebSocketHandlerBuilder) WithSubscriberTransportFactory(factory FSubscriberTransportFactory) *FWebSocketHandlerBuilder {
+	w.subscriberFactory = factory
+	return w
+}
+
+// Build a new configured WebSocket http.Handler.
+func (w *FWebSocketHandlerBuilder) Build() http.Handler {
+	return &fWebSocketHandler{
+		processor:         w.processor,
+		protoFactory:      w.protoFactory,
+		upgrader:          w.upgrader,
+		publisher:         w.publisher,
+		subscriberFactory: w.subscriberFactory,
+	}
+}
+
+// fWebSocketHandler implements http.Handler by upgrading requests to
+// WebSocket connections and serving each connection until it closes.
+type fWebSocketHandler struct {
+	processor         FProcessor
+	protoFactory      *FProtocolFactory
+	upgrader          *websocket.Upgrader
+	publisher         FPublisherTransport
+	subscriberFactory FSubscriberTransportFactory
+}
+
+// ServeHTTP upgrades the request to a WebSocket connection and serves it.
+func (h *fWebSocketHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already replied with an HTTP error.
+		logger().Warn("frugal: unable to upgrade WebSocket connection: ", err)
+		return
+	}
+
+	session := &webSocketSession{
+		handler:       h,
+		conn:          conn,
+		subscriptions: make(map[uint32]FSubscriberTransport),
+	}
+	session.serve()
+}
+
+// webSocketSession holds the state of a single client connection.
+type webSocketSession struct {
+	handler       *fWebSocketHandler
+	conn          *websocket.Conn
+	writeMu       sync.Mutex
+	subMu         sync.Mutex
+	subscriptions map[uint32]FSubscriberTransport
+	wg            sync.WaitGroup
+}
+
+// serve reads messages until the connection closes, then drops the client's
+// subscriptions.
+func (s *webSocketSession) serve() {
+	defer s.cleanup()
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				logger().Debug("frugal: WebSocket connection closed: ", err)
+			}
+			return
+		}
+		if len(data) == 0 {
+			logger().Warn("frugal: discarding empty WebSocket message")
+			continue
+		}
+
+		switch data[0] {
+		case wsMessageFrame:
+			// Requests are processed concurrently since responses are
+			// multiplexed by operation id.
+			s.wg.Add(1)
+			go func() {
+				defer s.wg.Done()
+				if err := s.processFrame(data[1:]); err != nil {
+					logger().Errorf("frugal: error processing request: %s", err.Error())
+				}
+			}()
+		case wsMessageSubscribe:
+			id, topic, err := decodeWebSocketSubscription(data)
+			if err != nil {
+				logger().Warn("frugal: discarding invalid subscribe message: ", err)
+				continue
+			}
+			s.subscribe(id, string(topic))
+		case wsMessageUnsubscribe:
+			id, _, err := decodeWebSocketSubscription(data)
+			if err != nil {
+				logger().Warn("frugal: discarding invalid unsubscribe message: ", err)
+				continue
+			}
+			s.unsubscribe(id)
+		case wsMessagePublish:
+			topic, frame, err := decodeWebSocketPublish(data)
+			if err != nil {
+				logger().Warn("frugal: discarding invalid publish message: ", err)
+				continue
+			}
+			s.publish(topic, frame)
+		default:
+			logger().Warnf("frugal: discarding WebSocket message with unknown type %d", data[0])
+		}
+	}
+}
+
+// processFrame invokes the FProcessor and sends the response, if any.
+func (s *webSocketSession) processFrame(frame []byte) error {
+	if s.handler.processor == nil {
+		logger().Warn("frugal: discarding request, WebSocket handler has no processor")
+		return nil
+	}
+	if len(frame) < 4 {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA,
+			errors.New("frugal: invalid WebSocket frame"))
+	}
+
+	input := &thrift.TMemoryBuffer{Buffer: bytes.NewBuffer(frame[4:])} // Discard frame size
+	output := NewTMemoryOutputBuffer(0)
+	iprot := s.handler.protoFactory.GetProtocol(input)
+	oprot := s.handler.protoFactory.GetProtocol(output)
+	if err := s.handler.processor.Process(iprot, oprot); err != nil {
+		return err
+	}
+	if !output.HasWriteData() {
+		return nil
+	}
+	return s.write(append([]byte{wsMessageFrame}, output.Bytes()...))
+}
+
+// subscribe subscribes to the topic on behalf of the client and forwards
+// received messages over the connection.
+func (s *webSocketSession) subscribe(id uint32, topic string) {
+	if s.handler.subscriberFactory == nil {
+		s.write(encodeWebSocketSubClosed(id, "subscriptions not supported"))
+		return
+	}
+
+	s.subMu.Lock()
+	_, exists := s.subscriptions[id]
+	s.subMu.Unlock()
+	if exists {
+		s.write(encodeWebSocketSubClosed(id, "duplicate subscription id"))
+		return
+	}
+
+	transport := s.handler.subscriberFactory.GetTransport()
+	err := transport.Subscribe(topic, func(tr thrift.TTransport) error {
+		frame, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		return s.write(encodeWebSocketScope(id, prependFrameSize(frame)))
+	})
+	if err != nil {
+		s.write(encodeWebSocketSubClosed(id, err.Error()))
+		return
+	}
+
+	s.subMu.Lock()
+	s.subscriptions[id] = transport
+	s.subMu.Unlock()
+}
+
+// unsubscribe drops the client's subscription with the given id.
+func (s *webSocketSession) unsubscribe(id uint32) {
+	s.subMu.Lock()
+	transport, ok := s.subscriptions[id]
+	delete(s.subscriptions, id)
+	s.subMu.Unlock()
+	if !ok {
+		return
+	}
+	if err := transport.Unsubscribe(); err != nil {
+		logger().Warn("frugal: error unsubscribing WebSocket client: ", err)
+	}
+}
+
+// publish forwards a client publish to the configured FPublisherTransport.
+func (s *webSocketSession) publish(topic string, frame []byte) {
+	if s.handler.publisher == nil {
+		logger().Warn("frugal: discarding publish, WebSocket handler has no publisher")
+		return
+	}
+	if err := s.handler.publisher.Publish(topic, frame); err != nil {
+		logger().Warnf("frugal: error publishing to %s for WebSocket client: %s", topic, err)
+	}
+}
+
+// write sends a message to the client.
+func (s *webSocketSession) write(message []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+	return s.conn.WriteMessage(websocket.BinaryMessage, message)
+}
+
+// cleanup waits for in-flight requests, drops the client's subscriptions,
+// and closes the connection.
+func (s *webSocketSession) cleanup() {
+	s.wg.Wait()
+	s.subMu.Lock()
+	for id, transport := range s.subscriptions {
+		if err := transport.Unsubscribe(); err != nil {
+			logger().Warn("frugal: error unsubscribing WebSocket client: ", err)
+		}
+		delete(s.subscriptions, id)
+	}
+	s.subMu.Unlock()
+	s.conn.Close()
+}
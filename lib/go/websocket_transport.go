@@ -0,0 +1,727 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket message types. Every binary WebSocket message begins with one of
+// these bytes, which determines the layout of the rest of the message. See
+// documentation/websocket.md for details.
+const (
+	wsMessageFrame       byte = 0x00
+	wsMessageSubscribe   byte = 0x01
+	wsMessageUnsubscribe byte = 0x02
+	wsMessagePublish     byte = 0x03
+	wsMessageScope       byte = 0x04
+	wsMessageSubClosed   byte = 0x05
+)
+
+const (
+	defaultWebSocketPingInterval = 10 * time.Second
+	defaultWebSocketPongTimeout  = 30 * time.Second
+	webSocketWriteTimeout        = 5 * time.Second
+
+	// webSocketSubscriptionBuffer is how many scope messages a subscription
+	// queues while its callback is busy before new messages are dropped.
+	webSocketSubscriptionBuffer = 1024
+)
+
+// FWebSocketTransport is an FTransport which sends requests over a single,
+// long-lived WebSocket connection. Responses are multiplexed by operation id,
+// so many requests can be in flight at once. Pub/sub scope messages are
+// carried on the same connection, so the transport also produces
+// FPublisherTransports and FSubscriberTransports which share it.
+type FWebSocketTransport interface {
+	FTransport
+
+	// PublisherTransportFactory returns an FPublisherTransportFactory which
+	// produces FPublisherTransports that publish over this connection.
+	PublisherTransportFactory() FPublisherTransportFactory
+
+	// SubscriberTransportFactory returns an FSubscriberTransportFactory which
+	// produces FSubscriberTransports that subscribe over this connection.
+	SubscriberTransportFactory() FSubscriberTransportFactory
+}
+
+// FWebSocketTransportBuilder configures and builds WebSocket FTransport
+// instances.
+type FWebSocketTransportBuilder struct {
+	dialer           *websocket.Dialer
+	url              string
+	requestHeaders   http.Header
+	requestSizeLimit uint
+	pingInterval     time.Duration
+	pongTimeout      time.Duration
+}
+
+// NewFWebSocketTransportBuilder creates a builder which configures and builds
+// WebSocket FTransport instances. If dialer is nil, websocket.DefaultDialer
+// is used.
+func NewFWebSocketTransportBuilder(dialer *websocket.Dialer, url string) *FWebSocketTransportBuilder {
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+	return &FWebSocketTransportBuilder{
+		dialer:       dialer,
+		url:          url,
+		pingInterval: defaultWebSocketPingInterval,
+		pongTimeout:  defaultWebSocketPongTimeout,
+	}
+}
+
+// WithRequestSizeLimit adds a request size limit. If set to 0 (the default),
+// there is no size limit on requests.
+func (w *FWebSocketTransportBuilder) WithRequestSizeLimit(requestSizeLimit uint) *FWebSocketTransportBuilder {
+	w.requestSizeLimit = requestSizeLimit
+	return w
+}
+
+// WithRequestHeaders adds HTTP headers to the WebSocket handshake request.
+func (w *FWebSocketTransportBuilder) WithRequestHeaders(requestHeaders http.Header) *FWebSocketTransportBuilder {
+	w.requestHeaders = requestHeaders
+	return w
+}
+
+// WithHealthCheck controls how often the transport pings the server and how
+// long it waits for a pong before considering the connection dead. A dead
+// connection is closed uncleanly, which triggers the FTransportMonitor, if
+// any. The defaults are a 10 second interval and a 30 second timeout.
+func (w *FWebSocketTransportBuilder) WithHealthCheck(pingInterval, pongTimeout time.Duration) *FWebSocketTransportBuilder {
+	w.pingInterval = pingInterval
+	w.pongTimeout = pongTimeout
+	return w
+}
+
+// Build a new configured WebSocket FTransport.
+func (w *FWebSocketTransportBuilder) Build() FWebSocketTransport {
+	return &fWebSocketTransport{
+		dialer:           w.dialer,
+		url:              w.url,
+		requestHeaders:   w.requestHeaders,
+		requestSizeLimit: w.requestSizeLimit,
+		pingInterval:     w.pingInterval,
+		pongTimeout:      w.pongTimeout,
+		registry:         newFRegistry(),
+		subscriptions:    make(map[uint32]*fWebSocketSubscriberTransport),
+	}
+}
+
+// fWebSocketTransport implements FWebSocketTransport.
+type fWebSocketTransport struct {
+	dialer             *websocket.Dialer
+	url                string
+	requestHeaders     http.Header
+	requestSizeLimit   uint
+	pingInterval       time.Duration
+	pongTimeout        time.Duration
+	registry           fRegistry
+	mu                 sync.RWMutex
+	writeMu            sync.Mutex
+	conn               *websocket.Conn
+	isOpen             bool
+	quit               chan struct{}
+	closeChan          chan error
+	monitorCloseSignal chan<- error
+	lastPong           time.Time
+	subMu              sync.Mutex
+	nextSubID          uint32
+	subscriptions      map[uint32]*fWebSocketSubscriberTransport
+}
+
+// Open dials the server and starts reading from the connection. Any scope
+// subscriptions made before a reconnect are restored.
+func (w *fWebSocketTransport) Open() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.isOpen {
+		return thrift.NewTTransportException(TRANSPORT_EXCEPTION_ALREADY_OPEN,
+			"frugal: transport already open")
+	}
+
+	conn, _, err := w.dialer.Dial(w.url, w.requestHeaders)
+	if err != nil {
+		return thrift.NewTTransportExceptionFromError(err)
+	}
+	conn.SetPongHandler(func(string) error {
+		w.mu.Lock()
+		w.lastPong = time.Now()
+		w.mu.Unlock()
+		return nil
+	})
+
+	w.conn = conn
+	w.isOpen = true
+	w.lastPong = time.Now()
+	w.quit = make(chan struct{})
+	w.closeChan = make(chan error, 1)
+	go w.readLoop(conn, w.quit)
+	if w.pingInterval > 0 {
+		go w.pingLoop(conn, w.quit)
+	}
+
+	w.subMu.Lock()
+	defer w.subMu.Unlock()
+	for id, sub := range w.subscriptions {
+		if err := w.writeMessage(conn, encodeWebSocketSubscribe(id, sub.topic)); err != nil {
+			logger().Warnf("frugal: unable to restore subscription to %s: %s", sub.topic, err)
+		}
+	}
+	return nil
+}
+
+// readLoop reads messages from the connection until it's closed.
+func (w *fWebSocketTransport) readLoop(conn *websocket.Conn, quit chan struct{}) {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			select {
+			case <-quit:
+				// Transport was closed.
+				return
+			default:
+			}
+
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				w.Close()
+				return
+			}
+
+			logger().Error("frugal: error reading WebSocket message, closing transport: ", err)
+			w.close(thrift.NewTTransportExceptionFromError(err))
+			return
+		}
+
+		if err := w.handleMessage(data); err != nil {
+			logger().Error("frugal: closing transport due to unrecoverable error processing message: ", err)
+			w.close(err)
+			return
+		}
+	}
+}
+
+// handleMessage dispatches a single message received from the server.
+func (w *fWebSocketTransport) handleMessage(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("frugal: empty WebSocket message")
+	}
+
+	switch data[0] {
+	case wsMessageFrame:
+		if len(data) < 5 {
+			return errors.New("frugal: invalid WebSocket frame")
+		}
+		return w.registry.Execute(data[5:]) // Discard message type and frame size
+	case wsMessageScope:
+		id, frame, err := decodeWebSocketScope(data)
+		if err != nil {
+			return err
+		}
+		w.subMu.Lock()
+		sub, ok := w.subscriptions[id]
+		w.subMu.Unlock()
+		if !ok {
+			logger().Warnf("frugal: discarding scope message for unknown subscription %d", id)
+			return nil
+		}
+		sub.enqueue(frame)
+	case wsMessageSubClosed:
+		id, reason, err := decodeWebSocketSubClosed(data)
+		if err != nil {
+			return err
+		}
+		w.subMu.Lock()
+		sub, ok := w.subscriptions[id]
+		delete(w.subscriptions, id)
+		w.subMu.Unlock()
+		if ok {
+			logger().Warnf("frugal: server closed subscription to %s: %s", sub.topic, reason)
+			sub.stop()
+		}
+	default:
+		logger().Warnf("frugal: discarding WebSocket message with unknown type %d", data[0])
+	}
+	return nil
+}
+
+// pingLoop pings the server on an interval and closes the transport if a pong
+// isn't received in time.
+func (w *fWebSocketTransport) pingLoop(conn *websocket.Conn, quit chan struct{}) {
+	ticker := time.NewTicker(w.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+		}
+
+		w.mu.RLock()
+		sincePong := time.Since(w.lastPong)
+		w.mu.RUnlock()
+		if w.pongTimeout > 0 && sincePong > w.pongTimeout {
+			w.close(thrift.NewTTransportException(TRANSPORT_EXCEPTION_TIMED_OUT,
+				fmt.Sprintf("frugal: no WebSocket pong received in %s", sincePong)))
+			return
+		}
+
+		deadline := time.Now().Add(webSocketWriteTimeout)
+		if err := conn.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+			w.close(thrift.NewTTransportExceptionFromError(err))
+			return
+		}
+	}
+}
+
+// IsOpen returns true if the transport is open, false otherwise.
+func (w *fWebSocketTransport) IsOpen() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.isOpen
+}
+
+// Close closes the transport.
+func (w *fWebSocketTransport) Close() error {
+	return w.close(nil)
+}
+
+func (w *fWebSocketTransport) close(cause error) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.isOpen {
+		return thrift.NewTTransportException(TRANSPORT_EXCEPTION_NOT_OPEN, "Transport not open")
+	}
+
+	close(w.quit)
+	if cause == nil {
+		deadline := time.Now().Add(webSocketWriteTimeout)
+		w.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), deadline)
+	}
+	w.conn.Close()
+
+	select {
+	case w.closeChan <- cause:
+	default:
+	}
+	close(w.closeChan)
+
+	if cause == nil {
+		logger().Debug("frugal: transport closed")
+	} else {
+		logger().Debugf("frugal: transport closed with cause: %s", cause)
+	}
+
+	// Signal transport monitor of close.
+	select {
+	case w.monitorCloseSignal <- cause:
+	default:
+	}
+
+	w.isOpen = false
+	return nil
+}
+
+// Oneway transmits the given data and doesn't wait for a response.
+// Implementations of oneway should be threadsafe and respect the timeout
+// present on the context.
+func (w *fWebSocketTransport) Oneway(ctx FContext, payload []byte) error {
+	if err := w.checkRequest(payload); err != nil {
+		return err
+	}
+	return w.send(append([]byte{wsMessageFrame}, payload...))
+}
+
+// Request transmits the given data and waits for a response.
+// Implementations of request should be threadsafe and respect the timeout
+// present on the context.
+func (w *fWebSocketTransport) Request(ctx FContext, payload []byte) (thrift.TTransport, error) {
+	if err := w.checkRequest(payload); err != nil {
+		return nil, err
+	}
+
+	w.mu.RLock()
+	quit := w.quit
+	w.mu.RUnlock()
+
+	resultC := make(chan []byte, 1)
+	if err := w.registry.Register(ctx, resultC); err != nil {
+		return nil, thrift.NewTTransportException(TRANSPORT_EXCEPTION_UNKNOWN, err.Error())
+	}
+	defer w.registry.Unregister(ctx)
+
+	if err := w.send(append([]byte{wsMessageFrame}, payload...)); err != nil {
+		return nil, err
+	}
+
+	select {
+	case result := <-resultC:
+		return &thrift.TMemoryBuffer{Buffer: bytes.NewBuffer(result)}, nil
+	case <-quit:
+		return nil, thrift.NewTTransportException(TRANSPORT_EXCEPTION_NOT_OPEN,
+			"frugal: WebSocket transport closed before a response was received")
+	case <-time.After(ctx.Timeout()):
+		return nil, thrift.NewTTransportException(TRANSPORT_EXCEPTION_TIMED_OUT, "frugal: request timed out")
+	}
+}
+
+func (w *fWebSocketTransport) checkRequest(payload []byte) error {
+	if !w.IsOpen() {
+		return thrift.NewTTransportException(TRANSPORT_EXCEPTION_NOT_OPEN,
+			"frugal: WebSocket transport not open")
+	}
+	if w.requestSizeLimit > 0 && len(payload) > int(w.requestSizeLimit) {
+		return thrift.NewTTransportException(
+			TRANSPORT_EXCEPTION_REQUEST_TOO_LARGE,
+			fmt.Sprintf("Message exceeds %d bytes, was %d bytes", w.requestSizeLimit, len(payload)))
+	}
+	return nil
+}
+
+// send writes the message to the current connection.
+func (w *fWebSocketTransport) send(message []byte) error {
+	w.mu.RLock()
+	conn := w.conn
+	isOpen := w.isOpen
+	w.mu.RUnlock()
+	if !isOpen {
+		return thrift.NewTTransportException(TRANSPORT_EXCEPTION_NOT_OPEN,
+			"frugal: WebSocket transport not open")
+	}
+	return w.writeMessage(conn, message)
+}
+
+func (w *fWebSocketTransport) writeMessage(conn *websocket.Conn, message []byte) error {
+	w.writeMu.Lock()
+	defer w.writeMu.Unlock()
+	conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout))
+	if err := conn.WriteMessage(websocket.BinaryMessage, message); err != nil {
+		return thrift.NewTTransportExceptionFromError(err)
+	}
+	return nil
+}
+
+// GetRequestSizeLimit returns the maximum number of bytes that can be
+// transmitted. Returns a non-positive number to indicate an unbounded
+// allowable size.
+func (w *fWebSocketTransport) GetRequestSizeLimit() uint {
+	return w.requestSizeLimit
+}
+
+// SetMonitor starts a monitor that can watch the health of, and reopen,
+// the transport.
+func (w *fWebSocketTransport) SetMonitor(monitor FTransportMonitor) {
+	// Stop the previous monitor, if any.
+	select {
+	case w.monitorCloseSignal <- nil:
+	default:
+	}
+
+	// Start the new monitor.
+	monitorClosedSignal := make(chan error, 1)
+	runner := &monitorRunner{
+		monitor:       monitor,
+		transport:     w,
+		closedChannel: monitorClosedSignal,
+	}
+	w.monitorCloseSignal = monitorClosedSignal
+	go runner.run()
+}
+
+// Closed channel receives the cause of an FTransport close (nil if clean
+// close).
+func (w *fWebSocketTransport) Closed() <-chan error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.closeChan
+}
+
+// PublisherTransportFactory returns an FPublisherTransportFactory which
+// produces FPublisherTransports that publish over this connection.
+func (w *fWebSocketTransport) PublisherTransportFactory() FPublisherTransportFactory {
+	return &fWebSocketPublisherTransportFactory{transport: w}
+}
+
+// SubscriberTransportFactory returns an FSubscriberTransportFactory which
+// produces FSubscriberTransports that subscribe over this connection.
+func (w *fWebSocketTransport) SubscriberTransportFactory() FSubscriberTransportFactory {
+	return &fWebSocketSubscriberTransportFactory{transport: w}
+}
+
+type fWebSocketPublisherTransportFactory struct {
+	transport *fWebSocketTransport
+}
+
+// GetTransport creates a new WebSocket FPublisherTransport.
+func (f *fWebSocketPublisherTransportFactory) GetTransport() FPublisherTransport {
+	return &fWebSocketPublisherTransport{transport: f.transport}
+}
+
+// fWebSocketPublisherTransport implements FPublisherTransport by sending
+// publishes over a shared WebSocket connection. The server forwards them to
+// its configured FPublisherTransport.
+type fWebSocketPublisherTransport struct {
+	transport *fWebSocketTransport
+}
+
+// Open is a no-op. The underlying FWebSocketTransport must be opened
+// separately.
+func (p *fWebSocketPublisherTransport) Open() error {
+	return nil
+}
+
+// IsOpen returns true if the underlying WebSocket connection is open.
+func (p *fWebSocketPublisherTransport) IsOpen() bool {
+	return p.transport.IsOpen()
+}
+
+// Close is a no-op. The underlying FWebSocketTransport must be closed
+// separately.
+func (p *fWebSocketPublisherTransport) Close() error {
+	return nil
+}
+
+// GetPublishSizeLimit returns the maximum allowable size of a payload
+// to be published. A non-positive number is returned to indicate an
+// unbounded allowable size.
+func (p *fWebSocketPublisherTransport) GetPublishSizeLimit() uint {
+	return p.transport.GetRequestSizeLimit()
+}
+
+// Publish sends the given payload with the transport.
+func (p *fWebSocketPublisherTransport) Publish(topic string, data []byte) error {
+	if err := p.transport.checkRequest(data); err != nil {
+		return err
+	}
+	return p.transport.send(encodeWebSocketPublish(topic, data))
+}
+
+type fWebSocketSubscriberTransportFactory struct {
+	transport *fWebSocketTransport
+}
+
+// GetTransport creates a new WebSocket FSubscriberTransport.
+func (f *fWebSocketSubscriberTransportFactory) GetTransport() FSubscriberTransport {
+	return &fWebSocketSubscriberTransport{transport: f.transport}
+}
+
+// fWebSocketSubscriberTransport implements FSubscriberTransport by asking the
+// server to subscribe on its behalf and forward messages over a shared
+// WebSocket connection. Messages are queued and handed to the callback on a
+// goroutine owned by the subscription, so a slow callback, or one which makes
+// requests over the same connection, doesn't stall the read loop.
+type fWebSocketSubscriberTransport struct {
+	transport    *fWebSocketTransport
+	id           uint32
+	topic        string
+	callback     FAsyncCallback
+	mu           sync.RWMutex
+	isSubscribed bool
+	messages     chan []byte
+	quit         chan struct{}
+}
+
+// Subscribe sets the subscribe topic and opens the transport.
+func (s *fWebSocketSubscriberTransport) Subscribe(topic string, callback FAsyncCallback) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.isSubscribed {
+		return thrift.NewTTransportException(TRANSPORT_EXCEPTION_ALREADY_OPEN,
+			"frugal: WebSocket subscriber transport already open")
+	}
+	if topic == "" {
+		return thrift.NewTTransportException(TRANSPORT_EXCEPTION_UNKNOWN,
+			"cannot subscribe to empty topic")
+	}
+
+	w := s.transport
+	w.subMu.Lock()
+	w.nextSubID++
+	s.id = w.nextSubID
+	s.topic = topic
+	s.callback = callback
+	s.messages = make(chan []byte, webSocketSubscriptionBuffer)
+	s.quit = make(chan struct{})
+	w.subscriptions[s.id] = s
+	w.subMu.Unlock()
+
+	if err := w.send(encodeWebSocketSubscribe(s.id, topic)); err != nil {
+		w.subMu.Lock()
+		delete(w.subscriptions, s.id)
+		w.subMu.Unlock()
+		return err
+	}
+	s.isSubscribed = true
+	go s.dispatch(s.messages, s.quit)
+	return nil
+}
+
+// Unsubscribe unsubscribes from the topic.
+func (s *fWebSocketSubscriberTransport) Unsubscribe() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isSubscribed {
+		return nil
+	}
+
+	w := s.transport
+	w.subMu.Lock()
+	delete(w.subscriptions, s.id)
+	w.subMu.Unlock()
+	s.isSubscribed = false
+	close(s.quit)
+
+	if !w.IsOpen() {
+		// The server drops subscriptions when the connection closes.
+		return nil
+	}
+	return w.send(encodeWebSocketUnsubscribe(s.id))
+}
+
+// IsSubscribed returns true if the transport is subscribed to a topic, false
+// otherwise.
+func (s *fWebSocketSubscriberTransport) IsSubscribed() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.isSubscribed && s.transport.IsOpen()
+}
+
+// stop marks the subscription closed after the server drops it and stops
+// dispatching messages to the callback.
+func (s *fWebSocketSubscriberTransport) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.isSubscribed {
+		return
+	}
+	s.isSubscribed = false
+	close(s.quit)
+}
+
+// enqueue queues the given frame for the callback. It doesn't block, so if
+// the callback has fallen too far behind the frame is dropped.
+func (s *fWebSocketSubscriberTransport) enqueue(frame []byte) {
+	s.mu.RLock()
+	messages := s.messages
+	s.mu.RUnlock()
+	select {
+	case messages <- frame:
+	default:
+		logger().Warnf("frugal: subscriber to %s is too slow, dropping scope message", s.topic)
+	}
+}
+
+// dispatch hands queued frames to the callback until quit is closed.
+func (s *fWebSocketSubscriberTransport) dispatch(messages chan []byte, quit chan struct{}) {
+	for {
+		select {
+		case <-quit:
+			return
+		case frame := <-messages:
+			s.handle(frame)
+		}
+	}
+}
+
+// handle invokes the callback with the given frame, which includes the frame
+// size.
+func (s *fWebSocketSubscriberTransport) handle(frame []byte) {
+	if len(frame) < 4 {
+		logger().Warn("frugal: Discarding invalid scope message frame")
+		return
+	}
+	transport := &thrift.TMemoryBuffer{Buffer: bytes.NewBuffer(frame[4:])}
+	if err := s.callback(transport); err != nil {
+		logger().Warn("frugal: error executing callback: ", err)
+	}
+}
+
+func encodeWebSocketSubscribe(id uint32, topic string) []byte {
+	message := make([]byte, 5, 5+len(topic))
+	message[0] = wsMessageSubscribe
+	binary.BigEndian.PutUint32(message[1:], id)
+	return append(message, topic...)
+}
+
+func encodeWebSocketUnsubscribe(id uint32) []byte {
+	message := make([]byte, 5)
+	message[0] = wsMessageUnsubscribe
+	binary.BigEndian.PutUint32(message[1:], id)
+	return message
+}
+
+func encodeWebSocketPublish(topic string, frame []byte) []byte {
+	message := make([]byte, 5, 5+len(topic)+len(frame))
+	message[0] = wsMessagePublish
+	binary.BigEndian.PutUint32(message[1:], uint32(len(topic)))
+	message = append(message, topic...)
+	return append(message, frame...)
+}
+
+func encodeWebSocketScope(id uint32, frame []byte) []byte {
+	message := make([]byte, 5, 5+len(frame))
+	message[0] = wsMessageScope
+	binary.BigEndian.PutUint32(message[1:], id)
+	return append(message, frame...)
+}
+
+func encodeWebSocketSubClosed(id uint32, reason string) []byte {
+	message := make([]byte, 5, 5+len(reason))
+	message[0] = wsMessageSubClosed
+	binary.BigEndian.PutUint32(message[1:], id)
+	return append(message, reason...)
+}
+
+// decodeWebSocketSubscription returns the subscription id and remaining bytes
+// of a subscribe, unsubscribe, scope, or subscription closed message.
+func decodeWebSocketSubscription(message []byte) (uint32, []byte, error) {
+	if len(message) < 5 {
+		return 0, nil, thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA,
+			errors.New("frugal: invalid WebSocket subscription message"))
+	}
+	return binary.BigEndian.Uint32(message[1:5]), message[5:], nil
+}
+
+func decodeWebSocketScope(message []byte) (uint32, []byte, error) {
+	return decodeWebSocketSubscription(message)
+}
+
+func decodeWebSocketSubClosed(message []byte) (uint32, string, error) {
+	id, reason, err := decodeWebSocketSubscription(message)
+	return id, string(reason), err
+}
+
+func decodeWebSocketPublish(message []byte) (string, []byte, error) {
+	if len(message) < 5 {
+		return "", nil, thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA,
+			errors.New("frugal: invalid WebSocket publish message"))
+	}
+	topicLen := binary.BigEndian.Uint32(message[1:5])
+	if uint64(len(message)-5) < uint64(topicLen) {
+		return "", nil, thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA,
+			errors.New("frugal: invalid WebSocket publish topic size"))
+	}
+	return string(message[5 : 5+topicLen]), message[5+topicLen:], nil
+}
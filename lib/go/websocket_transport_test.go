@@ -0,0 +1,356 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package frugal
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// echoFProcessor responds to every request with its request headers followed
+// by the given response payload.
+type echoFProcessor struct {
+	response []byte
+}
+
+func (e *echoFProcessor) Process(iprot, oprot *FProtocol) error {
+	ctx, err := iprot.ReadRequestHeader()
+	if err != nil {
+		return err
+	}
+	if err := oprot.WriteResponseHeader(ctx); err != nil {
+		return err
+	}
+	_, err = oprot.Transport().Write(e.response)
+	return err
+}
+
+func (e *echoFProcessor) AddMiddleware(middleware ServiceMiddleware) {}
+
+func (e *echoFProcessor) Annotations() map[string]map[string]string {
+	return nil
+}
+
+// signalingFTransportMonitor reports unclean closes on a channel and never
+// reopens the transport.
+type signalingFTransportMonitor struct {
+	BaseFTransportMonitor
+	closedUncleanly chan error
+}
+
+func (s *signalingFTransportMonitor) OnClosedUncleanly(cause error) (bool, time.Duration) {
+	s.closedUncleanly <- cause
+	return false, 0
+}
+
+func webSocketURL(server *httptest.Server) string {
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func newWebSocketTestServer(handler http.Handler) (*httptest.Server, FWebSocketTransport) {
+	server := httptest.NewServer(handler)
+	transport := NewFWebSocketTransportBuilder(nil, webSocketURL(server)).Build()
+	return server, transport
+}
+
+// Ensures Request sends the frame over the WebSocket and returns the response
+// correlated by operation id.
+func TestWebSocketTransportRequest(t *testing.T) {
+	protoFactory := NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault())
+	handler := NewFWebSocketHandlerBuilder(&echoFProcessor{response: []byte("world")}, protoFactory).Build()
+	server, tr := newWebSocketTestServer(handler)
+	defer server.Close()
+
+	assert.Nil(t, tr.Open())
+	assert.True(t, tr.IsOpen())
+	assert.Equal(t, uint(0), tr.GetRequestSizeLimit())
+
+	ctx := NewFContext("cid")
+	frame := scopeFrame(t, ctx, []byte("hello"))
+	resp, err := tr.Request(ctx, frame)
+	assert.Nil(t, err)
+	respProto := protoFactory.GetProtocol(resp)
+	respCtx := NewFContext("")
+	assert.Nil(t, respProto.ReadResponseHeader(respCtx))
+	payload, err := ioutil.ReadAll(resp)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("world"), payload)
+
+	assert.Nil(t, tr.Oneway(ctx, frame))
+
+	assert.Nil(t, tr.Close())
+	assert.False(t, tr.IsOpen())
+	assert.Nil(t, <-tr.Closed())
+}
+
+// Ensures Open returns an ALREADY_OPEN TTransportException if the transport is
+// already open and requests fail when the transport isn't open.
+func TestWebSocketTransportNotOpen(t *testing.T) {
+	server, tr := newWebSocketTestServer(NewFWebSocketHandlerBuilder(nil, nil).Build())
+	defer server.Close()
+
+	_, err := tr.Request(NewFContext(""), make([]byte, 10))
+	assert.Equal(t, TRANSPORT_EXCEPTION_NOT_OPEN, err.(thrift.TTransportException).TypeId())
+	err = tr.Close()
+	assert.Equal(t, TRANSPORT_EXCEPTION_NOT_OPEN, err.(thrift.TTransportException).TypeId())
+
+	assert.Nil(t, tr.Open())
+	err = tr.Open()
+	assert.Equal(t, TRANSPORT_EXCEPTION_ALREADY_OPEN, err.(thrift.TTransportException).TypeId())
+	assert.Nil(t, tr.Close())
+}
+
+// Ensures Open returns an error if the server can't be dialed.
+func TestWebSocketTransportOpenError(t *testing.T) {
+	tr := NewFWebSocketTransportBuilder(nil, "ws://localhost:1/nobody").Build()
+	assert.NotNil(t, tr.Open())
+	assert.False(t, tr.IsOpen())
+}
+
+// Ensures requests larger than the size limit are rejected.
+func TestWebSocketTransportRequestTooLarge(t *testing.T) {
+	server := httptest.NewServer(NewFWebSocketHandlerBuilder(nil, nil).Build())
+	defer server.Close()
+	tr := NewFWebSocketTransportBuilder(nil, webSocketURL(server)).
+		WithRequestSizeLimit(5).
+		WithRequestHeaders(http.Header{"foo": []string{"bar"}}).
+		Build()
+	assert.Nil(t, tr.Open())
+	defer tr.Close()
+
+	_, err := tr.Request(NewFContext(""), make([]byte, 10))
+	assert.True(t, IsErrTooLarge(err))
+}
+
+// Ensures Request times out if the server never responds.
+func TestWebSocketTransportRequestTimeout(t *testing.T) {
+	server, tr := newWebSocketTestServer(NewFWebSocketHandlerBuilder(nil, nil).Build())
+	defer server.Close()
+	assert.Nil(t, tr.Open())
+	defer tr.Close()
+
+	ctx := NewFContext("").SetTimeout(10 * time.Millisecond)
+	_, err := tr.Request(ctx, scopeFrame(t, ctx, nil))
+	assert.Equal(t, TRANSPORT_EXCEPTION_TIMED_OUT, err.(thrift.TTransportException).TypeId())
+}
+
+// Ensures an in-flight Request fails with NOT_OPEN when the transport is
+// closed rather than waiting for its timeout.
+func TestWebSocketTransportRequestClosed(t *testing.T) {
+	server, tr := newWebSocketTestServer(NewFWebSocketHandlerBuilder(nil, nil).Build())
+	defer server.Close()
+	assert.Nil(t, tr.Open())
+
+	errC := make(chan error, 1)
+	go func() {
+		ctx := NewFContext("").SetTimeout(time.Minute)
+		_, err := tr.Request(ctx, scopeFrame(t, ctx, nil))
+		errC <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	assert.Nil(t, tr.Close())
+
+	select {
+	case err := <-errC:
+		assert.Equal(t, TRANSPORT_EXCEPTION_NOT_OPEN, err.(thrift.TTransportException).TypeId())
+	case <-time.After(time.Second):
+		t.Fatal("Request did not return when the transport closed")
+	}
+}
+
+// Ensures the transport closes uncleanly and signals the monitor when the
+// server stops answering pings.
+func TestWebSocketTransportPongTimeout(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetPingHandler(func(string) error { return nil })
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	tr := NewFWebSocketTransportBuilder(nil, webSocketURL(server)).
+		WithHealthCheck(5*time.Millisecond, 20*time.Millisecond).
+		Build()
+	assert.Nil(t, tr.Open())
+
+	monitor := &signalingFTransportMonitor{closedUncleanly: make(chan error, 1)}
+	tr.SetMonitor(monitor)
+
+	closed := tr.Closed()
+	select {
+	case err := <-closed:
+		assert.Equal(t, TRANSPORT_EXCEPTION_TIMED_OUT, err.(thrift.TTransportException).TypeId())
+	case <-time.After(time.Second):
+		t.Fatal("Transport was not closed")
+	}
+	select {
+	case err := <-monitor.closedUncleanly:
+		assert.NotNil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Monitor was not signaled")
+	}
+	assert.False(t, tr.IsOpen())
+}
+
+// Ensures scope subscriptions and publishes are carried over the WebSocket and
+// bridged to the server's scope transports.
+func TestWebSocketTransportPubSub(t *testing.T) {
+	log := NewFMemoryPartitionedLog(1)
+	publisher := NewPartitionedLogFPublisherTransport(log, nil)
+	assert.Nil(t, publisher.Open())
+	handler := NewFWebSocketHandlerBuilder(nil, nil).
+		WithPublisherTransport(publisher).
+		WithSubscriberTransportFactory(NewFPartitionedLogSubscriberTransportFactory(log)).
+		Build()
+	server, tr := newWebSocketTestServer(handler)
+	defer server.Close()
+	assert.Nil(t, tr.Open())
+	defer tr.Close()
+
+	received := make(chan []byte, 1)
+	sub := tr.SubscriberTransportFactory().GetTransport()
+	assert.Nil(t, sub.Subscribe("foo", func(transport thrift.TTransport) error {
+		data, err := ioutil.ReadAll(transport)
+		assert.Nil(t, err)
+		received <- data
+		return nil
+	}))
+	assert.True(t, sub.IsSubscribed())
+	err := sub.Subscribe("foo", nil)
+	assert.Equal(t, TRANSPORT_EXCEPTION_ALREADY_OPEN, err.(thrift.TTransportException).TypeId())
+
+	pub := tr.PublisherTransportFactory().GetTransport()
+	assert.Nil(t, pub.Open())
+	assert.True(t, pub.IsOpen())
+	assert.Equal(t, uint(0), pub.GetPublishSizeLimit())
+
+	// The server subscribes asynchronously, so publish until it's received.
+	deadline := time.After(time.Second)
+	for done := false; !done; {
+		assert.Nil(t, pub.Publish("foo", []byte{0, 0, 0, 3, 1, 2, 3}))
+		select {
+		case data := <-received:
+			assert.Equal(t, []byte{1, 2, 3}, data)
+			done = true
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("Subscriber was not called")
+		}
+	}
+
+	assert.Nil(t, sub.Unsubscribe())
+	assert.False(t, sub.IsSubscribed())
+	assert.Nil(t, pub.Close())
+}
+
+// Ensures a subscriber callback can make a request over the same transport
+// without blocking the read loop which delivers the response.
+func TestWebSocketTransportSubscriberRequest(t *testing.T) {
+	log := NewFMemoryPartitionedLog(1)
+	publisher := NewPartitionedLogFPublisherTransport(log, nil)
+	assert.Nil(t, publisher.Open())
+	protoFactory := NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault())
+	handler := NewFWebSocketHandlerBuilder(&echoFProcessor{response: []byte("world")}, protoFactory).
+		WithPublisherTransport(publisher).
+		WithSubscriberTransportFactory(NewFPartitionedLogSubscriberTransportFactory(log)).
+		Build()
+	server, tr := newWebSocketTestServer(handler)
+	defer server.Close()
+	assert.Nil(t, tr.Open())
+	defer tr.Close()
+
+	responses := make(chan error, 1)
+	sub := tr.SubscriberTransportFactory().GetTransport()
+	assert.Nil(t, sub.Subscribe("foo", func(thrift.TTransport) error {
+		ctx := NewFContext("").SetTimeout(time.Second)
+		_, err := tr.Request(ctx, scopeFrame(t, ctx, []byte("hello")))
+		select {
+		case responses <- err:
+		default:
+		}
+		return nil
+	}))
+	defer sub.Unsubscribe()
+
+	pub := tr.PublisherTransportFactory().GetTransport()
+	deadline := time.After(2 * time.Second)
+	for done := false; !done; {
+		assert.Nil(t, pub.Publish("foo", []byte{0, 0, 0, 0}))
+		select {
+		case err := <-responses:
+			assert.Nil(t, err)
+			done = true
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("Subscriber request did not complete")
+		}
+	}
+}
+
+// Ensures the client marks a subscription closed if the server rejects it.
+func TestWebSocketTransportSubscriptionRejected(t *testing.T) {
+	server, tr := newWebSocketTestServer(NewFWebSocketHandlerBuilder(nil, nil).Build())
+	defer server.Close()
+	assert.Nil(t, tr.Open())
+	defer tr.Close()
+
+	sub := tr.SubscriberTransportFactory().GetTransport()
+	assert.Nil(t, sub.Subscribe("foo", func(thrift.TTransport) error { return nil }))
+	end := time.Now().Add(time.Second)
+	for sub.IsSubscribed() && time.Now().Before(end) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.False(t, sub.IsSubscribed())
+}
+
+// Ensures WebSocket messages are encoded and decoded symmetrically.
+func TestWebSocketMessageEncoding(t *testing.T) {
+	topic, frame, err := decodeWebSocketPublish(encodeWebSocketPublish("foo", []byte{1, 2}))
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", topic)
+	assert.Equal(t, []byte{1, 2}, frame)
+
+	_, _, err = decodeWebSocketPublish([]byte{wsMessagePublish, 0, 0, 0, 9, 'a'})
+	assert.NotNil(t, err)
+
+	id, payload, err := decodeWebSocketSubscription(encodeWebSocketSubscribe(7, "bar"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(7), id)
+	assert.Equal(t, "bar", string(payload))
+
+	id, reason, err := decodeWebSocketSubClosed(encodeWebSocketSubClosed(8, "nope"))
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(8), id)
+	assert.Equal(t, "nope", reason)
+
+	_, _, err = decodeWebSocketScope([]byte{wsMessageScope})
+	assert.NotNil(t, err)
+}
@@ -41,4 +41,9 @@ public class TApplicationExceptionType {
      * Indicates the response was too large for the transport.
      */
     public static final int RESPONSE_TOO_LARGE = 100;
+
+    /**
+     * Indicates the request arguments failed validation.
+     */
+    public static final int VALIDATION_ERROR = 101;
 }
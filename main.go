@@ -1,18 +1,31 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"os/exec"
 	"sort"
 
 	"github.com/Workiva/frugal/compiler"
+	"github.com/Workiva/frugal/compiler/format"
 	"github.com/Workiva/frugal/compiler/generator"
 	"github.com/Workiva/frugal/compiler/globals"
+	"github.com/Workiva/frugal/compiler/lint"
+	"github.com/Workiva/frugal/compiler/lsp"
 	"github.com/Workiva/frugal/compiler/parser"
 	"github.com/urfave/cli"
 )
 
-const defaultTopicDelim = "."
+const (
+	defaultTopicDelim  = "."
+	defaultLintConfig  = ".frugal-lint.yml"
+	defaultAuditPolicy = ".frugal-audit.yml"
+	auditFormatJUnit   = "junit"
+	cliCommand         = "frugal-cli"
+	cliPackage         = "github.com/Workiva/frugal/lib/go/cmd/frugal-cli"
+)
 
 var (
 	help    bool
@@ -20,6 +33,7 @@ var (
 	out     string
 	delim   string
 	audit   string
+	diags   string
 	recurse bool
 	verbose bool
 	version bool
@@ -74,6 +88,145 @@ func main() {
 			Usage:       "frugal file to run audit against",
 			Destination: &audit,
 		},
+		cli.StringFlag{
+			Name:        "diagnostics",
+			Value:       compiler.DiagnosticsText,
+			Usage:       "set the format of errors and warnings found in frugal files (text or json)",
+			Destination: &diags,
+		},
+	}
+
+	app.Commands = []cli.Command{
+		{
+			Name:  "lsp",
+			Usage: "run a language server for frugal files over stdin and stdout",
+			Action: func(c *cli.Context) error {
+				if err := lsp.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				return nil
+			},
+		},
+		{
+			Name:      "fmt",
+			Usage:     "rewrite frugal files in canonical form",
+			ArgsUsage: "file...",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "check",
+					Usage: "list files which aren't formatted instead of rewriting them and exit non-zero if there are any",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if len(c.Args()) == 0 {
+					fmt.Printf("Usage: %s fmt [-check] file...\n", app.Name)
+					os.Exit(1)
+				}
+				if !formatFiles(c.Args(), c.Bool("check")) {
+					os.Exit(1)
+				}
+				return nil
+			},
+		},
+		{
+			Name:      "lint",
+			Usage:     "check frugal files for style and correctness problems",
+			ArgsUsage: "file...",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "config",
+					Usage: "set the lint config file (default: " + defaultLintConfig + " if it exists)",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if len(c.Args()) == 0 {
+					fmt.Printf("Usage: %s lint [-config file] file...\n", app.Name)
+					os.Exit(1)
+				}
+				if !lintFiles(c.Args(), c.String("config")) {
+					os.Exit(1)
+				}
+				return nil
+			},
+		},
+		{
+			Name:      "audit",
+			Usage:     "check frugal files for breaking changes and report them",
+			ArgsUsage: "file|dir...",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "against",
+					Usage: "audit against this frugal file, or directory if auditing directories",
+				},
+				cli.StringFlag{
+					Name:  "rev",
+					Usage: "audit against the files as of this git revision",
+				},
+				cli.StringFlag{
+					Name:  "format",
+					Value: compiler.DiagnosticsText,
+					Usage: "set the report format (text, json, or junit)",
+				},
+				cli.StringFlag{
+					Name:  "policy",
+					Usage: "set the audit policy file (default: " + defaultAuditPolicy + " if it exists)",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				against, rev := c.String("against"), c.String("rev")
+				if len(c.Args()) == 0 || (against == "") == (rev == "") {
+					fmt.Printf("Usage: %s audit (-against file|dir | -rev revision) [-format text|json|junit] [-policy file] file|dir...\n", app.Name)
+					os.Exit(1)
+				}
+				switch format := c.String("format"); format {
+				case compiler.DiagnosticsText, compiler.DiagnosticsJSON, auditFormatJUnit:
+				default:
+					fmt.Printf("Invalid report format: %s\n", format)
+					os.Exit(1)
+				}
+				if !auditFiles(c.Args(), against, rev, c.String("format"), c.String("policy")) {
+					os.Exit(1)
+				}
+				return nil
+			},
+		},
+		{
+			Name:            "call",
+			Usage:           "call a method of a service with JSON arguments and print the result (run with -h for flags)",
+			ArgsUsage:       "file Service.method [json|-]",
+			SkipFlagParsing: true,
+			Action: func(c *cli.Context) error {
+				if !runCLI(c.Command.Name, c.Args()) {
+					os.Exit(1)
+				}
+				return nil
+			},
+		},
+		{
+			Name:            "publish",
+			Usage:           "publish a JSON value to an operation of a scope (run with -h for flags)",
+			ArgsUsage:       "file Scope.Operation json|-",
+			SkipFlagParsing: true,
+			Action: func(c *cli.Context) error {
+				if !runCLI(c.Command.Name, c.Args()) {
+					os.Exit(1)
+				}
+				return nil
+			},
+		},
+		{
+			Name:            "subscribe",
+			Usage:           "print the values published to an operation of a scope (run with -h for flags)",
+			ArgsUsage:       "file Scope.Operation",
+			SkipFlagParsing: true,
+			Action: func(c *cli.Context) error {
+				if !runCLI(c.Command.Name, c.Args()) {
+					os.Exit(1)
+				}
+				return nil
+			},
+		},
 	}
 
 	app.Action = func(c *cli.Context) error {
@@ -100,12 +253,18 @@ func main() {
 			os.Exit(1)
 		}
 
+		if diags != compiler.DiagnosticsText && diags != compiler.DiagnosticsJSON {
+			fmt.Printf("Invalid diagnostics format: %s\n", diags)
+			os.Exit(1)
+		}
+
 		options := compiler.Options{
-			Gen:     gen,
-			Out:     out,
-			Delim:   delim,
-			Recurse: recurse,
-			Verbose: verbose,
+			Gen:         gen,
+			Out:         out,
+			Delim:       delim,
+			Recurse:     recurse,
+			Verbose:     verbose,
+			Diagnostics: diags,
 		}
 
 		// Handle panics for graceful error messages.
@@ -125,7 +284,7 @@ func main() {
 				err = auditor.Audit(audit, options.File)
 			}
 			if err != nil {
-				fmt.Printf("Failed to generate %s:\n\t%s\n", options.File, err.Error())
+				printError("generate", options.File, err)
 				os.Exit(1)
 			}
 		}
@@ -136,6 +295,178 @@ func main() {
 	app.Run(os.Args)
 }
 
+// printError prints the error which caused the given file to fail. Diagnostics
+// are printed with their source lines, and every error is printed as
+// Diagnostics in JSON mode so tools only need to handle one format.
+func printError(action, file string, err error) {
+	d, ok := err.(parser.Diagnostics)
+	if !ok && diags == compiler.DiagnosticsJSON {
+		d = parser.Diagnostics{{Severity: parser.SeverityError, Message: err.Error()}}
+		ok = true
+	}
+	if !ok {
+		fmt.Printf("Failed to %s %s:\n\t%s\n", action, file, err.Error())
+		return
+	}
+	if diags == compiler.DiagnosticsText {
+		fmt.Printf("Failed to %s %s:\n", action, file)
+	}
+	compiler.PrintDiagnostics(os.Stdout, d, diags)
+}
+
+// runCLI runs the given command of frugal-cli, which calls services and
+// publishes and subscribes to scopes. It's a separate binary since it depends
+// on the Go library. It returns false if the command failed.
+func runCLI(command string, args []string) bool {
+	path, err := exec.LookPath(cliCommand)
+	if err != nil {
+		fmt.Printf("The %s command requires %s, install it with: go get %s\n", command, cliCommand, cliPackage)
+		return false
+	}
+	cmd := exec.Command(path, append([]string{command}, args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			fmt.Printf("Failed to run %s: %s\n", cliCommand, err)
+		}
+		return false
+	}
+	return true
+}
+
+// formatFiles rewrites the given files in canonical form, or lists those which
+// aren't if check is set. It returns false if any couldn't be formatted or, if
+// checking, aren't formatted.
+func formatFiles(files []string, check bool) bool {
+	ok := true
+	for _, file := range files {
+		formatted, err := format.File(file)
+		if err != nil {
+			printError("format", file, err)
+			ok = false
+			continue
+		}
+
+		original, err := ioutil.ReadFile(file)
+		if err != nil {
+			fmt.Printf("Failed to format %s:\n\t%s\n", file, err)
+			ok = false
+			continue
+		}
+		if bytes.Equal(original, formatted) {
+			continue
+		}
+		if check {
+			fmt.Println(file)
+			ok = false
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err == nil {
+			err = ioutil.WriteFile(file, formatted, info.Mode())
+		}
+		if err != nil {
+			fmt.Printf("Failed to format %s:\n\t%s\n", file, err)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// lintFiles prints the problems found in the given files by the rules
+// configured in the given config file. It returns false if any are errors.
+func lintFiles(files []string, configFile string) bool {
+	if configFile == "" {
+		if _, err := os.Stat(defaultLintConfig); err == nil {
+			configFile = defaultLintConfig
+		}
+	}
+	var config *lint.Config
+	if configFile != "" {
+		var err error
+		if config, err = lint.LoadConfig(configFile); err != nil {
+			fmt.Println(err)
+			return false
+		}
+	}
+
+	ok := true
+	for _, file := range files {
+		frugal, err := parser.ParseFrugal(file)
+		if err != nil {
+			printError("lint", file, err)
+			ok = false
+			continue
+		}
+		problems := lint.Lint(frugal, config)
+		if problems.HasErrors() {
+			ok = false
+		}
+		compiler.PrintDiagnostics(os.Stdout, problems, diags)
+	}
+	return ok
+}
+
+// auditFiles audits the given files or directories against another file or
+// directory, or a git revision, and prints a report in the given format. It
+// returns false if any files couldn't be audited or any breaking changes were
+// found.
+func auditFiles(files []string, against, rev, format, policyFile string) bool {
+	if policyFile == "" {
+		if _, err := os.Stat(defaultAuditPolicy); err == nil {
+			policyFile = defaultAuditPolicy
+		}
+	}
+	var policy *parser.AuditPolicy
+	if policyFile != "" {
+		var err error
+		if policy, err = parser.LoadAuditPolicy(policyFile); err != nil {
+			fmt.Println(err)
+			return false
+		}
+	}
+
+	auditor := parser.NewAuditorWithPolicy(nil, policy)
+	for _, file := range files {
+		var err error
+		switch {
+		case rev != "":
+			err = auditor.AuditRevision(rev, file)
+		case isDir(against):
+			if !isDir(file) {
+				err = fmt.Errorf("can't audit a file against directory %s", against)
+				break
+			}
+			err = auditor.AuditTree(against, file)
+		default:
+			err = auditor.Audit(against, file)
+		}
+		if _, failed := err.(*parser.AuditError); err != nil && !failed {
+			printError("audit", file, err)
+			return false
+		}
+	}
+
+	report := auditor.Report()
+	switch format {
+	case compiler.DiagnosticsJSON:
+		report.WriteJSON(os.Stdout)
+	case auditFormatJUnit:
+		report.WriteJUnit(os.Stdout, policy)
+	default:
+		compiler.PrintDiagnostics(os.Stdout, report.Diagnostics(), compiler.DiagnosticsText)
+	}
+	return !report.Failed()
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
 func genUsage() string {
 	usage := "generate code with a registered generator and optional parameters " +
 		"(lang[:key1=val1[,key2[,key3=val3]]])\n"
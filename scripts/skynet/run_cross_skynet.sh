@@ -36,9 +36,20 @@ go run scripts/skynet/cross/cross_setup.go
 # without cleaning up
 cd ${FRUGAL_HOME}/test/integration
 
-if go run main.go --tests tests.json --outDir log; then
-    /testing/scripts/skynet/test_cleanup.sh
-else
-    /testing/scripts/skynet/test_cleanup.sh
-    exit 1
-fi
+status=0
+go run main.go --tests tests.json --outDir log || status=1
+
+# Run the cross tests again with Go code generated with the fast_codec option,
+# so both the default and fast Go codecs are covered
+cd ${FRUGAL_HOME}
+rm -rf test/integration/go/gen/*
+frugal --gen go:package_prefix=github.com/Workiva/frugal/,fast_codec -r --out='test/integration/go/gen' test/integration/frugalTest.frugal
+cd ${FRUGAL_HOME}/test/integration/go
+go build -o bin/testclient src/bin/testclient/main.go
+go build -o bin/testserver src/bin/testserver/main.go
+
+cd ${FRUGAL_HOME}/test/integration
+go run main.go --tests tests.json --outDir log/fast_codec || status=1
+
+/testing/scripts/skynet/test_cleanup.sh
+exit ${status}
@@ -0,0 +1,60 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Workiva/frugal/compiler"
+)
+
+func TestAsyncAPI(t *testing.T) {
+	options := compiler.Options{
+		File:  "idl/asyncapi/events.frugal",
+		Gen:   "asyncapi",
+		Out:   filepath.Join(outputDir, "asyncapi"),
+		Delim: delim,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/asyncapi/events.Orders.asyncapi.json", filepath.Join(outputDir, "asyncapi", "events.Orders.asyncapi.json")},
+		{"expected/asyncapi/events.Heartbeats.asyncapi.json", filepath.Join(outputDir, "asyncapi", "events.Heartbeats.asyncapi.json")},
+	}
+
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
+
+func TestAsyncAPIYAML(t *testing.T) {
+	options := compiler.Options{
+		File:  "idl/asyncapi/events.frugal",
+		Gen:   "asyncapi:yaml,channel_prefix=",
+		Out:   filepath.Join(outputDir, "asyncapi"),
+		Delim: "/",
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/asyncapi/events.Orders.asyncapi.yaml", filepath.Join(outputDir, "asyncapi", "events.Orders.asyncapi.yaml")},
+	}
+
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
@@ -14,7 +14,14 @@
 package test
 
 import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -26,6 +33,14 @@ const (
 	testFileThrift = "idl/breaking_changes/test.thrift"
 	testWarning    = "idl/breaking_changes/warning.thrift"
 	scopeFile      = "idl/breaking_changes/scope.frugal"
+	streamFile     = "idl/breaking_changes/stream.frugal"
+	auditPolicy    = "idl/breaking_changes/policy.yml"
+	auditOld       = "idl/audit/v1"
+	auditNew       = "idl/audit/v2"
+	wireOld        = "idl/breaking_changes/wire/old/service.frugal"
+	wireNew        = "idl/breaking_changes/wire/new/service.frugal"
+	removedOld     = "idl/breaking_changes/removed/old/album.frugal"
+	removedNew     = "idl/breaking_changes/removed/new/album.frugal"
 )
 
 type MockValidationLogger struct {
@@ -138,3 +153,232 @@ func TestScopeBreakingChanges(t *testing.T) {
 		}
 	}
 }
+
+// Ensures adding or removing the stream modifier or request stream of a method
+// is reported.
+func TestStreamBreakingChanges(t *testing.T) {
+	logger := &MockValidationLogger{}
+	auditor := parser.NewAuditorWithLogger(logger)
+	if err := auditor.Audit(streamFile, "idl/breaking_changes/stream1.frugal"); err == nil {
+		t.Fatal("No errors found")
+	}
+	assert.Equal(t, "service Store: method listAlbums: stream modifier changed", logger.errors[0])
+
+	logger = &MockValidationLogger{}
+	auditor = parser.NewAuditorWithLogger(logger)
+	if err := auditor.Audit(streamFile, "idl/breaking_changes/stream2.frugal"); err == nil {
+		t.Fatal("No errors found")
+	}
+	assert.Equal(t, "service Store: method addAlbums: request stream removed", logger.errors[0])
+}
+
+// Ensures the report records each change's rule, location, and values.
+func TestAuditReport(t *testing.T) {
+	auditor := parser.NewAuditorWithPolicy(nil, nil)
+	err := auditor.Audit(testFileThrift, "idl/breaking_changes/break2.thrift")
+	assert.IsType(t, &parser.AuditError{}, err)
+
+	report := auditor.Report()
+	assert.Equal(t, []*parser.AuditedFile{{Old: testFileThrift, New: "idl/breaking_changes/break2.thrift"}}, report.Files)
+	assert.Len(t, report.Findings, 1)
+	finding := report.Findings[0]
+	assert.Equal(t, "type-changed", finding.Rule)
+	assert.Equal(t, parser.SeverityError, finding.Severity)
+	assert.Equal(t, "test_struct1.struct1_member1", finding.Subject)
+	assert.Equal(t, "idl/breaking_changes/break2.thrift", finding.Pos.File)
+	assert.True(t, finding.Pos.IsValid())
+	assert.Equal(t, "i16", finding.Old)
+	assert.Equal(t, "i32", finding.New)
+	assert.True(t, report.Failed())
+
+	// Removed definitions are located in the old file.
+	auditor = parser.NewAuditorWithPolicy(nil, nil)
+	auditor.Audit(testFileThrift, "idl/breaking_changes/break1.thrift")
+	finding = auditor.Report().Findings[0]
+	assert.Equal(t, "method-removed", finding.Rule)
+	assert.Equal(t, "base.base_function3", finding.Subject)
+	assert.Equal(t, testFileThrift, finding.Pos.File)
+}
+
+// Ensures a policy can change the severity of rules and suppress changes.
+func TestAuditPolicy(t *testing.T) {
+	policy, err := parser.LoadAuditPolicy(auditPolicy)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	logger := &MockValidationLogger{}
+	auditor := parser.NewAuditorWithPolicy(logger, policy)
+	assert.Error(t, auditor.Audit(testFileThrift, testWarning))
+
+	assert.Len(t, logger.errors, 6)
+	assert.Equal(t, []string{"constant value changed: const3"}, logger.warnings)
+	suppressed := []string{}
+	for _, finding := range auditor.Report().Findings {
+		assert.NotEqual(t, "field-renamed", finding.Rule)
+		if finding.Suppressed {
+			suppressed = append(suppressed, finding.Subject+" "+finding.Reason)
+		}
+	}
+	assert.Equal(t, []string{
+		"const2 ",
+		"test_struct3.struct3_member1 test_struct3 isn't sent over the network yet",
+		"test_struct3.struct3_member2 test_struct3 isn't sent over the network yet",
+	}, suppressed)
+
+	assert.Error(t, (&parser.AuditPolicy{Rules: map[string]string{"no-such-rule": "error"}}).Validate())
+	assert.Error(t, (&parser.AuditPolicy{Rules: map[string]string{"field-renamed": "fatal"}}).Validate())
+	assert.Error(t, (&parser.AuditPolicy{Suppressions: []*parser.AuditSuppression{{Reason: "everything"}}}).Validate())
+}
+
+// Ensures every file in a tree is audited with includes resolved within it.
+func TestAuditTree(t *testing.T) {
+	auditor := parser.NewAuditorWithPolicy(nil, nil)
+	assert.IsType(t, &parser.AuditError{}, auditor.AuditTree(auditOld, auditNew))
+	assert.Equal(t, []string{
+		"idl/audit/v2/base.frugal type-changed Meta.id string i64",
+		"idl/audit/v2/base.frugal required-field-added Meta.owner  3",
+		"idl/audit/v2/legacy.frugal file-removed legacy.frugal legacy.frugal ",
+		"idl/audit/v2/music.frugal type-changed Album.id string i64",
+		"idl/audit/v2/music.frugal struct-incompatible Album.meta base.Meta base.Meta",
+	}, auditFindings(auditor.Report()))
+}
+
+// Ensures files are audited against their contents at a git revision.
+func TestAuditRevision(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git isn't installed")
+	}
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	defer os.RemoveAll(dir)
+	copyFile := func(src string) {
+		contents, err := ioutil.ReadFile(src)
+		if err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, filepath.Base(src)), contents, 0644); err != nil {
+			t.Fatal("Unexpected error", err)
+		}
+	}
+	runGit := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s failed: %s", args, out)
+		}
+	}
+
+	copyFile(filepath.Join(auditOld, "base.frugal"))
+	copyFile(filepath.Join(auditOld, "music.frugal"))
+	runGit("init", "-q")
+	runGit("add", "-A")
+	runGit("commit", "-q", "-m", "v1")
+	copyFile(filepath.Join(auditNew, "base.frugal"))
+
+	auditor := parser.NewAuditorWithPolicy(nil, nil)
+	music := filepath.Join(dir, "music.frugal")
+	assert.IsType(t, &parser.AuditError{}, auditor.AuditRevision("HEAD", music))
+	report := auditor.Report()
+	assert.Equal(t, []*parser.AuditedFile{{Old: "HEAD:music.frugal", New: music}}, report.Files)
+	assert.Equal(t, []string{
+		music + " type-changed Album.id string i64",
+		music + " struct-incompatible Album.meta base.Meta base.Meta",
+	}, auditFindings(report))
+}
+
+// Ensures reports are written as JSON and JUnit XML.
+func TestAuditReportFormats(t *testing.T) {
+	auditor := parser.NewAuditorWithPolicy(nil, nil)
+	auditor.AuditTree(auditOld, auditNew)
+	report := auditor.Report()
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, report.WriteJSON(buf))
+	decoded := &parser.AuditReport{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), decoded))
+	assert.Equal(t, report, decoded)
+
+	buf.Reset()
+	policy := &parser.AuditPolicy{Rules: map[string]string{"file-removed": "off"}}
+	assert.NoError(t, report.WriteJUnit(buf, policy))
+	suites := struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Suites   []struct {
+			Name  string `xml:"name,attr"`
+			Cases []struct {
+				Name    string    `xml:"name,attr"`
+				Failure *struct{} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}{}
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &suites))
+	rules := len(parser.AuditRules()) - 1
+	assert.Equal(t, 3*rules, suites.Tests)
+	assert.Equal(t, 4, suites.Failures)
+	assert.Len(t, suites.Suites, 3)
+	failed := []string{}
+	for _, suite := range suites.Suites {
+		assert.Len(t, suite.Cases, rules)
+		for _, c := range suite.Cases {
+			if c.Failure != nil {
+				failed = append(failed, suite.Name+" "+c.Name)
+			}
+		}
+	}
+	assert.Equal(t, []string{
+		"idl/audit/v2/base.frugal required-field-added",
+		"idl/audit/v2/base.frugal type-changed",
+		"idl/audit/v2/music.frugal struct-incompatible",
+		"idl/audit/v2/music.frugal type-changed",
+	}, failed)
+}
+
+func auditFindings(report *parser.AuditReport) []string {
+	findings := []string{}
+	for _, f := range report.Findings {
+		findings = append(findings, strings.Join([]string{f.File, f.Rule, f.Subject, f.Old, f.New}, " "))
+	}
+	return findings
+}
+
+// Ensures changes are checked by how types are encoded, with typedefs and
+// includes resolved.
+func TestAuditWireCompatibility(t *testing.T) {
+	auditor := parser.NewAuditorWithPolicy(nil, nil)
+	assert.IsType(t, &parser.AuditError{}, auditor.Audit(wireOld, wireNew))
+	changes := []string{}
+	for _, f := range auditor.Report().Findings {
+		changes = append(changes, fmt.Sprintf("%s %s %s: %s", f.Severity, f.Rule, f.Subject, f.Message))
+		assert.NotEmpty(t, f.Explanation)
+	}
+	assert.Equal(t, []string{
+		"error struct-incompatible Events.Published: scope Events: operation Published: type 'types.Payload' changed incompatibly: field body types not equal: 'string' -> 'i64'",
+		"warning wire-compatible-type-changed Request.level: struct Request: field level: types not equal: 'Level' -> 'i32', but both are encoded as i32",
+		"warning wire-compatible-type-changed Request.data: struct Request: field data: types not equal: 'binary' -> 'string', but both are encoded as string",
+		"error struct-kind-changed Request.shape: struct Request: field shape: type kind changed: struct 'types.Shape' -> union 'types.Shape'",
+		"error type-moved Request.tag: struct Request: field tag: type moved: 'types.Tag' -> 'other.Tag'",
+		"error exception-removed Store.get.forbidden: service Store: method get: exception forbidden removed with ID=2",
+		"warning exception-added Store.get.unavailable: service Store: method get: exception unavailable added with ID=3",
+		"error oneway-changed Store.ping: service Store: method ping: one way modifier changed",
+	}, changes)
+}
+
+// Ensures removed fields are reported by their presence, with optional fields
+// not reported since readers built from the old version handle them unset.
+func TestAuditFieldRemoved(t *testing.T) {
+	auditor := parser.NewAuditorWithPolicy(nil, nil)
+	assert.IsType(t, &parser.AuditError{}, auditor.Audit(removedOld, removedNew))
+	changes := []string{}
+	for _, f := range auditor.Report().Findings {
+		changes = append(changes, fmt.Sprintf("%s %s %s: %s", f.Severity, f.Rule, f.Subject, f.Explanation))
+	}
+	assert.Equal(t, []string{
+		"error required-field-removed Album.id: " + parser.AuditRuleExplanation("required-field-removed"),
+		"error field-removed Album.title: " + parser.AuditRuleExplanation("field-removed"),
+	}, changes)
+	assert.Contains(t, parser.AuditRuleExplanation("required-field-removed"), "reject messages without it")
+	assert.Contains(t, parser.AuditRuleExplanation("field-removed"), "accept messages without the field")
+}
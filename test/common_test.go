@@ -37,6 +37,14 @@ const (
 	includeVendor           = "idl/include_vendor.frugal"
 	includeVendorNoPath     = "idl/include_vendor_no_path.frugal"
 	vendorNamespace         = "idl/vendor_namespace.frugal"
+	streamingFile           = "idl/streaming.frugal"
+	onewayStream            = "idl/oneway_stream.frugal"
+	onewayRequestStream     = "idl/oneway_request_stream.frugal"
+	syntaxErrors            = "idl/syntax_errors.frugal"
+	validationErrors        = "idl/validation_errors.frugal"
+	constraintErrors        = "idl/constraint_errors.frugal"
+	enumValueWarning        = "idl/enum_value_warning.frugal"
+	unformattedFile         = "idl/unformatted.frugal"
 )
 
 var copyFiles bool
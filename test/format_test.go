@@ -0,0 +1,114 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/Workiva/frugal/compiler/format"
+	"github.com/Workiva/frugal/compiler/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	formatted, err := format.File(unformattedFile)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	generated := filepath.Join(outputDir, "format", "unformatted.frugal")
+	if err := os.MkdirAll(filepath.Dir(generated), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(generated, formatted, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/format/unformatted.frugal", generated},
+	}
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
+
+func TestFormatIdempotent(t *testing.T) {
+	files := []string{unformattedFile, validFile, frugalGenFile, streamingFile, includeVendor, vendorNamespace}
+	for _, file := range files {
+		formatted, err := format.File(file)
+		if err != nil {
+			t.Fatalf("Unexpected error formatting %s: %s", file, err)
+		}
+
+		// Formatting the formatted file shouldn't change it or its meaning.
+		frugal, err := parser.ParseFrugalFrom(file, func(path string) ([]byte, error) {
+			if path == file {
+				return formatted, nil
+			}
+			return ioutil.ReadFile(path)
+		})
+		if err != nil {
+			t.Fatalf("Formatted %s doesn't parse: %s", file, err)
+		}
+		original, err := parser.ParseFrugal(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, string(formatted), string(format.Source(frugal, formatted)), file)
+		assert.Equal(t, definitionNames(original), definitionNames(frugal), file)
+	}
+}
+
+func TestFormatInvalid(t *testing.T) {
+	if _, err := format.File(syntaxErrors); err == nil {
+		t.Fatal("Expected error")
+	}
+}
+
+// definitionNames returns the sorted names of everything defined in a Frugal
+// file.
+func definitionNames(f *parser.Frugal) []string {
+	names := []string{}
+	for _, include := range f.Includes {
+		names = append(names, "include "+include.Value)
+	}
+	for _, constant := range f.Constants {
+		names = append(names, "const "+constant.Name)
+	}
+	for _, typedef := range f.Typedefs {
+		names = append(names, "typedef "+typedef.Name)
+	}
+	for _, enum := range f.Enums {
+		names = append(names, "enum "+enum.Name)
+	}
+	for _, s := range f.DataStructures() {
+		for _, field := range s.Fields {
+			names = append(names, s.Name+"."+field.Name)
+		}
+	}
+	for _, service := range f.Services {
+		for _, method := range service.Methods {
+			names = append(names, service.Name+"."+method.Name)
+		}
+	}
+	for _, scope := range f.Scopes {
+		for _, op := range scope.Operations {
+			names = append(names, scope.Name+"."+op.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
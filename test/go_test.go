@@ -14,7 +14,12 @@
 package test
 
 import (
+	"io/ioutil"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"testing"
 
 	"github.com/Workiva/frugal/compiler"
@@ -143,3 +148,364 @@ func TestIncludeOrdering(t *testing.T) {
 	copyAllFiles(t, files)
 	compareAllFiles(t, files)
 }
+
+// Ensures streaming service methods generate senders, streams, and a
+// streaming processor.
+func TestValidGoStreaming(t *testing.T) {
+	options := compiler.Options{
+		File:  streamingFile,
+		Gen:   "go:package_prefix=github.com/Workiva/frugal/test/out/",
+		Out:   outputDir,
+		Delim: delim,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/go/streaming/f_types.txt", filepath.Join(outputDir, "streaming", "f_types.go")},
+		{"expected/go/streaming/f_store_service.txt", filepath.Join(outputDir, "streaming", "f_store_service.go")},
+	}
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
+
+// Ensures Equals, DeepCopy, and field masks are generated for structs with
+// the struct_helpers option.
+func TestValidGoStructHelpers(t *testing.T) {
+	options := compiler.Options{
+		File:  "idl/struct_helpers.frugal",
+		Gen:   "go:struct_helpers",
+		Out:   outputDir,
+		Delim: delim,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/go/struct_helpers/f_types.txt", filepath.Join(outputDir, "helpers", "f_types.go")},
+	}
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
+
+func TestValidGoFastCodec(t *testing.T) {
+	options := compiler.Options{
+		File:  "idl/fast_codec.frugal",
+		Gen:   "go:fast_codec",
+		Out:   outputDir,
+		Delim: delim,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/go/fast_codec/f_types.txt", filepath.Join(outputDir, "fastcodec", "f_types.go")},
+	}
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
+
+func TestValidGoValidate(t *testing.T) {
+	options := compiler.Options{
+		File:  "idl/validate.frugal",
+		Gen:   "go:validate",
+		Out:   outputDir,
+		Delim: delim,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/go/validate/f_types.txt", filepath.Join(outputDir, "validate", "f_types.go")},
+		{"expected/go/validate/f_catalog_service.txt", filepath.Join(outputDir, "validate", "f_catalog_service.go")},
+	}
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
+
+func TestValidGoMocks(t *testing.T) {
+	options := compiler.Options{
+		File:  "idl/mocks.frugal",
+		Gen:   "go:mocks",
+		Out:   outputDir,
+		Delim: delim,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/go/mocks/f_base_service.txt", filepath.Join(outputDir, "mocks", "f_base_service.go")},
+		{"expected/go/mocks/f_store_service.txt", filepath.Join(outputDir, "mocks", "f_store_service.go")},
+		{"expected/go/mocks/f_albumwinners_scope.txt", filepath.Join(outputDir, "mocks", "f_albumwinners_scope.go")},
+		{"expected/go/mocks/f_heartbeats_scope.txt", filepath.Join(outputDir, "mocks", "f_heartbeats_scope.go")},
+		{"expected/go/mocks/f_base_service_mock.txt", filepath.Join(outputDir, "mocks", "f_base_service_mock.go")},
+		{"expected/go/mocks/f_store_service_mock.txt", filepath.Join(outputDir, "mocks", "f_store_service_mock.go")},
+		{"expected/go/mocks/f_albumwinners_scope_mock.txt", filepath.Join(outputDir, "mocks", "f_albumwinners_scope_mock.go")},
+		{"expected/go/mocks/f_heartbeats_scope_mock.txt", filepath.Join(outputDir, "mocks", "f_heartbeats_scope_mock.go")},
+	}
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
+
+func TestValidGoMultiplex(t *testing.T) {
+	options := compiler.Options{
+		File:  "idl/multiplex.frugal",
+		Gen:   "go:multiplex",
+		Out:   outputDir,
+		Delim: delim,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/go/multiplex/f_base_service.txt", filepath.Join(outputDir, "multiplex", "f_base_service.go")},
+		{"expected/go/multiplex/f_store_service.txt", filepath.Join(outputDir, "multiplex", "f_store_service.go")},
+		{"expected/go/multiplex/f_catalog_service.txt", filepath.Join(outputDir, "multiplex", "f_catalog_service.go")},
+	}
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
+
+func TestValidGoReflection(t *testing.T) {
+	options := compiler.Options{
+		File:    "idl/reflection.frugal",
+		Gen:     "go:reflection",
+		Out:     outputDir,
+		Delim:   delim,
+		Recurse: true,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/go/reflection/f_store_service.txt", filepath.Join(outputDir, "reflection", "f_store_service.go")},
+		{"expected/go/reflection_base/f_base_service.txt", filepath.Join(outputDir, "reflection_base", "f_base_service.go")},
+	}
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
+
+func TestValidGoStdContext(t *testing.T) {
+	options := compiler.Options{
+		File:  "idl/std_context.frugal",
+		Gen:   "go:std_context,async",
+		Out:   outputDir,
+		Delim: delim,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/go/std_context/f_store_service.txt", filepath.Join(outputDir, "std_context", "f_store_service.go")},
+		{"expected/go/std_context/f_albumwinners_scope.txt", filepath.Join(outputDir, "std_context", "f_albumwinners_scope.go")},
+	}
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
+
+// serviceDescriptorConst matches the constant holding the JSON encoded
+// FServiceDescriptor of a service.
+var serviceDescriptorConst = regexp.MustCompile(`(?m)^const \w+ServiceDescriptor = (".*")$`)
+
+// readServiceDescriptor returns the JSON encoded FServiceDescriptor declared
+// in the Go file.
+func readServiceDescriptor(t *testing.T, file string) string {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	match := serviceDescriptorConst.FindSubmatch(contents)
+	if match == nil {
+		t.Fatalf("No service descriptor in %s", file)
+	}
+	descriptor, err := strconv.Unquote(string(match[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return descriptor
+}
+
+// Ensures the health service of the Go library, which reads and writes checks
+// with its service descriptor, is the one generated for health.frugal, so it
+// stays compatible with clients generated from the IDL.
+func TestGoHealthServiceDescriptor(t *testing.T) {
+	out := filepath.Join(outputDir, "health")
+	options := compiler.Options{
+		File:  "../lib/go/health.frugal",
+		Gen:   "go:reflection",
+		Out:   out,
+		Delim: delim,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	generated := readServiceDescriptor(t, filepath.Join(out, "health", "f_health_service.go"))
+	library := readServiceDescriptor(t, "../lib/go/health.go")
+	if generated != library {
+		t.Fatalf("Health service descriptor of lib/go/health.go doesn't match health.frugal:\n%s\n%s", library, generated)
+	}
+}
+
+// stdContextDeadlineTest is run against the code generated for
+// idl/std_context.frugal by TestGoStdContextHandlerDeadline.
+const stdContextDeadlineTest = `package std_context
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/Workiva/frugal/lib/go"
+)
+
+type deadlineHandler struct {
+	deadline time.Time
+	ok       bool
+	ctx      context.Context
+}
+
+func (h *deadlineHandler) GetAlbum(ctx context.Context, asin string) (*Album, error) {
+	h.deadline, h.ok = ctx.Deadline()
+	h.ctx = ctx
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return &Album{ASIN: asin}, nil
+}
+
+func (h *deadlineHandler) Track(ctx context.Context, event string) error {
+	return nil
+}
+
+func (h *deadlineHandler) ListAlbums(ctx context.Context, artist string, sender FStoreListAlbumsSender) error {
+	return nil
+}
+
+func TestHandlerDeadline(t *testing.T) {
+	protocolFactory := frugal.NewFProtocolFactory(thrift.NewTBinaryProtocolFactoryDefault())
+	iprot := protocolFactory.GetProtocol(thrift.NewTMemoryBuffer())
+	fctx := frugal.NewFContext("cid")
+	fctx.SetTimeout(time.Minute)
+	iprot.WriteRequestHeader(fctx)
+	iprot.WriteMessageBegin("getAlbum", thrift.CALL, 0)
+	args := StoreGetAlbumArgs{ASIN: "asin"}
+	args.Write(iprot)
+	iprot.WriteMessageEnd()
+
+	handler := &deadlineHandler{}
+	start := time.Now()
+	if err := NewFStoreProcessor(handler).Process(iprot, protocolFactory.GetProtocol(thrift.NewTMemoryBuffer())); err != nil {
+		t.Fatal(err)
+	}
+	if !handler.ok {
+		t.Fatal("handler context has no deadline")
+	}
+	if handler.deadline.Before(start.Add(time.Minute)) || handler.deadline.After(time.Now().Add(time.Minute)) {
+		t.Fatalf("deadline %v isn't the timeout of the request", handler.deadline)
+	}
+	if handler.ctx.Err() != context.Canceled {
+		t.Fatalf("handler context isn't canceled once the handler returned: %v", handler.ctx.Err())
+	}
+	if cid := frugal.CorrelationID(handler.ctx); cid != "cid" {
+		t.Fatalf("handler context carries correlation id %q", cid)
+	}
+}
+`
+
+// Ensures handlers generated with the std_context option receive a
+// context.Context with the timeout of the request as its deadline, which is
+// canceled once they return. The generated code is tested with the go tool,
+// so this is skipped if the frugal library can't be built.
+func TestGoStdContextHandlerDeadline(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go isn't installed")
+	}
+	if out, err := exec.Command("go", "build", "github.com/Workiva/frugal/lib/go").CombinedOutput(); err != nil {
+		t.Skipf("the frugal library can't be built: %s", out)
+	}
+
+	out := filepath.Join(outputDir, "deadline")
+	options := compiler.Options{
+		File:  "idl/std_context.frugal",
+		Gen:   "go:std_context",
+		Out:   out,
+		Delim: delim,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	dir := filepath.Join(out, "std_context")
+	if err := ioutil.WriteFile(filepath.Join(dir, "deadline_test.go"), []byte(stdContextDeadlineTest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if output, err := exec.Command("go", "test", "./"+dir).CombinedOutput(); err != nil {
+		t.Fatalf("Generated handler test failed: %v\n%s", err, output)
+	}
+}
+
+// Ensures import paths are resolved from the go.mod of the output directory and
+// the package map, which places packages of the module in its directory.
+func TestValidGoModules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "modules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte("module github.com/acme/music\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	options := compiler.Options{
+		File:    "idl/modules/music.frugal",
+		Gen:     "go:module,package_map=idl/modules/packages.yaml,doc_go",
+		Out:     filepath.Join(dir, "gen"),
+		Delim:   delim,
+		Recurse: true,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/go/modules/music/f_types.txt", filepath.Join(dir, "gen", "music", "v1", "f_types.go")},
+		{"expected/go/modules/music/doc.txt", filepath.Join(dir, "gen", "music", "v1", "doc.go")},
+		{"expected/go/modules/catalog/f_types.txt", filepath.Join(dir, "gen", "catalog", "f_types.go")},
+		{"expected/go/modules/base/doc.txt", filepath.Join(dir, "internal", "basepb", "doc.go")},
+	}
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
+
+// Ensures a go.mod is generated for each package which requires and replaces
+// the packages of its includes.
+func TestValidGoGoMod(t *testing.T) {
+	options := compiler.Options{
+		File:    "idl/modules/music.frugal",
+		Gen:     "go:go_mod,package_prefix=github.com/acme/gen",
+		Out:     outputDir + "/gomod",
+		Delim:   delim,
+		Recurse: true,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/go/modules/music/go.mod.txt", filepath.Join(outputDir, "gomod", "music", "v1", "go.mod")},
+		{"expected/go/modules/catalog/go.mod.txt", filepath.Join(outputDir, "gomod", "catalog", "go.mod")},
+		{"expected/go/modules/base/go.mod.txt", filepath.Join(outputDir, "gomod", "base", "go.mod")},
+	}
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
@@ -0,0 +1,163 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package integration
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"git.apache.org/thrift.git/lib/go/thrift"
+	"github.com/Workiva/frugal/lib/go"
+	"github.com/Workiva/frugal/test/integration/go/common"
+	"github.com/Workiva/frugal/test/integration/go/gen/frugaltest"
+)
+
+// These benchmarks compare the codec generated with the fast_codec option to
+// the generic Read and Write, which the GenericCodec benchmarks force by
+// hiding the protocol from it. Without fast_codec, both use the generic
+// codec.
+
+// genericProtocol hides the protocol it wraps from the fast codec.
+type genericProtocol struct {
+	thrift.TProtocol
+}
+
+type genericProtocolFactory struct {
+	thrift.TProtocolFactory
+}
+
+func (g genericProtocolFactory) GetProtocol(tr thrift.TTransport) thrift.TProtocol {
+	return genericProtocol{g.TProtocolFactory.GetProtocol(tr)}
+}
+
+func BenchmarkBinaryInsanity(b *testing.B) {
+	benchmarkInsanity(b, thrift.NewTBinaryProtocolFactoryDefault())
+}
+
+func BenchmarkBinaryInsanityGenericCodec(b *testing.B) {
+	benchmarkInsanity(b, genericProtocolFactory{thrift.NewTBinaryProtocolFactoryDefault()})
+}
+
+func BenchmarkCompactInsanity(b *testing.B) {
+	benchmarkInsanity(b, thrift.NewTCompactProtocolFactory())
+}
+
+func BenchmarkCompactInsanityGenericCodec(b *testing.B) {
+	benchmarkInsanity(b, genericProtocolFactory{thrift.NewTCompactProtocolFactory()})
+}
+
+func BenchmarkBinaryWrite(b *testing.B) {
+	benchmarkWrite(b, thrift.NewTBinaryProtocolFactoryDefault())
+}
+
+func BenchmarkBinaryWriteGenericCodec(b *testing.B) {
+	benchmarkWrite(b, genericProtocolFactory{thrift.NewTBinaryProtocolFactoryDefault()})
+}
+
+func BenchmarkCompactWrite(b *testing.B) {
+	benchmarkWrite(b, thrift.NewTCompactProtocolFactory())
+}
+
+func BenchmarkCompactWriteGenericCodec(b *testing.B) {
+	benchmarkWrite(b, genericProtocolFactory{thrift.NewTCompactProtocolFactory()})
+}
+
+func BenchmarkBinaryRead(b *testing.B) {
+	benchmarkRead(b, thrift.NewTBinaryProtocolFactoryDefault())
+}
+
+func BenchmarkBinaryReadGenericCodec(b *testing.B) {
+	benchmarkRead(b, genericProtocolFactory{thrift.NewTBinaryProtocolFactoryDefault()})
+}
+
+func BenchmarkCompactRead(b *testing.B) {
+	benchmarkRead(b, thrift.NewTCompactProtocolFactory())
+}
+
+func BenchmarkCompactReadGenericCodec(b *testing.B) {
+	benchmarkRead(b, genericProtocolFactory{thrift.NewTCompactProtocolFactory()})
+}
+
+func benchmarkInsanity(b *testing.B, protoFactory thrift.TProtocolFactory) {
+	fProtoFactory := frugal.NewFProtocolFactory(protoFactory)
+	processor := frugaltest.NewFFrugalTestProcessor(common.PrintingHandler)
+	server := httptest.NewServer(frugal.NewFrugalHandlerFunc(processor, fProtoFactory))
+	defer server.Close()
+	transport := frugal.NewFHTTPTransportBuilder(&http.Client{}, server.URL).Build()
+	client := frugaltest.NewFFrugalTestClient(frugal.NewFServiceProvider(transport, fProtoFactory))
+	ctx := frugal.NewFContext("")
+	insanity := newInsanity()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.TestInsanity(ctx, insanity); err != nil {
+			b.Fatal("Unexpected TestInsanity error:", err)
+		}
+	}
+}
+
+func benchmarkWrite(b *testing.B, protoFactory thrift.TProtocolFactory) {
+	insanity := newInsanity()
+	buffer := thrift.NewTMemoryBuffer()
+	oprot := protoFactory.GetProtocol(buffer)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buffer.Reset()
+		if err := insanity.Write(oprot); err != nil {
+			b.Fatal("Unexpected Write error:", err)
+		}
+	}
+}
+
+func benchmarkRead(b *testing.B, protoFactory thrift.TProtocolFactory) {
+	buffer := thrift.NewTMemoryBuffer()
+	if err := newInsanity().Write(protoFactory.GetProtocol(buffer)); err != nil {
+		b.Fatal("Unexpected Write error:", err)
+	}
+	data := buffer.Bytes()
+	input := &thrift.TMemoryBuffer{Buffer: new(bytes.Buffer)}
+	iprot := protoFactory.GetProtocol(input)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		input.Reset()
+		input.Write(data)
+		if err := frugaltest.NewInsanity().Read(iprot); err != nil {
+			b.Fatal("Unexpected Read error:", err)
+		}
+	}
+}
+
+func newInsanity() *frugaltest.Insanity {
+	insanity := frugaltest.NewInsanity()
+	insanity.UserMap = map[frugaltest.Numberz]frugaltest.UserId{
+		frugaltest.Numberz_FIVE:  5,
+		frugaltest.Numberz_EIGHT: 8,
+	}
+	for i := 0; i < 10; i++ {
+		insanity.Xtructs = append(insanity.Xtructs, &frugaltest.Xtruct{
+			StringThing: "Goodbye4",
+			ByteThing:   4,
+			I32Thing:    int32(i),
+			I64Thing:    int64(i) << 40,
+		})
+	}
+	return insanity
+}
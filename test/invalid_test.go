@@ -14,9 +14,15 @@
 package test
 
 import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/Workiva/frugal/compiler"
+	"github.com/Workiva/frugal/compiler/parser"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestInvalid(t *testing.T) {
@@ -115,3 +121,205 @@ func TestWildcardNamespaceWithVendorAnnotation(t *testing.T) {
 		t.Fatal("Expected error")
 	}
 }
+
+// Ensures an error is returned when generated Go packages would import each
+// other.
+func TestGoImportCycle(t *testing.T) {
+	options := compiler.Options{
+		File:  "idl/modules/cycle_a.frugal",
+		Gen:   "go",
+		Out:   outputDir,
+		Delim: delim,
+	}
+	err := compiler.Compile(options)
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	assert.Equal(t, "Import cycle between generated Go packages: cycles/a (cycle_a.frugal) "+
+		"imports cycles/b (cycle_b.frugal) imports cycles/a (cycle_c.frugal)", err.Error())
+}
+
+// Ensures an error is returned when the module option is set but the output
+// directory isn't in a module.
+func TestGoModuleWithoutGoMod(t *testing.T) {
+	dir, err := ioutil.TempDir("", "modules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	options := compiler.Options{
+		File:  "idl/modules/catalog.frugal",
+		Gen:   "go:module",
+		Out:   dir,
+		Delim: delim,
+	}
+	if err := compiler.Compile(options); err == nil {
+		t.Fatal("Expected error")
+	}
+}
+
+// Ensures an error is returned when a oneway method streams a response.
+func TestOnewayStream(t *testing.T) {
+	options := compiler.Options{
+		File:  onewayStream,
+		Gen:   "go",
+		Out:   outputDir,
+		Delim: delim,
+	}
+	if compiler.Compile(options) == nil {
+		t.Fatal("Expected error")
+	}
+}
+
+// Ensures an error is returned when a oneway method streams requests.
+func TestOnewayRequestStream(t *testing.T) {
+	options := compiler.Options{
+		File:  onewayRequestStream,
+		Gen:   "go",
+		Out:   outputDir,
+		Delim: delim,
+	}
+	if compiler.Compile(options) == nil {
+		t.Fatal("Expected error")
+	}
+}
+
+// Ensures an error is returned when generating streaming methods for a
+// language which doesn't support them.
+func TestStreamingUnsupportedLanguage(t *testing.T) {
+	options := compiler.Options{
+		File:  streamingFile,
+		Gen:   "java",
+		Out:   outputDir,
+		Delim: delim,
+	}
+	if compiler.Compile(options) == nil {
+		t.Fatal("Expected error")
+	}
+}
+
+// Ensures every syntax error in a file is reported with its position.
+func TestSyntaxErrors(t *testing.T) {
+	options := compiler.Options{
+		File:  syntaxErrors,
+		Gen:   "go",
+		Out:   outputDir,
+		Delim: delim,
+	}
+	err := compiler.Compile(options)
+	diags, ok := err.(parser.Diagnostics)
+	if !ok {
+		t.Fatalf("Expected Diagnostics, got %v", err)
+	}
+	assertDiagnostics(t, diags, []expectedDiagnostic{
+		{5, 1, "parser: expected end of struct"},
+		{9, 1, "parser: syntax error"},
+		{13, 1, "parser: expected end of service"},
+	})
+}
+
+// Ensures every validation error in a file is reported with its position.
+func TestValidationErrors(t *testing.T) {
+	options := compiler.Options{
+		File:  validationErrors,
+		Gen:   "go",
+		Out:   outputDir,
+		Delim: delim,
+	}
+	err := compiler.Compile(options)
+	diags, ok := err.(parser.Diagnostics)
+	if !ok {
+		t.Fatalf("Expected Diagnostics, got %v", err)
+	}
+	assertDiagnostics(t, diags, []expectedDiagnostic{
+		{3, 5, "Duplicate field id 1 in struct Foo"},
+		{3, 8, "Invalid type Missing on struct Foo"},
+		{8, 5, "Duplicate method name ping"},
+		{9, 12, "Void method Baz.pong cannot return i32"},
+	})
+
+	var buf bytes.Buffer
+	assert.Nil(t, diags.Format(&buf))
+	assert.Contains(t, buf.String(), "validation_errors.frugal:3:8: error: Invalid type Missing on struct Foo\n"+
+		"\t    1: Missing b,\n"+
+		"\t       ^~~~~~~\n")
+
+	buf.Reset()
+	assert.Nil(t, compiler.PrintDiagnostics(&buf, diags, compiler.DiagnosticsJSON))
+	var decoded parser.Diagnostics
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, diags, decoded)
+}
+
+// Ensures validate annotations are checked for known rules, valid values, and
+// types they apply to, and are rejected on optional arguments.
+func TestConstraintErrors(t *testing.T) {
+	_, err := parser.ParseFrugal(constraintErrors)
+	diags, ok := err.(parser.Diagnostics)
+	if !ok {
+		t.Fatalf("Expected Diagnostics, got %v", err)
+	}
+	assertDiagnostics(t, diags, []expectedDiagnostic{
+		{6, 22, "validate.min does not apply to field Release.title of type string"},
+		{6, 42, "Unknown validation rule validate.size on field Release.title"},
+		{7, 20, "validate.pattern does not apply to field Release.tracks of type i32"},
+		{7, 46, "Invalid validate.min value \"x\" for i32 field Release.tracks"},
+		{7, 66, "Invalid validate.max value \"3000000000\" for i32 field Release.tracks"},
+		{8, 21, "Invalid validate.pattern on field Release.slug: error parsing regexp: missing closing ): `(`"},
+		{8, 45, "Invalid validate.min_len value \"-1\" on field Release.slug, expected a non-negative integer"},
+		{9, 31, "validate.min is greater than validate.max on field Release.discount"},
+		{10, 27, "validate.min_len is greater than validate.max_len on field Release.tags"},
+		{11, 24, "validate.required_if only applies to optional fields, Release.catalog is not optional"},
+		{12, 31, "validate.required_if on field Release.label refers to unknown field \"missing\""},
+		{14, 27, "validate.required_if on field Release.disc compares field kind of type Kind to invalid value \"SINGLE\""},
+		{15, 28, "validate.required_if on field Release.side can't compare the value of field tags of type list<string>"},
+		{16, 50, "Duplicate validation rule validate.min on field Release.volume"},
+		{20, 30, "validate.max does not apply to field Catalog.add.name of type string"},
+		{21, 37, "validate.min does not apply to optional argument Catalog.remove.id, make it default or required"},
+		{21, 85, "validate.required_if does not apply to optional argument Catalog.remove.reason, make it default or required"},
+	})
+}
+
+// Ensures warnings don't prevent a file from being parsed.
+func TestEnumValueWarning(t *testing.T) {
+	frugal, err := parser.ParseFrugal(enumValueWarning)
+	assert.Nil(t, err)
+	assertDiagnostics(t, frugal.Warnings, []expectedDiagnostic{
+		{3, 5, "Enum value Color.GREEN has the same value 1 as RED"},
+	})
+	assert.Equal(t, parser.SeverityWarning, frugal.Warnings[0].Severity)
+	assert.Equal(t, enumValueWarning, frugal.Warnings[0].Pos.File)
+}
+
+type expectedDiagnostic struct {
+	line, column int
+	message      string
+}
+
+func assertDiagnostics(t *testing.T, diags parser.Diagnostics, expected []expectedDiagnostic) {
+	if !assert.Len(t, diags, len(expected)) {
+		t.Fatal(diags)
+	}
+	for i, diag := range diags {
+		assert.Equal(t, expected[i].line, diag.Pos.Line)
+		assert.Equal(t, expected[i].column, diag.Pos.Column)
+		assert.Equal(t, expected[i].message, diag.Message)
+	}
+}
+
+// Ensures an error is returned when a service declares a method with the name
+// of the method generated by the Go reflection option.
+func TestGoReflectionMethodConflict(t *testing.T) {
+	options := compiler.Options{
+		File:  "idl/reflection_conflict.frugal",
+		Gen:   "go:reflection",
+		Out:   outputDir,
+		Delim: delim,
+	}
+	err := compiler.Compile(options)
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	assert.Equal(t, "Method reflection of service Debug conflicts with the method generated by the reflection option", err.Error())
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Workiva/frugal/compiler"
+)
+
+func TestJSON(t *testing.T) {
+	options := compiler.Options{
+		File:  "idl/model/model.frugal",
+		Gen:   "json",
+		Out:   outputDir,
+		Delim: delim,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/json/model.json", filepath.Join(outputDir, "model.json")},
+	}
+
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
+
+func TestJSONYAML(t *testing.T) {
+	options := compiler.Options{
+		File:  "idl/model/model.frugal",
+		Gen:   "json:yaml",
+		Out:   outputDir,
+		Delim: delim,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/json/model.yaml", filepath.Join(outputDir, "model.yaml")},
+	}
+
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
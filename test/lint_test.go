@@ -0,0 +1,73 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Workiva/frugal/compiler/lint"
+	"github.com/Workiva/frugal/compiler/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	lintFile   = "idl/lint.frugal"
+	lintConfig = "idl/lint.yml"
+)
+
+func lintProblems(t *testing.T, config *lint.Config) []string {
+	frugal, err := parser.ParseFrugal(lintFile)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	problems := []string{}
+	for _, diag := range lint.Lint(frugal, config) {
+		problems = append(problems, fmt.Sprintf("%d %s %s: %s", diag.Pos.Line, diag.Severity, diag.Rule, diag.Message))
+	}
+	return problems
+}
+
+func TestLintDefaults(t *testing.T) {
+	assert.Equal(t, []string{
+		"4 warning field-modifier: Field NotFound.message should be optional or required",
+		"9 warning enum-value: Enum value Status.INACTIVE should be numbered explicitly, it's 2",
+		"14 warning field-modifier: Field User.displayName should be optional or required",
+		"14 warning field-snake-case: Field displayName of User should be snake_case",
+		"16 warning field-modifier: Field User.legacyName should be optional or required",
+		"22 warning field-snake-case: Argument userId of Users.get_user should be snake_case",
+		"24 warning service-doc: Method Users.delete_user has no doc comment",
+		"31 warning scope-prefix: Scope Events has no prefix",
+	}, lintProblems(t, nil))
+}
+
+func TestLintConfig(t *testing.T) {
+	config, err := lint.LoadConfig(lintConfig)
+	if err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+	assert.Equal(t, []string{
+		"9 warning enum-value: Enum value Status.INACTIVE should be numbered explicitly, it's 2",
+		"14 warning field-snake-case: Field displayName of User should be snake_case",
+		"22 warning field-snake-case: Argument userId of Users.get_user should be snake_case",
+		"24 warning method-exceptions: Method Users.delete_user declares no exceptions",
+		"24 error service-doc: Method Users.delete_user has no doc comment",
+		"31 warning scope-prefix: Scope Events has no prefix",
+	}, lintProblems(t, config))
+}
+
+func TestLintInvalidConfig(t *testing.T) {
+	assert.Error(t, (&lint.Config{Rules: map[string]string{"no-such-rule": "error"}}).Validate())
+	assert.Error(t, (&lint.Config{Rules: map[string]string{"service-doc": "fatal"}}).Validate())
+}
@@ -0,0 +1,319 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Workiva/frugal/compiler/lsp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	lspDir     = "idl/lsp"
+	lspDrawing = "idl/lsp/drawing.frugal"
+	lspShapes  = "idl/lsp/shapes.frugal"
+)
+
+type lspMessage struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type publishedDiagnostics struct {
+	URI         string           `json:"uri"`
+	Diagnostics []lsp.Diagnostic `json:"diagnostics"`
+}
+
+// lspClient drives a language server over pipes.
+type lspClient struct {
+	t        *testing.T
+	in       *io.PipeWriter
+	messages chan *lspMessage
+	done     chan error
+	id       int
+}
+
+func newLSPClient(t *testing.T) *lspClient {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	c := &lspClient{
+		t:        t,
+		in:       clientOut,
+		messages: make(chan *lspMessage, 100),
+		done:     make(chan error, 1),
+	}
+	go func() {
+		err := lsp.NewServer(serverIn, serverOut).Serve()
+		serverOut.Close()
+		c.done <- err
+	}()
+	go func() {
+		r := bufio.NewReader(clientIn)
+		for {
+			header, err := textproto.NewReader(r).ReadMIMEHeader()
+			if err != nil {
+				close(c.messages)
+				return
+			}
+			length, _ := strconv.Atoi(header.Get("Content-Length"))
+			content := make([]byte, length)
+			if _, err := io.ReadFull(r, content); err != nil {
+				close(c.messages)
+				return
+			}
+			msg := &lspMessage{}
+			if err := json.Unmarshal(content, msg); err != nil {
+				t.Errorf("invalid message %s: %s", content, err)
+			}
+			c.messages <- msg
+		}
+	}()
+	return c
+}
+
+func (c *lspClient) write(msg map[string]interface{}) {
+	msg["jsonrpc"] = "2.0"
+	content, err := json.Marshal(msg)
+	require.NoError(c.t, err)
+	_, err = fmt.Fprintf(c.in, "Content-Length: %d\r\n\r\n%s", len(content), content)
+	require.NoError(c.t, err)
+}
+
+func (c *lspClient) next() *lspMessage {
+	select {
+	case msg, ok := <-c.messages:
+		require.True(c.t, ok, "server closed the connection")
+		return msg
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for server")
+		return nil
+	}
+}
+
+// call sends a request and unmarshals the result into result, skipping any
+// notifications sent before the response.
+func (c *lspClient) call(method string, params, result interface{}) {
+	c.id++
+	c.write(map[string]interface{}{"id": c.id, "method": method, "params": params})
+	for {
+		msg := c.next()
+		if msg.ID == nil || *msg.ID != c.id {
+			continue
+		}
+		require.Nil(c.t, msg.Error, "%s failed", method)
+		if result != nil {
+			require.NoError(c.t, json.Unmarshal(msg.Result, result))
+		}
+		return
+	}
+}
+
+func (c *lspClient) notify(method string, params interface{}) {
+	c.write(map[string]interface{}{"method": method, "params": params})
+}
+
+// diagnostics returns the next diagnostics published for each of the given
+// URIs.
+func (c *lspClient) diagnostics(uris ...string) map[string][]lsp.Diagnostic {
+	published := make(map[string][]lsp.Diagnostic)
+	for len(published) < len(uris) {
+		msg := c.next()
+		if msg.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		params := publishedDiagnostics{}
+		require.NoError(c.t, json.Unmarshal(msg.Params, &params))
+		for _, uri := range uris {
+			if params.URI == uri {
+				published[uri] = params.Diagnostics
+			}
+		}
+	}
+	return published
+}
+
+func (c *lspClient) close() {
+	c.call("shutdown", nil, nil)
+	c.notify("exit", nil)
+	select {
+	case err := <-c.done:
+		assert.NoError(c.t, err)
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for server to exit")
+	}
+}
+
+func lspURI(t *testing.T, path string) string {
+	abs, err := filepath.Abs(path)
+	require.NoError(t, err)
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+}
+
+func lspPosition(uri string, line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri},
+		"position":     lsp.Position{Line: line, Character: character},
+	}
+}
+
+// openLSP starts a server rooted at the LSP fixtures and opens drawing.frugal.
+func openLSP(t *testing.T) (*lspClient, string, string) {
+	drawing, shapes := lspURI(t, lspDrawing), lspURI(t, lspShapes)
+	text, err := ioutil.ReadFile(lspDrawing)
+	require.NoError(t, err)
+
+	c := newLSPClient(t)
+	c.call("initialize", map[string]interface{}{"rootUri": lspURI(t, lspDir)}, nil)
+	c.notify("initialized", map[string]interface{}{})
+	c.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": drawing, "languageId": "frugal", "version": 1, "text": string(text)},
+	})
+	assert.Empty(t, c.diagnostics(drawing)[drawing])
+	return c, drawing, shapes
+}
+
+func TestLSPDiagnostics(t *testing.T) {
+	c, drawing, _ := openLSP(t)
+	defer c.close()
+
+	text, err := ioutil.ReadFile(lspDrawing)
+	require.NoError(t, err)
+	broken := strings.Replace(string(text), "shapes.Point end", "shapes.Square end", 1)
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": drawing, "version": 2},
+		"contentChanges": []map[string]interface{}{{"text": broken}},
+	})
+	diags := c.diagnostics(drawing)[drawing]
+	require.Len(t, diags, 1)
+	assert.Equal(t, lsp.SeverityError, diags[0].Severity)
+	assert.Contains(t, diags[0].Message, "shapes.Square")
+	assert.Equal(t, 6, diags[0].Range.Start.Line)
+
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": drawing, "version": 3},
+		"contentChanges": []map[string]interface{}{{"text": string(text)}},
+	})
+	assert.Empty(t, c.diagnostics(drawing)[drawing])
+}
+
+func TestLSPDefinition(t *testing.T) {
+	c, drawing, shapes := openLSP(t)
+	defer c.close()
+
+	// shapes.Point in Line.start
+	loc := lsp.Location{}
+	c.call("textDocument/definition", lspPosition(drawing, 5, 16), &loc)
+	assert.Equal(t, shapes, loc.URI)
+	assert.Equal(t, 3, loc.Range.Start.Line)
+
+	// shapes.Color.RED
+	c.call("textDocument/definition", lspPosition(drawing, 7, 42), &loc)
+	assert.Equal(t, shapes, loc.URI)
+	assert.Equal(t, 9, loc.Range.Start.Line)
+
+	// Line in the Canvas scope
+	c.call("textDocument/definition", lspPosition(drawing, 11, 12), &loc)
+	assert.Equal(t, drawing, loc.URI)
+	assert.Equal(t, 4, loc.Range.Start.Line)
+}
+
+func TestLSPHover(t *testing.T) {
+	c, drawing, _ := openLSP(t)
+	defer c.close()
+
+	hover := lsp.Hover{}
+	c.call("textDocument/hover", lspPosition(drawing, 5, 16), &hover)
+	assert.Equal(t, "markdown", hover.Contents.Kind)
+	assert.Contains(t, hover.Contents.Value, "struct Point")
+	assert.Contains(t, hover.Contents.Value, "A point on a plane.")
+	require.NotNil(t, hover.Range)
+	assert.Equal(t, lsp.Range{Start: lsp.Position{Line: 5, Character: 7}, End: lsp.Position{Line: 5, Character: 19}}, *hover.Range)
+}
+
+func TestLSPReferences(t *testing.T) {
+	c, drawing, shapes := openLSP(t)
+	defer c.close()
+
+	params := lspPosition(drawing, 5, 16)
+	params["context"] = map[string]interface{}{"includeDeclaration": true}
+	locations := []lsp.Location{}
+	c.call("textDocument/references", params, &locations)
+	require.Len(t, locations, 4)
+	assert.Equal(t, shapes, locations[0].URI)
+	lines := []int{}
+	for _, loc := range locations[1:] {
+		assert.Equal(t, drawing, loc.URI)
+		lines = append(lines, loc.Range.Start.Line)
+	}
+	assert.Equal(t, []int{5, 6, 12}, lines)
+
+	// Enums are also referenced by their values.
+	params = lspPosition(drawing, 7, 16)
+	params["context"] = map[string]interface{}{"includeDeclaration": false}
+	c.call("textDocument/references", params, &locations)
+	assert.Len(t, locations, 2)
+}
+
+func TestLSPCompletion(t *testing.T) {
+	c, drawing, _ := openLSP(t)
+	defer c.close()
+
+	labels := func(items []lsp.CompletionItem) []string {
+		labels := []string{}
+		for _, item := range items {
+			labels = append(labels, item.Label)
+		}
+		return labels
+	}
+
+	// After "shapes." in Line.start
+	items := []lsp.CompletionItem{}
+	c.call("textDocument/completion", lspPosition(drawing, 5, 14), &items)
+	assert.Equal(t, []string{"Color", "Point"}, labels(items))
+
+	// At the start of a field type
+	c.call("textDocument/completion", lspPosition(drawing, 5, 7), &items)
+	assert.Contains(t, labels(items), "i32")
+	assert.Contains(t, labels(items), "Line")
+	assert.Contains(t, labels(items), "shapes")
+
+	text, err := ioutil.ReadFile(lspDrawing)
+	require.NoError(t, err)
+	annotated := strings.Replace(string(text), "shapes.Point end,", "shapes.Point end (,", 1)
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": drawing, "version": 2},
+		"contentChanges": []map[string]interface{}{{"text": annotated}},
+	})
+	c.call("textDocument/completion", lspPosition(drawing, 6, 25), &items)
+	assert.Equal(t, []string{"deprecated", "vendor"}, labels(items))
+}
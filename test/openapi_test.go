@@ -0,0 +1,87 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Workiva/frugal/compiler"
+)
+
+func TestOpenAPI(t *testing.T) {
+	options := compiler.Options{
+		File:    "idl/openapi/store.frugal",
+		Gen:     "openapi:go_gateway,package_prefix=github.com/Workiva/frugal/test/out/go",
+		Out:     filepath.Join(outputDir, "openapi"),
+		Delim:   delim,
+		Recurse: true,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/openapi/store.Store.openapi.json", filepath.Join(outputDir, "openapi", "store.Store.openapi.json")},
+		{"expected/openapi/store_base.BaseStore.openapi.json", filepath.Join(outputDir, "openapi", "store_base.BaseStore.openapi.json")},
+		{"expected/openapi/store_gateway.txt", filepath.Join(outputDir, "openapi", "storegateway", "store_gateway.go")},
+		{"expected/openapi/store_base_gateway.txt", filepath.Join(outputDir, "openapi", "store_basegateway", "store_base_gateway.go")},
+	}
+
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
+
+func TestOpenAPIYAML(t *testing.T) {
+	options := compiler.Options{
+		File:  "idl/openapi/store.frugal",
+		Gen:   "openapi:yaml",
+		Out:   filepath.Join(outputDir, "openapi"),
+		Delim: delim,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/openapi/store.Store.openapi.yaml", filepath.Join(outputDir, "openapi", "store.Store.openapi.yaml")},
+	}
+
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
+
+// Ensures routes which can't be served fail generation.
+func TestOpenAPIInvalidRoutes(t *testing.T) {
+	options := compiler.Options{
+		File:  "idl/openapi/invalid_routes.frugal",
+		Gen:   "openapi",
+		Out:   filepath.Join(outputDir, "openapi"),
+		Delim: delim,
+	}
+	err := compiler.Compile(options)
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	for _, expected := range []string{
+		"path /items/{key} of method Search.byID has variable key, which isn't an argument",
+		"argument query of method Search.find can't be passed in the query of a GET request",
+		`http.method of method Search.fetch must be GET, POST, PUT, PATCH, or DELETE, not "FETCH"`,
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error %q, got %q", expected, err)
+		}
+	}
+}
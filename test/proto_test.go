@@ -0,0 +1,63 @@
+/*
+ * Copyright 2017 Workiva
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Workiva/frugal/compiler"
+)
+
+func TestProto(t *testing.T) {
+	options := compiler.Options{
+		File:    "idl/proto/catalog.frugal",
+		Gen:     "proto:go_bridge,package_prefix=github.com/Workiva/frugal/test/out/proto,frugal_package_prefix=github.com/Workiva/frugal/test/out/go",
+		Out:     filepath.Join(outputDir, "proto"),
+		Delim:   delim,
+		Recurse: true,
+	}
+	if err := compiler.Compile(options); err != nil {
+		t.Fatal("Unexpected error", err)
+	}
+
+	files := []FileComparisonPair{
+		{"expected/proto/catalog.proto", filepath.Join(outputDir, "proto", "catalog.proto")},
+		{"expected/proto/catalog_base.proto", filepath.Join(outputDir, "proto", "catalog_base.proto")},
+		{"expected/proto/catalog_bridge.txt", filepath.Join(outputDir, "proto", "catalogbridge", "catalog_bridge.go")},
+		{"expected/proto/catalog_base_bridge.txt", filepath.Join(outputDir, "proto", "catalog_basebridge", "catalog_base_bridge.go")},
+	}
+
+	copyAllFiles(t, files)
+	compareAllFiles(t, files)
+}
+
+// Ensures constructs protobuf can't represent fail generation when strict is
+// set.
+func TestProtoStrict(t *testing.T) {
+	options := compiler.Options{
+		File:  "idl/proto/catalog.frugal",
+		Gen:   "proto:strict",
+		Out:   filepath.Join(outputDir, "proto_strict"),
+		Delim: delim,
+	}
+	err := compiler.Compile(options)
+	if err == nil {
+		t.Fatal("Expected error")
+	}
+	if !strings.Contains(err.Error(), "streaming method Catalog.watch") {
+		t.Fatalf("Unexpected error %q", err)
+	}
+}